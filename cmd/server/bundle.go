@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/app"
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// runExport implements the export subcommand, which writes a bundle of sources, teams and
+// collections to a file or stdout.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath := fs.String("config", "config.toml", "path to config file")
+	output := fs.String("output", "-", "file to write the bundle to, - for stdout")
+	formatName := fs.String("format", "", "bundle format: yaml or json (default: from the output file extension, else yaml)")
+	teams := fs.String("teams", "", "comma-separated IDs of the teams to export (default: all)")
+	_ = fs.Parse(args)
+
+	format, err := bundleFormat(*formatName, *output)
+	if err != nil {
+		return err
+	}
+	var teamIDs []models.TeamID
+	if *teams != "" {
+		for _, part := range strings.Split(*teams, ",") {
+			id, err := core.ParseTeamID(strings.TrimSpace(part))
+			if err != nil {
+				return fmt.Errorf("invalid team ID %q: %w", part, err)
+			}
+			teamIDs = append(teamIDs, id)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := app.ExportBundle(context.Background(), app.Options{ConfigPath: *configPath}, &buf, format, teamIDs); err != nil {
+		return err
+	}
+	if *output == "-" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*output, buf.Bytes(), 0o644)
+}
+
+// runImport implements the import subcommand, which imports a bundle from a file or stdin
+// and prints what was done with each object.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	configPath := fs.String("config", "config.toml", "path to config file")
+	file := fs.String("file", "-", "bundle file to import, - for stdin")
+	formatName := fs.String("format", "", "bundle format: yaml or json (default: from the file extension, else yaml)")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing anything")
+	conflict := fs.String("conflict", string(models.BundleConflictSkip), "what to do with existing objects: skip, overwrite or rename")
+	_ = fs.Parse(args)
+
+	format, err := bundleFormat(*formatName, *file)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	result, err := app.ImportBundle(context.Background(), app.Options{ConfigPath: *configPath}, data, format, models.BundleImportOptions{
+		DryRun:   *dryRun,
+		Conflict: models.BundleConflictStrategy(*conflict),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range result.Items {
+		line := fmt.Sprintf("%-8s %-11s %s", item.Action, item.Kind, item.Name)
+		if item.NewID != 0 {
+			line += fmt.Sprintf(" (id %d)", item.NewID)
+		}
+		if item.Message != "" {
+			line += ": " + item.Message
+		}
+		fmt.Println(line)
+	}
+	if result.DryRun {
+		fmt.Println("dry run, nothing was imported")
+	}
+	return nil
+}
+
+// bundleFormat returns the bundle format from the flag, or else from the file's extension.
+func bundleFormat(name, path string) (models.BundleFormat, error) {
+	if name == "" && path != "-" {
+		name = strings.TrimPrefix(filepath.Ext(path), ".")
+		if name != "json" && name != "yml" {
+			name = ""
+		}
+	}
+	return core.ParseBundleFormat(name)
+}
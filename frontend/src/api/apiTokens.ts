@@ -1,5 +1,27 @@
 import { apiClient } from "./apiUtils";
 
+// Scopes are "<area>:<action>"; "<area>:*" grants a whole area and "*" everything
+export type APITokenScope =
+  | "*"
+  | "logs:query"
+  | "collections:read"
+  | "collections:write"
+  | "teams:read"
+  | "teams:write"
+  | "shares:write"
+  | "tokens:write"
+  | "admin:users"
+  | "admin:teams"
+  | "admin:sources"
+  | "admin:audit"
+  | "admin:config"
+  | `${string}:*`;
+
+export interface APITokenResource {
+  team_id: number;
+  source_id: number;
+}
+
 export interface APIToken {
   id: number;
   user_id: number;
@@ -7,6 +29,10 @@ export interface APIToken {
   prefix: string;
   last_used_at?: string;
   expires_at?: string;
+  disabled_at?: string; // Set when the user was deactivated
+  scopes: APITokenScope[];
+  resources: APITokenResource[]; // Empty means all of the user's teams and sources
+  allowed_cidrs: string[]; // Empty means any client address
   created_at: string;
   updated_at: string;
 }
@@ -14,6 +40,9 @@ export interface APIToken {
 export interface CreateAPITokenRequest {
   name: string;
   expires_at?: string;
+  scopes?: APITokenScope[]; // Defaults to ["*"]
+  resources?: APITokenResource[];
+  allowed_cidrs?: string[];
 }
 
 export interface CreateAPITokenResponse {
@@ -0,0 +1,51 @@
+import { api } from "./config";
+import type { APIResponse } from "./types";
+
+export type BundleFormat = "yaml" | "json";
+export type BundleConflictStrategy = "skip" | "overwrite" | "rename";
+export type BundleImportAction = "create" | "update" | "skip" | "rename";
+
+export interface BundleImportItem {
+  kind: "source" | "team" | "team_source" | "collection";
+  name: string;
+  action: BundleImportAction;
+  old_id?: number;
+  new_id?: number; // Unset for objects a dry run would create
+  message?: string;
+}
+
+export interface BundleImportResult {
+  dry_run: boolean;
+  conflict: BundleConflictStrategy;
+  items: BundleImportItem[];
+  source_ids: Record<string, number>;
+  team_ids: Record<string, number>;
+  collection_ids: Record<string, number>;
+  created_sources?: number[]; // Created without credentials, which must be set before use
+}
+
+export interface BundleImportOptions {
+  format?: BundleFormat; // Defaults to yaml
+  dry_run?: boolean;
+  conflict?: BundleConflictStrategy; // Defaults to skip
+}
+
+export const bundlesApi = {
+  // Returns the bundle file; team_ids limits the export to those teams and their sources.
+  exportBundle: async (format: BundleFormat = "yaml", teamIds?: number[]) => {
+    const response = await api.get<Blob>(`/admin/bundle/export`, {
+      params: { format, team_ids: teamIds?.length ? teamIds.join(",") : undefined },
+      responseType: "blob",
+    });
+    return response.data;
+  },
+
+  importBundle: async (bundle: string, options: BundleImportOptions = {}) => {
+    const format = options.format ?? "yaml";
+    const response = await api.post<APIResponse<BundleImportResult>>(`/admin/bundle/import`, bundle, {
+      params: { format, dry_run: options.dry_run, conflict: options.conflict },
+      headers: { "Content-Type": format === "json" ? "application/json" : "application/yaml" },
+    });
+    return response.data;
+  },
+};
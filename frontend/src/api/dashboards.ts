@@ -0,0 +1,79 @@
+import { apiClient } from "./apiUtils";
+import type { ColumnInfo, QueryStats } from "./explore";
+import type { SavedQueryVariable } from "./savedQueries";
+
+export type DashboardPanelType = "table" | "histogram" | "stat" | "pie";
+
+export interface DashboardPanel {
+  id: string;
+  title: string;
+  type: DashboardPanelType;
+  source_id: number;
+  saved_query_id?: number; // Saved SQL query backing the panel
+  query?: string; // Inline SQL, used when saved_query_id is unset
+  limit?: number;
+  window?: string; // Histogram bucket size, e.g. "1m"
+  group_by?: string;
+  layout: { x: number; y: number; w: number; h: number };
+}
+
+export interface DashboardTimeRange {
+  relative?: string; // e.g. "15m" or "last 24h"
+  absolute?: { start: number; end: number };
+}
+
+export interface Dashboard {
+  id: number;
+  team_id: number;
+  name: string;
+  description: string;
+  panels: DashboardPanel[];
+  variables: SavedQueryVariable[]; // Shared by all panels
+  time_range: DashboardTimeRange;
+  concurrency: number; // Panels queried at once, capped by the server; 0 uses the maximum
+  created_at: string;
+  updated_at: string;
+}
+
+export type DashboardInput = Pick<Dashboard, "name" | "description" | "panels" | "variables" | "time_range"> &
+  Partial<Pick<Dashboard, "concurrency">>;
+
+export interface DashboardPanelResult {
+  panel_id: string;
+  type: DashboardPanelType;
+  data?: Record<string, any>[];
+  columns?: ColumnInfo[];
+  stats?: QueryStats;
+  histogram?: {
+    granularity: string;
+    data: { bucket: string; log_count: number; group_value: string }[];
+  };
+  error?: string;
+}
+
+export interface RunDashboardRequest {
+  variables?: Record<string, string>; // "time_range" overrides the dashboard's time range
+  panel_ids?: string[];
+  query_timeout?: number;
+  no_cache?: boolean; // Bypass the query result cache
+}
+
+export const dashboardsApi = {
+  listDashboards: (teamId: number) =>
+    apiClient.get<Dashboard[]>(`/teams/${teamId}/dashboards`),
+
+  getDashboard: (teamId: number, dashboardId: number) =>
+    apiClient.get<Dashboard>(`/teams/${teamId}/dashboards/${dashboardId}`),
+
+  createDashboard: (teamId: number, dashboard: DashboardInput) =>
+    apiClient.post<Dashboard>(`/teams/${teamId}/dashboards`, dashboard),
+
+  updateDashboard: (teamId: number, dashboardId: number, dashboard: Partial<DashboardInput>) =>
+    apiClient.put<Dashboard>(`/teams/${teamId}/dashboards/${dashboardId}`, dashboard),
+
+  deleteDashboard: (teamId: number, dashboardId: number) =>
+    apiClient.delete<{ message: string }>(`/teams/${teamId}/dashboards/${dashboardId}`),
+
+  runDashboard: (teamId: number, dashboardId: number, params: RunDashboardRequest = {}) =>
+    apiClient.post<{ panels: DashboardPanelResult[] }>(`/teams/${teamId}/dashboards/${dashboardId}/run`, params),
+};
@@ -1,5 +1,6 @@
 import { apiClient } from "./apiUtils";
 import type { DateValue } from "@internationalized/date";
+import type { FieldRole } from "./sources";
 import { useSourcesStore } from "@/stores/sources";
 import { QueryService } from "@/services/QueryService";
 import type { TimeRange } from "@/types/query";
@@ -52,12 +53,14 @@ export interface QueryParams {
   start_time?: string; // ISO formatted start time
   end_time?: string;   // ISO formatted end time
   query_timeout?: number; // Query timeout in seconds
+  no_cache?: boolean; // Bypass the query result cache
 }
 
 export interface QueryStats {
   execution_time_ms: number;
   rows_read: number;
   bytes_read: number;
+  cached?: boolean; // Served from the query result cache
 }
 
 export interface QuerySuccessResponse {
@@ -83,6 +86,7 @@ export interface LogContextRequest {
   timestamp: number;
   before_limit: number;
   after_limit: number;
+  scope?: Partial<Record<FieldRole, string>>; // Only logs with the same service, host, ...
 }
 
 export interface LogContextResponse {
@@ -93,6 +97,74 @@ export interface LogContextResponse {
   stats: QueryStats;
 }
 
+// Trace correlation types
+export interface TraceLog {
+  source_id: number;
+  source_name: string;
+  timestamp: string;
+  span_id?: string;
+  parent_span_id?: string;
+  service?: string;
+  severity?: string;
+  message?: string;
+  log: Record<string, any>;
+}
+
+export interface TraceSpan {
+  span_id: string;
+  parent_span_id?: string;
+  service?: string;
+  start: string;
+  end: string;
+  log_count: number;
+  children: TraceSpan[];
+}
+
+export interface TraceSourceResult {
+  source_id: number;
+  name: string;
+  logs: number;
+  truncated?: boolean;
+  error?: string;
+}
+
+export interface TraceTimeline {
+  trace_id: string;
+  from: string;
+  to: string;
+  logs: TraceLog[];
+  spans: TraceSpan[];
+  sources: TraceSourceResult[];
+  url?: string; // Link to the trace in the external tracing UI
+}
+
+// Federated search types
+export interface FederatedSearchParams {
+  query: string; // LogchefQL filter, may use semantic fields (service, severity, ...)
+  source_ids?: number[]; // Defaults to all of the team's sources
+  start_time: number; // Unix milliseconds
+  end_time: number; // Unix milliseconds
+  limit?: number;
+  query_timeout?: number; // Seconds, applied to each source
+}
+
+export interface FederatedSourceResult {
+  source_id: number;
+  name: string;
+  timestamp_field: string;
+  rows: number;
+  truncated?: boolean;
+  stats: QueryStats;
+  error?: string;
+}
+
+export interface FederatedSearchResult {
+  logs: Record<string, any>[]; // Each row has _source and _source_id
+  columns: ColumnInfo[];
+  sources: FederatedSourceResult[];
+  stats: QueryStats;
+}
+
 // Histogram data types
 export interface HistogramDataPoint {
   bucket: string;
@@ -103,6 +175,36 @@ export interface HistogramDataPoint {
 export interface HistogramResponse {
   granularity: string;
   data: HistogramDataPoint[];
+  cached?: boolean; // Served from the query result cache
+}
+
+// Log pattern clustering types
+export interface LogPatternsRequest {
+  raw_sql: string;
+  field?: string;
+  sample_size?: number;
+  max_patterns?: number;
+  samples?: number;
+  similarity?: number;
+  query_timeout?: number;
+}
+
+export interface LogPattern {
+  id: number;
+  template: string;
+  count: number;
+  percentage: number;
+  first_seen?: string;
+  last_seen?: string;
+  samples: Record<string, any>[];
+  filter: string; // ClickHouse condition for drilling down into the pattern
+}
+
+export interface LogPatternsResponse {
+  field: string;
+  rows_analyzed: number;
+  total_patterns: number;
+  patterns: LogPattern[];
 }
 
 /**
@@ -172,6 +274,18 @@ export const exploreApi = {
     );
   },
 
+  getLogPatterns: (sourceId: number, params: LogPatternsRequest, teamId: number, signal?: AbortSignal) => {
+    if (!teamId) {
+      throw new Error("Team ID is required for getting log patterns");
+    }
+    const timeout = params.query_timeout || 30; // Default to 30 seconds
+    return apiClient.post<LogPatternsResponse>(
+      `/teams/${teamId}/sources/${sourceId}/logs/patterns`,
+      params,
+      { timeout, signal }
+    );
+  },
+
   getLogContext: (sourceId: number, params: LogContextRequest, teamId: number) => {
     if (!teamId) {
       throw new Error("Team ID is required for getting log context");
@@ -195,6 +309,19 @@ export const exploreApi = {
     );
   },
 
+  // Logs of a trace across all the team's sources; time is the time hint in Unix ms
+  getTrace: (teamId: number, traceId: string, time: number, window?: string) => {
+    const query = new URLSearchParams({ time: String(time) });
+    if (window) query.append("window", window);
+    return apiClient.get<TraceTimeline>(
+      `/teams/${teamId}/traces/${encodeURIComponent(traceId)}?${query.toString()}`
+    );
+  },
+
+  federatedSearch: (teamId: number, params: FederatedSearchParams) => {
+    return apiClient.post<FederatedSearchResult>(`/teams/${teamId}/search`, params);
+  },
+
   cancelQuery: (sourceId: number, queryId: string, teamId: number) => {
     if (!teamId) {
       throw new Error("Team ID is required for cancelling queries");
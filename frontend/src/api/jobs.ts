@@ -0,0 +1,26 @@
+import { api } from "./config";
+import type { APIResponse } from "./types";
+
+export interface JobStatus {
+  name: string;
+  schedule: string; // "@every <duration>", "@hourly"-style descriptor or cron expression (UTC)
+  running: boolean;
+  next_run_at?: string;
+  last_run_at?: string;
+  last_duration_ms: number;
+  last_error?: string; // Unset when the last run succeeded
+  last_success_at?: string;
+  runs: number;
+  failures: number;
+}
+
+export const jobsApi = {
+  listJobs: async () => {
+    const response = await api.get<APIResponse<JobStatus[]>>(`/admin/jobs`);
+    return response.data;
+  },
+  runJob: async (name: string) => {
+    const response = await api.post<APIResponse<{ message: string; job: string }>>(`/admin/jobs/${encodeURIComponent(name)}/run`);
+    return response.data;
+  },
+};
@@ -4,6 +4,7 @@ import type { APIResponse } from "./types";
 export interface MetaResponse {
   version: string;
   http_server_timeout: string;
+  trace_url_template?: string; // {trace_id}, {start} and {end} (Unix ms) are replaced
 }
 
 export const metaApi = {
@@ -0,0 +1,40 @@
+import { api } from "./config";
+import type { APIResponse } from "./types";
+
+export type OIDCSyncAction =
+  | "create_user"
+  | "reject"
+  | "grant_admin"
+  | "revoke_admin"
+  | "add_member"
+  | "update_role"
+  | "remove_member"
+  | "skip";
+
+export interface OIDCSyncEvent {
+  id: number;
+  user_id?: number; // Unset for rejected logins
+  user_email: string;
+  team_id?: number;
+  team_name?: string;
+  action: OIDCSyncAction;
+  role?: string;
+  groups: string[];
+  reason?: string; // Why a change was skipped or rejected
+  created_at: string;
+}
+
+export interface OIDCSyncEventFilter {
+  user_id?: number;
+  team_id?: number;
+  action?: OIDCSyncAction;
+  limit?: number;
+  offset?: number;
+}
+
+export const oidcSyncApi = {
+  listEvents: async (filter: OIDCSyncEventFilter = {}) => {
+    const response = await api.get<APIResponse<OIDCSyncEvent[]>>(`/admin/oidc-sync-events`, { params: filter });
+    return response.data;
+  },
+};
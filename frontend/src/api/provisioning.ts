@@ -0,0 +1,32 @@
+import { api } from "./config";
+import type { APIResponse } from "./types";
+
+export interface ProvisioningChange {
+  kind: "user" | "source" | "team" | "team_member" | "team_source" | "collection";
+  name: string;
+  action: "create" | "update" | "delete" | "release";
+}
+
+export interface ProvisioningStatus {
+  enabled: boolean;
+  path: string;
+  watch: boolean;
+  prune: boolean;
+  applied_at?: string;
+  files: string[];
+  changes: ProvisioningChange[]; // Of the last run
+  error?: string;
+}
+
+export const provisioningApi = {
+  getStatus: async () => {
+    const response = await api.get<APIResponse<ProvisioningStatus>>(`/admin/provisioning`);
+    return response.data;
+  },
+
+  // Re-applies the provisioning files, for deployments that don't watch the directory.
+  apply: async () => {
+    const response = await api.post<APIResponse<ProvisioningStatus>>(`/admin/provisioning/apply`);
+    return response.data;
+  },
+};
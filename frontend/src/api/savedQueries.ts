@@ -1,4 +1,5 @@
 import { apiClient } from "./apiUtils";
+import type { QuerySuccessResponse } from "./explore";
 
 /**
  * Saved query content structure
@@ -7,6 +8,7 @@ export interface SavedQueryContent {
   version: number;
   sourceId: number | string;
   timeRange: {
+    relative?: string; // Range ending now, e.g. "15m" or "last 24h"; takes precedence over absolute
     absolute: {
       start: number;
       end: number;
@@ -14,6 +16,29 @@ export interface SavedQueryContent {
   } | null;
   limit: number;
   content: string; // The content of the query (either LogchefQL or SQL)
+  variables?: SavedQueryVariable[]; // Referenced in content as {{name}}
+}
+
+/**
+ * Variable declared by a saved query
+ */
+export interface SavedQueryVariable {
+  name: string;
+  label?: string;
+  type: "string" | "enum" | "number" | "time_range";
+  required?: boolean;
+  default?: string;
+  options?: string[]; // Static options of an enum variable
+  options_column?: string; // Column whose distinct values populate an enum variable
+}
+
+/**
+ * Request for running a saved query
+ */
+export interface RunSavedQueryRequest {
+  variables?: Record<string, string>; // "time_range" overrides the saved time range
+  limit?: number;
+  query_timeout?: number;
 }
 
 /**
@@ -27,10 +52,73 @@ export interface SavedTeamQuery {
   description: string;
   query_type: string;
   query_content: string; // JSON string of SavedQueryContent
+  created_by?: number;
+  updated_by?: number;
+  folder_id?: number;
+  tags: string[];
+  last_used_at?: string;
+  is_favorite: boolean; // For the current user
+  is_pinned: boolean; // For the current user
+  provisioned: boolean; // Managed by provisioning files, read-only in the UI
+  created_at: string;
+  updated_at: string;
+}
+
+/**
+ * Folder grouping saved queries of a team
+ */
+export interface CollectionFolder {
+  id: number;
+  team_id: number;
+  name: string;
+  query_count: number;
   created_at: string;
   updated_at: string;
 }
 
+export type CollectionSort = "last_used" | "updated" | "name";
+
+/**
+ * Filters for searching the saved queries of a team across its sources
+ */
+export interface CollectionSearchParams {
+  q?: string;
+  source_id?: number;
+  folder_id?: number; // 0 for queries outside any folder
+  tag?: string;
+  favorites?: boolean;
+  sort?: CollectionSort;
+  limit?: number;
+  offset?: number;
+}
+
+/**
+ * A recorded version of a saved query
+ */
+export interface SavedQueryRevision {
+  id: number;
+  query_id: number;
+  revision: number;
+  name: string;
+  description: string;
+  query_type: string;
+  query_content: string; // JSON string of SavedQueryContent
+  created_by?: number;
+  created_by_email?: string;
+  created_at: string;
+}
+
+/**
+ * Line-based diff between two revisions of a saved query
+ */
+export interface SavedQueryRevisionDiff {
+  query_id: number;
+  from_revision: number;
+  to_revision: number;
+  lines: { op: "equal" | "add" | "remove"; text: string }[];
+  unified: string;
+}
+
 /**
  * Team information
  */
@@ -53,8 +141,41 @@ export interface TeamGroupedQuery {
  * Saved Queries API client
  */
 export const savedQueriesApi = {
-  listTeamSourceQueries: (teamId: number, sourceId: number) =>
-    apiClient.get<SavedTeamQuery[]>(`/teams/${teamId}/sources/${sourceId}/collections`),
+  listTeamSourceQueries: (teamId: number, sourceId: number, sort?: CollectionSort) =>
+    apiClient.get<SavedTeamQuery[]>(
+      `/teams/${teamId}/sources/${sourceId}/collections` + (sort ? `?sort=${sort}` : "")
+    ),
+
+  searchTeamQueries: (teamId: number, params: CollectionSearchParams = {}) => {
+    const query = new URLSearchParams();
+    Object.entries(params).forEach(([key, value]) => {
+      if (value !== undefined && value !== "") query.append(key, String(value));
+    });
+    const qs = query.toString();
+    return apiClient.get<SavedTeamQuery[]>(`/teams/${teamId}/collections/search${qs ? `?${qs}` : ""}`);
+  },
+
+  listTeamTags: (teamId: number) => apiClient.get<string[]>(`/teams/${teamId}/collections/tags`),
+
+  listFolders: (teamId: number) => apiClient.get<CollectionFolder[]>(`/teams/${teamId}/collection-folders`),
+
+  createFolder: (teamId: number, name: string) =>
+    apiClient.post<CollectionFolder>(`/teams/${teamId}/collection-folders`, { name }),
+
+  renameFolder: (teamId: number, folderId: number, name: string) =>
+    apiClient.put<CollectionFolder>(`/teams/${teamId}/collection-folders/${folderId}`, { name }),
+
+  deleteFolder: (teamId: number, folderId: number) =>
+    apiClient.delete<{ message: string }>(`/teams/${teamId}/collection-folders/${folderId}`),
+
+  setFavorite: (teamId: number, sourceId: number, collectionId: string, pinned = false) =>
+    apiClient.put<{ is_favorite: boolean; is_pinned: boolean }>(
+      `/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/favorite`,
+      { pinned }
+    ),
+
+  removeFavorite: (teamId: number, sourceId: number, collectionId: string) =>
+    apiClient.delete<{ message: string }>(`/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/favorite`),
 
   getTeamSourceQuery: (teamId: number, sourceId: number, collectionId: string) =>
     apiClient.get<SavedTeamQuery>(`/teams/${teamId}/sources/${sourceId}/collections/${collectionId}`),
@@ -64,6 +185,8 @@ export const savedQueriesApi = {
     description: string;
     query_type: string;
     query_content: string;
+    folder_id?: number;
+    tags?: string[];
   }) => apiClient.post<SavedTeamQuery>(`/teams/${teamId}/sources/${sourceId}/collections`, query),
 
   updateTeamSourceQuery: (
@@ -77,6 +200,34 @@ export const savedQueriesApi = {
   deleteTeamSourceQuery: (teamId: number, sourceId: number, collectionId: string) =>
     apiClient.delete<{ success: boolean }>(`/teams/${teamId}/sources/${sourceId}/collections/${collectionId}`),
 
+  runTeamSourceQuery: (teamId: number, sourceId: number, collectionId: string, params: RunSavedQueryRequest) =>
+    apiClient.post<QuerySuccessResponse>(`/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/run`, params),
+
+  getVariableOptions: (teamId: number, sourceId: number, collectionId: string, name: string) =>
+    apiClient.get<string[]>(
+      `/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/variables/${encodeURIComponent(name)}/options`
+    ),
+
+  listRevisions: (teamId: number, sourceId: number, collectionId: string) =>
+    apiClient.get<SavedQueryRevision[]>(`/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/revisions`),
+
+  getRevision: (teamId: number, sourceId: number, collectionId: string, revision: number) =>
+    apiClient.get<SavedQueryRevision>(
+      `/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/revisions/${revision}`
+    ),
+
+  diffRevision: (teamId: number, sourceId: number, collectionId: string, revision: number, against?: number) =>
+    apiClient.get<SavedQueryRevisionDiff>(
+      `/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/revisions/${revision}/diff` +
+        (against ? `?against=${against}` : "")
+    ),
+
+  restoreRevision: (teamId: number, sourceId: number, collectionId: string, revision: number) =>
+    apiClient.post<SavedTeamQuery>(
+      `/teams/${teamId}/sources/${sourceId}/collections/${collectionId}/revisions/${revision}/restore`,
+      {}
+    ),
+
   // For retrieving user teams
   getUserTeams: () => apiClient.get<Team[]>("/me/teams")
 };
@@ -0,0 +1,73 @@
+import { api } from "./config";
+import { apiClient } from "./apiUtils";
+import type { APIResponse } from "./types";
+import type { ColumnInfo, QueryStats } from "./explore";
+
+export type ShareLinkMode = "snapshot" | "live";
+
+export interface ShareLink {
+  id: number;
+  slug: string;
+  team_id: number;
+  source_id: number;
+  created_by: number;
+  created_by_email: string;
+  title: string;
+  mode: ShareLinkMode;
+  raw_sql: string;
+  limit: number;
+  start_time?: string;
+  end_time?: string;
+  has_password: boolean;
+  allow_anonymous: boolean;
+  view_count: number;
+  last_viewed_at?: string;
+  expires_at: string;
+  revoked_at?: string;
+  created_at: string;
+}
+
+export interface CreateShareLinkRequest {
+  title?: string;
+  mode?: ShareLinkMode; // Defaults to snapshot
+  raw_sql: string;
+  limit?: number;
+  query_timeout?: number;
+  start_time?: string; // Set with end_time; raw_sql must reference it as {{time_range}}, {{time_range.start}} or {{time_range.end}}
+  end_time?: string;
+  expires_in_hours?: number; // Defaults to 7 days, at most 90 days
+  password?: string;
+  allow_anonymous?: boolean; // Only if the team allows anonymous share links
+}
+
+export interface SharedQueryResult {
+  share: ShareLink;
+  result: {
+    logs: Record<string, any>[] | null;
+    stats: QueryStats;
+    columns: ColumnInfo[];
+  };
+  executed_at: string;
+}
+
+export const sharesApi = {
+  createShareLink: (teamId: number, sourceId: number, data: CreateShareLinkRequest) =>
+    apiClient.post<ShareLink>(`/teams/${teamId}/sources/${sourceId}/shares`, data),
+
+  // The password of a protected link is sent in a header so it doesn't end up in URLs or logs.
+  openShareLink: async (slug: string, password?: string) => {
+    const response = await api.get<APIResponse<SharedQueryResult>>(`/shares/${slug}`, {
+      headers: password ? { "X-Share-Password": password } : {},
+    });
+    return response.data;
+  },
+
+  revokeShareLink: (slug: string) =>
+    apiClient.delete<{ message: string }>(`/shares/${slug}`),
+
+  listMyShareLinks: () =>
+    apiClient.get<ShareLink[]>(`/me/shares`),
+
+  listTeamShareLinks: (teamId: number) =>
+    apiClient.get<ShareLink[]>(`/teams/${teamId}/shares`),
+};
@@ -24,6 +24,9 @@ export interface Source {
   connection: ConnectionInfo;
   description?: string;
   ttl_days: number;
+  field_roles: SourceFieldRoles;
+  severity_mapping: SeverityMapping;
+  provisioned: boolean; // Managed by provisioning files, read-only in the UI
   created_at: string;
   updated_at: string;
   is_connected: boolean;
@@ -40,6 +43,23 @@ export interface ColumnInfo {
   type: string;
 }
 
+// Columns of a source holding well-known log fields
+export interface SourceFieldRoles {
+  message?: string;
+  service?: string;
+  host?: string;
+  trace_id?: string;
+  span_id?: string;
+  parent_span_id?: string;
+  http_status?: string;
+  duration?: string;
+}
+
+export type FieldRole = keyof SourceFieldRoles;
+
+// Raw severity values ("E") or numeric ranges ("17-20") mapped to levels
+export type SeverityMapping = Record<string, "trace" | "debug" | "info" | "warn" | "error" | "fatal">;
+
 export interface SourceWithTeamsResponse {
   source: Source;
   teams: Team[];
@@ -63,7 +83,40 @@ export interface CreateSourcePayload {
   connection: ConnectionRequestInfo;
   description?: string;
   ttl_days: number;
-  schema?: string;
+  field_roles?: SourceFieldRoles;
+  severity_mapping?: SeverityMapping;
+  schema?: string; // Custom CREATE TABLE statement
+  template?: string; // Schema template, used when no custom schema is given
+  template_params?: SchemaTemplateParams;
+}
+
+export interface SchemaTemplateParameter {
+  name: string;
+  type: "string" | "string_list" | "bool" | "int";
+  default?: string | string[] | boolean | number;
+  options?: string[];
+  description: string;
+}
+
+export interface SchemaTemplate {
+  name: string;
+  title: string;
+  description: string;
+  timestamp_field: string;
+  severity_field?: string;
+  field_roles: SourceFieldRoles; // Roles given to sources created from the template
+  columns: string[];
+  parameters: SchemaTemplateParameter[];
+}
+
+export interface SchemaTemplateParams {
+  partition_by?: "day" | "week" | "month" | "none";
+  order_by?: string[];
+  codec?: string;
+  replicated?: boolean;
+  cluster?: string;
+  zookeeper_path?: string;
+  replica_name?: string;
 }
 
 export interface CreateTeamQueryRequest {
@@ -93,6 +146,84 @@ export interface SourceStats {
     rows_count: number;
     avg_row_size: number;
   }[];
+  ttl?: string;
+  retention?: SourceRetention;
+}
+
+export interface SourceRetention {
+  ttl_days: number; // Retention stored for the source; 0 or -1 means no TTL
+  table: string; // Table holding the TTL, the local table of a Distributed table
+  table_ttl?: string;
+  table_ttl_days?: number;
+  drift: boolean; // The table TTL doesn't match the stored retention
+}
+
+export interface SourceRetentionChange {
+  id: number;
+  source_id: number;
+  user_id?: number;
+  old_ttl_days: number;
+  new_ttl_days: number;
+  status: "applied" | "failed" | "not_applied";
+  statement?: string;
+  error?: string;
+  created_at: string;
+}
+
+export interface SchemaChange {
+  id: number;
+  source_id: number;
+  type: "column_added" | "column_removed" | "column_type_changed" | "sort_keys_changed";
+  column?: string; // Empty for sort key changes
+  old_value?: string; // Column type or sort keys before the change
+  new_value?: string; // Column type or sort keys after the change
+  detected_at: string;
+}
+
+export interface SchemaChangeImpact {
+  query_id: number;
+  team_id: number;
+  name: string;
+  missing_columns: string[];
+}
+
+export interface SchemaDrift {
+  changes: SchemaChange[];
+  affected_queries: SchemaChangeImpact[]; // Saved queries referencing removed columns
+}
+
+export interface DiscoveredTable {
+  database: string;
+  table: string;
+  engine: string;
+  rows: number;
+  bytes_on_disk: number;
+  size: string;
+  columns: { name: string; type: string }[];
+  sort_keys: string[];
+  timestamp_field?: string; // Suggested timestamp field
+  severity_field?: string; // Suggested severity field
+  timestamp_candidates: string[];
+  severity_candidates: string[];
+  source_id?: number; // Set when the table is already registered as a source
+}
+
+export interface TableDiscovery {
+  databases: string[];
+  tables: DiscoveredTable[];
+}
+
+export interface MapKeyStat {
+  key: string;
+  count: number;
+  examples: string[];
+}
+
+export interface MapColumnKeys {
+  column: string;
+  type: string;
+  keys: MapKeyStat[];
+  sampled_at: string;
 }
 
 export const sourcesApi = {
@@ -107,9 +238,19 @@ export const sourcesApi = {
     apiClient.post<Source>("/admin/sources", payload),
   updateSource: (id: number, payload: Partial<Source>) =>
     apiClient.put<Source>(`/admin/sources/${id}`, payload),
+  getSourceRetention: (id: number) =>
+    apiClient.get<{ retention: SourceRetention | null; changes: SourceRetentionChange[] }>(`/admin/sources/${id}/retention`),
+  getSourceSchemaChanges: (id: number) =>
+    apiClient.get<SchemaDrift>(`/admin/sources/${id}/schema-changes`),
   deleteSource: (id: number) =>
     apiClient.delete<{ message: string }>(`/admin/sources/${id}`),
 
+  // Schema templates for auto-created tables
+  listSchemaTemplates: () =>
+    apiClient.get<SchemaTemplate[]>("/admin/schema-templates"),
+  renderSchemaTemplate: (name: string, payload: { database: string; table: string; ttl_days: number; params?: SchemaTemplateParams }) =>
+    apiClient.post<{ schema: string }>(`/admin/schema-templates/${name}/render`, payload),
+
   // Source stats and schema (admin and team-scoped versions)
   getAdminSourceStats: (sourceId: number) =>
     apiClient.get<SourceStats>(`/admin/sources/${sourceId}/stats`),
@@ -117,6 +258,17 @@ export const sourcesApi = {
     apiClient.get<SourceStats>(`/teams/${teamId}/sources/${sourceId}/stats`),
   getTeamSourceSchema: (teamId: number, sourceId: number) =>
     apiClient.get<string>(`/teams/${teamId}/sources/${sourceId}/schema`),
+  getTeamSourceMapKeys: (teamId: number, sourceId: number, column?: string, refresh = false) => {
+    const params = new URLSearchParams();
+    if (column) params.set("column", column);
+    if (refresh) params.set("refresh", "true");
+    const qs = params.toString();
+    return apiClient.get<MapColumnKeys[]>(
+      `/teams/${teamId}/sources/${sourceId}/schema/map-keys${qs ? `?${qs}` : ""}`
+    );
+  },
+  getTeamSourceSchemaChanges: (teamId: number, sourceId: number) =>
+    apiClient.get<SchemaDrift>(`/teams/${teamId}/sources/${sourceId}/schema-changes`),
 
   // Team-scoped source queries
   listTeamSourceQueries: (teamId: number, sourceId: number) =>
@@ -131,5 +283,8 @@ export const sourcesApi = {
   validateSourceConnection: (connectionInfo: ConnectionRequestInfo & {
     timestamp_field?: string;
     severity_field?: string;
-  }) => apiClient.post<{ message: string }>("/admin/sources/validate", connectionInfo)
+    severity_mapping?: SeverityMapping; // Allows a numeric severity field
+  }) => apiClient.post<{ message: string }>("/admin/sources/validate", connectionInfo),
+  discoverTables: (connectionInfo: Omit<ConnectionRequestInfo, "table_name" | "database"> & { database?: string }) =>
+    apiClient.post<TableDiscovery>("/admin/sources/discover", connectionInfo)
 };
@@ -10,6 +10,8 @@ export interface Team {
   created_at: string;
   updated_at: string;
   member_count?: number;
+  allow_anonymous_shares: boolean; // Share links can be opened without logging in
+  provisioned: boolean; // Managed by provisioning files, read-only in the UI
 }
 
 export interface UserTeamMembership {
@@ -30,6 +32,8 @@ export interface TeamMember {
   updated_at: string;
   email: string;
   full_name: string;
+  provisioned: boolean;
+  oidc_synced: boolean; // Granted by an OIDC group mapping
 }
 
 export interface CreateTeamRequest {
@@ -38,8 +42,9 @@ export interface CreateTeamRequest {
 }
 
 export interface UpdateTeamRequest {
-  name: string;
-  description: string;
+  name?: string;
+  description?: string;
+  allow_anonymous_shares?: boolean;
 }
 
 export interface UserIdentifier {
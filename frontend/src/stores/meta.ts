@@ -7,6 +7,7 @@ import type { APIErrorResponse } from "@/api/types";
 interface MetaState {
   version: string | null;
   httpServerTimeout: string | null;
+  traceUrlTemplate: string | null;
   isInitialized: boolean;
 }
 
@@ -14,12 +15,14 @@ export const useMetaStore = defineStore("meta", () => {
   const state = useBaseStore<MetaState>({
     version: null,
     httpServerTimeout: null,
+    traceUrlTemplate: null,
     isInitialized: false,
   });
 
   // Computed properties
   const version = computed(() => state.data.value.version);
   const httpServerTimeout = computed(() => state.data.value.httpServerTimeout);
+  const traceUrlTemplate = computed(() => state.data.value.traceUrlTemplate);
   const isInitialized = computed(() => state.data.value.isInitialized);
   const error = computed(() => state.error.value);
 
@@ -39,6 +42,7 @@ export const useMetaStore = defineStore("meta", () => {
             if (response) {
               state.data.value.version = response.version;
               state.data.value.httpServerTimeout = response.http_server_timeout;
+              state.data.value.traceUrlTemplate = response.trace_url_template || null;
               state.data.value.isInitialized = true;
               console.log("Meta loaded successfully:", {
                 version: response.version,
@@ -62,12 +66,14 @@ export const useMetaStore = defineStore("meta", () => {
   function clearState() {
     state.data.value.version = null;
     state.data.value.httpServerTimeout = null;
+    state.data.value.traceUrlTemplate = null;
     state.data.value.isInitialized = false;
   }
 
   return {
     version,
     httpServerTimeout,
+    traceUrlTemplate,
     isInitialized,
     error,
     loadMeta,
@@ -7,6 +7,8 @@ export interface User {
   avatar?: string;
   last_login_at?: string;
   last_active_at?: string;
+  provisioned: boolean; // Managed by provisioning files, read-only in the UI
+  oidc_admin: boolean; // Admin role granted by an OIDC admin group
   created_at: string;
   updated_at: string;
 }
@@ -39,8 +39,9 @@ const (
    - Use = or equals for exact matches (e.g., "equals", "is", "matches exactly")
    - Use LIKE for simple wildcard patterns, but prefer positionCaseInsensitive for most substring searches
 9. Order results by timestamp DESC for log analytics queries unless specified otherwise
-10. Output ONLY the executable SQL query - NO code fences, NO markdown formatting, NO explanation.
-11. Provide ONLY the raw SQL query itself with no additional formatting or markup.
+10. Columns with a "role" hold that kind of data (timestamp, severity, message, service, host, trace_id, span_id, parent_span_id, http_status, duration). Use them for those concepts instead of guessing from column names. A severity column with "severity_levels" stores raw values mapped to levels; filter it by the raw values (e.g. severity_number BETWEEN 17 AND 20 for errors given "17-20": "error").
+11. Output ONLY the executable SQL query - NO code fences, NO markdown formatting, NO explanation.
+12. Provide ONLY the raw SQL query itself with no additional formatting or markup.
 
 Example query patterns:
 SELECT *
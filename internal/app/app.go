@@ -19,14 +19,19 @@ import (
 
 // App represents the core application context, holding dependencies and configuration.
 type App struct {
-	Config     *config.Config
-	SQLite     *sqlite.DB
-	ClickHouse *clickhouse.Manager
-	Logger     *slog.Logger
-	server     *server.Server
-	WebFS      http.FileSystem
-	BuildInfo  string
-	Version    string
+	Config      *config.Config
+	SQLite      *sqlite.DB
+	ClickHouse  *clickhouse.Manager
+	Auditor     *core.AuditLogger
+	QueryCache  *core.QueryCache
+	MapKeys     *core.MapKeysCache
+	Provisioner *core.Provisioner
+	Scheduler   *core.Scheduler
+	Logger      *slog.Logger
+	server      *server.Server
+	WebFS       http.FileSystem
+	BuildInfo   string
+	Version     string
 }
 
 // Options contains configuration needed when creating a new App instance.
@@ -76,6 +81,15 @@ func (a *App) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize admin users: %w", err)
 	}
 
+	// Initialize the query audit log writer (nil when auditing is disabled).
+	a.Auditor = core.NewAuditLogger(a.SQLite, a.Logger, a.Config.Audit)
+
+	// Initialize the query result cache (nil when caching is disabled).
+	a.QueryCache = core.NewQueryCache(a.SQLite, a.Logger, a.Config.QueryCache)
+
+	// Initialize the Map column key cache used by autocomplete and AI prompts.
+	a.MapKeys = core.NewMapKeysCache()
+
 	// Initialize ClickHouse connection manager.
 	a.ClickHouse = clickhouse.NewManager(a.Logger)
 
@@ -110,16 +124,40 @@ func (a *App) Initialize(ctx context.Context) error {
 		}
 	}
 
+	// Apply the provisioning files, if configured. Sources they create or change are
+	// connected by the provisioner.
+	a.Provisioner = core.NewProvisioner(a.SQLite, a.ClickHouse, a.MapKeys, a.Logger, a.Config.Provisioning, a.Config.Auth.AdminEmails)
+	if err := a.Provisioner.Apply(ctx); err != nil {
+		return fmt.Errorf("failed to apply provisioning files: %w", err)
+	}
+	if err := a.Provisioner.Watch(); err != nil {
+		return fmt.Errorf("failed to watch provisioning files: %w", err)
+	}
+
+	// Snapshot source schemas during the health checks to record schema drift.
+	a.ClickHouse.SetSchemaObserver(core.NewSchemaDriftDetector(a.SQLite, a.QueryCache, a.MapKeys, a.Logger), a.Config.Clickhouse.SchemaSnapshotInterval)
+
 	// Start background health checks for the ClickHouse manager.
 	// Use 0 to trigger the default interval defined in the manager.
 	a.ClickHouse.StartBackgroundHealthChecks(0)
 
+	// Schedule the maintenance jobs (expired tokens and sessions, audit retention, caches).
+	if err := a.registerJobs(); err != nil {
+		return fmt.Errorf("failed to register background jobs: %w", err)
+	}
+	a.Scheduler.Start()
+
 	// Initialize HTTP server.
 	serverOpts := server.ServerOptions{
 		Config:       a.Config,
 		SQLite:       a.SQLite,
 		ClickHouse:   a.ClickHouse,
 		OIDCProvider: oidcProvider,
+		Auditor:      a.Auditor,
+		QueryCache:   a.QueryCache,
+		MapKeys:      a.MapKeys,
+		Provisioner:  a.Provisioner,
+		Scheduler:    a.Scheduler,
 		FS:           a.WebFS,
 		Logger:       a.Logger,
 		BuildInfo:    a.BuildInfo,
@@ -178,6 +216,21 @@ func (a *App) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Stop the background jobs before the databases they use are closed.
+	if a.Scheduler != nil {
+		a.Logger.Info("stopping background jobs")
+		if err := a.Scheduler.Close(ctx); err != nil {
+			a.Logger.Warn("timeout stopping background jobs, continuing", "error", err)
+		}
+	}
+
+	// Stop watching the provisioning files before the connections they use are closed.
+	if a.Provisioner != nil {
+		if err := a.Provisioner.Close(ctx); err != nil {
+			a.Logger.Warn("timeout stopping provisioning watcher, continuing", "error", err)
+		}
+	}
+
 	// Close ClickHouse manager (stops health checks and closes clients).
 	if a.ClickHouse != nil {
 		a.Logger.Info("shutting down ClickHouse connections")
@@ -200,6 +253,14 @@ func (a *App) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Flush pending audit entries before the database is closed.
+	if a.Auditor != nil {
+		a.Logger.Info("flushing query audit log")
+		if err := a.Auditor.Close(ctx); err != nil {
+			a.Logger.Warn("timeout flushing query audit log, continuing", "error", err)
+		}
+	}
+
 	// Close database connections.
 	if a.SQLite != nil {
 		a.Logger.Info("closing SQLite connection")
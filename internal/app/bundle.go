@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// ExportBundle writes a bundle of the configured instance's sources, teams and collections
+// to w. Only the SQLite database is opened, so the server need not be running.
+func ExportBundle(ctx context.Context, opts Options, w io.Writer, format models.BundleFormat, teamIDs []models.TeamID) error {
+	app, err := New(opts)
+	if err != nil {
+		return err
+	}
+	db, err := sqlite.New(sqlite.Options{Config: app.Config.SQLite, Logger: app.Logger})
+	if err != nil {
+		return fmt.Errorf("failed to initialize sqlite: %w", err)
+	}
+	defer db.Close()
+
+	bundle, err := core.ExportBundle(ctx, db, teamIDs)
+	if err != nil {
+		return err
+	}
+	data, err := core.EncodeBundle(bundle, format)
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ImportBundle imports a bundle into the configured instance. Only the SQLite database is
+// opened; a running server connects to created sources once it is restarted.
+func ImportBundle(ctx context.Context, opts Options, data []byte, format models.BundleFormat, importOpts models.BundleImportOptions) (*models.BundleImportResult, error) {
+	app, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sqlite.New(sqlite.Options{Config: app.Config.SQLite, Logger: app.Logger})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sqlite: %w", err)
+	}
+	defer db.Close()
+
+	bundle, err := core.DecodeBundle(data, format)
+	if err != nil {
+		return nil, err
+	}
+	return core.ImportBundle(ctx, db, nil, app.Logger, bundle, importOpts)
+}
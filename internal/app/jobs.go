@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/core"
+)
+
+// Names and default schedules of the background maintenance jobs.
+const (
+	jobTokenCleanup       = "token_cleanup"
+	jobSessionCleanup     = "session_cleanup"
+	jobAuditPrune         = "audit_prune"
+	jobSchemaCacheRefresh = "schema_cache_refresh"
+	jobQueryCachePrune    = "query_cache_prune"
+
+	defaultTokenCleanupSchedule       = "@every 1h"
+	defaultSessionCleanupSchedule     = "@every 1h"
+	defaultAuditPruneSchedule         = "@every 1h"
+	defaultSchemaCacheRefreshSchedule = "@every 10m"
+	defaultQueryCachePruneSchedule    = "@every 10m"
+)
+
+// registerJobs creates the scheduler and registers the maintenance jobs enabled in the config.
+func (a *App) registerJobs() error {
+	cfg := a.Config.Jobs
+	a.Scheduler = core.NewScheduler(a.Logger, cfg.Jitter, cfg.Timeout)
+
+	register := func(name, spec, defaultSpec string, fn core.JobFunc) error {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			spec = defaultSpec
+		}
+		if strings.EqualFold(spec, "off") {
+			a.Logger.Info("background job disabled", "job", name)
+			return nil
+		}
+		return a.Scheduler.Register(name, spec, fn)
+	}
+
+	if err := register(jobTokenCleanup, cfg.TokenCleanup, defaultTokenCleanupSchedule, func(ctx context.Context) error {
+		return core.CleanupExpiredTokens(ctx, a.SQLite, a.Logger)
+	}); err != nil {
+		return err
+	}
+
+	if err := register(jobSessionCleanup, cfg.SessionCleanup, defaultSessionCleanupSchedule, func(ctx context.Context) error {
+		return core.CleanupExpiredSessions(ctx, a.SQLite, a.Logger)
+	}); err != nil {
+		return err
+	}
+
+	// Audit entries are only pruned when auditing keeps them for a limited time.
+	if a.Config.Audit.Enabled && a.Config.Audit.Retention > 0 {
+		if err := register(jobAuditPrune, cfg.AuditPrune, defaultAuditPruneSchedule, func(ctx context.Context) error {
+			_, err := core.PruneQueryAuditLog(ctx, a.SQLite, a.Logger, a.Config.Audit.Retention)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Schema changes are otherwise only noticed by the health checks once per
+	// clickhouse.schema_snapshot_interval.
+	if err := register(jobSchemaCacheRefresh, cfg.SchemaCacheRefresh, defaultSchemaCacheRefreshSchedule, func(ctx context.Context) error {
+		refreshed, err := a.ClickHouse.RefreshSchemas(ctx)
+		a.Logger.Debug("refreshed source schemas", "count", refreshed)
+		if pruned := a.MapKeys.Prune(); pruned > 0 {
+			a.Logger.Debug("dropped expired map key samples", "count", pruned)
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+
+	// Expired results are otherwise only dropped when the same query runs again.
+	if !a.Config.QueryCache.Enabled {
+		return nil
+	}
+	return register(jobQueryCachePrune, cfg.QueryCachePrune, defaultQueryCachePruneSchedule, func(ctx context.Context) error {
+		pruned, err := a.QueryCache.Prune(ctx)
+		if pruned > 0 {
+			a.Logger.Debug("dropped expired query results", "count", pruned)
+		}
+		return err
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/mr-karan/logchef/internal/config"
@@ -102,7 +103,7 @@ func (p *OIDCProvider) GetAuthURL(state string) string {
 
 // HandleCallback processes the OIDC callback, exchanges the code for tokens,
 // verifies the ID token, looks up or potentially creates the user in the local database,
-// and creates a local application session.
+// syncs their team memberships from the groups claim, and creates a local application session.
 func (p *OIDCProvider) HandleCallback(ctx context.Context, db *sqlite.DB, log *slog.Logger, authCfg *config.AuthConfig, code, state string) (*models.User, *models.Session, error) {
 	// Exchange authorization code for OAuth2 tokens.
 	oauth2Token, err := p.oauthConf.Exchange(ctx, code)
@@ -134,6 +135,17 @@ func (p *OIDCProvider) HandleCallback(ctx context.Context, db *sqlite.DB, log *s
 		return nil, nil, fmt.Errorf("%w: failed to parse ID token claims: %v", ErrOIDCInvalidToken, err)
 	}
 
+	// Extract the user's groups when group sync is enabled.
+	var groups []string
+	if p.oidcCfg.GroupsClaim != "" {
+		var rawClaims map[string]any
+		if err := idToken.Claims(&rawClaims); err != nil {
+			p.log.Error("failed to parse ID token claims", "error", err)
+			return nil, nil, fmt.Errorf("%w: failed to parse ID token claims: %v", ErrOIDCInvalidToken, err)
+		}
+		groups = groupsFromClaims(rawClaims, p.oidcCfg.GroupsClaim)
+	}
+
 	// Ensure email is verified by the OIDC provider.
 	if !claims.EmailVerified {
 		p.log.Warn("OIDC login attempt with unverified email", "email", claims.Email)
@@ -143,14 +155,25 @@ func (p *OIDCProvider) HandleCallback(ctx context.Context, db *sqlite.DB, log *s
 	// Look up user in the local database.
 	user, err := core.GetUserByEmail(ctx, db, claims.Email)
 	if err != nil {
-		// User not found is treated as unauthorized access.
-		if errors.Is(err, core.ErrUserNotFound) {
+		if !errors.Is(err, core.ErrUserNotFound) {
+			// Log other unexpected DB errors.
+			p.log.Error("failed to lookup user by email via core function", "error", err, "email", claims.Email)
+			return nil, nil, fmt.Errorf("failed to lookup user: %w", err)
+		}
+		// User not found is treated as unauthorized access, unless users are created on first login.
+		if !p.oidcCfg.CreateUsers {
 			p.log.Warn("unauthorized user attempted login (user not found in db)", "email", claims.Email, "name", claims.Name)
 			return nil, nil, ErrUnauthorizedUser
 		}
-		// Log other unexpected DB errors.
-		p.log.Error("failed to lookup user by email via core function", "error", err, "email", claims.Email)
-		return nil, nil, fmt.Errorf("failed to lookup user: %w", err)
+		user, err = core.CreateOIDCUser(ctx, db, log, p.oidcCfg, claims.Email, claims.Name, groups)
+		if err != nil {
+			if errors.Is(err, core.ErrNoGroupMapping) {
+				p.log.Warn("unauthorized user attempted login (no matching group)", "email", claims.Email, "groups", groups)
+				return nil, nil, ErrUnauthorizedUser
+			}
+			p.log.Error("failed to create user on first login", "error", err, "email", claims.Email)
+			return nil, nil, fmt.Errorf("failed to create user: %w", err)
+		}
 	}
 	// User exists, check status.
 	if user.Status == models.UserStatusInactive {
@@ -158,6 +181,12 @@ func (p *OIDCProvider) HandleCallback(ctx context.Context, db *sqlite.DB, log *s
 		return nil, nil, ErrUserInactive
 	}
 
+	// Sync admin role and team memberships from the groups claim (best effort).
+	if err := core.SyncOIDCGroups(ctx, db, log, p.oidcCfg, authCfg.AdminEmails, user, groups); err != nil {
+		// Log failure but don't block login.
+		p.log.Error("failed to sync OIDC groups", "error", err, "user_id", user.ID)
+	}
+
 	// Update user's last login time (best effort).
 	now := time.Now()
 	updateData := models.User{LastLoginAt: &now}
@@ -176,3 +205,33 @@ func (p *OIDCProvider) HandleCallback(ctx context.Context, db *sqlite.DB, log *s
 	p.log.Info("user logged in successfully via OIDC callback", "user_id", user.ID, "session_id", session.ID)
 	return user, session, nil
 }
+
+// groupsFromClaims returns the groups listed in the given claim, which may be nested
+// (e.g. "realm_access.roles"). Both a list of strings and a single string are accepted.
+func groupsFromClaims(claims map[string]any, claim string) []string {
+	var value any = claims
+	for _, part := range strings.Split(claim, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok && s != "" {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	}
+	return nil
+}
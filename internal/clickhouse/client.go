@@ -184,6 +184,12 @@ func (c *Client) Query(ctx context.Context, query string /* params LogQueryParam
 	return c.QueryWithTimeout(ctx, query, nil)
 }
 
+// WithQueryParameters returns a context binding values to the {name:Type}
+// placeholders of queries run with it.
+func WithQueryParameters(ctx context.Context, params map[string]string) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithParameters(clickhouse.Parameters(params)))
+}
+
 // QueryWithTimeout executes a SELECT query with a timeout setting.
 // The timeoutSeconds parameter is required and will always be applied.
 func (c *Client) QueryWithTimeout(ctx context.Context, query string, timeoutSeconds *int) (*models.QueryResult, error) {
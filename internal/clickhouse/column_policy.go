@@ -0,0 +1,350 @@
+package clickhouse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	clickhouseparser "github.com/AfterShip/clickhouse-sql-parser/parser"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// DefaultRedactReplacement is substituted for matches of a redact policy without a replacement.
+const DefaultRedactReplacement = "[REDACTED]"
+
+// ValidateColumnPolicy checks that a policy has a known action and, for redact policies,
+// a valid regular expression.
+func ValidateColumnPolicy(policy *models.ColumnPolicy) error {
+	if strings.TrimSpace(policy.Column) == "" {
+		return fmt.Errorf("column is required")
+	}
+	switch policy.Action {
+	case models.ColumnPolicyHide, models.ColumnPolicyHash:
+		return nil
+	case models.ColumnPolicyRedact:
+		if policy.Pattern == "" {
+			return fmt.Errorf("pattern is required for redact policies")
+		}
+		// ClickHouse uses RE2 for replaceRegexpAll, same as Go's regexp package.
+		if _, err := regexp.Compile(policy.Pattern); err != nil {
+			return fmt.Errorf("invalid redact pattern: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q, must be one of: hide, hash, redact", policy.Action)
+	}
+}
+
+// ColumnMaskExpr returns the SQL expression that replaces a masked column in query results.
+func ColumnMaskExpr(policy *models.ColumnPolicy) string {
+	column := quoteIdentifier(policy.Column)
+	switch policy.Action {
+	case models.ColumnPolicyHash:
+		return fmt.Sprintf("lower(hex(SHA256(toString(%s))))", column)
+	case models.ColumnPolicyRedact:
+		replacement := policy.Replacement
+		if replacement == "" {
+			replacement = DefaultRedactReplacement
+		}
+		return fmt.Sprintf("replaceRegexpAll(toString(%s), %s, %s)", column, quoteString(policy.Pattern), quoteString(replacement))
+	default:
+		return "NULL"
+	}
+}
+
+// WithColumnPolicies sets the column policies BuildRawQuery enforces. columns is the
+// full schema of the table, used to expand SELECT * into the columns a team may see.
+func (qb *QueryBuilder) WithColumnPolicies(columns []models.ColumnInfo, policies []*models.ColumnPolicy) *QueryBuilder {
+	qb.columns = columns
+	qb.columnPolicies = make(map[string]*models.ColumnPolicy, len(policies))
+	for _, policy := range policies {
+		qb.columnPolicies[strings.ToLower(policy.Column)] = policy
+	}
+	return qb
+}
+
+// applyColumnPolicies rewrites the projection so that hidden columns are dropped and
+// masked columns return their masked value. Hidden columns may not be referenced at all;
+// masked columns may only be selected directly, so their raw values can't leak through
+// filters, grouping or expressions.
+func (qb *QueryBuilder) applyColumnPolicies(stmt *clickhouseparser.SelectQuery) error {
+	if len(qb.columnPolicies) == 0 {
+		return nil
+	}
+
+	if stmt.With != nil {
+		return fmt.Errorf("query validation failed: WITH clauses are not allowed on sources with column policies")
+	}
+	if countSelects(stmt) > 1 {
+		return fmt.Errorf("query validation failed: subqueries and UNION are not allowed on sources with column policies")
+	}
+	// Wildcards within expressions, e.g. tuple(*), would select hidden and masked columns.
+	if wildcard := nestedWildcard(stmt); wildcard != "" {
+		return fmt.Errorf("query validation failed: %s is only allowed as a whole SELECT item on sources with column policies", wildcard)
+	}
+
+	// 1. Clauses other than the projection must not touch restricted columns.
+	var clauses []clickhouseparser.Expr
+	if stmt.ArrayJoin != nil {
+		clauses = append(clauses, stmt.ArrayJoin)
+	}
+	if stmt.Prewhere != nil {
+		clauses = append(clauses, stmt.Prewhere)
+	}
+	if stmt.Where != nil {
+		clauses = append(clauses, stmt.Where)
+	}
+	if stmt.GroupBy != nil {
+		clauses = append(clauses, stmt.GroupBy)
+	}
+	if stmt.Having != nil {
+		clauses = append(clauses, stmt.Having)
+	}
+	if stmt.OrderBy != nil {
+		clauses = append(clauses, stmt.OrderBy)
+	}
+	if stmt.LimitBy != nil {
+		clauses = append(clauses, stmt.LimitBy)
+	}
+	if stmt.Window != nil {
+		clauses = append(clauses, stmt.Window)
+	}
+	for _, clause := range clauses {
+		if column, policy := qb.restrictedReference(clause); policy != nil {
+			return columnPolicyError(column, policy)
+		}
+	}
+
+	// 2. Rewrite the projection.
+	var projection []string
+	for _, item := range stmt.SelectItems {
+		rewritten, err := qb.rewriteSelectItem(item)
+		if err != nil {
+			return err
+		}
+		projection = append(projection, rewritten...)
+	}
+	if len(projection) == 0 {
+		return fmt.Errorf("query validation failed: no visible columns selected")
+	}
+
+	items, err := parseSelectItems(projection)
+	if err != nil {
+		return fmt.Errorf("failed to apply column policies: %w", err)
+	}
+	stmt.SelectItems = items
+	return nil
+}
+
+// rewriteSelectItem returns the projection entries replacing a single SELECT item.
+func (qb *QueryBuilder) rewriteSelectItem(item *clickhouseparser.SelectItem) ([]string, error) {
+	// Stars are expanded into the visible columns.
+	if isStar(item.Expr) {
+		return qb.expandStar(item)
+	}
+	if fn, ok := item.Expr.(*clickhouseparser.FunctionExpr); ok && strings.EqualFold(fn.Name.Name, "COLUMNS") {
+		return nil, fmt.Errorf("query validation failed: COLUMNS() is not allowed on sources with column policies")
+	}
+	if len(item.Modifiers) > 0 {
+		return nil, fmt.Errorf("query validation failed: column modifiers are not allowed on sources with column policies")
+	}
+
+	// A masked column selected directly is replaced by its mask, keeping its name.
+	if name, ok := columnName(item.Expr); ok {
+		if policy, restricted := qb.columnPolicies[strings.ToLower(name)]; restricted {
+			if policy.Action == models.ColumnPolicyHide {
+				return nil, columnPolicyError(name, policy)
+			}
+			alias := name
+			if item.Alias != nil {
+				alias = item.Alias.Name
+			}
+			return []string{fmt.Sprintf("%s AS %s", ColumnMaskExpr(policy), quoteIdentifier(alias))}, nil
+		}
+	}
+
+	// Any other expression must not reference restricted columns. This also covers
+	// subcolumns of restricted columns, e.g. remote_addr.size0.
+	if column, policy := qb.restrictedReference(item.Expr); policy != nil {
+		return nil, columnPolicyError(column, policy)
+	}
+	return []string{item.String()}, nil
+}
+
+// expandStar replaces * (optionally with EXCEPT) with the visible columns of the table.
+func (qb *QueryBuilder) expandStar(item *clickhouseparser.SelectItem) ([]string, error) {
+	if len(qb.columns) == 0 {
+		return nil, fmt.Errorf("query validation failed: table schema unavailable for expanding *")
+	}
+
+	excluded := make(map[string]bool)
+	for _, modifier := range item.Modifiers {
+		if !strings.EqualFold(modifier.Name.Name, "EXCEPT") || modifier.Params == nil || modifier.Params.Items == nil {
+			return nil, fmt.Errorf("query validation failed: only EXCEPT is allowed with * on sources with column policies")
+		}
+		for _, except := range modifier.Params.Items.Items {
+			if columnExpr, ok := except.(*clickhouseparser.ColumnExpr); ok {
+				except = columnExpr.Expr
+			}
+			name, ok := columnName(except)
+			if !ok {
+				return nil, fmt.Errorf("query validation failed: EXCEPT only accepts column names on sources with column policies")
+			}
+			excluded[strings.ToLower(name)] = true
+		}
+	}
+
+	var projection []string
+	for _, col := range qb.columns {
+		if excluded[strings.ToLower(col.Name)] {
+			continue
+		}
+		policy, restricted := qb.columnPolicies[strings.ToLower(col.Name)]
+		switch {
+		case !restricted:
+			projection = append(projection, quoteIdentifier(col.Name))
+		case policy.Action != models.ColumnPolicyHide:
+			projection = append(projection, fmt.Sprintf("%s AS %s", ColumnMaskExpr(policy), quoteIdentifier(col.Name)))
+		}
+	}
+	return projection, nil
+}
+
+// CheckColumnAccess returns an error when a column is hidden or masked by the column policies,
+// for building queries around a column outside of BuildRawQuery, e.g. grouping a histogram.
+func (qb *QueryBuilder) CheckColumnAccess(column string) error {
+	if policy, restricted := qb.columnPolicies[strings.ToLower(column)]; restricted {
+		return columnPolicyError(column, policy)
+	}
+	return nil
+}
+
+// restrictedReference returns the first hidden or masked column referenced within expr.
+func (qb *QueryBuilder) restrictedReference(expr clickhouseparser.Expr) (string, *models.ColumnPolicy) {
+	var (
+		column string
+		found  *models.ColumnPolicy
+	)
+	_ = expr.Accept(&clickhouseparser.DefaultASTVisitor{
+		Visit: func(node clickhouseparser.Expr) error {
+			ident, ok := node.(*clickhouseparser.Ident)
+			if !ok || found != nil {
+				return nil
+			}
+			if policy, restricted := qb.columnPolicies[strings.ToLower(ident.Name)]; restricted {
+				column, found = ident.Name, policy
+			}
+			return nil
+		},
+	})
+	return column, found
+}
+
+// nestedWildcard returns the first *, table.* or COLUMNS() in the query other than a whole
+// SELECT item, which applyColumnPolicies expands or rejects itself, and the argument of count(*).
+func nestedWildcard(stmt *clickhouseparser.SelectQuery) string {
+	allowed := make(map[clickhouseparser.Expr]bool)
+	for _, item := range stmt.SelectItems {
+		allowed[item.Expr] = true
+	}
+	_ = stmt.Accept(&clickhouseparser.DefaultASTVisitor{
+		Visit: func(node clickhouseparser.Expr) error {
+			switch e := node.(type) {
+			case *clickhouseparser.NestedIdentifier:
+				// The * of table.* is checked as part of the qualified name.
+				allowed[e.DotIdent] = true
+			case *clickhouseparser.FunctionExpr:
+				if strings.EqualFold(e.Name.Name, "count") && e.Params != nil && e.Params.Items != nil && len(e.Params.Items.Items) == 1 {
+					arg := e.Params.Items.Items[0]
+					if columnExpr, ok := arg.(*clickhouseparser.ColumnExpr); ok {
+						arg = columnExpr.Expr
+					}
+					allowed[arg] = true
+				}
+			}
+			return nil
+		},
+	})
+
+	var wildcard string
+	_ = stmt.Accept(&clickhouseparser.DefaultASTVisitor{
+		Visit: func(node clickhouseparser.Expr) error {
+			if wildcard != "" || allowed[node] {
+				return nil
+			}
+			switch e := node.(type) {
+			case *clickhouseparser.Ident, *clickhouseparser.NestedIdentifier:
+				if isStar(e) {
+					wildcard = e.String()
+				}
+			case *clickhouseparser.FunctionExpr:
+				if strings.EqualFold(e.Name.Name, "COLUMNS") {
+					wildcard = "COLUMNS()"
+				}
+			}
+			return nil
+		},
+	})
+	return wildcard
+}
+
+// columnName returns the column name of a plain or table-qualified column reference.
+func columnName(expr clickhouseparser.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *clickhouseparser.Ident:
+		return e.Name, e.Name != "*"
+	case *clickhouseparser.NestedIdentifier:
+		if e.DotIdent != nil && e.DotIdent.Name != "*" {
+			return e.DotIdent.Name, true
+		}
+	case *clickhouseparser.ColumnIdentifier:
+		if e.Column != nil {
+			return e.Column.Name, true
+		}
+	}
+	return "", false
+}
+
+// isStar reports whether a SELECT item is * or table.*.
+func isStar(expr clickhouseparser.Expr) bool {
+	switch e := expr.(type) {
+	case *clickhouseparser.Ident:
+		return e.Name == "*"
+	case *clickhouseparser.NestedIdentifier:
+		return e.DotIdent != nil && e.DotIdent.Name == "*"
+	}
+	return false
+}
+
+// parseSelectItems parses projection entries into SELECT items. Escaped quotes are
+// swapped for the placeholder BuildRawQuery restores once the query is rebuilt.
+func parseSelectItems(projection []string) ([]*clickhouseparser.SelectItem, error) {
+	sql := strings.ReplaceAll("SELECT "+strings.Join(projection, ", ")+" FROM __projection", "''", escapedQuotePlaceholder)
+	stmts, err := clickhouseparser.NewParser(sql).ParseStmts()
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("unexpected projection")
+	}
+	stmt, ok := stmts[0].(*clickhouseparser.SelectQuery)
+	if !ok {
+		return nil, fmt.Errorf("unexpected projection")
+	}
+	return stmt.SelectItems, nil
+}
+
+// columnPolicyError describes why a restricted column can't be used.
+func columnPolicyError(column string, policy *models.ColumnPolicy) error {
+	if policy.Action == models.ColumnPolicyHide {
+		return fmt.Errorf("query validation failed: column '%s' is not accessible", column)
+	}
+	return fmt.Errorf("query validation failed: masked column '%s' can only be selected directly", column)
+}
+
+// quoteString returns a single-quoted ClickHouse string literal.
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `''`)
+	return "'" + s + "'"
+}
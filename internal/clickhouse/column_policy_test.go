@@ -0,0 +1,191 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+var policyTestColumns = []models.ColumnInfo{
+	{Name: "timestamp", Type: "DateTime64(3)"},
+	{Name: "level", Type: "String"},
+	{Name: "remote_addr", Type: "String"},
+	{Name: "body", Type: "String"},
+	{Name: "namespace", Type: "String"},
+}
+
+var policyTestPolicies = []*models.ColumnPolicy{
+	{Column: "remote_addr", Action: models.ColumnPolicyHide},
+	{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d+`},
+	{Column: "namespace", Action: models.ColumnPolicyHash},
+}
+
+const (
+	maskedBody      = "replaceRegexpAll(toString(`body`), '\\\\d+', '[REDACTED]') AS `body`"
+	maskedNamespace = "lower(hex(SHA256(toString(`namespace`)))) AS `namespace`"
+)
+
+func TestValidateColumnPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  models.ColumnPolicy
+		wantErr string
+	}{
+		{name: "hide", policy: models.ColumnPolicy{Column: "remote_addr", Action: models.ColumnPolicyHide}},
+		{name: "hash", policy: models.ColumnPolicy{Column: "user_id", Action: models.ColumnPolicyHash}},
+		{name: "redact", policy: models.ColumnPolicy{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d{4}`}},
+		{name: "missing column", policy: models.ColumnPolicy{Column: " ", Action: models.ColumnPolicyHide}, wantErr: "column is required"},
+		{name: "redact without pattern", policy: models.ColumnPolicy{Column: "body", Action: models.ColumnPolicyRedact}, wantErr: "pattern is required"},
+		{name: "invalid pattern", policy: models.ColumnPolicy{Column: "body", Action: models.ColumnPolicyRedact, Pattern: "("}, wantErr: "invalid redact pattern"},
+		{name: "unknown action", policy: models.ColumnPolicy{Column: "body", Action: "drop"}, wantErr: "unknown action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, ValidateColumnPolicy(&tt.policy), tt.wantErr)
+		})
+	}
+}
+
+func TestBuildRawQueryColumnPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "star expanded to visible columns",
+			sql:  "SELECT * FROM db.logs",
+			want: "SELECT `timestamp`, `level`, " + maskedBody + ", " + maskedNamespace + " FROM db.logs",
+		},
+		{
+			name: "star with except",
+			sql:  "SELECT * EXCEPT (body) FROM db.logs",
+			want: "SELECT `timestamp`, `level`, " + maskedNamespace + " FROM db.logs",
+		},
+		{
+			name: "qualified star",
+			sql:  "SELECT l.* FROM db.logs AS l",
+			want: "SELECT `timestamp`, `level`, " + maskedBody + ", " + maskedNamespace + " FROM db.logs AS l",
+		},
+		{
+			name: "masked column selected directly",
+			sql:  "SELECT level, body FROM db.logs",
+			want: "SELECT level, " + maskedBody + " FROM db.logs",
+		},
+		{
+			name: "masked column keeps its alias",
+			sql:  "SELECT body AS b FROM db.logs",
+			want: "SELECT replaceRegexpAll(toString(`body`), '\\\\d+', '[REDACTED]') AS `b` FROM db.logs",
+		},
+		{
+			name: "qualified masked column",
+			sql:  "SELECT l.namespace FROM db.logs AS l",
+			want: "SELECT " + maskedNamespace + " FROM db.logs AS l",
+		},
+		{
+			name: "count star",
+			sql:  "SELECT count(*) AS n, level FROM db.logs GROUP BY level",
+			want: "SELECT count(*) AS n, level FROM db.logs GROUP BY level",
+		},
+		{
+			name: "escaped quotes",
+			sql:  "SELECT body FROM db.logs WHERE level = 'it''s'",
+			want: "SELECT " + maskedBody + " FROM db.logs WHERE level = 'it''s'",
+		},
+		{name: "hidden column", sql: "SELECT remote_addr FROM db.logs", wantErr: "column 'remote_addr' is not accessible"},
+		{name: "quoted hidden column", sql: "SELECT `remote_addr` FROM db.logs", wantErr: "column 'remote_addr' is not accessible"},
+		{name: "qualified hidden column", sql: "SELECT l.remote_addr FROM db.logs AS l", wantErr: "column 'remote_addr' is not accessible"},
+		{name: "subcolumn of hidden column", sql: "SELECT remote_addr.size0 FROM db.logs", wantErr: "column 'remote_addr' is not accessible"},
+		{name: "hidden column in expression", sql: "SELECT toString(remote_addr) FROM db.logs", wantErr: "column 'remote_addr' is not accessible"},
+		{name: "masked column in expression", sql: "SELECT lower(body) FROM db.logs", wantErr: "masked column 'body' can only be selected directly"},
+		{name: "masked column in where", sql: "SELECT level FROM db.logs WHERE body LIKE '%4111%'", wantErr: "masked column 'body'"},
+		{name: "masked column in group by", sql: "SELECT level FROM db.logs GROUP BY namespace", wantErr: "masked column 'namespace'"},
+		{name: "star in function", sql: "SELECT toString(tuple(*)) FROM db.logs", wantErr: "* is only allowed as a whole SELECT item"},
+		{name: "qualified star in function", sql: "SELECT tuple(l.*) FROM db.logs AS l", wantErr: "l.* is only allowed as a whole SELECT item"},
+		{name: "columns in function", sql: "SELECT tuple(COLUMNS('remote')) FROM db.logs", wantErr: "COLUMNS() is only allowed as a whole SELECT item"},
+		{name: "star in lambda arguments", sql: "SELECT arrayMap(x -> x, [*]) FROM db.logs", wantErr: "* is only allowed as a whole SELECT item"},
+		{name: "star in count argument expression", sql: "SELECT count(tuple(*)) FROM db.logs", wantErr: "* is only allowed as a whole SELECT item"},
+		{name: "star in where", sql: "SELECT level FROM db.logs WHERE cityHash64(*) > 0", wantErr: "* is only allowed as a whole SELECT item"},
+		{name: "star in order by", sql: "SELECT level FROM db.logs ORDER BY tuple(*)", wantErr: "* is only allowed as a whole SELECT item"},
+		{name: "columns item", sql: "SELECT COLUMNS('remote') FROM db.logs", wantErr: "COLUMNS() is not allowed"},
+		{name: "star with apply", sql: "SELECT * APPLY(toString) FROM db.logs", wantErr: "only EXCEPT is allowed with *"},
+		{name: "subquery", sql: "SELECT level FROM db.logs WHERE level IN (SELECT level FROM db.logs)", wantErr: "subqueries and UNION are not allowed"},
+		{name: "with clause", sql: "WITH 1 AS x SELECT level FROM db.logs", wantErr: "WITH clauses are not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := NewQueryBuilder("db.logs").WithColumnPolicies(policyTestColumns, policyTestPolicies)
+			got, err := qb.BuildRawQuery(tt.sql, 0)
+			checkError(t, err, tt.wantErr)
+			if err == nil && got != tt.want {
+				t.Errorf("BuildRawQuery(%q)\n got: %s\nwant: %s", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckColumnAccess(t *testing.T) {
+	qb := NewQueryBuilder("db.logs").WithColumnPolicies(policyTestColumns, policyTestPolicies)
+	checkError(t, qb.CheckColumnAccess("level"), "")
+	checkError(t, qb.CheckColumnAccess("Remote_Addr"), "is not accessible")
+	checkError(t, qb.CheckColumnAccess("namespace"), "can only be selected directly")
+}
+
+func TestBuildRawQueryRowFiltersWithColumnPolicies(t *testing.T) {
+	const filteredLogs = "(SELECT `timestamp`, `level`, `remote_addr`, `body`, `namespace` FROM db.logs WHERE (namespace = 'payments'))"
+	tests := []struct {
+		name    string
+		filter  string
+		sql     string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "filter on an unrestricted column stays in where",
+			filter: "level = 'error'",
+			sql:    "SELECT * FROM db.logs",
+			want:   "SELECT `timestamp`, `level`, " + maskedBody + ", " + maskedNamespace + " FROM db.logs WHERE (level = 'error')",
+		},
+		{
+			name:   "filter on a hidden column stays in where",
+			filter: "remote_addr = '10.0.0.1'",
+			sql:    "SELECT level FROM db.logs",
+			want:   "SELECT level FROM db.logs WHERE (remote_addr = '10.0.0.1')",
+		},
+		{
+			name:   "filter on a masked column applies to the raw column",
+			filter: "namespace = 'payments'",
+			sql:    "SELECT * FROM db.logs WHERE level = 'error' OR 1=1",
+			want:   "SELECT `timestamp`, `level`, " + maskedBody + ", " + maskedNamespace + " FROM " + filteredLogs + " AS `logs` WHERE level = 'error' OR 1 = 1",
+		},
+		{
+			name:   "table alias kept for qualified names",
+			filter: "namespace = 'payments'",
+			sql:    "SELECT l.namespace, l.level FROM db.logs AS l WHERE l.level = 'x'",
+			want:   "SELECT " + maskedNamespace + ", l.level FROM " + filteredLogs + " AS `l` WHERE l.level = 'x'",
+		},
+		{
+			name:   "final moves into the subquery and prewhere into where",
+			filter: "namespace = 'payments'",
+			sql:    "SELECT level FROM db.logs FINAL PREWHERE level = 'x' WHERE timestamp > now()",
+			want:   "SELECT level FROM (SELECT `timestamp`, `level`, `remote_addr`, `body`, `namespace` FROM db.logs FINAL WHERE (namespace = 'payments')) AS `logs` WHERE (timestamp > now()) AND (level = 'x')",
+		},
+		{
+			name:    "user alias shadowing the filtered column",
+			filter:  "namespace = 'payments'",
+			sql:     "SELECT level AS namespace FROM db.logs",
+			wantErr: "alias 'namespace' is not allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := NewQueryBuilder("db.logs").WithColumnPolicies(policyTestColumns, policyTestPolicies).WithRowFilters(tt.filter)
+			got, err := qb.BuildRawQuery(tt.sql, 0)
+			checkError(t, err, tt.wantErr)
+			if err == nil && got != tt.want {
+				t.Errorf("BuildRawQuery(%q)\n got: %s\nwant: %s", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
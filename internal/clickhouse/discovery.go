@@ -0,0 +1,138 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// systemDatabasesFilter excludes the ClickHouse system databases from discovery queries.
+const systemDatabasesFilter = `NOT IN ('system', 'INFORMATION_SCHEMA', 'information_schema')`
+
+// DiscoveredTable describes a table found on a ClickHouse server, with its columns and
+// the size of its active parts.
+type DiscoveredTable struct {
+	Database    string              `json:"database"`
+	Name        string              `json:"name"`
+	Engine      string              `json:"engine"`
+	SortKeys    []string            `json:"sort_keys"`
+	Rows        uint64              `json:"rows"`          // Rows in active parts.
+	BytesOnDisk uint64              `json:"bytes_on_disk"` // Compressed size of active parts.
+	Size        string              `json:"size"`          // BytesOnDisk, human-readable.
+	Columns     []models.ColumnInfo `json:"columns"`
+}
+
+// ListDatabases returns the names of the non-system databases on the server.
+func (c *Client) ListDatabases(ctx context.Context) ([]string, error) {
+	query := `SELECT name FROM system.databases WHERE name ` + systemDatabasesFilter + ` ORDER BY name`
+
+	var rows driver.Rows
+	var err error
+	err = c.executeQueryWithHooks(ctx, query, func(hookCtx context.Context) error {
+		rows, err = c.conn.Query(hookCtx, query)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query databases: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan database: %w", err)
+		}
+		databases = append(databases, name)
+	}
+	return databases, rows.Err()
+}
+
+// DiscoverTables lists the tables of a database, or of every non-system database when
+// database is empty, with their engines, sizes (as TableStats computes them) and columns.
+func (c *Client) DiscoverTables(ctx context.Context, database string) ([]*DiscoveredTable, error) {
+	filter := "database " + systemDatabasesFilter
+	var args []any
+	if database != "" {
+		filter = "database = ?"
+		args = append(args, database)
+	}
+
+	query := `
+		SELECT
+			t.database,
+			t.name,
+			t.engine,
+			t.sorting_key,
+			ifNull(p.part_rows, 0) AS rows,
+			ifNull(p.part_bytes, 0) AS bytes,
+			formatReadableSize(bytes) AS size
+		FROM system.tables AS t
+		LEFT JOIN (
+			SELECT database, table, sum(rows) AS part_rows, sum(data_compressed_bytes) AS part_bytes
+			FROM system.parts
+			WHERE active = 1
+			GROUP BY database, table
+		) AS p ON t.database = p.database AND t.name = p.table
+		WHERE t.` + filter + ` AND NOT t.is_temporary
+		ORDER BY t.database, t.name
+	`
+
+	var rows driver.Rows
+	var err error
+	err = c.executeQueryWithHooks(ctx, query, func(hookCtx context.Context) error {
+		rows, err = c.conn.Query(hookCtx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []*DiscoveredTable
+	byName := make(map[string]*DiscoveredTable)
+	for rows.Next() {
+		table := &DiscoveredTable{}
+		var sortingKey string
+		if err := rows.Scan(&table.Database, &table.Name, &table.Engine, &sortingKey, &table.Rows, &table.BytesOnDisk, &table.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		table.SortKeys = parseSortKeys(sortingKey)
+		tables = append(tables, table)
+		byName[table.Database+"."+table.Name] = table
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tables: %w", err)
+	}
+	rows.Close()
+
+	// Fetch the columns of all the tables at once rather than per table.
+	columnsQuery := `
+		SELECT database, table, name, type
+		FROM system.columns
+		WHERE ` + filter + `
+		ORDER BY database, table, position
+	`
+	err = c.executeQueryWithHooks(ctx, columnsQuery, func(hookCtx context.Context) error {
+		rows, err = c.conn.Query(hookCtx, columnsQuery, args...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var db, tableName string
+		var col models.ColumnInfo
+		if err := rows.Scan(&db, &tableName, &col.Name, &col.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		if table, ok := byName[db+"."+tableName]; ok {
+			table.Columns = append(table.Columns, col)
+		}
+	}
+	return tables, rows.Err()
+}
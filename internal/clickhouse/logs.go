@@ -16,6 +16,10 @@ type LogQueryParams struct {
 	RawSQL string
 	// Query execution timeout in seconds. If not specified, uses default timeout.
 	QueryTimeout *int
+	// Parameters bound to {name:Type} placeholders in RawSQL.
+	Parameters map[string]string
+	// NoCache bypasses cached results, running the query and refreshing its cached result.
+	NoCache bool
 }
 
 // LogQueryResult represents the structured result of a log query.
@@ -71,7 +75,7 @@ type LogContextResult struct {
 type HistogramParams struct {
 	Window   TimeWindow
 	Query    string // Raw SQL query to use as base for histogram
-	GroupBy  string // Optional: Field to group by for segmented histograms.
+	GroupBy  string // Optional: Column to group by for segmented histograms.
 	Timezone string // Optional: Timezone identifier for time-based operations.
 	// Query execution timeout in seconds. If not specified, uses default timeout.
 	QueryTimeout *int
@@ -110,30 +114,35 @@ func (c *Client) GetHistogramData(ctx context.Context, tableName, timestampField
 	if timezone == "" {
 		timezone = "UTC"
 	}
+	// The timezone ends up in the query, so only known zones are accepted.
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("invalid timezone: %q", timezone)
+	}
+	tz := quoteString(timezone)
 
 	// Convert TimeWindow to the appropriate ClickHouse interval function
 	var intervalFunc string
 	switch params.Window {
 	case TimeWindow1s:
-		intervalFunc = fmt.Sprintf("toStartOfSecond(%s, '%s')", timestampField, timezone)
+		intervalFunc = fmt.Sprintf("toStartOfSecond(%s, %s)", timestampField, tz)
 	case TimeWindow5s, TimeWindow10s, TimeWindow15s, TimeWindow30s:
 		// For custom second intervals, use toStartOfInterval
 		seconds := strings.TrimSuffix(string(params.Window), "s")
-		intervalFunc = fmt.Sprintf("toStartOfInterval(%s, INTERVAL %s SECOND, '%s')", timestampField, seconds, timezone)
+		intervalFunc = fmt.Sprintf("toStartOfInterval(%s, INTERVAL %s SECOND, %s)", timestampField, seconds, tz)
 	case TimeWindow1m:
-		intervalFunc = fmt.Sprintf("toStartOfMinute(%s, '%s')", timestampField, timezone)
+		intervalFunc = fmt.Sprintf("toStartOfMinute(%s, %s)", timestampField, tz)
 	case TimeWindow5m:
-		intervalFunc = fmt.Sprintf("toStartOfFiveMinute(%s, '%s')", timestampField, timezone)
+		intervalFunc = fmt.Sprintf("toStartOfFiveMinute(%s, %s)", timestampField, tz)
 	case TimeWindow10m, TimeWindow15m, TimeWindow30m:
 		// For custom minute intervals, use toStartOfInterval
 		minutes := strings.TrimSuffix(string(params.Window), "m")
-		intervalFunc = fmt.Sprintf("toStartOfInterval(%s, INTERVAL %s MINUTE, '%s')", timestampField, minutes, timezone)
+		intervalFunc = fmt.Sprintf("toStartOfInterval(%s, INTERVAL %s MINUTE, %s)", timestampField, minutes, tz)
 	case TimeWindow1h:
-		intervalFunc = fmt.Sprintf("toStartOfHour(%s, '%s')", timestampField, timezone)
+		intervalFunc = fmt.Sprintf("toStartOfHour(%s, %s)", timestampField, tz)
 	case TimeWindow2h, TimeWindow3h, TimeWindow6h, TimeWindow12h, TimeWindow24h:
 		// For custom hour intervals, use toStartOfInterval
 		hours := strings.TrimSuffix(string(params.Window), "h")
-		intervalFunc = fmt.Sprintf("toStartOfInterval(%s, INTERVAL %s HOUR, '%s')", timestampField, hours, timezone)
+		intervalFunc = fmt.Sprintf("toStartOfInterval(%s, INTERVAL %s HOUR, %s)", timestampField, hours, tz)
 	default:
 		return nil, fmt.Errorf("invalid time window: %s", params.Window)
 	}
@@ -176,7 +185,9 @@ func (c *Client) GetHistogramData(ctx context.Context, tableName, timestampField
 	// Construct the histogram query using CTE
 	var query string
 	if params.GroupBy != "" && strings.TrimSpace(params.GroupBy) != "" {
-		// Histogram with grouping - find top N groups
+		// Histogram with grouping - find top N groups. The group is a single column,
+		// quoted since it's pasted into the query.
+		groupBy := quoteIdentifier(strings.TrimSpace(params.GroupBy))
 		// Ensure timestamp field is available in subquery for histogram bucketing
 		modifiedQuery, err := c.ensureTimestampInQuery(baseQuery, timestampField)
 		if err != nil {
@@ -206,7 +217,7 @@ func (c *Client) GetHistogramData(ctx context.Context, tableName, timestampField
 			ORDER BY
 				bucket ASC,
 				log_count DESC
-		`, params.GroupBy, modifiedQuery, intervalFunc, params.GroupBy, modifiedQuery, params.GroupBy)
+		`, groupBy, modifiedQuery, intervalFunc, groupBy, modifiedQuery, groupBy)
 	} else {
 		// Standard histogram without grouping
 		// Ensure timestamp field is available in subquery for histogram bucketing
@@ -327,3 +338,17 @@ func (c *Client) ensureTimestampInQuery(query, timestampField string) (string, e
 	return query, nil
 }
 
+// DistinctValuesQuery returns a query for the most frequent distinct values of a
+// column within a time range, e.g. to offer them as options for a saved query variable.
+func DistinctValuesQuery(tableName, column, timestampField string, start, end time.Time, limit int) string {
+	const layout = "2006-01-02 15:04:05.000"
+	return fmt.Sprintf(
+		"SELECT %s AS value, count() AS cnt FROM %s WHERE %s BETWEEN toDateTime64('%s', 3, 'UTC') AND toDateTime64('%s', 3, 'UTC') GROUP BY value ORDER BY cnt DESC, value ASC LIMIT %d",
+		quoteIdentifier(column),
+		tableName,
+		quoteIdentifier(timestampField),
+		start.UTC().Format(layout),
+		end.UTC().Format(layout),
+		limit,
+	)
+}
@@ -14,11 +14,18 @@ import (
 
 // Default values
 const (
-	DefaultQueryLimit          = 100
-	HealthCheckTimeout         = 1 * time.Second // Reduce to 1 second for faster health checks
-	DefaultHealthCheckInterval = 30 * time.Second
+	DefaultQueryLimit             = 100
+	HealthCheckTimeout            = 1 * time.Second // Reduce to 1 second for faster health checks
+	DefaultHealthCheckInterval    = 30 * time.Second
+	DefaultSchemaSnapshotInterval = 10 * time.Minute
+	SchemaSnapshotTimeout         = 10 * time.Second
 )
 
+// SchemaObserver receives the table schema of a source snapshotted by the health checks.
+type SchemaObserver interface {
+	ObserveSchema(ctx context.Context, sourceID models.SourceID, snapshot *models.SchemaSnapshot)
+}
+
 // Manager handles pooling and management of multiple ClickHouse client connections,
 // one per data source. It also manages query hooks and background health checks.
 type Manager struct {
@@ -30,6 +37,11 @@ type Manager struct {
 	hooks      []QueryHook    // Hooks applied to all managed clients.
 	stopHealth chan struct{}  // Channel to signal health check goroutine to stop.
 	healthWG   sync.WaitGroup // WaitGroup to wait for health check goroutine to exit.
+
+	schemaObserver SchemaObserver                // Receives schema snapshots, if set.
+	schemaInterval time.Duration                 // Minimum time between two snapshots of a source.
+	snapshots      map[models.SourceID]time.Time // Time of the last snapshot of each source.
+	snapshotsMux   sync.Mutex                    // Protects the snapshots map.
 }
 
 // NewManager creates a new ClickHouse connection manager.
@@ -40,6 +52,7 @@ func NewManager(log *slog.Logger) *Manager {
 		health:     make(map[models.SourceID]models.SourceHealth),
 		hooks:      []QueryHook{}, // Initialize empty slice.
 		stopHealth: make(chan struct{}),
+		snapshots:  make(map[models.SourceID]time.Time),
 	}
 
 	// Apply default hooks for basic logging.
@@ -88,6 +101,108 @@ func (m *Manager) StopBackgroundHealthChecks() {
 	close(m.stopHealth)
 }
 
+// SetSchemaObserver makes the health checks snapshot the table schema of each healthy source
+// at most once per interval and pass it to the observer. A zero interval uses the default,
+// a negative one disables snapshots. It must be called before StartBackgroundHealthChecks.
+func (m *Manager) SetSchemaObserver(observer SchemaObserver, interval time.Duration) {
+	if interval < 0 {
+		m.logger.Info("schema snapshots disabled")
+		return
+	}
+	if interval == 0 {
+		interval = DefaultSchemaSnapshotInterval
+	}
+	m.schemaObserver = observer
+	m.schemaInterval = interval
+}
+
+// snapshotDue reports whether the schema of a source should be snapshotted now, and if so
+// records the snapshot time so concurrent checks of the same source don't take it twice.
+func (m *Manager) snapshotDue(sourceID models.SourceID) bool {
+	if m.schemaObserver == nil {
+		return false
+	}
+	m.snapshotsMux.Lock()
+	defer m.snapshotsMux.Unlock()
+
+	if last, ok := m.snapshots[sourceID]; ok && time.Since(last) < m.schemaInterval {
+		return false
+	}
+	m.snapshots[sourceID] = time.Now()
+	return true
+}
+
+// snapshotSchema reads the table schema of a healthy source and passes it to the schema observer.
+func (m *Manager) snapshotSchema(sourceID models.SourceID, client *Client) {
+	if client.source == nil || !m.snapshotDue(sourceID) {
+		return
+	}
+
+	if err := m.takeSchemaSnapshot(context.Background(), sourceID, client); err != nil {
+		m.logger.Warn("failed to snapshot source schema", "source_id", sourceID, "error", err)
+	}
+}
+
+// takeSchemaSnapshot reads the table schema of a source and passes it to the schema observer.
+// On failure the snapshot time is forgotten so the next health check tries again rather than
+// waiting a full interval.
+func (m *Manager) takeSchemaSnapshot(ctx context.Context, sourceID models.SourceID, client *Client) error {
+	ctx, cancel := context.WithTimeout(ctx, SchemaSnapshotTimeout)
+	defer cancel()
+
+	info, err := client.GetTableInfo(ctx, client.source.Connection.Database, client.source.Connection.TableName)
+	if err != nil {
+		m.snapshotsMux.Lock()
+		delete(m.snapshots, sourceID)
+		m.snapshotsMux.Unlock()
+		return err
+	}
+
+	m.schemaObserver.ObserveSchema(ctx, sourceID, &models.SchemaSnapshot{
+		SourceID: sourceID,
+		Columns:  info.Columns,
+		SortKeys: info.SortKeys,
+		TakenAt:  time.Now(),
+	})
+	return nil
+}
+
+// RefreshSchemas snapshots the table schema of every healthy source now, whatever the snapshot
+// interval, and passes it to the schema observer. Returns the number of sources snapshotted.
+// It does nothing when schema snapshots are disabled.
+func (m *Manager) RefreshSchemas(ctx context.Context) (int, error) {
+	if m.schemaObserver == nil {
+		return 0, nil
+	}
+
+	m.clientsMux.RLock()
+	clients := make(map[models.SourceID]*Client, len(m.clients))
+	for id, client := range m.clients {
+		clients[id] = client
+	}
+	m.clientsMux.RUnlock()
+
+	refreshed := 0
+	var errs []error
+	for sourceID, client := range clients {
+		if client.source == nil || m.GetCachedHealth(sourceID).Status != models.HealthStatusHealthy {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return refreshed, err
+		}
+		m.snapshotsMux.Lock()
+		m.snapshots[sourceID] = time.Now()
+		m.snapshotsMux.Unlock()
+		if err := m.takeSchemaSnapshot(ctx, sourceID, client); err != nil {
+			errs = append(errs, fmt.Errorf("source %d: %w", sourceID, err))
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, errors.Join(errs...)
+}
+
 // checkAllSourcesHealth iterates through managed clients and updates their health status.
 func (m *Manager) checkAllSourcesHealth() {
 	m.clientsMux.RLock() // Lock clients map for reading.
@@ -192,10 +307,12 @@ func (m *Manager) checkSource(sourceID models.SourceID) {
 			// Reconnection successful
 			m.logger.Info("successfully reconnected to source", "source_id", sourceID)
 			m.updateHealthStatus(sourceID, true, "")
+			m.snapshotSchema(sourceID, client)
 		}
 	} else {
 		// Connection is healthy after ping
 		m.updateHealthStatus(sourceID, true, "")
+		m.snapshotSchema(sourceID, client)
 	}
 }
 
@@ -303,6 +420,10 @@ func (m *Manager) RemoveSource(sourceID models.SourceID) error {
 	delete(m.health, sourceID) // Remove health status.
 	m.healthMux.Unlock()
 
+	m.snapshotsMux.Lock()
+	delete(m.snapshots, sourceID) // Take a new snapshot if the source is added back.
+	m.snapshotsMux.Unlock()
+
 	if exists && client != nil {
 		if err := client.Close(); err != nil {
 			m.logger.Error("error closing client during removal",
@@ -0,0 +1,111 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// MapKeysParams controls how keys of a Map column are discovered.
+// Discovery only looks at a bounded sample of recent rows to keep it cheap on large tables.
+type MapKeysParams struct {
+	Column         string        // Map column to inspect, e.g. "log_attributes".
+	TimestampField string        // Timestamp column used to restrict the sample to recent data.
+	Filter         string        // Optional row-level filter expression restricting the sampled rows.
+	Lookback       time.Duration // How far back from now() to sample rows.
+	SampleRows     int           // Maximum number of rows read for the sample.
+	MaxKeys        int           // Maximum number of keys returned, ordered by frequency.
+	MaxExamples    int           // Maximum number of example values returned per key.
+}
+
+// MapKeyStat describes a single key found in a Map column.
+type MapKeyStat struct {
+	Key      string   `json:"key"`
+	Count    uint64   `json:"count"`    // Number of sampled rows containing the key.
+	Examples []string `json:"examples"` // A few distinct example values for the key.
+}
+
+// IsMapType reports whether a ClickHouse type string is a Map type.
+func IsMapType(columnType string) bool {
+	return strings.HasPrefix(strings.TrimSpace(columnType), "Map(")
+}
+
+// GetMapKeys samples recent rows of a table and returns the most frequent keys
+// of the given Map column along with example values.
+func (c *Client) GetMapKeys(ctx context.Context, tableName string, params MapKeysParams) ([]MapKeyStat, error) {
+	if params.Column == "" {
+		return nil, fmt.Errorf("map column is required")
+	}
+	if params.TimestampField == "" {
+		return nil, fmt.Errorf("timestamp field is required")
+	}
+	if params.Lookback <= 0 || params.SampleRows <= 0 || params.MaxKeys <= 0 || params.MaxExamples <= 0 {
+		return nil, fmt.Errorf("invalid map key discovery bounds")
+	}
+
+	var filterClause string
+	if strings.TrimSpace(params.Filter) != "" {
+		if err := ValidateRowFilter(params.Filter); err != nil {
+			return nil, err
+		}
+		filterClause = fmt.Sprintf(" AND (%s)", params.Filter)
+	}
+
+	// The inner query bounds the scan by time and row count; keys are expanded
+	// with ARRAY JOIN so that each key/value pair becomes its own row.
+	query := fmt.Sprintf(`
+		SELECT
+			key,
+			count() AS cnt,
+			groupUniqArray(%d)(toString(value)) AS examples
+		FROM (
+			SELECT %s AS attrs
+			FROM %s
+			WHERE %s >= now() - INTERVAL %d SECOND%s
+			LIMIT %d
+		)
+		ARRAY JOIN mapKeys(attrs) AS key, mapValues(attrs) AS value
+		GROUP BY key
+		ORDER BY cnt DESC, key ASC
+		LIMIT %d
+	`,
+		params.MaxExamples,
+		quoteIdentifier(params.Column),
+		tableName,
+		quoteIdentifier(params.TimestampField),
+		int64(params.Lookback.Seconds()),
+		filterClause,
+		params.SampleRows,
+		params.MaxKeys,
+	)
+
+	var rows driver.Rows
+	var err error
+
+	err = c.executeQueryWithHooks(ctx, query, func(hookCtx context.Context) error {
+		rows, err = c.conn.Query(hookCtx, query)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query map keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]MapKeyStat, 0)
+	for rows.Next() {
+		var stat MapKeyStat
+		if err := rows.Scan(&stat.Key, &stat.Count, &stat.Examples); err != nil {
+			return nil, fmt.Errorf("failed to scan map key: %w", err)
+		}
+		keys = append(keys, stat)
+	}
+	return keys, rows.Err()
+}
+
+// quoteIdentifier wraps a column identifier in backticks, escaping any embedded backticks.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
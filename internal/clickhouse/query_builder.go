@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	clickhouseparser "github.com/AfterShip/clickhouse-sql-parser/parser"
+	"github.com/mr-karan/logchef/pkg/models"
 )
 
 // QueryBuilder assists in building and validating ClickHouse SQL queries.
@@ -12,8 +13,18 @@ type QueryBuilder struct {
 	// tableName is the fully qualified table name (e.g., "database.table")
 	// used for validation and as the default target in generated queries.
 	tableName string
+	// rowFilters are mandatory boolean expressions AND-ed into the WHERE clause
+	// of every query built, e.g. a team's row-level access filter.
+	rowFilters []string
+	// columns is the table schema, used to expand * when column policies apply.
+	columns []models.ColumnInfo
+	// columnPolicies hide or mask columns, keyed by lowercase column name.
+	columnPolicies map[string]*models.ColumnPolicy
 }
 
+// escapedQuotePlaceholder temporarily replaces doubled single quotes, which the parser might misinterpret.
+const escapedQuotePlaceholder = "___ESCAPED_QUOTE___"
+
 // NewQueryBuilder creates a new QueryBuilder for a specific table.
 func NewQueryBuilder(tableName string) *QueryBuilder {
 	return &QueryBuilder{
@@ -21,12 +32,28 @@ func NewQueryBuilder(tableName string) *QueryBuilder {
 	}
 }
 
-// BuildRawQuery parses, validates, potentially modifies (adds LIMIT),
+// WithRowFilters adds mandatory filter expressions that BuildRawQuery injects into
+// the WHERE clause of every query. Empty expressions are ignored.
+func (qb *QueryBuilder) WithRowFilters(filters ...string) *QueryBuilder {
+	for _, filter := range filters {
+		if strings.TrimSpace(filter) != "" {
+			qb.rowFilters = append(qb.rowFilters, filter)
+		}
+	}
+	return qb
+}
+
+// HasRestrictions reports whether the builder enforces row filters or column policies.
+func (qb *QueryBuilder) HasRestrictions() bool {
+	return len(qb.rowFilters) > 0 || len(qb.columnPolicies) > 0
+}
+
+// BuildRawQuery parses, validates, potentially modifies (adds LIMIT, column policies, row filters),
 // and reconstructs a raw SQL query string.
 func (qb *QueryBuilder) BuildRawQuery(rawSQL string, limit int) (string, error) {
 	// Preprocess SQL to handle escaped single quotes ('') which the parser might misinterpret.
 	// Replace them with a temporary placeholder.
-	const placeholder = "___ESCAPED_QUOTE___"
+	const placeholder = escapedQuotePlaceholder
 	processedSQL := strings.ReplaceAll(rawSQL, "''", placeholder)
 
 	parser := clickhouseparser.NewParser(processedSQL)
@@ -62,6 +89,22 @@ func (qb *QueryBuilder) BuildRawQuery(rawSQL string, limit int) (string, error)
 		return "", err
 	}
 
+	// Check the query can't evade the row-level filters before column policies add aliases.
+	filterExprs, filterIdents, err := qb.checkRowFilters(selectQuery)
+	if err != nil {
+		return "", err
+	}
+
+	// Drop hidden columns and mask restricted ones in the projection.
+	if err := qb.applyColumnPolicies(selectQuery); err != nil {
+		return "", err
+	}
+
+	// Inject mandatory row-level filters into the WHERE clause.
+	if err := qb.applyRowFilters(selectQuery, filterExprs, filterIdents); err != nil {
+		return "", err
+	}
+
 	// Ensure a LIMIT clause exists if a positive limit is provided.
 	if limit > 0 {
 		qb.ensureLimit(selectQuery, limit)
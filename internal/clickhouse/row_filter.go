@@ -0,0 +1,252 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+
+	clickhouseparser "github.com/AfterShip/clickhouse-sql-parser/parser"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// ValidateRowFilter checks that a row-level filter is a single boolean expression
+// that can be safely injected into a WHERE clause.
+func ValidateRowFilter(filter string) error {
+	_, _, err := parseRowFilter(strings.ReplaceAll(filter, "''", escapedQuotePlaceholder))
+	return err
+}
+
+// parseRowFilter parses a filter expression by embedding it in a dummy SELECT.
+// It returns the expression node and the identifiers it references.
+func parseRowFilter(filter string) (clickhouseparser.Expr, map[string]bool, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil, fmt.Errorf("row filter is empty")
+	}
+
+	stmts, err := clickhouseparser.NewParser("SELECT 1 FROM __filter WHERE " + filter).ParseStmts()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid row filter syntax: %w", err)
+	}
+	if len(stmts) != 1 {
+		return nil, nil, fmt.Errorf("row filter must be a single expression")
+	}
+	stmt, ok := stmts[0].(*clickhouseparser.SelectQuery)
+	if !ok || stmt.Where == nil {
+		return nil, nil, fmt.Errorf("row filter must be a single expression")
+	}
+	// Anything parsed beyond the WHERE expression means the filter tried to extend the query.
+	if stmt.GroupBy != nil || stmt.Having != nil || stmt.OrderBy != nil || stmt.Limit != nil ||
+		stmt.LimitBy != nil || stmt.Settings != nil || stmt.Format != nil || stmt.Window != nil ||
+		stmt.UnionAll != nil || stmt.UnionDistinct != nil || stmt.Except != nil {
+		return nil, nil, fmt.Errorf("row filter must be a single expression")
+	}
+	if countSelects(stmt) > 1 {
+		return nil, nil, fmt.Errorf("row filter must not contain subqueries")
+	}
+
+	idents := make(map[string]bool)
+	_ = stmt.Where.Expr.Accept(&clickhouseparser.DefaultASTVisitor{
+		Visit: func(expr clickhouseparser.Expr) error {
+			if ident, ok := expr.(*clickhouseparser.Ident); ok {
+				idents[strings.ToLower(ident.Name)] = true
+			}
+			return nil
+		},
+	})
+
+	return stmt.Where.Expr, idents, nil
+}
+
+// checkRowFilters parses the builder's row filters and rejects constructs in the query that
+// could evade them: nested SELECTs (subqueries, UNION, CTEs), WITH clauses and aliases that
+// shadow a column used by a filter. It checks the query as written, before column policies
+// rewrite the projection. It returns the parsed filters and the columns they reference.
+func (qb *QueryBuilder) checkRowFilters(stmt *clickhouseparser.SelectQuery) ([]clickhouseparser.Expr, map[string]bool, error) {
+	if len(qb.rowFilters) == 0 {
+		return nil, nil, nil
+	}
+
+	if stmt.With != nil {
+		return nil, nil, fmt.Errorf("query validation failed: WITH clauses are not allowed on sources with row-level filters")
+	}
+	if countSelects(stmt) > 1 {
+		return nil, nil, fmt.Errorf("query validation failed: subqueries and UNION are not allowed on sources with row-level filters")
+	}
+
+	filterExprs := make([]clickhouseparser.Expr, 0, len(qb.rowFilters))
+	filterIdents := make(map[string]bool)
+	for _, filter := range qb.rowFilters {
+		expr, idents, err := parseRowFilter(strings.ReplaceAll(filter, "''", escapedQuotePlaceholder))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid row-level filter configured for this source: %w", err)
+		}
+		filterExprs = append(filterExprs, expr)
+		for ident := range idents {
+			filterIdents[ident] = true
+		}
+	}
+
+	// In ClickHouse, SELECT aliases are visible in WHERE and take precedence over columns,
+	// so an alias named like a filtered column would turn the filter into a no-op.
+	for _, alias := range collectAliases(stmt) {
+		if filterIdents[strings.ToLower(alias)] {
+			return nil, nil, fmt.Errorf("query validation failed: alias '%s' is not allowed on this source", alias)
+		}
+	}
+	return filterExprs, filterIdents, nil
+}
+
+// applyRowFilters AND-s the row filters returned by checkRowFilters into the WHERE clause of
+// the query. Masked columns are selected under their own name, so when a filter references one,
+// the mask's alias would shadow the column in WHERE; the filters are then applied to the raw
+// columns in a subquery replacing the table instead.
+func (qb *QueryBuilder) applyRowFilters(stmt *clickhouseparser.SelectQuery, filterExprs []clickhouseparser.Expr, filterIdents map[string]bool) error {
+	if len(filterExprs) == 0 {
+		return nil
+	}
+
+	for ident := range filterIdents {
+		if policy, restricted := qb.columnPolicies[ident]; restricted && policy.Action != models.ColumnPolicyHide {
+			return qb.filterTable(stmt, filterExprs)
+		}
+	}
+
+	stmt.Where = &clickhouseparser.WhereClause{Expr: andExprs(whereExpr(stmt), filterExprs)}
+	return nil
+}
+
+// filterTable replaces the table of the query with a subquery selecting the table's columns
+// filtered by filterExprs, under the table's alias or name so qualified references still resolve.
+// FINAL and SAMPLE move into the subquery, and PREWHERE, which subqueries don't support, into WHERE.
+func (qb *QueryBuilder) filterTable(stmt *clickhouseparser.SelectQuery, filterExprs []clickhouseparser.Expr) error {
+	var (
+		joinTable *clickhouseparser.JoinTableExpr
+		tableID   *clickhouseparser.TableIdentifier
+		alias     string
+	)
+	if stmt.From != nil {
+		joinTable, _ = stmt.From.Expr.(*clickhouseparser.JoinTableExpr)
+	}
+	if joinTable != nil && joinTable.Table != nil {
+		tableExpr := joinTable.Table.Expr
+		if aliasExpr, ok := tableExpr.(*clickhouseparser.AliasExpr); ok {
+			tableExpr = aliasExpr.Expr
+			alias = strings.Trim(aliasExpr.Alias.String(), "`\"")
+		}
+		tableID, _ = tableExpr.(*clickhouseparser.TableIdentifier)
+	}
+	if tableID == nil || tableID.Table == nil {
+		return fmt.Errorf("query validation failed: could not identify table in FROM clause")
+	}
+	if alias == "" {
+		alias = tableID.Table.Name
+	}
+	if len(qb.columns) == 0 {
+		return fmt.Errorf("query validation failed: table schema unavailable for applying row-level filters")
+	}
+
+	columns := make([]string, len(qb.columns))
+	for i, col := range qb.columns {
+		columns[i] = quoteIdentifier(col.Name)
+	}
+	table := &clickhouseparser.JoinTableExpr{
+		Table:       &clickhouseparser.TableExpr{Expr: tableID, HasFinal: joinTable.Table.HasFinal},
+		SampleRatio: joinTable.SampleRatio,
+		HasFinal:    joinTable.HasFinal,
+	}
+	sql := fmt.Sprintf("SELECT 1 FROM (SELECT %s FROM %s WHERE %s) AS %s",
+		strings.Join(columns, ", "), table.String(), andExprs(nil, filterExprs).String(), quoteIdentifier(alias))
+	stmts, err := clickhouseparser.NewParser(sql).ParseStmts()
+	if err != nil {
+		return fmt.Errorf("failed to apply row-level filters: %w", err)
+	}
+	filtered, ok := stmts[0].(*clickhouseparser.SelectQuery)
+	if len(stmts) != 1 || !ok {
+		return fmt.Errorf("failed to apply row-level filters: unexpected subquery")
+	}
+	stmt.From = filtered.From
+
+	if stmt.Prewhere != nil {
+		prewhere := []clickhouseparser.Expr{stmt.Prewhere.Expr}
+		stmt.Where = &clickhouseparser.WhereClause{Expr: andExprs(whereExpr(stmt), prewhere)}
+		stmt.Prewhere = nil
+	}
+	return nil
+}
+
+// whereExpr returns the WHERE condition of the query, or nil.
+func whereExpr(stmt *clickhouseparser.SelectQuery) clickhouseparser.Expr {
+	if stmt.Where == nil {
+		return nil
+	}
+	return stmt.Where.Expr
+}
+
+// andExprs AND-s exprs onto base, which may be nil, parenthesizing each operand.
+func andExprs(base clickhouseparser.Expr, exprs []clickhouseparser.Expr) clickhouseparser.Expr {
+	var combined clickhouseparser.Expr
+	if base != nil {
+		combined = parenthesize(base)
+	}
+	for _, expr := range exprs {
+		if combined == nil {
+			combined = parenthesize(expr)
+			continue
+		}
+		combined = &clickhouseparser.BinaryOperation{
+			LeftExpr:  combined,
+			Operation: clickhouseparser.TokenKind(clickhouseparser.KeywordAnd),
+			RightExpr: parenthesize(expr),
+		}
+	}
+	return combined
+}
+
+// countSelects returns the number of SELECT statements in the tree, including the root.
+func countSelects(stmt *clickhouseparser.SelectQuery) int {
+	count := 0
+	_ = stmt.Accept(&clickhouseparser.DefaultASTVisitor{
+		Visit: func(expr clickhouseparser.Expr) error {
+			if _, ok := expr.(*clickhouseparser.SelectQuery); ok {
+				count++
+			}
+			return nil
+		},
+	})
+	return count
+}
+
+// collectAliases returns every alias defined in the query (SELECT items, ARRAY JOIN, expressions).
+func collectAliases(stmt *clickhouseparser.SelectQuery) []string {
+	var aliases []string
+	_ = stmt.Accept(&clickhouseparser.DefaultASTVisitor{
+		Visit: func(expr clickhouseparser.Expr) error {
+			switch e := expr.(type) {
+			case *clickhouseparser.SelectItem:
+				if e.Alias != nil {
+					aliases = append(aliases, e.Alias.Name)
+				}
+			case *clickhouseparser.ColumnExpr:
+				if e.Alias != nil {
+					aliases = append(aliases, e.Alias.Name)
+				}
+			case *clickhouseparser.OrderExpr:
+				if e.Alias != nil {
+					aliases = append(aliases, e.Alias.Name)
+				}
+			case *clickhouseparser.AliasExpr:
+				if e.Alias != nil {
+					aliases = append(aliases, strings.Trim(e.Alias.String(), "`\""))
+				}
+			}
+			return nil
+		},
+	})
+	return aliases
+}
+
+// parenthesize wraps an expression in parentheses so operator precedence is preserved when combined.
+func parenthesize(expr clickhouseparser.Expr) clickhouseparser.Expr {
+	return &clickhouseparser.ParamExprList{
+		Items: &clickhouseparser.ColumnExprList{Items: []clickhouseparser.Expr{expr}},
+	}
+}
@@ -0,0 +1,133 @@
+package clickhouse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRowFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		wantErr string
+	}{
+		{name: "comparison", filter: "namespace = 'payments'"},
+		{name: "escaped quote", filter: "service = 'it''s'"},
+		{name: "boolean expression", filter: "namespace = 'payments' OR env = 'prod'"},
+		{name: "empty", filter: "  ", wantErr: "row filter is empty"},
+		{name: "invalid syntax", filter: "namespace =", wantErr: "invalid row filter syntax"},
+		{name: "second statement", filter: "1 = 1; DROP TABLE logs", wantErr: "single expression"},
+		{name: "union", filter: "1 = 1 UNION ALL SELECT * FROM other", wantErr: "single expression"},
+		{name: "group by", filter: "1 = 1 GROUP BY namespace", wantErr: "single expression"},
+		{name: "order by", filter: "1 = 1 ORDER BY namespace", wantErr: "single expression"},
+		{name: "limit", filter: "1 = 1 LIMIT 1", wantErr: "single expression"},
+		{name: "settings", filter: "1 = 1 SETTINGS max_threads = 1", wantErr: "single expression"},
+		{name: "subquery", filter: "namespace IN (SELECT namespace FROM other)", wantErr: "must not contain subqueries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, ValidateRowFilter(tt.filter), tt.wantErr)
+		})
+	}
+}
+
+func TestBuildRawQueryRowFilters(t *testing.T) {
+	filters := []string{"namespace = 'payments'", "env = 'prod'"}
+	tests := []struct {
+		name    string
+		sql     string
+		limit   int
+		want    string
+		wantErr string
+	}{
+		{
+			name: "no where clause",
+			sql:  "SELECT * FROM db.logs",
+			want: "SELECT * FROM db.logs WHERE (namespace = 'payments') AND (env = 'prod')",
+		},
+		{
+			name: "or 1=1 stays inside the user's condition",
+			sql:  "SELECT * FROM db.logs WHERE level = 'error' OR 1=1",
+			want: "SELECT * FROM db.logs WHERE (level = 'error' OR 1 = 1) AND (namespace = 'payments') AND (env = 'prod')",
+		},
+		{
+			name: "or on the filtered column",
+			sql:  "SELECT * FROM db.logs WHERE level = 'x' OR namespace = 'other'",
+			want: "SELECT * FROM db.logs WHERE (level = 'x' OR namespace = 'other') AND (namespace = 'payments') AND (env = 'prod')",
+		},
+		{
+			name:  "limit replaced",
+			sql:   "SELECT * FROM db.logs WHERE level = 'error' LIMIT 500",
+			limit: 10,
+			want:  "SELECT * FROM db.logs WHERE (level = 'error') AND (namespace = 'payments') AND (env = 'prod') LIMIT 10",
+		},
+		{
+			name: "escaped quotes",
+			sql:  "SELECT * FROM db.logs WHERE msg = 'it''s'",
+			want: "SELECT * FROM db.logs WHERE (msg = 'it''s') AND (namespace = 'payments') AND (env = 'prod')",
+		},
+		{
+			name: "qualified names",
+			sql:  "SELECT l.namespace FROM db.logs AS l WHERE l.level = 'x'",
+			want: "SELECT l.namespace FROM db.logs AS l WHERE (l.level = 'x') AND (namespace = 'payments') AND (env = 'prod')",
+		},
+		{
+			name: "having stays after the filter",
+			sql:  "SELECT count() FROM db.logs GROUP BY level HAVING count() > 1 OR 1=1",
+			want: "SELECT count() FROM db.logs WHERE (namespace = 'payments') AND (env = 'prod') GROUP BY level HAVING count() > 1 OR 1 = 1",
+		},
+		{
+			name: "settings kept",
+			sql:  "SELECT * FROM db.logs WHERE level = 'a' SETTINGS max_threads = 1",
+			want: "SELECT * FROM db.logs WHERE (level = 'a') AND (namespace = 'payments') AND (env = 'prod') SETTINGS max_threads=1",
+		},
+		{name: "alias shadowing a filtered column", sql: "SELECT 'payments' AS namespace FROM db.logs", wantErr: "alias 'namespace' is not allowed"},
+		{name: "quoted alias", sql: "SELECT level AS `namespace` FROM db.logs", wantErr: "alias 'namespace' is not allowed"},
+		{name: "alias in other case", sql: "SELECT level AS NameSpace FROM db.logs", wantErr: "alias 'NameSpace' is not allowed"},
+		{name: "function alias", sql: "SELECT arrayJoin(['prod']) AS env FROM db.logs", wantErr: "alias 'env' is not allowed"},
+		{name: "array join alias", sql: "SELECT * FROM db.logs ARRAY JOIN tags AS env", wantErr: "alias 'env' is not allowed"},
+		{name: "subquery in where", sql: "SELECT * FROM db.logs WHERE id IN (SELECT id FROM db.other)", wantErr: "subqueries and UNION are not allowed"},
+		{name: "subquery in from", sql: "SELECT * FROM (SELECT * FROM db.logs)", wantErr: "could not identify table"},
+		{name: "union", sql: "SELECT * FROM db.logs UNION ALL SELECT * FROM db.logs", wantErr: "subqueries and UNION are not allowed"},
+		{name: "with clause", sql: "WITH 'payments' AS ns SELECT * FROM db.logs", wantErr: "WITH clauses are not allowed"},
+		{name: "join", sql: "SELECT * FROM db.logs AS l JOIN db.logs AS r ON l.id = r.id", wantErr: "JOIN clauses are not allowed"},
+		{name: "other table", sql: "SELECT * FROM db.other", wantErr: "invalid table reference"},
+		{name: "multiple statements", sql: "SELECT * FROM db.logs; SELECT 1", wantErr: "multiple SQL statements"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewQueryBuilder("db.logs").WithRowFilters(filters...).BuildRawQuery(tt.sql, tt.limit)
+			checkError(t, err, tt.wantErr)
+			if err == nil && got != tt.want {
+				t.Errorf("BuildRawQuery(%q)\n got: %s\nwant: %s", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRawQueryIgnoresEmptyRowFilters(t *testing.T) {
+	qb := NewQueryBuilder("db.logs").WithRowFilters("", "  ")
+	if qb.HasRestrictions() {
+		t.Fatal("empty row filters should not restrict queries")
+	}
+	got, err := qb.BuildRawQuery("SELECT * FROM db.logs", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT * FROM db.logs"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// checkError fails the test unless err contains wantErr, or is nil when wantErr is empty.
+func checkError(t *testing.T, err error, wantErr string) {
+	t.Helper()
+	switch {
+	case wantErr == "" && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case wantErr != "" && err == nil:
+		t.Fatalf("expected an error containing %q", wantErr)
+	case wantErr != "" && !strings.Contains(err.Error(), wantErr):
+		t.Fatalf("error %q does not contain %q", err, wantErr)
+	}
+}
@@ -14,13 +14,21 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Server     ServerConfig     `koanf:"server"`
-	SQLite     SQLiteConfig     `koanf:"sqlite"`
-	Clickhouse ClickhouseConfig `koanf:"clickhouse"`
-	OIDC       OIDCConfig       `koanf:"oidc"`
-	Auth       AuthConfig       `koanf:"auth"`
-	Logging    LoggingConfig    `koanf:"logging"`
-	AI         AIConfig         `koanf:"ai"`
+	Server       ServerConfig       `koanf:"server"`
+	SQLite       SQLiteConfig       `koanf:"sqlite"`
+	Clickhouse   ClickhouseConfig   `koanf:"clickhouse"`
+	OIDC         OIDCConfig         `koanf:"oidc"`
+	Auth         AuthConfig         `koanf:"auth"`
+	Logging      LoggingConfig      `koanf:"logging"`
+	AI           AIConfig           `koanf:"ai"`
+	Audit        AuditConfig        `koanf:"audit"`
+	Provisioning ProvisioningConfig `koanf:"provisioning"`
+	SCIM         SCIMConfig         `koanf:"scim"`
+	Jobs         JobsConfig         `koanf:"jobs"`
+	Tracing      TracingConfig      `koanf:"tracing"`
+	QueryCache   QueryCacheConfig   `koanf:"query_cache"`
+	QueryLimits  QueryLimitsConfig  `koanf:"query_limits"`
+	Dashboards   DashboardsConfig   `koanf:"dashboards"`
 }
 
 // ServerConfig contains HTTP server settings
@@ -29,6 +37,9 @@ type ServerConfig struct {
 	Host              string        `koanf:"host"`
 	FrontendURL       string        `koanf:"frontend_url"`
 	HTTPServerTimeout time.Duration `koanf:"http_server_timeout"`
+	// TrustedProxies are the addresses or CIDRs of reverse proxies whose X-Forwarded-For
+	// header is used as the client address, e.g. for API token IP allow-lists.
+	TrustedProxies []string `koanf:"trusted_proxies"`
 }
 
 // SQLiteConfig contains SQLite database settings
@@ -43,6 +54,9 @@ type ClickhouseConfig struct {
 	Database string `koanf:"database"`
 	Username string `koanf:"username"`
 	Password string `koanf:"password"`
+	// SchemaSnapshotInterval is how often the health checks snapshot each source's table
+	// schema to detect schema drift (0 uses the default, negative disables snapshots)
+	SchemaSnapshotInterval time.Duration `koanf:"schema_snapshot_interval"`
 }
 
 // OIDCConfig contains OpenID Connect settings
@@ -57,6 +71,23 @@ type OIDCConfig struct {
 	ClientSecret string   `koanf:"client_secret"`
 	RedirectURL  string   `koanf:"redirect_url"`
 	Scopes       []string `koanf:"scopes"`
+
+	// CreateUsers creates unknown users on their first login instead of rejecting them
+	CreateUsers bool `koanf:"create_users"`
+	// GroupsClaim is the ID token claim listing the user's groups (empty disables group sync).
+	// Nested claims are addressed with dots, e.g. "realm_access.roles".
+	GroupsClaim string `koanf:"groups_claim"`
+	// AdminGroups are groups whose members are made global admins
+	AdminGroups []string `koanf:"admin_groups"`
+	// GroupMappings grant team memberships to members of a group
+	GroupMappings []OIDCGroupMapping `koanf:"group_mappings"`
+}
+
+// OIDCGroupMapping grants a role in a team to the members of an OIDC group
+type OIDCGroupMapping struct {
+	Group string `koanf:"group"`
+	Team  string `koanf:"team"` // Team name
+	Role  string `koanf:"role"` // admin, editor or member (default: member)
 }
 
 // AuthConfig contains authentication settings
@@ -90,6 +121,119 @@ type AIConfig struct {
 	BaseURL string `koanf:"base_url"`
 }
 
+// AuditConfig contains query audit log settings
+type AuditConfig struct {
+	// Enabled records every executed query in the audit log
+	Enabled bool `koanf:"enabled"`
+	// Retention is how long audit entries are kept (0 keeps them forever)
+	Retention time.Duration `koanf:"retention"`
+	// BatchSize is the maximum number of entries written per batch (default: 100)
+	BatchSize int `koanf:"batch_size"`
+	// FlushInterval is how often pending entries are written (default: 2s)
+	FlushInterval time.Duration `koanf:"flush_interval"`
+	// BufferSize is the number of entries queued before new ones are dropped (default: 10000)
+	BufferSize int `koanf:"buffer_size"`
+}
+
+// ProvisioningConfig contains declarative provisioning settings
+type ProvisioningConfig struct {
+	// Path is a directory of YAML files declaring users, sources and teams (empty disables provisioning)
+	Path string `koanf:"path"`
+	// Watch re-applies the files when they change
+	Watch bool `koanf:"watch"`
+	// Prune deletes provisioned objects that are no longer declared, instead of releasing them to the UI
+	Prune bool `koanf:"prune"`
+}
+
+// SCIMConfig contains SCIM 2.0 user and group provisioning settings
+type SCIMConfig struct {
+	// Enabled serves the SCIM endpoints under /scim/v2
+	Enabled bool `koanf:"enabled"`
+	// Token is the bearer token the identity provider authenticates with (at least 32 characters)
+	Token string `koanf:"token"`
+}
+
+// JobsConfig contains the schedules of background maintenance jobs. A schedule is
+// "@every <duration>", a descriptor like "@hourly" or a 5-field cron expression in UTC;
+// empty uses the job's default and "off" disables the job.
+type JobsConfig struct {
+	// Jitter is the maximum random delay added to every run (0 disables jitter)
+	Jitter time.Duration `koanf:"jitter"`
+	// Timeout bounds a single run of a job (default: 5m)
+	Timeout time.Duration `koanf:"timeout"`
+	// TokenCleanup deletes expired API tokens (default: @every 1h)
+	TokenCleanup string `koanf:"token_cleanup"`
+	// SessionCleanup deletes expired sessions (default: @every 1h)
+	SessionCleanup string `koanf:"session_cleanup"`
+	// AuditPrune deletes audit entries past audit.retention (default: @every 1h)
+	AuditPrune string `koanf:"audit_prune"`
+	// SchemaCacheRefresh re-reads the schema of healthy sources, recording drift and dropping
+	// the cached results and Map keys of changed sources, then drops expired Map column key
+	// samples (default: @every 10m)
+	SchemaCacheRefresh string `koanf:"schema_cache_refresh"`
+	// QueryCachePrune drops expired and excess query results stored in SQLite (default: @every 10m)
+	QueryCachePrune string `koanf:"query_cache_prune"`
+}
+
+// TracingConfig contains trace correlation settings
+type TracingConfig struct {
+	// URLTemplate links a trace to an external tracing UI, e.g. "https://jaeger.example.com/trace/{trace_id}".
+	// {trace_id}, {start} and {end} (Unix milliseconds) are replaced (empty disables links)
+	URLTemplate string `koanf:"url_template"`
+	// Window is how far before and after the time hint the logs of a trace are searched (default: 1h)
+	Window time.Duration `koanf:"window"`
+}
+
+// QueryCacheConfig contains query result cache settings. Results of ranges ending in the
+// past change rarely, so they're kept longer than results of recent ranges.
+type QueryCacheConfig struct {
+	// Enabled caches the results of log and histogram queries
+	Enabled bool `koanf:"enabled"`
+	// MaxEntries bounds the results kept in memory (default: 1000)
+	MaxEntries int `koanf:"max_entries"`
+	// MaxBytes bounds the memory used by cached results, as JSON encoded size (default: 64 MiB)
+	MaxBytes int64 `koanf:"max_bytes"`
+	// MaxEntryBytes is the size above which results aren't cached (default: 4 MiB)
+	MaxEntryBytes int64 `koanf:"max_entry_bytes"`
+	// RecentWindow is how close to now a time range must end to count as recent (default: 1h)
+	RecentWindow time.Duration `koanf:"recent_window"`
+	// RecentTTL is how long results of recent ranges, or of queries without a detectable
+	// time range, are cached (default: 30s)
+	RecentTTL time.Duration `koanf:"recent_ttl"`
+	// HistoricalTTL is how long results of ranges ending before the recent window are cached (default: 10m)
+	HistoricalTTL time.Duration `koanf:"historical_ttl"`
+	// Persist also stores results in SQLite, so they survive restarts and are shared by the
+	// processes using the same database
+	Persist bool `koanf:"persist"`
+	// MaxPersistedEntries bounds the results stored in SQLite (default: 10000)
+	MaxPersistedEntries int `koanf:"max_persisted_entries"`
+}
+
+// QueryLimitsConfig limits the queries run on sources at once and per minute,
+// so a single user or team can't saturate a shared ClickHouse cluster. A limit of 0 disables it.
+type QueryLimitsConfig struct {
+	// MaxConcurrentPerUser is the number of queries a user may run at once
+	MaxConcurrentPerUser int `koanf:"max_concurrent_per_user"`
+	// MaxConcurrentPerTeam is the number of queries the members of a team may run at once
+	MaxConcurrentPerTeam int `koanf:"max_concurrent_per_team"`
+	// MaxConcurrentPerSource is the number of queries that may run on a source at once
+	MaxConcurrentPerSource int `koanf:"max_concurrent_per_source"`
+	// QueriesPerMinutePerUser is the number of queries a user may start per minute
+	QueriesPerMinutePerUser int `koanf:"queries_per_minute_per_user"`
+	// QueriesPerMinutePerTeam is the number of queries the members of a team may start per minute
+	QueriesPerMinutePerTeam int `koanf:"queries_per_minute_per_team"`
+	// WaitTimeout is how long a query over a limit queues for a slot before it's rejected
+	// (default: 10s, negative rejects it right away)
+	WaitTimeout time.Duration `koanf:"wait_timeout"`
+}
+
+// DashboardsConfig contains dashboard settings
+type DashboardsConfig struct {
+	// MaxConcurrency is the number of panels of a dashboard queried at once. A dashboard's own
+	// concurrency can only lower it (default: 4)
+	MaxConcurrency int `koanf:"max_concurrency"`
+}
+
 const envPrefix = "LOGCHEF_"
 
 // Load loads the configuration from a file and environment variables.
@@ -139,6 +283,11 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("api_token_secret must be at least 32 characters long for security")
 	}
 
+	// Validate SCIM configuration
+	if cfg.SCIM.Enabled && len(cfg.SCIM.Token) < 32 {
+		return nil, fmt.Errorf("scim token must be at least 32 characters long when SCIM is enabled (either in file or %sSCIM__TOKEN)", envPrefix)
+	}
+
 	// Validate OIDC configuration
 	if cfg.OIDC.ProviderURL == "" {
 		return nil, fmt.Errorf("provider_url is required in OIDC configuration (either in file or %sOIDC__PROVIDER_URL)", envPrefix)
@@ -155,6 +304,21 @@ func Load(path string) (*Config, error) {
 	if cfg.OIDC.RedirectURL == "" {
 		return nil, fmt.Errorf("redirect_url is required in OIDC configuration (either in file or %sOIDC__REDIRECT_URL)", envPrefix)
 	}
+	if (len(cfg.OIDC.AdminGroups) > 0 || len(cfg.OIDC.GroupMappings) > 0) && cfg.OIDC.GroupsClaim == "" {
+		return nil, fmt.Errorf("groups_claim is required in OIDC configuration when admin_groups or group_mappings are set")
+	}
+	for i, m := range cfg.OIDC.GroupMappings {
+		if m.Group == "" || m.Team == "" {
+			return nil, fmt.Errorf("group and team are required in OIDC group mapping %d", i+1)
+		}
+		switch m.Role {
+		case "":
+			cfg.OIDC.GroupMappings[i].Role = "member"
+		case "admin", "editor", "member":
+		default:
+			return nil, fmt.Errorf("invalid role %q in OIDC group mapping for group %q (must be admin, editor or member)", m.Role, m.Group)
+		}
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+var (
+	// ErrTokenIPNotAllowed is returned when a token is used from outside its allowed CIDRs
+	ErrTokenIPNotAllowed = errors.New("token is not allowed from this address")
+	// ErrTokenExceedsParent is returned when a token would have more access than the token creating it
+	ErrTokenExceedsParent = errors.New("new token cannot exceed the access of the token creating it")
+)
+
+// knownAPITokenScopes are the scopes a token can be created with, besides "*" and "<area>:*".
+var knownAPITokenScopes = []models.APITokenScope{
+	models.ScopeLogsQuery,
+	models.ScopeCollectionsRead,
+	models.ScopeCollectionsWrite,
+	models.ScopeTeamsRead,
+	models.ScopeTeamsWrite,
+	models.ScopeSharesWrite,
+	models.ScopeTokensWrite,
+	models.ScopeAdminUsers,
+	models.ScopeAdminTeams,
+	models.ScopeAdminSources,
+	models.ScopeAdminAudit,
+	models.ScopeAdminConfig,
+}
+
+// scopeArea returns the area of a scope, e.g. "collections" for "collections:write".
+func scopeArea(scope models.APITokenScope) string {
+	area, _, _ := strings.Cut(string(scope), ":")
+	return area
+}
+
+// validAPITokenScope reports whether scope is a known scope, an area wildcard or "*".
+func validAPITokenScope(scope models.APITokenScope) bool {
+	if scope == models.ScopeAll || slices.Contains(knownAPITokenScopes, scope) {
+		return true
+	}
+	area, action, ok := strings.Cut(string(scope), ":")
+	if !ok || action != "*" {
+		return false
+	}
+	return slices.ContainsFunc(knownAPITokenScopes, func(known models.APITokenScope) bool {
+		return scopeArea(known) == area
+	})
+}
+
+// scopeCovers reports whether the granted scope includes the wanted one.
+// Wildcards cover their area, and "<area>:write" covers "<area>:read".
+func scopeCovers(granted, wanted models.APITokenScope) bool {
+	switch {
+	case granted == models.ScopeAll, granted == wanted:
+		return true
+	case strings.HasSuffix(string(granted), ":*"):
+		return wanted != models.ScopeAll && scopeArea(granted) == scopeArea(wanted)
+	case strings.HasSuffix(string(granted), ":write"):
+		return wanted == models.APITokenScope(scopeArea(granted)+":read")
+	}
+	return false
+}
+
+// APITokenHasScope reports whether a token grants the scope. A nil token (session
+// authentication) has every scope.
+func APITokenHasScope(token *models.APIToken, scope models.APITokenScope) bool {
+	if token == nil {
+		return true
+	}
+	return slices.ContainsFunc(token.Scopes, func(granted models.APITokenScope) bool {
+		return scopeCovers(granted, scope)
+	})
+}
+
+// APITokenHasResources reports whether a token is restricted to specific team/source pairs.
+func APITokenHasResources(token *models.APIToken) bool {
+	return token != nil && len(token.Resources) > 0
+}
+
+// APITokenAllowsTeam reports whether a token may act on the team.
+func APITokenAllowsTeam(token *models.APIToken, teamID models.TeamID) bool {
+	if !APITokenHasResources(token) {
+		return true
+	}
+	return slices.ContainsFunc(token.Resources, func(r models.APITokenResource) bool {
+		return r.TeamID == teamID
+	})
+}
+
+// APITokenAllowsSource reports whether a token may act on the source through the team.
+func APITokenAllowsSource(token *models.APIToken, teamID models.TeamID, sourceID models.SourceID) bool {
+	if !APITokenHasResources(token) {
+		return true
+	}
+	return slices.Contains(token.Resources, models.APITokenResource{TeamID: teamID, SourceID: sourceID})
+}
+
+// CheckAPITokenIP returns ErrTokenIPNotAllowed when the client address is outside the
+// token's allowed CIDRs. Tokens without CIDRs are allowed from anywhere.
+func CheckAPITokenIP(token *models.APIToken, clientIP string) error {
+	if token == nil || len(token.AllowedCIDRs) == 0 {
+		return nil
+	}
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return ErrTokenIPNotAllowed
+	}
+	// Zones never match a prefix, so link-local addresses are checked without theirs.
+	addr = addr.Unmap().WithZone("")
+	for _, cidr := range token.AllowedCIDRs {
+		prefix, err := parseCIDR(cidr)
+		if err == nil && prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return ErrTokenIPNotAllowed
+}
+
+// parseCIDR parses a CIDR or a single address, which is treated as a /32 or /128. IPv4-mapped
+// IPv6 ranges are converted to IPv4, as client addresses are unmapped before they're checked.
+func parseCIDR(cidr string) (netip.Prefix, error) {
+	if !strings.Contains(cidr, "/") {
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// normalizeAPITokenRestrictions validates the restrictions of a new token for a user.
+// Scopes default to "*"; resources must be sources linked to teams the user belongs to.
+func normalizeAPITokenRestrictions(ctx context.Context, db *sqlite.DB, user *models.User, r models.APITokenRestrictions) (models.APITokenRestrictions, error) {
+	out := models.APITokenRestrictions{
+		Scopes:       []models.APITokenScope{},
+		Resources:    []models.APITokenResource{},
+		AllowedCIDRs: []string{},
+	}
+
+	if len(r.Scopes) == 0 {
+		r.Scopes = []models.APITokenScope{models.ScopeAll}
+	}
+	for _, scope := range r.Scopes {
+		scope = models.APITokenScope(strings.TrimSpace(string(scope)))
+		if !validAPITokenScope(scope) {
+			return out, &ValidationError{Field: "scopes", Message: fmt.Sprintf("unknown scope %q", scope)}
+		}
+		if !slices.Contains(out.Scopes, scope) {
+			out.Scopes = append(out.Scopes, scope)
+		}
+	}
+
+	for _, res := range r.Resources {
+		if slices.Contains(out.Resources, res) {
+			continue
+		}
+		hasSource, err := TeamHasSourceAccess(ctx, db, res.TeamID, res.SourceID)
+		if err != nil {
+			return out, err
+		}
+		if !hasSource {
+			return out, &ValidationError{Field: "resources", Message: fmt.Sprintf("source %d is not linked to team %d", res.SourceID, res.TeamID)}
+		}
+		if user.Role != models.UserRoleAdmin {
+			isMember, err := IsTeamMember(ctx, db, res.TeamID, user.ID)
+			if err != nil {
+				return out, err
+			}
+			if !isMember {
+				return out, &ValidationError{Field: "resources", Message: fmt.Sprintf("not a member of team %d", res.TeamID)}
+			}
+		}
+		out.Resources = append(out.Resources, res)
+	}
+
+	for _, cidr := range r.AllowedCIDRs {
+		prefix, err := parseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return out, &ValidationError{Field: "allowed_cidrs", Message: fmt.Sprintf("invalid CIDR %q", cidr)}
+		}
+		if !slices.Contains(out.AllowedCIDRs, prefix.String()) {
+			out.AllowedCIDRs = append(out.AllowedCIDRs, prefix.String())
+		}
+	}
+
+	return out, nil
+}
+
+// checkAPITokenWithinParent returns ErrTokenExceedsParent unless a token with restrictions r
+// has at most the access of parent, the token authenticating its creation. A nil parent
+// (session authentication) allows anything.
+func checkAPITokenWithinParent(parent *models.APIToken, r models.APITokenRestrictions) error {
+	if parent == nil {
+		return nil
+	}
+
+	for _, scope := range r.Scopes {
+		if !APITokenHasScope(parent, scope) {
+			return ErrTokenExceedsParent
+		}
+	}
+
+	if APITokenHasResources(parent) {
+		if len(r.Resources) == 0 {
+			return ErrTokenExceedsParent
+		}
+		for _, res := range r.Resources {
+			if !APITokenAllowsSource(parent, res.TeamID, res.SourceID) {
+				return ErrTokenExceedsParent
+			}
+		}
+	}
+
+	if len(parent.AllowedCIDRs) > 0 {
+		if len(r.AllowedCIDRs) == 0 {
+			return ErrTokenExceedsParent
+		}
+		for _, cidr := range r.AllowedCIDRs {
+			child, _ := netip.ParsePrefix(cidr)
+			if !slices.ContainsFunc(parent.AllowedCIDRs, func(p string) bool {
+				parentPrefix, err := netip.ParsePrefix(p)
+				return err == nil && parentPrefix.Bits() <= child.Bits() && parentPrefix.Contains(child.Addr())
+			}) {
+				return ErrTokenExceedsParent
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeAPITokenRestrictions decodes the JSON-encoded restrictions of a stored token.
+// Restrictions that fail to decode leave the token without any scope.
+func decodeAPITokenRestrictions(scopes, resources, allowedCIDRs string) models.APITokenRestrictions {
+	r := models.APITokenRestrictions{
+		Scopes:       []models.APITokenScope{},
+		Resources:    []models.APITokenResource{},
+		AllowedCIDRs: []string{},
+	}
+	if err := errors.Join(
+		json.Unmarshal([]byte(scopes), &r.Scopes),
+		json.Unmarshal([]byte(resources), &r.Resources),
+		json.Unmarshal([]byte(allowedCIDRs), &r.AllowedCIDRs),
+	); err != nil {
+		r.Scopes = []models.APITokenScope{}
+	}
+	return r
+}
@@ -0,0 +1,241 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+func TestScopeCovers(t *testing.T) {
+	tests := []struct {
+		granted, wanted models.APITokenScope
+		want            bool
+	}{
+		{models.ScopeAll, models.ScopeLogsQuery, true},
+		{models.ScopeAll, models.ScopeAdminConfig, true},
+		{models.ScopeAll, models.ScopeAll, true},
+		{models.ScopeLogsQuery, models.ScopeLogsQuery, true},
+		{models.ScopeLogsQuery, models.ScopeCollectionsRead, false},
+		{models.ScopeCollectionsWrite, models.ScopeCollectionsRead, true},
+		{models.ScopeCollectionsRead, models.ScopeCollectionsWrite, false},
+		{models.ScopeCollectionsWrite, "collections:*", false},
+		{models.ScopeTeamsWrite, models.ScopeCollectionsRead, false},
+		{"admin:*", models.ScopeAdminUsers, true},
+		{"admin:*", models.ScopeAdminConfig, true},
+		{"admin:*", "admin:*", true},
+		{"admin:*", models.ScopeAll, false},
+		{"admin:*", models.ScopeLogsQuery, false},
+		{"collections:*", models.ScopeCollectionsWrite, true},
+		{"teams:*", "teamsx:read", false},
+		{models.ScopeAdminUsers, models.ScopeAdminTeams, false},
+		{models.ScopeAdminUsers, models.ScopeAll, false},
+	}
+	for _, tt := range tests {
+		if got := scopeCovers(tt.granted, tt.wanted); got != tt.want {
+			t.Errorf("scopeCovers(%q, %q) = %v, want %v", tt.granted, tt.wanted, got, tt.want)
+		}
+	}
+}
+
+func TestValidAPITokenScope(t *testing.T) {
+	tests := []struct {
+		scope models.APITokenScope
+		want  bool
+	}{
+		{models.ScopeAll, true},
+		{models.ScopeLogsQuery, true},
+		{"admin:*", true},
+		{"logs:*", true},
+		{"unknown:*", false},
+		{"logs:delete", false},
+		{"logs", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := validAPITokenScope(tt.scope); got != tt.want {
+			t.Errorf("validAPITokenScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAPITokenHasScope(t *testing.T) {
+	token := &models.APIToken{APITokenRestrictions: models.APITokenRestrictions{Scopes: []models.APITokenScope{models.ScopeCollectionsWrite, "admin:*"}}}
+	tests := []struct {
+		token *models.APIToken
+		scope models.APITokenScope
+		want  bool
+	}{
+		{nil, models.ScopeAdminConfig, true},
+		{token, models.ScopeCollectionsRead, true},
+		{token, models.ScopeAdminAudit, true},
+		{token, models.ScopeLogsQuery, false},
+		{&models.APIToken{APITokenRestrictions: models.APITokenRestrictions{Scopes: []models.APITokenScope{}}}, models.ScopeLogsQuery, false},
+	}
+	for _, tt := range tests {
+		if got := APITokenHasScope(tt.token, tt.scope); got != tt.want {
+			t.Errorf("APITokenHasScope(%v, %q) = %v, want %v", tt.token, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAPITokenResources(t *testing.T) {
+	token := &models.APIToken{APITokenRestrictions: models.APITokenRestrictions{Resources: []models.APITokenResource{{TeamID: 1, SourceID: 10}}}}
+	if !APITokenAllowsTeam(nil, 2) || !APITokenAllowsSource(&models.APIToken{}, 2, 20) {
+		t.Error("tokens without resources should allow every team and source")
+	}
+	if !APITokenAllowsTeam(token, 1) || APITokenAllowsTeam(token, 2) {
+		t.Error("token should only allow team 1")
+	}
+	if !APITokenAllowsSource(token, 1, 10) {
+		t.Error("token should allow source 10 through team 1")
+	}
+	if APITokenAllowsSource(token, 1, 11) || APITokenAllowsSource(token, 2, 10) {
+		t.Error("token should not allow other sources, or source 10 through another team")
+	}
+}
+
+func TestCheckAPITokenIP(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidrs []string
+		ip    string
+		want  error
+	}{
+		{name: "no restrictions", ip: "203.0.113.7"},
+		{name: "no restrictions with invalid address", ip: "unknown"},
+		{name: "inside ipv4 range", cidrs: []string{"10.0.0.0/8"}, ip: "10.20.30.40"},
+		{name: "first address of range", cidrs: []string{"10.0.0.0/8"}, ip: "10.0.0.0"},
+		{name: "last address of range", cidrs: []string{"10.0.0.0/8"}, ip: "10.255.255.255"},
+		{name: "just outside range", cidrs: []string{"10.0.0.0/8"}, ip: "11.0.0.0", want: ErrTokenIPNotAllowed},
+		{name: "single address", cidrs: []string{"192.0.2.1/32"}, ip: "192.0.2.1"},
+		{name: "neighbour of single address", cidrs: []string{"192.0.2.1/32"}, ip: "192.0.2.2", want: ErrTokenIPNotAllowed},
+		{name: "any ipv4 address", cidrs: []string{"0.0.0.0/0"}, ip: "198.51.100.1"},
+		{name: "ipv6 outside ipv4 any", cidrs: []string{"0.0.0.0/0"}, ip: "2001:db8::1", want: ErrTokenIPNotAllowed},
+		{name: "second of several ranges", cidrs: []string{"10.0.0.0/8", "2001:db8::/32"}, ip: "2001:db8:1::5"},
+		{name: "ipv4-mapped ipv6 client", cidrs: []string{"10.0.0.0/8"}, ip: "::ffff:10.1.2.3"},
+		{name: "ipv4-mapped ipv6 range", cidrs: []string{"::ffff:10.0.0.0/104"}, ip: "10.1.2.3"},
+		{name: "zoned ipv6 client", cidrs: []string{"fe80::/10"}, ip: "fe80::1%eth0"},
+		{name: "invalid client address", cidrs: []string{"10.0.0.0/8"}, ip: "10.0.0", want: ErrTokenIPNotAllowed},
+		{name: "empty client address", cidrs: []string{"10.0.0.0/8"}, ip: "", want: ErrTokenIPNotAllowed},
+		{name: "invalid stored range is skipped", cidrs: []string{"bogus", "10.0.0.0/8"}, ip: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &models.APIToken{APITokenRestrictions: models.APITokenRestrictions{AllowedCIDRs: tt.cidrs}}
+			if err := CheckAPITokenIP(token, tt.ip); !errors.Is(err, tt.want) {
+				t.Errorf("CheckAPITokenIP(%v, %q) = %v, want %v", tt.cidrs, tt.ip, err, tt.want)
+			}
+		})
+	}
+	if err := CheckAPITokenIP(nil, "10.0.0.1"); err != nil {
+		t.Errorf("session authentication should not be restricted, got %v", err)
+	}
+}
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		want    string
+		wantErr bool
+	}{
+		{cidr: "10.0.0.0/8", want: "10.0.0.0/8"},
+		{cidr: "10.1.2.3/8", want: "10.0.0.0/8"},
+		{cidr: "192.0.2.1", want: "192.0.2.1/32"},
+		{cidr: "2001:db8::1", want: "2001:db8::1/128"},
+		{cidr: "2001:db8::1/32", want: "2001:db8::/32"},
+		{cidr: "::ffff:192.0.2.1", want: "192.0.2.1/32"},
+		{cidr: "::ffff:10.0.0.0/104", want: "10.0.0.0/8"},
+		{cidr: "0.0.0.0/0", want: "0.0.0.0/0"},
+		{cidr: "10.0.0.0/33", wantErr: true},
+		{cidr: "10.0.0", wantErr: true},
+		{cidr: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCIDR(tt.cidr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCIDR(%q) error = %v, wantErr %v", tt.cidr, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("parseCIDR(%q) = %s, want %s", tt.cidr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAPITokenWithinParent(t *testing.T) {
+	parent := &models.APIToken{APITokenRestrictions: models.APITokenRestrictions{
+		Scopes:       []models.APITokenScope{models.ScopeLogsQuery, models.ScopeCollectionsWrite},
+		Resources:    []models.APITokenResource{{TeamID: 1, SourceID: 10}},
+		AllowedCIDRs: []string{"10.0.0.0/8"},
+	}}
+	within := models.APITokenRestrictions{
+		Scopes:       []models.APITokenScope{models.ScopeCollectionsRead},
+		Resources:    []models.APITokenResource{{TeamID: 1, SourceID: 10}},
+		AllowedCIDRs: []string{"10.1.0.0/16"},
+	}
+	tests := []struct {
+		name   string
+		parent *models.APIToken
+		modify func(r *models.APITokenRestrictions)
+		want   error
+	}{
+		{name: "session authentication", parent: nil, modify: func(r *models.APITokenRestrictions) { r.Scopes = []models.APITokenScope{models.ScopeAll} }},
+		{name: "within parent", parent: parent},
+		{name: "same range as parent", parent: parent, modify: func(r *models.APITokenRestrictions) { r.AllowedCIDRs = []string{"10.0.0.0/8"} }},
+		{name: "broader scope", parent: parent, modify: func(r *models.APITokenRestrictions) { r.Scopes = []models.APITokenScope{"collections:*"} }, want: ErrTokenExceedsParent},
+		{name: "other scope", parent: parent, modify: func(r *models.APITokenRestrictions) { r.Scopes = []models.APITokenScope{models.ScopeAdminUsers} }, want: ErrTokenExceedsParent},
+		{name: "no resources", parent: parent, modify: func(r *models.APITokenRestrictions) { r.Resources = nil }, want: ErrTokenExceedsParent},
+		{name: "other source", parent: parent, modify: func(r *models.APITokenRestrictions) {
+			r.Resources = []models.APITokenResource{{TeamID: 1, SourceID: 11}}
+		}, want: ErrTokenExceedsParent},
+		{name: "no ranges", parent: parent, modify: func(r *models.APITokenRestrictions) { r.AllowedCIDRs = nil }, want: ErrTokenExceedsParent},
+		{name: "wider range", parent: parent, modify: func(r *models.APITokenRestrictions) { r.AllowedCIDRs = []string{"10.0.0.0/7"} }, want: ErrTokenExceedsParent},
+		{name: "range outside parent", parent: parent, modify: func(r *models.APITokenRestrictions) { r.AllowedCIDRs = []string{"192.168.0.0/16"} }, want: ErrTokenExceedsParent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := within
+			if tt.modify != nil {
+				tt.modify(&r)
+			}
+			if err := checkAPITokenWithinParent(tt.parent, r); !errors.Is(err, tt.want) {
+				t.Errorf("checkAPITokenWithinParent() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAPITokenRestrictions(t *testing.T) {
+	r := decodeAPITokenRestrictions(`["logs:query"]`, `[{"team_id":1,"source_id":10}]`, `["10.0.0.0/8"]`)
+	if len(r.Scopes) != 1 || r.Scopes[0] != models.ScopeLogsQuery {
+		t.Errorf("unexpected scopes %v", r.Scopes)
+	}
+	if len(r.Resources) != 1 || r.Resources[0] != (models.APITokenResource{TeamID: 1, SourceID: 10}) {
+		t.Errorf("unexpected resources %v", r.Resources)
+	}
+	if len(r.AllowedCIDRs) != 1 || r.AllowedCIDRs[0] != "10.0.0.0/8" {
+		t.Errorf("unexpected allowed CIDRs %v", r.AllowedCIDRs)
+	}
+
+	// Restrictions that fail to decode must not grant anything.
+	r = decodeAPITokenRestrictions(`["*"]`, `not json`, `[]`)
+	if len(r.Scopes) != 0 {
+		t.Errorf("corrupt restrictions should leave no scopes, got %v", r.Scopes)
+	}
+}
+
+func TestFilterDashboardPanels(t *testing.T) {
+	token := &models.APIToken{APITokenRestrictions: models.APITokenRestrictions{Resources: []models.APITokenResource{{TeamID: 1, SourceID: 10}}}}
+	dashboard := &models.Dashboard{TeamID: 1, Panels: []models.DashboardPanel{
+		{ID: "errors", SourceID: 10},
+		{ID: "billing", SourceID: 11},
+		{ID: "latency", SourceID: 10},
+	}}
+	FilterDashboardPanels(dashboard, func(sourceID models.SourceID) bool {
+		return APITokenAllowsSource(token, 1, sourceID)
+	})
+	if len(dashboard.Panels) != 2 || dashboard.Panels[0].ID != "errors" || dashboard.Panels[1].ID != "latency" {
+		t.Errorf("panels = %+v, want the panels of source 10", dashboard.Panels)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/subtle"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -71,19 +72,41 @@ func validateAPITokenCreation(name string) error {
 	return nil
 }
 
-// CreateAPIToken creates a new API token for a user
-func CreateAPIToken(ctx context.Context, db *sqlite.DB, log *slog.Logger, config *config.AuthConfig, userID models.UserID, name string, expiresAt *time.Time) (*models.CreateAPITokenResponse, error) {
+// CreateAPIToken creates a new API token for a user, limited by the given restrictions.
+// parent is the API token authenticating the request, if any; the new token can't exceed it.
+func CreateAPIToken(ctx context.Context, db *sqlite.DB, log *slog.Logger, config *config.AuthConfig, userID models.UserID, name string, expiresAt *time.Time, restrictions models.APITokenRestrictions, parent *models.APIToken) (*models.CreateAPITokenResponse, error) {
 	// Validate input
 	if err := validateAPITokenCreation(name); err != nil {
 		return nil, err
 	}
 
 	// Verify user exists
-	_, err := GetUser(ctx, db, userID)
+	user, err := GetUser(ctx, db, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	restrictions, err = normalizeAPITokenRestrictions(ctx, db, user, restrictions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAPITokenWithinParent(parent, restrictions); err != nil {
+		return nil, err
+	}
+
+	scopesJSON, err := json.Marshal(restrictions.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token scopes: %w", err)
+	}
+	resourcesJSON, err := json.Marshal(restrictions.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token resources: %w", err)
+	}
+	cidrsJSON, err := json.Marshal(restrictions.AllowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token CIDRs: %w", err)
+	}
+
 	// Generate token
 	token, err := generateAPIToken(userID)
 	if err != nil {
@@ -108,11 +131,14 @@ func CreateAPIToken(ctx context.Context, db *sqlite.DB, log *slog.Logger, config
 
 	// Save to database
 	tokenID, err := db.CreateAPIToken(ctx, sqlc.CreateAPITokenParams{
-		UserID:    int64(userID),
-		Name:      name,
-		TokenHash: tokenHash,
-		Prefix:    prefix,
-		ExpiresAt: sqlExpiresAt,
+		UserID:       int64(userID),
+		Name:         name,
+		TokenHash:    tokenHash,
+		Prefix:       prefix,
+		ExpiresAt:    sqlExpiresAt,
+		Scopes:       string(scopesJSON),
+		Resources:    string(resourcesJSON),
+		AllowedCidrs: string(cidrsJSON),
 	})
 	if err != nil {
 		log.Error("failed to create API token in database", "error", err, "user_id", userID)
@@ -126,7 +152,7 @@ func CreateAPIToken(ctx context.Context, db *sqlite.DB, log *slog.Logger, config
 		return nil, fmt.Errorf("failed to retrieve created token: %w", err)
 	}
 
-	log.Info("API token created successfully", "token_id", tokenID, "user_id", userID, "name", name)
+	log.Info("API token created successfully", "token_id", tokenID, "user_id", userID, "name", name, "scopes", restrictions.Scopes)
 
 	return &models.CreateAPITokenResponse{
 		Token:    token,
@@ -206,6 +232,11 @@ func AuthenticateAPIToken(ctx context.Context, db *sqlite.DB, log *slog.Logger,
 		return nil, nil, fmt.Errorf("failed to get token: %w", err)
 	}
 
+	// Disabled tokens belong to a deactivated user and never authenticate again
+	if sqlcToken.DisabledAt.Valid {
+		return nil, nil, ErrInvalidToken
+	}
+
 	// Check if token is expired
 	if sqlcToken.ExpiresAt.Valid && time.Now().After(sqlcToken.ExpiresAt.Time) {
 		return nil, nil, ErrTokenExpired
@@ -238,6 +269,17 @@ func UpdateAPITokenLastUsed(ctx context.Context, db *sqlite.DB, tokenID int) err
 	return db.UpdateAPITokenLastUsed(ctx, int64(tokenID))
 }
 
+// DisableUserAPITokens disables all active API tokens of a user, e.g. on deactivation.
+func DisableUserAPITokens(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID models.UserID) error {
+	if err := db.DisableUserAPITokens(ctx, int64(userID), time.Now().UTC()); err != nil {
+		log.Error("failed to disable API tokens", "error", err, "user_id", userID)
+		return fmt.Errorf("failed to disable API tokens: %w", err)
+	}
+
+	log.Info("API tokens disabled for user", "user_id", userID)
+	return nil
+}
+
 // CleanupExpiredTokens removes all expired API tokens
 func CleanupExpiredTokens(ctx context.Context, db *sqlite.DB, log *slog.Logger) error {
 	if err := db.DeleteExpiredAPITokens(ctx); err != nil {
@@ -309,5 +351,11 @@ func convertSQLCAPITokenToModel(sqlcToken sqlc.ApiToken) *models.APIToken {
 		token.ExpiresAt = &sqlcToken.ExpiresAt.Time
 	}
 
+	if sqlcToken.DisabledAt.Valid {
+		token.DisabledAt = &sqlcToken.DisabledAt.Time
+	}
+
+	token.APITokenRestrictions = decodeAPITokenRestrictions(sqlcToken.Scopes, sqlcToken.Resources, sqlcToken.AllowedCidrs)
+
 	return token
 }
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/config"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Query Audit Log ---
+
+const (
+	// DefaultAuditBatchSize is the number of entries written per batch when not configured.
+	DefaultAuditBatchSize = 100
+	// DefaultAuditFlushInterval is how often pending entries are written when not configured.
+	DefaultAuditFlushInterval = 2 * time.Second
+	// DefaultAuditBufferSize is the number of queued entries when not configured.
+	DefaultAuditBufferSize = 10000
+	// DefaultAuditPageSize is the number of entries returned by history and search when no limit is given.
+	DefaultAuditPageSize = 50
+	// MaxAuditPageSize bounds the entries returned by history and search.
+	MaxAuditPageSize = 500
+
+	// auditWriteTimeout bounds a single batch write.
+	auditWriteTimeout = 10 * time.Second
+)
+
+// AuditLogger records executed queries asynchronously. Entries are queued in memory
+// and written to SQLite in batches, so recording never blocks a query. A nil
+// *AuditLogger is valid and discards all entries.
+type AuditLogger struct {
+	db  *sqlite.DB
+	log *slog.Logger
+	cfg config.AuditConfig
+
+	entries chan *models.QueryAuditEntry
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// NewAuditLogger creates an audit logger and starts its background writer.
+// Returns nil when auditing is disabled.
+func NewAuditLogger(db *sqlite.DB, log *slog.Logger, cfg config.AuditConfig) *AuditLogger {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultAuditBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultAuditFlushInterval
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultAuditBufferSize
+	}
+
+	a := &AuditLogger{
+		db:      db,
+		log:     log.With("component", "audit"),
+		cfg:     cfg,
+		entries: make(chan *models.QueryAuditEntry, cfg.BufferSize),
+		done:    make(chan struct{}),
+	}
+
+	a.wg.Add(1)
+	go a.run()
+	return a
+}
+
+// Record queues an entry for writing. If the queue is full the entry is dropped
+// rather than delaying the caller.
+func (a *AuditLogger) Record(entry *models.QueryAuditEntry) {
+	if a == nil || entry == nil {
+		return
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	select {
+	case a.entries <- entry:
+	default:
+		a.log.Warn("audit queue full, dropping entry", "user_id", entry.UserID, "source_id", entry.SourceID)
+	}
+}
+
+// Close stops the background writer after flushing queued entries, or when ctx expires.
+func (a *AuditLogger) Close(ctx context.Context) error {
+	if a == nil {
+		return nil
+	}
+	a.once.Do(func() { close(a.done) })
+
+	finished := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run batches queued entries and writes them when the batch is full or the flush interval elapses.
+func (a *AuditLogger) run() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.QueryAuditEntry, 0, a.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+		defer cancel()
+		if err := a.db.InsertQueryAuditEntries(ctx, batch); err != nil {
+			a.log.Error("failed to write audit entries", "error", err, "count", len(batch))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry := <-a.entries:
+			batch = append(batch, entry)
+			if len(batch) >= a.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-a.done:
+			// Drain whatever is still queued before exiting.
+			for {
+				select {
+				case entry := <-a.entries:
+					batch = append(batch, entry)
+					if len(batch) >= a.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// PruneQueryAuditLog deletes audit entries older than the retention period. It runs as the
+// audit pruning job of the scheduler.
+func PruneQueryAuditLog(ctx context.Context, db *sqlite.DB, log *slog.Logger, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	deleted, err := db.DeleteQueryAuditLogBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		log.Info("pruned query audit log", "deleted", deleted, "retention", retention.String())
+	}
+	return deleted, nil
+}
+
+// ListUserQueryHistory returns the most recent queries run by a user.
+func ListUserQueryHistory(ctx context.Context, db *sqlite.DB, userID models.UserID, limit, offset int) ([]*models.QueryAuditEntry, error) {
+	limit, offset = normalizeAuditPage(limit, offset)
+	return db.ListUserQueryHistory(ctx, userID, limit, offset)
+}
+
+// SearchQueryAuditLog returns audit entries matching the filter, newest first.
+func SearchQueryAuditLog(ctx context.Context, db *sqlite.DB, filter models.QueryAuditFilter) ([]*models.QueryAuditEntry, error) {
+	if filter.StartTime != nil && filter.EndTime != nil && !filter.StartTime.Before(*filter.EndTime) {
+		return nil, &ValidationError{Field: "start_time", Message: "start_time must be before end_time"}
+	}
+	switch filter.QueryType {
+	case "", models.QueryAuditTypeLogs, models.QueryAuditTypeHistogram, models.QueryAuditTypePatterns, models.QueryAuditTypeShare:
+	default:
+		return nil, &ValidationError{Field: "query_type", Message: "query_type must be one of: logs, histogram, patterns, share"}
+	}
+	filter.Limit, filter.Offset = normalizeAuditPage(filter.Limit, filter.Offset)
+	return db.SearchQueryAuditLog(ctx, filter)
+}
+
+// normalizeAuditPage applies the default and maximum page size.
+func normalizeAuditPage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = DefaultAuditPageSize
+	}
+	if limit > MaxAuditPageSize {
+		limit = MaxAuditPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// auditEntryKey is the context key carrying the audit entry of the current request.
+type auditEntryKey struct{}
+
+// WithAuditEntry attaches an audit entry to the context. Query functions fill in
+// the SQL they finally execute so the caller can record it.
+func WithAuditEntry(ctx context.Context, entry *models.QueryAuditEntry) context.Context {
+	return context.WithValue(ctx, auditEntryKey{}, entry)
+}
+
+// setAuditFinalSQL records the executed SQL on the context's audit entry, if any.
+func setAuditFinalSQL(ctx context.Context, sql string) {
+	if entry, ok := ctx.Value(auditEntryKey{}).(*models.QueryAuditEntry); ok && entry != nil {
+		entry.FinalSQL = sql
+	}
+}
+
+// setAuditShareQuery records a live share link query on the context's audit entry, if any.
+// The query runs on behalf of the link's creator, so they're recorded as the actor.
+func setAuditShareQuery(ctx context.Context, link *models.ShareLink) {
+	if entry, ok := ctx.Value(auditEntryKey{}).(*models.QueryAuditEntry); ok && entry != nil {
+		teamID, sourceID, userID := link.TeamID, link.SourceID, link.CreatedBy
+		entry.TeamID = &teamID
+		entry.SourceID = &sourceID
+		entry.UserID = &userID
+		entry.UserEmail = link.CreatedByEmail
+		entry.APITokenID = nil
+		entry.RawSQL = link.RawSQL
+	}
+}
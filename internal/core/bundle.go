@@ -0,0 +1,743 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseBundleFormat parses a bundle format name, defaulting to YAML.
+func ParseBundleFormat(format string) (models.BundleFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "yaml", "yml":
+		return models.BundleFormatYAML, nil
+	case "json":
+		return models.BundleFormatJSON, nil
+	}
+	return "", &ValidationError{Field: "format", Message: "format must be one of: yaml, json"}
+}
+
+// EncodeBundle encodes a bundle as YAML or JSON.
+func EncodeBundle(bundle *models.Bundle, format models.BundleFormat) ([]byte, error) {
+	if format == models.BundleFormatJSON {
+		return json.MarshalIndent(bundle, "", "  ")
+	}
+	return yaml.Marshal(bundle)
+}
+
+// DecodeBundle decodes a YAML or JSON bundle, rejecting unknown fields.
+func DecodeBundle(data []byte, format models.BundleFormat) (*models.Bundle, error) {
+	var bundle models.Bundle
+	if format == models.BundleFormatJSON {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&bundle); err != nil {
+			return nil, &ValidationError{Field: "bundle", Message: fmt.Sprintf("invalid JSON bundle: %v", err)}
+		}
+		return &bundle, nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&bundle); err != nil {
+		return nil, &ValidationError{Field: "bundle", Message: fmt.Sprintf("invalid YAML bundle: %v", err)}
+	}
+	return &bundle, nil
+}
+
+// --- Export ---
+
+// ExportBundle exports sources (without credentials), teams, their source links and their
+// collections. When teamIDs is set, only those teams and the sources linked to them are exported.
+func ExportBundle(ctx context.Context, db *sqlite.DB, teamIDs []models.TeamID) (*models.Bundle, error) {
+	teams, err := db.ListTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing teams: %w", err)
+	}
+	if len(teamIDs) > 0 {
+		byID := make(map[models.TeamID]*models.Team, len(teams))
+		for _, team := range teams {
+			byID[team.ID] = team
+		}
+		selected := make([]*models.Team, 0, len(teamIDs))
+		for _, id := range teamIDs {
+			team, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("%w: %d", ErrTeamNotFound, id)
+			}
+			selected = append(selected, team)
+		}
+		teams = selected
+	}
+
+	bundle := &models.Bundle{
+		Version:    models.BundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Sources:    []models.BundleSource{},
+		Teams:      make([]models.BundleTeam, 0, len(teams)),
+	}
+
+	linked := make(map[models.SourceID]bool)
+	for _, team := range teams {
+		bundleTeam, err := exportBundleTeam(ctx, db, team)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range bundleTeam.Sources {
+			linked[link.SourceID] = true
+		}
+		bundle.Teams = append(bundle.Teams, *bundleTeam)
+	}
+
+	sources, err := db.ListSources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sources: %w", err)
+	}
+	for _, source := range sources {
+		if len(teamIDs) > 0 && !linked[source.ID] {
+			continue
+		}
+		bundle.Sources = append(bundle.Sources, models.BundleSource{
+			ID:                source.ID,
+			Name:              source.Name,
+			Description:       source.Description,
+			Host:              source.Connection.Host,
+			Database:          source.Connection.Database,
+			TableName:         source.Connection.TableName,
+			MetaTSField:       source.MetaTSField,
+			MetaSeverityField: source.MetaSeverityField,
+			TTLDays:           source.TTLDays,
+			FieldRoles:        source.FieldRoles,
+			SeverityMapping:   source.SeverityMapping,
+		})
+	}
+	return bundle, nil
+}
+
+// exportBundleTeam exports a team with its source links and collections.
+func exportBundleTeam(ctx context.Context, db *sqlite.DB, team *models.Team) (*models.BundleTeam, error) {
+	bundleTeam := &models.BundleTeam{
+		ID:                   team.ID,
+		Name:                 team.Name,
+		Description:          team.Description,
+		AllowAnonymousShares: team.AllowAnonymousShares,
+		Sources:              []models.BundleTeamSource{},
+		Collections:          []models.BundleCollection{},
+	}
+
+	sources, err := db.ListTeamSources(ctx, team.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sources of team %q: %w", team.Name, err)
+	}
+	for _, source := range sources {
+		filter, err := db.GetTeamSourceRowFilter(ctx, team.ID, source.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting row filter of team %q: %w", team.Name, err)
+		}
+		policies, err := db.ListColumnPolicies(ctx, team.ID, source.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error listing column policies of team %q: %w", team.Name, err)
+		}
+		link := models.BundleTeamSource{SourceID: source.ID, RowFilter: filter}
+		for _, policy := range policies {
+			link.ColumnPolicies = append(link.ColumnPolicies, models.BundleColumnPolicy{
+				Column:      policy.Column,
+				Action:      policy.Action,
+				Pattern:     policy.Pattern,
+				Replacement: policy.Replacement,
+			})
+		}
+		bundleTeam.Sources = append(bundleTeam.Sources, link)
+	}
+
+	folders, err := db.ListTeamCollectionFolders(ctx, team.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing folders of team %q: %w", team.Name, err)
+	}
+	folderNames := make(map[int]string, len(folders))
+	for _, folder := range folders {
+		folderNames[folder.ID] = folder.Name
+	}
+
+	queries, err := db.SearchTeamQueries(ctx, models.SavedQuerySearchFilter{TeamID: team.ID, Sort: models.SavedQuerySortName})
+	if err != nil {
+		return nil, fmt.Errorf("error listing collections of team %q: %w", team.Name, err)
+	}
+	for _, query := range queries {
+		var content map[string]any
+		if err := json.Unmarshal([]byte(query.QueryContent), &content); err != nil {
+			return nil, fmt.Errorf("error decoding content of collection %d: %w", query.ID, err)
+		}
+		collection := models.BundleCollection{
+			ID:          query.ID,
+			SourceID:    query.SourceID,
+			Name:        query.Name,
+			Description: query.Description,
+			QueryType:   query.QueryType,
+			Tags:        query.Tags,
+			Content:     content,
+		}
+		if query.FolderID != nil {
+			collection.Folder = folderNames[*query.FolderID]
+		}
+		bundleTeam.Collections = append(bundleTeam.Collections, collection)
+	}
+	return bundleTeam, nil
+}
+
+// --- Import ---
+
+// ImportBundle imports a bundle, matching sources by database and table, teams by name and
+// collections by team, source and name. Existing objects are handled by the conflict strategy;
+// sources, being identified by their table, are never renamed and are reused instead.
+// The whole bundle is validated before anything is written. A dry run reports the same
+// actions without writing. Map keys of sources whose team access changed are dropped from
+// mapKeys, which may be nil.
+func ImportBundle(ctx context.Context, db *sqlite.DB, mapKeys *MapKeysCache, log *slog.Logger, bundle *models.Bundle, opts models.BundleImportOptions) (*models.BundleImportResult, error) {
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = models.BundleConflictSkip
+	case models.BundleConflictSkip, models.BundleConflictOverwrite, models.BundleConflictRename:
+	default:
+		return nil, &ValidationError{Field: "conflict", Message: "conflict must be one of: skip, overwrite, rename"}
+	}
+	if err := validateBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	imp := &bundleImporter{
+		db:      db,
+		mapKeys: mapKeys,
+		log:     log,
+		opts:    opts,
+		result: &models.BundleImportResult{
+			DryRun:        opts.DryRun,
+			Conflict:      opts.Conflict,
+			Items:         []models.BundleImportItem{},
+			SourceIDs:     make(map[models.SourceID]models.SourceID),
+			TeamIDs:       make(map[models.TeamID]models.TeamID),
+			CollectionIDs: make(map[int]int),
+		},
+	}
+	for i := range bundle.Sources {
+		if err := imp.importSource(ctx, &bundle.Sources[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range bundle.Teams {
+		if err := imp.importTeam(ctx, &bundle.Teams[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info("bundle imported", "dry_run", opts.DryRun, "conflict", opts.Conflict, "items", len(imp.result.Items))
+	return imp.result, nil
+}
+
+// validateBundle checks a bundle and its references before anything is imported.
+// Collection tags are normalized in place.
+func validateBundle(bundle *models.Bundle) error {
+	if bundle.Version <= 0 || bundle.Version > models.BundleVersion {
+		return &ValidationError{Field: "version", Message: fmt.Sprintf("unsupported bundle version %d, this instance supports up to version %d", bundle.Version, models.BundleVersion)}
+	}
+
+	sources := make(map[models.SourceID]bool, len(bundle.Sources))
+	for _, source := range bundle.Sources {
+		if sources[source.ID] {
+			return &ValidationError{Field: "sources", Message: fmt.Sprintf("duplicate source id %d", source.ID)}
+		}
+		sources[source.ID] = true
+		conn := models.ConnectionInfo{Host: source.Host, Database: source.Database, TableName: source.TableName}
+		if err := validateSourceCreation(source.Name, conn, source.Description, source.TTLDays, source.MetaTSField, source.MetaSeverityField); err != nil {
+			return &ValidationError{Field: "sources", Message: fmt.Sprintf("source %q: %v", source.Name, err)}
+		}
+		if err := validateSourceFields(source.FieldRoles, source.SeverityMapping, source.MetaSeverityField); err != nil {
+			return &ValidationError{Field: "sources", Message: fmt.Sprintf("source %q: %v", source.Name, err)}
+		}
+	}
+
+	teams := make(map[string]bool, len(bundle.Teams))
+	for i := range bundle.Teams {
+		team := &bundle.Teams[i]
+		if err := validateTeamCreation(team.Name, team.Description); err != nil {
+			return &ValidationError{Field: "teams", Message: fmt.Sprintf("team %q: %v", team.Name, err)}
+		}
+		if teams[team.Name] {
+			return &ValidationError{Field: "teams", Message: fmt.Sprintf("duplicate team %q", team.Name)}
+		}
+		teams[team.Name] = true
+
+		linked := make(map[models.SourceID]bool, len(team.Sources))
+		for i := range team.Sources {
+			link := &team.Sources[i]
+			if !sources[link.SourceID] {
+				return &ValidationError{Field: "teams", Message: fmt.Sprintf("team %q: unknown source id %d", team.Name, link.SourceID)}
+			}
+			if link.RowFilter != "" {
+				if err := clickhouse.ValidateRowFilter(link.RowFilter); err != nil {
+					return &ValidationError{Field: "teams", Message: fmt.Sprintf("team %q: invalid row filter: %v", team.Name, err)}
+				}
+			}
+			policies := bundleColumnPolicies(link)
+			if err := validateLinkColumnPolicies(policies); err != nil {
+				return &ValidationError{Field: "teams", Message: fmt.Sprintf("team %q, source id %d: invalid column policy: %v", team.Name, link.SourceID, err)}
+			}
+			for j, policy := range policies {
+				link.ColumnPolicies[j] = models.BundleColumnPolicy{Column: policy.Column, Action: policy.Action, Pattern: policy.Pattern, Replacement: policy.Replacement}
+			}
+			linked[link.SourceID] = true
+		}
+
+		for j := range team.Collections {
+			collection := &team.Collections[j]
+			if err := validateBundleCollection(collection, linked); err != nil {
+				return &ValidationError{Field: "collections", Message: fmt.Sprintf("team %q, collection %q: %v", team.Name, collection.Name, err)}
+			}
+		}
+	}
+	return nil
+}
+
+// validateBundleCollection checks a collection of a bundle team and normalizes its tags.
+func validateBundleCollection(collection *models.BundleCollection, linked map[models.SourceID]bool) error {
+	if !linked[collection.SourceID] {
+		return fmt.Errorf("source id %d is not linked to the team", collection.SourceID)
+	}
+	tags, err := validateCollectionDefinition(collection.Name, collection.QueryType, collection.Folder, collection.Tags, collection.Content)
+	if err != nil {
+		return err
+	}
+	collection.Tags = tags
+	return nil
+}
+
+// validateCollectionDefinition checks a collection declared in a file, returning its
+// normalized tags.
+func validateCollectionDefinition(name string, queryType models.SavedQueryType, folder string, tags []string, content map[string]any) ([]string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if queryType != models.SavedQueryTypeSQL && queryType != models.SavedQueryTypeLogchefQL {
+		return nil, ErrInvalidQueryType
+	}
+	if folder != "" {
+		if _, err := validateFolderName(folder); err != nil {
+			return nil, err
+		}
+	}
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content: %w", err)
+	}
+	if err := ValidateSavedQueryContent(string(data)); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// bundleImporter applies a validated bundle, recording each action in the result.
+type bundleImporter struct {
+	db      *sqlite.DB
+	mapKeys *MapKeysCache
+	log     *slog.Logger
+	opts    models.BundleImportOptions
+	result  *models.BundleImportResult
+}
+
+// record adds an item to the import result.
+func (imp *bundleImporter) record(kind, name string, action models.BundleImportAction, oldID, newID int, message string) {
+	imp.result.Items = append(imp.result.Items, models.BundleImportItem{
+		Kind:    kind,
+		Name:    name,
+		Action:  action,
+		OldID:   oldID,
+		NewID:   newID,
+		Message: message,
+	})
+}
+
+// importSource creates a bundle source, or reuses or overwrites the source of the same table.
+// Created sources have no credentials.
+func (imp *bundleImporter) importSource(ctx context.Context, bs *models.BundleSource) error {
+	existing, err := imp.db.GetSourceByName(ctx, bs.Database, bs.TableName)
+	if err != nil && !sqlite.IsNotFoundError(err) {
+		return fmt.Errorf("error looking up source %q: %w", bs.Name, err)
+	}
+
+	if existing == nil {
+		source := &models.Source{
+			Name:              bs.Name,
+			Description:       bs.Description,
+			MetaTSField:       bs.MetaTSField,
+			MetaSeverityField: bs.MetaSeverityField,
+			TTLDays:           bs.TTLDays,
+			FieldRoles:        bs.FieldRoles,
+			SeverityMapping:   bs.SeverityMapping,
+			Connection: models.ConnectionInfo{
+				Host:      bs.Host,
+				Database:  bs.Database,
+				TableName: bs.TableName,
+			},
+		}
+		if !imp.opts.DryRun {
+			if err := imp.db.CreateSource(ctx, source); err != nil {
+				return fmt.Errorf("error creating source %q: %w", bs.Name, err)
+			}
+			imp.result.SourceIDs[bs.ID] = source.ID
+			imp.result.CreatedSources = append(imp.result.CreatedSources, source.ID)
+		}
+		imp.record("source", bs.Name, models.BundleImportCreate, int(bs.ID), int(source.ID), "credentials must be set before the source can be queried")
+		return nil
+	}
+
+	imp.result.SourceIDs[bs.ID] = existing.ID
+	if imp.opts.Conflict != models.BundleConflictOverwrite {
+		imp.record("source", bs.Name, models.BundleImportSkip, int(bs.ID), int(existing.ID), "a source for this table already exists")
+		return nil
+	}
+	if existing.Provisioned {
+		imp.record("source", bs.Name, models.BundleImportSkip, int(bs.ID), int(existing.ID), "source is managed by provisioning")
+		return nil
+	}
+
+	// Credentials aren't part of bundles, so the existing ones are kept.
+	existing.Name = bs.Name
+	existing.Description = bs.Description
+	existing.MetaTSField = bs.MetaTSField
+	existing.MetaSeverityField = bs.MetaSeverityField
+	existing.TTLDays = bs.TTLDays
+	existing.FieldRoles = bs.FieldRoles
+	existing.SeverityMapping = bs.SeverityMapping
+	existing.Connection.Host = bs.Host
+	if !imp.opts.DryRun {
+		if err := imp.db.UpdateSource(ctx, existing); err != nil {
+			return fmt.Errorf("error updating source %q: %w", bs.Name, err)
+		}
+	}
+	imp.record("source", bs.Name, models.BundleImportUpdate, int(bs.ID), int(existing.ID), "")
+	return nil
+}
+
+// importTeam imports a team, then its source links and collections.
+func (imp *bundleImporter) importTeam(ctx context.Context, bt *models.BundleTeam) error {
+	existing, err := imp.db.GetTeamByName(ctx, bt.Name)
+	if err != nil && !sqlite.IsNotFoundError(err) && !sqlite.IsTeamNotFoundError(err) {
+		return fmt.Errorf("error looking up team %q: %w", bt.Name, err)
+	}
+
+	var teamID models.TeamID
+	switch {
+	case existing == nil:
+		if teamID, err = imp.createTeam(ctx, bt, bt.Name); err != nil {
+			return err
+		}
+		imp.record("team", bt.Name, models.BundleImportCreate, int(bt.ID), int(teamID), "")
+	case imp.opts.Conflict == models.BundleConflictRename:
+		name, err := imp.uniqueTeamName(ctx, bt.Name)
+		if err != nil {
+			return err
+		}
+		if teamID, err = imp.createTeam(ctx, bt, name); err != nil {
+			return err
+		}
+		imp.record("team", name, models.BundleImportRename, int(bt.ID), int(teamID), fmt.Sprintf("team %q already exists", bt.Name))
+	case existing.Provisioned:
+		// Its links would be reverted by the next provisioning run, so only collections
+		// are imported into it.
+		teamID = existing.ID
+		imp.record("team", bt.Name, models.BundleImportSkip, int(bt.ID), int(teamID), "team is managed by provisioning")
+	case imp.opts.Conflict == models.BundleConflictOverwrite:
+		teamID = existing.ID
+		existing.Description = bt.Description
+		existing.AllowAnonymousShares = bt.AllowAnonymousShares
+		existing.UpdatedAt = time.Now().UTC()
+		if !imp.opts.DryRun {
+			if err := imp.db.UpdateTeam(ctx, existing); err != nil {
+				return fmt.Errorf("error updating team %q: %w", bt.Name, err)
+			}
+		}
+		imp.record("team", bt.Name, models.BundleImportUpdate, int(bt.ID), int(teamID), "")
+	default:
+		teamID = existing.ID
+		imp.record("team", bt.Name, models.BundleImportSkip, int(bt.ID), int(teamID), "team already exists")
+	}
+	if teamID != 0 {
+		imp.result.TeamIDs[bt.ID] = teamID
+	}
+
+	for _, link := range bt.Sources {
+		if existing != nil && existing.Provisioned && teamID == existing.ID {
+			imp.record("team_source", fmt.Sprintf("%s/%d", bt.Name, link.SourceID), models.BundleImportSkip, int(link.SourceID), int(imp.result.SourceIDs[link.SourceID]), "team is managed by provisioning")
+			continue
+		}
+		if err := imp.importTeamSource(ctx, bt, teamID, link); err != nil {
+			return err
+		}
+	}
+	return imp.importCollections(ctx, bt, teamID)
+}
+
+// createTeam creates a bundle team under the given name. Returns 0 in a dry run.
+func (imp *bundleImporter) createTeam(ctx context.Context, bt *models.BundleTeam, name string) (models.TeamID, error) {
+	if imp.opts.DryRun {
+		return 0, nil
+	}
+	team := &models.Team{Name: name, Description: bt.Description}
+	if err := imp.db.CreateTeam(ctx, team); err != nil {
+		return 0, fmt.Errorf("error creating team %q: %w", name, err)
+	}
+	if bt.AllowAnonymousShares {
+		team.AllowAnonymousShares = true
+		team.UpdatedAt = time.Now().UTC()
+		if err := imp.db.UpdateTeam(ctx, team); err != nil {
+			return 0, fmt.Errorf("error updating team %q: %w", name, err)
+		}
+	}
+	return team.ID, nil
+}
+
+// uniqueTeamName returns a name for a renamed team that no team uses yet.
+func (imp *bundleImporter) uniqueTeamName(ctx context.Context, name string) (string, error) {
+	for i := 1; ; i++ {
+		suffix := " imported"
+		if i > 1 {
+			suffix = fmt.Sprintf(" imported %d", i)
+		}
+		// Keep within the team name length limit.
+		candidate := truncateString(name, 50-len(suffix)) + suffix
+		_, err := imp.db.GetTeamByName(ctx, candidate)
+		if sqlite.IsNotFoundError(err) || sqlite.IsTeamNotFoundError(err) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error looking up team %q: %w", candidate, err)
+		}
+	}
+}
+
+// bundleColumnPolicies returns the column policies of a bundle team-source link.
+func bundleColumnPolicies(link *models.BundleTeamSource) []*models.ColumnPolicy {
+	policies := make([]*models.ColumnPolicy, 0, len(link.ColumnPolicies))
+	for _, policy := range link.ColumnPolicies {
+		policies = append(policies, &models.ColumnPolicy{
+			Column:      policy.Column,
+			Action:      policy.Action,
+			Pattern:     policy.Pattern,
+			Replacement: policy.Replacement,
+		})
+	}
+	return policies
+}
+
+// teamSourceAccessSummary describes the restrictions a team-source link is imported with.
+func teamSourceAccessSummary(link models.BundleTeamSource) string {
+	var parts []string
+	if link.RowFilter != "" {
+		parts = append(parts, "row filter")
+	}
+	switch n := len(link.ColumnPolicies); n {
+	case 0:
+	case 1:
+		parts = append(parts, "1 column policy")
+	default:
+		parts = append(parts, fmt.Sprintf("%d column policies", n))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "with " + strings.Join(parts, " and ")
+}
+
+// importTeamSource links a team to a source, or replaces the row filter and column policies
+// of an existing link when overwriting. The link and its restrictions are written together.
+func (imp *bundleImporter) importTeamSource(ctx context.Context, bt *models.BundleTeam, teamID models.TeamID, link models.BundleTeamSource) error {
+	sourceID := imp.result.SourceIDs[link.SourceID]
+	name := fmt.Sprintf("%s/%d", bt.Name, link.SourceID)
+
+	linked := false
+	if teamID != 0 && sourceID != 0 {
+		var err error
+		if linked, err = imp.db.TeamHasSource(ctx, teamID, sourceID); err != nil {
+			return fmt.Errorf("error checking source link of team %q: %w", bt.Name, err)
+		}
+	}
+
+	switch {
+	case !linked:
+		if !imp.opts.DryRun {
+			if err := imp.db.SetTeamSourceAccess(ctx, teamID, sourceID, link.RowFilter, bundleColumnPolicies(&link)); err != nil {
+				return fmt.Errorf("error linking source to team %q: %w", bt.Name, err)
+			}
+		}
+		imp.record("team_source", name, models.BundleImportCreate, int(link.SourceID), int(sourceID), teamSourceAccessSummary(link))
+	case imp.opts.Conflict == models.BundleConflictOverwrite:
+		if !imp.opts.DryRun {
+			if err := imp.db.SetTeamSourceAccess(ctx, teamID, sourceID, link.RowFilter, bundleColumnPolicies(&link)); err != nil {
+				return fmt.Errorf("error setting source access of team %q: %w", bt.Name, err)
+			}
+			imp.mapKeys.Invalidate(sourceID)
+		}
+		imp.record("team_source", name, models.BundleImportUpdate, int(link.SourceID), int(sourceID), teamSourceAccessSummary(link))
+	default:
+		imp.record("team_source", name, models.BundleImportSkip, int(link.SourceID), int(sourceID), "source already linked to the team")
+	}
+	return nil
+}
+
+// collectionKey identifies a collection within a team.
+type collectionKey struct {
+	sourceID models.SourceID
+	name     string
+}
+
+// importCollections imports the collections of a team.
+func (imp *bundleImporter) importCollections(ctx context.Context, bt *models.BundleTeam, teamID models.TeamID) error {
+	existing := make(map[collectionKey]*models.SavedTeamQuery)
+	folders := make(map[string]int)
+	if teamID != 0 {
+		queries, err := imp.db.SearchTeamQueries(ctx, models.SavedQuerySearchFilter{TeamID: teamID, Sort: models.SavedQuerySortName})
+		if err != nil {
+			return fmt.Errorf("error listing collections of team %q: %w", bt.Name, err)
+		}
+		for _, query := range queries {
+			existing[collectionKey{query.SourceID, query.Name}] = query
+		}
+		teamFolders, err := imp.db.ListTeamCollectionFolders(ctx, teamID)
+		if err != nil {
+			return fmt.Errorf("error listing folders of team %q: %w", bt.Name, err)
+		}
+		for _, folder := range teamFolders {
+			folders[folder.Name] = folder.ID
+		}
+	}
+
+	for i := range bt.Collections {
+		bc := &bt.Collections[i]
+		sourceID := imp.result.SourceIDs[bc.SourceID]
+
+		// Point the query at the source's ID in this instance.
+		bc.Content["sourceId"] = int(sourceID)
+		content, err := json.Marshal(bc.Content)
+		if err != nil {
+			return fmt.Errorf("error encoding collection %q: %w", bc.Name, err)
+		}
+
+		name := bc.Name
+		current := existing[collectionKey{sourceID, name}]
+		action := models.BundleImportCreate
+		message := ""
+		switch {
+		case current == nil:
+		case imp.opts.Conflict == models.BundleConflictRename:
+			action = models.BundleImportRename
+			message = fmt.Sprintf("collection %q already exists", bc.Name)
+			for n := 1; existing[collectionKey{sourceID, name}] != nil; n++ {
+				name = fmt.Sprintf("%s (imported)", bc.Name)
+				if n > 1 {
+					name = fmt.Sprintf("%s (imported %d)", bc.Name, n)
+				}
+			}
+		case imp.opts.Conflict == models.BundleConflictOverwrite && !current.Provisioned:
+			action = models.BundleImportUpdate
+		default:
+			message = "collection already exists"
+			if current.Provisioned {
+				message = "collection is managed by provisioning"
+			}
+			imp.result.CollectionIDs[bc.ID] = current.ID
+			imp.record("collection", bt.Name+"/"+bc.Name, models.BundleImportSkip, bc.ID, current.ID, message)
+			continue
+		}
+
+		id := 0
+		if !imp.opts.DryRun {
+			if id, err = imp.writeCollection(ctx, teamID, sourceID, current, action, name, bc, string(content), folders); err != nil {
+				return fmt.Errorf("error importing collection %q of team %q: %w", bc.Name, bt.Name, err)
+			}
+			imp.result.CollectionIDs[bc.ID] = id
+		}
+		if action != models.BundleImportUpdate {
+			// Track the new name so later collections of the bundle see it.
+			existing[collectionKey{sourceID, name}] = &models.SavedTeamQuery{ID: id, Name: name}
+		}
+		imp.record("collection", bt.Name+"/"+name, action, bc.ID, id, message)
+	}
+	return nil
+}
+
+// writeCollection creates or overwrites a collection with its folder and tags, returning its ID.
+func (imp *bundleImporter) writeCollection(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, current *models.SavedTeamQuery, action models.BundleImportAction, name string, bc *models.BundleCollection, content string, folders map[string]int) (int, error) {
+	var id int
+	if action == models.BundleImportUpdate {
+		id = current.ID
+		if err := imp.db.UpdateTeamSourceQuery(ctx, teamID, sourceID, id, name, bc.Description, string(bc.QueryType), content, imp.opts.ImportedBy); err != nil {
+			return 0, err
+		}
+	} else {
+		query := &models.TeamQuery{
+			TeamID:       teamID,
+			SourceID:     sourceID,
+			Name:         name,
+			Description:  bc.Description,
+			QueryType:    bc.QueryType,
+			QueryContent: content,
+			CreatedBy:    imp.opts.ImportedBy,
+		}
+		if err := imp.db.CreateTeamSourceQuery(ctx, query); err != nil {
+			return 0, err
+		}
+		id = query.ID
+	}
+
+	var folderID *int
+	if bc.Folder != "" {
+		fid, ok := folders[bc.Folder]
+		if !ok {
+			folder := &models.CollectionFolder{TeamID: teamID, Name: bc.Folder}
+			if err := imp.db.CreateTeamCollectionFolder(ctx, folder); err != nil {
+				return 0, err
+			}
+			fid = folder.ID
+			folders[bc.Folder] = fid
+		}
+		folderID = &fid
+	}
+	if err := imp.db.SetTeamQueryFolder(ctx, teamID, sourceID, id, folderID); err != nil {
+		return 0, err
+	}
+	if err := imp.db.SetTeamQueryTags(ctx, id, bc.Tags); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// truncateString shortens s to at most n bytes without splitting a UTF-8 character.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// utf8RuneStart reports whether b can start a UTF-8 encoded character.
+func utf8RuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+func TestValidateBundleColumnPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []models.BundleColumnPolicy
+		wantErr  string
+	}{
+		{name: "no policies"},
+		{name: "valid policies", policies: []models.BundleColumnPolicy{
+			{Column: " remote_addr ", Action: models.ColumnPolicyHide, Pattern: "ignored"},
+			{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d+`},
+		}},
+		{name: "unknown action", policies: []models.BundleColumnPolicy{{Column: "body", Action: "drop"}}, wantErr: "unknown action"},
+		{name: "redact without pattern", policies: []models.BundleColumnPolicy{{Column: "body", Action: models.ColumnPolicyRedact}}, wantErr: "pattern is required"},
+		{name: "invalid pattern", policies: []models.BundleColumnPolicy{{Column: "body", Action: models.ColumnPolicyRedact, Pattern: "("}}, wantErr: "invalid redact pattern"},
+		{name: "missing column", policies: []models.BundleColumnPolicy{{Action: models.ColumnPolicyHash}}, wantErr: "column is required"},
+		{name: "duplicate column", policies: []models.BundleColumnPolicy{
+			{Column: "body", Action: models.ColumnPolicyHash},
+			{Column: "Body", Action: models.ColumnPolicyHide},
+		}, wantErr: "duplicate column policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &models.Bundle{
+				Version: models.BundleVersion,
+				Sources: []models.BundleSource{{ID: 1, Name: "logs", Host: "localhost:9000", Database: "db", TableName: "logs", MetaTSField: "timestamp", TTLDays: 30}},
+				Teams: []models.BundleTeam{{Name: "payments", Sources: []models.BundleTeamSource{
+					{SourceID: 1, RowFilter: "namespace = 'payments'", ColumnPolicies: tt.policies},
+				}}},
+			}
+			err := validateBundle(bundle)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("error %v does not contain %q", err, tt.wantErr)
+			}
+			if tt.wantErr == "" && len(tt.policies) > 0 {
+				got := bundle.Teams[0].Sources[0].ColumnPolicies[0]
+				if got.Column != "remote_addr" || got.Pattern != "" {
+					t.Errorf("policy not normalized: %+v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestTeamSourceAccessSummary(t *testing.T) {
+	tests := []struct {
+		link models.BundleTeamSource
+		want string
+	}{
+		{models.BundleTeamSource{}, ""},
+		{models.BundleTeamSource{RowFilter: "env = 'prod'"}, "with row filter"},
+		{models.BundleTeamSource{ColumnPolicies: make([]models.BundleColumnPolicy, 1)}, "with 1 column policy"},
+		{models.BundleTeamSource{RowFilter: "env = 'prod'", ColumnPolicies: make([]models.BundleColumnPolicy, 2)}, "with row filter and 2 column policies"},
+	}
+	for _, tt := range tests {
+		if got := teamSourceAccessSummary(tt.link); got != tt.want {
+			t.Errorf("teamSourceAccessSummary(%+v) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
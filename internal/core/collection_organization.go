@@ -0,0 +1,270 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+const (
+	// MaxTagsPerQuery is the maximum number of tags of a saved query.
+	MaxTagsPerQuery = 20
+	// MaxFolderNameLength is the maximum length of a collection folder name.
+	MaxFolderNameLength = 100
+	// DefaultCollectionPageSize is the number of search results returned when no limit is given.
+	DefaultCollectionPageSize = 50
+	// MaxCollectionPageSize is the maximum number of search results per page.
+	MaxCollectionPageSize = 200
+)
+
+var (
+	// ErrFolderNotFound is returned when a collection folder doesn't exist for the team.
+	ErrFolderNotFound = errors.New("collection folder not found")
+	// ErrFolderAlreadyExists is returned when a team already has a folder with the same name.
+	ErrFolderAlreadyExists = errors.New("collection folder already exists")
+	// ErrFavoriteNotFound is returned when removing a saved query that isn't a favorite.
+	ErrFavoriteNotFound = errors.New("saved query is not a favorite")
+)
+
+// tagPattern matches a normalized tag. Commas are excluded so tag lists can be concatenated.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:/-]{0,49}$`)
+
+// --- Collection Folders ---
+
+// ListCollectionFolders returns the collection folders of a team.
+func ListCollectionFolders(ctx context.Context, db *sqlite.DB, teamID models.TeamID) ([]*models.CollectionFolder, error) {
+	return db.ListTeamCollectionFolders(ctx, teamID)
+}
+
+// CreateCollectionFolder creates a collection folder for a team.
+func CreateCollectionFolder(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, name string) (*models.CollectionFolder, error) {
+	name, err := validateFolderName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &models.CollectionFolder{TeamID: teamID, Name: name}
+	if err := db.CreateTeamCollectionFolder(ctx, folder); err != nil {
+		if errors.Is(err, sqlite.ErrUniqueConstraint) {
+			return nil, fmt.Errorf("%w: %q", ErrFolderAlreadyExists, name)
+		}
+		return nil, fmt.Errorf("error creating collection folder: %w", err)
+	}
+
+	log.Info("collection folder created", "folder_id", folder.ID, "team_id", teamID)
+	return db.GetTeamCollectionFolder(ctx, teamID, folder.ID)
+}
+
+// RenameCollectionFolder renames a collection folder of a team.
+func RenameCollectionFolder(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, folderID int, name string) (*models.CollectionFolder, error) {
+	name, err := validateFolderName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.UpdateTeamCollectionFolder(ctx, teamID, folderID, name); err != nil {
+		switch {
+		case sqlite.IsNotFoundError(err):
+			return nil, ErrFolderNotFound
+		case errors.Is(err, sqlite.ErrUniqueConstraint):
+			return nil, fmt.Errorf("%w: %q", ErrFolderAlreadyExists, name)
+		}
+		return nil, fmt.Errorf("error renaming collection folder: %w", err)
+	}
+
+	log.Info("collection folder renamed", "folder_id", folderID, "team_id", teamID)
+	return db.GetTeamCollectionFolder(ctx, teamID, folderID)
+}
+
+// DeleteCollectionFolder deletes a collection folder of a team. Its saved queries are kept, outside any folder.
+func DeleteCollectionFolder(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, folderID int) error {
+	if err := db.DeleteTeamCollectionFolder(ctx, teamID, folderID); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return ErrFolderNotFound
+		}
+		return fmt.Errorf("error deleting collection folder: %w", err)
+	}
+
+	log.Info("collection folder deleted", "folder_id", folderID, "team_id", teamID)
+	return nil
+}
+
+// validateFolderName trims and checks a folder name.
+func validateFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", &ValidationError{Field: "name", Message: "folder name is required"}
+	}
+	if len(name) > MaxFolderNameLength {
+		return "", &ValidationError{Field: "name", Message: fmt.Sprintf("folder name cannot exceed %d characters", MaxFolderNameLength)}
+	}
+	return name, nil
+}
+
+// --- Folder Placement and Tags ---
+
+// UpdateSavedQueryOrganization moves a saved query into a folder and/or replaces its tags.
+// A nil argument leaves that setting unchanged and a folder ID of 0 removes the query from its folder.
+// These changes don't create a revision.
+func UpdateSavedQueryOrganization(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int, folderID *int, tags *[]string) error {
+	query, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID)
+	if err != nil {
+		return err
+	}
+	if query.Provisioned {
+		return ErrProvisioned
+	}
+
+	if folderID != nil {
+		var target *int
+		if *folderID != 0 {
+			if _, err := db.GetTeamCollectionFolder(ctx, teamID, *folderID); err != nil {
+				if sqlite.IsNotFoundError(err) {
+					return &ValidationError{Field: "folder_id", Message: "folder not found for this team"}
+				}
+				return fmt.Errorf("error getting collection folder: %w", err)
+			}
+			target = folderID
+		}
+		if err := db.SetTeamQueryFolder(ctx, teamID, sourceID, queryID, target); err != nil {
+			return fmt.Errorf("error moving saved query to folder: %w", err)
+		}
+	}
+
+	if tags != nil {
+		normalized, err := normalizeTags(*tags)
+		if err != nil {
+			return err
+		}
+		if err := db.SetTeamQueryTags(ctx, queryID, normalized); err != nil {
+			return fmt.Errorf("error setting saved query tags: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListTeamTags returns the distinct tags used by the saved queries of a team. When allowSource
+// is set, only the tags of queries on the sources it allows are listed.
+func ListTeamTags(ctx context.Context, db *sqlite.DB, teamID models.TeamID, allowSource func(models.SourceID) bool) ([]string, error) {
+	if allowSource == nil {
+		return db.ListTeamTags(ctx, teamID)
+	}
+	queries, err := db.SearchTeamQueries(ctx, models.SavedQuerySearchFilter{TeamID: teamID, Sort: models.SavedQuerySortName})
+	if err != nil {
+		return nil, err
+	}
+	tags := []string{}
+	for _, query := range queries {
+		if allowSource(query.SourceID) {
+			tags = append(tags, query.Tags...)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags), nil
+}
+
+// normalizeTags lowercases, validates and de-duplicates tags, keeping their order.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("invalid tag %q: use up to 50 letters, digits and _ . : / -", tag)}
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTagsPerQuery {
+		return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("a saved query can have at most %d tags", MaxTagsPerQuery)}
+	}
+	return normalized, nil
+}
+
+// --- Favorites ---
+
+// SetSavedQueryFavorite adds a saved query to the user's favorites, or updates whether it's pinned.
+func SetSavedQueryFavorite(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int, userID models.UserID, pinned bool) error {
+	if _, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID); err != nil {
+		return err
+	}
+	return db.SetUserQueryFavorite(ctx, userID, queryID, pinned)
+}
+
+// RemoveSavedQueryFavorite removes a saved query from the user's favorites.
+func RemoveSavedQueryFavorite(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int, userID models.UserID) error {
+	if _, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID); err != nil {
+		return err
+	}
+	if err := db.DeleteUserQueryFavorite(ctx, userID, queryID); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return ErrFavoriteNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// --- Search ---
+
+// SearchSavedQueries returns a page of the team's saved queries across all its sources matching
+// the filter, with the user's pinned favorites first.
+func SearchSavedQueries(ctx context.Context, db *sqlite.DB, filter models.SavedQuerySearchFilter) ([]*models.SavedTeamQuery, error) {
+	if err := validateSavedQuerySort(&filter.Sort, models.SavedQuerySortLastUsed); err != nil {
+		return nil, err
+	}
+	if filter.Tag != "" {
+		filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	}
+	filter.Search = strings.TrimSpace(filter.Search)
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultCollectionPageSize
+	}
+	if filter.Limit > MaxCollectionPageSize {
+		filter.Limit = MaxCollectionPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.AllowSource == nil {
+		return db.SearchTeamQueries(ctx, filter)
+	}
+
+	// Disallowed sources are filtered out before paging, so pages stay full.
+	limit, offset := filter.Limit, filter.Offset
+	filter.Limit, filter.Offset = 0, 0
+	queries, err := db.SearchTeamQueries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	queries = slices.DeleteFunc(queries, func(query *models.SavedTeamQuery) bool {
+		return !filter.AllowSource(query.SourceID)
+	})
+	if offset >= len(queries) {
+		return []*models.SavedTeamQuery{}, nil
+	}
+	return queries[offset:min(offset+limit, len(queries))], nil
+}
+
+// validateSavedQuerySort checks the sort order, applying the default when unset.
+func validateSavedQuerySort(sort *models.SavedQuerySort, def models.SavedQuerySort) error {
+	switch *sort {
+	case "":
+		*sort = def
+	case models.SavedQuerySortLastUsed, models.SavedQuerySortUpdated, models.SavedQuerySortName:
+	default:
+		return &ValidationError{Field: "sort", Message: "sort must be one of: last_used, updated, name"}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Column Policy Functions ---
+
+// ErrColumnPolicyNotFound is returned when a column policy doesn't exist for the team's source.
+var ErrColumnPolicyNotFound = errors.New("column policy not found")
+
+// ListColumnPolicies returns the column policies for a team's access to a source.
+func ListColumnPolicies(ctx context.Context, db *sqlite.DB, teamID models.TeamID, sourceID models.SourceID) ([]*models.ColumnPolicy, error) {
+	// Ensure the source is linked to the team.
+	if _, err := GetTeamSourceRowFilter(ctx, db, teamID, sourceID); err != nil {
+		return nil, err
+	}
+
+	policies, err := db.ListColumnPolicies(ctx, teamID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing column policies: %w", err)
+	}
+	return policies, nil
+}
+
+// SetColumnPolicy validates and creates or replaces the policy for a column of a team's source.
+func SetColumnPolicy(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, mapKeys *MapKeysCache, log *slog.Logger, policy *models.ColumnPolicy) error {
+	policy.Column = strings.TrimSpace(policy.Column)
+	if err := clickhouse.ValidateColumnPolicy(policy); err != nil {
+		return &ValidationError{Field: "column_policy", Message: err.Error()}
+	}
+	if policy.Action != models.ColumnPolicyRedact {
+		policy.Pattern, policy.Replacement = "", ""
+	}
+
+	if _, err := GetTeamSourceRowFilter(ctx, db, policy.TeamID, policy.SourceID); err != nil {
+		return err
+	}
+
+	// The column must exist on the source's table.
+	source, err := db.GetSource(ctx, policy.SourceID)
+	if err != nil {
+		return fmt.Errorf("error getting source details: %w", err)
+	}
+	if source == nil {
+		return ErrSourceNotFound
+	}
+	client, err := chDB.GetConnection(policy.SourceID)
+	if err != nil {
+		return fmt.Errorf("error getting database connection for source %d: %w", policy.SourceID, err)
+	}
+	tableInfo, err := client.GetTableInfo(ctx, source.Connection.Database, source.Connection.TableName)
+	if err != nil {
+		return fmt.Errorf("error retrieving schema for source %d: %w", policy.SourceID, err)
+	}
+	exists := false
+	for _, col := range tableInfo.Columns {
+		if strings.EqualFold(col.Name, policy.Column) {
+			policy.Column = col.Name
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		return &ValidationError{Field: "column", Message: fmt.Sprintf("column %q does not exist on this source", policy.Column)}
+	}
+
+	log.Info("setting column policy", "team_id", policy.TeamID, "source_id", policy.SourceID, "column", policy.Column, "action", policy.Action)
+	if err := db.UpsertColumnPolicy(ctx, policy); err != nil {
+		log.Error("failed to set column policy in db", "error", err, "team_id", policy.TeamID, "source_id", policy.SourceID)
+		return fmt.Errorf("error setting column policy: %w", err)
+	}
+
+	// Discovered Map keys include example values that may now be restricted.
+	mapKeys.Invalidate(policy.SourceID)
+	return nil
+}
+
+// DeleteColumnPolicy removes a column policy from a team's source.
+func DeleteColumnPolicy(ctx context.Context, db *sqlite.DB, mapKeys *MapKeysCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, policyID int) error {
+	log.Info("deleting column policy", "team_id", teamID, "source_id", sourceID, "policy_id", policyID)
+	if err := db.DeleteColumnPolicy(ctx, teamID, sourceID, policyID); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return ErrColumnPolicyNotFound
+		}
+		return fmt.Errorf("error deleting column policy: %w", err)
+	}
+
+	mapKeys.Invalidate(sourceID)
+	return nil
+}
+
+// validateLinkColumnPolicies validates the column policies declared for a team-source link
+// and normalizes them like SetColumnPolicy does. Columns can't be checked against the
+// source's table, which may not be reachable yet.
+func validateLinkColumnPolicies(policies []*models.ColumnPolicy) error {
+	seen := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		policy.Column = strings.TrimSpace(policy.Column)
+		if err := clickhouse.ValidateColumnPolicy(policy); err != nil {
+			return err
+		}
+		if policy.Action != models.ColumnPolicyRedact {
+			policy.Pattern, policy.Replacement = "", ""
+		}
+		if seen[strings.ToLower(policy.Column)] {
+			return fmt.Errorf("duplicate column policy for %q", policy.Column)
+		}
+		seen[strings.ToLower(policy.Column)] = true
+	}
+	return nil
+}
+
+// newTeamQueryBuilder returns a query builder for the source that enforces the team's
+// row-level filter and column policies. The table schema is only fetched when policies exist.
+func newTeamQueryBuilder(ctx context.Context, db *sqlite.DB, client *clickhouse.Client, source *models.Source, teamID models.TeamID) (*clickhouse.QueryBuilder, error) {
+	rowFilter, err := GetTeamSourceRowFilter(ctx, db, teamID, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	qb := clickhouse.NewQueryBuilder(source.GetFullTableName()).WithRowFilters(rowFilter)
+
+	policies, err := db.ListColumnPolicies(ctx, teamID, source.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing column policies: %w", err)
+	}
+	if len(policies) > 0 {
+		tableInfo, err := client.GetTableInfo(ctx, source.Connection.Database, source.Connection.TableName)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving schema for source %d: %w", source.ID, err)
+		}
+		qb.WithColumnPolicies(tableInfo.Columns, policies)
+	}
+	return qb, nil
+}
+
+// ValidateTeamQuery checks that a query can run for a team under its row-level filter
+// and column policies, without executing it.
+func ValidateTeamQuery(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, teamID models.TeamID, sourceID models.SourceID, rawSQL string) error {
+	source, err := db.GetSource(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("error getting source details: %w", err)
+	}
+	if source == nil {
+		return ErrSourceNotFound
+	}
+	client, err := chDB.GetConnection(sourceID)
+	if err != nil {
+		return fmt.Errorf("error getting database connection for source %d: %w", sourceID, err)
+	}
+	qb, err := newTeamQueryBuilder(ctx, db, client, source, teamID)
+	if err != nil {
+		return err
+	}
+	if _, err := qb.BuildRawQuery(rawSQL, 0); err != nil {
+		return &ValidationError{Field: "query", Message: err.Error()}
+	}
+	return nil
+}
+
+// ApplyColumnPolicies removes hidden columns from a schema. Masked columns are
+// reported as strings, since that's what queries return for them.
+func ApplyColumnPolicies(columns []models.ColumnInfo, policies []*models.ColumnPolicy) []models.ColumnInfo {
+	if len(policies) == 0 {
+		return columns
+	}
+	byColumn := make(map[string]*models.ColumnPolicy, len(policies))
+	for _, policy := range policies {
+		byColumn[strings.ToLower(policy.Column)] = policy
+	}
+
+	visible := make([]models.ColumnInfo, 0, len(columns))
+	for _, col := range columns {
+		policy, restricted := byColumn[strings.ToLower(col.Name)]
+		if !restricted {
+			visible = append(visible, col)
+			continue
+		}
+		if policy.Action == models.ColumnPolicyHide {
+			continue
+		}
+		col.Type = "String"
+		visible = append(visible, col)
+	}
+	return visible
+}
@@ -0,0 +1,421 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Dashboard Functions ---
+
+const (
+	// MaxDashboardPanels bounds the number of panels on a dashboard.
+	MaxDashboardPanels = 50
+	// DefaultDashboardConcurrency is the number of panels of a dashboard queried at the same
+	// time when dashboards.max_concurrency isn't set.
+	DefaultDashboardConcurrency = 4
+	// DefaultDashboardPanelLimit is the row limit of inline query panels without one.
+	DefaultDashboardPanelLimit = 100
+)
+
+// ErrDashboardNotFound is returned when a dashboard doesn't exist for the team.
+var ErrDashboardNotFound = errors.New("dashboard not found")
+
+// DashboardPanelResult holds the outcome of running a single dashboard panel.
+// A failed panel carries its error without failing the rest of the dashboard.
+type DashboardPanelResult struct {
+	PanelID   string                    `json:"panel_id"`
+	Type      models.DashboardPanelType `json:"type"`
+	Data      []map[string]interface{}  `json:"data,omitempty"`
+	Columns   []models.ColumnInfo       `json:"columns,omitempty"`
+	Stats     *models.QueryStats        `json:"stats,omitempty"`
+	Histogram *HistogramResponse        `json:"histogram,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+
+	// Audit describes the panel's query for the audit log. The caller fills in who ran it.
+	Audit *models.QueryAuditEntry `json:"-"`
+}
+
+// ListTeamDashboards returns the dashboards of a team.
+func ListTeamDashboards(ctx context.Context, db *sqlite.DB, teamID models.TeamID) ([]*models.Dashboard, error) {
+	dashboards, err := db.ListTeamDashboards(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing dashboards: %w", err)
+	}
+	return dashboards, nil
+}
+
+// FilterDashboardPanels removes the panels whose source isn't allowed from a dashboard.
+func FilterDashboardPanels(dashboard *models.Dashboard, allowSource func(models.SourceID) bool) {
+	dashboard.Panels = slices.DeleteFunc(dashboard.Panels, func(panel models.DashboardPanel) bool {
+		return !allowSource(panel.SourceID)
+	})
+}
+
+// GetTeamDashboard returns a dashboard of a team.
+func GetTeamDashboard(ctx context.Context, db *sqlite.DB, teamID models.TeamID, dashboardID int) (*models.Dashboard, error) {
+	dashboard, err := db.GetTeamDashboard(ctx, teamID, dashboardID)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return nil, ErrDashboardNotFound
+		}
+		return nil, fmt.Errorf("error getting dashboard: %w", err)
+	}
+	return dashboard, nil
+}
+
+// CreateTeamDashboard validates and creates a dashboard for a team.
+func CreateTeamDashboard(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, req models.CreateDashboardRequest) (*models.Dashboard, error) {
+	dashboard := &models.Dashboard{
+		TeamID:      teamID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Panels:      req.Panels,
+		Variables:   req.Variables,
+		TimeRange:   req.TimeRange,
+		Concurrency: req.Concurrency,
+	}
+	if err := validateDashboard(ctx, db, dashboard); err != nil {
+		return nil, err
+	}
+
+	log.Info("creating dashboard", "team_id", teamID, "name", dashboard.Name, "panels", len(dashboard.Panels))
+	if err := db.CreateTeamDashboard(ctx, dashboard); err != nil {
+		log.Error("failed to create dashboard in db", "error", err, "team_id", teamID)
+		return nil, fmt.Errorf("error creating dashboard: %w", err)
+	}
+	return GetTeamDashboard(ctx, db, teamID, dashboard.ID)
+}
+
+// UpdateTeamDashboard applies the provided fields to a team's dashboard.
+func UpdateTeamDashboard(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, dashboardID int, req models.UpdateDashboardRequest) (*models.Dashboard, error) {
+	dashboard, err := GetTeamDashboard(ctx, db, teamID, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		dashboard.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		dashboard.Description = *req.Description
+	}
+	if req.Panels != nil {
+		dashboard.Panels = *req.Panels
+	}
+	if req.Variables != nil {
+		dashboard.Variables = *req.Variables
+	}
+	if req.TimeRange != nil {
+		dashboard.TimeRange = *req.TimeRange
+	}
+	if req.Concurrency != nil {
+		dashboard.Concurrency = *req.Concurrency
+	}
+	if err := validateDashboard(ctx, db, dashboard); err != nil {
+		return nil, err
+	}
+
+	log.Info("updating dashboard", "team_id", teamID, "dashboard_id", dashboardID)
+	if err := db.UpdateTeamDashboard(ctx, dashboard); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return nil, ErrDashboardNotFound
+		}
+		log.Error("failed to update dashboard in db", "error", err, "dashboard_id", dashboardID)
+		return nil, fmt.Errorf("error updating dashboard: %w", err)
+	}
+	return GetTeamDashboard(ctx, db, teamID, dashboardID)
+}
+
+// DeleteTeamDashboard deletes a team's dashboard.
+func DeleteTeamDashboard(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, dashboardID int) error {
+	log.Info("deleting dashboard", "team_id", teamID, "dashboard_id", dashboardID)
+	if err := db.DeleteTeamDashboard(ctx, teamID, dashboardID); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return ErrDashboardNotFound
+		}
+		return fmt.Errorf("error deleting dashboard: %w", err)
+	}
+	return nil
+}
+
+// validateDashboard checks a dashboard's variables, time range and panels. Panels must
+// query sources linked to the team, through either a saved SQL query or an inline query.
+func validateDashboard(ctx context.Context, db *sqlite.DB, dashboard *models.Dashboard) error {
+	if dashboard.Name == "" {
+		return &ValidationError{Field: "name", Message: "dashboard name is required"}
+	}
+	if len(dashboard.Panels) > MaxDashboardPanels {
+		return &ValidationError{Field: "panels", Message: fmt.Sprintf("a dashboard can have at most %d panels", MaxDashboardPanels)}
+	}
+	if dashboard.Concurrency < 0 {
+		return &ValidationError{Field: "concurrency", Message: "concurrency must not be negative"}
+	}
+
+	declared, err := validateVariableDeclarations(dashboard.Variables)
+	if err != nil {
+		return &ValidationError{Field: "variables", Message: err.Error()}
+	}
+	if err := validateSavedQueryTimeRange(dashboard.TimeRange); err != nil {
+		return &ValidationError{Field: "time_range", Message: err.Error()}
+	}
+
+	ids := make(map[string]bool, len(dashboard.Panels))
+	for i := range dashboard.Panels {
+		panel := &dashboard.Panels[i]
+		panel.ID = strings.TrimSpace(panel.ID)
+		if panel.ID == "" {
+			return &ValidationError{Field: "panels", Message: "panel id is required"}
+		}
+		if ids[panel.ID] {
+			return &ValidationError{Field: "panels", Message: fmt.Sprintf("duplicate panel id %q", panel.ID)}
+		}
+		ids[panel.ID] = true
+
+		switch panel.Type {
+		case models.DashboardPanelTable, models.DashboardPanelStat, models.DashboardPanelPie:
+		case models.DashboardPanelHistogram:
+			if panel.Window == "" {
+				return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: window is required for histogram panels", panel.ID)}
+			}
+		default:
+			return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: unknown type %q, must be one of: table, histogram, stat, pie", panel.ID, panel.Type)}
+		}
+		if panel.Limit < 0 {
+			return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: limit must not be negative", panel.ID)}
+		}
+
+		hasAccess, err := TeamHasSourceAccess(ctx, db, dashboard.TeamID, panel.SourceID)
+		if err != nil {
+			return fmt.Errorf("error checking team source access: %w", err)
+		}
+		if !hasAccess {
+			return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: source %d is not linked to this team", panel.ID, panel.SourceID)}
+		}
+
+		switch {
+		case panel.SavedQueryID != nil && strings.TrimSpace(panel.Query) != "":
+			return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: set either saved_query_id or query, not both", panel.ID)}
+		case panel.SavedQueryID != nil:
+			savedQuery, err := db.GetTeamSourceQuery(ctx, dashboard.TeamID, panel.SourceID, *panel.SavedQueryID)
+			if err != nil {
+				return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: saved query %d not found for this source", panel.ID, *panel.SavedQueryID)}
+			}
+			if savedQuery.QueryType != models.SavedQueryTypeSQL {
+				return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: only SQL saved queries can be used in dashboards", panel.ID)}
+			}
+		case strings.TrimSpace(panel.Query) != "":
+			if err := validateVariableRefs(panel.Query, declared); err != nil {
+				return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: %v", panel.ID, err)}
+			}
+		default:
+			return &ValidationError{Field: "panels", Message: fmt.Sprintf("panel %q: saved_query_id or query is required", panel.ID)}
+		}
+	}
+	return nil
+}
+
+// RunDashboard runs the panels of a team's dashboard with the given shared variable values.
+// Panels run concurrently, at most the dashboard's concurrency capped by maxConcurrency at a
+// time, and results are returned in panel order. acquire, if set, admits each panel's query
+// under the query limits.
+func RunDashboard(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, dashboardID int, req models.APIRunDashboardRequest, maxConcurrency int, acquire AcquireQueryFunc) ([]*DashboardPanelResult, error) {
+	dashboard, err := GetTeamDashboard(ctx, db, teamID, dashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	panels := dashboard.Panels
+	if len(req.PanelIDs) > 0 {
+		byID := make(map[string]models.DashboardPanel, len(dashboard.Panels))
+		for _, panel := range dashboard.Panels {
+			byID[panel.ID] = panel
+		}
+		panels = make([]models.DashboardPanel, 0, len(req.PanelIDs))
+		for _, id := range req.PanelIDs {
+			panel, ok := byID[id]
+			if !ok {
+				return nil, &ValidationError{Field: "panel_ids", Message: fmt.Sprintf("unknown panel %q", id)}
+			}
+			panels = append(panels, panel)
+		}
+	}
+
+	// Resolve relative time ranges once so all panels cover the same period.
+	now := time.Now()
+	results := make([]*DashboardPanelResult, len(panels))
+	sem := make(chan struct{}, dashboardConcurrency(dashboard.Concurrency, maxConcurrency))
+	var wg sync.WaitGroup
+	for i := range panels {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = runDashboardPanel(ctx, db, chDB, cache, log, dashboard, &panels[i], req, now, acquire)
+		}(i)
+	}
+	wg.Wait()
+
+	log.Debug("dashboard run complete", "team_id", teamID, "dashboard_id", dashboardID, "panels", len(panels))
+	return results, nil
+}
+
+// dashboardConcurrency returns the number of panels of a dashboard run at once: its own
+// concurrency, capped by maxConcurrency (DefaultDashboardConcurrency when not positive).
+func dashboardConcurrency(concurrency, maxConcurrency int) int {
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultDashboardConcurrency
+	}
+	if concurrency <= 0 || concurrency > maxConcurrency {
+		return maxConcurrency
+	}
+	return concurrency
+}
+
+// runDashboardPanel renders and runs a single panel's query.
+func runDashboardPanel(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, dashboard *models.Dashboard, panel *models.DashboardPanel, req models.APIRunDashboardRequest, now time.Time, acquire AcquireQueryFunc) *DashboardPanelResult {
+	result := &DashboardPanelResult{PanelID: panel.ID, Type: panel.Type}
+
+	queryType := models.QueryAuditTypeLogs
+	if panel.Type == models.DashboardPanelHistogram {
+		queryType = models.QueryAuditTypeHistogram
+	}
+	teamID, sourceID := dashboard.TeamID, panel.SourceID
+	result.Audit = &models.QueryAuditEntry{TeamID: &teamID, SourceID: &sourceID, QueryType: queryType}
+	ctx = WithAuditEntry(ctx, result.Audit)
+
+	start := time.Now()
+	rows, err := func() (int, error) {
+		content, err := dashboardPanelContent(ctx, db, log, dashboard, panel)
+		if err != nil {
+			return 0, err
+		}
+		source, err := db.GetSource(ctx, panel.SourceID)
+		if err != nil {
+			return 0, fmt.Errorf("error getting source details: %w", err)
+		}
+		if source == nil {
+			return 0, ErrSourceNotFound
+		}
+
+		// Shared variables the panel doesn't declare are ignored.
+		values := make(map[string]string, len(req.Variables))
+		declared := map[string]bool{TimeRangeVariable: true}
+		for _, v := range content.Variables {
+			declared[v.Name] = true
+		}
+		for name, value := range req.Variables {
+			if declared[name] {
+				values[name] = value
+			}
+		}
+
+		rawSQL, params, err := RenderSavedQuery(content, values, source.MetaTSField, now)
+		if err != nil {
+			return 0, err
+		}
+		result.Audit.RawSQL = rawSQL
+
+		ctx, release, err := acquireQuery(ctx, acquire, teamID, sourceID, queryType, rawSQL)
+		if err != nil {
+			return 0, err
+		}
+		defer release()
+
+		if panel.Type == models.DashboardPanelHistogram {
+			histogram, err := GetHistogramData(ctx, db, chDB, cache, log, teamID, sourceID, HistogramParams{
+				Window:       panel.Window,
+				Query:        rawSQL,
+				GroupBy:      panel.GroupBy,
+				QueryTimeout: req.QueryTimeout,
+				Parameters:   params,
+				NoCache:      req.NoCache,
+			})
+			if err != nil {
+				return 0, err
+			}
+			result.Histogram = histogram
+			return len(histogram.Data), nil
+		}
+
+		limit := content.Limit
+		if panel.Limit > 0 {
+			limit = panel.Limit
+		}
+		queryResult, err := QueryLogs(ctx, db, chDB, cache, log, teamID, sourceID, clickhouse.LogQueryParams{
+			RawSQL:       rawSQL,
+			Limit:        limit,
+			QueryTimeout: req.QueryTimeout,
+			Parameters:   params,
+			NoCache:      req.NoCache,
+		})
+		if err != nil {
+			return 0, err
+		}
+		result.Data = queryResult.Logs
+		result.Columns = queryResult.Columns
+		result.Stats = &queryResult.Stats
+		return len(queryResult.Logs), nil
+	}()
+
+	result.Audit.CreatedAt = start.UTC()
+	result.Audit.DurationMs = time.Since(start).Milliseconds()
+	result.Audit.RowsReturned = rows
+	if err != nil {
+		log.Warn("dashboard panel failed", "dashboard_id", dashboard.ID, "panel_id", panel.ID, "error", err)
+		result.Error = err.Error()
+		result.Audit.Error = err.Error()
+	}
+	return result
+}
+
+// dashboardPanelContent returns the query content of a panel. Saved queries keep their
+// own variables, extended with the dashboard's shared variables, which take precedence.
+// The dashboard's time range, when set, replaces the saved one.
+func dashboardPanelContent(ctx context.Context, db *sqlite.DB, log *slog.Logger, dashboard *models.Dashboard, panel *models.DashboardPanel) (*models.SavedQueryContent, error) {
+	if panel.SavedQueryID == nil {
+		return &models.SavedQueryContent{
+			Content:   panel.Query,
+			Limit:     DefaultDashboardPanelLimit,
+			TimeRange: dashboard.TimeRange,
+			Variables: dashboard.Variables,
+		}, nil
+	}
+
+	savedQuery, content, err := getSavedQueryContent(ctx, db, log, dashboard.TeamID, panel.SourceID, *panel.SavedQueryID)
+	if err != nil {
+		return nil, err
+	}
+	if savedQuery.QueryType != models.SavedQueryTypeSQL {
+		return nil, &ValidationError{Field: "query_type", Message: "only SQL saved queries can be used in dashboards"}
+	}
+
+	shared := make(map[string]bool, len(dashboard.Variables))
+	for _, v := range dashboard.Variables {
+		shared[v.Name] = true
+	}
+	variables := append([]models.SavedQueryVariable{}, dashboard.Variables...)
+	for _, v := range content.Variables {
+		if !shared[v.Name] {
+			variables = append(variables, v)
+		}
+	}
+	content.Variables = variables
+	if !dashboard.TimeRange.IsZero() {
+		content.TimeRange = dashboard.TimeRange
+	}
+	if content.Limit <= 0 {
+		content.Limit = DefaultDashboardPanelLimit
+	}
+	return content, nil
+}
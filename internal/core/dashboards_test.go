@@ -0,0 +1,27 @@
+package core
+
+import "testing"
+
+func TestDashboardConcurrency(t *testing.T) {
+	tests := []struct {
+		name                        string
+		concurrency, maxConcurrency int
+		want                        int
+	}{
+		{name: "defaults", want: DefaultDashboardConcurrency},
+		{name: "dashboard lowers the default", concurrency: 2, want: 2},
+		{name: "capped by the default", concurrency: DefaultDashboardConcurrency + 1, want: DefaultDashboardConcurrency},
+		{name: "configured maximum", maxConcurrency: 8, want: 8},
+		{name: "dashboard lowers the maximum", concurrency: 3, maxConcurrency: 8, want: 3},
+		{name: "capped by the maximum", concurrency: 16, maxConcurrency: 8, want: 8},
+		{name: "maximum of one", concurrency: 4, maxConcurrency: 1, want: 1},
+		{name: "negative maximum uses the default", concurrency: 2, maxConcurrency: -1, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dashboardConcurrency(tt.concurrency, tt.maxConcurrency); got != tt.want {
+				t.Errorf("dashboardConcurrency(%d, %d) = %d, want %d", tt.concurrency, tt.maxConcurrency, got, tt.want)
+			}
+		})
+	}
+}
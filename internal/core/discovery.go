@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Table Discovery ---
+
+// Column names commonly used for the timestamp and severity of logs, in order of preference.
+var (
+	timestampColumnNames = []string{"timestamp", "_timestamp", "ts", "time", "event_time", "datetime", "created_at"}
+	severityColumnNames  = []string{"severity_text", "severity", "level", "log_level", "loglevel", "lvl", "priority"}
+)
+
+// isTimestampColumnType reports whether a column of the type can be a source's timestamp field.
+func isTimestampColumnType(colType string) bool {
+	return strings.HasPrefix(colType, "DateTime")
+}
+
+// isSeverityColumnType reports whether a column of the type can be a source's severity field.
+func isSeverityColumnType(colType string) bool {
+	return colType == "String" || strings.Contains(colType, "LowCardinality(String)")
+}
+
+// isNumericColumnType reports whether a column of the type holds integers, such as a
+// severity number mapped to levels.
+func isNumericColumnType(colType string) bool {
+	return strings.HasPrefix(colType, "Int") || strings.HasPrefix(colType, "UInt")
+}
+
+// DiscoverTables lists the databases and tables reachable with a connection, suggesting the
+// timestamp and severity fields of each table and marking the tables already registered as sources.
+func DiscoverTables(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, req models.DiscoverTablesRequest) (*models.TableDiscovery, error) {
+	conn := models.ConnectionInfo{
+		Host:     req.Host,
+		Username: req.Username,
+		Password: req.Password,
+		Database: req.Database,
+	}
+	// The database is optional here, validate the rest of the connection.
+	check := conn
+	if check.Database == "" {
+		check.Database = "default"
+	}
+	if err := validateConnection(check); err != nil {
+		return nil, err
+	}
+
+	client, err := chDB.CreateTemporaryClient(&models.Source{Connection: conn})
+	if err != nil {
+		log.Warn("table discovery failed: could not create temporary client", "error", err, "host", conn.Host)
+		return nil, &ValidationError{Field: "connection", Message: "Failed to connect to the database", Err: err}
+	}
+	defer client.Close()
+
+	databases, err := client.ListDatabases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing databases: %w", err)
+	}
+	if conn.Database != "" && !slices.Contains(databases, conn.Database) {
+		return nil, &ValidationError{Field: "database", Message: fmt.Sprintf("Database '%s' not found or inaccessible", conn.Database)}
+	}
+	tables, err := client.DiscoverTables(ctx, conn.Database)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
+	}
+
+	sources, err := db.ListSources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sources: %w", err)
+	}
+	registered := make(map[string]models.SourceID, len(sources))
+	for _, source := range sources {
+		if normalizeHost(source.Connection.Host) == normalizeHost(conn.Host) {
+			registered[source.Connection.Database+"."+source.Connection.TableName] = source.ID
+		}
+	}
+
+	discovery := &models.TableDiscovery{
+		Databases: append([]string{}, databases...),
+		Tables:    make([]*models.DiscoveredTable, 0, len(tables)),
+	}
+	for _, table := range tables {
+		discovered := discoveredTable(table)
+		if id, ok := registered[table.Database+"."+table.Name]; ok {
+			discovered.SourceID = &id
+		}
+		discovery.Tables = append(discovery.Tables, discovered)
+	}
+	return discovery, nil
+}
+
+// discoveredTable describes a table and suggests its timestamp and severity fields.
+func discoveredTable(table *clickhouse.DiscoveredTable) *models.DiscoveredTable {
+	discovered := &models.DiscoveredTable{
+		Database:    table.Database,
+		Table:       table.Name,
+		Engine:      table.Engine,
+		Rows:        table.Rows,
+		BytesOnDisk: table.BytesOnDisk,
+		Size:        table.Size,
+		Columns:     append([]models.ColumnInfo{}, table.Columns...),
+		SortKeys:    append([]string{}, table.SortKeys...),
+	}
+
+	var timestamps, severities []string
+	for _, col := range table.Columns {
+		if isTimestampColumnType(col.Type) {
+			timestamps = append(timestamps, col.Name)
+		}
+		if isSeverityColumnType(col.Type) {
+			severities = append(severities, col.Name)
+		}
+	}
+
+	// Timestamp columns in the sort key are preferred over other timestamp columns
+	// not named like one.
+	discovered.TimestampCandidates = rankColumns(timestamps, timestampColumnNames, table.SortKeys)
+	// Severity columns must be named like one: any String column could otherwise qualify.
+	discovered.SeverityCandidates = rankColumns(filterByName(severities, severityColumnNames), severityColumnNames, nil)
+	if len(discovered.TimestampCandidates) > 0 {
+		discovered.TimestampField = discovered.TimestampCandidates[0]
+	}
+	if len(discovered.SeverityCandidates) > 0 {
+		discovered.SeverityField = discovered.SeverityCandidates[0]
+	}
+	return discovered
+}
+
+// rankColumns orders columns by the position of their name in names, then by whether they
+// are in sortKeys, keeping the table order otherwise. Names are compared case-insensitively.
+func rankColumns(columns, names, sortKeys []string) []string {
+	rank := func(column string) int {
+		if i := slices.Index(names, strings.ToLower(column)); i >= 0 {
+			return i
+		}
+		if slices.Contains(sortKeys, column) {
+			return len(names)
+		}
+		return len(names) + 1
+	}
+
+	ranked := append([]string{}, columns...)
+	slices.SortStableFunc(ranked, func(a, b string) int {
+		return rank(a) - rank(b)
+	})
+	return ranked
+}
+
+// filterByName keeps the columns whose name is one of names, ignoring case.
+func filterByName(columns, names []string) []string {
+	filtered := []string{}
+	for _, column := range columns {
+		if slices.Contains(names, strings.ToLower(column)) {
+			filtered = append(filtered, column)
+		}
+	}
+	return filtered
+}
+
+// normalizeHost returns a host with the default native port, to compare source hosts.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if !strings.Contains(host, ":") {
+		host += ":9000"
+	}
+	return host
+}
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/logchefql"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Federated Search ---
+
+const (
+	// DefaultFederatedSearchLimit is the number of merged rows returned when no limit is given.
+	DefaultFederatedSearchLimit = 100
+	// MaxFederatedSearchLimit bounds the merged rows, and the rows fetched from each source.
+	MaxFederatedSearchLimit = 10000
+	// MaxFederatedSearchSources bounds the sources searched at once.
+	MaxFederatedSearchSources = 20
+	// MaxFederatedSearchConcurrency is the number of sources searched at the same time.
+	MaxFederatedSearchConcurrency = 4
+)
+
+// FederatedSearchParams defines parameters for searching several sources of a team.
+type FederatedSearchParams struct {
+	Query     string            // LogchefQL filter
+	SourceIDs []models.SourceID // Defaults to all of the team's sources
+	Start     time.Time
+	End       time.Time
+	Limit     int
+	// AllowSource restricts the searched sources, e.g. to those an API token may query.
+	AllowSource func(models.SourceID) bool
+	// AcquireQuery admits the query of each source under the query limits.
+	AcquireQuery AcquireQueryFunc
+	QueryTimeout *int // Applied to each source
+}
+
+// federatedRow is a row of a source with its timestamp, for merging.
+type federatedRow struct {
+	ts  time.Time
+	row map[string]interface{}
+}
+
+// FederatedSearch runs a LogchefQL filter on several sources of a team concurrently and merges
+// the rows by timestamp, newest first. Filter fields are resolved per source: semantic fields
+// (the roles of models.FieldRoles, "timestamp" and "severity") to the columns the source declares
+// for them, other fields to the source's columns. Severity levels are matched through the source's
+// severity mapping. A source that fails, times out or lacks a field is reported in its result
+// without failing the search. Sources are queried through QueryLogs, so the team's row-level filter
+// and column policies apply. The audit entries of the queries are returned for the caller to record.
+func FederatedSearch(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, params FederatedSearchParams) (*models.FederatedSearchResult, []*models.QueryAuditEntry, error) {
+	filter, err := logchefql.Parse(params.Query)
+	if err != nil {
+		return nil, nil, &ValidationError{Field: "query", Message: fmt.Sprintf("invalid query: %v", err), Err: err}
+	}
+	if params.Start.IsZero() || params.End.IsZero() || !params.End.After(params.Start) {
+		return nil, nil, &ValidationError{Field: "end_time", Message: "a time range with end_time after start_time is required"}
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultFederatedSearchLimit
+	}
+	if params.Limit > MaxFederatedSearchLimit {
+		return nil, nil, &ValidationError{Field: "limit", Message: fmt.Sprintf("limit must not exceed %d", MaxFederatedSearchLimit)}
+	}
+	if err := models.ValidateQueryTimeout(params.QueryTimeout); err != nil {
+		return nil, nil, &ValidationError{Field: "query_timeout", Message: err.Error()}
+	}
+
+	sources, err := ListTeamSources(ctx, db, chDB, log, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(params.SourceIDs) > 0 {
+		for _, id := range params.SourceIDs {
+			if !slices.ContainsFunc(sources, func(source *models.Source) bool { return source.ID == id }) {
+				return nil, nil, &ValidationError{Field: "source_ids", Message: fmt.Sprintf("source %d is not linked to the team", id)}
+			}
+		}
+		sources = slices.DeleteFunc(sources, func(source *models.Source) bool {
+			return !slices.Contains(params.SourceIDs, source.ID)
+		})
+	}
+	if params.AllowSource != nil {
+		sources = slices.DeleteFunc(sources, func(source *models.Source) bool { return !params.AllowSource(source.ID) })
+	}
+	if len(sources) == 0 {
+		return nil, nil, &ValidationError{Field: "source_ids", Message: "no sources to search"}
+	}
+	if len(sources) > MaxFederatedSearchSources {
+		return nil, nil, &ValidationError{Field: "source_ids", Message: fmt.Sprintf("at most %d sources can be searched at once", MaxFederatedSearchSources)}
+	}
+
+	timeout := time.Duration(models.DefaultQueryTimeoutSeconds) * time.Second
+	if params.QueryTimeout != nil {
+		timeout = time.Duration(*params.QueryTimeout) * time.Second
+	}
+
+	results := make([]*models.FederatedSourceResult, len(sources))
+	sourceRows := make([][]federatedRow, len(sources))
+	sourceColumns := make([][]models.ColumnInfo, len(sources))
+	audits := make([]*models.QueryAuditEntry, len(sources))
+	sem := make(chan struct{}, MaxFederatedSearchConcurrency)
+	var wg sync.WaitGroup
+	for i := range sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			// The timeout starts once the source's turn comes, so slow sources don't eat into others'.
+			sourceCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i], sourceRows[i], sourceColumns[i], audits[i] = searchFederatedSource(sourceCtx, db, chDB, cache, log, teamID, sources[i], filter, params)
+		}(i)
+	}
+	wg.Wait()
+
+	result := &models.FederatedSearchResult{
+		Logs: []map[string]interface{}{},
+		Columns: []models.ColumnInfo{
+			{Name: "_source", Type: "String"},
+			{Name: "_source_id", Type: "Int64"},
+		},
+		Sources: results,
+	}
+	var merged []federatedRow
+	seen := map[string]bool{"_source": true, "_source_id": true}
+	for i, rows := range sourceRows {
+		merged = append(merged, rows...)
+		for _, column := range sourceColumns[i] {
+			if !seen[column.Name] {
+				seen[column.Name] = true
+				result.Columns = append(result.Columns, column)
+			}
+		}
+		result.Stats.ExecutionTimeMs = max(result.Stats.ExecutionTimeMs, results[i].Stats.ExecutionTimeMs)
+		result.Stats.RowsRead += results[i].Stats.RowsRead
+		result.Stats.BytesRead += results[i].Stats.BytesRead
+	}
+	slices.SortStableFunc(merged, func(a, b federatedRow) int {
+		return b.ts.Compare(a.ts)
+	})
+	for _, row := range merged[:min(len(merged), params.Limit)] {
+		result.Logs = append(result.Logs, row.row)
+	}
+
+	var searched []*models.QueryAuditEntry
+	for _, audit := range audits {
+		if audit != nil {
+			searched = append(searched, audit)
+		}
+	}
+
+	log.Debug("federated search complete", "team_id", teamID, "sources", len(sources), "rows", len(result.Logs))
+	return result, searched, nil
+}
+
+// searchFederatedSource runs the filter on a single source. No audit entry is returned when the
+// filter can't be translated for the source or its query isn't admitted, since nothing was queried.
+func searchFederatedSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, source *models.Source, filter logchefql.Node, params FederatedSearchParams) (*models.FederatedSourceResult, []federatedRow, []models.ColumnInfo, *models.QueryAuditEntry) {
+	result := &models.FederatedSourceResult{SourceID: source.ID, Name: source.Name, TimestampField: source.MetaTSField}
+
+	columns, err := GetSourceSchema(ctx, db, chDB, log, teamID, source.ID)
+	if err != nil {
+		log.Warn("federated search failed to get source schema", "source_id", source.ID, "error", err)
+		result.Error = err.Error()
+		return result, nil, nil, nil
+	}
+	condition, err := logchefql.ToSQL(filter, federatedFieldResolver(source, columns))
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil, nil, nil
+	}
+
+	sourceID := source.ID
+	tsField := "`" + strings.ReplaceAll(source.MetaTSField, "`", "``") + "`"
+	rawSQL := fmt.Sprintf("SELECT * FROM %s WHERE %s BETWEEN fromUnixTimestamp64Milli(%d) AND fromUnixTimestamp64Milli(%d) AND (%s) ORDER BY %s DESC",
+		source.GetFullTableName(), tsField, params.Start.UnixMilli(), params.End.UnixMilli(), condition, tsField)
+	// A source whose query isn't admitted under the query limits is reported like a failed one.
+	ctx, release, err := acquireQuery(ctx, params.AcquireQuery, teamID, source.ID, models.QueryAuditTypeLogs, rawSQL)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil, nil, nil
+	}
+	defer release()
+	audit := &models.QueryAuditEntry{TeamID: &teamID, SourceID: &sourceID, QueryType: models.QueryAuditTypeLogs, RawSQL: rawSQL}
+
+	start := time.Now()
+	// One row more than kept tells whether the source had more matching rows.
+	queryResult, err := QueryLogs(WithAuditEntry(ctx, audit), db, chDB, cache, log, teamID, source.ID, clickhouse.LogQueryParams{
+		RawSQL:       rawSQL,
+		Limit:        params.Limit + 1,
+		QueryTimeout: params.QueryTimeout,
+	})
+	audit.CreatedAt = start.UTC()
+	audit.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		log.Warn("federated search failed for source", "source_id", source.ID, "error", err)
+		result.Error = err.Error()
+		audit.Error = err.Error()
+		return result, nil, nil, audit
+	}
+
+	logs := queryResult.Logs
+	audit.RowsReturned = len(logs)
+	if len(logs) > params.Limit {
+		logs = logs[:params.Limit]
+		result.Truncated = true
+	}
+	rows := make([]federatedRow, 0, len(logs))
+	for _, row := range logs {
+		ts, _ := timeValue(row[source.MetaTSField])
+		row["_source"] = source.Name
+		row["_source_id"] = source.ID
+		rows = append(rows, federatedRow{ts: ts, row: row})
+	}
+	result.Rows = len(rows)
+	result.Stats = queryResult.Stats
+	return result, rows, queryResult.Columns, audit
+}
+
+// federatedFieldResolver resolves filter fields on a source: semantic fields to the columns
+// declared for them, anything else to the source's columns.
+func federatedFieldResolver(source *models.Source, columns []models.ColumnInfo) logchefql.Resolver {
+	return func(field string) (*logchefql.Column, error) {
+		name := field
+		switch field {
+		case "timestamp":
+			name = source.MetaTSField
+		case "severity":
+			if source.MetaSeverityField != "" {
+				name = source.MetaSeverityField
+			}
+		default:
+			if column := source.FieldRoles.Column(models.FieldRole(field)); column != "" {
+				name = column
+			}
+		}
+
+		i := slices.IndexFunc(columns, func(column models.ColumnInfo) bool { return column.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("field %q not found in source", field)
+		}
+		column := &logchefql.Column{Name: name, Type: columns[i].Type}
+		if field == "severity" && name == source.MetaSeverityField && len(source.SeverityMapping) > 0 {
+			column.Match = func(level string) (string, bool) {
+				return severityLevelCondition(name, source.SeverityMapping, strings.ToLower(level))
+			}
+		}
+		return column, nil
+	}
+}
+
+// severityLevelCondition returns a condition matching the raw severity values mapped to a level,
+// or false when the value isn't a level.
+func severityLevelCondition(column string, mapping models.SeverityMapping, level string) (string, bool) {
+	quoted := "`" + strings.ReplaceAll(column, "`", "``") + "`"
+	var values, conditions []string
+	for key, mapped := range mapping {
+		if mapped != level {
+			continue
+		}
+		if lo, hi, ok := models.ParseSeverityRange(key); ok {
+			conditions = append(conditions, fmt.Sprintf("toInt64OrNull(toString(%s)) BETWEEN %d AND %d", quoted, lo, hi))
+			continue
+		}
+		values = append(values, "'"+strings.ReplaceAll(strings.ReplaceAll(key, `\`, `\\`), "'", "''")+"'")
+	}
+	if len(values) > 0 {
+		slices.Sort(values)
+		conditions = append(conditions, fmt.Sprintf("toString(%s) IN (%s)", quoted, strings.Join(values, ", ")))
+	}
+	if len(conditions) == 0 {
+		// A level nothing maps to matches no logs of the source, rather than the raw value.
+		if slices.Contains(models.SeverityLevels, level) {
+			return "1 = 0", true
+		}
+		return "", false
+	}
+	slices.Sort(conditions)
+	return strings.Join(conditions, " OR "), true
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Log Context ---
+
+// MaxLogContextLimit bounds the logs returned on each side of the target log.
+const MaxLogContextLimit = 100
+
+// GetLogContext returns the logs around a target timestamp. With a scope, only the logs with
+// the same values of the source's role fields (e.g. the same service and host) are returned.
+// Queries run through QueryLogs, so the team's row-level filter and column policies apply.
+func GetLogContext(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, req *models.LogContextRequest) (*models.LogContextResponse, error) {
+	if req.Timestamp <= 0 {
+		return nil, &ValidationError{Field: "timestamp", Message: "timestamp is required"}
+	}
+	req.BeforeLimit = min(max(req.BeforeLimit, 0), MaxLogContextLimit)
+	req.AfterLimit = min(max(req.AfterLimit, 0), MaxLogContextLimit)
+
+	source, err := db.GetSource(ctx, sourceID)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) || sqlite.IsSourceNotFoundError(err) {
+			return nil, ErrSourceNotFound
+		}
+		return nil, fmt.Errorf("error getting source details: %w", err)
+	}
+
+	scope, err := logContextScope(source, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	tsField := "`" + strings.ReplaceAll(source.MetaTSField, "`", "``") + "`"
+	target := fmt.Sprintf("fromUnixTimestamp64Milli(%d)", req.Timestamp)
+	query := func(condition, order string, limit int) (*models.QueryResult, error) {
+		sql := fmt.Sprintf("SELECT * FROM %s WHERE %s %s%s ORDER BY %s %s",
+			source.GetFullTableName(), tsField, condition, scope, tsField, order)
+		return QueryLogs(ctx, db, chDB, cache, log, teamID, sourceID, clickhouse.LogQueryParams{RawSQL: sql, Limit: limit})
+	}
+
+	response := &models.LogContextResponse{
+		TargetTimestamp: req.Timestamp,
+		BeforeLogs:      []map[string]interface{}{},
+		TargetLogs:      []map[string]interface{}{},
+		AfterLogs:       []map[string]interface{}{},
+	}
+	addStats := func(result *models.QueryResult) {
+		response.Stats.ExecutionTimeMs += result.Stats.ExecutionTimeMs
+		response.Stats.RowsRead += result.Stats.RowsRead
+		response.Stats.BytesRead += result.Stats.BytesRead
+	}
+
+	targetResult, err := query("= "+target, "ASC", MaxLogContextLimit)
+	if err != nil {
+		return nil, err
+	}
+	response.TargetLogs = targetResult.Logs
+	addStats(targetResult)
+
+	if req.BeforeLimit > 0 {
+		result, err := query("< "+target, "DESC", req.BeforeLimit)
+		if err != nil {
+			return nil, err
+		}
+		// Fetched newest first, returned in chronological order.
+		slices.Reverse(result.Logs)
+		response.BeforeLogs = result.Logs
+		addStats(result)
+	}
+	if req.AfterLimit > 0 {
+		result, err := query("> "+target, "ASC", req.AfterLimit)
+		if err != nil {
+			return nil, err
+		}
+		response.AfterLogs = result.Logs
+		addStats(result)
+	}
+	return response, nil
+}
+
+// logContextScope returns the conditions restricting the context to logs with the given
+// values of the source's role fields.
+func logContextScope(source *models.Source, scope map[models.FieldRole]string) (string, error) {
+	var conditions strings.Builder
+	for _, role := range models.FieldRoles {
+		value, ok := scope[role]
+		if !ok {
+			continue
+		}
+		column := source.FieldRoles.Column(role)
+		if column == "" {
+			return "", &ValidationError{Field: "scope", Message: fmt.Sprintf("source has no %s field", role)}
+		}
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `'`, `''`)
+		fmt.Fprintf(&conditions, " AND toString(`%s`) = '%s'", strings.ReplaceAll(column, "`", "``"), value)
+	}
+	for role := range scope {
+		if !slices.Contains(models.FieldRoles, role) {
+			return "", &ValidationError{Field: "scope", Message: fmt.Sprintf("unknown field role %q", role)}
+		}
+	}
+	return conditions.String(), nil
+}
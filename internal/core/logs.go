@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"github.com/mr-karan/logchef/internal/clickhouse"
 	"github.com/mr-karan/logchef/internal/sqlite"
@@ -12,9 +14,24 @@ import (
 
 // --- Log Querying Functions ---
 
+// AcquireQueryFunc admits a query on a team's source under the caller's query limits, queueing
+// while it's over them. It returns the context to run the query with and a func to call once the
+// query finished. Functions running several queries per request take one so each counts separately.
+type AcquireQueryFunc func(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, queryType models.QueryAuditType, rawSQL string) (context.Context, func(), error)
+
+// acquireQuery runs acquire for a query, if set.
+func acquireQuery(ctx context.Context, acquire AcquireQueryFunc, teamID models.TeamID, sourceID models.SourceID, queryType models.QueryAuditType, rawSQL string) (context.Context, func(), error) {
+	if acquire == nil {
+		return ctx, func() {}, nil
+	}
+	return acquire(ctx, teamID, sourceID, queryType, rawSQL)
+}
+
 // QueryLogs retrieves logs from a specific source based on the provided parameters.
-// Timeout is always applied - either from params or default value.
-func QueryLogs(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, sourceID models.SourceID, params clickhouse.LogQueryParams) (*models.QueryResult, error) {
+// The team's row-level filter and column policies for the source, if any, are always enforced.
+// Timeout is always applied - either from params or default value. Results are cached in cache,
+// which may be nil.
+func QueryLogs(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, params clickhouse.LogQueryParams) (*models.QueryResult, error) {
 	// 1. Get source details from SQLite to validate existence and get table name
 	source, err := db.GetSource(ctx, sourceID)
 	if err != nil {
@@ -48,8 +65,11 @@ func QueryLogs(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log
 
 	// 3. Build the query (assuming LogQueryParams includes RawSQL or structured fields)
 	// Use the query builder from the clickhouse package
-	tableName := source.GetFullTableName() // e.g., "default.logs"
-	qb := clickhouse.NewQueryBuilder(tableName)
+	// The builder enforces the team's row-level filter and column policies.
+	qb, err := newTeamQueryBuilder(ctx, db, client, source, teamID)
+	if err != nil {
+		return nil, err
+	}
 
 	// TODO: Refine query building based on LogQueryParams structure
 	// Example: If params.RawSQL is provided and validated:
@@ -59,10 +79,24 @@ func QueryLogs(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log
 		// Return a user-friendly error indicating invalid query syntax
 		return nil, fmt.Errorf("invalid query syntax: %w", err)
 	}
+	setAuditFinalSQL(ctx, builtQuery)
 
 	// --- Alternatively, build query from structured params --- //
 	// query := qb.BuildSelectQuery(params.StartTime, params.EndTime, params.Filter, params.Limit)
 
+	// Bind values for any {name:Type} placeholders, e.g. saved query variables.
+	if len(params.Parameters) > 0 {
+		ctx = clickhouse.WithQueryParameters(ctx, params.Parameters)
+	}
+
+	// Serve repeated queries from the cache. The final SQL includes the team's restrictions.
+	cacheKey := queryCacheKey(queryCacheKindLogs, sourceID, builtQuery, params.Parameters)
+	if cached, ok := lookupQueryCache[models.QueryResult](ctx, cache, source, queryCacheKindLogs, cacheKey, params.NoCache); ok {
+		cached.Stats.Cached = true
+		log.Debug("log query served from cache", "source_id", sourceID, "rows_returned", len(cached.Logs))
+		return cached, nil
+	}
+
 	// 4. Execute the query via the ClickHouse client with timeout (always applied)
 	log.Debug("executing clickhouse query", "source_id", sourceID, "query_len", len(builtQuery))
 	queryResult, err := client.QueryWithTimeout(ctx, builtQuery, params.QueryTimeout)
@@ -71,13 +105,15 @@ func QueryLogs(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log
 		// Consider parsing CH error for user-friendliness
 		return nil, fmt.Errorf("error executing query on source %d: %w", sourceID, err)
 	}
+	cache.store(ctx, source, queryCacheKindLogs, cacheKey, queryResult, builtQuery, params.Parameters)
 
 	log.Debug("log query successful", "source_id", sourceID, "rows_returned", len(queryResult.Logs))
 	return queryResult, nil
 }
 
-// GetSourceSchema retrieves the schema (column information) for a specific source from ClickHouse.
-func GetSourceSchema(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, sourceID models.SourceID) ([]models.ColumnInfo, error) {
+// GetSourceSchema retrieves the schema (column information) for a specific source from ClickHouse,
+// as visible to the team: hidden columns are omitted and masked columns are reported as strings.
+func GetSourceSchema(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID) ([]models.ColumnInfo, error) {
 	// 1. Get source details from SQLite
 	source, err := db.GetSource(ctx, sourceID)
 	if err != nil {
@@ -104,8 +140,14 @@ func GetSourceSchema(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manage
 		return nil, fmt.Errorf("error retrieving schema for source %d: %w", sourceID, err)
 	}
 
-	log.Debug("schema retrieval successful", "source_id", sourceID, "column_count", len(tableInfo.Columns))
-	return tableInfo.Columns, nil
+	policies, err := db.ListColumnPolicies(ctx, teamID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing column policies: %w", err)
+	}
+	columns := ApplyColumnPolicies(tableInfo.Columns, policies)
+
+	log.Debug("schema retrieval successful", "source_id", sourceID, "column_count", len(columns))
+	return columns, nil
 }
 
 // --- Histogram Data Functions ---
@@ -119,17 +161,23 @@ type HistogramParams struct {
 	Timezone string // Optional timezone identifier (e.g., 'America/New_York', 'UTC')
 	// Query execution timeout in seconds. If not specified, uses default timeout.
 	QueryTimeout *int
+	// Parameters bound to {name:Type} placeholders in Query.
+	Parameters map[string]string
+	// NoCache bypasses cached results, running the query and refreshing its cached result.
+	NoCache bool
 }
 
 // HistogramResponse structures the response for histogram data.
 type HistogramResponse struct {
 	Granularity string                     `json:"granularity"`
 	Data        []clickhouse.HistogramData `json:"data"`
+	Cached      bool                       `json:"cached,omitempty"` // Served from the query result cache
 }
 
 // GetHistogramData fetches histogram data for a specific source and time range.
-// It uses the source's configured timestamp field. Timeout is always applied.
-func GetHistogramData(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, sourceID models.SourceID, params HistogramParams) (*HistogramResponse, error) {
+// It uses the source's configured timestamp field and applies the team's row-level filter and column policies.
+// Timeout is always applied. Results are cached in cache, which may be nil.
+func GetHistogramData(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, params HistogramParams) (*HistogramResponse, error) {
 	// 1. Get source details (especially the timestamp field)
 	source, err := db.GetSource(ctx, sourceID)
 	if err != nil {
@@ -175,6 +223,37 @@ func GetHistogramData(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manag
 		return nil, fmt.Errorf("error getting database connection for source %d: %w", sourceID, err)
 	}
 
+	// Enforce the team's row-level filter and column policies on the base query before it
+	// gets wrapped for bucketing. Grouping works on the base query's (masked) output columns.
+	qb, err := newTeamQueryBuilder(ctx, db, client, source, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if qb.HasRestrictions() {
+		params.Query, err = qb.BuildRawQuery(params.Query, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query syntax: %w", err)
+		}
+	}
+	setAuditFinalSQL(ctx, params.Query)
+
+	// The group is pasted into the bucketing query around the base query, so it must be a
+	// single column of the source.
+	params.GroupBy = strings.TrimSpace(params.GroupBy)
+	if params.GroupBy != "" {
+		tableInfo, err := client.GetTableInfo(ctx, source.Connection.Database, source.Connection.TableName)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving schema for source %d: %w", sourceID, err)
+		}
+		if !slices.ContainsFunc(tableInfo.Columns, func(col models.ColumnInfo) bool { return col.Name == params.GroupBy }) {
+			return nil, &ValidationError{Field: "group_by", Message: fmt.Sprintf("group_by must be a column of the source, %q is not", params.GroupBy)}
+		}
+		// Group values are returned as they are, so the column must not be hidden or masked for the team.
+		if err := qb.CheckColumnAccess(params.GroupBy); err != nil {
+			return nil, &ValidationError{Field: "group_by", Message: err.Error()}
+		}
+	}
+
 	// 3. Prepare parameters for the ClickHouse client call
 	// Validate and convert window string to clickhouse.TimeWindow
 	var chWindow clickhouse.TimeWindow
@@ -230,6 +309,18 @@ func GetHistogramData(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manag
 		QueryTimeout: params.QueryTimeout, // Pass the query timeout (always set now)
 	}
 
+	if len(params.Parameters) > 0 {
+		ctx = clickhouse.WithQueryParameters(ctx, params.Parameters)
+	}
+
+	// Serve repeated histograms from the cache, keyed on the query with the team's restrictions.
+	cacheKey := queryCacheKey(queryCacheKindHistogram, sourceID, params.Query, params.Parameters, string(chWindow), params.GroupBy, params.Timezone)
+	if cached, ok := lookupQueryCache[HistogramResponse](ctx, cache, source, queryCacheKindHistogram, cacheKey, params.NoCache); ok {
+		cached.Cached = true
+		log.Debug("histogram served from cache", "source_id", sourceID, "bucket_count", len(cached.Data))
+		return cached, nil
+	}
+
 	// 4. Call the ClickHouse client method
 	histogramData, err := client.GetHistogramData(
 		ctx,
@@ -246,8 +337,10 @@ func GetHistogramData(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manag
 	log.Debug("histogram data retrieval successful", "source_id", sourceID, "bucket_count", len(histogramData.Data))
 
 	// 5. Format the response
-	return &HistogramResponse{
+	response := &HistogramResponse{
 		Granularity: histogramData.Granularity,
 		Data:        histogramData.Data,
-	}, nil
+	}
+	cache.store(ctx, source, queryCacheKindHistogram, cacheKey, response, params.Query, params.Parameters)
+	return response, nil
 }
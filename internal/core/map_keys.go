@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Map Column Key Discovery ---
+
+const (
+	// MapKeysCacheTTL is how long discovered Map keys are reused before sampling again.
+	MapKeysCacheTTL = 5 * time.Minute
+	// MapKeysLookback bounds the sample to recently ingested data.
+	MapKeysLookback = 1 * time.Hour
+	// MapKeysSampleRows is the maximum number of rows read while sampling.
+	MapKeysSampleRows = 10000
+	// MapKeysMaxKeys is the maximum number of keys returned per column.
+	MapKeysMaxKeys = 200
+	// MapKeysMaxExamples is the maximum number of example values returned per key.
+	MapKeysMaxExamples = 5
+)
+
+// MapColumnKeys holds the discovered keys for a single Map column of a source.
+type MapColumnKeys struct {
+	Column    string                  `json:"column"`
+	Type      string                  `json:"type"`
+	Keys      []clickhouse.MapKeyStat `json:"keys"`
+	SampledAt time.Time               `json:"sampled_at"`
+}
+
+// mapKeysCacheKey identifies a cached discovery result.
+type mapKeysCacheKey struct {
+	sourceID  models.SourceID
+	column    string
+	rowFilter string // Keys are sampled under the team's row-level filter.
+}
+
+// MapKeysCache stores discovery results per source and column. It is shared by the
+// autocomplete endpoint and the AI prompt builder. A nil cache caches nothing.
+type MapKeysCache struct {
+	mu      sync.RWMutex
+	entries map[mapKeysCacheKey]*MapColumnKeys
+}
+
+// NewMapKeysCache creates an empty Map key cache.
+func NewMapKeysCache() *MapKeysCache {
+	return &MapKeysCache{entries: make(map[mapKeysCacheKey]*MapColumnKeys)}
+}
+
+// get returns a cached entry if it exists and hasn't expired.
+func (mc *MapKeysCache) get(key mapKeysCacheKey) (*MapColumnKeys, bool) {
+	if mc == nil {
+		return nil, false
+	}
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	entry, ok := mc.entries[key]
+	if !ok || time.Since(entry.SampledAt) > MapKeysCacheTTL {
+		return nil, false
+	}
+	return entry, true
+}
+
+// set stores a discovery result.
+func (mc *MapKeysCache) set(key mapKeysCacheKey, entry *MapColumnKeys) {
+	if mc == nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.entries[key] = entry
+}
+
+// Invalidate drops all cached Map keys for a source.
+func (mc *MapKeysCache) Invalidate(sourceID models.SourceID) {
+	if mc == nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for key := range mc.entries {
+		if key.sourceID == sourceID {
+			delete(mc.entries, key)
+		}
+	}
+}
+
+// Prune drops expired Map key samples, which are otherwise only replaced when the same
+// source and column are requested again. Returns the number of entries dropped.
+func (mc *MapKeysCache) Prune() int {
+	if mc == nil {
+		return 0
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	pruned := 0
+	for key, entry := range mc.entries {
+		if time.Since(entry.SampledAt) > MapKeysCacheTTL {
+			delete(mc.entries, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// GetSourceMapKeys discovers the most frequent keys of the Map columns of a source,
+// sampling only the rows visible to the team. If column is empty, all Map columns are
+// inspected. Results are cached in mapKeys per source and column for MapKeysCacheTTL unless
+// refresh is set.
+func GetSourceMapKeys(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, mapKeys *MapKeysCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, column string, refresh bool) ([]MapColumnKeys, error) {
+	// 1. Get source details from SQLite
+	source, err := db.GetSource(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting source details: %w", err)
+	}
+	if source == nil {
+		return nil, ErrSourceNotFound
+	}
+
+	rowFilter, err := GetTeamSourceRowFilter(ctx, db, teamID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Get ClickHouse connection
+	client, err := chDB.GetConnection(sourceID)
+	if err != nil {
+		log.Error("failed to get clickhouse client for map key discovery", "source_id", sourceID, "error", err)
+		return nil, fmt.Errorf("error getting database connection for source %d: %w", sourceID, err)
+	}
+
+	// 3. Resolve the Map columns to inspect from the table schema
+	tableInfo, err := client.GetTableInfo(ctx, source.Connection.Database, source.Connection.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving schema for source %d: %w", sourceID, err)
+	}
+
+	// Hidden and masked columns are excluded; masked columns are returned as strings.
+	policies, err := db.ListColumnPolicies(ctx, teamID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing column policies: %w", err)
+	}
+
+	var mapColumns []models.ColumnInfo
+	for _, col := range ApplyColumnPolicies(tableInfo.Columns, policies) {
+		if !clickhouse.IsMapType(col.Type) {
+			continue
+		}
+		if column == "" || col.Name == column {
+			mapColumns = append(mapColumns, col)
+		}
+	}
+	if column != "" && len(mapColumns) == 0 {
+		return nil, &ValidationError{Field: "column", Message: fmt.Sprintf("column %q does not exist or is not a Map column", column)}
+	}
+
+	// 4. Serve from cache where possible, sample the rest
+	results := make([]MapColumnKeys, 0, len(mapColumns))
+	for _, col := range mapColumns {
+		cacheKey := mapKeysCacheKey{sourceID: sourceID, column: col.Name, rowFilter: rowFilter}
+		if !refresh {
+			if cached, ok := mapKeys.get(cacheKey); ok {
+				results = append(results, *cached)
+				continue
+			}
+		}
+
+		log.Debug("discovering map keys", "source_id", sourceID, "column", col.Name)
+		keys, err := client.GetMapKeys(ctx, source.GetFullTableName(), clickhouse.MapKeysParams{
+			Column:         col.Name,
+			TimestampField: source.MetaTSField,
+			Filter:         rowFilter,
+			Lookback:       MapKeysLookback,
+			SampleRows:     MapKeysSampleRows,
+			MaxKeys:        MapKeysMaxKeys,
+			MaxExamples:    MapKeysMaxExamples,
+		})
+		if err != nil {
+			log.Error("failed to discover map keys", "source_id", sourceID, "column", col.Name, "error", err)
+			return nil, fmt.Errorf("error discovering keys for column %s: %w", col.Name, err)
+		}
+
+		entry := &MapColumnKeys{
+			Column:    col.Name,
+			Type:      col.Type,
+			Keys:      keys,
+			SampledAt: time.Now(),
+		}
+		mapKeys.set(cacheKey, entry)
+		results = append(results, *entry)
+	}
+
+	return results, nil
+}
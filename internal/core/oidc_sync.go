@@ -0,0 +1,268 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/config"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// ErrNoGroupMapping is returned when a new user's OIDC groups match no admin group or group mapping.
+var ErrNoGroupMapping = errors.New("no OIDC group mapping matches the user")
+
+// teamRoleRank orders team roles so the highest of several matching mappings wins.
+var teamRoleRank = map[models.TeamRole]int{
+	models.TeamRoleMember: 1,
+	models.TeamRoleEditor: 2,
+	models.TeamRoleAdmin:  3,
+}
+
+// oidcTeamGrant is the role a user's groups grant in one team.
+type oidcTeamGrant struct {
+	role   models.TeamRole
+	groups []string
+}
+
+// oidcAdminGroups returns the admin groups the user is in.
+func oidcAdminGroups(cfg *config.OIDCConfig, groups []string) []string {
+	var matched []string
+	for _, g := range cfg.AdminGroups {
+		if slices.Contains(groups, g) {
+			matched = append(matched, g)
+		}
+	}
+	return matched
+}
+
+// oidcTeamGrants resolves the group mappings matching the user's groups, by team name.
+func oidcTeamGrants(cfg *config.OIDCConfig, groups []string) map[string]*oidcTeamGrant {
+	grants := make(map[string]*oidcTeamGrant)
+	for _, m := range cfg.GroupMappings {
+		if !slices.Contains(groups, m.Group) {
+			continue
+		}
+		role := models.TeamRole(m.Role)
+		if role == "" {
+			role = models.TeamRoleMember
+		}
+		grant, ok := grants[m.Team]
+		if !ok {
+			grant = &oidcTeamGrant{role: role}
+			grants[m.Team] = grant
+		} else if teamRoleRank[role] > teamRoleRank[grant.role] {
+			grant.role = role
+		}
+		grant.groups = append(grant.groups, m.Group)
+	}
+	return grants
+}
+
+// recordOIDCSyncEvent writes a sync decision to the log. Failures are logged but do not
+// interrupt the login.
+func recordOIDCSyncEvent(ctx context.Context, db *sqlite.DB, log *slog.Logger, event *models.OIDCSyncEvent) {
+	event.CreatedAt = time.Now().UTC()
+	if event.Groups == nil {
+		event.Groups = []string{}
+	}
+	log.Info("oidc group sync", "email", event.UserEmail, "action", event.Action, "team", event.TeamName, "role", event.Role, "reason", event.Reason)
+	if err := db.InsertOIDCSyncEvent(ctx, event); err != nil {
+		log.Error("failed to record oidc sync event", "error", err, "email", event.UserEmail)
+	}
+}
+
+// CreateOIDCUser creates a user on their first OIDC login. When group sync is enabled, the
+// user must be in an admin group or a mapped group; otherwise ErrNoGroupMapping is returned
+// and the rejection is recorded.
+func CreateOIDCUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, cfg *config.OIDCConfig, email, name string, groups []string) (*models.User, error) {
+	if cfg.GroupsClaim != "" && len(oidcAdminGroups(cfg, groups)) == 0 && len(oidcTeamGrants(cfg, groups)) == 0 {
+		recordOIDCSyncEvent(ctx, db, log, &models.OIDCSyncEvent{
+			UserEmail: email,
+			Action:    models.OIDCSyncReject,
+			Groups:    groups,
+			Reason:    "no admin group or group mapping matches",
+		})
+		return nil, ErrNoGroupMapping
+	}
+
+	user, err := CreateUser(ctx, db, log, email, derivedFullName(name, email), models.UserRoleMember, models.UserStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	recordOIDCSyncEvent(ctx, db, log, &models.OIDCSyncEvent{
+		UserID:    &user.ID,
+		UserEmail: user.Email,
+		Action:    models.OIDCSyncCreateUser,
+		Role:      string(user.Role),
+		Groups:    groups,
+	})
+	return user, nil
+}
+
+// SyncOIDCGroups applies the admin groups and group mappings to a user on login.
+// Only the admin role and team memberships granted by the sync are changed or revoked by
+// it; manual and provisioned ones are kept, and configured admin emails are never demoted.
+func SyncOIDCGroups(ctx context.Context, db *sqlite.DB, log *slog.Logger, cfg *config.OIDCConfig, adminEmails []string, user *models.User, groups []string) error {
+	if cfg.GroupsClaim == "" {
+		return nil
+	}
+	log = log.With("component", "oidc_sync")
+	event := func(action models.OIDCSyncAction) *models.OIDCSyncEvent {
+		return &models.OIDCSyncEvent{UserID: &user.ID, UserEmail: user.Email, Action: action}
+	}
+
+	if err := syncOIDCAdmin(ctx, db, log, cfg, adminEmails, user, groups, event); err != nil {
+		return err
+	}
+
+	grants := oidcTeamGrants(cfg, groups)
+	for _, teamName := range slices.Sorted(maps.Keys(grants)) {
+		grant := grants[teamName]
+		team, err := GetTeamByName(ctx, db, teamName)
+		if err != nil {
+			if !errors.Is(err, ErrTeamNotFound) {
+				return fmt.Errorf("error getting team %q: %w", teamName, err)
+			}
+			e := event(models.OIDCSyncSkip)
+			e.TeamName, e.Role, e.Groups, e.Reason = teamName, string(grant.role), grant.groups, "team not found"
+			recordOIDCSyncEvent(ctx, db, log, e)
+			continue
+		}
+		if err := syncOIDCMembership(ctx, db, log, user, team, grant, event); err != nil {
+			return err
+		}
+	}
+
+	// Revoke synced memberships of teams the user's groups no longer map to.
+	teams, err := db.ListUserTeams(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error listing teams of user: %w", err)
+	}
+	for _, team := range teams {
+		if _, ok := grants[team.Name]; ok {
+			continue
+		}
+		member, err := db.GetTeamMember(ctx, team.ID, user.ID)
+		if err != nil {
+			return fmt.Errorf("error getting team member: %w", err)
+		}
+		if member == nil || !member.OIDCSynced || member.Provisioned {
+			continue
+		}
+		if err := db.RemoveTeamMember(ctx, team.ID, user.ID); err != nil {
+			return fmt.Errorf("error removing team member: %w", err)
+		}
+		e := event(models.OIDCSyncRemoveMember)
+		e.TeamID, e.TeamName, e.Role, e.Groups = &team.ID, team.Name, string(member.Role), groups
+		recordOIDCSyncEvent(ctx, db, log, e)
+	}
+	return nil
+}
+
+// syncOIDCAdmin grants the admin role to members of an admin group and revokes it from
+// users who were granted it by the sync and left all admin groups.
+func syncOIDCAdmin(ctx context.Context, db *sqlite.DB, log *slog.Logger, cfg *config.OIDCConfig, adminEmails []string, user *models.User, groups []string, event func(models.OIDCSyncAction) *models.OIDCSyncEvent) error {
+	matched := oidcAdminGroups(cfg, groups)
+	switch {
+	case len(matched) > 0 && user.Role != models.UserRoleAdmin:
+		if user.Provisioned {
+			e := event(models.OIDCSyncSkip)
+			e.Role, e.Groups, e.Reason = string(models.UserRoleAdmin), matched, "user is managed by provisioning"
+			recordOIDCSyncEvent(ctx, db, log, e)
+			return nil
+		}
+		user.Role = models.UserRoleAdmin
+		user.UpdatedAt = time.Now().UTC()
+		if err := db.UpdateUser(ctx, user); err != nil {
+			return fmt.Errorf("error granting admin role: %w", err)
+		}
+		if err := db.SetUserOIDCAdmin(ctx, user.ID, true); err != nil {
+			return err
+		}
+		user.OIDCAdmin = true
+		e := event(models.OIDCSyncGrantAdmin)
+		e.Role, e.Groups = string(models.UserRoleAdmin), matched
+		recordOIDCSyncEvent(ctx, db, log, e)
+
+	case len(matched) == 0 && user.Role == models.UserRoleAdmin && user.OIDCAdmin && !slices.Contains(adminEmails, user.Email):
+		e := event(models.OIDCSyncSkip)
+		e.Role, e.Groups = string(models.UserRoleMember), groups
+		count, err := db.CountAdminUsers(ctx)
+		if err != nil {
+			return fmt.Errorf("error counting admin users: %w", err)
+		}
+		if count <= 1 {
+			e.Reason = "user is the last admin"
+			recordOIDCSyncEvent(ctx, db, log, e)
+			return nil
+		}
+		user.Role = models.UserRoleMember
+		user.UpdatedAt = time.Now().UTC()
+		if err := db.UpdateUser(ctx, user); err != nil {
+			return fmt.Errorf("error revoking admin role: %w", err)
+		}
+		if err := db.SetUserOIDCAdmin(ctx, user.ID, false); err != nil {
+			return err
+		}
+		user.OIDCAdmin = false
+		e.Action, e.Reason = models.OIDCSyncRevokeAdmin, ""
+		recordOIDCSyncEvent(ctx, db, log, e)
+	}
+	return nil
+}
+
+// syncOIDCMembership adds the user to a mapped team, or updates the role of a synced membership.
+func syncOIDCMembership(ctx context.Context, db *sqlite.DB, log *slog.Logger, user *models.User, team *models.Team, grant *oidcTeamGrant, event func(models.OIDCSyncAction) *models.OIDCSyncEvent) error {
+	member, err := db.GetTeamMember(ctx, team.ID, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting team member: %w", err)
+	}
+	e := event(models.OIDCSyncAddMember)
+	e.TeamID, e.TeamName, e.Role, e.Groups = &team.ID, team.Name, string(grant.role), grant.groups
+
+	switch {
+	case member == nil:
+		if err := db.AddTeamMember(ctx, team.ID, user.ID, grant.role); err != nil {
+			return fmt.Errorf("error adding team member: %w", err)
+		}
+		if err := db.SetTeamMemberOIDCSynced(ctx, team.ID, user.ID, true); err != nil {
+			return err
+		}
+	case member.Role == grant.role:
+		return nil
+	case member.Provisioned:
+		e.Action, e.Reason = models.OIDCSyncSkip, "membership is managed by provisioning"
+	case !member.OIDCSynced:
+		e.Action, e.Reason = models.OIDCSyncSkip, "membership was added manually"
+	default:
+		if err := db.UpdateTeamMemberRole(ctx, team.ID, user.ID, grant.role); err != nil {
+			return fmt.Errorf("error updating team member role: %w", err)
+		}
+		e.Action = models.OIDCSyncUpdateRole
+	}
+	recordOIDCSyncEvent(ctx, db, log, e)
+	return nil
+}
+
+// SearchOIDCSyncEvents returns OIDC sync events matching the filter, newest first.
+func SearchOIDCSyncEvents(ctx context.Context, db *sqlite.DB, filter models.OIDCSyncEventFilter) ([]*models.OIDCSyncEvent, error) {
+	switch filter.Action {
+	case "", models.OIDCSyncCreateUser, models.OIDCSyncReject, models.OIDCSyncGrantAdmin, models.OIDCSyncRevokeAdmin,
+		models.OIDCSyncAddMember, models.OIDCSyncUpdateRole, models.OIDCSyncRemoveMember, models.OIDCSyncSkip:
+	default:
+		return nil, &ValidationError{Field: "action", Message: fmt.Sprintf("unknown action %q", filter.Action)}
+	}
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return db.SearchOIDCSyncEvents(ctx, filter)
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/patterns"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Log Pattern Clustering ---
+
+const (
+	// DefaultPatternField is the column clustered when none is specified and the source
+	// has no message field.
+	DefaultPatternField = "body"
+	// DefaultPatternSampleSize is the number of rows clustered when none is specified.
+	DefaultPatternSampleSize = 5000
+	// MaxPatternSampleSize bounds the rows fetched for clustering.
+	MaxPatternSampleSize = 20000
+	// DefaultMaxPatterns is the number of patterns returned when none is specified.
+	DefaultMaxPatterns = 50
+	// DefaultPatternSamples is the number of sample rows kept per pattern.
+	DefaultPatternSamples = 3
+	// MaxPatternSamples bounds the sample rows kept per pattern.
+	MaxPatternSamples = 20
+)
+
+// PatternParams defines parameters for clustering the results of a query into patterns.
+type PatternParams struct {
+	RawSQL       string  // Query whose results are clustered.
+	Field        string  // Text column to cluster, defaults to the source's message field or "body".
+	SampleSize   int     // Maximum number of rows clustered.
+	MaxPatterns  int     // Maximum number of patterns returned.
+	Samples      int     // Sample rows returned per pattern.
+	Similarity   float64 // Token similarity threshold (0-1], defaults to patterns.DefaultSimilarity.
+	QueryTimeout *int
+}
+
+// LogPattern is a single template and the rows that matched it.
+type LogPattern struct {
+	ID         int                      `json:"id"`
+	Template   string                   `json:"template"`
+	Count      int                      `json:"count"`
+	Percentage float64                  `json:"percentage"`
+	FirstSeen  *time.Time               `json:"first_seen,omitempty"`
+	LastSeen   *time.Time               `json:"last_seen,omitempty"`
+	Samples    []map[string]interface{} `json:"samples"`
+	// Filter is a ClickHouse condition matching lines of this pattern, for drill-down.
+	Filter string `json:"filter"`
+}
+
+// PatternsResponse structures the response for pattern clustering.
+type PatternsResponse struct {
+	Field         string       `json:"field"`
+	RowsAnalyzed  int          `json:"rows_analyzed"`
+	TotalPatterns int          `json:"total_patterns"`
+	Patterns      []LogPattern `json:"patterns"`
+}
+
+// GetLogPatterns runs the given query with a bounded limit and groups the values of a
+// text column into Drain-style templates, replacing numbers, UUIDs, IPs and hex with placeholders.
+func GetLogPatterns(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, params PatternParams) (*PatternsResponse, error) {
+	if strings.TrimSpace(params.RawSQL) == "" {
+		return nil, &ValidationError{Field: "raw_sql", Message: "query is required"}
+	}
+	if params.SampleSize <= 0 {
+		params.SampleSize = DefaultPatternSampleSize
+	}
+	if params.SampleSize > MaxPatternSampleSize {
+		params.SampleSize = MaxPatternSampleSize
+	}
+	if params.MaxPatterns <= 0 {
+		params.MaxPatterns = DefaultMaxPatterns
+	}
+	if params.Samples <= 0 {
+		params.Samples = DefaultPatternSamples
+	}
+	if params.Samples > MaxPatternSamples {
+		params.Samples = MaxPatternSamples
+	}
+
+	source, err := db.GetSource(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting source details: %w", err)
+	}
+	if source == nil {
+		return nil, ErrSourceNotFound
+	}
+	if params.Field == "" {
+		params.Field = source.FieldRoles.Column(models.FieldRoleMessage)
+	}
+	if params.Field == "" {
+		params.Field = DefaultPatternField
+	}
+
+	// 1. Fetch the bounded sample through the regular query path.
+	result, err := QueryLogs(ctx, db, chDB, cache, log, teamID, sourceID, clickhouse.LogQueryParams{
+		RawSQL:       params.RawSQL,
+		Limit:        params.SampleSize,
+		QueryTimeout: params.QueryTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hasField := false
+	for _, col := range result.Columns {
+		if col.Name == params.Field {
+			hasField = true
+			break
+		}
+	}
+	if !hasField {
+		return nil, &ValidationError{Field: "field", Message: fmt.Sprintf("column %q is not part of the query results", params.Field)}
+	}
+
+	// 2. Cluster the text column.
+	drain := patterns.NewDrain(params.Similarity, patterns.DefaultMaxChildren, params.Samples)
+	type seen struct {
+		first, last time.Time
+	}
+	seenByCluster := make(map[int]*seen)
+
+	for i, row := range result.Logs {
+		cluster := drain.Add(stringValue(row[params.Field]), i)
+
+		ts, ok := timeValue(row[source.MetaTSField])
+		if !ok {
+			continue
+		}
+		s, exists := seenByCluster[cluster.ID]
+		if !exists {
+			seenByCluster[cluster.ID] = &seen{first: ts, last: ts}
+			continue
+		}
+		if ts.Before(s.first) {
+			s.first = ts
+		}
+		if ts.After(s.last) {
+			s.last = ts
+		}
+	}
+
+	// 3. Build the response, most frequent patterns first.
+	clusters := drain.Clusters()
+	response := &PatternsResponse{
+		Field:         params.Field,
+		RowsAnalyzed:  len(result.Logs),
+		TotalPatterns: len(clusters),
+		Patterns:      make([]LogPattern, 0, min(len(clusters), params.MaxPatterns)),
+	}
+	for _, cluster := range clusters {
+		if len(response.Patterns) >= params.MaxPatterns {
+			break
+		}
+		template := cluster.Template()
+		pattern := LogPattern{
+			ID:         cluster.ID,
+			Template:   template,
+			Count:      cluster.Count,
+			Percentage: float64(cluster.Count) * 100 / float64(len(result.Logs)),
+			Samples:    make([]map[string]interface{}, 0, len(cluster.Members)),
+			Filter:     PatternFilter(params.Field, template),
+		}
+		if s, ok := seenByCluster[cluster.ID]; ok {
+			first, last := s.first, s.last
+			pattern.FirstSeen, pattern.LastSeen = &first, &last
+		}
+		for _, idx := range cluster.Members {
+			pattern.Samples = append(pattern.Samples, result.Logs[idx])
+		}
+		response.Patterns = append(response.Patterns, pattern)
+	}
+
+	log.Debug("log pattern clustering complete",
+		"source_id", sourceID,
+		"rows", len(result.Logs),
+		"patterns", len(clusters),
+	)
+	return response, nil
+}
+
+// PatternFilter returns a ClickHouse condition that matches the lines of a template,
+// suitable for adding to a query's WHERE clause to drill down into a pattern.
+func PatternFilter(field, template string) string {
+	regex := patterns.TemplateRegex(template)
+	// Escape for a single-quoted ClickHouse string literal.
+	regex = strings.ReplaceAll(regex, `\`, `\\`)
+	regex = strings.ReplaceAll(regex, `'`, `\'`)
+	return fmt.Sprintf("match(`%s`, '%s')", strings.ReplaceAll(field, "`", "``"), regex)
+}
+
+// stringValue converts a scanned column value into a string.
+func stringValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case *string:
+		if val == nil {
+			return ""
+		}
+		return *val
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// timeValue extracts a time from a scanned column value.
+func timeValue(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case *time.Time:
+		if val == nil {
+			return time.Time{}, false
+		}
+		return *val, true
+	case string:
+		// Results read back from the on-disk query cache have their times JSON encoded.
+		t, err := time.Parse(time.RFC3339Nano, val)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
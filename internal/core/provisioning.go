@@ -0,0 +1,1106 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/config"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// --- Declarative Provisioning ---
+
+// ErrProvisioned is returned when changing an object that is managed by provisioning.
+var ErrProvisioned = errors.New("managed by provisioning, change the provisioning files instead")
+
+const (
+	// provisioningReloadDelay collects a burst of file changes into a single apply.
+	provisioningReloadDelay = time.Second
+	// provisioningApplyTimeout bounds an apply triggered by a file change.
+	provisioningApplyTimeout = time.Minute
+)
+
+// provisioningEnvRef matches ${NAME} environment variable references in provisioning files.
+var provisioningEnvRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Provisioner reconciles users, sources, teams, memberships and collections with the YAML
+// files of a directory. Objects it creates or adopts are marked as provisioned, which makes
+// them read-only in the UI. Like InitAdminUsers it runs at startup, and it can re-apply the
+// files whenever they change. A nil *Provisioner is valid and does nothing.
+type Provisioner struct {
+	db          *sqlite.DB
+	chDB        *clickhouse.Manager
+	mapKeys     *MapKeysCache
+	log         *slog.Logger
+	cfg         config.ProvisioningConfig
+	adminEmails map[string]bool
+
+	applyMu  sync.Mutex // Serializes applies
+	statusMu sync.RWMutex
+	status   models.ProvisioningStatus
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewProvisioner creates a provisioner for the configured directory.
+// Returns nil when provisioning is disabled.
+func NewProvisioner(db *sqlite.DB, chDB *clickhouse.Manager, mapKeys *MapKeysCache, log *slog.Logger, cfg config.ProvisioningConfig, adminEmails []string) *Provisioner {
+	if cfg.Path == "" {
+		return nil
+	}
+
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(email)] = true
+	}
+	return &Provisioner{
+		db:          db,
+		chDB:        chDB,
+		mapKeys:     mapKeys,
+		log:         log.With("component", "provisioning"),
+		cfg:         cfg,
+		adminEmails: admins,
+		status:      models.ProvisioningStatus{Path: cfg.Path, Watch: cfg.Watch, Prune: cfg.Prune},
+		done:        make(chan struct{}),
+	}
+}
+
+// Apply reads the provisioning files and reconciles the database with them. Invalid files
+// are rejected before anything is written.
+func (p *Provisioner) Apply(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.applyMu.Lock()
+	defer p.applyMu.Unlock()
+
+	files, def, err := loadProvisioningFiles(p.cfg.Path)
+	run := &provisioningRun{Provisioner: p, changes: []models.ProvisioningChange{}}
+	if err == nil {
+		err = run.apply(ctx, def)
+	}
+
+	now := time.Now().UTC()
+	p.statusMu.Lock()
+	p.status.AppliedAt = &now
+	p.status.Files = files
+	p.status.Changes = run.changes
+	p.status.Error = ""
+	if err != nil {
+		p.status.Error = err.Error()
+	}
+	p.statusMu.Unlock()
+
+	if err != nil {
+		p.log.Error("failed to apply provisioning files", "path", p.cfg.Path, "changes", len(run.changes), "error", err)
+		return fmt.Errorf("error applying provisioning files: %w", err)
+	}
+	p.log.Info("applied provisioning files", "path", p.cfg.Path, "files", len(files), "changes", len(run.changes))
+	return nil
+}
+
+// Watch starts re-applying the files whenever the directory changes, until Close is called.
+// Does nothing unless watching is enabled.
+func (p *Provisioner) Watch() error {
+	if p == nil || !p.cfg.Watch {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating provisioning watcher: %w", err)
+	}
+	if err := watcher.Add(p.cfg.Path); err != nil {
+		watcher.Close()
+		return fmt.Errorf("error watching provisioning directory: %w", err)
+	}
+
+	p.wg.Add(1)
+	go p.watch(watcher)
+	return nil
+}
+
+// Status returns the configuration and the result of the last apply.
+func (p *Provisioner) Status() models.ProvisioningStatus {
+	if p == nil {
+		return models.ProvisioningStatus{}
+	}
+	p.statusMu.RLock()
+	defer p.statusMu.RUnlock()
+	status := p.status
+	status.Enabled = true
+	return status
+}
+
+// Close stops watching the directory, waiting for a running apply or until ctx expires.
+func (p *Provisioner) Close(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.once.Do(func() { close(p.done) })
+
+	finished := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// watch applies the files once the directory has been quiet for provisioningReloadDelay.
+func (p *Provisioner) watch(watcher *fsnotify.Watcher) {
+	defer p.wg.Done()
+	defer watcher.Close()
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-p.done:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			p.log.Debug("provisioning directory changed", "file", event.Name, "op", event.Op.String())
+			reload = time.After(provisioningReloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			p.log.Warn("error watching provisioning directory", "error", err)
+		case <-reload:
+			reload = nil
+			ctx, cancel := context.WithTimeout(context.Background(), provisioningApplyTimeout)
+			_ = p.Apply(ctx) // Failures are logged and reported in the status.
+			cancel()
+		}
+	}
+}
+
+// loadProvisioningFiles reads and merges the YAML files of dir, in name order. Hidden files
+// are skipped, as are the directories Kubernetes uses for mounted ConfigMaps.
+func loadProvisioningFiles(dir string) ([]string, *models.ProvisioningFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading provisioning directory: %w", err)
+	}
+
+	files := []string{}
+	merged := &models.ProvisioningFile{}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if strings.HasPrefix(name, ".") || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return files, nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+		data, err = expandProvisioningEnv(data)
+		if err != nil {
+			return files, nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		var file models.ProvisioningFile
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return files, nil, fmt.Errorf("error parsing %s: %w", name, err)
+		}
+		files = append(files, name)
+		merged.Users = append(merged.Users, file.Users...)
+		merged.Sources = append(merged.Sources, file.Sources...)
+		merged.Teams = append(merged.Teams, file.Teams...)
+	}
+	return files, merged, nil
+}
+
+// expandProvisioningEnv replaces ${NAME} references with environment variables. Unlike
+// os.ExpandEnv it leaves other dollar signs alone, so queries can use them, and it rejects
+// references to unset variables rather than silently using an empty value.
+func expandProvisioningEnv(data []byte) ([]byte, error) {
+	var missing []string
+	expanded := provisioningEnvRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name := string(provisioningEnvRef.FindSubmatch(ref)[1])
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return []byte(value)
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unset environment variables: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// validateProvisioning checks the declared objects and fills in defaults, before anything
+// is written.
+func (p *Provisioner) validateProvisioning(def *models.ProvisioningFile) error {
+	users := make(map[string]bool, len(def.Users))
+	for i := range def.Users {
+		user := &def.Users[i]
+		user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+		if user.Role == "" {
+			user.Role = models.UserRoleMember
+		}
+		if user.Status == "" {
+			user.Status = models.UserStatusActive
+		}
+		if err := validateUserCreation(user.Email, user.FullName, user.Role); err != nil {
+			return provisioningError("user", user.Email, err)
+		}
+		if user.Status != models.UserStatusActive && user.Status != models.UserStatusInactive {
+			return provisioningError("user", user.Email, errors.New("status must be either 'active' or 'inactive'"))
+		}
+		if p.adminEmails[user.Email] && (user.Role != models.UserRoleAdmin || user.Status != models.UserStatusActive) {
+			return provisioningError("user", user.Email, errors.New("admin emails of the auth config must be active admins"))
+		}
+		if users[user.Email] {
+			return provisioningError("user", user.Email, errors.New("declared more than once"))
+		}
+		users[user.Email] = true
+	}
+
+	sources := make(map[string]bool, len(def.Sources))
+	tables := make(map[string]bool, len(def.Sources))
+	for _, source := range def.Sources {
+		conn := models.ConnectionInfo{
+			Host:      source.Host,
+			Username:  source.Username,
+			Password:  source.Password,
+			Database:  source.Database,
+			TableName: source.TableName,
+		}
+		if err := validateSourceCreation(source.Name, conn, source.Description, source.TTLDays, source.MetaTSField, source.MetaSeverityField); err != nil {
+			return provisioningError("source", source.Name, err)
+		}
+		if err := validateSourceFields(source.FieldRoles, source.SeverityMapping, source.MetaSeverityField); err != nil {
+			return provisioningError("source", source.Name, err)
+		}
+		if sources[source.Name] {
+			return provisioningError("source", source.Name, errors.New("declared more than once"))
+		}
+		table := source.Database + "." + source.TableName
+		if tables[table] {
+			return provisioningError("source", source.Name, fmt.Errorf("another source is declared for table %s", table))
+		}
+		sources[source.Name] = true
+		tables[table] = true
+	}
+
+	teams := make(map[string]bool, len(def.Teams))
+	for i := range def.Teams {
+		team := &def.Teams[i]
+		if err := validateTeamCreation(team.Name, team.Description); err != nil {
+			return provisioningError("team", team.Name, err)
+		}
+		if teams[team.Name] {
+			return provisioningError("team", team.Name, errors.New("declared more than once"))
+		}
+		teams[team.Name] = true
+
+		members := make(map[string]bool, len(team.Members))
+		for j := range team.Members {
+			member := &team.Members[j]
+			member.Email = strings.ToLower(strings.TrimSpace(member.Email))
+			if member.Role == "" {
+				member.Role = models.TeamRoleMember
+			}
+			if member.Role != models.TeamRoleAdmin && member.Role != models.TeamRoleMember && member.Role != models.TeamRoleEditor {
+				return provisioningError("team", team.Name, fmt.Errorf("member %s: role must be 'admin', 'editor', or 'member'", member.Email))
+			}
+			if members[member.Email] {
+				return provisioningError("team", team.Name, fmt.Errorf("member %s is declared more than once", member.Email))
+			}
+			members[member.Email] = true
+		}
+
+		linked := make(map[string]bool, len(team.Sources))
+		for j := range team.Sources {
+			link := &team.Sources[j]
+			link.RowFilter = strings.TrimSpace(link.RowFilter)
+			if !sources[link.Source] {
+				return provisioningError("team", team.Name, fmt.Errorf("unknown source %q", link.Source))
+			}
+			if linked[link.Source] {
+				return provisioningError("team", team.Name, fmt.Errorf("source %q is linked more than once", link.Source))
+			}
+			if link.RowFilter != "" {
+				if err := clickhouse.ValidateRowFilter(link.RowFilter); err != nil {
+					return provisioningError("team", team.Name, fmt.Errorf("invalid row filter for source %q: %w", link.Source, err))
+				}
+			}
+			policies := provisionedColumnPolicies(link)
+			if err := validateLinkColumnPolicies(policies); err != nil {
+				return provisioningError("team", team.Name, fmt.Errorf("invalid column policy for source %q: %w", link.Source, err))
+			}
+			for k, policy := range policies {
+				link.ColumnPolicies[k] = models.ProvisionedColumnPolicy{Column: policy.Column, Action: policy.Action, Pattern: policy.Pattern, Replacement: policy.Replacement}
+			}
+			linked[link.Source] = true
+		}
+
+		collections := make(map[string]bool, len(team.Collections))
+		for j := range team.Collections {
+			collection := &team.Collections[j]
+			name := team.Name + "/" + collection.Name
+			if !linked[collection.Source] {
+				return provisioningError("collection", name, fmt.Errorf("source %q is not linked to the team", collection.Source))
+			}
+			tags, err := validateCollectionDefinition(collection.Name, collection.QueryType, collection.Folder, collection.Tags, collection.Content)
+			if err != nil {
+				return provisioningError("collection", name, err)
+			}
+			collection.Tags = tags
+			collection.Folder = strings.TrimSpace(collection.Folder)
+			key := collection.Source + "\x00" + collection.Name
+			if collections[key] {
+				return provisioningError("collection", name, errors.New("declared more than once for the source"))
+			}
+			collections[key] = true
+		}
+	}
+	return nil
+}
+
+// provisioningError wraps a validation error with the object it was found in.
+func provisioningError(kind, name string, err error) error {
+	return &ValidationError{Field: kind, Message: fmt.Sprintf("%q: %v", name, err)}
+}
+
+// provisioningRun reconciles the database with one set of provisioning files.
+type provisioningRun struct {
+	*Provisioner
+	changes   []models.ProvisioningChange
+	userIDs   map[string]models.UserID
+	sourceIDs map[string]models.SourceID
+}
+
+// record adds a change to the run.
+func (r *provisioningRun) record(kind, name string, action models.ProvisioningAction) {
+	r.changes = append(r.changes, models.ProvisioningChange{Kind: kind, Name: name, Action: action})
+	r.log.Info("provisioning change", "kind", kind, "name", name, "action", action)
+}
+
+// apply validates the declared objects, then creates and updates them, and finally prunes
+// or releases provisioned objects that are no longer declared.
+func (r *provisioningRun) apply(ctx context.Context, def *models.ProvisioningFile) error {
+	if err := r.validateProvisioning(def); err != nil {
+		return err
+	}
+	if err := r.resolveMembers(ctx, def); err != nil {
+		return err
+	}
+
+	for i := range def.Users {
+		if err := r.applyUser(ctx, &def.Users[i]); err != nil {
+			return err
+		}
+	}
+	for i := range def.Sources {
+		if err := r.applySource(ctx, &def.Sources[i]); err != nil {
+			return err
+		}
+	}
+	for i := range def.Teams {
+		if err := r.applyTeam(ctx, &def.Teams[i]); err != nil {
+			return err
+		}
+	}
+	return r.removeUndeclared(ctx, def)
+}
+
+// resolveMembers checks that team members which aren't declared as users already exist.
+func (r *provisioningRun) resolveMembers(ctx context.Context, def *models.ProvisioningFile) error {
+	r.userIDs = make(map[string]models.UserID)
+	r.sourceIDs = make(map[string]models.SourceID)
+
+	declared := make(map[string]bool, len(def.Users))
+	for _, user := range def.Users {
+		declared[user.Email] = true
+	}
+	for _, team := range def.Teams {
+		for _, member := range team.Members {
+			if declared[member.Email] {
+				continue
+			}
+			if _, ok := r.userIDs[member.Email]; ok {
+				continue
+			}
+			user, err := r.db.GetUserByEmail(ctx, member.Email)
+			if err != nil {
+				if sqlite.IsNotFoundError(err) || sqlite.IsUserNotFoundError(err) {
+					return provisioningError("team", team.Name, fmt.Errorf("member %s is neither declared nor an existing user", member.Email))
+				}
+				return fmt.Errorf("error looking up user %s: %w", member.Email, err)
+			}
+			r.userIDs[member.Email] = user.ID
+		}
+	}
+	return nil
+}
+
+// applyUser creates or updates a declared user.
+func (r *provisioningRun) applyUser(ctx context.Context, pu *models.ProvisionedUser) error {
+	existing, err := r.db.GetUserByEmail(ctx, pu.Email)
+	if err != nil && !sqlite.IsNotFoundError(err) && !sqlite.IsUserNotFoundError(err) {
+		return fmt.Errorf("error looking up user %s: %w", pu.Email, err)
+	}
+
+	if err != nil {
+		user := &models.User{Email: pu.Email, FullName: pu.FullName, Role: pu.Role, Status: pu.Status}
+		if err := r.db.CreateUser(ctx, user); err != nil {
+			return fmt.Errorf("error creating user %s: %w", pu.Email, err)
+		}
+		if err := r.db.SetUserProvisioned(ctx, user.ID, true); err != nil {
+			return err
+		}
+		r.userIDs[pu.Email] = user.ID
+		r.record("user", pu.Email, models.ProvisioningCreate)
+		return nil
+	}
+
+	r.userIDs[pu.Email] = existing.ID
+	changed := existing.FullName != pu.FullName || existing.Role != pu.Role || existing.Status != pu.Status
+	if changed {
+		existing.FullName = pu.FullName
+		existing.Role = pu.Role
+		existing.Status = pu.Status
+		existing.UpdatedAt = time.Now().UTC()
+		if err := r.db.UpdateUser(ctx, existing); err != nil {
+			return fmt.Errorf("error updating user %s: %w", pu.Email, err)
+		}
+	}
+	if !existing.Provisioned {
+		if err := r.db.SetUserProvisioned(ctx, existing.ID, true); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if changed {
+		r.record("user", pu.Email, models.ProvisioningUpdate)
+	}
+	return nil
+}
+
+// applySource creates or updates a declared source, reconnecting it when its connection
+// changed.
+func (r *provisioningRun) applySource(ctx context.Context, ps *models.ProvisionedSource) error {
+	existing, err := r.db.GetSourceByName(ctx, ps.Database, ps.TableName)
+	if err != nil && !sqlite.IsNotFoundError(err) {
+		return fmt.Errorf("error looking up source %q: %w", ps.Name, err)
+	}
+	conn := models.ConnectionInfo{
+		Host:      ps.Host,
+		Username:  ps.Username,
+		Password:  ps.Password,
+		Database:  ps.Database,
+		TableName: ps.TableName,
+	}
+
+	if existing == nil {
+		source := &models.Source{
+			Name:              ps.Name,
+			Description:       ps.Description,
+			MetaTSField:       ps.MetaTSField,
+			MetaSeverityField: ps.MetaSeverityField,
+			TTLDays:           ps.TTLDays,
+			FieldRoles:        ps.FieldRoles,
+			SeverityMapping:   ps.SeverityMapping,
+			Connection:        conn,
+		}
+		if err := r.db.CreateSource(ctx, source); err != nil {
+			return fmt.Errorf("error creating source %q: %w", ps.Name, err)
+		}
+		if err := r.db.SetSourceProvisioned(ctx, source.ID, true); err != nil {
+			return err
+		}
+		r.sourceIDs[ps.Name] = source.ID
+		r.connectSource(source, false)
+		r.record("source", ps.Name, models.ProvisioningCreate)
+		return nil
+	}
+
+	r.sourceIDs[ps.Name] = existing.ID
+	reconnect := existing.Connection != conn
+	changed := reconnect || existing.Name != ps.Name || existing.Description != ps.Description ||
+		existing.MetaTSField != ps.MetaTSField || existing.MetaSeverityField != ps.MetaSeverityField ||
+		existing.TTLDays != ps.TTLDays || existing.FieldRoles != ps.FieldRoles ||
+		!maps.Equal(existing.SeverityMapping, ps.SeverityMapping)
+	if changed {
+		existing.Name = ps.Name
+		existing.Description = ps.Description
+		existing.MetaTSField = ps.MetaTSField
+		existing.MetaSeverityField = ps.MetaSeverityField
+		existing.TTLDays = ps.TTLDays
+		existing.FieldRoles = ps.FieldRoles
+		existing.SeverityMapping = ps.SeverityMapping
+		existing.Connection = conn
+		if err := r.db.UpdateSource(ctx, existing); err != nil {
+			return fmt.Errorf("error updating source %q: %w", ps.Name, err)
+		}
+		if reconnect {
+			r.connectSource(existing, true)
+		}
+	}
+	if !existing.Provisioned {
+		if err := r.db.SetSourceProvisioned(ctx, existing.ID, true); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if changed {
+		r.record("source", ps.Name, models.ProvisioningUpdate)
+	}
+	return nil
+}
+
+// connectSource adds a source to the ClickHouse manager, first dropping its old connection
+// when reconnecting. Connection failures are only logged, as for sources created in the UI.
+func (r *provisioningRun) connectSource(source *models.Source, reconnect bool) {
+	if r.chDB == nil {
+		return
+	}
+	if reconnect {
+		if err := r.chDB.RemoveSource(source.ID); err != nil {
+			r.log.Warn("error removing source connection", "source_id", source.ID, "error", err)
+		}
+	}
+	if err := r.chDB.AddSource(source); err != nil {
+		r.log.Warn("error connecting to source", "source_id", source.ID, "error", err)
+	}
+}
+
+// applyTeam creates or updates a declared team, then reconciles its members, source links
+// and collections.
+func (r *provisioningRun) applyTeam(ctx context.Context, pt *models.ProvisionedTeam) error {
+	team, err := r.db.GetTeamByName(ctx, pt.Name)
+	if err != nil && !sqlite.IsNotFoundError(err) && !sqlite.IsTeamNotFoundError(err) {
+		return fmt.Errorf("error looking up team %q: %w", pt.Name, err)
+	}
+
+	if team == nil {
+		team = &models.Team{Name: pt.Name, Description: pt.Description}
+		if err := r.db.CreateTeam(ctx, team); err != nil {
+			return fmt.Errorf("error creating team %q: %w", pt.Name, err)
+		}
+		if pt.AllowAnonymousShares {
+			team.AllowAnonymousShares = true
+			team.UpdatedAt = time.Now().UTC()
+			if err := r.db.UpdateTeam(ctx, team); err != nil {
+				return fmt.Errorf("error updating team %q: %w", pt.Name, err)
+			}
+		}
+		if err := r.db.SetTeamProvisioned(ctx, team.ID, true); err != nil {
+			return err
+		}
+		r.record("team", pt.Name, models.ProvisioningCreate)
+	} else {
+		changed := team.Description != pt.Description || team.AllowAnonymousShares != pt.AllowAnonymousShares
+		if changed {
+			team.Description = pt.Description
+			team.AllowAnonymousShares = pt.AllowAnonymousShares
+			team.UpdatedAt = time.Now().UTC()
+			if err := r.db.UpdateTeam(ctx, team); err != nil {
+				return fmt.Errorf("error updating team %q: %w", pt.Name, err)
+			}
+		}
+		if !team.Provisioned {
+			if err := r.db.SetTeamProvisioned(ctx, team.ID, true); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if changed {
+			r.record("team", pt.Name, models.ProvisioningUpdate)
+		}
+	}
+
+	if err := r.applyTeamMembers(ctx, team, pt); err != nil {
+		return err
+	}
+	if err := r.applyTeamSources(ctx, team, pt); err != nil {
+		return err
+	}
+	return r.applyTeamCollections(ctx, team, pt)
+}
+
+// applyTeamMembers adds and updates the declared members of a team. Provisioned members that
+// are no longer declared are removed when pruning, else released; other members are kept.
+func (r *provisioningRun) applyTeamMembers(ctx context.Context, team *models.Team, pt *models.ProvisionedTeam) error {
+	members, err := r.db.ListTeamMembers(ctx, team.ID)
+	if err != nil {
+		return fmt.Errorf("error listing members of team %q: %w", pt.Name, err)
+	}
+	current := make(map[models.UserID]*models.TeamMember, len(members))
+	for _, member := range members {
+		current[member.UserID] = member
+	}
+
+	declared := make(map[models.UserID]bool, len(pt.Members))
+	for _, pm := range pt.Members {
+		userID := r.userIDs[pm.Email]
+		declared[userID] = true
+		name := pt.Name + "/" + pm.Email
+
+		member := current[userID]
+		if member == nil {
+			if err := r.db.AddTeamMember(ctx, team.ID, userID, pm.Role); err != nil {
+				return fmt.Errorf("error adding %s to team %q: %w", pm.Email, pt.Name, err)
+			}
+			if err := r.db.SetTeamMemberProvisioned(ctx, team.ID, userID, true); err != nil {
+				return err
+			}
+			r.record("team_member", name, models.ProvisioningCreate)
+			continue
+		}
+
+		changed := member.Role != pm.Role
+		if changed {
+			if err := r.db.UpdateTeamMemberRole(ctx, team.ID, userID, pm.Role); err != nil {
+				return fmt.Errorf("error updating role of %s in team %q: %w", pm.Email, pt.Name, err)
+			}
+		}
+		if !member.Provisioned {
+			if err := r.db.SetTeamMemberProvisioned(ctx, team.ID, userID, true); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if changed {
+			r.record("team_member", name, models.ProvisioningUpdate)
+		}
+	}
+
+	for _, member := range members {
+		if !member.Provisioned || declared[member.UserID] {
+			continue
+		}
+		name := fmt.Sprintf("%s/%d", pt.Name, member.UserID)
+		if r.cfg.Prune {
+			if err := r.db.RemoveTeamMember(ctx, team.ID, member.UserID); err != nil {
+				return fmt.Errorf("error removing member from team %q: %w", pt.Name, err)
+			}
+			r.record("team_member", name, models.ProvisioningDelete)
+			continue
+		}
+		if err := r.db.SetTeamMemberProvisioned(ctx, team.ID, member.UserID, false); err != nil {
+			return err
+		}
+		r.record("team_member", name, models.ProvisioningRelease)
+	}
+	return nil
+}
+
+// provisionedColumnPolicies returns the declared column policies of a team-source link, or
+// nil when the link doesn't declare any.
+func provisionedColumnPolicies(link *models.ProvisionedTeamSource) []*models.ColumnPolicy {
+	if link.ColumnPolicies == nil {
+		return nil
+	}
+	policies := make([]*models.ColumnPolicy, 0, len(link.ColumnPolicies))
+	for _, policy := range link.ColumnPolicies {
+		policies = append(policies, &models.ColumnPolicy{
+			Column:      policy.Column,
+			Action:      policy.Action,
+			Pattern:     policy.Pattern,
+			Replacement: policy.Replacement,
+		})
+	}
+	return policies
+}
+
+// sameColumnPolicies reports whether stored column policies match the declared ones,
+// regardless of order.
+func sameColumnPolicies(stored, declared []*models.ColumnPolicy) bool {
+	if len(stored) != len(declared) {
+		return false
+	}
+	byColumn := make(map[string]*models.ColumnPolicy, len(stored))
+	for _, policy := range stored {
+		byColumn[strings.ToLower(policy.Column)] = policy
+	}
+	for _, policy := range declared {
+		current, ok := byColumn[strings.ToLower(policy.Column)]
+		if !ok || current.Column != policy.Column || current.Action != policy.Action ||
+			current.Pattern != policy.Pattern || current.Replacement != policy.Replacement {
+			return false
+		}
+	}
+	return true
+}
+
+// applyTeamSources links a team to exactly the declared sources with their row filters and
+// declared column policies. A link and its restrictions are written together.
+func (r *provisioningRun) applyTeamSources(ctx context.Context, team *models.Team, pt *models.ProvisionedTeam) error {
+	sources, err := r.db.ListTeamSources(ctx, team.ID)
+	if err != nil {
+		return fmt.Errorf("error listing sources of team %q: %w", pt.Name, err)
+	}
+	linked := make(map[models.SourceID]bool, len(sources))
+	for _, source := range sources {
+		linked[source.ID] = true
+	}
+
+	declared := make(map[models.SourceID]bool, len(pt.Sources))
+	for i := range pt.Sources {
+		link := &pt.Sources[i]
+		sourceID := r.sourceIDs[link.Source]
+		declared[sourceID] = true
+		name := pt.Name + "/" + link.Source
+		policies := provisionedColumnPolicies(link)
+
+		if !linked[sourceID] {
+			if err := r.db.SetTeamSourceAccess(ctx, team.ID, sourceID, link.RowFilter, policies); err != nil {
+				return fmt.Errorf("error linking source %q to team %q: %w", link.Source, pt.Name, err)
+			}
+			r.mapKeys.Invalidate(sourceID)
+			r.record("team_source", name, models.ProvisioningCreate)
+			continue
+		}
+
+		filter, err := r.db.GetTeamSourceRowFilter(ctx, team.ID, sourceID)
+		if err != nil {
+			return fmt.Errorf("error getting row filter of team %q: %w", pt.Name, err)
+		}
+		changed := filter != link.RowFilter
+		if policies != nil && !changed {
+			stored, err := r.db.ListColumnPolicies(ctx, team.ID, sourceID)
+			if err != nil {
+				return fmt.Errorf("error listing column policies of team %q: %w", pt.Name, err)
+			}
+			changed = !sameColumnPolicies(stored, policies)
+		}
+		if changed {
+			if err := r.db.SetTeamSourceAccess(ctx, team.ID, sourceID, link.RowFilter, policies); err != nil {
+				return fmt.Errorf("error setting source access of team %q: %w", pt.Name, err)
+			}
+			r.mapKeys.Invalidate(sourceID)
+			r.record("team_source", name, models.ProvisioningUpdate)
+		}
+	}
+
+	for _, source := range sources {
+		if declared[source.ID] {
+			continue
+		}
+		if err := r.db.RemoveTeamSource(ctx, team.ID, source.ID); err != nil {
+			return fmt.Errorf("error unlinking source %q from team %q: %w", source.Name, pt.Name, err)
+		}
+		r.mapKeys.Invalidate(source.ID)
+		r.record("team_source", pt.Name+"/"+source.Name, models.ProvisioningDelete)
+	}
+	return nil
+}
+
+// applyTeamCollections creates and updates the declared collections of a team. Provisioned
+// collections that are no longer declared are deleted when pruning, else released.
+func (r *provisioningRun) applyTeamCollections(ctx context.Context, team *models.Team, pt *models.ProvisionedTeam) error {
+	queries, err := r.db.SearchTeamQueries(ctx, models.SavedQuerySearchFilter{TeamID: team.ID, Sort: models.SavedQuerySortName})
+	if err != nil {
+		return fmt.Errorf("error listing collections of team %q: %w", pt.Name, err)
+	}
+	current := make(map[collectionKey]*models.SavedTeamQuery, len(queries))
+	for _, query := range queries {
+		current[collectionKey{query.SourceID, query.Name}] = query
+	}
+	teamFolders, err := r.db.ListTeamCollectionFolders(ctx, team.ID)
+	if err != nil {
+		return fmt.Errorf("error listing folders of team %q: %w", pt.Name, err)
+	}
+	folderIDs := make(map[string]int, len(teamFolders))
+	folderNames := make(map[int]string, len(teamFolders))
+	for _, folder := range teamFolders {
+		folderIDs[folder.Name] = folder.ID
+		folderNames[folder.ID] = folder.Name
+	}
+
+	declared := make(map[int]bool, len(pt.Collections))
+	for i := range pt.Collections {
+		pc := &pt.Collections[i]
+		sourceID := r.sourceIDs[pc.Source]
+		name := pt.Name + "/" + pc.Name
+
+		// Point the query at the source's ID in this instance.
+		pc.Content["sourceId"] = int(sourceID)
+		content, err := json.Marshal(pc.Content)
+		if err != nil {
+			return fmt.Errorf("error encoding collection %q: %w", name, err)
+		}
+
+		query := current[collectionKey{sourceID, pc.Name}]
+		if query == nil {
+			created := &models.TeamQuery{
+				TeamID:       team.ID,
+				SourceID:     sourceID,
+				Name:         pc.Name,
+				Description:  pc.Description,
+				QueryType:    pc.QueryType,
+				QueryContent: string(content),
+			}
+			if err := r.db.CreateTeamSourceQuery(ctx, created); err != nil {
+				return fmt.Errorf("error creating collection %q: %w", name, err)
+			}
+			if err := r.setCollectionPlacement(ctx, team.ID, sourceID, created.ID, pc, folderIDs); err != nil {
+				return fmt.Errorf("error organizing collection %q: %w", name, err)
+			}
+			if err := r.db.SetTeamQueryProvisioned(ctx, created.ID, true); err != nil {
+				return err
+			}
+			declared[created.ID] = true
+			r.record("collection", name, models.ProvisioningCreate)
+			continue
+		}
+		declared[query.ID] = true
+
+		changed := false
+		if query.Description != pc.Description || query.QueryType != pc.QueryType || !sameQueryContent(query.QueryContent, content) {
+			if err := r.db.UpdateTeamSourceQuery(ctx, team.ID, sourceID, query.ID, pc.Name, pc.Description, string(pc.QueryType), string(content), nil); err != nil {
+				return fmt.Errorf("error updating collection %q: %w", name, err)
+			}
+			changed = true
+		}
+		folder := ""
+		if query.FolderID != nil {
+			folder = folderNames[*query.FolderID]
+		}
+		if folder != pc.Folder || !slices.Equal(sortedTags(query.Tags), sortedTags(pc.Tags)) {
+			if err := r.setCollectionPlacement(ctx, team.ID, sourceID, query.ID, pc, folderIDs); err != nil {
+				return fmt.Errorf("error organizing collection %q: %w", name, err)
+			}
+			changed = true
+		}
+		if !query.Provisioned {
+			if err := r.db.SetTeamQueryProvisioned(ctx, query.ID, true); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if changed {
+			r.record("collection", name, models.ProvisioningUpdate)
+		}
+	}
+
+	for _, query := range queries {
+		if !query.Provisioned || declared[query.ID] {
+			continue
+		}
+		name := pt.Name + "/" + query.Name
+		if r.cfg.Prune {
+			if err := r.db.DeleteTeamSourceQuery(ctx, team.ID, query.SourceID, query.ID); err != nil {
+				return fmt.Errorf("error deleting collection %q: %w", name, err)
+			}
+			r.record("collection", name, models.ProvisioningDelete)
+			continue
+		}
+		if err := r.db.SetTeamQueryProvisioned(ctx, query.ID, false); err != nil {
+			return err
+		}
+		r.record("collection", name, models.ProvisioningRelease)
+	}
+	return nil
+}
+
+// setCollectionPlacement puts a collection into its declared folder, creating the folder
+// when needed, and sets its tags.
+func (r *provisioningRun) setCollectionPlacement(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, queryID int, pc *models.ProvisionedCollection, folderIDs map[string]int) error {
+	var folderID *int
+	if pc.Folder != "" {
+		id, ok := folderIDs[pc.Folder]
+		if !ok {
+			folder := &models.CollectionFolder{TeamID: teamID, Name: pc.Folder}
+			if err := r.db.CreateTeamCollectionFolder(ctx, folder); err != nil {
+				return err
+			}
+			id = folder.ID
+			folderIDs[pc.Folder] = id
+		}
+		folderID = &id
+	}
+	if err := r.db.SetTeamQueryFolder(ctx, teamID, sourceID, queryID, folderID); err != nil {
+		return err
+	}
+	return r.db.SetTeamQueryTags(ctx, queryID, pc.Tags)
+}
+
+// sameQueryContent reports whether stored query content matches the declared content,
+// ignoring formatting and key order.
+func sameQueryContent(stored string, declared []byte) bool {
+	var content map[string]any
+	if err := json.Unmarshal([]byte(stored), &content); err != nil {
+		return false
+	}
+	normalized, err := json.Marshal(content)
+	return err == nil && bytes.Equal(normalized, declared)
+}
+
+// sortedTags returns a sorted copy of tags.
+func sortedTags(tags []string) []string {
+	sorted := slices.Clone(tags)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// removeUndeclared deletes provisioned teams, sources and users that are no longer declared
+// when pruning, and otherwise releases them so they can be managed from the UI.
+func (r *provisioningRun) removeUndeclared(ctx context.Context, def *models.ProvisioningFile) error {
+	declaredTeams := make(map[string]bool, len(def.Teams))
+	for _, team := range def.Teams {
+		declaredTeams[team.Name] = true
+	}
+	teams, err := r.db.ListTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing teams: %w", err)
+	}
+	for _, team := range teams {
+		if !team.Provisioned || declaredTeams[team.Name] {
+			continue
+		}
+		if r.cfg.Prune {
+			if err := r.db.DeleteTeam(ctx, team.ID); err != nil {
+				return fmt.Errorf("error deleting team %q: %w", team.Name, err)
+			}
+			r.record("team", team.Name, models.ProvisioningDelete)
+			continue
+		}
+		if err := r.releaseTeam(ctx, team); err != nil {
+			return err
+		}
+		r.record("team", team.Name, models.ProvisioningRelease)
+	}
+
+	sources, err := r.db.ListSources(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing sources: %w", err)
+	}
+	declaredSources := make(map[models.SourceID]bool, len(r.sourceIDs))
+	for _, id := range r.sourceIDs {
+		declaredSources[id] = true
+	}
+	for _, source := range sources {
+		if !source.Provisioned || declaredSources[source.ID] {
+			continue
+		}
+		if r.cfg.Prune {
+			if r.chDB != nil {
+				if err := r.chDB.RemoveSource(source.ID); err != nil {
+					r.log.Warn("error removing source connection", "source_id", source.ID, "error", err)
+				}
+			}
+			if err := r.db.DeleteSource(ctx, source.ID); err != nil {
+				return fmt.Errorf("error deleting source %q: %w", source.Name, err)
+			}
+			r.record("source", source.Name, models.ProvisioningDelete)
+			continue
+		}
+		if err := r.db.SetSourceProvisioned(ctx, source.ID, false); err != nil {
+			return err
+		}
+		r.record("source", source.Name, models.ProvisioningRelease)
+	}
+
+	declaredUsers := make(map[string]bool, len(def.Users))
+	for _, user := range def.Users {
+		declaredUsers[user.Email] = true
+	}
+	users, err := r.db.ListUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing users: %w", err)
+	}
+	for _, user := range users {
+		if !user.Provisioned || declaredUsers[strings.ToLower(user.Email)] {
+			continue
+		}
+		// Admins of the auth config are never deleted, so an instance can't lock itself out.
+		if r.cfg.Prune && !r.adminEmails[strings.ToLower(user.Email)] {
+			if err := r.db.DeleteUser(ctx, user.ID); err != nil {
+				return fmt.Errorf("error deleting user %s: %w", user.Email, err)
+			}
+			r.record("user", user.Email, models.ProvisioningDelete)
+			continue
+		}
+		if err := r.db.SetUserProvisioned(ctx, user.ID, false); err != nil {
+			return err
+		}
+		r.record("user", user.Email, models.ProvisioningRelease)
+	}
+	return nil
+}
+
+// releaseTeam unmarks a team along with its provisioned members and collections.
+func (r *provisioningRun) releaseTeam(ctx context.Context, team *models.Team) error {
+	members, err := r.db.ListTeamMembers(ctx, team.ID)
+	if err != nil {
+		return fmt.Errorf("error listing members of team %q: %w", team.Name, err)
+	}
+	for _, member := range members {
+		if member.Provisioned {
+			if err := r.db.SetTeamMemberProvisioned(ctx, team.ID, member.UserID, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	queries, err := r.db.SearchTeamQueries(ctx, models.SavedQuerySearchFilter{TeamID: team.ID})
+	if err != nil {
+		return fmt.Errorf("error listing collections of team %q: %w", team.Name, err)
+	}
+	for _, query := range queries {
+		if query.Provisioned {
+			if err := r.db.SetTeamQueryProvisioned(ctx, query.ID, false); err != nil {
+				return err
+			}
+		}
+	}
+	return r.db.SetTeamProvisioned(ctx, team.ID, false)
+}
+
+// checkTeamNotProvisioned returns ErrProvisioned if the team is managed by provisioning.
+func checkTeamNotProvisioned(ctx context.Context, db *sqlite.DB, teamID models.TeamID) error {
+	team, err := db.GetTeam(ctx, teamID)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) || sqlite.IsTeamNotFoundError(err) {
+			return ErrTeamNotFound
+		}
+		return fmt.Errorf("error getting team: %w", err)
+	}
+	if team.Provisioned {
+		return ErrProvisioned
+	}
+	return nil
+}
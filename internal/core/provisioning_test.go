@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+func TestSameColumnPolicies(t *testing.T) {
+	stored := []*models.ColumnPolicy{
+		{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d+`, Replacement: "***"},
+		{Column: "remote_addr", Action: models.ColumnPolicyHide},
+	}
+	tests := []struct {
+		name     string
+		declared []*models.ColumnPolicy
+		want     bool
+	}{
+		{name: "same policies in another order", declared: []*models.ColumnPolicy{
+			{Column: "remote_addr", Action: models.ColumnPolicyHide},
+			{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d+`, Replacement: "***"},
+		}, want: true},
+		{name: "empty list", declared: []*models.ColumnPolicy{}, want: false},
+		{name: "missing policy", declared: []*models.ColumnPolicy{
+			{Column: "remote_addr", Action: models.ColumnPolicyHide},
+		}, want: false},
+		{name: "changed action", declared: []*models.ColumnPolicy{
+			{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d+`, Replacement: "***"},
+			{Column: "remote_addr", Action: models.ColumnPolicyHash},
+		}, want: false},
+		{name: "changed replacement", declared: []*models.ColumnPolicy{
+			{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d+`, Replacement: "[redacted]"},
+			{Column: "remote_addr", Action: models.ColumnPolicyHide},
+		}, want: false},
+		{name: "other column", declared: []*models.ColumnPolicy{
+			{Column: "body", Action: models.ColumnPolicyRedact, Pattern: `\d+`, Replacement: "***"},
+			{Column: "user_email", Action: models.ColumnPolicyHide},
+		}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameColumnPolicies(stored, tt.declared); got != tt.want {
+				t.Errorf("sameColumnPolicies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvisionedColumnPolicies(t *testing.T) {
+	if got := provisionedColumnPolicies(&models.ProvisionedTeamSource{Source: "logs"}); got != nil {
+		t.Errorf("undeclared policies = %v, want nil", got)
+	}
+	got := provisionedColumnPolicies(&models.ProvisionedTeamSource{Source: "logs", ColumnPolicies: []models.ProvisionedColumnPolicy{}})
+	if got == nil || len(got) != 0 {
+		t.Errorf("empty policies = %v, want an empty non-nil list", got)
+	}
+}
@@ -0,0 +1,469 @@
+package core
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/config"
+	"github.com/mr-karan/logchef/internal/metrics"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Query Result Cache ---
+
+const (
+	// DefaultQueryCacheMaxEntries is the number of results kept in memory when not configured.
+	DefaultQueryCacheMaxEntries = 1000
+	// DefaultQueryCacheMaxBytes bounds the memory used by cached results when not configured.
+	DefaultQueryCacheMaxBytes = 64 << 20
+	// DefaultQueryCacheMaxEntryBytes is the size above which results aren't cached when not configured.
+	DefaultQueryCacheMaxEntryBytes = 4 << 20
+	// DefaultQueryCacheRecentWindow is how close to now a range must end to be recent when not configured.
+	DefaultQueryCacheRecentWindow = time.Hour
+	// DefaultQueryCacheRecentTTL is how long results of recent ranges are cached when not configured.
+	DefaultQueryCacheRecentTTL = 30 * time.Second
+	// DefaultQueryCacheHistoricalTTL is how long results of past ranges are cached when not configured.
+	DefaultQueryCacheHistoricalTTL = 10 * time.Minute
+	// DefaultQueryCacheMaxPersistedEntries is the number of results stored in SQLite when not configured.
+	DefaultQueryCacheMaxPersistedEntries = 10000
+
+	queryCacheKindLogs      = "logs"
+	queryCacheKindHistogram = "histogram"
+
+	// queryCacheDiskTimeout bounds a single read or write of the on-disk tier.
+	queryCacheDiskTimeout = 2 * time.Second
+)
+
+var (
+	// dateTimeLiteralPattern matches toDateTime('2024-01-02 15:04:05', 'Zone') and
+	// toDateTime64('2024-01-02 15:04:05.000', 3, 'Zone'), the zone being optional.
+	dateTimeLiteralPattern = regexp.MustCompile(`(?i)\btoDateTime(?:64)?\(\s*'([^']+)'\s*(?:,\s*\d+\s*)?(?:,\s*'([^']+)'\s*)?\)`)
+	// unixTimeLiteralPattern matches Unix timestamps converted to times, e.g. fromUnixTimestamp64Milli(1700000000000).
+	unixTimeLiteralPattern = regexp.MustCompile(`(?i)\b(fromUnixTimestamp64Milli|fromUnixTimestamp64Micro|fromUnixTimestamp64Nano|fromUnixTimestamp|toDateTime)\(\s*(\d+)\s*\)`)
+	// dateTimeParamPattern matches bound parameters of DateTime types, e.g. {end:DateTime64(3, 'UTC')}.
+	dateTimeParamPattern = regexp.MustCompile(`\{\s*(\w+)\s*:\s*(?:Nullable\()?DateTime(?:64)?(?:\(([^)]*)\))?\)?\s*\}`)
+	// timeZoneArgPattern matches the time zone argument of a DateTime type.
+	timeZoneArgPattern = regexp.MustCompile(`'([^']+)'`)
+	// relativeTimePattern matches functions making a query's time range relative to when it runs.
+	relativeTimePattern = regexp.MustCompile(`(?i)\b(now|now64|today|yesterday)\s*\(`)
+)
+
+// dateTimeLayouts are the layouts of time literals in queries.
+var dateTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+// QueryCache caches the results of log and histogram queries in memory, evicting the least
+// recently used results beyond its bounds, and optionally in SQLite. Results are kept for a TTL
+// that depends on how recent the queried time range is. A nil *QueryCache is valid and caches nothing.
+type QueryCache struct {
+	db  *sqlite.DB
+	log *slog.Logger
+	cfg config.QueryCacheConfig
+
+	mu      sync.Mutex
+	lru     *list.List // *queryCacheEntry, most recently used first
+	entries map[string]*list.Element
+	bytes   int64
+}
+
+// queryCacheEntry is a result cached in memory.
+type queryCacheEntry struct {
+	key       string
+	sourceID  models.SourceID
+	value     any // *models.QueryResult or *HistogramResponse
+	size      int64
+	expiresAt time.Time
+}
+
+// NewQueryCache creates a query result cache. Returns nil when caching is disabled.
+func NewQueryCache(db *sqlite.DB, log *slog.Logger, cfg config.QueryCacheConfig) *QueryCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultQueryCacheMaxEntries
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultQueryCacheMaxBytes
+	}
+	if cfg.MaxEntryBytes <= 0 {
+		cfg.MaxEntryBytes = DefaultQueryCacheMaxEntryBytes
+	}
+	if cfg.RecentWindow <= 0 {
+		cfg.RecentWindow = DefaultQueryCacheRecentWindow
+	}
+	if cfg.RecentTTL <= 0 {
+		cfg.RecentTTL = DefaultQueryCacheRecentTTL
+	}
+	if cfg.HistoricalTTL <= 0 {
+		cfg.HistoricalTTL = DefaultQueryCacheHistoricalTTL
+	}
+	if cfg.MaxPersistedEntries <= 0 {
+		cfg.MaxPersistedEntries = DefaultQueryCacheMaxPersistedEntries
+	}
+
+	return &QueryCache{
+		db:      db,
+		log:     log.With("component", "query_cache"),
+		cfg:     cfg,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// queryCacheKey identifies a query by its kind, source, normalized final SQL (which includes the
+// team's row-level filter and column policies), bound parameters and kind-specific options.
+func queryCacheKey(kind string, sourceID models.SourceID, finalSQL string, parameters map[string]string, options ...string) string {
+	h := sha256.New()
+	for _, part := range []string{kind, strconv.Itoa(int(sourceID)), normalizeQuerySQL(finalSQL)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	for _, name := range slices.Sorted(maps.Keys(parameters)) {
+		h.Write([]byte(name + "=" + parameters[name]))
+		h.Write([]byte{0})
+	}
+	for _, option := range options {
+		h.Write([]byte(option))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeQuerySQL trims a query and collapses runs of whitespace outside quoted strings and
+// identifiers, so formatting differences don't defeat the cache.
+func normalizeQuerySQL(sql string) string {
+	var b strings.Builder
+	var quote byte
+	space := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(sql) {
+				i++
+				b.WriteByte(sql[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// queryRangeEnd returns the latest time a query's conditions refer to, from time literals and
+// DateTime parameters. It returns false when the query has no time literal or uses relative
+// times like now(), so the range may include the latest data.
+func queryRangeEnd(sql string, parameters map[string]string) (time.Time, bool) {
+	if relativeTimePattern.MatchString(sql) {
+		return time.Time{}, false
+	}
+
+	var end time.Time
+	found := false
+	observe := func(t time.Time) {
+		if !found || t.After(end) {
+			end, found = t, true
+		}
+	}
+
+	for _, m := range dateTimeLiteralPattern.FindAllStringSubmatch(sql, -1) {
+		if t, ok := parseQueryTime(m[1], m[2]); ok {
+			observe(t)
+		}
+	}
+	for _, m := range unixTimeLiteralPattern.FindAllStringSubmatch(sql, -1) {
+		n, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(m[1]) {
+		case "fromunixtimestamp64milli":
+			observe(time.UnixMilli(n))
+		case "fromunixtimestamp64micro":
+			observe(time.UnixMicro(n))
+		case "fromunixtimestamp64nano":
+			observe(time.Unix(0, n))
+		default:
+			observe(time.Unix(n, 0))
+		}
+	}
+	for _, m := range dateTimeParamPattern.FindAllStringSubmatch(sql, -1) {
+		if value, ok := parameters[m[1]]; ok {
+			zone := ""
+			if z := timeZoneArgPattern.FindStringSubmatch(m[2]); z != nil {
+				zone = z[1]
+			}
+			if t, ok := parseQueryTime(value, zone); ok {
+				observe(t)
+			}
+		}
+	}
+	return end, found
+}
+
+// parseQueryTime parses a time literal of a query in a time zone, defaulting to UTC.
+func parseQueryTime(value, zone string) (time.Time, bool) {
+	loc := time.UTC
+	if zone != "" {
+		if l, err := time.LoadLocation(zone); err == nil {
+			loc = l
+		}
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ttl returns how long the result of a query is cached: longer when its time range ended
+// before the recent window, since such data rarely changes.
+func (c *QueryCache) ttl(sql string, parameters map[string]string, now time.Time) time.Duration {
+	if end, ok := queryRangeEnd(sql, parameters); ok && end.Before(now.Add(-c.cfg.RecentWindow)) {
+		return c.cfg.HistoricalTTL
+	}
+	return c.cfg.RecentTTL
+}
+
+// lookupQueryCache returns a copy of a cached result, looking in memory first and then in SQLite,
+// and records the lookup. A bypassed lookup always misses, so the caller refreshes the result.
+func lookupQueryCache[T any](ctx context.Context, c *QueryCache, source *models.Source, kind, key string, bypass bool) (*T, bool) {
+	if c == nil {
+		return nil, false
+	}
+	if bypass {
+		metrics.RecordQueryCache(source, kind, "bypass", "")
+		return nil, false
+	}
+
+	if value, ok := c.getMemory(key); ok {
+		if result, ok := cloneCachedResult(value).(*T); ok {
+			metrics.RecordQueryCache(source, kind, "hit", "memory")
+			return result, true
+		}
+	}
+
+	if c.cfg.Persist {
+		diskCtx, cancel := context.WithTimeout(ctx, queryCacheDiskTimeout)
+		defer cancel()
+		entry, err := c.db.GetQueryCacheEntry(diskCtx, key, time.Now())
+		if err == nil {
+			result := new(T)
+			decoder := json.NewDecoder(bytes.NewReader(entry.Value))
+			decoder.UseNumber() // Keep 64-bit integers exact
+			if err := decoder.Decode(result); err == nil {
+				c.setMemory(key, source.ID, result, int64(len(entry.Value)), entry.ExpiresAt)
+				metrics.RecordQueryCache(source, kind, "hit", "disk")
+				return cloneCachedResult(result).(*T), true
+			}
+			c.log.Warn("failed to decode persisted query result", "source_id", source.ID, "error", err)
+		} else if !errors.Is(err, sqlite.ErrNotFound) {
+			c.log.Warn("failed to read persisted query result", "source_id", source.ID, "error", err)
+		}
+	}
+
+	metrics.RecordQueryCache(source, kind, "miss", "")
+	return nil, false
+}
+
+// store caches a copy of the result of a query, for a TTL depending on the query's time range.
+// Results larger than the entry size limit aren't cached. The result is persisted in the
+// background, so storing never delays the query.
+func (c *QueryCache) store(ctx context.Context, source *models.Source, kind, key string, value any, sql string, parameters map[string]string) {
+	if c == nil {
+		return
+	}
+	value = cloneCachedResult(value)
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		c.log.Warn("failed to encode query result for caching", "source_id", source.ID, "error", err)
+		return
+	}
+	if int64(len(encoded)) > c.cfg.MaxEntryBytes {
+		return
+	}
+
+	now := time.Now()
+	ttl := c.ttl(sql, parameters, now)
+	c.setMemory(key, source.ID, value, int64(len(encoded)), now.Add(ttl))
+
+	if c.cfg.Persist {
+		entry := &models.QueryCacheEntry{Key: key, SourceID: source.ID, Kind: kind, Value: encoded, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+		go func() {
+			diskCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), queryCacheDiskTimeout)
+			defer cancel()
+			if err := c.db.UpsertQueryCacheEntry(diskCtx, entry); err != nil {
+				c.log.Warn("failed to persist query result", "source_id", source.ID, "error", err)
+			}
+		}()
+	}
+}
+
+// getMemory returns an unexpired result cached in memory and marks it recently used.
+func (c *QueryCache) getMemory(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*queryCacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.removeLocked(elem)
+		c.updateSizeLocked()
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value, true
+}
+
+// setMemory caches a result in memory, evicting the least recently used results beyond the bounds.
+func (c *QueryCache) setMemory(key string, sourceID models.SourceID, value any, size int64, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&queryCacheEntry{key: key, sourceID: sourceID, value: value, size: size, expiresAt: expiresAt})
+	c.bytes += size
+
+	evicted := 0
+	for c.lru.Len() > c.cfg.MaxEntries || c.bytes > c.cfg.MaxBytes {
+		c.removeLocked(c.lru.Back())
+		evicted++
+	}
+	if evicted > 0 {
+		metrics.RecordQueryCacheEvictions(evicted)
+	}
+	c.updateSizeLocked()
+}
+
+// removeLocked drops a result from memory. The caller must hold c.mu.
+func (c *QueryCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*queryCacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// updateSizeLocked reports the size of the cache. The caller must hold c.mu.
+func (c *QueryCache) updateSizeLocked() {
+	metrics.UpdateQueryCacheSize(c.lru.Len(), c.bytes)
+}
+
+// Invalidate drops all cached results of a source, e.g. after its table changed.
+func (c *QueryCache) Invalidate(ctx context.Context, sourceID models.SourceID) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*queryCacheEntry).sourceID == sourceID {
+			c.removeLocked(elem)
+		}
+		elem = next
+	}
+	c.updateSizeLocked()
+	c.mu.Unlock()
+
+	if c.cfg.Persist {
+		if err := c.db.DeleteSourceQueryCacheEntries(ctx, sourceID); err != nil {
+			c.log.Warn("failed to drop persisted query results", "source_id", sourceID, "error", err)
+		}
+	}
+}
+
+// Prune drops expired results from memory and, when results are persisted, expired and
+// excess results from SQLite. Returns the number of results dropped.
+func (c *QueryCache) Prune(ctx context.Context) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	now := time.Now()
+	pruned := 0
+	c.mu.Lock()
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if !now.Before(elem.Value.(*queryCacheEntry).expiresAt) {
+			c.removeLocked(elem)
+			pruned++
+		}
+		elem = next
+	}
+	c.updateSizeLocked()
+	c.mu.Unlock()
+
+	if c.cfg.Persist {
+		deleted, err := c.db.PruneQueryCache(ctx, now, c.cfg.MaxPersistedEntries)
+		pruned += int(deleted)
+		if err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+// cloneCachedResult copies a cached result, so callers changing the result they get (e.g.
+// federated search adding fields to rows) don't change the cached copy.
+func cloneCachedResult(value any) any {
+	switch v := value.(type) {
+	case *models.QueryResult:
+		return cloneQueryResult(v)
+	case *HistogramResponse:
+		return cloneHistogramResponse(v)
+	}
+	return value
+}
+
+// cloneQueryResult copies a query result down to its rows.
+func cloneQueryResult(result *models.QueryResult) *models.QueryResult {
+	clone := *result
+	clone.Logs = make([]map[string]interface{}, len(result.Logs))
+	for i, row := range result.Logs {
+		clone.Logs[i] = maps.Clone(row)
+	}
+	clone.Columns = slices.Clone(result.Columns)
+	return &clone
+}
+
+// cloneHistogramResponse copies a histogram response down to its buckets.
+func cloneHistogramResponse(response *HistogramResponse) *HistogramResponse {
+	clone := *response
+	clone.Data = slices.Clone(response.Data)
+	return &clone
+}
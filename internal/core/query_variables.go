@@ -0,0 +1,474 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Saved Query Variables ---
+
+const (
+	// TimeRangeVariable is the built-in time range variable of every saved query.
+	// It defaults to the query's saved time range.
+	TimeRangeVariable = "time_range"
+
+	// MaxVariableOptions bounds the options returned for an enum variable.
+	MaxVariableOptions = 1000
+	// defaultOptionsLookback is the range sampled for enum options when the query has no time range.
+	defaultOptionsLookback = 24 * time.Hour
+
+	// variableParamPrefix namespaces variable parameters so they can't collide with
+	// parameters written directly in the query.
+	variableParamPrefix = "var_"
+	// timeRangeParamType is the ClickHouse type of time range bound parameters.
+	timeRangeParamType = "DateTime64(3, 'UTC')"
+	// timeRangeParamLayout formats time range bounds for timeRangeParamType.
+	timeRangeParamLayout = "2006-01-02 15:04:05.000"
+)
+
+var (
+	// variableNamePattern matches valid variable names.
+	variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// variableRefPattern matches {{name}}, {{name.start}} and {{name.end}}, optionally
+	// wrapped in single quotes, which are dropped since the value is bound as a parameter.
+	variableRefPattern = regexp.MustCompile(`'\{\{\s*([A-Za-z_][A-Za-z0-9_]*)(?:\.(start|end))?\s*\}\}'|\{\{\s*([A-Za-z_][A-Za-z0-9_]*)(?:\.(start|end))?\s*\}\}`)
+	// relativeRangePattern matches relative time ranges like "15m", "last 24h" or "now-7d".
+	relativeRangePattern = regexp.MustCompile(`^(?:last\s+|now\s*-\s*)?(\d+)\s*(s|m|h|d|w)$`)
+)
+
+// variableRef is a single {{...}} reference in a query.
+type variableRef struct {
+	start, end int    // Byte offsets of the reference, including any quotes.
+	name       string // Variable name.
+	bound      string // "start" or "end" for time range bounds, empty otherwise.
+}
+
+// findVariableRefs returns the variable references in a query in order of appearance.
+func findVariableRefs(query string) []variableRef {
+	var refs []variableRef
+	for _, m := range variableRefPattern.FindAllStringSubmatchIndex(query, -1) {
+		ref := variableRef{start: m[0], end: m[1]}
+		if m[2] >= 0 {
+			ref.name = query[m[2]:m[3]]
+			if m[4] >= 0 {
+				ref.bound = query[m[4]:m[5]]
+			}
+		} else {
+			ref.name = query[m[6]:m[7]]
+			if m[8] >= 0 {
+				ref.bound = query[m[8]:m[9]]
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// validateSavedQueryVariables checks variable declarations and that every reference
+// in the query is to a declared variable.
+func validateSavedQueryVariables(content *models.SavedQueryContent) error {
+	declared, err := validateVariableDeclarations(content.Variables)
+	if err != nil {
+		return err
+	}
+	return validateVariableRefs(content.Content, declared)
+}
+
+// validateVariableDeclarations checks variable names, types, options and defaults, and
+// returns the type of each declared variable including the built-in time range.
+func validateVariableDeclarations(variables []models.SavedQueryVariable) (map[string]models.SavedQueryVariableType, error) {
+	declared := map[string]models.SavedQueryVariableType{TimeRangeVariable: models.SavedQueryVariableTimeRange}
+	for _, v := range variables {
+		if !variableNamePattern.MatchString(v.Name) {
+			return nil, fmt.Errorf("%w: invalid variable name %q", ErrInvalidQueryContent, v.Name)
+		}
+		if _, exists := declared[v.Name]; exists {
+			if v.Name == TimeRangeVariable {
+				return nil, fmt.Errorf("%w: variable name %q is reserved", ErrInvalidQueryContent, v.Name)
+			}
+			return nil, fmt.Errorf("%w: duplicate variable %q", ErrInvalidQueryContent, v.Name)
+		}
+		declared[v.Name] = v.Type
+
+		switch v.Type {
+		case models.SavedQueryVariableString, models.SavedQueryVariableNumber, models.SavedQueryVariableTimeRange:
+			if len(v.Options) > 0 || v.OptionsColumn != "" {
+				return nil, fmt.Errorf("%w: only enum variables can have options", ErrInvalidQueryContent)
+			}
+		case models.SavedQueryVariableEnum:
+			if len(v.Options) == 0 && v.OptionsColumn == "" {
+				return nil, fmt.Errorf("%w: enum variable %q needs options or an options column", ErrInvalidQueryContent, v.Name)
+			}
+			if len(v.Options) > 0 && v.OptionsColumn != "" {
+				return nil, fmt.Errorf("%w: enum variable %q can't have both options and an options column", ErrInvalidQueryContent, v.Name)
+			}
+		default:
+			return nil, fmt.Errorf("%w: variable %q has unknown type %q, must be one of: string, enum, number, time_range", ErrInvalidQueryContent, v.Name, v.Type)
+		}
+
+		if v.Default != "" {
+			if err := validateVariableValue(v, v.Default); err != nil {
+				return nil, fmt.Errorf("%w: invalid default for variable %q: %v", ErrInvalidQueryContent, v.Name, err)
+			}
+		}
+	}
+	return declared, nil
+}
+
+// validateVariableRefs checks that every variable referenced in a query is declared.
+func validateVariableRefs(query string, declared map[string]models.SavedQueryVariableType) error {
+	for _, ref := range findVariableRefs(query) {
+		varType, ok := declared[ref.name]
+		if !ok {
+			return fmt.Errorf("%w: query references undeclared variable %q", ErrInvalidQueryContent, ref.name)
+		}
+		if ref.bound != "" && varType != models.SavedQueryVariableTimeRange {
+			return fmt.Errorf("%w: .%s can only be used on time range variables", ErrInvalidQueryContent, ref.bound)
+		}
+	}
+	return nil
+}
+
+// validateSavedQueryTimeRange checks that a saved time range is either a valid relative
+// range or an ordered absolute range.
+func validateSavedQueryTimeRange(tr models.SavedQueryTimeRange) error {
+	if tr.IsZero() {
+		return nil
+	}
+	if tr.Relative != "" {
+		if _, err := parseRelativeRange(tr.Relative); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidQueryContent, err)
+		}
+		return nil
+	}
+	if tr.Absolute.Start <= 0 {
+		return fmt.Errorf("%w: absolute start time must be positive", ErrInvalidQueryContent)
+	}
+	if tr.Absolute.End <= 0 {
+		return fmt.Errorf("%w: absolute end time must be positive", ErrInvalidQueryContent)
+	}
+	if tr.Absolute.End < tr.Absolute.Start {
+		return fmt.Errorf("%w: absolute end time must be after start time", ErrInvalidQueryContent)
+	}
+	return nil
+}
+
+// parseRelativeRange parses a relative range like "15m", "last 24h" or "now-7d" into its duration.
+func parseRelativeRange(value string) (time.Duration, error) {
+	m := relativeRangePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid relative time range %q, expected e.g. \"15m\" or \"last 24h\"", value)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid relative time range %q", value)
+	}
+	unit := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}[m[2]]
+	return time.Duration(n) * unit, nil
+}
+
+// resolveTimeRange returns the bounds of a time range at the given time.
+func resolveTimeRange(tr models.SavedQueryTimeRange, now time.Time) (time.Time, time.Time, error) {
+	if tr.Relative != "" {
+		d, err := parseRelativeRange(tr.Relative)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return now.Add(-d), now, nil
+	}
+	if tr.IsZero() {
+		return time.Time{}, time.Time{}, fmt.Errorf("no time range set")
+	}
+	return time.UnixMilli(tr.Absolute.Start), time.UnixMilli(tr.Absolute.End), nil
+}
+
+// parseTimeRangeValue parses a time range variable value: either a relative range or
+// an absolute "start/end" range with RFC3339 or Unix millisecond bounds.
+func parseTimeRangeValue(value string) (models.SavedQueryTimeRange, error) {
+	start, end, found := strings.Cut(value, "/")
+	if !found {
+		if _, err := parseRelativeRange(value); err != nil {
+			return models.SavedQueryTimeRange{}, err
+		}
+		return models.SavedQueryTimeRange{Relative: value}, nil
+	}
+
+	parseBound := func(s string) (int64, error) {
+		s = strings.TrimSpace(s)
+		if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return ms, nil
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q, expected RFC3339 or Unix milliseconds", s)
+		}
+		return t.UnixMilli(), nil
+	}
+	startMs, err := parseBound(start)
+	if err != nil {
+		return models.SavedQueryTimeRange{}, err
+	}
+	endMs, err := parseBound(end)
+	if err != nil {
+		return models.SavedQueryTimeRange{}, err
+	}
+	tr := models.SavedQueryTimeRange{Absolute: &models.SavedQueryAbsoluteRange{Start: startMs, End: endMs}}
+	if err := validateSavedQueryTimeRange(tr); err != nil {
+		return models.SavedQueryTimeRange{}, err
+	}
+	return tr, nil
+}
+
+// validateVariableValue checks that a value is valid for a variable's type.
+func validateVariableValue(v models.SavedQueryVariable, value string) error {
+	switch v.Type {
+	case models.SavedQueryVariableString:
+		return nil
+	case models.SavedQueryVariableEnum:
+		if len(v.Options) == 0 {
+			return nil
+		}
+		for _, option := range v.Options {
+			if option == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %q is not one of the allowed options", value)
+	case models.SavedQueryVariableNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("value %q is not a number", value)
+		}
+		return nil
+	case models.SavedQueryVariableTimeRange:
+		_, err := parseTimeRangeValue(value)
+		return err
+	default:
+		return fmt.Errorf("unknown variable type %q", v.Type)
+	}
+}
+
+// RenderSavedQuery substitutes the variables of a saved SQL query with ClickHouse query
+// parameters. Values are never interpolated into the SQL; each {{name}} becomes a typed
+// {var_name:Type} placeholder and the returned parameters hold the values to bind.
+// tsField is the source's timestamp column, used to expand bare time range references.
+func RenderSavedQuery(content *models.SavedQueryContent, values map[string]string, tsField string, now time.Time) (string, map[string]string, error) {
+	vars := make(map[string]models.SavedQueryVariable, len(content.Variables)+1)
+	vars[TimeRangeVariable] = models.SavedQueryVariable{Name: TimeRangeVariable, Type: models.SavedQueryVariableTimeRange}
+	for _, v := range content.Variables {
+		vars[v.Name] = v
+	}
+	for name := range values {
+		if _, ok := vars[name]; !ok {
+			return "", nil, &ValidationError{Field: "variables", Message: fmt.Sprintf("unknown variable %q", name)}
+		}
+	}
+
+	// Resolve the value of every declared variable, so required variables are
+	// enforced even when the query doesn't reference them.
+	resolved := make(map[string]string, len(vars))
+	for name, v := range vars {
+		value, provided := values[name]
+		if !provided || value == "" {
+			value = v.Default
+		}
+		if value == "" {
+			if v.Required {
+				return "", nil, &ValidationError{Field: "variables", Message: fmt.Sprintf("variable %q is required", name)}
+			}
+			continue
+		}
+		if err := validateVariableValue(v, value); err != nil {
+			return "", nil, &ValidationError{Field: "variables", Message: fmt.Sprintf("variable %q: %v", name, err)}
+		}
+		resolved[name] = value
+	}
+
+	var (
+		sql    strings.Builder
+		params = make(map[string]string)
+		last   int
+	)
+	for _, ref := range findVariableRefs(content.Content) {
+		v, ok := vars[ref.name]
+		if !ok {
+			return "", nil, &ValidationError{Field: "query", Message: fmt.Sprintf("query references undeclared variable %q", ref.name)}
+		}
+		sql.WriteString(content.Content[last:ref.start])
+		last = ref.end
+
+		if v.Type != models.SavedQueryVariableTimeRange {
+			if ref.bound != "" {
+				return "", nil, &ValidationError{Field: "query", Message: fmt.Sprintf(".%s can only be used on time range variables", ref.bound)}
+			}
+			// Optional variables without a value are bound as empty strings.
+			value, ok := resolved[v.Name]
+			paramType := "String"
+			if v.Type == models.SavedQueryVariableNumber {
+				if !ok {
+					return "", nil, &ValidationError{Field: "variables", Message: fmt.Sprintf("variable %q has no value", v.Name)}
+				}
+				paramType = "Float64"
+			}
+			params[variableParamPrefix+v.Name] = value
+			fmt.Fprintf(&sql, "{%s%s:%s}", variableParamPrefix, v.Name, paramType)
+			continue
+		}
+
+		// Time range variables bind their start and end as separate parameters.
+		tr := content.TimeRange
+		if value, ok := resolved[v.Name]; ok {
+			tr, _ = parseTimeRangeValue(value)
+		}
+		start, end, err := resolveTimeRange(tr, now)
+		if err != nil {
+			return "", nil, &ValidationError{Field: "variables", Message: fmt.Sprintf("variable %q: %v", v.Name, err)}
+		}
+		startParam := variableParamPrefix + v.Name + "_start"
+		endParam := variableParamPrefix + v.Name + "_end"
+		params[startParam] = start.UTC().Format(timeRangeParamLayout)
+		params[endParam] = end.UTC().Format(timeRangeParamLayout)
+
+		switch ref.bound {
+		case "start":
+			fmt.Fprintf(&sql, "{%s:%s}", startParam, timeRangeParamType)
+		case "end":
+			fmt.Fprintf(&sql, "{%s:%s}", endParam, timeRangeParamType)
+		default:
+			if tsField == "" {
+				return "", nil, &ValidationError{Field: "query", Message: "source has no timestamp field for time range conditions"}
+			}
+			fmt.Fprintf(&sql, "`%s` BETWEEN {%s:%s} AND {%s:%s}",
+				strings.ReplaceAll(tsField, "`", "``"), startParam, timeRangeParamType, endParam, timeRangeParamType)
+		}
+	}
+	sql.WriteString(content.Content[last:])
+	return sql.String(), params, nil
+}
+
+// RunSavedQuery renders a saved SQL query with the given variables and runs it for the team.
+func RunSavedQuery(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int, req models.APIRunSavedQueryRequest) (*models.QueryResult, error) {
+	savedQuery, content, err := getSavedQueryContent(ctx, db, log, teamID, sourceID, queryID)
+	if err != nil {
+		return nil, err
+	}
+	if savedQuery.QueryType != models.SavedQueryTypeSQL {
+		return nil, &ValidationError{Field: "query_type", Message: "only SQL saved queries can be run"}
+	}
+
+	source, err := db.GetSource(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting source details: %w", err)
+	}
+	if source == nil {
+		return nil, ErrSourceNotFound
+	}
+
+	rawSQL, params, err := RenderSavedQuery(content, req.Variables, source.MetaTSField, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Track usage for sorting collections by last use; a failure here shouldn't fail the run.
+	if err := db.MarkTeamQueryUsed(ctx, queryID); err != nil {
+		log.Warn("failed to mark saved query as used", "error", err, "query_id", queryID)
+	}
+
+	limit := content.Limit
+	if req.Limit > 0 {
+		limit = req.Limit
+	}
+	return QueryLogs(ctx, db, chDB, cache, log, teamID, sourceID, clickhouse.LogQueryParams{
+		RawSQL:       rawSQL,
+		Limit:        limit,
+		QueryTimeout: req.QueryTimeout,
+		Parameters:   params,
+	})
+}
+
+// GetSavedQueryVariableOptions returns the allowed values of an enum variable: either its
+// static options or the most frequent values of its options column within the query's
+// time range, as visible to the team.
+func GetSavedQueryVariableOptions(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int, name string) ([]string, error) {
+	_, content, err := getSavedQueryContent(ctx, db, log, teamID, sourceID, queryID)
+	if err != nil {
+		return nil, err
+	}
+
+	var variable *models.SavedQueryVariable
+	for i := range content.Variables {
+		if content.Variables[i].Name == name {
+			variable = &content.Variables[i]
+			break
+		}
+	}
+	if variable == nil {
+		return nil, &ValidationError{Field: "name", Message: fmt.Sprintf("unknown variable %q", name)}
+	}
+	if variable.Type != models.SavedQueryVariableEnum {
+		return nil, &ValidationError{Field: "name", Message: fmt.Sprintf("variable %q is not an enum", name)}
+	}
+	if len(variable.Options) > 0 {
+		return variable.Options, nil
+	}
+
+	source, err := db.GetSource(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting source details: %w", err)
+	}
+	if source == nil {
+		return nil, ErrSourceNotFound
+	}
+
+	now := time.Now()
+	start, end := now.Add(-defaultOptionsLookback), now
+	if !content.TimeRange.IsZero() {
+		if start, end, err = resolveTimeRange(content.TimeRange, now); err != nil {
+			return nil, &ValidationError{Field: "timeRange", Message: err.Error()}
+		}
+	}
+
+	// Run through QueryLogs so the team's row filter and column policies apply.
+	query := clickhouse.DistinctValuesQuery(source.GetFullTableName(), variable.OptionsColumn, source.MetaTSField, start, end, MaxVariableOptions)
+	result, err := QueryLogs(ctx, db, chDB, cache, log, teamID, sourceID, clickhouse.LogQueryParams{
+		RawSQL: query,
+		Limit:  MaxVariableOptions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]string, 0, len(result.Logs))
+	for _, row := range result.Logs {
+		options = append(options, fmt.Sprint(row["value"]))
+	}
+	return options, nil
+}
+
+// getSavedQueryContent loads a team's saved query and decodes its content.
+func getSavedQueryContent(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int) (*models.SavedTeamQuery, *models.SavedQueryContent, error) {
+	savedQuery, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var content models.SavedQueryContent
+	if err := json.Unmarshal([]byte(savedQuery.QueryContent), &content); err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to parse JSON: %v", ErrInvalidQueryContent, err)
+	}
+	return savedQuery, &content, nil
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+func TestRenderSavedQuery(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	const rangeType = "DateTime64(3, 'UTC')"
+	service := models.SavedQueryVariable{Name: "service", Type: models.SavedQueryVariableString}
+	minStatus := models.SavedQueryVariable{Name: "min_status", Type: models.SavedQueryVariableNumber}
+	level := models.SavedQueryVariable{Name: "level", Type: models.SavedQueryVariableEnum, Options: []string{"info", "error"}}
+	window := models.SavedQueryVariable{Name: "window", Type: models.SavedQueryVariableTimeRange}
+	last15m := models.SavedQueryTimeRange{Relative: "15m"}
+
+	tests := []struct {
+		name       string
+		query      string
+		variables  []models.SavedQueryVariable
+		timeRange  models.SavedQueryTimeRange
+		values     map[string]string
+		tsField    string
+		wantSQL    string
+		wantParams map[string]string
+		wantErr    string
+	}{
+		{
+			name:       "string",
+			query:      "SELECT * FROM logs WHERE service = {{service}}",
+			variables:  []models.SavedQueryVariable{service},
+			values:     map[string]string{"service": "api"},
+			wantSQL:    "SELECT * FROM logs WHERE service = {var_service:String}",
+			wantParams: map[string]string{"var_service": "api"},
+		},
+		{
+			name:       "quotes around the reference are dropped",
+			query:      "SELECT * FROM logs WHERE service = '{{ service }}'",
+			variables:  []models.SavedQueryVariable{service},
+			values:     map[string]string{"service": "api"},
+			wantSQL:    "SELECT * FROM logs WHERE service = {var_service:String}",
+			wantParams: map[string]string{"var_service": "api"},
+		},
+		{
+			name:       "values are bound, never interpolated",
+			query:      "SELECT * FROM logs WHERE service = {{service}}",
+			variables:  []models.SavedQueryVariable{service},
+			values:     map[string]string{"service": "x' OR 1=1 --"},
+			wantSQL:    "SELECT * FROM logs WHERE service = {var_service:String}",
+			wantParams: map[string]string{"var_service": "x' OR 1=1 --"},
+		},
+		{
+			name:       "repeated reference binds one parameter",
+			query:      "SELECT {{service}} AS s FROM logs WHERE service = {{service}}",
+			variables:  []models.SavedQueryVariable{service},
+			values:     map[string]string{"service": "api"},
+			wantSQL:    "SELECT {var_service:String} AS s FROM logs WHERE service = {var_service:String}",
+			wantParams: map[string]string{"var_service": "api"},
+		},
+		{
+			name:       "number",
+			query:      "SELECT * FROM logs WHERE status >= {{min_status}}",
+			variables:  []models.SavedQueryVariable{minStatus},
+			values:     map[string]string{"min_status": "500"},
+			wantSQL:    "SELECT * FROM logs WHERE status >= {var_min_status:Float64}",
+			wantParams: map[string]string{"var_min_status": "500"},
+		},
+		{
+			name:      "number rejects text",
+			query:     "SELECT * FROM logs WHERE status >= {{min_status}}",
+			variables: []models.SavedQueryVariable{minStatus},
+			values:    map[string]string{"min_status": "1 OR 1=1"},
+			wantErr:   `variable "min_status": value "1 OR 1=1" is not a number`,
+		},
+		{
+			name:       "enum option",
+			query:      "SELECT * FROM logs WHERE level = {{level}}",
+			variables:  []models.SavedQueryVariable{level},
+			values:     map[string]string{"level": "error"},
+			wantSQL:    "SELECT * FROM logs WHERE level = {var_level:String}",
+			wantParams: map[string]string{"var_level": "error"},
+		},
+		{
+			name:      "enum rejects other values",
+			query:     "SELECT * FROM logs WHERE level = {{level}}",
+			variables: []models.SavedQueryVariable{level},
+			values:    map[string]string{"level": "debug"},
+			wantErr:   `value "debug" is not one of the allowed options`,
+		},
+		{
+			name:       "default used when missing",
+			query:      "SELECT * FROM logs WHERE level = {{level}}",
+			variables:  []models.SavedQueryVariable{{Name: "level", Type: models.SavedQueryVariableEnum, Options: []string{"info", "error"}, Default: "info"}},
+			wantSQL:    "SELECT * FROM logs WHERE level = {var_level:String}",
+			wantParams: map[string]string{"var_level": "info"},
+		},
+		{
+			name:       "default used when empty",
+			query:      "SELECT * FROM logs WHERE service = {{service}}",
+			variables:  []models.SavedQueryVariable{{Name: "service", Type: models.SavedQueryVariableString, Default: "web"}},
+			values:     map[string]string{"service": ""},
+			wantSQL:    "SELECT * FROM logs WHERE service = {var_service:String}",
+			wantParams: map[string]string{"var_service": "web"},
+		},
+		{
+			name:      "required variable missing",
+			query:     "SELECT * FROM logs WHERE service = {{service}}",
+			variables: []models.SavedQueryVariable{{Name: "service", Type: models.SavedQueryVariableString, Required: true}},
+			wantErr:   `variable "service" is required`,
+		},
+		{
+			name:      "required variable enforced when unreferenced",
+			query:     "SELECT * FROM logs",
+			variables: []models.SavedQueryVariable{{Name: "service", Type: models.SavedQueryVariableString, Required: true}},
+			wantErr:   `variable "service" is required`,
+		},
+		{
+			name:       "optional string missing is bound empty",
+			query:      "SELECT * FROM logs WHERE service = {{service}}",
+			variables:  []models.SavedQueryVariable{service},
+			wantSQL:    "SELECT * FROM logs WHERE service = {var_service:String}",
+			wantParams: map[string]string{"var_service": ""},
+		},
+		{
+			name:      "optional number missing",
+			query:     "SELECT * FROM logs WHERE status >= {{min_status}}",
+			variables: []models.SavedQueryVariable{minStatus},
+			wantErr:   `variable "min_status" has no value`,
+		},
+		{
+			name:      "extra variable",
+			query:     "SELECT * FROM logs WHERE service = {{service}}",
+			variables: []models.SavedQueryVariable{service},
+			values:    map[string]string{"service": "api", "host": "web1"},
+			wantErr:   `unknown variable "host"`,
+		},
+		{
+			name:    "undeclared reference",
+			query:   "SELECT * FROM logs WHERE service = {{service}}",
+			wantErr: `query references undeclared variable "service"`,
+		},
+		{
+			name:      "bound on a non time range variable",
+			query:     "SELECT * FROM logs WHERE service = {{service.start}}",
+			variables: []models.SavedQueryVariable{service},
+			values:    map[string]string{"service": "api"},
+			wantErr:   ".start can only be used on time range variables",
+		},
+		{
+			name:      "built-in time range uses the saved range",
+			query:     "SELECT * FROM logs WHERE {{time_range}}",
+			timeRange: last15m,
+			tsField:   "timestamp",
+			wantSQL:   "SELECT * FROM logs WHERE `timestamp` BETWEEN {var_time_range_start:" + rangeType + "} AND {var_time_range_end:" + rangeType + "}",
+			wantParams: map[string]string{
+				"var_time_range_start": "2025-03-01 11:45:00.000",
+				"var_time_range_end":   "2025-03-01 12:00:00.000",
+			},
+		},
+		{
+			name:      "time range value overrides the saved range",
+			query:     "SELECT * FROM logs WHERE {{time_range}}",
+			timeRange: last15m,
+			values:    map[string]string{"time_range": "last 2h"},
+			tsField:   "ts`x",
+			wantSQL:   "SELECT * FROM logs WHERE `ts``x` BETWEEN {var_time_range_start:" + rangeType + "} AND {var_time_range_end:" + rangeType + "}",
+			wantParams: map[string]string{
+				"var_time_range_start": "2025-03-01 10:00:00.000",
+				"var_time_range_end":   "2025-03-01 12:00:00.000",
+			},
+		},
+		{
+			name:      "absolute time range bounds",
+			query:     "SELECT * FROM logs WHERE t >= {{window.start}} AND t < '{{window.end}}'",
+			variables: []models.SavedQueryVariable{window},
+			values:    map[string]string{"window": "2025-02-01T00:00:00Z/1738454400000"},
+			wantSQL:   "SELECT * FROM logs WHERE t >= {var_window_start:" + rangeType + "} AND t < {var_window_end:" + rangeType + "}",
+			wantParams: map[string]string{
+				"var_window_start": "2025-02-01 00:00:00.000",
+				"var_window_end":   "2025-02-02 00:00:00.000",
+			},
+		},
+		{
+			name:      "invalid time range",
+			query:     "SELECT * FROM logs WHERE {{window}}",
+			variables: []models.SavedQueryVariable{window},
+			values:    map[string]string{"window": "yesterday"},
+			tsField:   "timestamp",
+			wantErr:   `variable "window": invalid relative time range "yesterday"`,
+		},
+		{
+			name:      "reversed time range",
+			query:     "SELECT * FROM logs WHERE {{window}}",
+			variables: []models.SavedQueryVariable{window},
+			values:    map[string]string{"window": "2000/1000"},
+			tsField:   "timestamp",
+			wantErr:   "absolute end time must be after start time",
+		},
+		{
+			name:    "time range without a range",
+			query:   "SELECT * FROM logs WHERE {{time_range}}",
+			tsField: "timestamp",
+			wantErr: `variable "time_range": no time range set`,
+		},
+		{
+			name:      "bare time range without a timestamp field",
+			query:     "SELECT * FROM logs WHERE {{time_range}}",
+			timeRange: last15m,
+			wantErr:   "source has no timestamp field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := &models.SavedQueryContent{Content: tt.query, Variables: tt.variables, TimeRange: tt.timeRange}
+			sql, params, err := RenderSavedQuery(content, tt.values, tt.tsField, now)
+			if tt.wantErr != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v is not a validation error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql:\n got: %s\nwant: %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
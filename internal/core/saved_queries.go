@@ -46,18 +46,14 @@ func ValidateSavedQueryContent(contentJSON string) error {
 		return fmt.Errorf("%w: limit must be positive", ErrInvalidQueryContent)
 	}
 
-	// Validate time range if present (check against the zero value of the struct)
-	// The TimeRange struct only contains Absolute times.
-	if queryContent.TimeRange != (models.SavedQueryTimeRange{}) {
-		if queryContent.TimeRange.Absolute.Start <= 0 {
-			return fmt.Errorf("%w: absolute start time must be positive", ErrInvalidQueryContent)
-		}
-		if queryContent.TimeRange.Absolute.End <= 0 {
-			return fmt.Errorf("%w: absolute end time must be positive", ErrInvalidQueryContent)
-		}
-		if queryContent.TimeRange.Absolute.End < queryContent.TimeRange.Absolute.Start {
-			return fmt.Errorf("%w: absolute end time must be after start time", ErrInvalidQueryContent)
-		}
+	// Validate time range if present
+	if err := validateSavedQueryTimeRange(queryContent.TimeRange); err != nil {
+		return err
+	}
+
+	// Validate variable declarations and references
+	if err := validateSavedQueryVariables(&queryContent); err != nil {
+		return err
 	}
 
 	// Add more specific content validation based on queryContent.Version if needed
@@ -67,15 +63,21 @@ func ValidateSavedQueryContent(contentJSON string) error {
 
 // --- Saved Query Management Functions ---
 
-// ListQueriesForTeamAndSource retrieves all saved queries associated with a specific team and source.
-func ListQueriesForTeamAndSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID) ([]*models.SavedTeamQuery, error) {
+// ListQueriesForTeamAndSource retrieves all saved queries associated with a specific team and source,
+// with the folder, tags and the user's favorites. Pinned favorites come first, then the given sort order.
+func ListQueriesForTeamAndSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, userID models.UserID, sort models.SavedQuerySort) ([]*models.SavedTeamQuery, error) {
 	log.Debug("listing saved queries for team and source", "team_id", teamID, "source_id", sourceID)
 
-	// Optional: Validate team and source existence first?
-	// _, err := GetTeam(ctx, db, teamID) ...
-	// _, err := GetSource(ctx, db, chDB, log, sourceID) ...
+	if err := validateSavedQuerySort(&sort, models.SavedQuerySortName); err != nil {
+		return nil, err
+	}
 
-	queries, err := db.ListQueriesByTeamAndSource(ctx, teamID, sourceID)
+	queries, err := db.SearchTeamQueries(ctx, models.SavedQuerySearchFilter{
+		TeamID:   teamID,
+		UserID:   userID,
+		SourceID: &sourceID,
+		Sort:     sort,
+	})
 	if err != nil {
 		log.Error("failed to list saved queries from db", "error", err, "team_id", teamID, "source_id", sourceID)
 		return nil, fmt.Errorf("error listing saved queries: %w", err)
@@ -85,8 +87,8 @@ func ListQueriesForTeamAndSource(ctx context.Context, db *sqlite.DB, log *slog.L
 	return queries, nil
 }
 
-// CreateTeamSourceQuery creates a new saved query for a team and source.
-func CreateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, name, description, queryContentJSON, queryType string /*, createdBy models.UserID */) (*models.SavedTeamQuery, error) {
+// CreateTeamSourceQuery creates a new saved query for a team and source, recording it as revision 1.
+func CreateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, name, description, queryContentJSON, queryType string, createdBy *models.UserID) (*models.SavedTeamQuery, error) {
 	log.Debug("creating saved query", "team_id", teamID, "source_id", sourceID, "name", name, "type", queryType)
 
 	// Validate Query Type
@@ -115,7 +117,7 @@ func CreateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger,
 		Description:  description,
 		QueryContent: queryContentJSON, // Store the raw JSON
 		QueryType:    models.SavedQueryType(queryType),
-		// CreatedBy: createdBy, // Pass if DB layer expects it
+		CreatedBy:    createdBy,
 	}
 
 	// Create in database
@@ -135,9 +137,10 @@ func CreateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger,
 		Description:  description,
 		QueryType:    models.SavedQueryType(queryType),
 		QueryContent: queryContentJSON,
+		CreatedBy:    createdBy,
+		UpdatedBy:    createdBy,
 		CreatedAt:    dbQuery.CreatedAt,
 		UpdatedAt:    dbQuery.UpdatedAt,
-		// CreatedByUserID: createdBy, // Map if needed
 	}
 
 	log.Info("saved query created successfully", "query_id", createdQuery.ID, "team_id", teamID, "source_id", sourceID)
@@ -150,7 +153,7 @@ func GetTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger, te
 
 	query, err := db.GetTeamSourceQuery(ctx, teamID, sourceID, queryID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if sqlite.IsNotFoundError(err) {
 			log.Warn("saved query not found", "query_id", queryID, "team_id", teamID, "source_id", sourceID)
 			return nil, ErrQueryNotFound
 		}
@@ -162,8 +165,9 @@ func GetTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger, te
 	return query, nil
 }
 
-// UpdateTeamSourceQuery updates an existing saved query.
-func UpdateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int, name, description, queryContentJSON, queryType string) (*models.SavedTeamQuery, error) {
+// UpdateTeamSourceQuery updates an existing saved query and records the result as a new revision.
+// Empty fields keep their current value.
+func UpdateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int, name, description, queryContentJSON, queryType string, updatedBy *models.UserID) (*models.SavedTeamQuery, error) {
 	log.Debug("updating saved query", "query_id", queryID, "team_id", teamID, "source_id", sourceID)
 
 	// Validate Query Type if provided
@@ -179,11 +183,30 @@ func UpdateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger,
 		}
 	}
 
-	// Call the database update method
-	// The DB layer should handle partial updates based on provided fields
-	err := db.UpdateTeamSourceQuery(ctx, teamID, sourceID, queryID, name, description, queryType, queryContentJSON)
+	// The DB layer replaces all fields, so merge omitted ones with the current state.
+	current, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if current.Provisioned {
+		return nil, ErrProvisioned
+	}
+	if name == "" {
+		name = current.Name
+	}
+	if description == "" {
+		description = current.Description
+	}
+	if queryType == "" {
+		queryType = string(current.QueryType)
+	}
+	if queryContentJSON == "" {
+		queryContentJSON = current.QueryContent
+	}
+
+	err = db.UpdateTeamSourceQuery(ctx, teamID, sourceID, queryID, name, description, queryType, queryContentJSON, updatedBy)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) {
 			log.Warn("saved query not found for update", "query_id", queryID, "team_id", teamID, "source_id", sourceID)
 			return nil, ErrQueryNotFound
 		}
@@ -208,11 +231,15 @@ func UpdateTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger,
 func DeleteTeamSourceQuery(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int) error {
 	log.Info("deleting saved query", "query_id", queryID, "team_id", teamID, "source_id", sourceID)
 
-	// Optional: Check if query exists first?
-	// _, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID)
-	// if err != nil { return err } // Handle ErrQueryNotFound appropriately
+	current, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID)
+	if err != nil {
+		return err
+	}
+	if current.Provisioned {
+		return ErrProvisioned
+	}
 
-	err := db.DeleteTeamSourceQuery(ctx, teamID, sourceID, queryID)
+	err = db.DeleteTeamSourceQuery(ctx, teamID, sourceID, queryID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// Depending on desired behavior, this might not be an error
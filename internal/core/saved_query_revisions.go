@@ -0,0 +1,203 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// ErrRevisionNotFound is returned when a saved query revision doesn't exist.
+var ErrRevisionNotFound = errors.New("saved query revision not found")
+
+// ListSavedQueryRevisions returns the revision history of a saved query, newest first.
+func ListSavedQueryRevisions(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID int) ([]*models.SavedQueryRevision, error) {
+	// Resolve the query first so revisions are only visible within its team and source.
+	if _, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := db.ListTeamQueryRevisions(ctx, queryID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing saved query revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetSavedQueryRevision returns a single revision of a saved query.
+func GetSavedQueryRevision(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID, revision int) (*models.SavedQueryRevision, error) {
+	if _, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID); err != nil {
+		return nil, err
+	}
+
+	rev, err := db.GetTeamQueryRevision(ctx, queryID, revision)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("error getting saved query revision: %w", err)
+	}
+	return rev, nil
+}
+
+// DiffSavedQueryRevisions diffs revision `to` of a saved query against revision `from`.
+// When from is 0 the previous revision is used, or an empty document for revision 1.
+func DiffSavedQueryRevisions(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID, from, to int) (*models.SavedQueryRevisionDiff, error) {
+	toRev, err := GetSavedQueryRevision(ctx, db, log, teamID, sourceID, queryID, to)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	var oldLines []string
+	if from > 0 {
+		fromRev, err := GetSavedQueryRevision(ctx, db, log, teamID, sourceID, queryID, from)
+		if err != nil {
+			return nil, err
+		}
+		oldLines = revisionDiffLines(fromRev)
+	}
+
+	lines := diffLines(oldLines, revisionDiffLines(toRev))
+	return &models.SavedQueryRevisionDiff{
+		QueryID:      queryID,
+		FromRevision: from,
+		ToRevision:   to,
+		Lines:        lines,
+		Unified:      unifiedDiff(lines, fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to)),
+	}, nil
+}
+
+// RestoreSavedQueryRevision makes an old revision the current state of a saved query.
+// The restore is recorded as a new revision, so history is never rewritten.
+func RestoreSavedQueryRevision(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, queryID, revision int, restoredBy *models.UserID) (*models.SavedTeamQuery, error) {
+	rev, err := GetSavedQueryRevision(ctx, db, log, teamID, sourceID, queryID, revision)
+	if err != nil {
+		return nil, err
+	}
+	current, err := GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Provisioned {
+		return nil, ErrProvisioned
+	}
+
+	if err := db.UpdateTeamSourceQuery(ctx, teamID, sourceID, queryID, rev.Name, rev.Description, string(rev.QueryType), rev.QueryContent, restoredBy); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return nil, ErrQueryNotFound
+		}
+		return nil, fmt.Errorf("error restoring saved query revision: %w", err)
+	}
+
+	log.Info("saved query revision restored", "query_id", queryID, "revision", revision)
+	return GetTeamSourceQuery(ctx, db, log, teamID, sourceID, queryID)
+}
+
+// revisionDiffLines renders a revision as lines for diffing: the metadata header, the query
+// text, then the remaining query settings as indented JSON.
+func revisionDiffLines(rev *models.SavedQueryRevision) []string {
+	lines := []string{
+		"name: " + rev.Name,
+		"description: " + rev.Description,
+		"query_type: " + string(rev.QueryType),
+		"",
+	}
+
+	var content models.SavedQueryContent
+	if err := json.Unmarshal([]byte(rev.QueryContent), &content); err != nil {
+		// Diff the raw content if it can't be decoded.
+		return append(lines, strings.Split(rev.QueryContent, "\n")...)
+	}
+	lines = append(lines, strings.Split(content.Content, "\n")...)
+
+	// The query text is diffed above, so leave it out of the settings.
+	settings, err := json.MarshalIndent(struct {
+		models.SavedQueryContent
+		Content string `json:"content,omitempty"`
+	}{SavedQueryContent: content}, "", "  ")
+	if err == nil {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(string(settings), "\n")...)
+	}
+	return lines
+}
+
+// diffLines computes a line diff of two documents using their longest common subsequence.
+func diffLines(a, b []string) []models.DiffLine {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]models.DiffLine, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, models.DiffLine{Op: models.DiffOpEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, models.DiffLine{Op: models.DiffOpRemove, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, models.DiffLine{Op: models.DiffOpAdd, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, models.DiffLine{Op: models.DiffOpRemove, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, models.DiffLine{Op: models.DiffOpAdd, Text: b[j]})
+	}
+	return lines
+}
+
+// unifiedDiff renders diff lines in unified diff format, as a single hunk with full context.
+func unifiedDiff(lines []models.DiffLine, fromLabel, toLabel string) string {
+	var oldCount, newCount int
+	for _, line := range lines {
+		if line.Op != models.DiffOpAdd {
+			oldCount++
+		}
+		if line.Op != models.DiffOpRemove {
+			newCount++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromLabel, toLabel)
+	fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", min(1, oldCount), oldCount, min(1, newCount), newCount)
+	for _, line := range lines {
+		switch line.Op {
+		case models.DiffOpAdd:
+			sb.WriteString("+")
+		case models.DiffOpRemove:
+			sb.WriteString("-")
+		default:
+			sb.WriteString(" ")
+		}
+		sb.WriteString(line.Text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
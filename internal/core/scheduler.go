@@ -0,0 +1,284 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Background Job Scheduler ---
+
+const (
+	// DefaultJobTimeout bounds a single job run when not configured.
+	DefaultJobTimeout = 5 * time.Minute
+)
+
+var (
+	// ErrJobNotFound is returned when triggering a job that isn't registered
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when triggering a job that is already running
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// JobFunc is the work of a scheduled job. ctx is cancelled when the run times out or the
+// scheduler shuts down.
+type JobFunc func(ctx context.Context) error
+
+// Scheduler runs named maintenance jobs on interval or cron schedules. Each job runs in
+// its own loop, so a run never overlaps the previous run of the same job. A random delay
+// of up to the jitter is added to every run so instances don't hit shared databases at
+// the same moment. A nil *Scheduler is valid and has no jobs.
+type Scheduler struct {
+	log     *slog.Logger
+	jitter  time.Duration
+	timeout time.Duration
+
+	mu      sync.Mutex
+	jobs    []*scheduledJob
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// scheduledJob is a registered job and the status of its runs.
+type scheduledJob struct {
+	name     string
+	spec     string
+	schedule Schedule
+	fn       JobFunc
+	trigger  chan struct{}
+	running  atomic.Bool
+
+	mu     sync.Mutex
+	status models.JobStatus
+}
+
+// NewScheduler creates a scheduler. Jobs are registered with Register and run once Start is called.
+func NewScheduler(log *slog.Logger, jitter, timeout time.Duration) *Scheduler {
+	if timeout <= 0 {
+		timeout = DefaultJobTimeout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		log:     log.With("component", "scheduler"),
+		jitter:  max(jitter, 0),
+		timeout: timeout,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Register adds a named job with a schedule as accepted by ParseSchedule.
+// Jobs must be registered before Start.
+func (s *Scheduler) Register(name, spec string, fn JobFunc) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("job %s: scheduler already started", name)
+	}
+	if slices.ContainsFunc(s.jobs, func(j *scheduledJob) bool { return j.name == name }) {
+		return fmt.Errorf("job %s: already registered", name)
+	}
+
+	s.jobs = append(s.jobs, &scheduledJob{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		trigger:  make(chan struct{}, 1),
+		status:   models.JobStatus{Name: name, Schedule: spec},
+	})
+	s.log.Debug("registered job", "job", name, "schedule", spec)
+	return nil
+}
+
+// Start runs the registered jobs on their schedules until Close is called.
+func (s *Scheduler) Start() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+	s.log.Info("scheduler started", "jobs", len(s.jobs))
+}
+
+// Close stops scheduling, cancels running jobs and waits for them to return, or until ctx expires.
+func (s *Scheduler) Close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Trigger runs a job now, outside its schedule. It returns ErrJobRunning rather than
+// queueing a second run while the job is running.
+func (s *Scheduler) Trigger(name string) error {
+	job := s.job(name)
+	if job == nil {
+		return ErrJobNotFound
+	}
+	if job.running.Load() {
+		return ErrJobRunning
+	}
+	select {
+	case job.trigger <- struct{}{}:
+	default: // A run is already pending
+	}
+	return nil
+}
+
+// Status returns the status of every registered job, in registration order.
+func (s *Scheduler) Status() []models.JobStatus {
+	if s == nil {
+		return []models.JobStatus{}
+	}
+	s.mu.Lock()
+	jobs := slices.Clone(s.jobs)
+	s.mu.Unlock()
+
+	statuses := make([]models.JobStatus, 0, len(jobs))
+	for _, job := range jobs {
+		job.mu.Lock()
+		status := job.status
+		job.mu.Unlock()
+		status.Running = job.running.Load()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// job returns the registered job with the name, or nil.
+func (s *Scheduler) job(name string) *scheduledJob {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// loop waits for each scheduled (or triggered) run of a job and runs it.
+func (s *Scheduler) loop(job *scheduledJob) {
+	defer s.wg.Done()
+
+	for {
+		var timer *time.Timer
+		var fire <-chan time.Time
+		next := job.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.log.Warn("job schedule never matches, only manual runs are possible", "job", job.name, "schedule", job.spec)
+		} else {
+			if s.jitter > 0 {
+				next = next.Add(rand.N(s.jitter))
+			}
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+		job.mu.Lock()
+		job.status.NextRunAt = timePtrOrNil(next)
+		job.mu.Unlock()
+
+		select {
+		case <-fire:
+		case <-job.trigger:
+		case <-s.ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.run(job)
+	}
+}
+
+// run executes one run of a job and records its outcome. A panicking job is reported
+// as failed instead of stopping the scheduler.
+func (s *Scheduler) run(job *scheduledJob) {
+	if !job.running.CompareAndSwap(false, true) {
+		return
+	}
+	defer job.running.Store(false)
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		return job.fn(ctx)
+	}()
+	duration := time.Since(start)
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	startedAt := start.UTC()
+	job.status.LastRunAt = &startedAt
+	job.status.LastDurationMs = duration.Milliseconds()
+	job.status.Runs++
+	if err != nil {
+		job.status.LastError = err.Error()
+		job.status.Failures++
+		s.log.Error("job failed", "job", job.name, "error", err, "duration", duration)
+		return
+	}
+	job.status.LastError = ""
+	job.status.LastSuccessAt = &startedAt
+	s.log.Debug("job finished", "job", job.name, "duration", duration)
+}
+
+// timePtrOrNil returns a pointer to t in UTC, or nil for the zero time.
+func timePtrOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,298 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Source Schema Drift ---
+
+// SourceSchemaChangesLimit is the number of schema changes listed for a source.
+const SourceSchemaChangesLimit = 100
+
+// quotedStringPattern matches single-quoted string literals, which can't reference columns.
+var quotedStringPattern = regexp.MustCompile(`'(?:[^'\\]|\\.)*'`)
+
+// SchemaDriftDetector records the changes between successive schema snapshots of sources.
+// It implements clickhouse.SchemaObserver.
+type SchemaDriftDetector struct {
+	db      *sqlite.DB
+	cache   *QueryCache
+	mapKeys *MapKeysCache
+	log     *slog.Logger
+}
+
+var _ clickhouse.SchemaObserver = (*SchemaDriftDetector)(nil)
+
+// NewSchemaDriftDetector creates a detector storing snapshots and changes in db. Query results
+// and Map keys of changed sources are dropped from cache and mapKeys, either of which may be nil.
+func NewSchemaDriftDetector(db *sqlite.DB, cache *QueryCache, mapKeys *MapKeysCache, log *slog.Logger) *SchemaDriftDetector {
+	return &SchemaDriftDetector{db: db, cache: cache, mapKeys: mapKeys, log: log.With("component", "schema_drift")}
+}
+
+// ObserveSchema compares a snapshot with the previous one of the source, records the
+// changes and keeps the snapshot. The first snapshot of a source is only kept as a baseline.
+func (d *SchemaDriftDetector) ObserveSchema(ctx context.Context, sourceID models.SourceID, snapshot *models.SchemaSnapshot) {
+	previous, err := d.db.GetSourceSchemaSnapshot(ctx, sourceID)
+	if err != nil && !errors.Is(err, sqlite.ErrNotFound) {
+		d.log.Error("failed to get previous schema snapshot", "source_id", sourceID, "error", err)
+		return
+	}
+
+	if previous != nil {
+		changes := diffSchemaSnapshots(previous, snapshot)
+		for _, change := range changes {
+			if err := d.db.InsertSourceSchemaChange(ctx, change); err != nil {
+				d.log.Error("failed to record schema change", "source_id", sourceID, "error", err)
+				return
+			}
+		}
+		if len(changes) > 0 {
+			d.log.Info("source schema changed", "source_id", sourceID, "changes", len(changes))
+			// Cached results and Map keys have the old columns.
+			d.cache.Invalidate(ctx, sourceID)
+			d.mapKeys.Invalidate(sourceID)
+		}
+	}
+
+	if err := d.db.UpsertSourceSchemaSnapshot(ctx, snapshot); err != nil {
+		d.log.Error("failed to save schema snapshot", "source_id", sourceID, "error", err)
+	}
+}
+
+// diffSchemaSnapshots returns the column and sort key changes from previous to current.
+func diffSchemaSnapshots(previous, current *models.SchemaSnapshot) []*models.SchemaChange {
+	newChange := func(changeType models.SchemaChangeType, column, oldValue, newValue string) *models.SchemaChange {
+		return &models.SchemaChange{
+			SourceID:   current.SourceID,
+			Type:       changeType,
+			Column:     column,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+			DetectedAt: current.TakenAt,
+		}
+	}
+
+	oldTypes := make(map[string]string, len(previous.Columns))
+	for _, col := range previous.Columns {
+		oldTypes[col.Name] = col.Type
+	}
+	newTypes := make(map[string]string, len(current.Columns))
+	for _, col := range current.Columns {
+		newTypes[col.Name] = col.Type
+	}
+
+	var changes []*models.SchemaChange
+	for _, col := range current.Columns {
+		oldType, ok := oldTypes[col.Name]
+		switch {
+		case !ok:
+			changes = append(changes, newChange(models.SchemaChangeColumnAdded, col.Name, "", col.Type))
+		case oldType != col.Type:
+			changes = append(changes, newChange(models.SchemaChangeColumnTypeChanged, col.Name, oldType, col.Type))
+		}
+	}
+	for _, col := range previous.Columns {
+		if _, ok := newTypes[col.Name]; !ok {
+			changes = append(changes, newChange(models.SchemaChangeColumnRemoved, col.Name, col.Type, ""))
+		}
+	}
+	if !slices.Equal(previous.SortKeys, current.SortKeys) {
+		changes = append(changes, newChange(models.SchemaChangeSortKeysChanged, "",
+			strings.Join(previous.SortKeys, ", "), strings.Join(current.SortKeys, ", ")))
+	}
+	return changes
+}
+
+// ListSourceSchemaChanges returns the latest schema changes of a source, newest first.
+func ListSourceSchemaChanges(ctx context.Context, db *sqlite.DB, id models.SourceID) ([]*models.SchemaChange, error) {
+	return db.ListSourceSchemaChanges(ctx, id, SourceSchemaChangesLimit)
+}
+
+// FindSchemaChangeImpacts returns the saved queries of a source referencing columns that were
+// removed from its table and haven't been added back since.
+func FindSchemaChangeImpacts(ctx context.Context, db *sqlite.DB, id models.SourceID, changes []*models.SchemaChange) ([]*models.SchemaChangeImpact, error) {
+	impacts := []*models.SchemaChangeImpact{}
+
+	snapshot, err := db.GetSourceSchemaSnapshot(ctx, id)
+	if err != nil {
+		if errors.Is(err, sqlite.ErrNotFound) {
+			return impacts, nil
+		}
+		return nil, err
+	}
+	current := make(map[string]bool, len(snapshot.Columns))
+	for _, col := range snapshot.Columns {
+		current[col.Name] = true
+	}
+
+	var removed []string
+	for _, change := range changes {
+		if change.Type == models.SchemaChangeColumnRemoved && !current[change.Column] && !slices.Contains(removed, change.Column) {
+			removed = append(removed, change.Column)
+		}
+	}
+	if len(removed) == 0 {
+		return impacts, nil
+	}
+
+	queries, err := db.ListQueriesBySource(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error listing saved queries: %w", err)
+	}
+	for _, query := range queries {
+		if missing := referencedColumns(query.QueryContent, removed); len(missing) > 0 {
+			impacts = append(impacts, &models.SchemaChangeImpact{
+				QueryID:        query.ID,
+				TeamID:         query.TeamID,
+				Name:           query.Name,
+				MissingColumns: missing,
+			})
+		}
+	}
+	return impacts, nil
+}
+
+// referencedColumns returns the columns a saved query's content references. Columns are
+// matched as whole identifiers outside string literals, which holds for SQL and LogchefQL.
+func referencedColumns(contentJSON string, columns []string) []string {
+	var content models.SavedQueryContent
+	if err := json.Unmarshal([]byte(contentJSON), &content); err != nil {
+		return nil
+	}
+	query := quotedStringPattern.ReplaceAllString(content.Content, "''")
+
+	var referenced []string
+	for _, column := range columns {
+		if columnPattern(column).MatchString(query) {
+			referenced = append(referenced, column)
+		}
+	}
+	return referenced
+}
+
+// columnPattern matches a column name as a whole identifier.
+func columnPattern(column string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w])` + regexp.QuoteMeta(column) + `(?:[^\w]|$)`)
+}
+
+// filterImpactsByTeam keeps the impacts on saved queries of a team.
+func filterImpactsByTeam(impacts []*models.SchemaChangeImpact, teamID models.TeamID) []*models.SchemaChangeImpact {
+	filtered := []*models.SchemaChangeImpact{}
+	for _, impact := range impacts {
+		if impact.TeamID == teamID {
+			filtered = append(filtered, impact)
+		}
+	}
+	return filtered
+}
+
+// filterDriftByColumnPolicies applies a team's column policies to schema changes and impacts,
+// the way ApplyColumnPolicies does to a schema: changes of hidden columns are dropped, hidden
+// columns are removed from sort keys and missing columns, and masked columns are reported with
+// the String type queries return for them. A masked column's type change is dropped, as its
+// type doesn't change for the team.
+func filterDriftByColumnPolicies(changes []*models.SchemaChange, impacts []*models.SchemaChangeImpact, policies []*models.ColumnPolicy) ([]*models.SchemaChange, []*models.SchemaChangeImpact) {
+	if len(policies) == 0 {
+		return changes, impacts
+	}
+	byColumn := make(map[string]*models.ColumnPolicy, len(policies))
+	for _, policy := range policies {
+		byColumn[strings.ToLower(policy.Column)] = policy
+	}
+	hidden := func(column string) bool {
+		policy, ok := byColumn[strings.ToLower(column)]
+		return ok && policy.Action == models.ColumnPolicyHide
+	}
+	var hiddenPatterns []*regexp.Regexp
+	for _, policy := range policies {
+		if policy.Action == models.ColumnPolicyHide {
+			hiddenPatterns = append(hiddenPatterns, regexp.MustCompile(`(?i)`+columnPattern(policy.Column).String()))
+		}
+	}
+	// Sort keys can be expressions, so keys referencing a hidden column are removed.
+	visibleKeys := func(keys string) string {
+		if keys == "" {
+			return ""
+		}
+		return strings.Join(slices.DeleteFunc(strings.Split(keys, ", "), func(key string) bool {
+			return slices.ContainsFunc(hiddenPatterns, func(p *regexp.Regexp) bool { return p.MatchString(key) })
+		}), ", ")
+	}
+
+	filteredChanges := make([]*models.SchemaChange, 0, len(changes))
+	for _, change := range changes {
+		c := *change
+		if c.Type == models.SchemaChangeSortKeysChanged {
+			c.OldValue, c.NewValue = visibleKeys(c.OldValue), visibleKeys(c.NewValue)
+			if c.OldValue != c.NewValue {
+				filteredChanges = append(filteredChanges, &c)
+			}
+			continue
+		}
+		policy, restricted := byColumn[strings.ToLower(c.Column)]
+		switch {
+		case !restricted:
+		case policy.Action == models.ColumnPolicyHide, c.Type == models.SchemaChangeColumnTypeChanged:
+			continue
+		default:
+			if c.OldValue != "" {
+				c.OldValue = "String"
+			}
+			if c.NewValue != "" {
+				c.NewValue = "String"
+			}
+		}
+		filteredChanges = append(filteredChanges, &c)
+	}
+
+	filteredImpacts := make([]*models.SchemaChangeImpact, 0, len(impacts))
+	for _, impact := range impacts {
+		i := *impact
+		i.MissingColumns = slices.DeleteFunc(slices.Clone(i.MissingColumns), hidden)
+		if len(i.MissingColumns) > 0 {
+			filteredImpacts = append(filteredImpacts, &i)
+		}
+	}
+	return filteredChanges, filteredImpacts
+}
+
+// GetSourceSchemaDrift returns the latest schema changes of a source and the saved queries
+// they break. With a team, only the saved queries of that team are returned, and changes are
+// filtered through the team's column policies.
+func GetSourceSchemaDrift(ctx context.Context, db *sqlite.DB, id models.SourceID, teamID *models.TeamID) (*models.SchemaDrift, error) {
+	if _, err := db.GetSource(ctx, id); err != nil {
+		if sqlite.IsNotFoundError(err) || sqlite.IsSourceNotFoundError(err) {
+			return nil, ErrSourceNotFound
+		}
+		return nil, fmt.Errorf("error getting source: %w", err)
+	}
+
+	changes, err := ListSourceSchemaChanges(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	impacts, err := FindSchemaChangeImpacts(ctx, db, id, changes)
+	if err != nil {
+		return nil, err
+	}
+	if teamID != nil {
+		impacts = filterImpactsByTeam(impacts, *teamID)
+		policies, err := db.ListColumnPolicies(ctx, *teamID, id)
+		if err != nil {
+			return nil, fmt.Errorf("error listing column policies: %w", err)
+		}
+		changes, impacts = filterDriftByColumnPolicies(changes, impacts, policies)
+	}
+	return &models.SchemaDrift{Changes: changes, AffectedQueries: impacts}, nil
+}
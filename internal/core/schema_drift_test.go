@@ -0,0 +1,46 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+func TestFilterDriftByColumnPolicies(t *testing.T) {
+	policies := []*models.ColumnPolicy{
+		{Column: "remote_addr", Action: models.ColumnPolicyHide},
+		{Column: "user_email", Action: models.ColumnPolicyHash},
+	}
+	changes := []*models.SchemaChange{
+		{Type: models.SchemaChangeColumnAdded, Column: "level", NewValue: "LowCardinality(String)"},
+		{Type: models.SchemaChangeColumnAdded, Column: "Remote_Addr", NewValue: "IPv4"},
+		{Type: models.SchemaChangeColumnRemoved, Column: "user_email", OldValue: "Nullable(String)"},
+		{Type: models.SchemaChangeColumnTypeChanged, Column: "user_email", OldValue: "String", NewValue: "LowCardinality(String)"},
+		{Type: models.SchemaChangeColumnTypeChanged, Column: "remote_addr", OldValue: "IPv4", NewValue: "IPv6"},
+		{Type: models.SchemaChangeSortKeysChanged, OldValue: "timestamp", NewValue: "timestamp, cityHash64(remote_addr)"},
+		{Type: models.SchemaChangeSortKeysChanged, OldValue: "timestamp, remote_addr", NewValue: "level, timestamp"},
+	}
+	impacts := []*models.SchemaChangeImpact{
+		{QueryID: 1, MissingColumns: []string{"remote_addr"}},
+		{QueryID: 2, MissingColumns: []string{"remote_addr", "user_email"}},
+	}
+
+	gotChanges, gotImpacts := filterDriftByColumnPolicies(changes, impacts, policies)
+
+	wantChanges := []*models.SchemaChange{
+		{Type: models.SchemaChangeColumnAdded, Column: "level", NewValue: "LowCardinality(String)"},
+		{Type: models.SchemaChangeColumnRemoved, Column: "user_email", OldValue: "String"},
+		{Type: models.SchemaChangeSortKeysChanged, OldValue: "timestamp", NewValue: "level, timestamp"},
+	}
+	if !reflect.DeepEqual(gotChanges, wantChanges) {
+		t.Errorf("changes:\n got: %+v\nwant: %+v", gotChanges, wantChanges)
+	}
+	wantImpacts := []*models.SchemaChangeImpact{{QueryID: 2, MissingColumns: []string{"user_email"}}}
+	if !reflect.DeepEqual(gotImpacts, wantImpacts) {
+		t.Errorf("impacts:\n got: %+v\nwant: %+v", gotImpacts, wantImpacts)
+	}
+	if changes[2].OldValue != "Nullable(String)" || len(impacts[1].MissingColumns) != 2 {
+		t.Error("the unfiltered changes and impacts must not be modified")
+	}
+}
@@ -0,0 +1,442 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	clickhouseparser "github.com/AfterShip/clickhouse-sql-parser/parser"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Schema Templates ---
+
+// DefaultSchemaTemplate is used when a table is auto-created without a template or custom schema.
+const DefaultSchemaTemplate = "otel_logs"
+
+// ErrSchemaTemplateNotFound is returned when a schema template doesn't exist
+var ErrSchemaTemplateNotFound = errors.New("schema template not found")
+
+const (
+	defaultSchemaCodec         = "ZSTD(1)"
+	defaultReplicationPath     = "/clickhouse/tables/{shard}/{database}/{table}"
+	defaultReplicationReplica  = "{replica}"
+	schemaPartitionNone        = "none"
+	schemaTemplateMaxOrderKeys = 8
+)
+
+// schemaPartitionExprs maps a partition granularity to its PARTITION BY expression.
+// %s is replaced by the timestamp column.
+var schemaPartitionExprs = map[string]string{
+	"day":               "toDate(%s)",
+	"week":              "toMonday(%s)",
+	"month":             "toYYYYMM(%s)",
+	schemaPartitionNone: "",
+}
+
+// schemaCodecs are the compression codecs offered for the columns of a template.
+var schemaCodecs = []string{"ZSTD(1)", "ZSTD(3)", "ZSTD(6)", "LZ4", "LZ4HC(9)"}
+
+// replicationArgPattern matches the ZooKeeper path and replica name of ReplicatedMergeTree,
+// which may contain macros such as {shard} and {replica}.
+var replicationArgPattern = regexp.MustCompile(`^[A-Za-z0-9_{}/.-]+$`)
+
+// clusterNamePattern matches a ClickHouse cluster name.
+var clusterNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// schemaTableTemplate is the table definition shared by every template. Templates only
+// define their "columns" block.
+const schemaTableTemplate = `CREATE TABLE {{ .Table }}{{ with .Cluster }} ON CLUSTER '{{ . }}'{{ end }}
+(
+{{ template "columns" . }}
+)
+ENGINE = {{ .Engine }}
+{{- with .PartitionBy }}
+PARTITION BY {{ . }}
+{{- end }}
+ORDER BY ({{ .OrderBy }})
+{{- with .TTL }}
+TTL {{ . }}
+{{- end }}
+SETTINGS index_granularity = 8192{{ if and .TTL .PartitionBy }}, ttl_only_drop_parts = 1{{ end }}`
+
+// schemaTemplateData is the data a schema template is rendered with.
+type schemaTemplateData struct {
+	Table       string // Quoted database.table
+	Cluster     string
+	Engine      string
+	PartitionBy string // Empty when the table isn't partitioned
+	OrderBy     string
+	TTL         string // Empty when rows don't expire
+	Codec       string
+}
+
+// schemaTemplate is a registered schema template with its rendering defaults.
+type schemaTemplate struct {
+	models.SchemaTemplate
+	defaultPartitionBy string
+	defaultOrderBy     []string
+	tmpl               *template.Template
+}
+
+// schemaTemplates is the registry of schema templates, in the order they are listed.
+var schemaTemplates = []*schemaTemplate{
+	newSchemaTemplate(models.SchemaTemplate{
+		Name:           "otel_logs",
+		Title:          "OpenTelemetry logs",
+		Description:    "Logs in the OpenTelemetry log data model, as written by the OpenTelemetry Collector or Vector.",
+		TimestampField: "timestamp",
+		SeverityField:  "severity_text",
+		FieldRoles: models.SourceFieldRoles{
+			Message: "body",
+			Service: "service_name",
+			TraceID: "trace_id",
+			SpanID:  "span_id",
+		},
+	}, "day", []string{"namespace", "service_name", "timestamp"}, `	timestamp DateTime64(3) CODEC(DoubleDelta, {{ .Codec }}),
+	trace_id String CODEC({{ .Codec }}),
+	span_id String CODEC({{ .Codec }}),
+	trace_flags UInt32 CODEC({{ .Codec }}),
+	severity_text LowCardinality(String) CODEC({{ .Codec }}),
+	severity_number Int32 CODEC({{ .Codec }}),
+	service_name LowCardinality(String) CODEC({{ .Codec }}),
+	namespace LowCardinality(String) CODEC({{ .Codec }}),
+	body String CODEC({{ .Codec }}),
+	log_attributes Map(LowCardinality(String), String) CODEC({{ .Codec }}),
+
+	INDEX idx_trace_id trace_id TYPE bloom_filter(0.001) GRANULARITY 1,
+	INDEX idx_severity_text severity_text TYPE set(100) GRANULARITY 4,
+	INDEX idx_log_attributes_keys mapKeys(log_attributes) TYPE bloom_filter(0.01) GRANULARITY 1,
+	INDEX idx_log_attributes_values mapValues(log_attributes) TYPE bloom_filter(0.01) GRANULARITY 1,
+	INDEX idx_body body TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 1`),
+
+	newSchemaTemplate(models.SchemaTemplate{
+		Name:           "http_logs",
+		Title:          "HTTP access logs",
+		Description:    "Access logs of web servers and proxies such as Nginx, with Cloudflare request headers.",
+		TimestampField: "timestamp",
+		FieldRoles: models.SourceFieldRoles{
+			Host:       "http_host",
+			HTTPStatus: "status",
+			Duration:   "request_time",
+		},
+	}, "month", []string{"timestamp", "request_id"}, `	timestamp DateTime CODEC(DoubleDelta, {{ .Codec }}),
+	remote_addr String CODEC({{ .Codec }}),
+	request_method LowCardinality(String) CODEC({{ .Codec }}),
+	request_uri String CODEC({{ .Codec }}),
+	status UInt16 CODEC({{ .Codec }}),
+	body_bytes_sent UInt64 CODEC({{ .Codec }}),
+	http_referer String CODEC({{ .Codec }}),
+	http_user_agent String CODEC({{ .Codec }}),
+	http_x_forwarded_for String CODEC({{ .Codec }}),
+	http_host LowCardinality(String) CODEC({{ .Codec }}),
+	request_time Float64 CODEC({{ .Codec }}),
+	upstream_response_time Float64 CODEC({{ .Codec }}),
+	upstream_addr String CODEC({{ .Codec }}),
+	upstream_status UInt16 CODEC({{ .Codec }}),
+	request_id String CODEC({{ .Codec }}),
+	request_length UInt64 CODEC({{ .Codec }}),
+	request_completion String CODEC({{ .Codec }}),
+	ssl_protocol LowCardinality(String) CODEC({{ .Codec }}),
+	ssl_cipher LowCardinality(String) CODEC({{ .Codec }}),
+	scheme LowCardinality(String) CODEC({{ .Codec }}),
+	gzip_ratio Float64 CODEC({{ .Codec }}),
+	http_cf_ray String CODEC({{ .Codec }}),
+	http_cf_connecting_ip String CODEC({{ .Codec }}),
+	http_true_client_ip String CODEC({{ .Codec }}),
+	http_cf_ipcountry LowCardinality(String) CODEC({{ .Codec }}),
+	http_cf_visitor String CODEC({{ .Codec }}),
+	http_cdn_loop String CODEC({{ .Codec }}),
+	http_cf_worker String CODEC({{ .Codec }}),
+
+	INDEX idx_request_id request_id TYPE bloom_filter(0.001) GRANULARITY 1,
+	INDEX idx_status status TYPE set(100) GRANULARITY 4,
+	INDEX idx_request_uri request_uri TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 1`),
+
+	newSchemaTemplate(models.SchemaTemplate{
+		Name:           "syslog",
+		Title:          "Syslog",
+		Description:    "RFC 5424 syslog messages with structured data.",
+		TimestampField: "timestamp",
+		SeverityField:  "severity",
+		FieldRoles: models.SourceFieldRoles{
+			Message: "message",
+			Service: "app_name",
+			Host:    "hostname",
+		},
+	}, "day", []string{"hostname", "app_name", "timestamp"}, `	timestamp DateTime64(3) CODEC(DoubleDelta, {{ .Codec }}),
+	hostname LowCardinality(String) CODEC({{ .Codec }}),
+	app_name LowCardinality(String) CODEC({{ .Codec }}),
+	proc_id String CODEC({{ .Codec }}),
+	msg_id String CODEC({{ .Codec }}),
+	facility LowCardinality(String) CODEC({{ .Codec }}),
+	severity LowCardinality(String) CODEC({{ .Codec }}),
+	message String CODEC({{ .Codec }}),
+	structured_data Map(LowCardinality(String), String) CODEC({{ .Codec }}),
+
+	INDEX idx_severity severity TYPE set(10) GRANULARITY 4,
+	INDEX idx_structured_data_keys mapKeys(structured_data) TYPE bloom_filter(0.01) GRANULARITY 1,
+	INDEX idx_message message TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 1`),
+
+	newSchemaTemplate(models.SchemaTemplate{
+		Name:           "k8s_events",
+		Title:          "Kubernetes events",
+		Description:    "Kubernetes events, as exported by the OpenTelemetry k8sobjects receiver or an event exporter.",
+		TimestampField: "timestamp",
+		SeverityField:  "event_type",
+		FieldRoles: models.SourceFieldRoles{
+			Message: "message",
+			Host:    "source_host",
+		},
+	}, "day", []string{"namespace", "object_kind", "reason", "timestamp"}, `	timestamp DateTime64(3) CODEC(DoubleDelta, {{ .Codec }}),
+	cluster LowCardinality(String) CODEC({{ .Codec }}),
+	namespace LowCardinality(String) CODEC({{ .Codec }}),
+	object_kind LowCardinality(String) CODEC({{ .Codec }}),
+	object_name String CODEC({{ .Codec }}),
+	reason LowCardinality(String) CODEC({{ .Codec }}),
+	event_type LowCardinality(String) CODEC({{ .Codec }}),
+	message String CODEC({{ .Codec }}),
+	source_component LowCardinality(String) CODEC({{ .Codec }}),
+	source_host LowCardinality(String) CODEC({{ .Codec }}),
+	count UInt32 CODEC({{ .Codec }}),
+	labels Map(LowCardinality(String), String) CODEC({{ .Codec }}),
+
+	INDEX idx_object_name object_name TYPE bloom_filter(0.01) GRANULARITY 1,
+	INDEX idx_message message TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 1`),
+}
+
+// newSchemaTemplate parses a template's columns and records the columns it creates.
+// It panics on an invalid template, like regexp.MustCompile, since templates are static.
+func newSchemaTemplate(info models.SchemaTemplate, partitionBy string, orderBy []string, columns string) *schemaTemplate {
+	tmpl := template.Must(template.New(info.Name).Option("missingkey=error").Parse(schemaTableTemplate))
+	template.Must(tmpl.New("columns").Parse(columns))
+
+	t := &schemaTemplate{
+		SchemaTemplate:     info,
+		defaultPartitionBy: partitionBy,
+		defaultOrderBy:     orderBy,
+		tmpl:               tmpl,
+	}
+	ddl, err := t.render("logs", "logs", 0, models.SchemaTemplateParams{})
+	if err != nil {
+		panic(fmt.Sprintf("schema template %s: %v", info.Name, err))
+	}
+	if t.Columns, err = validateCreateTableDDL(ddl, "logs", "logs"); err != nil {
+		panic(fmt.Sprintf("schema template %s: %v", info.Name, err))
+	}
+	t.Parameters = []models.SchemaTemplateParameter{
+		{Name: "partition_by", Type: "string", Default: partitionBy, Options: []string{"day", "week", "month", schemaPartitionNone}, Description: "Granularity of the table partitions."},
+		{Name: "order_by", Type: "string_list", Default: orderBy, Options: t.Columns, Description: "Columns of the sorting key."},
+		{Name: "codec", Type: "string", Default: defaultSchemaCodec, Options: schemaCodecs, Description: "Compression codec of the columns."},
+		{Name: "replicated", Type: "bool", Default: false, Description: "Use ReplicatedMergeTree instead of MergeTree."},
+		{Name: "cluster", Type: "string", Description: "Create the table on every node of this cluster (ON CLUSTER)."},
+		{Name: "zookeeper_path", Type: "string", Default: defaultReplicationPath, Description: "ZooKeeper path of a replicated table."},
+		{Name: "replica_name", Type: "string", Default: defaultReplicationReplica, Description: "Replica name of a replicated table."},
+		{Name: "ttl_days", Type: "int", Default: 0, Description: "Days after which rows are dropped, taken from the source's TTL. 0 or -1 keeps rows forever."},
+	}
+	return t
+}
+
+// render fills the template with the validated parameters. ttlDays <= 0 omits the TTL.
+func (t *schemaTemplate) render(database, table string, ttlDays int, params models.SchemaTemplateParams) (string, error) {
+	data := schemaTemplateData{
+		Table: fmt.Sprintf("`%s`.`%s`", database, table),
+		Codec: defaultSchemaCodec,
+	}
+
+	partitionBy := orDefault(params.PartitionBy, t.defaultPartitionBy)
+	partitionExpr, ok := schemaPartitionExprs[partitionBy]
+	if !ok {
+		return "", &ValidationError{Field: "template_params.partition_by", Message: "partition granularity must be day, week, month or none"}
+	}
+	if partitionExpr != "" {
+		data.PartitionBy = fmt.Sprintf(partitionExpr, t.TimestampField)
+	}
+
+	orderBy := params.OrderBy
+	if len(orderBy) == 0 {
+		orderBy = t.defaultOrderBy
+	}
+	if len(orderBy) > schemaTemplateMaxOrderKeys {
+		return "", &ValidationError{Field: "template_params.order_by", Message: fmt.Sprintf("sorting key must not have more than %d columns", schemaTemplateMaxOrderKeys)}
+	}
+	for i, column := range orderBy {
+		// The columns are only known once the template has been parsed at registration.
+		if !isValidColumnName(column) || (t.Columns != nil && !slices.Contains(t.Columns, column)) {
+			return "", &ValidationError{Field: "template_params.order_by", Message: fmt.Sprintf("unknown column %q", column)}
+		}
+		if slices.Contains(orderBy[:i], column) {
+			return "", &ValidationError{Field: "template_params.order_by", Message: fmt.Sprintf("column %q is listed more than once", column)}
+		}
+	}
+	data.OrderBy = strings.Join(orderBy, ", ")
+
+	if params.Codec != "" {
+		if !slices.Contains(schemaCodecs, params.Codec) {
+			return "", &ValidationError{Field: "template_params.codec", Message: "codec must be one of " + strings.Join(schemaCodecs, ", ")}
+		}
+		data.Codec = params.Codec
+	}
+
+	if params.Cluster != "" {
+		if !clusterNamePattern.MatchString(params.Cluster) {
+			return "", &ValidationError{Field: "template_params.cluster", Message: "cluster name contains invalid characters"}
+		}
+		data.Cluster = params.Cluster
+	}
+
+	data.Engine = "MergeTree()"
+	if params.Replicated {
+		path := orDefault(params.ZooKeeperPath, defaultReplicationPath)
+		replica := orDefault(params.ReplicaName, defaultReplicationReplica)
+		if !strings.HasPrefix(path, "/") || !replicationArgPattern.MatchString(path) {
+			return "", &ValidationError{Field: "template_params.zookeeper_path", Message: "ZooKeeper path must start with / and contain only letters, numbers, _ - . / and {macros}"}
+		}
+		if !replicationArgPattern.MatchString(replica) {
+			return "", &ValidationError{Field: "template_params.replica_name", Message: "replica name must contain only letters, numbers, _ - . / and {macros}"}
+		}
+		data.Engine = fmt.Sprintf("ReplicatedMergeTree('%s', '%s')", path, replica)
+	} else if params.ZooKeeperPath != "" || params.ReplicaName != "" {
+		return "", &ValidationError{Field: "template_params.replicated", Message: "ZooKeeper path and replica name require a replicated table"}
+	}
+
+	if ttlDays > 0 {
+		data.TTL = fmt.Sprintf("toDateTime(%s) + INTERVAL %d DAY", t.TimestampField, ttlDays)
+	}
+
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error rendering schema template %s: %w", t.Name, err)
+	}
+	return b.String(), nil
+}
+
+// orDefault returns value, or fallback when value is empty.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// lookupSchemaTemplate returns the registered template with the name.
+func lookupSchemaTemplate(name string) (*schemaTemplate, error) {
+	for _, t := range schemaTemplates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, ErrSchemaTemplateNotFound
+}
+
+// ListSchemaTemplates returns the available schema templates.
+func ListSchemaTemplates() []models.SchemaTemplate {
+	templates := make([]models.SchemaTemplate, 0, len(schemaTemplates))
+	for _, t := range schemaTemplates {
+		templates = append(templates, t.SchemaTemplate)
+	}
+	return templates
+}
+
+// GetSchemaTemplate returns the schema template with the name.
+func GetSchemaTemplate(name string) (*models.SchemaTemplate, error) {
+	t, err := lookupSchemaTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	info := t.SchemaTemplate
+	return &info, nil
+}
+
+// RenderSchemaTemplate renders the CREATE TABLE statement of a schema template for
+// database.table and validates it by parsing it. ttlDays <= 0 creates a table without a TTL.
+func RenderSchemaTemplate(name, database, table string, ttlDays int, params models.SchemaTemplateParams) (string, error) {
+	t, err := lookupSchemaTemplate(name)
+	if err != nil {
+		return "", err
+	}
+	if !isValidTableName(database) {
+		return "", &ValidationError{Field: "database", Message: "database name contains invalid characters"}
+	}
+	if !isValidTableName(table) {
+		return "", &ValidationError{Field: "table", Message: "table name contains invalid characters"}
+	}
+
+	ddl, err := t.render(database, table, ttlDays, params)
+	if err != nil {
+		return "", err
+	}
+	if _, err := validateCreateTableDDL(ddl, database, table); err != nil {
+		return "", err
+	}
+	return ddl, nil
+}
+
+// validateCreateTableDDL parses a schema and checks that it is a single CREATE TABLE statement
+// with columns for database.table. It returns the names of the columns.
+func validateCreateTableDDL(ddl, database, table string) ([]string, error) {
+	stmts, err := clickhouseparser.NewParser(ddl).ParseStmts()
+	if err != nil {
+		return nil, &ValidationError{Field: "schema", Message: "schema is not valid SQL", Err: err}
+	}
+	if len(stmts) != 1 {
+		return nil, &ValidationError{Field: "schema", Message: "schema must contain exactly one CREATE TABLE statement"}
+	}
+	create, ok := stmts[0].(*clickhouseparser.CreateTable)
+	if !ok || create.Name == nil || create.Name.Table == nil {
+		return nil, &ValidationError{Field: "schema", Message: "schema must be a CREATE TABLE statement"}
+	}
+
+	// A schema without a database creates the table in the connection's database.
+	if create.Name.Table.Name != table || (create.Name.Database != nil && create.Name.Database.Name != database) {
+		return nil, &ValidationError{Field: "schema", Message: fmt.Sprintf("schema must create the table %s.%s", database, table)}
+	}
+	if create.SubQuery != nil {
+		return nil, &ValidationError{Field: "schema", Message: "CREATE TABLE ... AS SELECT is not supported"}
+	}
+	if create.TableSchema == nil {
+		return nil, &ValidationError{Field: "schema", Message: "schema must define the table's columns"}
+	}
+
+	var columns []string
+	for _, expr := range create.TableSchema.Columns {
+		if column, ok := expr.(*clickhouseparser.ColumnDef); ok && column.Name != nil && column.Name.Ident != nil {
+			columns = append(columns, column.Name.Ident.Name)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, &ValidationError{Field: "schema", Message: "schema must define the table's columns"}
+	}
+	return columns, nil
+}
+
+// sourceTableSchema returns the validated CREATE TABLE statement used to auto-create a source's
+// table: the custom schema when given, otherwise the rendered schema template.
+func sourceTableSchema(conn models.ConnectionInfo, ttlDays int, metaTSField, customSchema, templateName string, params models.SchemaTemplateParams) (string, error) {
+	ddl := customSchema
+	if ddl == "" {
+		var err error
+		ddl, err = RenderSchemaTemplate(orDefault(templateName, DefaultSchemaTemplate), conn.Database, conn.TableName, ttlDays, params)
+		if errors.Is(err, ErrSchemaTemplateNotFound) {
+			return "", &ValidationError{Field: "template", Message: fmt.Sprintf("unknown schema template %q", templateName)}
+		}
+		if err != nil {
+			return "", err
+		}
+	} else if templateName != "" {
+		return "", &ValidationError{Field: "template", Message: "a schema template can't be combined with a custom schema"}
+	}
+
+	columns, err := validateCreateTableDDL(ddl, conn.Database, conn.TableName)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(columns, metaTSField) {
+		return "", &ValidationError{Field: "metaTSField", Message: fmt.Sprintf("timestamp field '%s' is not a column of the schema", metaTSField)}
+	}
+	return ddl, nil
+}
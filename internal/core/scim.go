@@ -0,0 +1,716 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// SCIM 2.0 lets an identity provider manage users and teams. Users are identified by their
+// email (userName) and teams are exposed as groups. Teams have no SCIM counterpart for
+// roles, so members added through SCIM get the member role and existing roles are kept.
+
+// scimFilterPattern matches the only filter form identity providers send for lookups.
+var scimFilterPattern = regexp.MustCompile(`(?i)^\s*([a-z][a-z.]*)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// scimMemberPathPattern matches a PATCH path addressing one group member.
+var scimMemberPathPattern = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+// maxSCIMPageSize caps the number of resources returned per list request.
+const maxSCIMPageSize = 1000
+
+// parseSCIMFilter parses a filter of the form `attribute eq "value"`. The attribute is
+// returned lower-cased; an empty filter returns an empty attribute.
+func parseSCIMFilter(filter string) (string, string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", nil
+	}
+	m := scimFilterPattern.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", &ValidationError{Field: "filter", Message: `only filters of the form 'attribute eq "value"' are supported`}
+	}
+	value, err := strconv.Unquote(m[2])
+	if err != nil {
+		return "", "", &ValidationError{Field: "filter", Message: "invalid filter value"}
+	}
+	return strings.ToLower(m[1]), value, nil
+}
+
+// scimPage returns the 1-based page of resources and fills in the list response.
+func scimPage(resources []any, startIndex, count int) *models.SCIMListResponse {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	count = min(count, maxSCIMPageSize)
+
+	page := []any{}
+	if from := startIndex - 1; from < len(resources) {
+		page = resources[from:min(from+count, len(resources))]
+	}
+	return &models.SCIMListResponse{
+		Schemas:      []string{models.SCIMListResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
+
+// scimBool reads a boolean PATCH value, which some identity providers send as a string.
+func scimBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("expected a boolean, got %T", value)
+}
+
+// --- Users ---
+
+// scimUserID parses a SCIM user id. Malformed ids are reported as not found.
+func scimUserID(id string) (models.UserID, error) {
+	userID, err := ParseUserID(id)
+	if err != nil {
+		return 0, ErrUserNotFound
+	}
+	return userID, nil
+}
+
+// toSCIMUser converts a user and the teams they are a member of to a SCIM User.
+func toSCIMUser(user *models.User, teams []*models.UserTeamDetails) *models.SCIMUser {
+	active := user.Status == models.UserStatusActive
+	u := &models.SCIMUser{
+		Schemas:     []string{models.SCIMUserSchema},
+		ID:          strconv.FormatInt(int64(user.ID), 10),
+		UserName:    user.Email,
+		Name:        &models.SCIMName{Formatted: user.FullName},
+		DisplayName: user.FullName,
+		Emails:      []models.SCIMEmail{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &models.SCIMMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+		},
+	}
+	for _, team := range teams {
+		u.Groups = append(u.Groups, models.SCIMGroupRef{Value: strconv.FormatInt(int64(team.ID), 10), Display: team.Name})
+	}
+	return u
+}
+
+// scimUserResource loads a user with their teams as a SCIM User.
+func scimUserResource(ctx context.Context, db *sqlite.DB, user *models.User) (*models.SCIMUser, error) {
+	teams, err := ListTeamsForUser(ctx, db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toSCIMUser(user, teams), nil
+}
+
+// scimEmail returns the email of a SCIM user: its userName, or else its primary email.
+func scimEmail(u *models.SCIMUser) string {
+	if isValidEmail(u.UserName) || len(u.Emails) == 0 {
+		return u.UserName
+	}
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	return u.Emails[0].Value
+}
+
+// scimFullName picks the most complete name a SCIM user carries.
+func scimFullName(u *models.SCIMUser) string {
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		if u.Name.GivenName != "" && u.Name.FamilyName != "" {
+			return u.Name.GivenName + " " + u.Name.FamilyName
+		}
+	}
+	return u.DisplayName
+}
+
+// SCIMListUsers lists users as SCIM resources. Filters on userName and emails.value are supported.
+func SCIMListUsers(ctx context.Context, db *sqlite.DB, filter string, startIndex, count int) (*models.SCIMListResponse, error) {
+	attr, value, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	switch attr {
+	case "", "username", "emails.value", "emails":
+	default:
+		return nil, &ValidationError{Field: "filter", Message: fmt.Sprintf("filtering users by %q is not supported", attr)}
+	}
+
+	users, err := ListUsers(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]any, 0, len(users))
+	for _, user := range users {
+		if attr != "" && !strings.EqualFold(user.Email, value) {
+			continue
+		}
+		u, err := scimUserResource(ctx, db, user)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, u)
+	}
+	return scimPage(resources, startIndex, count), nil
+}
+
+// SCIMGetUser returns a user as a SCIM resource.
+func SCIMGetUser(ctx context.Context, db *sqlite.DB, id string) (*models.SCIMUser, error) {
+	userID, err := scimUserID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetUser(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scimUserResource(ctx, db, user)
+}
+
+// SCIMCreateUser creates a member user from a SCIM resource.
+func SCIMCreateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, req *models.SCIMUser) (*models.SCIMUser, error) {
+	email := scimEmail(req)
+	status := models.UserStatusActive
+	if req.Active != nil && !*req.Active {
+		status = models.UserStatusInactive
+	}
+
+	user, err := CreateUser(ctx, db, log, email, derivedFullName(scimFullName(req), email), models.UserRoleMember, status)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("user created via SCIM", "user_id", user.ID, "email", user.Email)
+	return scimUserResource(ctx, db, user)
+}
+
+// SCIMReplaceUser updates a user's name and active state from a SCIM resource.
+// The userName can't be changed, as it is the user's email.
+func SCIMReplaceUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, id string, req *models.SCIMUser) (*models.SCIMUser, error) {
+	userID, err := scimUserID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetUser(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if email := scimEmail(req); email != "" && !strings.EqualFold(email, user.Email) {
+		return nil, &ValidationError{Field: "userName", Message: "userName can't be changed"}
+	}
+
+	update := models.User{}
+	if name := scimFullName(req); name != "" {
+		update.FullName = derivedFullName(name, user.Email)
+	}
+	if req.Active != nil {
+		update.Status = models.UserStatusActive
+		if !*req.Active {
+			update.Status = models.UserStatusInactive
+		}
+	}
+	return scimUpdateUser(ctx, db, log, userID, update)
+}
+
+// SCIMPatchUser applies a SCIM PATCH to a user. The active state and name can be changed;
+// other attributes identity providers commonly send are ignored.
+func SCIMPatchUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, id string, req *models.SCIMPatchRequest) (*models.SCIMUser, error) {
+	userID, err := scimUserID(id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetUser(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	update, err := parseSCIMUserPatch(req, user, log)
+	if err != nil {
+		return nil, err
+	}
+	return scimUpdateUser(ctx, db, log, userID, update)
+}
+
+// parseSCIMUserPatch reads the update a SCIM PATCH request makes to a user.
+func parseSCIMUserPatch(req *models.SCIMPatchRequest, user *models.User, log *slog.Logger) (models.User, error) {
+	var name models.SCIMName
+	var displayName string
+	update := models.User{}
+	var apply func(attr string, value any) error
+	apply = func(attr string, value any) error {
+		switch strings.ToLower(attr) {
+		case "active":
+			active, err := scimBool(value)
+			if err != nil {
+				return &ValidationError{Field: "active", Message: err.Error()}
+			}
+			update.Status = models.UserStatusActive
+			if !active {
+				update.Status = models.UserStatusInactive
+			}
+		case "displayname":
+			displayName, _ = value.(string)
+		case "name.formatted":
+			name.Formatted, _ = value.(string)
+		case "name.givenname":
+			name.GivenName, _ = value.(string)
+		case "name.familyname":
+			name.FamilyName, _ = value.(string)
+		case "name":
+			fields, ok := value.(map[string]any)
+			if !ok {
+				return &ValidationError{Field: "name", Message: "expected an object"}
+			}
+			for k, v := range fields {
+				if err := apply("name."+k, v); err != nil {
+					return err
+				}
+			}
+		case "username":
+			if s, _ := value.(string); !strings.EqualFold(s, user.Email) {
+				return &ValidationError{Field: "userName", Message: "userName can't be changed"}
+			}
+		default:
+			log.Debug("ignoring unsupported SCIM user attribute", "attribute", attr, "user_id", user.ID)
+		}
+		return nil
+	}
+
+	for _, op := range req.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return models.User{}, &ValidationError{Field: "op", Message: fmt.Sprintf("operation %q is not supported on users", op.Op)}
+		}
+		if op.Path != "" {
+			if err := apply(op.Path, op.Value); err != nil {
+				return models.User{}, err
+			}
+			continue
+		}
+		fields, ok := op.Value.(map[string]any)
+		if !ok {
+			return models.User{}, &ValidationError{Field: "value", Message: "an operation without path needs an object value"}
+		}
+		for k, v := range fields {
+			if err := apply(k, v); err != nil {
+				return models.User{}, err
+			}
+		}
+	}
+
+	if full := scimFullName(&models.SCIMUser{Name: &name, DisplayName: displayName}); full != "" {
+		update.FullName = derivedFullName(full, user.Email)
+	}
+	return update, nil
+}
+
+// SCIMDeactivateUser handles a SCIM DELETE. Users are deactivated rather than deleted so
+// their audit history and saved queries are kept.
+func SCIMDeactivateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, id string) error {
+	userID, err := scimUserID(id)
+	if err != nil {
+		return err
+	}
+	if err := updateUser(ctx, db, log, userID, models.User{Status: models.UserStatusInactive}, true); err != nil {
+		return err
+	}
+	log.Info("user deactivated via SCIM", "user_id", userID)
+	return nil
+}
+
+// scimUpdateUser applies an update and returns the resulting SCIM resource. Deactivation
+// revokes the user's sessions and disables their API tokens, also for provisioned users
+// (see updateUser).
+func scimUpdateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID models.UserID, update models.User) (*models.SCIMUser, error) {
+	if err := updateUser(ctx, db, log, userID, update, true); err != nil {
+		return nil, err
+	}
+	user, err := GetUser(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scimUserResource(ctx, db, user)
+}
+
+// --- Groups ---
+
+// scimTeamID parses a SCIM group id. Malformed ids are reported as not found.
+func scimTeamID(id string) (models.TeamID, error) {
+	teamID, err := ParseTeamID(id)
+	if err != nil {
+		return 0, ErrTeamNotFound
+	}
+	return teamID, nil
+}
+
+// toSCIMGroup converts a team and its members to a SCIM Group. Members are omitted when nil.
+func toSCIMGroup(team *models.Team, members []*models.TeamMember) *models.SCIMGroup {
+	g := &models.SCIMGroup{
+		Schemas:     []string{models.SCIMGroupSchema},
+		ID:          strconv.FormatInt(int64(team.ID), 10),
+		DisplayName: team.Name,
+		Meta: &models.SCIMMeta{
+			ResourceType: "Group",
+			Created:      team.CreatedAt,
+			LastModified: team.UpdatedAt,
+		},
+	}
+	for _, m := range members {
+		g.Members = append(g.Members, models.SCIMMember{Value: strconv.FormatInt(int64(m.UserID), 10), Display: m.Email})
+	}
+	return g
+}
+
+// scimGroupResource loads a team as a SCIM Group, with its members unless excluded.
+func scimGroupResource(ctx context.Context, db *sqlite.DB, team *models.Team, withMembers bool) (*models.SCIMGroup, error) {
+	if !withMembers {
+		return toSCIMGroup(team, nil), nil
+	}
+	members, err := ListTeamMembers(ctx, db, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toSCIMGroup(team, members), nil
+}
+
+// scimMemberIDs reads the users of a members value, a list of {"value": "<id>"} objects,
+// and checks that they exist.
+func scimMemberIDs(ctx context.Context, db *sqlite.DB, value any) ([]models.UserID, error) {
+	ids, err := parseSCIMMemberIDs(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSCIMMembers(ctx, db, ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// checkSCIMMembers checks that the members of a SCIM request exist.
+func checkSCIMMembers(ctx context.Context, db *sqlite.DB, userIDs []models.UserID) error {
+	for _, userID := range userIDs {
+		if _, err := GetUser(ctx, db, userID); err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				return &ValidationError{Field: "members", Message: fmt.Sprintf("unknown member \"%d\"", userID)}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSCIMMemberIDs reads the users of a members value, a list of {"value": "<id>"} objects.
+func parseSCIMMemberIDs(value any) ([]models.UserID, error) {
+	var items []any
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		items = v
+	case map[string]any:
+		items = []any{v}
+	default:
+		return nil, &ValidationError{Field: "members", Message: "expected a list of members"}
+	}
+
+	ids := make([]models.UserID, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, &ValidationError{Field: "members", Message: "expected member objects with a value"}
+		}
+		raw, _ := m["value"].(string)
+		userID, err := ParseUserID(raw)
+		if err != nil {
+			return nil, &ValidationError{Field: "members", Message: fmt.Sprintf("invalid member %q", raw)}
+		}
+		ids = append(ids, userID)
+	}
+	return ids, nil
+}
+
+// scimAddMembers adds users to a team as members. Existing members keep their role.
+func scimAddMembers(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, userIDs []models.UserID) error {
+	for _, userID := range userIDs {
+		member, err := GetTeamMember(ctx, db, teamID, userID)
+		if err != nil {
+			return err
+		}
+		if member != nil {
+			continue
+		}
+		if err := AddTeamMember(ctx, db, log, teamID, userID, models.TeamRoleMember); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scimRemoveMembers removes users from a team.
+func scimRemoveMembers(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, userIDs []models.UserID) error {
+	for _, userID := range userIDs {
+		if err := RemoveTeamMember(ctx, db, log, teamID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scimSetMembers makes the team's members exactly the given users.
+func scimSetMembers(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, userIDs []models.UserID) error {
+	members, err := ListTeamMembers(ctx, db, teamID)
+	if err != nil {
+		return err
+	}
+	var stale []models.UserID
+	for _, m := range members {
+		if !slices.Contains(userIDs, m.UserID) {
+			stale = append(stale, m.UserID)
+		}
+	}
+	if err := scimRemoveMembers(ctx, db, log, teamID, stale); err != nil {
+		return err
+	}
+	return scimAddMembers(ctx, db, log, teamID, userIDs)
+}
+
+// scimRenameTeam renames a team, if the name changed. Its other settings are kept.
+func scimRenameTeam(ctx context.Context, db *sqlite.DB, log *slog.Logger, team *models.Team, name string) error {
+	if name == "" || name == team.Name {
+		return nil
+	}
+	return UpdateTeam(ctx, db, log, team.ID, models.Team{
+		Name:                 name,
+		Description:          team.Description,
+		AllowAnonymousShares: team.AllowAnonymousShares,
+	})
+}
+
+// SCIMListGroups lists teams as SCIM groups. Filtering on displayName is supported.
+func SCIMListGroups(ctx context.Context, db *sqlite.DB, filter string, startIndex, count int, withMembers bool) (*models.SCIMListResponse, error) {
+	attr, value, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if attr != "" && attr != "displayname" {
+		return nil, &ValidationError{Field: "filter", Message: fmt.Sprintf("filtering groups by %q is not supported", attr)}
+	}
+
+	teams, err := ListTeams(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]any, 0, len(teams))
+	for _, team := range teams {
+		if attr != "" && team.Name != value {
+			continue
+		}
+		g, err := scimGroupResource(ctx, db, team, withMembers)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, g)
+	}
+	return scimPage(resources, startIndex, count), nil
+}
+
+// SCIMGetGroup returns a team as a SCIM group.
+func SCIMGetGroup(ctx context.Context, db *sqlite.DB, id string, withMembers bool) (*models.SCIMGroup, error) {
+	teamID, err := scimTeamID(id)
+	if err != nil {
+		return nil, err
+	}
+	team, err := GetTeam(ctx, db, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return scimGroupResource(ctx, db, team, withMembers)
+}
+
+// SCIMCreateGroup creates a team from a SCIM group, with its members.
+func SCIMCreateGroup(ctx context.Context, db *sqlite.DB, log *slog.Logger, req *models.SCIMGroup) (*models.SCIMGroup, error) {
+	memberIDs, err := scimMemberIDs(ctx, db, scimMembersValue(req.Members))
+	if err != nil {
+		return nil, err
+	}
+	team, err := CreateTeam(ctx, db, log, req.DisplayName, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := scimAddMembers(ctx, db, log, team.ID, memberIDs); err != nil {
+		return nil, err
+	}
+	log.Info("team created via SCIM", "team_id", team.ID, "name", team.Name)
+	return scimGroupResource(ctx, db, team, true)
+}
+
+// SCIMReplaceGroup renames a team and replaces its members from a SCIM group.
+func SCIMReplaceGroup(ctx context.Context, db *sqlite.DB, log *slog.Logger, id string, req *models.SCIMGroup) (*models.SCIMGroup, error) {
+	teamID, err := scimTeamID(id)
+	if err != nil {
+		return nil, err
+	}
+	team, err := GetTeam(ctx, db, teamID)
+	if err != nil {
+		return nil, err
+	}
+	memberIDs, err := scimMemberIDs(ctx, db, scimMembersValue(req.Members))
+	if err != nil {
+		return nil, err
+	}
+	if err := scimRenameTeam(ctx, db, log, team, req.DisplayName); err != nil {
+		return nil, err
+	}
+	if err := scimSetMembers(ctx, db, log, teamID, memberIDs); err != nil {
+		return nil, err
+	}
+	return SCIMGetGroup(ctx, db, id, true)
+}
+
+// SCIMPatchGroup applies a SCIM PATCH to a team: adding, removing or replacing members,
+// and renaming it.
+func SCIMPatchGroup(ctx context.Context, db *sqlite.DB, log *slog.Logger, id string, req *models.SCIMPatchRequest) (*models.SCIMGroup, error) {
+	teamID, err := scimTeamID(id)
+	if err != nil {
+		return nil, err
+	}
+	team, err := GetTeam(ctx, db, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := parseSCIMGroupPatch(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.members == "" {
+			if err := scimRenameTeam(ctx, db, log, team, change.name); err != nil {
+				return nil, err
+			}
+			team.Name = change.name
+			continue
+		}
+		if err := checkSCIMMembers(ctx, db, change.userIDs); err != nil {
+			return nil, err
+		}
+		switch change.members {
+		case "add":
+			err = scimAddMembers(ctx, db, log, teamID, change.userIDs)
+		case "remove":
+			err = scimRemoveMembers(ctx, db, log, teamID, change.userIDs)
+		case "replace":
+			err = scimSetMembers(ctx, db, log, teamID, change.userIDs)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return SCIMGetGroup(ctx, db, id, true)
+}
+
+// scimGroupChange is a change to a team read from a SCIM PATCH request: a rename, or adding,
+// removing or replacing members.
+type scimGroupChange struct {
+	name    string          // New name of a rename
+	members string          // "add", "remove" or "replace" for member changes, empty for a rename
+	userIDs []models.UserID // Members to add, remove or set
+}
+
+// parseSCIMGroupPatch reads the changes a SCIM PATCH request makes to a team, in order.
+// Removing members without a value or member filter removes all members.
+func parseSCIMGroupPatch(req *models.SCIMPatchRequest) ([]scimGroupChange, error) {
+	var changes []scimGroupChange
+	for _, op := range req.Operations {
+		kind := strings.ToLower(op.Op)
+		path := strings.TrimSpace(op.Path)
+		value := op.Value
+
+		// Without a path, the value holds the attributes to change.
+		if path == "" {
+			fields, ok := value.(map[string]any)
+			if !ok || kind == "remove" {
+				return nil, &ValidationError{Field: "path", Message: "path is required"}
+			}
+			if name, _ := fields["displayName"].(string); name != "" {
+				changes = append(changes, scimGroupChange{name: name})
+			}
+			members, ok := fields["members"]
+			if !ok {
+				continue
+			}
+			path, value = "members", members
+		}
+
+		if m := scimMemberPathPattern.FindStringSubmatch(path); m != nil && kind == "remove" {
+			path, value = "members", []any{map[string]any{"value": m[1]}}
+		}
+
+		switch {
+		case strings.EqualFold(path, "displayName") && (kind == "add" || kind == "replace"):
+			if name, _ := value.(string); name != "" {
+				changes = append(changes, scimGroupChange{name: name})
+			}
+		case strings.EqualFold(path, "members"):
+			if kind == "remove" && value == nil {
+				changes = append(changes, scimGroupChange{members: "replace"})
+				continue
+			}
+			if kind != "add" && kind != "remove" && kind != "replace" {
+				return nil, &ValidationError{Field: "op", Message: fmt.Sprintf("unknown operation %q", op.Op)}
+			}
+			userIDs, err := parseSCIMMemberIDs(value)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, scimGroupChange{members: kind, userIDs: userIDs})
+		default:
+			return nil, &ValidationError{Field: "path", Message: fmt.Sprintf("unsupported %s of %q on groups", op.Op, path)}
+		}
+	}
+	return changes, nil
+}
+
+// SCIMDeleteGroup deletes a team.
+func SCIMDeleteGroup(ctx context.Context, db *sqlite.DB, log *slog.Logger, id string) error {
+	teamID, err := scimTeamID(id)
+	if err != nil {
+		return err
+	}
+	if err := DeleteTeam(ctx, db, log, teamID); err != nil {
+		return err
+	}
+	log.Info("team deleted via SCIM", "team_id", teamID)
+	return nil
+}
+
+// scimMembersValue converts typed members to the generic form PATCH values arrive in.
+func scimMembersValue(members []models.SCIMMember) any {
+	items := make([]any, 0, len(members))
+	for _, m := range members {
+		items = append(items, map[string]any{"value": m.Value})
+	}
+	return items
+}
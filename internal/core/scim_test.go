@@ -0,0 +1,226 @@
+package core
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// wantValidationError fails unless err is a *ValidationError containing want, or nil when want is empty.
+func wantValidationError(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if _, ok := err.(*ValidationError); !ok || !strings.Contains(err.Error(), want) {
+		t.Fatalf("error %v is not a validation error containing %q", err, want)
+	}
+}
+
+func TestParseSCIMFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    string
+		wantAttr  string
+		wantValue string
+		wantErr   string
+	}{
+		{name: "empty", filter: "  "},
+		{name: "userName", filter: `userName eq "jane@example.com"`, wantAttr: "username", wantValue: "jane@example.com"},
+		{name: "nested attribute", filter: `emails.value eq "jane@example.com"`, wantAttr: "emails.value", wantValue: "jane@example.com"},
+		{name: "case-insensitive operator", filter: ` displayName EQ "Payments" `, wantAttr: "displayname", wantValue: "Payments"},
+		{name: "escaped quotes", filter: `displayName eq "say \"hi\""`, wantAttr: "displayname", wantValue: `say "hi"`},
+		{name: "escaped backslash", filter: `displayName eq "a\\b"`, wantAttr: "displayname", wantValue: `a\b`},
+		{name: "empty value", filter: `userName eq ""`, wantAttr: "username"},
+		{name: "other operator", filter: `userName co "jane"`, wantErr: "only filters of the form"},
+		{name: "unquoted value", filter: `active eq true`, wantErr: "only filters of the form"},
+		{name: "combined filters", filter: `userName eq "a" and active eq "true"`, wantErr: "only filters of the form"},
+		{name: "unterminated value", filter: `userName eq "jane`, wantErr: "only filters of the form"},
+		{name: "invalid escape", filter: `userName eq "\q"`, wantErr: "invalid filter value"},
+		{name: "missing attribute", filter: `eq "jane"`, wantErr: "only filters of the form"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attr, value, err := parseSCIMFilter(tt.filter)
+			wantValidationError(t, err, tt.wantErr)
+			if attr != tt.wantAttr || value != tt.wantValue {
+				t.Errorf("parseSCIMFilter(%q) = %q, %q; want %q, %q", tt.filter, attr, value, tt.wantAttr, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestSCIMPage(t *testing.T) {
+	resources := []any{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name       string
+		startIndex int
+		count      int
+		want       []any
+		wantStart  int
+	}{
+		{name: "first page", startIndex: 1, count: 2, want: []any{"a", "b"}, wantStart: 1},
+		{name: "later page", startIndex: 4, count: 2, want: []any{"d", "e"}, wantStart: 4},
+		{name: "past the end", startIndex: 6, count: 2, want: []any{}, wantStart: 6},
+		{name: "start below one", startIndex: 0, count: 1, want: []any{"a"}, wantStart: 1},
+		{name: "negative count", startIndex: 1, count: -1, want: []any{}, wantStart: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := scimPage(resources, tt.startIndex, tt.count)
+			if !reflect.DeepEqual(page.Resources, tt.want) || page.StartIndex != tt.wantStart ||
+				page.ItemsPerPage != len(tt.want) || page.TotalResults != len(resources) {
+				t.Errorf("scimPage(%d, %d) = %+v", tt.startIndex, tt.count, page)
+			}
+		})
+	}
+	if page := scimPage(make([]any, maxSCIMPageSize+1), 1, maxSCIMPageSize+1); page.ItemsPerPage != maxSCIMPageSize {
+		t.Errorf("page size %d, want it capped at %d", page.ItemsPerPage, maxSCIMPageSize)
+	}
+}
+
+func TestParseSCIMUserPatch(t *testing.T) {
+	user := &models.User{ID: 1, Email: "jane@example.com", FullName: "Jane Doe"}
+	op := func(kind, path string, value any) models.SCIMPatchOperation {
+		return models.SCIMPatchOperation{Op: kind, Path: path, Value: value}
+	}
+	tests := []struct {
+		name    string
+		ops     []models.SCIMPatchOperation
+		want    models.User
+		wantErr string
+	}{
+		{name: "deactivate by path", ops: []models.SCIMPatchOperation{op("replace", "active", false)}, want: models.User{Status: models.UserStatusInactive}},
+		{name: "activate with string value", ops: []models.SCIMPatchOperation{op("Replace", "active", "True")}, want: models.User{Status: models.UserStatusActive}},
+		{name: "deactivate without path", ops: []models.SCIMPatchOperation{op("replace", "", map[string]any{"active": false})}, want: models.User{Status: models.UserStatusInactive}},
+		{name: "formatted name", ops: []models.SCIMPatchOperation{op("replace", "name.formatted", "Jane Smith")}, want: models.User{FullName: "Jane Smith"}},
+		{name: "given and family name", ops: []models.SCIMPatchOperation{
+			op("replace", "name.givenName", "Jane"),
+			op("add", "name.familyName", "Smith"),
+		}, want: models.User{FullName: "Jane Smith"}},
+		{name: "name object", ops: []models.SCIMPatchOperation{op("replace", "name", map[string]any{"givenName": "Jane", "familyName": "Roe"})}, want: models.User{FullName: "Jane Roe"}},
+		{name: "display name", ops: []models.SCIMPatchOperation{op("replace", "displayName", "J. Smith")}, want: models.User{FullName: "J Smith"}},
+		{name: "formatted name wins over display name", ops: []models.SCIMPatchOperation{
+			op("replace", "", map[string]any{"displayName": "Display", "name": map[string]any{"formatted": "Formatted Name"}}),
+		}, want: models.User{FullName: "Formatted Name"}},
+		{name: "given name alone falls back to display name", ops: []models.SCIMPatchOperation{
+			op("replace", "name.givenName", "Jane"),
+			op("replace", "displayName", "Jane Q"),
+		}, want: models.User{FullName: "Jane Q"}},
+		{name: "unchanged userName", ops: []models.SCIMPatchOperation{op("replace", "userName", "JANE@example.com")}},
+		{name: "unsupported attributes are ignored", ops: []models.SCIMPatchOperation{op("replace", "title", "Engineer"), op("add", "", map[string]any{"locale": "en"})}},
+		{name: "changed userName", ops: []models.SCIMPatchOperation{op("replace", "userName", "john@example.com")}, wantErr: "userName can't be changed"},
+		{name: "remove", ops: []models.SCIMPatchOperation{op("remove", "active", nil)}, wantErr: `operation "remove" is not supported`},
+		{name: "invalid active", ops: []models.SCIMPatchOperation{op("replace", "active", "maybe")}, wantErr: "invalid syntax"},
+		{name: "active of wrong type", ops: []models.SCIMPatchOperation{op("replace", "active", 1.0)}, wantErr: "expected a boolean"},
+		{name: "name not an object", ops: []models.SCIMPatchOperation{op("replace", "name", "Jane")}, wantErr: "expected an object"},
+		{name: "no path without object", ops: []models.SCIMPatchOperation{op("replace", "", false)}, wantErr: "needs an object value"},
+	}
+	log := slog.New(slog.DiscardHandler)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSCIMUserPatch(&models.SCIMPatchRequest{Operations: tt.ops}, user, log)
+			wantValidationError(t, err, tt.wantErr)
+			if got != tt.want {
+				t.Errorf("update = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSCIMGroupPatch(t *testing.T) {
+	members := func(ids ...string) []any {
+		items := make([]any, len(ids))
+		for i, id := range ids {
+			items[i] = map[string]any{"value": id}
+		}
+		return items
+	}
+	tests := []struct {
+		name    string
+		ops     []models.SCIMPatchOperation
+		want    []scimGroupChange
+		wantErr string
+	}{
+		{
+			name: "add members",
+			ops:  []models.SCIMPatchOperation{{Op: "Add", Path: "members", Value: members("1", "2")}},
+			want: []scimGroupChange{{members: "add", userIDs: []models.UserID{1, 2}}},
+		},
+		{
+			name: "add a single member object",
+			ops:  []models.SCIMPatchOperation{{Op: "add", Path: "members", Value: map[string]any{"value": "3"}}},
+			want: []scimGroupChange{{members: "add", userIDs: []models.UserID{3}}},
+		},
+		{
+			name: "remove members by value",
+			ops:  []models.SCIMPatchOperation{{Op: "remove", Path: "members", Value: members("2")}},
+			want: []scimGroupChange{{members: "remove", userIDs: []models.UserID{2}}},
+		},
+		{
+			name: "remove a member by filter",
+			ops:  []models.SCIMPatchOperation{{Op: "Remove", Path: `members[value eq "7"]`}},
+			want: []scimGroupChange{{members: "remove", userIDs: []models.UserID{7}}},
+		},
+		{
+			name: "remove all members",
+			ops:  []models.SCIMPatchOperation{{Op: "remove", Path: "members"}},
+			want: []scimGroupChange{{members: "replace"}},
+		},
+		{
+			name: "replace members",
+			ops:  []models.SCIMPatchOperation{{Op: "replace", Path: "members", Value: members("4")}},
+			want: []scimGroupChange{{members: "replace", userIDs: []models.UserID{4}}},
+		},
+		{
+			name: "rename by path",
+			ops:  []models.SCIMPatchOperation{{Op: "replace", Path: "displayName", Value: "Payments"}},
+			want: []scimGroupChange{{name: "Payments"}},
+		},
+		{
+			name: "rename and members without path",
+			ops:  []models.SCIMPatchOperation{{Op: "replace", Value: map[string]any{"displayName": "Billing", "members": members("5")}}},
+			want: []scimGroupChange{{name: "Billing"}, {members: "replace", userIDs: []models.UserID{5}}},
+		},
+		{
+			name: "empty name is ignored",
+			ops:  []models.SCIMPatchOperation{{Op: "replace", Path: "displayName", Value: ""}},
+		},
+		{
+			name: "operations keep their order",
+			ops: []models.SCIMPatchOperation{
+				{Op: "remove", Path: "members"},
+				{Op: "add", Path: "members", Value: members("1")},
+			},
+			want: []scimGroupChange{{members: "replace"}, {members: "add", userIDs: []models.UserID{1}}},
+		},
+		{name: "remove without path", ops: []models.SCIMPatchOperation{{Op: "remove", Value: map[string]any{"members": members("1")}}}, wantErr: "path is required"},
+		{name: "no path without object", ops: []models.SCIMPatchOperation{{Op: "add", Value: members("1")}}, wantErr: "path is required"},
+		{name: "remove name", ops: []models.SCIMPatchOperation{{Op: "remove", Path: "displayName"}}, wantErr: `unsupported remove of "displayName"`},
+		{name: "unsupported path", ops: []models.SCIMPatchOperation{{Op: "replace", Path: "externalId", Value: "x"}}, wantErr: `unsupported replace of "externalId"`},
+		{name: "unknown operation", ops: []models.SCIMPatchOperation{{Op: "move", Path: "members", Value: members("1")}}, wantErr: `unknown operation "move"`},
+		{name: "invalid member id", ops: []models.SCIMPatchOperation{{Op: "add", Path: "members", Value: members("abc")}}, wantErr: `invalid member "abc"`},
+		{name: "member without value", ops: []models.SCIMPatchOperation{{Op: "add", Path: "members", Value: []any{"1"}}}, wantErr: "expected member objects"},
+		{name: "members of wrong type", ops: []models.SCIMPatchOperation{{Op: "add", Path: "members", Value: "1"}}, wantErr: "expected a list of members"},
+		{
+			name:    "a bad operation rejects the whole request",
+			ops:     []models.SCIMPatchOperation{{Op: "add", Path: "members", Value: members("1")}, {Op: "add", Path: "members", Value: members("x")}},
+			wantErr: `invalid member "x"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSCIMGroupPatch(&models.SCIMPatchRequest{Operations: tt.ops})
+			wantValidationError(t, err, tt.wantErr)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
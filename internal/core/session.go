@@ -124,3 +124,16 @@ func RevokeUserSessions(ctx context.Context, db *sqlite.DB, log *slog.Logger, us
 	log.Info("all sessions revoked for user", "user_id", userID)
 	return nil
 }
+
+// CleanupExpiredSessions deletes all sessions past their expiry.
+func CleanupExpiredSessions(ctx context.Context, db *sqlite.DB, log *slog.Logger) error {
+	deleted, err := db.DeleteExpiredSessions(ctx, time.Now())
+	if err != nil {
+		log.Error("failed to cleanup expired sessions", "error", err)
+		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
+	}
+	if deleted > 0 {
+		log.Info("expired sessions cleaned up", "deleted", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,556 @@
+package core
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+const (
+	// DefaultShareLinkTTL is the lifetime of a share link when none is given.
+	DefaultShareLinkTTL = 7 * 24 * time.Hour
+	// MaxShareLinkTTL is the longest a share link can stay valid.
+	MaxShareLinkTTL = 90 * 24 * time.Hour
+	// DefaultShareLinkRows is the row limit of a shared query when none is given.
+	DefaultShareLinkRows = 100
+	// MaxShareLinkRows is the maximum number of rows a share link can show.
+	MaxShareLinkRows = 1000
+	// MinSharePasswordLength is the minimum length of a share link password.
+	MinSharePasswordLength = 8
+	// MaxSharePasswordFailures is the number of wrong passwords a client may try on a share
+	// link within SharePasswordFailureWindow before its attempts are refused.
+	MaxSharePasswordFailures = 5
+	// SharePasswordFailureWindow is how long wrong share link passwords are counted.
+	SharePasswordFailureWindow = 15 * time.Minute
+
+	shareSlugBytes        = 16
+	sharePasswordIter     = 100_000
+	sharePasswordSaltSize = 16
+	sharePasswordKeySize  = 32
+	sharePasswordScheme   = "pbkdf2-sha256"
+)
+
+var (
+	// ErrShareLinkNotFound is returned when a share link doesn't exist.
+	ErrShareLinkNotFound = errors.New("share link not found")
+	// ErrShareLinkExpired is returned when opening a share link past its expiry.
+	ErrShareLinkExpired = errors.New("share link has expired")
+	// ErrShareLinkRevoked is returned when opening a revoked share link.
+	ErrShareLinkRevoked = errors.New("share link has been revoked")
+	// ErrSharePasswordRequired is returned when a password protected share link is opened without one.
+	ErrSharePasswordRequired = errors.New("share link requires a password")
+	// ErrSharePasswordInvalid is returned when a share link is opened with the wrong password.
+	ErrSharePasswordInvalid = errors.New("invalid share link password")
+	// ErrShareLoginRequired is returned when an anonymous viewer opens a link that needs a login.
+	ErrShareLoginRequired = errors.New("share link requires login")
+	// ErrShareAccessRevoked is returned when the creator of a live share link lost access to its source.
+	ErrShareAccessRevoked = errors.New("share link creator no longer has access to this source")
+	// ErrShareLinkForbidden is returned when a user may not manage a share link.
+	ErrShareLinkForbidden = errors.New("not allowed to manage this share link")
+)
+
+// SharePasswordAttemptsError is returned when a client tried too many wrong passwords on a
+// share link.
+type SharePasswordAttemptsError struct {
+	RetryAfter time.Duration // Until the oldest counted failure expires
+}
+
+func (e *SharePasswordAttemptsError) Error() string {
+	return "too many wrong share link passwords"
+}
+
+// sharePasswordKey identifies the password attempts of a client on a share link.
+type sharePasswordKey struct {
+	linkID int
+	client string
+}
+
+// SharePasswordLimiter counts wrong share link passwords per link and client address, and
+// refuses further attempts once MaxSharePasswordFailures were made within
+// SharePasswordFailureWindow. A nil limiter limits nothing.
+type SharePasswordLimiter struct {
+	mu       sync.Mutex
+	failures map[sharePasswordKey][]time.Time // Times of the counted failures, oldest first
+}
+
+// NewSharePasswordLimiter creates a share link password attempt limiter.
+func NewSharePasswordLimiter() *SharePasswordLimiter {
+	return &SharePasswordLimiter{failures: make(map[sharePasswordKey][]time.Time)}
+}
+
+// check returns a *SharePasswordAttemptsError when the client may not try another password at now.
+func (l *SharePasswordLimiter) check(key sharePasswordKey, now time.Time) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	failures := l.recentLocked(key, now)
+	if len(failures) < MaxSharePasswordFailures {
+		return nil
+	}
+	return &SharePasswordAttemptsError{RetryAfter: failures[0].Add(SharePasswordFailureWindow).Sub(now)}
+}
+
+// fail counts a wrong password at now.
+func (l *SharePasswordLimiter) fail(key sharePasswordKey, now time.Time) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop the clients whose failures all expired, so the map doesn't grow without bound.
+	for other := range l.failures {
+		if other != key {
+			l.recentLocked(other, now)
+		}
+	}
+	l.failures[key] = append(l.recentLocked(key, now), now)
+}
+
+// reset forgets the failures of a client that opened the link with the right password.
+func (l *SharePasswordLimiter) reset(key sharePasswordKey) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, key)
+}
+
+// recentLocked drops the expired failures of key and returns the remaining ones.
+func (l *SharePasswordLimiter) recentLocked(key sharePasswordKey, now time.Time) []time.Time {
+	failures := l.failures[key]
+	i := 0
+	for i < len(failures) && !now.Before(failures[i].Add(SharePasswordFailureWindow)) {
+		i++
+	}
+	if i == len(failures) {
+		delete(l.failures, key)
+		return nil
+	}
+	failures = failures[i:]
+	l.failures[key] = failures
+	return failures
+}
+
+// CreateShareLink creates a permalink to a query result of a team's source. Snapshot links
+// capture the result now; live links re-run the query whenever they're opened. acquire, if set,
+// admits the snapshot's query under the query limits.
+func CreateShareLink(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, creator models.UserID, req models.CreateShareLinkRequest, acquire AcquireQueryFunc) (*models.ShareLink, error) {
+	link, err := newShareLink(teamID, sourceID, creator, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.AllowAnonymous {
+		team, err := GetTeam(ctx, db, teamID)
+		if err != nil {
+			return nil, err
+		}
+		if !team.AllowAnonymousShares {
+			return nil, &ValidationError{Field: "allow_anonymous", Message: "this team doesn't allow anonymous share links"}
+		}
+	}
+
+	rawSQL, params, err := renderShareQuery(ctx, db, link)
+	if err != nil {
+		return nil, err
+	}
+
+	switch link.Mode {
+	case models.ShareLinkSnapshot:
+		queryCtx, release, err := acquireQuery(ctx, acquire, teamID, sourceID, models.QueryAuditTypeShare, rawSQL)
+		if err != nil {
+			return nil, err
+		}
+		result, err := QueryLogs(queryCtx, db, chDB, cache, log, teamID, sourceID, clickhouse.LogQueryParams{
+			RawSQL:       rawSQL,
+			Limit:        link.Limit,
+			QueryTimeout: req.QueryTimeout,
+			Parameters:   params,
+		})
+		release()
+		if err != nil {
+			return nil, err
+		}
+		snapshot, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding share link snapshot: %w", err)
+		}
+		link.Snapshot = string(snapshot)
+	case models.ShareLinkLive:
+		if err := ValidateTeamQuery(ctx, db, chDB, teamID, sourceID, rawSQL); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Password != "" {
+		if link.PasswordHash, err = hashSharePassword(req.Password); err != nil {
+			return nil, err
+		}
+		link.HasPassword = true
+	}
+
+	if err := db.CreateShareLink(ctx, link); err != nil {
+		return nil, fmt.Errorf("error creating share link: %w", err)
+	}
+
+	log.Info("share link created", "share_link_id", link.ID, "team_id", teamID, "source_id", sourceID, "mode", link.Mode, "expires_at", link.ExpiresAt)
+	return db.GetShareLinkBySlug(ctx, link.Slug)
+}
+
+// newShareLink validates a share request and builds the link, without its snapshot or password.
+func newShareLink(teamID models.TeamID, sourceID models.SourceID, creator models.UserID, req models.CreateShareLinkRequest) (*models.ShareLink, error) {
+	link := &models.ShareLink{
+		TeamID:         teamID,
+		SourceID:       sourceID,
+		CreatedBy:      creator,
+		Title:          strings.TrimSpace(req.Title),
+		Mode:           req.Mode,
+		RawSQL:         strings.TrimSpace(req.RawSQL),
+		Limit:          req.Limit,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		AllowAnonymous: req.AllowAnonymous,
+	}
+
+	if link.RawSQL == "" {
+		return nil, &ValidationError{Field: "raw_sql", Message: "query is required"}
+	}
+	if len(link.Title) > 200 {
+		return nil, &ValidationError{Field: "title", Message: "title cannot exceed 200 characters"}
+	}
+	switch link.Mode {
+	case "":
+		link.Mode = models.ShareLinkSnapshot
+	case models.ShareLinkSnapshot, models.ShareLinkLive:
+	default:
+		return nil, &ValidationError{Field: "mode", Message: "mode must be one of: snapshot, live"}
+	}
+	if link.Limit <= 0 {
+		link.Limit = DefaultShareLinkRows
+	}
+	if link.Limit > MaxShareLinkRows {
+		return nil, &ValidationError{Field: "limit", Message: fmt.Sprintf("a share link can show at most %d rows", MaxShareLinkRows)}
+	}
+	if (link.StartTime == nil) != (link.EndTime == nil) {
+		return nil, &ValidationError{Field: "end_time", Message: "start time and end time must be set together"}
+	}
+	if link.StartTime != nil && !link.EndTime.After(*link.StartTime) {
+		return nil, &ValidationError{Field: "end_time", Message: "end time must be after start time"}
+	}
+	if err := models.ValidateQueryTimeout(req.QueryTimeout); err != nil {
+		return nil, &ValidationError{Field: "query_timeout", Message: err.Error()}
+	}
+
+	ttl := DefaultShareLinkTTL
+	if req.ExpiresInHours < 0 {
+		return nil, &ValidationError{Field: "expires_in_hours", Message: "expiry must be positive"}
+	}
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl > MaxShareLinkTTL {
+		return nil, &ValidationError{Field: "expires_in_hours", Message: fmt.Sprintf("a share link can be valid for at most %d hours", int(MaxShareLinkTTL.Hours()))}
+	}
+	link.ExpiresAt = time.Now().Add(ttl).UTC()
+
+	if req.Password != "" && len(req.Password) < MinSharePasswordLength {
+		return nil, &ValidationError{Field: "password", Message: fmt.Sprintf("password must be at least %d characters", MinSharePasswordLength)}
+	}
+
+	slug, err := generateShareSlug()
+	if err != nil {
+		return nil, err
+	}
+	link.Slug = slug
+	return link, nil
+}
+
+// OpenShareLink returns the result of a share link for a viewer, which is nil for anonymous
+// viewers. Live links are re-run with the creator's current team permissions and fill in
+// the context's audit entry, after acquire, if set, admitted their query under the query limits.
+// Wrong passwords are counted by limiter per link and client address. Each successful open
+// counts as a view.
+func OpenShareLink(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, limiter *SharePasswordLimiter, log *slog.Logger, slug string, viewer *models.User, password, client string, acquire AcquireQueryFunc) (*models.SharedQueryResult, error) {
+	link, err := getShareLink(ctx, db, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key := sharePasswordKey{linkID: link.ID, client: client}
+	if link.PasswordHash != "" && password != "" {
+		if err := limiter.check(key, now); err != nil {
+			log.Warn("share link password attempts refused", "share_link_id", link.ID, "client", client)
+			return nil, err
+		}
+	}
+	if err := checkShareLinkAccess(link, viewer, password, now); err != nil {
+		if errors.Is(err, ErrSharePasswordInvalid) {
+			limiter.fail(key, now)
+		}
+		return nil, err
+	}
+	if link.PasswordHash != "" {
+		limiter.reset(key)
+	}
+
+	shared := &models.SharedQueryResult{Share: link}
+	switch link.Mode {
+	case models.ShareLinkLive:
+		if err := checkShareCreatorAccess(ctx, db, link); err != nil {
+			return nil, err
+		}
+		rawSQL, params, err := renderShareQuery(ctx, db, link)
+		if err != nil {
+			return nil, err
+		}
+		queryCtx, release, err := acquireQuery(ctx, acquire, link.TeamID, link.SourceID, models.QueryAuditTypeShare, rawSQL)
+		if err != nil {
+			return nil, err
+		}
+		setAuditShareQuery(ctx, link)
+		shared.ExecutedAt = time.Now().UTC()
+		shared.Result, err = QueryLogs(queryCtx, db, chDB, cache, log, link.TeamID, link.SourceID, clickhouse.LogQueryParams{
+			RawSQL:     rawSQL,
+			Limit:      link.Limit,
+			Parameters: params,
+		})
+		release()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		shared.ExecutedAt = link.CreatedAt
+		if err := json.Unmarshal([]byte(link.Snapshot), &shared.Result); err != nil {
+			return nil, fmt.Errorf("error decoding share link snapshot: %w", err)
+		}
+	}
+
+	if err := db.RecordShareLinkView(ctx, link.ID); err != nil {
+		log.Warn("failed to record share link view", "error", err, "share_link_id", link.ID)
+	} else {
+		link.ViewCount++
+	}
+	return shared, nil
+}
+
+// checkShareLinkAccess checks that a share link can be opened at now by a viewer, which is nil
+// for anonymous viewers, with the given password.
+func checkShareLinkAccess(link *models.ShareLink, viewer *models.User, password string, now time.Time) error {
+	if link.RevokedAt != nil {
+		return ErrShareLinkRevoked
+	}
+	if !now.Before(link.ExpiresAt) {
+		return ErrShareLinkExpired
+	}
+	// The team setting is checked on every open, so turning it off closes existing anonymous links.
+	if viewer == nil && !(link.AllowAnonymous && link.TeamAllowAnonymous) {
+		return ErrShareLoginRequired
+	}
+	if link.PasswordHash != "" {
+		if password == "" {
+			return ErrSharePasswordRequired
+		}
+		if !checkSharePassword(link.PasswordHash, password) {
+			return ErrSharePasswordInvalid
+		}
+	}
+	return nil
+}
+
+// renderShareQuery binds the time range references of a share link's query to the link's time
+// range. Queries of links without a time range run as they are.
+func renderShareQuery(ctx context.Context, db *sqlite.DB, link *models.ShareLink) (string, map[string]string, error) {
+	if link.StartTime == nil || link.EndTime == nil {
+		return link.RawSQL, nil, nil
+	}
+	source, err := db.GetSource(ctx, link.SourceID)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) || sqlite.IsSourceNotFoundError(err) {
+			return "", nil, ErrSourceNotFound
+		}
+		return "", nil, fmt.Errorf("error getting source details: %w", err)
+	}
+	return bindShareTimeRange(link.RawSQL, *link.StartTime, *link.EndTime, source.MetaTSField)
+}
+
+// bindShareTimeRange renders the {{time_range}}, {{time_range.start}} and {{time_range.end}}
+// references of a shared query as parameters bound to start and end. tsField is the source's
+// timestamp column. The query must reference the range, or it would silently be ignored.
+func bindShareTimeRange(rawSQL string, start, end time.Time, tsField string) (string, map[string]string, error) {
+	content := &models.SavedQueryContent{
+		Content: rawSQL,
+		TimeRange: models.SavedQueryTimeRange{
+			Absolute: &models.SavedQueryAbsoluteRange{Start: start.UnixMilli(), End: end.UnixMilli()},
+		},
+	}
+	sql, params, err := RenderSavedQuery(content, nil, tsField, end)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(params) == 0 {
+		return "", nil, &ValidationError{Field: "raw_sql", Message: "query must reference its time range as {{time_range}}, {{time_range.start}} or {{time_range.end}}"}
+	}
+	return sql, params, nil
+}
+
+// GetShareLink returns a share link's details by its slug, without its result.
+func GetShareLink(ctx context.Context, db *sqlite.DB, slug string) (*models.ShareLink, error) {
+	return getShareLink(ctx, db, slug)
+}
+
+// getShareLink loads a share link, mapping a missing link to ErrShareLinkNotFound.
+func getShareLink(ctx context.Context, db *sqlite.DB, slug string) (*models.ShareLink, error) {
+	link, err := db.GetShareLinkBySlug(ctx, slug)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("error getting share link: %w", err)
+	}
+	return link, nil
+}
+
+// checkShareCreatorAccess checks that the creator of a live link can still query its source
+// through the team, so revoking their access also stops the link.
+func checkShareCreatorAccess(ctx context.Context, db *sqlite.DB, link *models.ShareLink) error {
+	creator, err := db.GetUser(ctx, link.CreatedBy)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) || sqlite.IsUserNotFoundError(err) {
+			return ErrShareAccessRevoked
+		}
+		return fmt.Errorf("error getting share link creator: %w", err)
+	}
+	if creator.Status != models.UserStatusActive {
+		return ErrShareAccessRevoked
+	}
+
+	member, err := db.GetTeamMember(ctx, link.TeamID, link.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("error checking share link creator membership: %w", err)
+	}
+	if member == nil {
+		return ErrShareAccessRevoked
+	}
+
+	hasSource, err := db.TeamHasSource(ctx, link.TeamID, link.SourceID)
+	if err != nil {
+		return fmt.Errorf("error checking team source access: %w", err)
+	}
+	if !hasSource {
+		return ErrShareAccessRevoked
+	}
+	return nil
+}
+
+// ListUserShareLinks returns the share links created by a user.
+func ListUserShareLinks(ctx context.Context, db *sqlite.DB, userID models.UserID) ([]*models.ShareLink, error) {
+	return db.ListUserShareLinks(ctx, userID)
+}
+
+// ListTeamShareLinks returns the share links of a team.
+func ListTeamShareLinks(ctx context.Context, db *sqlite.DB, teamID models.TeamID) ([]*models.ShareLink, error) {
+	return db.ListTeamShareLinks(ctx, teamID)
+}
+
+// RevokeShareLink revokes a share link. Links can be revoked by their creator, an admin
+// of their team or a global admin.
+func RevokeShareLink(ctx context.Context, db *sqlite.DB, log *slog.Logger, slug string, user *models.User) error {
+	link, err := getShareLink(ctx, db, slug)
+	if err != nil {
+		return err
+	}
+
+	if link.CreatedBy != user.ID && user.Role != models.UserRoleAdmin {
+		member, err := db.GetTeamMember(ctx, link.TeamID, user.ID)
+		if err != nil {
+			return fmt.Errorf("error checking team membership: %w", err)
+		}
+		if member == nil || member.Role != models.TeamRoleAdmin {
+			return ErrShareLinkForbidden
+		}
+	}
+
+	if err := db.RevokeShareLink(ctx, link.ID); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return ErrShareLinkRevoked
+		}
+		return err
+	}
+
+	log.Info("share link revoked", "share_link_id", link.ID, "team_id", link.TeamID, "revoked_by", user.ID)
+	return nil
+}
+
+// generateShareSlug returns a random, URL safe share link identifier.
+func generateShareSlug() (string, error) {
+	b := make([]byte, shareSlugBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate share link slug: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSharePassword derives a salted PBKDF2 hash of a share link password, encoded as
+// "pbkdf2-sha256$<iterations>$<salt>$<hash>".
+func hashSharePassword(password string) (string, error) {
+	salt := make([]byte, sharePasswordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate password salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, sharePasswordIter, sharePasswordKeySize)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash share link password: %w", err)
+	}
+	return strings.Join([]string{
+		sharePasswordScheme,
+		strconv.Itoa(sharePasswordIter),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// checkSharePassword reports whether a password matches a hash from hashSharePassword.
+func checkSharePassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != sharePasswordScheme {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
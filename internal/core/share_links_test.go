@@ -0,0 +1,210 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+func TestSharePassword(t *testing.T) {
+	hash, err := hashSharePassword("correct horse")
+	if err != nil {
+		t.Fatalf("hashSharePassword: %v", err)
+	}
+	if !strings.HasPrefix(hash, sharePasswordScheme+"$") || strings.Contains(hash, "correct horse") {
+		t.Fatalf("unexpected hash encoding %q", hash)
+	}
+	other, err := hashSharePassword("correct horse")
+	if err != nil {
+		t.Fatalf("hashSharePassword: %v", err)
+	}
+	if hash == other {
+		t.Error("hashes of the same password should use different salts")
+	}
+
+	parts := strings.Split(hash, "$")
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+	}{
+		{name: "correct password", hash: hash, password: "correct horse", want: true},
+		{name: "other salt", hash: other, password: "correct horse", want: true},
+		{name: "wrong password", hash: hash, password: "correct horsE"},
+		{name: "empty password", hash: hash},
+		{name: "other scheme", hash: strings.Join(append([]string{"md5"}, parts[1:]...), "$"), password: "correct horse"},
+		{name: "missing part", hash: strings.Join(parts[:3], "$"), password: "correct horse"},
+		{name: "changed iterations", hash: strings.Join([]string{parts[0], "1", parts[2], parts[3]}, "$"), password: "correct horse"},
+		{name: "invalid iterations", hash: strings.Join([]string{parts[0], "0", parts[2], parts[3]}, "$"), password: "correct horse"},
+		{name: "invalid salt", hash: strings.Join([]string{parts[0], parts[1], "!!", parts[3]}, "$"), password: "correct horse"},
+		{name: "empty hash", hash: "", password: "correct horse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkSharePassword(tt.hash, tt.password); got != tt.want {
+				t.Errorf("checkSharePassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckShareLinkAccess(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	hash, err := hashSharePassword("s3cret-pass")
+	if err != nil {
+		t.Fatalf("hashSharePassword: %v", err)
+	}
+	viewer := &models.User{ID: 2, Email: "viewer@example.com"}
+	revokedAt := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		link     models.ShareLink
+		viewer   *models.User
+		password string
+		wantErr  error
+	}{
+		{name: "logged in viewer", link: models.ShareLink{}, viewer: viewer},
+		{name: "anonymous without anonymous access", link: models.ShareLink{}, wantErr: ErrShareLoginRequired},
+		{name: "anonymous allowed by link and team", link: models.ShareLink{AllowAnonymous: true, TeamAllowAnonymous: true}},
+		{name: "anonymous after team disabled it", link: models.ShareLink{AllowAnonymous: true}, wantErr: ErrShareLoginRequired},
+		{name: "anonymous allowed by team only", link: models.ShareLink{TeamAllowAnonymous: true}, wantErr: ErrShareLoginRequired},
+		{name: "expired", link: models.ShareLink{ExpiresAt: now}, viewer: viewer, wantErr: ErrShareLinkExpired},
+		{name: "revoked", link: models.ShareLink{RevokedAt: &revokedAt}, viewer: viewer, wantErr: ErrShareLinkRevoked},
+		{name: "revoked before login check", link: models.ShareLink{RevokedAt: &revokedAt}, wantErr: ErrShareLinkRevoked},
+		{name: "login checked before password", link: models.ShareLink{PasswordHash: hash}, wantErr: ErrShareLoginRequired},
+		{name: "password required", link: models.ShareLink{PasswordHash: hash}, viewer: viewer, wantErr: ErrSharePasswordRequired},
+		{name: "wrong password", link: models.ShareLink{PasswordHash: hash}, viewer: viewer, password: "guess-1234", wantErr: ErrSharePasswordInvalid},
+		{name: "correct password", link: models.ShareLink{PasswordHash: hash}, viewer: viewer, password: "s3cret-pass"},
+		{name: "anonymous with password", link: models.ShareLink{PasswordHash: hash, AllowAnonymous: true, TeamAllowAnonymous: true}, password: "s3cret-pass"},
+		{name: "anonymous without password", link: models.ShareLink{PasswordHash: hash, AllowAnonymous: true, TeamAllowAnonymous: true}, wantErr: ErrSharePasswordRequired},
+		{name: "password ignored without hash", link: models.ShareLink{}, viewer: viewer, password: "anything"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := tt.link
+			if link.ExpiresAt.IsZero() {
+				link.ExpiresAt = now.Add(time.Hour)
+			}
+			if err := checkShareLinkAccess(&link, tt.viewer, tt.password, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkShareLinkAccess() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSharePasswordLimiter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	key := sharePasswordKey{linkID: 1, client: "192.0.2.1"}
+	otherClient := sharePasswordKey{linkID: 1, client: "192.0.2.2"}
+	otherLink := sharePasswordKey{linkID: 2, client: "192.0.2.1"}
+
+	l := NewSharePasswordLimiter()
+	for i := range MaxSharePasswordFailures {
+		if err := l.check(key, now); err != nil {
+			t.Fatalf("attempt %d refused: %v", i+1, err)
+		}
+		l.fail(key, now.Add(time.Duration(i)*time.Minute))
+	}
+
+	var attemptsErr *SharePasswordAttemptsError
+	if err := l.check(key, now.Add(10*time.Minute)); !errors.As(err, &attemptsErr) {
+		t.Fatalf("check after %d failures = %v, want a SharePasswordAttemptsError", MaxSharePasswordFailures, err)
+	}
+	if attemptsErr.RetryAfter != 5*time.Minute {
+		t.Errorf("RetryAfter = %v, want until the first failure expires", attemptsErr.RetryAfter)
+	}
+	if err := l.check(otherClient, now); err != nil {
+		t.Errorf("other client refused: %v", err)
+	}
+	if err := l.check(otherLink, now); err != nil {
+		t.Errorf("other link refused: %v", err)
+	}
+	if err := l.check(key, now.Add(SharePasswordFailureWindow)); err != nil {
+		t.Errorf("refused after the first failure expired: %v", err)
+	}
+
+	l.fail(otherClient, now)
+	l.fail(otherLink, now.Add(SharePasswordFailureWindow+5*time.Minute))
+	if _, ok := l.failures[key]; ok {
+		t.Error("expired failures of a client were kept")
+	}
+	if _, ok := l.failures[otherClient]; ok {
+		t.Error("expired failures of another client were kept")
+	}
+
+	l.reset(otherLink)
+	if err := l.check(otherLink, now); err != nil || len(l.failures) != 0 {
+		t.Errorf("reset kept failures: %v, %v", err, l.failures)
+	}
+
+	var nilLimiter *SharePasswordLimiter
+	nilLimiter.fail(key, now)
+	if err := nilLimiter.check(key, now); err != nil {
+		t.Errorf("nil limiter refused an attempt: %v", err)
+	}
+}
+
+func TestBindShareTimeRange(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	const rangeType = "DateTime64(3, 'UTC')"
+	wantParams := map[string]string{
+		"var_time_range_start": "2025-03-01 10:00:00.000",
+		"var_time_range_end":   "2025-03-01 12:00:00.000",
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		wantSQL string
+		wantErr string
+	}{
+		{
+			name:    "bare reference",
+			query:   "SELECT * FROM logs WHERE {{time_range}}",
+			wantSQL: "SELECT * FROM logs WHERE `timestamp` BETWEEN {var_time_range_start:" + rangeType + "} AND {var_time_range_end:" + rangeType + "}",
+		},
+		{
+			name:    "bounds",
+			query:   "SELECT * FROM logs WHERE t >= {{time_range.start}} AND t < {{time_range.end}}",
+			wantSQL: "SELECT * FROM logs WHERE t >= {var_time_range_start:" + rangeType + "} AND t < {var_time_range_end:" + rangeType + "}",
+		},
+		{
+			name:    "range not referenced",
+			query:   "SELECT * FROM logs WHERE timestamp > now() - INTERVAL 1 HOUR",
+			wantErr: "query must reference its time range",
+		},
+		{
+			name:    "other variables",
+			query:   "SELECT * FROM logs WHERE {{time_range}} AND service = {{service}}",
+			wantErr: `undeclared variable "service"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, params, err := bindShareTimeRange(tt.query, start, end, "timestamp")
+			if tt.wantErr != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v is not a validation error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql:\n got: %s\nwant: %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(params, wantParams) {
+				t.Errorf("params = %v, want %v", params, wantParams)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -68,6 +70,49 @@ func validateSourceCreation(name string, conn models.ConnectionInfo, description
 	return nil
 }
 
+// validateSourceFields validates the field roles and severity mapping of a source. Mapped
+// levels are lowercased in place.
+func validateSourceFields(fieldRoles models.SourceFieldRoles, severityMapping models.SeverityMapping, metaSeverityField string) error {
+	for _, role := range models.FieldRoles {
+		if col := fieldRoles.Column(role); col != "" && !isValidColumnName(col) {
+			return &ValidationError{Field: "fieldRoles." + string(role), Message: fmt.Sprintf("%s field contains invalid characters", role)}
+		}
+	}
+
+	if len(severityMapping) > 0 && metaSeverityField == "" {
+		return &ValidationError{Field: "severityMapping", Message: "severity mapping requires a severity field"}
+	}
+	for value, level := range severityMapping {
+		if strings.TrimSpace(value) == "" {
+			return &ValidationError{Field: "severityMapping", Message: "severity mapping values must not be empty"}
+		}
+		level = strings.ToLower(strings.TrimSpace(level))
+		if !slices.Contains(models.SeverityLevels, level) {
+			return &ValidationError{Field: "severityMapping", Message: fmt.Sprintf("severity level of %q must be one of %s", value, strings.Join(models.SeverityLevels, ", "))}
+		}
+		severityMapping[value] = level
+	}
+	return nil
+}
+
+// validateFieldRoleColumns checks that the columns given a role exist in the source table.
+func validateFieldRoleColumns(ctx context.Context, client *clickhouse.Client, database, tableName string, fieldRoles models.SourceFieldRoles) error {
+	info, err := client.GetTableInfo(ctx, database, tableName)
+	if err != nil {
+		return &ValidationError{Field: "fieldRoles", Message: "Failed to read the table columns", Err: err}
+	}
+	columns := make(map[string]bool, len(info.Columns))
+	for _, col := range info.Columns {
+		columns[col.Name] = true
+	}
+	for _, role := range models.FieldRoles {
+		if col := fieldRoles.Column(role); col != "" && !columns[col] {
+			return &ValidationError{Field: "fieldRoles." + string(role), Message: fmt.Sprintf("%s field '%s' not found in table '%s.%s'", role, col, database, tableName)}
+		}
+	}
+	return nil
+}
+
 // validateSourceUpdate validates source update parameters.
 func validateSourceUpdate(description string, ttlDays int) error {
 	// Description can be empty, but check length if provided
@@ -128,7 +173,8 @@ func validateConnection(conn models.ConnectionInfo) error {
 }
 
 // validateColumnTypes validates that the timestamp and severity columns exist and have compatible types in ClickHouse.
-func validateColumnTypes(ctx context.Context, client *clickhouse.Client, log *slog.Logger, database, tableName, tsField, severityField string) error {
+// A numeric severity field is accepted when its values are mapped to levels (numericSeverity).
+func validateColumnTypes(ctx context.Context, client *clickhouse.Client, log *slog.Logger, database, tableName, tsField, severityField string, numericSeverity bool) error {
 	if client == nil {
 		return &ValidationError{Field: "connection", Message: "Internal error: Invalid database client provided for validation"}
 	}
@@ -150,7 +196,7 @@ func validateColumnTypes(ctx context.Context, client *clickhouse.Client, log *sl
 	if !ok {
 		return &ValidationError{Field: "metaTSField", Message: fmt.Sprintf("Failed to determine type of timestamp field '%s'", tsField)}
 	}
-	if !strings.HasPrefix(tsType, "DateTime") {
+	if !isTimestampColumnType(tsType) {
 		return &ValidationError{Field: "metaTSField", Message: fmt.Sprintf("Timestamp field '%s' must be DateTime or DateTime64, found %s", tsField, tsType)}
 	}
 
@@ -172,8 +218,11 @@ func validateColumnTypes(ctx context.Context, client *clickhouse.Client, log *sl
 		if !ok {
 			return &ValidationError{Field: "metaSeverityField", Message: fmt.Sprintf("Failed to determine type of severity field '%s'", severityField)}
 		}
-		if sevType != "String" && !strings.Contains(sevType, "LowCardinality(String)") {
-			return &ValidationError{Field: "metaSeverityField", Message: fmt.Sprintf("Severity field '%s' must be String or LowCardinality(String), found %s", severityField, sevType)}
+		if numericSeverity && isNumericColumnType(sevType) {
+			return nil
+		}
+		if !isSeverityColumnType(sevType) {
+			return &ValidationError{Field: "metaSeverityField", Message: fmt.Sprintf("Severity field '%s' must be String or LowCardinality(String), or numeric with a severity mapping, found %s", severityField, sevType)}
 		}
 	}
 
@@ -380,11 +429,31 @@ func GetSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log
 }
 
 // CreateSource creates a new source, validates connection, and optionally creates the table.
-func CreateSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, name string, autoCreateTable bool, conn models.ConnectionInfo, description string, ttlDays int, metaTSField string, metaSeverityField string, customSchema string) (*models.Source, error) {
+// The table is created from customSchema when given, otherwise from the named schema template
+// (DefaultSchemaTemplate when empty).
+func CreateSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, name string, autoCreateTable bool, conn models.ConnectionInfo, description string, ttlDays int, metaTSField string, metaSeverityField string, fieldRoles models.SourceFieldRoles, severityMapping models.SeverityMapping, customSchema string, templateName string, templateParams models.SchemaTemplateParams) (*models.Source, error) {
 	// 1. Validate input parameters
 	if err := validateSourceCreation(name, conn, description, ttlDays, metaTSField, metaSeverityField); err != nil {
 		return nil, err
 	}
+	if err := validateSourceFields(fieldRoles, severityMapping, metaSeverityField); err != nil {
+		return nil, err
+	}
+
+	// Render and validate the table schema before connecting, so schema errors are reported first.
+	var tableSchema string
+	if autoCreateTable {
+		var err error
+		if tableSchema, err = sourceTableSchema(conn, ttlDays, metaTSField, customSchema, templateName, templateParams); err != nil {
+			return nil, err
+		}
+		// Sources created from a template get its field roles unless others are given.
+		if customSchema == "" && fieldRoles == (models.SourceFieldRoles{}) {
+			if t, err := lookupSchemaTemplate(orDefault(templateName, DefaultSchemaTemplate)); err == nil {
+				fieldRoles = t.FieldRoles
+			}
+		}
+	}
 
 	// 2. Check if source already exists in SQLite (using validateSourceConfig)
 	if err := validateSourceConfig(ctx, db, log, conn.Database, conn.TableName); err != nil {
@@ -408,27 +477,18 @@ func CreateSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager,
 			return nil, &ValidationError{Field: "connection.tableName", Message: fmt.Sprintf("Table '%s.%s' not found", conn.Database, conn.TableName)}
 		}
 		// Validate crucial column types (Timestamp, Severity if provided)
-		if err := validateColumnTypes(ctx, tempClient, log, conn.Database, conn.TableName, metaTSField, metaSeverityField); err != nil {
+		if err := validateColumnTypes(ctx, tempClient, log, conn.Database, conn.TableName, metaTSField, metaSeverityField, len(severityMapping) > 0); err != nil {
 			return nil, err // Return the detailed validation error
 		}
+		if err := validateFieldRoleColumns(ctx, tempClient, conn.Database, conn.TableName, fieldRoles); err != nil {
+			return nil, err
+		}
 	}
 
 	// 5. Create table in ClickHouse if autoCreateTable is true
 	if autoCreateTable {
-		schemaToExecute := customSchema
-		if schemaToExecute == "" {
-			schemaToExecute = models.OTELLogsTableSchema // Assuming this constant exists
-			schemaToExecute = strings.ReplaceAll(schemaToExecute, "{{database_name}}", conn.Database)
-			schemaToExecute = strings.ReplaceAll(schemaToExecute, "{{table_name}}", conn.TableName)
-			if ttlDays >= 0 { // Apply TTL only if non-negative (-1 means no TTL)
-				schemaToExecute = strings.ReplaceAll(schemaToExecute, "{{ttl_day}}", strconv.Itoa(ttlDays))
-			} else {
-				// Remove TTL clause entirely if ttlDays is -1
-				schemaToExecute = strings.ReplaceAll(schemaToExecute, " TTL toDateTime(timestamp) + INTERVAL {{ttl_day}} DAY", "")
-			}
-		}
 		log.Info("auto creating table", "database", conn.Database, "table", conn.TableName)
-		if _, err := tempClient.Query(ctx, schemaToExecute); err != nil {
+		if _, err := tempClient.Query(ctx, tableSchema); err != nil {
 			log.Error("failed to auto-create table in clickhouse", "error", err, "database", conn.Database, "table", conn.TableName)
 			return nil, &ValidationError{Field: "connection.tableName", Message: "Failed to create table in ClickHouse", Err: err}
 		}
@@ -443,6 +503,8 @@ func CreateSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager,
 		Connection:        conn,
 		Description:       description,
 		TTLDays:           ttlDays,
+		FieldRoles:        fieldRoles,
+		SeverityMapping:   severityMapping,
 		// Schema is not stored in DB, fetched dynamically
 		Timestamps: models.Timestamps{
 			CreatedAt: time.Now(), // Set by DB ideally, but good practice here too
@@ -467,8 +529,10 @@ func CreateSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager,
 	return sourceToCreate, nil // Return the source with ID populated by CreateSource DB call
 }
 
-// UpdateSource updates an existing source's mutable fields (description, ttlDays)
-func UpdateSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, id models.SourceID, description string, ttlDays int) (*models.Source, error) {
+// UpdateSource updates an existing source's mutable fields (description, ttlDays, field roles
+// and severity mapping). A new ttlDays is applied to the ClickHouse table of an auto-created
+// source before it is stored, and the change is recorded with changedBy.
+func UpdateSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, id models.SourceID, description string, ttlDays int, fieldRoles models.SourceFieldRoles, severityMapping models.SeverityMapping, changedBy *models.UserID) (*models.Source, error) {
 	// 1. Validate input
 	if err := validateSourceUpdate(description, ttlDays); err != nil {
 		return nil, err
@@ -486,14 +550,37 @@ func UpdateSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, id model
 	if source == nil {
 		return nil, ErrSourceNotFound
 	}
+	if source.Provisioned {
+		return nil, ErrProvisioned
+	}
+	if err := validateSourceFields(fieldRoles, severityMapping, source.MetaSeverityField); err != nil {
+		return nil, err
+	}
 
 	// 3. Update fields if they have changed
 	updated := false
+	if fieldRoles != source.FieldRoles {
+		// The columns are checked when the table can be read; an unreachable source can still be updated.
+		if client, err := chDB.GetConnection(id); err == nil {
+			if err := validateFieldRoleColumns(ctx, client, source.Connection.Database, source.Connection.TableName, fieldRoles); err != nil {
+				return nil, err
+			}
+		}
+		source.FieldRoles = fieldRoles
+		updated = true
+	}
+	if !maps.Equal(severityMapping, source.SeverityMapping) {
+		source.SeverityMapping = severityMapping
+		updated = true
+	}
 	if description != source.Description {
 		source.Description = description
 		updated = true
 	}
 	if ttlDays != source.TTLDays {
+		if err := changeSourceRetention(ctx, db, chDB, log, source, ttlDays, changedBy); err != nil {
+			return nil, err
+		}
 		source.TTLDays = ttlDays
 		updated = true
 	}
@@ -513,6 +600,8 @@ func UpdateSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, id model
 		)
 		return nil, fmt.Errorf("error updating source configuration: %w", err)
 	}
+	// A retention change drops data that cached results may still include.
+	cache.Invalidate(ctx, id)
 
 	// 5. Fetch the updated source again to get potentially updated fields (like updated_at)
 	updatedSource, err := db.GetSource(ctx, id)
@@ -527,7 +616,7 @@ func UpdateSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, id model
 }
 
 // DeleteSource deletes a source from SQLite and removes its connection from the manager
-func DeleteSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, id models.SourceID) error {
+func DeleteSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, id models.SourceID) error {
 	// No input validation needed for ID
 	// 1. Validate source exists in SQLite first
 	source, err := db.GetSource(ctx, id)
@@ -540,6 +629,9 @@ func DeleteSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager,
 	if source == nil { // Should be covered by ErrNotFound check
 		return ErrSourceNotFound
 	}
+	if source.Provisioned {
+		return ErrProvisioned
+	}
 
 	log.Info("deleting source", "source_id", id, "name", source.Name)
 
@@ -551,6 +643,8 @@ func DeleteSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager,
 		// return fmt.Errorf("error removing Clickhouse connection: %w", err)
 	}
 
+	cache.Invalidate(ctx, source.ID)
+
 	// 3. Then remove from database
 	if err := db.DeleteSource(ctx, source.ID); err != nil {
 		log.Error("failed to remove source from database", "source_id", id, "error", err)
@@ -632,7 +726,8 @@ func ValidateConnection(ctx context.Context, chDB *clickhouse.Manager, log *slog
 }
 
 // ValidateConnectionWithColumns validates a connection and checks specified column types.
-func ValidateConnectionWithColumns(ctx context.Context, chDB *clickhouse.Manager, log *slog.Logger, conn models.ConnectionInfo, tsField, severityField string) (*models.ConnectionValidationResult, error) {
+// A numeric severity field is accepted with a severityMapping.
+func ValidateConnectionWithColumns(ctx context.Context, chDB *clickhouse.Manager, log *slog.Logger, conn models.ConnectionInfo, tsField, severityField string, severityMapping models.SeverityMapping) (*models.ConnectionValidationResult, error) {
 	// 1. Validate connection parameters format
 	if err := validateConnection(conn); err != nil {
 		return nil, err
@@ -658,7 +753,7 @@ func ValidateConnectionWithColumns(ctx context.Context, chDB *clickhouse.Manager
 	}
 
 	// 4. Validate column types
-	if err := validateColumnTypes(ctx, client, log, conn.Database, conn.TableName, tsField, severityField); err != nil {
+	if err := validateColumnTypes(ctx, client, log, conn.Database, conn.TableName, tsField, severityField, len(severityMapping) > 0); err != nil {
 		return nil, err // Return the detailed validation error
 	}
 
@@ -740,10 +835,11 @@ func parseTTLExpression(ttlPart string) string {
 // SourceStats represents the combined statistics for a ClickHouse table
 // Use types directly from the clickhouse package
 type SourceStats struct {
-	TableStats  *clickhouse.TableStat        `json:"table_stats"`   // Use pointer to allow nil if stats fail completely
-	ColumnStats []clickhouse.TableColumnStat `json:"column_stats"`  // Slice is sufficient, empty if stats fail
-	TableInfo   *clickhouse.TableInfo        `json:"table_info"`    // Schema, engine, and metadata information
-	TTL         string                       `json:"ttl,omitempty"` // TTL information extracted from CREATE TABLE
+	TableStats  *clickhouse.TableStat        `json:"table_stats"`         // Use pointer to allow nil if stats fail completely
+	ColumnStats []clickhouse.TableColumnStat `json:"column_stats"`        // Slice is sufficient, empty if stats fail
+	TableInfo   *clickhouse.TableInfo        `json:"table_info"`          // Schema, engine, and metadata information
+	TTL         string                       `json:"ttl,omitempty"`       // TTL information extracted from CREATE TABLE
+	Retention   *models.SourceRetention      `json:"retention,omitempty"` // Stored retention compared with the table TTL
 }
 
 // GetSourceStats retrieves statistics for a specific source (ClickHouse table)
@@ -783,6 +879,7 @@ func GetSourceStats(ctx context.Context, chDB *clickhouse.Manager, log *slog.Log
 
 	// Extract TTL information from CREATE TABLE statement if available
 	var ttlExpr string
+	var retention *models.SourceRetention
 	if tableInfo != nil && tableInfo.CreateQuery != "" {
 		// For Distributed tables, TTL is always on the local table, not the distributed table
 		if tableInfo.Engine == "Distributed" && len(tableInfo.EngineParams) >= 3 {
@@ -793,10 +890,12 @@ func GetSourceStats(ctx context.Context, chDB *clickhouse.Manager, log *slog.Log
 			localTableInfo, err := client.GetTableInfo(ctx, localDB, localTable)
 			if err == nil && localTableInfo != nil && localTableInfo.CreateQuery != "" {
 				ttlExpr = extractTTLFromCreateQuery(localTableInfo.CreateQuery)
+				retention = sourceRetention(source, localDB+"."+localTable, ttlExpr)
 			}
 		} else {
 			// For non-Distributed tables, extract TTL directly
 			ttlExpr = extractTTLFromCreateQuery(tableInfo.CreateQuery)
+			retention = sourceRetention(source, source.GetFullTableName(), ttlExpr)
 		}
 	}
 
@@ -887,6 +986,7 @@ func GetSourceStats(ctx context.Context, chDB *clickhouse.Manager, log *slog.Log
 		ColumnStats: columnStats,
 		TableInfo:   tableInfo,
 		TTL:         ttlExpr,
+		Retention:   retention,
 	}
 
 	// Log detailed information about what was retrieved
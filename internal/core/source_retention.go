@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Source Retention ---
+
+// SourceRetentionHistoryLimit is the number of retention changes listed for a source.
+const SourceRetentionHistoryLimit = 50
+
+// ErrRetentionNotApplied is returned when a new retention can't be applied to the ClickHouse table
+var ErrRetentionNotApplied = errors.New("failed to apply retention to the ClickHouse table")
+
+// ttlIntervalPattern matches a TTL of a whole number of days, as ClickHouse stores it
+// ("+ toIntervalDay(30)") or as it is written ("+ INTERVAL 30 DAY").
+var ttlIntervalPattern = regexp.MustCompile(`(?i)\+\s*(?:toIntervalDay\(\s*(\d+)\s*\)|INTERVAL\s+(\d+)\s+DAY)\s*$`)
+
+// retentionTable is the table holding a source's TTL.
+type retentionTable struct {
+	database string
+	table    string
+	cluster  string // Cluster of a Distributed table, the local table is altered on every node
+	ttl      string // Current TTL expression
+}
+
+func (t *retentionTable) String() string {
+	return t.database + "." + t.table
+}
+
+// resolveRetentionTable finds the table holding a source's TTL: the source table itself, or
+// the local table behind it when it uses the Distributed engine.
+func resolveRetentionTable(ctx context.Context, client *clickhouse.Client, source *models.Source) (*retentionTable, error) {
+	info, err := client.GetTableInfo(ctx, source.Connection.Database, source.Connection.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting table info: %w", err)
+	}
+
+	target := &retentionTable{database: source.Connection.Database, table: source.Connection.TableName}
+	createQuery := info.CreateQuery
+	if info.Engine == "Distributed" && len(info.EngineParams) >= 3 {
+		target.cluster = info.EngineParams[0]
+		target.database = info.EngineParams[1]
+		target.table = info.EngineParams[2]
+
+		localInfo, err := client.GetTableInfo(ctx, target.database, target.table)
+		if err != nil {
+			return nil, fmt.Errorf("error getting info of local table %s: %w", target, err)
+		}
+		createQuery = localInfo.CreateQuery
+	}
+	target.ttl = extractTTLFromCreateQuery(createQuery)
+	return target, nil
+}
+
+// ttlStatement returns the ALTER TABLE statement setting the table TTL to ttlDays, or removing
+// it when ttlDays <= 0. It returns "" when there is nothing to change.
+func (t *retentionTable) ttlStatement(tsField string, ttlDays int) (string, error) {
+	if !isValidTableName(t.database) || !isValidTableName(t.table) {
+		return "", fmt.Errorf("table name %s can't be used in a statement", t)
+	}
+	if !isValidColumnName(tsField) {
+		return "", fmt.Errorf("timestamp field %q can't be used in a statement", tsField)
+	}
+
+	table := fmt.Sprintf("`%s`.`%s`", t.database, t.table)
+	if t.cluster != "" {
+		if !clusterNamePattern.MatchString(t.cluster) {
+			return "", fmt.Errorf("cluster name %q can't be used in a statement", t.cluster)
+		}
+		table += fmt.Sprintf(" ON CLUSTER '%s'", t.cluster)
+	}
+
+	switch {
+	case ttlDays > 0:
+		return fmt.Sprintf("ALTER TABLE %s MODIFY TTL toDateTime(%s) + INTERVAL %d DAY", table, tsField, ttlDays), nil
+	case t.ttl != "":
+		return fmt.Sprintf("ALTER TABLE %s REMOVE TTL", table), nil
+	}
+	return "", nil
+}
+
+// applySourceTTL sets the TTL of a source's ClickHouse table to ttlDays and returns the
+// statement it ran, if any.
+func applySourceTTL(ctx context.Context, chDB *clickhouse.Manager, log *slog.Logger, source *models.Source, ttlDays int) (string, error) {
+	client, err := chDB.GetConnection(source.ID)
+	if err != nil {
+		return "", fmt.Errorf("error getting connection: %w", err)
+	}
+	target, err := resolveRetentionTable(ctx, client, source)
+	if err != nil {
+		return "", err
+	}
+	stmt, err := target.ttlStatement(source.MetaTSField, ttlDays)
+	if err != nil || stmt == "" {
+		return stmt, err
+	}
+
+	log.Info("applying source retention", "source_id", source.ID, "table", target.String(), "ttl_days", ttlDays)
+	if _, err := client.Query(ctx, stmt); err != nil {
+		return stmt, err
+	}
+	return stmt, nil
+}
+
+// changeSourceRetention applies a new retention to an auto-created source's table and records
+// the change. Tables not created by LogChef are left alone and the change is recorded as not applied.
+func changeSourceRetention(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, log *slog.Logger, source *models.Source, ttlDays int, changedBy *models.UserID) error {
+	change := &models.SourceRetentionChange{
+		SourceID:   source.ID,
+		UserID:     changedBy,
+		OldTTLDays: source.TTLDays,
+		NewTTLDays: ttlDays,
+		Status:     models.RetentionChangeNotApplied,
+		CreatedAt:  time.Now(),
+	}
+
+	var applyErr error
+	if source.MetaIsAutoCreated {
+		change.Statement, applyErr = applySourceTTL(ctx, chDB, log, source, ttlDays)
+		change.Status = models.RetentionChangeApplied
+		if applyErr != nil {
+			change.Status = models.RetentionChangeFailed
+			change.Error = applyErr.Error()
+			log.Error("failed to apply source retention", "source_id", source.ID, "error", applyErr)
+		}
+	}
+
+	// The change is only recorded: failing to record it doesn't undo it.
+	if err := db.InsertSourceRetentionChange(ctx, change); err != nil {
+		log.Error("failed to record source retention change", "source_id", source.ID, "error", err)
+	}
+	if applyErr != nil {
+		return fmt.Errorf("%w: %v", ErrRetentionNotApplied, applyErr)
+	}
+	return nil
+}
+
+// sourceRetention compares a source's stored retention with the TTL expression of its table.
+func sourceRetention(source *models.Source, table, ttl string) *models.SourceRetention {
+	retention := &models.SourceRetention{
+		TTLDays:  source.TTLDays,
+		Table:    table,
+		TableTTL: ttl,
+	}
+	if m := ttlIntervalPattern.FindStringSubmatch(ttl); m != nil {
+		if days, err := strconv.Atoi(m[1] + m[2]); err == nil {
+			retention.TableTTLDays = &days
+		}
+	}
+
+	if source.TTLDays > 0 {
+		retention.Drift = retention.TableTTLDays == nil || *retention.TableTTLDays != source.TTLDays
+	} else {
+		retention.Drift = ttl != ""
+	}
+	return retention
+}
+
+// GetSourceRetention compares the retention stored for a source with the TTL of its ClickHouse table.
+func GetSourceRetention(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, id models.SourceID) (*models.SourceRetention, error) {
+	source, err := db.GetSource(ctx, id)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) || sqlite.IsSourceNotFoundError(err) {
+			return nil, ErrSourceNotFound
+		}
+		return nil, fmt.Errorf("error getting source: %w", err)
+	}
+
+	client, err := chDB.GetConnection(source.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting connection: %w", err)
+	}
+	target, err := resolveRetentionTable(ctx, client, source)
+	if err != nil {
+		return nil, err
+	}
+	return sourceRetention(source, target.String(), target.ttl), nil
+}
+
+// ListSourceRetentionChanges returns the latest retention changes of a source, newest first.
+func ListSourceRetentionChanges(ctx context.Context, db *sqlite.DB, id models.SourceID) ([]*models.SourceRetentionChange, error) {
+	return db.ListSourceRetentionChanges(ctx, id, SourceRetentionHistoryLimit)
+}
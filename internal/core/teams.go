@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mr-karan/logchef/internal/clickhouse"
@@ -160,6 +161,9 @@ func UpdateTeam(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID mod
 		log.Error("failed to get existing team for update", "error", err, "team_id", teamID)
 		return fmt.Errorf("error getting team for update: %w", err)
 	}
+	if existing.Provisioned {
+		return ErrProvisioned
+	}
 
 	updated := false
 	// Apply updates from updateData
@@ -180,6 +184,10 @@ func UpdateTeam(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID mod
 		existing.Description = updateData.Description
 		updated = true
 	}
+	if updateData.AllowAnonymousShares != existing.AllowAnonymousShares {
+		existing.AllowAnonymousShares = updateData.AllowAnonymousShares
+		updated = true
+	}
 
 	// If no changes, return early
 	if !updated {
@@ -200,7 +208,7 @@ func UpdateTeam(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID mod
 // DeleteTeam deletes a team and its associations (members, sources, queries).
 func DeleteTeam(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID) error {
 	// Validate team exists
-	_, err := db.GetTeam(ctx, teamID)
+	existing, err := db.GetTeam(ctx, teamID)
 	if err != nil {
 		if sqlite.IsNotFoundError(err) || sqlite.IsTeamNotFoundError(err) {
 			return ErrTeamNotFound
@@ -208,6 +216,9 @@ func DeleteTeam(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID mod
 		log.Error("failed to get team for deletion check", "error", err, "team_id", teamID)
 		return fmt.Errorf("error checking existing team: %w", err)
 	}
+	if existing.Provisioned {
+		return ErrProvisioned
+	}
 
 	log.Warn("deleting team and all associated data", "team_id", teamID)
 
@@ -285,6 +296,13 @@ func AddTeamMember(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID
 		} else {
 			log.Debug("user already team member with correct role", "team_id", teamID, "user_id", userID, "role", role)
 		}
+		// Adding a member by hand takes the membership over from OIDC group sync.
+		if existingMember.OIDCSynced {
+			if err := db.SetTeamMemberOIDCSynced(ctx, teamID, userID, false); err != nil {
+				log.Error("failed to clear oidc sync flag", "error", err, "team_id", teamID, "user_id", userID)
+				return err
+			}
+		}
 		return nil // Indicate success (or no-op)
 	}
 
@@ -316,6 +334,10 @@ func UpdateTeamMemberRole(ctx context.Context, db *sqlite.DB, log *slog.Logger,
 		return fmt.Errorf("error checking team member: %w", err)
 	}
 
+	if existingMember != nil && existingMember.Provisioned {
+		return ErrProvisioned
+	}
+
 	// If role is the same, do nothing
 	if existingMember.Role == newRole {
 		log.Debug("team member role already correct", "team_id", teamID, "user_id", userID, "role", newRole)
@@ -328,6 +350,13 @@ func UpdateTeamMemberRole(ctx context.Context, db *sqlite.DB, log *slog.Logger,
 		log.Error("failed to update team member role in db", "error", err, "team_id", teamID, "user_id", userID)
 		return fmt.Errorf("error updating team member role: %w", err)
 	}
+	// A role set by hand is no longer managed by OIDC group sync.
+	if existingMember.OIDCSynced {
+		if err := db.SetTeamMemberOIDCSynced(ctx, teamID, userID, false); err != nil {
+			log.Error("failed to clear oidc sync flag", "error", err, "team_id", teamID, "user_id", userID)
+			return err
+		}
+	}
 
 	return nil
 }
@@ -341,7 +370,7 @@ func RemoveTeamMember(ctx context.Context, db *sqlite.DB, log *slog.Logger, team
 	// if err != nil { return err }
 
 	// Check if user is actually a member before attempting delete
-	_, err := db.GetTeamMember(ctx, teamID, userID)
+	member, err := db.GetTeamMember(ctx, teamID, userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Debug("user not a member of team, nothing to remove", "team_id", teamID, "user_id", userID)
@@ -350,6 +379,9 @@ func RemoveTeamMember(ctx context.Context, db *sqlite.DB, log *slog.Logger, team
 		log.Error("failed to check team member before removal", "error", err, "team_id", teamID, "user_id", userID)
 		return fmt.Errorf("error checking team member before removal: %w", err)
 	}
+	if member != nil && member.Provisioned {
+		return ErrProvisioned
+	}
 
 	// Remove user from team
 	log.Info("removing team member", "team_id", teamID, "user_id", userID)
@@ -417,9 +449,13 @@ func ListTeamSources(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manage
 // AddTeamSource associates a source with a team.
 func AddTeamSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID) error {
 	// Validate team exists
-	if _, err := GetTeam(ctx, db, teamID); err != nil {
+	team, err := GetTeam(ctx, db, teamID)
+	if err != nil {
 		return err // Propagate ErrTeamNotFound or other DB errors
 	}
+	if team.Provisioned {
+		return ErrProvisioned
+	}
 
 	// Validate source exists
 	if _, err := db.GetSource(ctx, sourceID); err != nil { // Check DB directly for now
@@ -446,6 +482,9 @@ func RemoveTeamSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, team
 	// if err != nil { return err }
 	// _, err = db.GetSource(ctx, sourceID) // Check DB directly
 	// if err != nil { ... handle ErrSourceNotFound ... }
+	if err := checkTeamNotProvisioned(ctx, db, teamID); err != nil {
+		return err
+	}
 
 	// Remove source from team
 	log.Info("removing source from team", "team_id", teamID, "source_id", sourceID)
@@ -457,6 +496,46 @@ func RemoveTeamSource(ctx context.Context, db *sqlite.DB, log *slog.Logger, team
 	return nil
 }
 
+// GetTeamSourceRowFilter returns the mandatory row-level filter for a team's access to a source.
+// An empty string means the team can read all rows of the source.
+func GetTeamSourceRowFilter(ctx context.Context, db *sqlite.DB, teamID models.TeamID, sourceID models.SourceID) (string, error) {
+	filter, err := db.GetTeamSourceRowFilter(ctx, teamID, sourceID)
+	if err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return "", ErrSourceNotFound
+		}
+		return "", fmt.Errorf("error getting row filter for team %d and source %d: %w", teamID, sourceID, err)
+	}
+	return filter, nil
+}
+
+// UpdateTeamSourceRowFilter validates and sets the row-level filter for a team's access to a source.
+// Pass an empty filter to remove the restriction.
+func UpdateTeamSourceRowFilter(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, mapKeys *MapKeysCache, log *slog.Logger, teamID models.TeamID, sourceID models.SourceID, filter string) error {
+	filter = strings.TrimSpace(filter)
+	if filter != "" {
+		if err := clickhouse.ValidateRowFilter(filter); err != nil {
+			return &ValidationError{Field: "row_filter", Message: err.Error()}
+		}
+	}
+	if err := checkTeamNotProvisioned(ctx, db, teamID); err != nil {
+		return err
+	}
+
+	log.Info("updating team source row filter", "team_id", teamID, "source_id", sourceID, "row_filter", filter)
+	if err := db.UpdateTeamSourceRowFilter(ctx, teamID, sourceID, filter); err != nil {
+		if sqlite.IsNotFoundError(err) {
+			return ErrSourceNotFound
+		}
+		log.Error("failed to update team source row filter in db", "error", err, "team_id", teamID, "source_id", sourceID)
+		return fmt.Errorf("error updating team source row filter: %w", err)
+	}
+
+	// Discovered Map keys were sampled under the previous filter.
+	mapKeys.Invalidate(sourceID)
+	return nil
+}
+
 // --- Authorization/Access Check Functions ---
 
 // ListSourcesForUser returns all unique sources a user has access to across all teams.
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// --- Trace Correlation ---
+
+const (
+	// DefaultTraceWindow is how far before and after the time hint a trace is searched.
+	DefaultTraceWindow = time.Hour
+	// MaxTraceWindow bounds the time range searched on each side of the time hint.
+	MaxTraceWindow = 24 * time.Hour
+	// MaxTraceLogsPerSource bounds the logs of a trace fetched from a single source.
+	MaxTraceLogsPerSource = 1000
+	// MaxTraceConcurrency is the number of sources searched at the same time.
+	MaxTraceConcurrency = 4
+)
+
+// traceIDPattern matches trace IDs: hex IDs (W3C, OpenTelemetry), UUIDs and similar tokens.
+var traceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// TraceParams defines parameters for finding the logs of a trace.
+type TraceParams struct {
+	TraceID string
+	Time    time.Time     // Time hint, e.g. the timestamp of the log the trace was picked from.
+	Window  time.Duration // Searched before and after the time hint, defaults to DefaultTraceWindow.
+	// URLTemplate links the trace to an external tracing UI (see config.TracingConfig).
+	URLTemplate string
+	// AllowSource restricts the searched sources, e.g. to those an API token may query.
+	AllowSource func(models.SourceID) bool
+	// AcquireQuery admits the query of each source under the query limits.
+	AcquireQuery AcquireQueryFunc
+	QueryTimeout *int
+}
+
+// GetTraceTimeline fetches the logs of a trace from every source of the team with a trace ID
+// field, and merges them into a single timeline with the span tree built from their span and
+// parent span IDs. Sources are queried through QueryLogs, so the team's row-level filter and
+// column policies apply. The audit entries of the queries are returned for the caller to record.
+func GetTraceTimeline(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, params TraceParams) (*models.TraceTimeline, []*models.QueryAuditEntry, error) {
+	if !traceIDPattern.MatchString(params.TraceID) {
+		return nil, nil, &ValidationError{Field: "trace_id", Message: "trace ID must be 1-128 letters, digits, '-' or '_'"}
+	}
+	if params.Time.IsZero() {
+		return nil, nil, &ValidationError{Field: "time", Message: "time hint is required"}
+	}
+	if params.Window <= 0 {
+		params.Window = DefaultTraceWindow
+	}
+	if params.Window > MaxTraceWindow {
+		return nil, nil, &ValidationError{Field: "window", Message: fmt.Sprintf("window must not exceed %s", MaxTraceWindow)}
+	}
+
+	sources, err := ListTeamSources(ctx, db, chDB, log, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Only sources declaring a trace ID field can be searched.
+	sources = slices.DeleteFunc(sources, func(source *models.Source) bool {
+		return source.FieldRoles.TraceID == "" || (params.AllowSource != nil && !params.AllowSource(source.ID))
+	})
+
+	timeline := &models.TraceTimeline{
+		TraceID: params.TraceID,
+		From:    params.Time.Add(-params.Window).UTC(),
+		To:      params.Time.Add(params.Window).UTC(),
+		Logs:    []*models.TraceLog{},
+		Spans:   []*models.TraceSpan{},
+		Sources: make([]*models.TraceSourceResult, len(sources)),
+	}
+
+	sourceLogs := make([][]*models.TraceLog, len(sources))
+	audits := make([]*models.QueryAuditEntry, len(sources))
+	sem := make(chan struct{}, MaxTraceConcurrency)
+	var wg sync.WaitGroup
+	for i := range sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			timeline.Sources[i], sourceLogs[i], audits[i] = searchTraceSource(ctx, db, chDB, cache, log, teamID, sources[i], timeline, params)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, logs := range sourceLogs {
+		timeline.Logs = append(timeline.Logs, logs...)
+	}
+	slices.SortStableFunc(timeline.Logs, func(a, b *models.TraceLog) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	timeline.Spans = buildSpanTree(timeline.Logs)
+	timeline.URL = TraceURL(params.URLTemplate, params.TraceID, timeline.From, timeline.To)
+
+	// Sources whose query wasn't admitted have no audit entry.
+	audits = slices.DeleteFunc(audits, func(audit *models.QueryAuditEntry) bool { return audit == nil })
+
+	log.Debug("trace search complete", "team_id", teamID, "trace_id", params.TraceID, "sources", len(sources), "logs", len(timeline.Logs))
+	return timeline, audits, nil
+}
+
+// searchTraceSource fetches the logs of a trace from a single source.
+func searchTraceSource(ctx context.Context, db *sqlite.DB, chDB *clickhouse.Manager, cache *QueryCache, log *slog.Logger, teamID models.TeamID, source *models.Source, timeline *models.TraceTimeline, params TraceParams) (*models.TraceSourceResult, []*models.TraceLog, *models.QueryAuditEntry) {
+	result := &models.TraceSourceResult{SourceID: source.ID, Name: source.Name}
+
+	sourceID := source.ID
+	tsField := "`" + strings.ReplaceAll(source.MetaTSField, "`", "``") + "`"
+	rawSQL := fmt.Sprintf("SELECT * FROM %s WHERE %s BETWEEN fromUnixTimestamp64Milli(%d) AND fromUnixTimestamp64Milli(%d) AND toString(`%s`) = '%s' ORDER BY %s ASC",
+		source.GetFullTableName(), tsField, timeline.From.UnixMilli(), timeline.To.UnixMilli(),
+		strings.ReplaceAll(source.FieldRoles.TraceID, "`", "``"), timeline.TraceID, tsField)
+	// A source whose query isn't admitted under the query limits is reported like a failed one.
+	ctx, release, err := acquireQuery(ctx, params.AcquireQuery, teamID, source.ID, models.QueryAuditTypeLogs, rawSQL)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil, nil
+	}
+	defer release()
+	audit := &models.QueryAuditEntry{TeamID: &teamID, SourceID: &sourceID, QueryType: models.QueryAuditTypeLogs, RawSQL: rawSQL}
+
+	start := time.Now()
+	// One row more than kept tells whether the source had more logs of the trace.
+	queryResult, err := QueryLogs(WithAuditEntry(ctx, audit), db, chDB, cache, log, teamID, source.ID, clickhouse.LogQueryParams{
+		RawSQL:       rawSQL,
+		Limit:        MaxTraceLogsPerSource + 1,
+		QueryTimeout: params.QueryTimeout,
+	})
+	audit.CreatedAt = start.UTC()
+	audit.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		log.Warn("trace search failed for source", "source_id", source.ID, "trace_id", timeline.TraceID, "error", err)
+		result.Error = err.Error()
+		audit.Error = err.Error()
+		return result, nil, audit
+	}
+
+	rows := queryResult.Logs
+	audit.RowsReturned = len(rows)
+	if len(rows) > MaxTraceLogsPerSource {
+		rows = rows[:MaxTraceLogsPerSource]
+		result.Truncated = true
+	}
+	logs := make([]*models.TraceLog, 0, len(rows))
+	for _, row := range rows {
+		logs = append(logs, newTraceLog(source, row))
+	}
+	result.Logs = len(logs)
+	return result, logs, audit
+}
+
+// newTraceLog extracts the fields of a source's role columns from a log.
+func newTraceLog(source *models.Source, row map[string]interface{}) *models.TraceLog {
+	field := func(role models.FieldRole) string {
+		if column := source.FieldRoles.Column(role); column != "" {
+			return stringValue(row[column])
+		}
+		return ""
+	}
+
+	traceLog := &models.TraceLog{
+		SourceID:     source.ID,
+		SourceName:   source.Name,
+		SpanID:       field(models.FieldRoleSpanID),
+		ParentSpanID: field(models.FieldRoleParentSpanID),
+		Service:      field(models.FieldRoleService),
+		Message:      field(models.FieldRoleMessage),
+		Log:          row,
+	}
+	if ts, ok := timeValue(row[source.MetaTSField]); ok {
+		traceLog.Timestamp = ts
+	}
+	if source.MetaSeverityField != "" {
+		traceLog.Severity = stringValue(row[source.MetaSeverityField])
+		if level, ok := source.SeverityMapping.Level(traceLog.Severity); ok {
+			traceLog.Severity = level
+		}
+	}
+	return traceLog
+}
+
+// buildSpanTree groups time-ordered logs by span and links the spans to their parents. Spans
+// whose parent has no logs, or that are part of a parent cycle, are returned as roots.
+func buildSpanTree(logs []*models.TraceLog) []*models.TraceSpan {
+	var ordered []*models.TraceSpan
+	spans := make(map[string]*models.TraceSpan)
+	for _, traceLog := range logs {
+		if traceLog.SpanID == "" {
+			continue
+		}
+		span, ok := spans[traceLog.SpanID]
+		if !ok {
+			span = &models.TraceSpan{SpanID: traceLog.SpanID, Start: traceLog.Timestamp, Children: []*models.TraceSpan{}}
+			spans[traceLog.SpanID] = span
+			ordered = append(ordered, span)
+		}
+		if span.ParentSpanID == "" && traceLog.ParentSpanID != traceLog.SpanID {
+			span.ParentSpanID = traceLog.ParentSpanID
+		}
+		if span.Service == "" {
+			span.Service = traceLog.Service
+		}
+		span.End = traceLog.Timestamp
+		span.LogCount++
+	}
+
+	// A span whose ancestors lead back to it would never be reached from a root.
+	inCycle := func(span *models.TraceSpan) bool {
+		seen := map[string]bool{}
+		for parent := spans[span.ParentSpanID]; parent != nil && !seen[parent.SpanID]; parent = spans[parent.ParentSpanID] {
+			if parent == span {
+				return true
+			}
+			seen[parent.SpanID] = true
+		}
+		return false
+	}
+
+	roots := []*models.TraceSpan{}
+	for _, span := range ordered {
+		parent, ok := spans[span.ParentSpanID]
+		if !ok || inCycle(span) {
+			roots = append(roots, span)
+			continue
+		}
+		parent.Children = append(parent.Children, span)
+	}
+	return roots
+}
+
+// TraceURL builds the link to a trace in an external tracing UI from a URL template, replacing
+// {trace_id}, {start} and {end} (Unix milliseconds). It returns "" without a template.
+func TraceURL(template, traceID string, start, end time.Time) string {
+	if template == "" {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{trace_id}", url.PathEscape(traceID),
+		"{start}", strconv.FormatInt(start.UnixMilli(), 10),
+		"{end}", strconv.FormatInt(end.UnixMilli(), 10),
+	).Replace(template)
+}
@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	// "strconv"
 	"time"
+	"unicode"
 
 	"github.com/mr-karan/logchef/internal/sqlite"
 	"github.com/mr-karan/logchef/pkg/models"
@@ -97,6 +99,35 @@ func validateUserUpdate(updateData models.User) error {
 	return nil
 }
 
+// derivedFullName derives a valid full name for a user created by an identity provider
+// from the name it sent, falling back to the local part of the email.
+func derivedFullName(name, email string) string {
+	clean := func(s string) string {
+		s = strings.Map(func(r rune) rune {
+			switch {
+			case unicode.IsLetter(r), r == '-', r == '\'':
+				return r
+			case r == ' ', r == '.', r == '_':
+				return ' '
+			}
+			return -1
+		}, s)
+		s = strings.Join(strings.Fields(s), " ")
+		if r := []rune(s); len(r) > 100 {
+			s = strings.TrimSpace(string(r[:100]))
+		}
+		return s
+	}
+	if n := clean(name); len(n) >= 2 {
+		return n
+	}
+	local, _, _ := strings.Cut(email, "@")
+	if n := clean(local); len(n) >= 2 {
+		return n
+	}
+	return "New User"
+}
+
 // --- User Management Functions ---
 
 // ListUsers returns all users from the database.
@@ -187,6 +218,13 @@ func CreateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, email, ful
 // UpdateUser updates an existing user's information.
 // Only non-empty fields in the `updateData` struct are applied.
 func UpdateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID models.UserID, updateData models.User) error {
+	return updateUser(ctx, db, log, userID, updateData, false)
+}
+
+// updateUser implements UpdateUser. Updates from SCIM may deactivate a provisioned user, so
+// the identity provider can always cut off access; their other changes to a provisioned user
+// are ignored, and the next provisioning run restores the declared status.
+func updateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID models.UserID, updateData models.User, fromSCIM bool) error {
 	// Validate the fields provided in updateData first
 	if err := validateUserUpdate(updateData); err != nil {
 		return err
@@ -201,8 +239,20 @@ func UpdateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID mod
 		log.Error("failed to get existing user for update", "error", err, "user_id", userID)
 		return fmt.Errorf("error getting user for update: %w", err)
 	}
+	if existing.Provisioned && fromSCIM {
+		if updateData.Status != models.UserStatusInactive {
+			updateData.Status = ""
+		}
+		updateData.FullName, updateData.Role = "", ""
+	}
+	// Provisioned users only accept login bookkeeping; their profile comes from the provisioning files.
+	if existing.Provisioned && ((updateData.FullName != "" && updateData.FullName != existing.FullName) ||
+		(updateData.Role != "" && updateData.Role != existing.Role) ||
+		(updateData.Status != "" && updateData.Status != existing.Status)) {
+		return ErrProvisioned
+	}
 
-	updated := false
+	updated, roleChanged, deactivated := false, false, false
 	// Apply updates from updateData
 	if updateData.FullName != "" && updateData.FullName != existing.FullName {
 		existing.FullName = updateData.FullName
@@ -213,6 +263,7 @@ func UpdateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID mod
 			return fmt.Errorf("%w: must be '%s' or '%s'", ErrInvalidRole, models.UserRoleAdmin, models.UserRoleMember)
 		}
 		existing.Role = updateData.Role
+		roleChanged = true
 		updated = true
 	}
 	if updateData.Status != "" && updateData.Status != existing.Status {
@@ -232,6 +283,7 @@ func UpdateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID mod
 			}
 		}
 		existing.Status = updateData.Status
+		deactivated = updateData.Status == models.UserStatusInactive
 		updated = true
 	}
 	// Only update LastLoginAt if provided in updateData
@@ -258,6 +310,22 @@ func UpdateUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, userID mod
 		log.Error("failed to update user in db", "error", err, "user_id", userID)
 		return fmt.Errorf("error updating user: %w", err)
 	}
+	// Deactivated users lose access immediately rather than when their session expires.
+	if deactivated {
+		if err := RevokeUserSessions(ctx, db, log, userID); err != nil {
+			return err
+		}
+		if err := DisableUserAPITokens(ctx, db, log, userID); err != nil {
+			return err
+		}
+	}
+	// A role set by hand is no longer managed by OIDC group sync.
+	if roleChanged && existing.OIDCAdmin {
+		if err := db.SetUserOIDCAdmin(ctx, userID, false); err != nil {
+			log.Error("failed to clear oidc admin flag", "error", err, "user_id", userID)
+			return err
+		}
+	}
 
 	log.Info("user updated successfully", "user_id", userID)
 	return nil
@@ -274,6 +342,9 @@ func DeleteUser(ctx context.Context, db *sqlite.DB, log *slog.Logger, id models.
 		log.Error("failed to get user for deletion check", "error", err, "user_id", id)
 		return fmt.Errorf("error checking existing user: %w", err)
 	}
+	if existing.Provisioned {
+		return ErrProvisioned
+	}
 
 	// Check if deleting the last admin
 	if existing.Role == models.UserRoleAdmin {
@@ -324,7 +395,7 @@ func InitAdminUsers(ctx context.Context, db *sqlite.DB, log *slog.Logger, adminE
 				if createErr != nil {
 					errMsg := fmt.Sprintf("failed to create admin user %s: %v", email, createErr)
 					log.Error(errMsg)
-					setupErrors = append(setupErrors, fmt.Errorf(errMsg))
+					setupErrors = append(setupErrors, errors.New(errMsg))
 				} else {
 					log.Info("created new admin user successfully", "email", email, "user_id", newUser.ID)
 				}
@@ -334,7 +405,7 @@ func InitAdminUsers(ctx context.Context, db *sqlite.DB, log *slog.Logger, adminE
 			// If it's a different error (not "not found"), log and continue
 			errMsg := fmt.Sprintf("failed to check existing admin user %s: %v", email, err)
 			log.Error(errMsg)
-			setupErrors = append(setupErrors, fmt.Errorf(errMsg))
+			setupErrors = append(setupErrors, errors.New(errMsg))
 			continue // Try next email
 		}
 
@@ -355,7 +426,7 @@ func InitAdminUsers(ctx context.Context, db *sqlite.DB, log *slog.Logger, adminE
 				if err := db.UpdateUser(ctx, existing); err != nil {
 					errMsg := fmt.Sprintf("failed to update admin user %s: %v", email, err)
 					log.Error(errMsg)
-					setupErrors = append(setupErrors, fmt.Errorf(errMsg))
+					setupErrors = append(setupErrors, errors.New(errMsg))
 				} else {
 					log.Info("updated existing user to active admin", "email", email, "user_id", existing.ID)
 				}
@@ -370,7 +441,7 @@ func InitAdminUsers(ctx context.Context, db *sqlite.DB, log *slog.Logger, adminE
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to count admin users after initialization: %v", err)
 		log.Error(errMsg)
-		setupErrors = append(setupErrors, fmt.Errorf(errMsg))
+		setupErrors = append(setupErrors, errors.New(errMsg))
 	} else if count == 0 {
 		errMsg := "initialization finished, but no active admin users found in the database"
 		log.Error(errMsg)
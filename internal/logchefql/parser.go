@@ -0,0 +1,266 @@
+// Package logchefql parses LogchefQL filters and translates them into ClickHouse conditions,
+// following the frontend's LogchefQL implementation.
+//
+// A filter compares fields with values and combines the comparisons with "and"/"or" and
+// parentheses, e.g. `level = "error" and (service ~ api or status >= 500)`. Operators are
+// left-associative without precedence, as in the frontend. Nested fields (`attrs.user.id`,
+// `attrs."http.method"`) address Map keys or JSON paths.
+package logchefql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Operator is a comparison operator.
+type Operator string
+
+const (
+	OpEquals      Operator = "="
+	OpNotEquals   Operator = "!="
+	OpContains    Operator = "~" // Case-insensitive substring match
+	OpNotContains Operator = "!~"
+	OpGreater     Operator = ">"
+	OpLess        Operator = "<"
+	OpGreaterEq   Operator = ">="
+	OpLessEq      Operator = "<="
+)
+
+// operators maps the operator spellings to operators.
+var operators = map[string]Operator{
+	"=": OpEquals, "==": OpEquals, "!=": OpNotEquals, "~": OpContains, "!~": OpNotContains,
+	">": OpGreater, "<": OpLess, ">=": OpGreaterEq, "<=": OpLessEq,
+}
+
+// ErrEmptyFilter is returned when a filter has no comparisons.
+var ErrEmptyFilter = errors.New("filter is empty")
+
+// Node is a node of a parsed filter: a *Comparison or a *Logical.
+type Node interface {
+	node()
+}
+
+// Comparison compares a field with a value.
+type Comparison struct {
+	Field  string   // Column or semantic field
+	Path   []string // Keys below the field, for Map and JSON columns
+	Op     Operator
+	Value  string
+	Quoted bool // Quoted values are always strings
+}
+
+// Logical combines nodes with AND or OR.
+type Logical struct {
+	Op       string // "AND" or "OR"
+	Children []Node
+}
+
+func (*Comparison) node() {}
+func (*Logical) node()    {}
+
+// Parse parses a LogchefQL filter. The select part of a query ("| field, ...") isn't supported.
+func Parse(input string) (Node, error) {
+	p := &parser{input: input}
+	p.skipSpace()
+	if p.eof() {
+		return nil, ErrEmptyFilter
+	}
+	node, err := p.parseExpression(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:p.pos+1])
+	}
+	return node, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// parseExpression parses comparisons and groups joined by boolean operators. Inside
+// parentheses, expressions without an operator between them are joined with AND.
+func (p *parser) parseExpression(inGroup bool) (Node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.eof() || p.input[p.pos] == ')' {
+			return left, nil
+		}
+		op := p.boolOperator()
+		if op == "" {
+			if !inGroup {
+				return nil, p.errorf("missing boolean operator (and/or)")
+			}
+			op = "AND"
+		}
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if logical, ok := left.(*Logical); ok && logical.Op == op {
+			logical.Children = append(logical.Children, right)
+		} else {
+			left = &Logical{Op: op, Children: []Node{left, right}}
+		}
+	}
+}
+
+// boolOperator consumes "and" or "or" and returns it in upper case, or returns "".
+func (p *parser) boolOperator() string {
+	for _, op := range []string{"and", "or"} {
+		end := p.pos + len(op)
+		if end <= len(p.input) && strings.EqualFold(p.input[p.pos:end], op) &&
+			(end == len(p.input) || isSpace(p.input[end]) || p.input[end] == '(') {
+			p.pos = end
+			return strings.ToUpper(op)
+		}
+	}
+	return ""
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unexpected end of filter")
+	}
+	switch p.input[p.pos] {
+	case '(':
+		p.pos++
+		node, err := p.parseExpression(true)
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.input[p.pos] != ')' {
+			return nil, p.errorf("expected closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case ')':
+		return nil, p.errorf("unexpected closing parenthesis")
+	case '|':
+		return nil, p.errorf("selecting fields with | is not supported here")
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (*Comparison, error) {
+	segments, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	cmp := &Comparison{Field: segments[0], Path: segments[1:]}
+
+	p.skipSpace()
+	start := p.pos
+	for !p.eof() && strings.IndexByte("=!~<>", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+	op, ok := operators[p.input[start:p.pos]]
+	if !ok {
+		if start == p.pos {
+			return nil, p.errorf("expected an operator after %q", cmp.Field)
+		}
+		return nil, fmt.Errorf("position %d: unknown operator %q", start+1, p.input[start:p.pos])
+	}
+	cmp.Op = op
+
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("expected a value")
+	}
+	if q := p.input[p.pos]; q == '"' || q == '\'' {
+		cmp.Value, err = p.parseString(q)
+		cmp.Quoted = true
+		return cmp, err
+	}
+	start = p.pos
+	for !p.eof() && !isSpace(p.input[p.pos]) && p.input[p.pos] != ')' {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, p.errorf("expected a value")
+	}
+	cmp.Value = p.input[start:p.pos]
+	return cmp, nil
+}
+
+// parseField parses a field and splits it on dots outside quoted segments.
+func (p *parser) parseField() ([]string, error) {
+	var segments []string
+	var segment strings.Builder
+	for !p.eof() {
+		c := p.input[p.pos]
+		if isSpace(c) || strings.IndexByte("=!~<>()|", c) >= 0 {
+			break
+		}
+		switch c {
+		case '"', '\'':
+			quoted, err := p.parseString(c)
+			if err != nil {
+				return nil, err
+			}
+			segment.WriteString(quoted)
+			continue
+		case '.':
+			segments = append(segments, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(c)
+		}
+		p.pos++
+	}
+	segments = append(segments, segment.String())
+	for _, s := range segments {
+		if s == "" {
+			return nil, p.errorf("expected a field name")
+		}
+	}
+	return segments, nil
+}
+
+// parseString parses a string quoted with q, in which backslash escapes the next character.
+func (p *parser) parseString(q byte) (string, error) {
+	start := p.pos
+	p.pos++
+	var s strings.Builder
+	for !p.eof() {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && !p.eof():
+			s.WriteByte(p.input[p.pos])
+			p.pos++
+		case c == q:
+			return s.String(), nil
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("position %d: unterminated string", start+1)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
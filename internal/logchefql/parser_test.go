@@ -0,0 +1,123 @@
+package logchefql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dump renders a parsed filter compactly: fields with their path keys, quoted values with %q,
+// and logical nodes as (OP children...).
+func dump(node Node) string {
+	switch n := node.(type) {
+	case *Comparison:
+		var field strings.Builder
+		field.WriteString(fmt.Sprintf("%q", n.Field))
+		for _, key := range n.Path {
+			field.WriteString(fmt.Sprintf("[%q]", key))
+		}
+		value := n.Value
+		if n.Quoted {
+			value = fmt.Sprintf("%q", n.Value)
+		}
+		return field.String() + " " + string(n.Op) + " " + value
+	case *Logical:
+		children := make([]string, len(n.Children))
+		for i, child := range n.Children {
+			children[i] = dump(child)
+		}
+		return "(" + n.Op + " " + strings.Join(children, ", ") + ")"
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "bare value", input: "level = error", want: `"level" = error`},
+		{name: "double equals", input: `level == "error"`, want: `"level" = "error"`},
+		{name: "no spaces", input: "status>=500", want: `"status" >= 500`},
+		{name: "contains", input: "msg ~ timeout", want: `"msg" ~ timeout`},
+		{name: "not contains", input: "msg !~ 'health check'", want: `"msg" !~ "health check"`},
+		{name: "escaped quote", input: `msg = 'it\'s'`, want: `"msg" = "it's"`},
+		{name: "escaped backslash", input: `msg = "C:\\temp"`, want: `"msg" = "C:\\temp"`},
+		{name: "other quote inside string", input: `msg = "say 'hi'"`, want: `"msg" = "say 'hi'"`},
+		{name: "operator characters inside string", input: `msg = "a = b and c"`, want: `"msg" = "a = b and c"`},
+		{name: "quoted field", input: `"log level" = info`, want: `"log level" = info`},
+		{name: "backtick in field", input: "odd`name = 1", want: `"odd` + "`" + `name" = 1`},
+		{name: "nested field", input: "attrs.user.id = 42", want: `"attrs"["user"]["id"] = 42`},
+		{name: "quoted key with dots", input: `attrs."http.method" = GET`, want: `"attrs"["http.method"] = GET`},
+		{name: "quoted key with escaped quote", input: `attrs.'it\'s' = 1`, want: `"attrs"["it's"] = 1`},
+		{name: "and", input: "a = 1 and b = 2", want: `(AND "a" = 1, "b" = 2)`},
+		{name: "operators are case-insensitive", input: "a = 1 AND b = 2 Or c = 3", want: `(OR (AND "a" = 1, "b" = 2), "c" = 3)`},
+		{name: "same operator is flattened", input: "a = 1 and b = 2 and c = 3", want: `(AND "a" = 1, "b" = 2, "c" = 3)`},
+		{name: "left-associative without precedence", input: "a = 1 or b = 2 and c = 3", want: `(AND (OR "a" = 1, "b" = 2), "c" = 3)`},
+		{name: "and before or", input: "a = 1 and b = 2 or c = 3", want: `(OR (AND "a" = 1, "b" = 2), "c" = 3)`},
+		{name: "parentheses group", input: "a = 1 or (b = 2 and c = 3)", want: `(OR "a" = 1, (AND "b" = 2, "c" = 3))`},
+		{name: "operator before parenthesis", input: "a = 1 and(b = 2 or c = 3)", want: `(AND "a" = 1, (OR "b" = 2, "c" = 3))`},
+		{name: "implicit and inside parentheses", input: "(a = 1 b = 2)", want: `(AND "a" = 1, "b" = 2)`},
+		{name: "nested parentheses", input: "((a = 1))", want: `"a" = 1`},
+		{name: "surrounding whitespace", input: "\t a = 1 \n", want: `"a" = 1`},
+		{name: "field starting with an operator word", input: "a = 1 and android = 2", want: `(AND "a" = 1, "android" = 2)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if got := dump(node); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "empty", input: "", wantErr: ErrEmptyFilter.Error()},
+		{name: "whitespace", input: "  \t", wantErr: ErrEmptyFilter.Error()},
+		{name: "field only", input: "level", wantErr: `expected an operator after "level"`},
+		{name: "missing value", input: "level =", wantErr: "expected a value"},
+		{name: "missing value before parenthesis", input: "(level =)", wantErr: "expected a value"},
+		{name: "unknown operator", input: "level => 1", wantErr: `unknown operator "=>"`},
+		{name: "missing field", input: "= 1", wantErr: "expected a field name"},
+		{name: "empty path segment", input: "attrs..id = 1", wantErr: "expected a field name"},
+		{name: "leading dot", input: ".id = 1", wantErr: "expected a field name"},
+		{name: "trailing dot", input: "attrs. = 1", wantErr: "expected a field name"},
+		{name: "unterminated string", input: `msg = "timeout`, wantErr: "position 7: unterminated string"},
+		{name: "unterminated field", input: `"msg = 1`, wantErr: "unterminated string"},
+		{name: "escaped closing quote", input: `msg = 'it\'`, wantErr: "unterminated string"},
+		{name: "missing boolean operator", input: "a = 1 b = 2", wantErr: "missing boolean operator"},
+		{name: "operator word glued to field", input: "a = 1 andb = 2", wantErr: "missing boolean operator"},
+		{name: "dangling operator", input: "a = 1 and", wantErr: "unexpected end of filter"},
+		{name: "leading operator", input: "and a = 1", wantErr: `expected an operator after "and"`},
+		{name: "not is unsupported", input: "not level = error", wantErr: `expected an operator after "not"`},
+		{name: "unclosed parenthesis", input: "(a = 1", wantErr: "expected closing parenthesis"},
+		{name: "extra closing parenthesis", input: "a = 1)", wantErr: `unexpected ")"`},
+		{name: "empty parentheses", input: "()", wantErr: "unexpected closing parenthesis"},
+		{name: "select part", input: "| msg", wantErr: "selecting fields with | is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err == nil {
+				t.Fatalf("Parse(%q) = %s, want error containing %q", tt.input, dump(node), tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse(%q) error %q does not contain %q", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := Parse(" "); !errors.Is(err, ErrEmptyFilter) {
+		t.Errorf("empty filter error = %v, want ErrEmptyFilter", err)
+	}
+}
@@ -0,0 +1,112 @@
+package logchefql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// numberPattern matches unquoted values compared as numbers.
+var numberPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// Column is the column a filter field resolves to.
+type Column struct {
+	Name string
+	Type string // ClickHouse type, used to address nested fields of Map and JSON columns
+	// Match, when set, renders the condition of an equality with a value, e.g. to compare
+	// a mapped severity level with the raw values it maps to. It returns false to fall back
+	// to a plain comparison.
+	Match func(value string) (string, bool)
+}
+
+// Resolver returns the column a filter field refers to.
+type Resolver func(field string) (*Column, error)
+
+// ToSQL translates a parsed filter into a ClickHouse condition, resolving its fields with resolve.
+func ToSQL(node Node, resolve Resolver) (string, error) {
+	switch n := node.(type) {
+	case *Comparison:
+		return comparisonSQL(n, resolve)
+	case *Logical:
+		conditions := make([]string, 0, len(n.Children))
+		for _, child := range n.Children {
+			condition, err := ToSQL(child, resolve)
+			if err != nil {
+				return "", err
+			}
+			conditions = append(conditions, "("+condition+")")
+		}
+		return strings.Join(conditions, " "+n.Op+" "), nil
+	}
+	return "", fmt.Errorf("unknown filter node %T", node)
+}
+
+func comparisonSQL(cmp *Comparison, resolve Resolver) (string, error) {
+	column, err := resolve(cmp.Field)
+	if err != nil {
+		return "", err
+	}
+
+	if column.Match != nil && len(cmp.Path) == 0 && (cmp.Op == OpEquals || cmp.Op == OpNotEquals) {
+		if condition, ok := column.Match(cmp.Value); ok {
+			if cmp.Op == OpNotEquals {
+				return "NOT (" + condition + ")", nil
+			}
+			return condition, nil
+		}
+	}
+
+	expr := quoteIdentifier(column.Name)
+	if len(cmp.Path) > 0 {
+		if strings.HasPrefix(strings.ToLower(column.Type), "map(") {
+			// Map keys are flat: attrs.http.method is the key "http.method".
+			expr += "[" + quoteString(strings.Join(cmp.Path, ".")) + "]"
+		} else {
+			keys := make([]string, len(cmp.Path))
+			for i, key := range cmp.Path {
+				keys[i] = quoteString(key)
+			}
+			expr = "JSONExtractString(" + expr + ", " + strings.Join(keys, ", ") + ")"
+		}
+	}
+
+	switch cmp.Op {
+	case OpContains:
+		return fmt.Sprintf("positionCaseInsensitive(%s, %s) > 0", expr, quoteString(cmp.Value)), nil
+	case OpNotContains:
+		return fmt.Sprintf("positionCaseInsensitive(%s, %s) = 0", expr, quoteString(cmp.Value)), nil
+	}
+	return fmt.Sprintf("%s %s %s", expr, cmp.Op, formatValue(cmp)), nil
+}
+
+// formatValue formats the value of a comparison as a ClickHouse literal. Unquoted numbers,
+// booleans and null keep their type.
+func formatValue(cmp *Comparison) string {
+	if cmp.Quoted {
+		return quoteString(cmp.Value)
+	}
+	switch strings.ToLower(cmp.Value) {
+	case "null":
+		return "NULL"
+	case "true":
+		return "1"
+	case "false":
+		return "0"
+	}
+	if numberPattern.MatchString(cmp.Value) {
+		return cmp.Value
+	}
+	return quoteString(cmp.Value)
+}
+
+// quoteIdentifier wraps an identifier in backticks, escaping any embedded backticks.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteString returns a single-quoted ClickHouse string literal.
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `''`)
+	return "'" + s + "'"
+}
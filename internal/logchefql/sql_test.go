@@ -0,0 +1,113 @@
+package logchefql
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testColumns are the columns of the test resolver, by field.
+var testColumns = map[string]*Column{
+	"msg":      {Name: "msg", Type: "String"},
+	"status":   {Name: "status", Type: "UInt16"},
+	"attrs":    {Name: "attrs", Type: "Map(LowCardinality(String), String)"},
+	"payload":  {Name: "payload", Type: "JSON"},
+	"odd`name": {Name: "odd`name", Type: "String"},
+	"service":  {Name: "service.name", Type: "String"},
+	"level": {Name: "level", Type: "String", Match: func(value string) (string, bool) {
+		if value != "error" {
+			return "", false
+		}
+		return "`severity` IN (3, 4)", true
+	}},
+}
+
+func testResolver(field string) (*Column, error) {
+	column, ok := testColumns[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+	return column, nil
+}
+
+func TestToSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "string value", input: "msg = timeout", want: "`msg` = 'timeout'"},
+		{name: "single quote escaped", input: `msg = "it's"`, want: "`msg` = 'it''s'"},
+		{name: "backslash escaped", input: `msg = "C:\\temp"`, want: "`msg` = 'C:\\\\temp'"},
+		{name: "backslash before quote", input: `msg = "\\'"`, want: "`msg` = '\\\\'''"},
+		{name: "injection attempt stays a literal", input: `msg = "x' OR 1=1 --"`, want: "`msg` = 'x'' OR 1=1 --'"},
+		{name: "backtick in column", input: "odd`name = x", want: "`odd``name` = 'x'"},
+		{name: "column with dots", input: "service = api", want: "`service.name` = 'api'"},
+		{name: "integer", input: "status >= 500", want: "`status` >= 500"},
+		{name: "negative decimal", input: "status < -1.5", want: "`status` < -1.5"},
+		{name: "quoted number stays a string", input: `status = "500"`, want: "`status` = '500'"},
+		{name: "exponent is a string", input: "status = 1e3", want: "`status` = '1e3'"},
+		{name: "true", input: "msg = true", want: "`msg` = 1"},
+		{name: "false", input: "msg != FALSE", want: "`msg` != 0"},
+		{name: "null", input: "msg = null", want: "`msg` = NULL"},
+		{name: "quoted null", input: `msg = "null"`, want: "`msg` = 'null'"},
+		{name: "contains", input: "msg ~ Timeout", want: "positionCaseInsensitive(`msg`, 'Timeout') > 0"},
+		{name: "not contains", input: `msg !~ "it's"`, want: "positionCaseInsensitive(`msg`, 'it''s') = 0"},
+		{name: "map key", input: "attrs.user = bob", want: "`attrs`['user'] = 'bob'"},
+		{name: "map keys are flat", input: "attrs.http.method = GET", want: "`attrs`['http.method'] = 'GET'"},
+		{name: "quoted map key", input: `attrs."http.method" = GET`, want: "`attrs`['http.method'] = 'GET'"},
+		{name: "map key escaped", input: `attrs."it's" = 1`, want: "`attrs`['it''s'] = 1"},
+		{name: "map contains", input: "attrs.path ~ /api", want: "positionCaseInsensitive(`attrs`['path'], '/api') > 0"},
+		{name: "json path", input: "payload.user.id = 42", want: "JSONExtractString(`payload`, 'user', 'id') = 42"},
+		{name: "quoted json key", input: `payload."a.b".c = x`, want: "JSONExtractString(`payload`, 'a.b', 'c') = 'x'"},
+		{name: "json key escaped", input: `payload.'a\\b' = x`, want: `JSONExtractString(` + "`payload`" + `, 'a\\b') = 'x'`},
+		{name: "match", input: "level = error", want: "`severity` IN (3, 4)"},
+		{name: "negated match", input: "level != error", want: "NOT (`severity` IN (3, 4))"},
+		{name: "match falls back", input: "level = debug", want: "`level` = 'debug'"},
+		{name: "match only for equality", input: "level ~ error", want: "positionCaseInsensitive(`level`, 'error') > 0"},
+		{name: "and", input: "msg = a and status = 1", want: "(`msg` = 'a') AND (`status` = 1)"},
+		{name: "or", input: "msg = a or msg = b or msg = c", want: "(`msg` = 'a') OR (`msg` = 'b') OR (`msg` = 'c')"},
+		{name: "left-associative", input: "msg = a or msg = b and status = 1", want: "((`msg` = 'a') OR (`msg` = 'b')) AND (`status` = 1)"},
+		{name: "grouped", input: "msg = a or (msg = b and status = 1)", want: "(`msg` = 'a') OR ((`msg` = 'b') AND (`status` = 1))"},
+		{name: "negated match inside group", input: "(level != error or status = 1)", want: "(NOT (`severity` IN (3, 4))) OR (`status` = 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			got, err := ToSQL(node, testResolver)
+			if err != nil {
+				t.Fatalf("ToSQL(%q): %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ToSQL(%q)\n got: %s\nwant: %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToSQLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "unknown field", input: "host = web1", wantErr: `unknown field "host"`},
+		{name: "unknown field in group", input: "msg = a and (status = 1 or host = web1)", wantErr: `unknown field "host"`},
+		{name: "unknown nested field", input: "labels.app = api", wantErr: `unknown field "labels"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			got, err := ToSQL(node, testResolver)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ToSQL(%q) = %q, %v; want error containing %q", tt.input, got, err, tt.wantErr)
+			}
+		})
+	}
+}
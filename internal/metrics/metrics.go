@@ -110,6 +110,43 @@ func RecordHistogram(source *models.Source, success bool, duration time.Duration
 	metrics.GetOrCreateHistogram(durationLabels).Update(duration.Seconds())
 }
 
+// RecordQueryCache records a query result cache lookup. Result is "hit", "miss" or "bypass";
+// tier is "memory" or "disk" for hits and empty otherwise.
+func RecordQueryCache(source *models.Source, queryType, result, tier string) {
+	labels := fmt.Sprintf(`logchef_query_cache_requests_total{source_id="%d",source_name="%s",query_type="%s",result="%s",tier="%s"}`,
+		source.ID, source.Name, queryType, result, tier)
+	metrics.GetOrCreateCounter(labels).Inc()
+}
+
+// RecordQueryCacheEvictions records results evicted from the in-memory query cache to stay within its bounds
+func RecordQueryCacheEvictions(count int) {
+	metrics.GetOrCreateCounter("logchef_query_cache_evictions_total").Add(count)
+}
+
+// UpdateQueryCacheSize sets the number and JSON encoded size of results in the in-memory query cache
+func UpdateQueryCacheSize(entries int, bytes int64) {
+	metrics.GetOrCreateGauge("logchef_query_cache_entries", nil).Set(float64(entries))
+	metrics.GetOrCreateGauge("logchef_query_cache_bytes", nil).Set(float64(bytes))
+}
+
+// RecordQueryLimitRejection records a query rejected by a query limit. Limit is the limit that
+// was exceeded, e.g. "user_concurrency" or "team_rate".
+func RecordQueryLimitRejection(limit, queryType string) {
+	labels := fmt.Sprintf(`logchef_query_limit_rejections_total{limit="%s",query_type="%s"}`, limit, queryType)
+	metrics.GetOrCreateCounter(labels).Inc()
+}
+
+// RecordQueryLimitWait records how long a query queued for a query limit before it ran
+func RecordQueryLimitWait(queryType string, duration time.Duration) {
+	labels := fmt.Sprintf(`logchef_query_limit_wait_seconds{query_type="%s"}`, queryType)
+	metrics.GetOrCreateHistogram(labels).Update(duration.Seconds())
+}
+
+// UpdateActiveQueries sets the number of running queries tracked for query limits
+func UpdateActiveQueries(count int) {
+	metrics.GetOrCreateGauge("logchef_active_queries", nil).Set(float64(count))
+}
+
 // RecordClickHouseConnectionStatus sets connection status for a source
 func RecordClickHouseConnectionStatus(source *models.Source, healthy bool) {
 	status := 0.0
@@ -0,0 +1,174 @@
+package patterns
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSimilarity is the minimum fraction of matching tokens for a line to join a cluster.
+	DefaultSimilarity = 0.5
+	// DefaultMaxChildren limits the fan-out of a prefix node before lines fall into the wildcard branch.
+	DefaultMaxChildren = 100
+	// prefixDepth is the number of leading tokens used to route lines in the prefix tree.
+	prefixDepth = 2
+)
+
+// Cluster is a group of log lines sharing the same template.
+type Cluster struct {
+	ID      int
+	Tokens  []string // Template tokens; variable positions hold placeholders.
+	Count   int
+	Members []int // Indexes of the first lines that joined this cluster, bounded by the parser's maxMembers.
+}
+
+// Template returns the cluster's template as a single string.
+func (c *Cluster) Template() string {
+	return strings.Join(c.Tokens, " ")
+}
+
+// node is a prefix tree node. Leaves hold the clusters for a routing path.
+type node struct {
+	children map[string]*node
+	clusters []*Cluster
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// Drain implements the Drain log parsing algorithm: lines are routed through a
+// fixed-depth tree keyed by token count and leading tokens, then matched against
+// the clusters in the leaf by positional token similarity.
+type Drain struct {
+	root        *node
+	clusters    []*Cluster
+	similarity  float64
+	maxChildren int
+	maxMembers  int
+}
+
+// NewDrain creates a Drain parser. maxMembers bounds how many member indexes each cluster keeps.
+func NewDrain(similarity float64, maxChildren, maxMembers int) *Drain {
+	if similarity <= 0 || similarity > 1 {
+		similarity = DefaultSimilarity
+	}
+	if maxChildren <= 0 {
+		maxChildren = DefaultMaxChildren
+	}
+	return &Drain{
+		root:        newNode(),
+		similarity:  similarity,
+		maxChildren: maxChildren,
+		maxMembers:  maxMembers,
+	}
+}
+
+// Add masks and tokenizes a line, assigns it to a cluster and returns that cluster.
+// index identifies the line and is recorded as a cluster member.
+func (d *Drain) Add(line string, index int) *Cluster {
+	tokens := Tokenize(Mask(line))
+	leaf := d.route(tokens)
+
+	cluster := d.bestMatch(leaf.clusters, tokens)
+	if cluster == nil {
+		cluster = &Cluster{
+			ID:     len(d.clusters) + 1,
+			Tokens: append([]string(nil), tokens...),
+		}
+		leaf.clusters = append(leaf.clusters, cluster)
+		d.clusters = append(d.clusters, cluster)
+	} else {
+		// Positions that differ become wildcards.
+		for i, token := range tokens {
+			if cluster.Tokens[i] != token {
+				cluster.Tokens[i] = PlaceholderWildcard
+			}
+		}
+	}
+
+	cluster.Count++
+	if len(cluster.Members) < d.maxMembers {
+		cluster.Members = append(cluster.Members, index)
+	}
+	return cluster
+}
+
+// Clusters returns all clusters ordered by descending count.
+func (d *Drain) Clusters() []*Cluster {
+	out := append([]*Cluster(nil), d.clusters...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Count > out[j].Count
+	})
+	return out
+}
+
+// route walks (and grows) the prefix tree for the given tokens and returns the leaf.
+func (d *Drain) route(tokens []string) *node {
+	// First level: token count, so only lines of equal length are compared.
+	current := child(d.root, lengthKey(len(tokens)), d.maxChildren)
+
+	for depth := 0; depth < prefixDepth && depth < len(tokens); depth++ {
+		key := tokens[depth]
+		// Tokens that still carry variable data route to the wildcard branch.
+		if strings.ContainsAny(key, "0123456789") {
+			key = PlaceholderWildcard
+		}
+		current = child(current, key, d.maxChildren)
+	}
+	return current
+}
+
+// child returns the child node for key, creating it if the fan-out allows.
+// Once a node is full, new keys share the wildcard child.
+func child(n *node, key string, maxChildren int) *node {
+	if next, ok := n.children[key]; ok {
+		return next
+	}
+	if len(n.children) >= maxChildren {
+		key = PlaceholderWildcard
+		if next, ok := n.children[key]; ok {
+			return next
+		}
+	}
+	next := newNode()
+	n.children[key] = next
+	return next
+}
+
+// bestMatch returns the cluster with the highest similarity above the threshold.
+func (d *Drain) bestMatch(clusters []*Cluster, tokens []string) *Cluster {
+	var best *Cluster
+	bestScore := -1.0
+	for _, cluster := range clusters {
+		if len(cluster.Tokens) != len(tokens) {
+			continue
+		}
+		score := similarity(cluster.Tokens, tokens)
+		if score >= d.similarity && score > bestScore {
+			best, bestScore = cluster, score
+		}
+	}
+	return best
+}
+
+// similarity is the fraction of positions where template and line tokens agree.
+// Wildcards in the template match any token.
+func similarity(template, tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 1
+	}
+	same := 0
+	for i, token := range tokens {
+		if template[i] == PlaceholderWildcard || template[i] == token {
+			same++
+		}
+	}
+	return float64(same) / float64(len(tokens))
+}
+
+// lengthKey is the first-level routing key for a line with n tokens.
+func lengthKey(n int) string {
+	return "len:" + strconv.Itoa(n)
+}
@@ -0,0 +1,113 @@
+package patterns
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholders substituted for variable parts of a log line.
+const (
+	PlaceholderUUID     = "<UUID>"
+	PlaceholderIP       = "<IP>"
+	PlaceholderHex      = "<HEX>"
+	PlaceholderNumber   = "<NUM>"
+	PlaceholderWildcard = "<*>"
+)
+
+// masker replaces a class of variable tokens with a placeholder.
+type masker struct {
+	re          *regexp.Regexp
+	placeholder string
+	match       string            // RE2 expression matching the original value, used for drill-down filters.
+	accept      func(string) bool // Optional check applied to each regex match.
+}
+
+// Maskers are applied in order; more specific classes must come first so that,
+// for example, the digits of a UUID aren't masked as numbers.
+var maskers = []masker{
+	{
+		re:          regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`),
+		placeholder: PlaceholderUUID,
+		match:       `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	},
+	{
+		re:          regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d{1,5})?\b`),
+		placeholder: PlaceholderIP,
+		match:       `\d{1,3}(?:\.\d{1,3}){3}(?::\d{1,5})?`,
+	},
+	{
+		re:          regexp.MustCompile(`\b(?:0[xX][0-9a-fA-F]+|[0-9a-fA-F]{6,})\b`),
+		placeholder: PlaceholderHex,
+		match:       `(?:0[xX])?[0-9a-fA-F]+`,
+		accept:      isHexValue,
+	},
+	{
+		re:          regexp.MustCompile(`-?\b\d+(?:\.\d+)?\b`),
+		placeholder: PlaceholderNumber,
+		match:       `-?\d+(?:\.\d+)?`,
+	},
+}
+
+// Mask replaces UUIDs, IP addresses, hex strings and numbers in a line with placeholders.
+func Mask(line string) string {
+	for _, m := range maskers {
+		if m.accept == nil {
+			line = m.re.ReplaceAllLiteralString(line, m.placeholder)
+			continue
+		}
+		line = m.re.ReplaceAllStringFunc(line, func(s string) string {
+			if m.accept(s) {
+				return m.placeholder
+			}
+			return s
+		})
+	}
+	return line
+}
+
+// isHexValue reports whether a candidate looks like a hex value rather than a word
+// or a plain number: it must be 0x-prefixed or mix digits with hex letters.
+func isHexValue(s string) bool {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return true
+	}
+	return strings.ContainsAny(s, "0123456789") && strings.ContainsAny(s, "abcdefABCDEF")
+}
+
+// Tokenize splits a masked line into whitespace separated tokens.
+func Tokenize(line string) []string {
+	return strings.Fields(line)
+}
+
+// placeholderRegex returns the RE2 fragment matching a placeholder's original values.
+func placeholderRegex(placeholder string) string {
+	for _, m := range maskers {
+		if m.placeholder == placeholder {
+			return m.match
+		}
+	}
+	return `\S+` // Wildcard: any single token.
+}
+
+// placeholderSplitter finds placeholders embedded in a template token, e.g. "user=<NUM>".
+var placeholderSplitter = regexp.MustCompile(`<UUID>|<IP>|<HEX>|<NUM>|<\*>`)
+
+// TemplateRegex converts a template into an anchored RE2 expression matching
+// every line that belongs to it. Literal text is quoted; placeholders match
+// the values they replaced and tokens are separated by any whitespace.
+func TemplateRegex(template string) string {
+	tokens := Tokenize(template)
+	parts := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		var b strings.Builder
+		last := 0
+		for _, loc := range placeholderSplitter.FindAllStringIndex(token, -1) {
+			b.WriteString(regexp.QuoteMeta(token[last:loc[0]]))
+			b.WriteString(placeholderRegex(token[loc[0]:loc[1]]))
+			last = loc[1]
+		}
+		b.WriteString(regexp.QuoteMeta(token[last:]))
+		parts = append(parts, b.String())
+	}
+	return `^\s*` + strings.Join(parts, `\s+`) + `\s*$`
+}
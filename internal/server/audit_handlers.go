@@ -0,0 +1,145 @@
+package server
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// --- Query Audit Log Handlers ---
+
+// newQueryAuditEntry starts an audit entry for a query run by the request's user
+// (and API token, if used) against a team's source.
+func (s *Server) newQueryAuditEntry(c *fiber.Ctx, queryType models.QueryAuditType, teamID models.TeamID, sourceID models.SourceID, rawSQL string) *models.QueryAuditEntry {
+	entry := &models.QueryAuditEntry{
+		TeamID:    &teamID,
+		SourceID:  &sourceID,
+		QueryType: queryType,
+		RawSQL:    rawSQL,
+	}
+	setAuditActor(c, entry)
+	return entry
+}
+
+// setAuditActor records the request's user and API token, if used, on an audit entry.
+func setAuditActor(c *fiber.Ctx, entry *models.QueryAuditEntry) {
+	if user, ok := c.Locals("user").(*models.User); ok && user != nil {
+		entry.UserID = &user.ID
+		entry.UserEmail = user.Email
+	}
+	if token, ok := c.Locals("api_token").(*models.APIToken); ok && token != nil {
+		entry.APITokenID = &token.ID
+	}
+}
+
+// recordQueryAudit completes an audit entry with the query outcome and queues it for writing.
+func (s *Server) recordQueryAudit(entry *models.QueryAuditEntry, start time.Time, rows int, err error) {
+	entry.CreatedAt = start.UTC()
+	entry.DurationMs = time.Since(start).Milliseconds()
+	entry.RowsReturned = rows
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.auditor.Record(entry)
+}
+
+// handleListMyQueryHistory returns the queries recently run by the current user.
+// URL: GET /api/v1/me/query-history?limit=50&offset=0
+// Requires: Authenticated user
+func (s *Server) handleListMyQueryHistory(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	entries, err := core.ListUserQueryHistory(c.Context(), s.sqlite, user.ID, c.QueryInt("limit"), c.QueryInt("offset"))
+	if err != nil {
+		s.log.Error("failed to list query history", slog.Any("error", err), "user_id", user.ID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to list query history", models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, entries)
+}
+
+// handleSearchQueryAuditLog searches the query audit log.
+// URL: GET /api/v1/admin/query-audit
+// Query params: user_id, team_id, source_id, api_token_id, query_type (logs|histogram|patterns),
+// status (success|error), q (SQL substring), start_time, end_time (RFC3339), limit, offset.
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleSearchQueryAuditLog(c *fiber.Ctx) error {
+	var filter models.QueryAuditFilter
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := core.ParseUserID(v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid user_id", models.ValidationErrorType)
+		}
+		filter.UserID = &id
+	}
+	if v := c.Query("team_id"); v != "" {
+		id, err := core.ParseTeamID(v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team_id", models.ValidationErrorType)
+		}
+		filter.TeamID = &id
+	}
+	if v := c.Query("source_id"); v != "" {
+		id, err := core.ParseSourceID(v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source_id", models.ValidationErrorType)
+		}
+		filter.SourceID = &id
+	}
+	if v := c.Query("api_token_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid api_token_id", models.ValidationErrorType)
+		}
+		filter.APITokenID = &id
+	}
+	switch c.Query("status") {
+	case "":
+	case "success":
+		failed := false
+		filter.Failed = &failed
+	case "error":
+		failed := true
+		filter.Failed = &failed
+	default:
+		return SendErrorWithType(c, fiber.StatusBadRequest, "status must be one of: success, error", models.ValidationErrorType)
+	}
+	if v := c.Query("start_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid start_time, expected RFC3339", models.ValidationErrorType)
+		}
+		filter.StartTime = &t
+	}
+	if v := c.Query("end_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid end_time, expected RFC3339", models.ValidationErrorType)
+		}
+		filter.EndTime = &t
+	}
+	filter.QueryType = models.QueryAuditType(c.Query("query_type"))
+	filter.Search = c.Query("q")
+	filter.Limit = c.QueryInt("limit")
+	filter.Offset = c.QueryInt("offset")
+
+	entries, err := core.SearchQueryAuditLog(c.Context(), s.sqlite, filter)
+	if err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		s.log.Error("failed to search query audit log", slog.Any("error", err))
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to search query audit log", models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, entries)
+}
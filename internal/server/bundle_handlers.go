@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleExportBundle exports sources (without credentials), teams, their source links and
+// their collections as a downloadable bundle.
+// URL: GET /api/v1/admin/bundle/export
+// Query params: format (yaml|json, default yaml), team_ids (comma-separated, default all teams).
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleExportBundle(c *fiber.Ctx) error {
+	format, err := core.ParseBundleFormat(c.Query("format"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+	var teamIDs []models.TeamID
+	if v := c.Query("team_ids"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			id, err := core.ParseTeamID(strings.TrimSpace(part))
+			if err != nil {
+				return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team_ids", models.ValidationErrorType)
+			}
+			teamIDs = append(teamIDs, id)
+		}
+	}
+
+	bundle, err := core.ExportBundle(c.Context(), s.sqlite, teamIDs)
+	if err != nil {
+		if errors.Is(err, core.ErrTeamNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, err.Error(), models.NotFoundErrorType)
+		}
+		s.log.Error("failed to export bundle", slog.Any("error", err))
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to export bundle", models.DatabaseErrorType)
+	}
+	data, err := core.EncodeBundle(bundle, format)
+	if err != nil {
+		s.log.Error("failed to encode bundle", slog.Any("error", err))
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to encode bundle", models.GeneralErrorType)
+	}
+
+	contentType := "application/yaml"
+	if format == models.BundleFormatJSON {
+		contentType = fiber.MIMEApplicationJSON
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment(fmt.Sprintf("logchef-bundle-%s.%s", bundle.ExportedAt.Format("20060102-150405"), format))
+	return c.Status(fiber.StatusOK).Send(data)
+}
+
+// handleImportBundle imports a bundle sent as the request body.
+// URL: POST /api/v1/admin/bundle/import
+// Query params: format (yaml|json, defaults to json for JSON content types and yaml otherwise),
+// dry_run (bool), conflict (skip|overwrite|rename, default skip).
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleImportBundle(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	formatName := c.Query("format")
+	if formatName == "" && strings.Contains(c.Get(fiber.HeaderContentType), "json") {
+		formatName = string(models.BundleFormatJSON)
+	}
+	format, err := core.ParseBundleFormat(formatName)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+	opts := models.BundleImportOptions{
+		Conflict:   models.BundleConflictStrategy(c.Query("conflict")),
+		ImportedBy: &user.ID,
+	}
+	if v := c.Query("dry_run"); v != "" {
+		if opts.DryRun, err = strconv.ParseBool(v); err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid dry_run", models.ValidationErrorType)
+		}
+	}
+
+	bundle, err := core.DecodeBundle(c.Body(), format)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+	result, err := core.ImportBundle(c.Context(), s.sqlite, s.mapKeys, s.log, bundle, opts)
+	if err != nil {
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Error(), models.ValidationErrorType)
+		}
+		s.log.Error("failed to import bundle", slog.Any("error", err))
+		return SendErrorWithType(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to import bundle: %v", err), models.DatabaseErrorType)
+	}
+
+	if !result.DryRun {
+		s.reloadBundleSources(c, result)
+	}
+	return SendSuccess(c, fiber.StatusOK, result)
+}
+
+// reloadBundleSources (re)connects the sources an import created or updated.
+func (s *Server) reloadBundleSources(c *fiber.Ctx, result *models.BundleImportResult) {
+	for _, item := range result.Items {
+		if item.Kind != "source" || item.NewID == 0 {
+			continue
+		}
+		if item.Action != models.BundleImportCreate && item.Action != models.BundleImportUpdate {
+			continue
+		}
+		sourceID := models.SourceID(item.NewID)
+		source, err := s.sqlite.GetSource(c.Context(), sourceID)
+		if err != nil {
+			s.log.Warn("failed to load imported source", "source_id", sourceID, "error", err)
+			continue
+		}
+		_ = s.clickhouse.RemoveSource(sourceID)
+		if err := s.clickhouse.AddSource(source); err != nil {
+			s.log.Warn("failed to connect imported source, will attempt recovery via health checks",
+				"source_id", sourceID, "error", err)
+		}
+	}
+}
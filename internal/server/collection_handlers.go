@@ -0,0 +1,188 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// --- Team-wide Collection Handlers ---
+
+// handleSearchTeamCollections searches the saved queries (collections) of a team across all its sources.
+// URL: GET /api/v1/teams/:teamID/collections/search
+// Query params: q (matches name, description, tags and query text), source_id, folder_id (0 for unfiled),
+// tag, favorites (true for the user's favorites only), sort (last_used|updated|name, default last_used),
+// limit, offset.
+// Requires: Team membership
+func (s *Server) handleSearchTeamCollections(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	filter := models.SavedQuerySearchFilter{
+		TeamID:        teamID,
+		UserID:        getUserIDFromContext(c),
+		Tag:           c.Query("tag"),
+		FavoritesOnly: c.QueryBool("favorites"),
+		Search:        c.Query("q"),
+		Sort:          models.SavedQuerySort(c.Query("sort")),
+		Limit:         c.QueryInt("limit"),
+		Offset:        c.QueryInt("offset"),
+		// A token restricted to team/source pairs only finds the queries of the sources it covers.
+		AllowSource: tokenSourceFilter(c, teamID),
+	}
+	if v := c.Query("source_id"); v != "" {
+		id, err := core.ParseSourceID(v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source_id", models.ValidationErrorType)
+		}
+		filter.SourceID = &id
+	}
+	if v := c.Query("folder_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 0 {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid folder_id", models.ValidationErrorType)
+		}
+		filter.FolderID = &id
+	}
+
+	queries, err := core.SearchSavedQueries(c.Context(), s.sqlite, filter)
+	if err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		s.log.Error("failed to search collections", slog.Any("error", err), "team_id", teamID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to search collections", models.DatabaseErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, queries)
+}
+
+// handleListTeamCollectionTags lists the tags used by the saved queries of a team.
+// URL: GET /api/v1/teams/:teamID/collections/tags
+// Requires: Team membership
+func (s *Server) handleListTeamCollectionTags(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	tags, err := core.ListTeamTags(c.Context(), s.sqlite, teamID, tokenSourceFilter(c, teamID))
+	if err != nil {
+		s.log.Error("failed to list collection tags", slog.Any("error", err), "team_id", teamID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to list tags", models.DatabaseErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, tags)
+}
+
+// --- Collection Folder Handlers ---
+
+// sendFolderError maps core collection folder errors to responses.
+func (s *Server) sendFolderError(c *fiber.Ctx, err error, msg string) error {
+	var validationErr *core.ValidationError
+	switch {
+	case errors.Is(err, core.ErrFolderNotFound):
+		return SendErrorWithType(c, fiber.StatusNotFound, "Folder not found", models.NotFoundErrorType)
+	case errors.Is(err, core.ErrFolderAlreadyExists):
+		return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+	case errors.As(err, &validationErr):
+		return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+	}
+	s.log.Error(msg, slog.Any("error", err), "team_id", c.Params("teamID"), "folder_id", c.Params("folderID"))
+	return SendErrorWithType(c, fiber.StatusInternalServerError, msg, models.GeneralErrorType)
+}
+
+// parseFolderParams parses the team and folder IDs of a collection folder route.
+func parseFolderParams(c *fiber.Ctx) (models.TeamID, int, error) {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return 0, 0, errors.New("Invalid team ID format")
+	}
+	folderID, err := strconv.Atoi(c.Params("folderID"))
+	if err != nil {
+		return 0, 0, errors.New("Invalid folder ID format")
+	}
+	return teamID, folderID, nil
+}
+
+// handleListCollectionFolders lists the collection folders of a team.
+// URL: GET /api/v1/teams/:teamID/collection-folders
+// Requires: Team membership
+func (s *Server) handleListCollectionFolders(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	folders, err := core.ListCollectionFolders(c.Context(), s.sqlite, teamID)
+	if err != nil {
+		return s.sendFolderError(c, err, "Failed to list folders")
+	}
+	return SendSuccess(c, fiber.StatusOK, folders)
+}
+
+// handleCreateCollectionFolder creates a collection folder for a team. Body: {"name": string}.
+// URL: POST /api/v1/teams/:teamID/collection-folders
+// Requires: Team editor, team admin or global admin
+func (s *Server) handleCreateCollectionFolder(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	folder, err := core.CreateCollectionFolder(c.Context(), s.sqlite, s.log, teamID, req.Name)
+	if err != nil {
+		return s.sendFolderError(c, err, "Failed to create folder")
+	}
+	return SendSuccess(c, fiber.StatusCreated, folder)
+}
+
+// handleRenameCollectionFolder renames a collection folder of a team. Body: {"name": string}.
+// URL: PUT /api/v1/teams/:teamID/collection-folders/:folderID
+// Requires: Team editor, team admin or global admin
+func (s *Server) handleRenameCollectionFolder(c *fiber.Ctx) error {
+	teamID, folderID, err := parseFolderParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	folder, err := core.RenameCollectionFolder(c.Context(), s.sqlite, s.log, teamID, folderID, req.Name)
+	if err != nil {
+		return s.sendFolderError(c, err, "Failed to rename folder")
+	}
+	return SendSuccess(c, fiber.StatusOK, folder)
+}
+
+// handleDeleteCollectionFolder deletes a collection folder of a team. Its collections are kept, unfiled.
+// URL: DELETE /api/v1/teams/:teamID/collection-folders/:folderID
+// Requires: Team editor, team admin or global admin
+func (s *Server) handleDeleteCollectionFolder(c *fiber.Ctx) error {
+	teamID, folderID, err := parseFolderParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	if err := core.DeleteCollectionFolder(c.Context(), s.sqlite, s.log, teamID, folderID); err != nil {
+		return s.sendFolderError(c, err, "Failed to delete folder")
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Folder deleted successfully"})
+}
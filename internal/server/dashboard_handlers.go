@@ -0,0 +1,194 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// --- Dashboard Handlers ---
+
+// parseDashboardParams parses the team and dashboard IDs of a dashboard route.
+func parseDashboardParams(c *fiber.Ctx) (models.TeamID, int, error) {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return 0, 0, errors.New("Invalid team ID format")
+	}
+	dashboardID, err := strconv.Atoi(c.Params("dashboardID"))
+	if err != nil {
+		return 0, 0, errors.New("Invalid dashboard ID format")
+	}
+	return teamID, dashboardID, nil
+}
+
+// sendDashboardError maps core dashboard errors to responses.
+func (s *Server) sendDashboardError(c *fiber.Ctx, err error, msg string) error {
+	var validationErr *core.ValidationError
+	switch {
+	case errors.Is(err, core.ErrDashboardNotFound):
+		return SendErrorWithType(c, fiber.StatusNotFound, "Dashboard not found", models.NotFoundErrorType)
+	case errors.As(err, &validationErr):
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+	s.log.Error(msg, slog.Any("error", err), "team_id", c.Params("teamID"), "dashboard_id", c.Params("dashboardID"))
+	return SendErrorWithType(c, fiber.StatusInternalServerError, msg, models.GeneralErrorType)
+}
+
+// handleListTeamDashboards lists the dashboards of a team.
+// URL: GET /api/v1/teams/:teamID/dashboards
+// Requires: Team membership
+func (s *Server) handleListTeamDashboards(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	dashboards, err := core.ListTeamDashboards(c.Context(), s.sqlite, teamID)
+	if err != nil {
+		return s.sendDashboardError(c, err, "Failed to list dashboards")
+	}
+	// A token restricted to team/source pairs only sees the panels of the sources it covers.
+	if allow := tokenSourceFilter(c, teamID); allow != nil {
+		for _, dashboard := range dashboards {
+			core.FilterDashboardPanels(dashboard, allow)
+		}
+	}
+	return SendSuccess(c, fiber.StatusOK, dashboards)
+}
+
+// handleGetTeamDashboard returns a dashboard of a team.
+// URL: GET /api/v1/teams/:teamID/dashboards/:dashboardID
+// Requires: Team membership
+func (s *Server) handleGetTeamDashboard(c *fiber.Ctx) error {
+	teamID, dashboardID, err := parseDashboardParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	dashboard, err := core.GetTeamDashboard(c.Context(), s.sqlite, teamID, dashboardID)
+	if err != nil {
+		return s.sendDashboardError(c, err, "Failed to get dashboard")
+	}
+	if allow := tokenSourceFilter(c, teamID); allow != nil {
+		core.FilterDashboardPanels(dashboard, allow)
+	}
+	return SendSuccess(c, fiber.StatusOK, dashboard)
+}
+
+// handleCreateTeamDashboard creates a dashboard for a team.
+// URL: POST /api/v1/teams/:teamID/dashboards
+// Requires: Team editor, team admin or global admin
+func (s *Server) handleCreateTeamDashboard(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	var req models.CreateDashboardRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	dashboard, err := core.CreateTeamDashboard(c.Context(), s.sqlite, s.log, teamID, req)
+	if err != nil {
+		return s.sendDashboardError(c, err, "Failed to create dashboard")
+	}
+	return SendSuccess(c, fiber.StatusCreated, dashboard)
+}
+
+// handleUpdateTeamDashboard updates a dashboard of a team. Omitted fields are unchanged.
+// URL: PUT /api/v1/teams/:teamID/dashboards/:dashboardID
+// Requires: Team editor, team admin or global admin
+func (s *Server) handleUpdateTeamDashboard(c *fiber.Ctx) error {
+	teamID, dashboardID, err := parseDashboardParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	var req models.UpdateDashboardRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	dashboard, err := core.UpdateTeamDashboard(c.Context(), s.sqlite, s.log, teamID, dashboardID, req)
+	if err != nil {
+		return s.sendDashboardError(c, err, "Failed to update dashboard")
+	}
+	return SendSuccess(c, fiber.StatusOK, dashboard)
+}
+
+// handleDeleteTeamDashboard deletes a dashboard of a team.
+// URL: DELETE /api/v1/teams/:teamID/dashboards/:dashboardID
+// Requires: Team editor, team admin or global admin
+func (s *Server) handleDeleteTeamDashboard(c *fiber.Ctx) error {
+	teamID, dashboardID, err := parseDashboardParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	if err := core.DeleteTeamDashboard(c.Context(), s.sqlite, s.log, teamID, dashboardID); err != nil {
+		return s.sendDashboardError(c, err, "Failed to delete dashboard")
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Dashboard deleted successfully"})
+}
+
+// handleRunTeamDashboard runs all (or the selected) panels of a dashboard concurrently and
+// returns each panel's result. A failing panel reports its error without failing the request.
+// URL: POST /api/v1/teams/:teamID/dashboards/:dashboardID/run
+// Requires: Team membership
+func (s *Server) handleRunTeamDashboard(c *fiber.Ctx) error {
+	teamID, dashboardID, err := parseDashboardParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	var req models.APIRunDashboardRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+		}
+	}
+	if err := models.ValidateQueryTimeout(req.QueryTimeout); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	// Panels can query any of the team's sources, so a token restricted to team/source
+	// pairs must cover the source of every panel that runs.
+	if token := apiTokenFromContext(c); core.APITokenHasResources(token) {
+		dashboard, err := core.GetTeamDashboard(c.Context(), s.sqlite, teamID, dashboardID)
+		if err != nil {
+			return s.sendDashboardError(c, err, "Failed to run dashboard")
+		}
+		for _, panel := range dashboard.Panels {
+			if len(req.PanelIDs) > 0 && !slices.Contains(req.PanelIDs, panel.ID) {
+				continue
+			}
+			if !core.APITokenAllowsSource(token, teamID, panel.SourceID) {
+				return SendErrorWithType(c, fiber.StatusForbidden, "API token is not allowed for the source of panel "+panel.ID, models.AuthorizationErrorType)
+			}
+		}
+	}
+
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Each panel's query counts against the query limits.
+	results, err := core.RunDashboard(c.Context(), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, dashboardID, req, s.config.Dashboards.MaxConcurrency, queryAcquirer(user.ID))
+	if err != nil {
+		return s.sendDashboardError(c, err, "Failed to run dashboard")
+	}
+
+	for _, result := range results {
+		setAuditActor(c, result.Audit)
+		s.auditor.Record(result.Audit)
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"panels": results})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// handleFederatedSearch runs a LogchefQL filter across several of the team's sources and returns
+// the rows merged by timestamp, with per-source results reporting sources that failed.
+// URL: POST /api/v1/teams/:teamID/search
+// Body: models.APIFederatedSearchRequest
+// Requires: Team membership
+func (s *Server) handleFederatedSearch(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	var req models.APIFederatedSearchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Each source's query counts against the query limits.
+	params := core.FederatedSearchParams{
+		Query:        req.Query,
+		SourceIDs:    req.SourceIDs,
+		Limit:        req.Limit,
+		AcquireQuery: queryAcquirer(user.ID),
+		QueryTimeout: req.QueryTimeout,
+	}
+	if req.StartTime > 0 {
+		params.Start = time.UnixMilli(req.StartTime)
+	}
+	if req.EndTime > 0 {
+		params.End = time.UnixMilli(req.EndTime)
+	}
+	// A token restricted to team/source pairs only searches the sources it covers.
+	params.AllowSource = tokenSourceFilter(c, teamID)
+
+	result, audits, err := core.FederatedSearch(c.Context(), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, params)
+	if err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		if errors.Is(err, core.ErrTeamNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Team not found", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to run federated search", slog.Any("error", err), "team_id", teamID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to run search", models.DatabaseErrorType)
+	}
+
+	for _, audit := range audits {
+		setAuditActor(c, audit)
+		s.auditor.Record(audit)
+	}
+	return SendSuccess(c, fiber.StatusOK, result)
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleListJobs returns the schedule and the last run of every background maintenance job.
+// URL: GET /api/v1/admin/jobs
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleListJobs(c *fiber.Ctx) error {
+	return SendSuccess(c, fiber.StatusOK, s.scheduler.Status())
+}
+
+// handleRunJob runs a background job now, outside its schedule. The job runs in the
+// background; its outcome is reported by handleListJobs.
+// URL: POST /api/v1/admin/jobs/:name/run
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleRunJob(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := s.scheduler.Trigger(name); err != nil {
+		switch {
+		case errors.Is(err, core.ErrJobNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Job not found", models.NotFoundErrorType)
+		case errors.Is(err, core.ErrJobRunning):
+			return SendErrorWithType(c, fiber.StatusConflict, "Job is already running", models.ConflictErrorType)
+		}
+		return SendErrorWithType(c, fiber.StatusInternalServerError, err.Error(), models.GeneralErrorType)
+	}
+	return SendSuccess(c, fiber.StatusAccepted, fiber.Map{"message": "Job triggered", "job": name})
+}
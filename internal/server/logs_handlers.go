@@ -16,7 +16,9 @@ import (
 
 	"github.com/mr-karan/logchef/internal/ai"
 	"github.com/mr-karan/logchef/internal/clickhouse"
+	"github.com/mr-karan/logchef/internal/config"
 	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/internal/metrics"
 	"github.com/mr-karan/logchef/pkg/models"
 	// "github.com/mr-karan/logchef/internal/logs" // Removed
 )
@@ -34,10 +36,14 @@ const (
 	OpenAIRequestTimeout = 15 * time.Second
 )
 
-// QueryTracker manages active queries for cancellation support
+// QueryTracker manages active queries for cancellation support, and enforces the query limits
 type QueryTracker struct {
 	mu      sync.RWMutex
 	queries map[string]*ActiveQuery
+
+	limits   config.QueryLimitsConfig
+	released chan struct{}                  // Closed and replaced when a query finishes
+	started  map[queryLimitKey][]time.Time // Recent query starts, for per-minute limits
 }
 
 // ActiveQuery represents an active query with its context for cancellation
@@ -46,6 +52,7 @@ type ActiveQuery struct {
 	UserID    models.UserID
 	SourceID  models.SourceID
 	TeamID    models.TeamID
+	Type      string // "logs", "histogram" or "ai"
 	StartTime time.Time
 	SQL       string
 	Cancel    context.CancelFunc
@@ -53,33 +60,44 @@ type ActiveQuery struct {
 
 // Global query tracker instance
 var queryTracker = &QueryTracker{
-	queries: make(map[string]*ActiveQuery),
+	queries:  make(map[string]*ActiveQuery),
+	released: make(chan struct{}),
+	started:  make(map[queryLimitKey][]time.Time),
 }
 
-// AddQuery adds a new active query to the tracker
-func (qt *QueryTracker) AddQuery(userID models.UserID, sourceID models.SourceID, teamID models.TeamID, sql string, cancel context.CancelFunc) string {
-	qt.mu.Lock()
-	defer qt.mu.Unlock()
-	
+// add tracks a query. The caller must hold the lock.
+func (qt *QueryTracker) add(userID models.UserID, sourceID models.SourceID, teamID models.TeamID, queryType, sql string, cancel context.CancelFunc, now time.Time) string {
 	queryID := uuid.New().String()
 	qt.queries[queryID] = &ActiveQuery{
 		ID:        queryID,
 		UserID:    userID,
 		SourceID:  sourceID,
 		TeamID:    teamID,
-		StartTime: time.Now(),
+		Type:      queryType,
+		StartTime: now,
 		SQL:       sql,
 		Cancel:    cancel,
 	}
+	metrics.UpdateActiveQueries(len(qt.queries))
 	
 	return queryID
 }
 
+// release wakes the queries queued for a limit after queries finished. The caller must hold the lock.
+func (qt *QueryTracker) release() {
+	close(qt.released)
+	qt.released = make(chan struct{})
+	metrics.UpdateActiveQueries(len(qt.queries))
+}
+
 // RemoveQuery removes a query from the tracker
 func (qt *QueryTracker) RemoveQuery(queryID string) {
 	qt.mu.Lock()
 	defer qt.mu.Unlock()
-	delete(qt.queries, queryID)
+	if _, exists := qt.queries[queryID]; exists {
+		delete(qt.queries, queryID)
+		qt.release()
+	}
 }
 
 // CancelQuery cancels a query if it exists and belongs to the user
@@ -102,6 +120,7 @@ func (qt *QueryTracker) CancelQuery(queryID string, userID models.UserID) bool {
 	
 	// Remove from tracker
 	delete(qt.queries, queryID)
+	qt.release()
 	
 	return true
 }
@@ -127,12 +146,17 @@ func (qt *QueryTracker) Cleanup() {
 	defer qt.mu.Unlock()
 	
 	cutoff := time.Now().Add(-1 * time.Hour)
+	removed := false
 	for queryID, query := range qt.queries {
 		if query.StartTime.Before(cutoff) {
 			query.Cancel()
 			delete(qt.queries, queryID)
+			removed = true
 		}
 	}
+	if removed {
+		qt.release()
+	}
 }
 
 // handleQueryLogs handles requests to query logs for a specific source.
@@ -182,8 +206,11 @@ func (s *Server) handleQueryLogs(c *fiber.Ctx) error {
 	queryCtx, cancel := context.WithCancel(c.Context())
 	defer cancel() // Ensure cleanup
 	
-	// Add query to tracker
-	queryID := queryTracker.AddQuery(user.ID, sourceID, teamID, req.RawSQL, cancel)
+	// Add query to tracker, queueing while it's over the query limits
+	queryID, err := queryTracker.AcquireQuery(queryCtx, user.ID, sourceID, teamID, queryTypeLogs, req.RawSQL, cancel)
+	if err != nil {
+		return sendQueryLimitError(c, err)
+	}
 	defer queryTracker.RemoveQuery(queryID) // Ensure cleanup
 
 	// Prepare parameters for the core query function.
@@ -191,12 +218,20 @@ func (s *Server) handleQueryLogs(c *fiber.Ctx) error {
 		RawSQL:       req.RawSQL,
 		Limit:        req.Limit,
 		QueryTimeout: req.QueryTimeout, // Always non-nil now
+		NoCache:      req.NoCache,
 	}
 	// StartTime, EndTime, and Timezone are no longer passed here;
 	// they are expected to be baked into the RawSQL by the frontend.
 
-	// Execute query via core function with cancellable context.
-	result, err := core.QueryLogs(queryCtx, s.sqlite, s.clickhouse, s.log, sourceID, params)
+	// Execute query via core function with cancellable context, recording it in the audit log.
+	auditEntry := s.newQueryAuditEntry(c, models.QueryAuditTypeLogs, teamID, sourceID, req.RawSQL)
+	start := time.Now()
+	result, err := core.QueryLogs(core.WithAuditEntry(queryCtx, auditEntry), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, sourceID, params)
+	rows := 0
+	if result != nil {
+		rows = len(result.Logs)
+	}
+	s.recordQueryAudit(auditEntry, start, rows, err)
 	if err != nil {
 		if errors.Is(err, core.ErrSourceNotFound) {
 			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
@@ -259,9 +294,13 @@ func (s *Server) handleGetSourceSchema(c *fiber.Ctx) error {
 	if err != nil {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source ID format", models.ValidationErrorType)
 	}
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
 
-	// Get schema via core function.
-	schema, err := core.GetSourceSchema(c.Context(), s.sqlite, s.clickhouse, s.log, sourceID)
+	// Get schema via core function; hidden columns are omitted for the team.
+	schema, err := core.GetSourceSchema(c.Context(), s.sqlite, s.clickhouse, s.log, teamID, sourceID)
 	if err != nil {
 		if errors.Is(err, core.ErrSourceNotFound) {
 			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
@@ -273,6 +312,39 @@ func (s *Server) handleGetSourceSchema(c *fiber.Ctx) error {
 	return SendSuccess(c, fiber.StatusOK, schema)
 }
 
+// handleGetSourceMapKeys returns the most frequent keys (with example values) of the
+// Map columns of a source, e.g. log_attributes. Used for autocomplete suggestions.
+// Query params: column (optional, defaults to all Map columns), refresh (optional, bypasses the cache).
+func (s *Server) handleGetSourceMapKeys(c *fiber.Ctx) error {
+	sourceIDStr := c.Params("sourceID")
+	sourceID, err := core.ParseSourceID(sourceIDStr)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source ID format", models.ValidationErrorType)
+	}
+
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	column := strings.TrimSpace(c.Query("column"))
+	refresh := c.QueryBool("refresh", false)
+
+	keys, err := core.GetSourceMapKeys(c.Context(), s.sqlite, s.clickhouse, s.mapKeys, s.log, teamID, sourceID, column, refresh)
+	if err != nil {
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		}
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		s.log.Error("failed to discover map keys via core function", slog.Any("error", err), "source_id", sourceID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to discover map keys: %v", err), models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, keys)
+}
+
 // handleGetHistogram generates histogram data (log counts over time intervals) for a specific source.
 // Access is controlled by the requireSourceAccess middleware.
 func (s *Server) handleGetHistogram(c *fiber.Ctx) error {
@@ -282,6 +354,11 @@ func (s *Server) handleGetHistogram(c *fiber.Ctx) error {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source ID format", models.ValidationErrorType)
 	}
 
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
 	// Parse request body containing time range, window, groupBy and optional filter query
 	var req models.APIHistogramRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -301,8 +378,9 @@ func (s *Server) handleGetHistogram(c *fiber.Ctx) error {
 
 	// Prepare parameters for the core histogram function.
 	params := core.HistogramParams{
-		Window: window,
-		Query:  req.RawSQL, // Pass raw SQL containing filters and time conditions
+		Window:  window,
+		Query:   req.RawSQL, // Pass raw SQL containing filters and time conditions
+		NoCache: req.NoCache,
 	}
 
 	// Only add groupBy if it's not empty
@@ -331,12 +409,36 @@ func (s *Server) handleGetHistogram(c *fiber.Ctx) error {
 	// Pass the query timeout (always non-nil now)
 	params.QueryTimeout = req.QueryTimeout
 
-	// Execute histogram query via core function.
-	result, err := core.GetHistogramData(c.Context(), s.sqlite, s.clickhouse, s.log, sourceID, params)
+	user := c.Locals("user").(*models.User)
+	if user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Track the query for the query limits, queueing while it's over them.
+	queryCtx, cancel := context.WithCancel(c.Context())
+	defer cancel()
+	queryID, err := queryTracker.AcquireQuery(queryCtx, user.ID, sourceID, teamID, queryTypeHistogram, req.RawSQL, cancel)
+	if err != nil {
+		return sendQueryLimitError(c, err)
+	}
+	defer queryTracker.RemoveQuery(queryID)
+
+	// Execute histogram query via core function, recording it in the audit log.
+	auditEntry := s.newQueryAuditEntry(c, models.QueryAuditTypeHistogram, teamID, sourceID, req.RawSQL)
+	start := time.Now()
+	result, err := core.GetHistogramData(core.WithAuditEntry(queryCtx, auditEntry), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, sourceID, params)
+	rows := 0
+	if result != nil {
+		rows = len(result.Data)
+	}
+	s.recordQueryAudit(auditEntry, start, rows, err)
 	if err != nil {
 		if errors.Is(err, core.ErrSourceNotFound) {
 			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
 		}
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
 
 		// Check for specific error types
 		switch {
@@ -357,6 +459,129 @@ func (s *Server) handleGetHistogram(c *fiber.Ctx) error {
 	return SendSuccess(c, fiber.StatusOK, result)
 }
 
+// handleGetLogPatterns clusters the results of a query into log templates (signatures).
+// Access is controlled by the requireSourceAccess middleware.
+func (s *Server) handleGetLogPatterns(c *fiber.Ctx) error {
+	sourceIDStr := c.Params("sourceID")
+	sourceID, err := core.ParseSourceID(sourceIDStr)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source ID format", models.ValidationErrorType)
+	}
+
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	var req models.APIPatternsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	if strings.TrimSpace(req.RawSQL) == "" {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "raw_sql parameter is required", models.ValidationErrorType)
+	}
+
+	// Apply default timeout if not specified
+	if req.QueryTimeout == nil {
+		defaultTimeout := models.DefaultQueryTimeoutSeconds
+		req.QueryTimeout = &defaultTimeout
+	}
+
+	// Validate timeout
+	if err := models.ValidateQueryTimeout(req.QueryTimeout); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Track the query for the query limits, queueing while it's over them.
+	queryCtx, release, err := acquireQuery(c.Context(), user.ID, teamID, sourceID, queryTypePatterns, req.RawSQL)
+	if err != nil {
+		return sendQueryLimitError(c, err)
+	}
+	defer release()
+
+	auditEntry := s.newQueryAuditEntry(c, models.QueryAuditTypePatterns, teamID, sourceID, req.RawSQL)
+	start := time.Now()
+	result, err := core.GetLogPatterns(core.WithAuditEntry(queryCtx, auditEntry), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, sourceID, core.PatternParams{
+		RawSQL:       req.RawSQL,
+		Field:        req.Field,
+		SampleSize:   req.SampleSize,
+		MaxPatterns:  req.MaxPatterns,
+		Samples:      req.Samples,
+		Similarity:   req.Similarity,
+		QueryTimeout: req.QueryTimeout,
+	})
+	rows := 0
+	if result != nil {
+		rows = result.RowsAnalyzed
+	}
+	s.recordQueryAudit(auditEntry, start, rows, err)
+	if err != nil {
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		}
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		s.log.Error("failed to get log patterns via core function", slog.Any("error", err), "source_id", sourceID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to cluster log patterns: %v", err), models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, result)
+}
+
+// handleGetLogContext returns the logs around a target timestamp, optionally scoped to the
+// same values of the source's role fields (e.g. service and host).
+// Access is controlled by the requireSourceAccess middleware.
+func (s *Server) handleGetLogContext(c *fiber.Ctx) error {
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source ID format", models.ValidationErrorType)
+	}
+
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	var req models.LogContextRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+	req.SourceID = sourceID
+
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Track the query for the query limits, queueing while it's over them.
+	queryCtx, release, err := acquireQuery(c.Context(), user.ID, teamID, sourceID, queryTypeContext, "")
+	if err != nil {
+		return sendQueryLimitError(c, err)
+	}
+	defer release()
+
+	result, err := core.GetLogContext(queryCtx, s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, sourceID, &req)
+	if err != nil {
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		}
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		s.log.Error("failed to get log context via core function", slog.Any("error", err), "source_id", sourceID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to get log context: %v", err), models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, result)
+}
+
 // handleGenerateAISQL handles the generation of SQL from natural language queries
 func (s *Server) handleGenerateAISQL(c *fiber.Ctx) error {
 	// Check if AI features are enabled in the configuration first.
@@ -413,8 +638,17 @@ func (s *Server) handleGenerateAISQL(c *fiber.Ctx) error {
 		return SendErrorWithType(c, http.StatusBadRequest, "Natural language query is required", models.ValidationErrorType)
 	}
 
+	// Schema discovery and SQL generation count against the query limits, queueing while over them.
+	aiCtx, aiCancel := context.WithCancel(c.Context())
+	defer aiCancel()
+	queryID, err := queryTracker.AcquireQuery(aiCtx, user.ID, sourceID, teamID, queryTypeAI, req.NaturalLanguageQuery, aiCancel)
+	if err != nil {
+		return sendQueryLimitError(c, err)
+	}
+	defer queryTracker.RemoveQuery(queryID)
+
 	// Get the source to verify it exists and is connected
-	source, err := core.GetSource(c.Context(), s.sqlite, s.clickhouse, s.log, sourceID)
+	source, err := core.GetSource(aiCtx, s.sqlite, s.clickhouse, s.log, sourceID)
 	if err != nil {
 		if errors.Is(err, core.ErrSourceNotFound) {
 			return SendErrorWithType(c, http.StatusNotFound, "Source not found", models.NotFoundErrorType)
@@ -439,24 +673,68 @@ func (s *Server) handleGenerateAISQL(c *fiber.Ctx) error {
 		return SendErrorWithType(c, http.StatusInternalServerError, "Failed to connect to source", models.ExternalServiceErrorType)
 	}
 
-	tableInfo, err := client.GetTableInfo(c.Context(), source.Connection.Database, source.Connection.TableName)
+	tableInfo, err := client.GetTableInfo(aiCtx, source.Connection.Database, source.Connection.TableName)
 	if err != nil {
 		s.log.Error("failed to get source schema", slog.Any("error", err), "source_id", sourceID)
 		return SendErrorWithType(c, http.StatusInternalServerError, "Failed to get source schema", models.ExternalServiceErrorType)
 	}
 
-	formattedColumns := make([]map[string]interface{}, 0, len(tableInfo.Columns))
-	for _, col := range tableInfo.Columns {
-		formattedColumns = append(formattedColumns, map[string]interface{}{
+	// Only describe the columns visible to the team.
+	policies, err := core.ListColumnPolicies(aiCtx, s.sqlite, teamID, sourceID)
+	if err != nil {
+		s.log.Error("failed to get column policies", slog.Any("error", err), "source_id", sourceID, "team_id", teamID)
+		return SendErrorWithType(c, http.StatusInternalServerError, "Failed to get source schema", models.DatabaseErrorType)
+	}
+	columns := core.ApplyColumnPolicies(tableInfo.Columns, policies)
+	visible := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		visible[col.Name] = true
+	}
+
+	// Enrich Map columns with their commonly used keys so the model can reference them.
+	// Discovery is best-effort; the prompt is still useful without it.
+	mapColumnKeys := make(map[string][]string)
+	discovered, err := core.GetSourceMapKeys(aiCtx, s.sqlite, s.clickhouse, s.mapKeys, s.log, teamID, sourceID, "", false)
+	if err != nil {
+		s.log.Warn("failed to discover map keys for AI prompt", slog.Any("error", err), "source_id", sourceID)
+	}
+	for _, mc := range discovered {
+		keys := make([]string, 0, len(mc.Keys))
+		for _, k := range mc.Keys {
+			keys = append(keys, k.Key)
+		}
+		mapColumnKeys[mc.Column] = keys
+	}
+
+	formattedColumns := make([]map[string]interface{}, 0, len(columns))
+	for _, col := range columns {
+		formattedColumn := map[string]interface{}{
 			"name": col.Name,
 			"type": col.Type,
-		})
+		}
+		if keys := mapColumnKeys[col.Name]; len(keys) > 0 {
+			formattedColumn["map_keys"] = keys
+			formattedColumn["note"] = fmt.Sprintf("Common keys of this Map column. Access values with %s['key'].", col.Name)
+		}
+		if roles := source.ColumnRoles(col.Name); len(roles) > 0 {
+			formattedColumn["role"] = strings.Join(roles, ", ")
+		}
+		if col.Name == source.MetaSeverityField && len(source.SeverityMapping) > 0 {
+			formattedColumn["severity_levels"] = source.SeverityMapping
+		}
+		formattedColumns = append(formattedColumns, formattedColumn)
 	}
 
-	if len(tableInfo.SortKeys) > 0 {
+	sortKeys := make([]string, 0, len(tableInfo.SortKeys))
+	for _, key := range tableInfo.SortKeys {
+		if visible[key] {
+			sortKeys = append(sortKeys, key)
+		}
+	}
+	if len(sortKeys) > 0 {
 		formattedColumns = append(formattedColumns, map[string]interface{}{
 			"name": "_sort_keys",
-			"keys": tableInfo.SortKeys,
+			"keys": sortKeys,
 			"note": "The columns above are sort keys. Queries filtered by these columns will be faster.",
 		})
 	}
@@ -469,7 +747,7 @@ func (s *Server) handleGenerateAISQL(c *fiber.Ctx) error {
 
 	tableName := source.GetFullTableName()
 
-	ctx, cancel := context.WithTimeout(c.Context(), OpenAIRequestTimeout)
+	ctx, cancel := context.WithTimeout(aiCtx, OpenAIRequestTimeout)
 	defer cancel()
 
 	aiClient, err := ai.NewClient(ai.ClientOptions{
@@ -503,6 +781,18 @@ func (s *Server) handleGenerateAISQL(c *fiber.Ctx) error {
 		return SendErrorWithType(c, http.StatusInternalServerError, fmt.Sprintf("Failed to generate SQL: %v", err), models.ExternalServiceErrorType)
 	}
 
+	// Generated queries run through the regular query path, which enforces the team's
+	// row-level filter and column policies. Reject SQL that could never run under them up front.
+	if err := core.ValidateTeamQuery(aiCtx, s.sqlite, s.clickhouse, teamID, sourceID, generatedSQL); err != nil {
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			s.log.Warn("AI generated SQL is not allowed for the team", "query", req.NaturalLanguageQuery, "error", err)
+			return SendErrorWithType(c, http.StatusBadRequest, fmt.Sprintf("AI could not generate a valid SQL query from your input: %s", validationErr.Message), models.ValidationErrorType)
+		}
+		s.log.Error("failed to validate AI generated SQL", slog.Any("error", err), "source_id", sourceID, "team_id", teamID)
+		return SendErrorWithType(c, http.StatusInternalServerError, "Failed to verify source access", models.GeneralErrorType)
+	}
+
 	return SendSuccess(c, http.StatusOK, models.GenerateSQLResponse{
 		SQLQuery: generatedSQL,
 	})
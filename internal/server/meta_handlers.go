@@ -10,6 +10,8 @@ import (
 type MetaResponse struct {
 	Version           string `json:"version"`
 	HTTPServerTimeout string `json:"http_server_timeout"`
+	// TraceURLTemplate links a trace to an external tracing UI (see the tracing config)
+	TraceURLTemplate string `json:"trace_url_template,omitempty"`
 }
 
 // handleGetMeta returns server metadata including version and configuration
@@ -26,6 +28,7 @@ func (s *Server) handleGetMeta(c *fiber.Ctx) error {
 	meta := MetaResponse{
 		Version:           s.version,
 		HTTPServerTimeout: s.config.Server.HTTPServerTimeout.String(),
+		TraceURLTemplate:  s.config.Tracing.URLTemplate,
 	}
 
 	return SendSuccess(c, fiber.StatusOK, meta)
@@ -29,6 +29,25 @@ func isUserAdmin(c *fiber.Ctx) bool {
 	return user.Role == models.UserRoleAdmin
 }
 
+// apiTokenFromContext returns the API token that authenticated the request, or nil for
+// session authentication.
+func apiTokenFromContext(c *fiber.Ctx) *models.APIToken {
+	token, _ := c.Locals("api_token").(*models.APIToken)
+	return token
+}
+
+// tokenSourceFilter returns a filter allowing only the team's sources the request's API token
+// covers, or nil when the request isn't restricted to team/source pairs.
+func tokenSourceFilter(c *fiber.Ctx, teamID models.TeamID) func(models.SourceID) bool {
+	token := apiTokenFromContext(c)
+	if !core.APITokenHasResources(token) {
+		return nil
+	}
+	return func(sourceID models.SourceID) bool {
+		return core.APITokenAllowsSource(token, teamID, sourceID)
+	}
+}
+
 // requireAuth is middleware that ensures the request includes valid authentication.
 // It supports both API token authentication (Authorization: Bearer <token>) and
 // session-based authentication (session cookie). It validates the authentication,
@@ -45,6 +64,15 @@ func (s *Server) requireAuth(c *fiber.Ctx) error {
 	return s.authenticateWithSession(c)
 }
 
+// optionalAuth authenticates the request like requireAuth when it carries credentials,
+// and otherwise lets it continue anonymously without a "user" in the context.
+func (s *Server) optionalAuth(c *fiber.Ctx) error {
+	if c.Get("Authorization") == "" && c.Cookies(sessionCookieName) == "" {
+		return c.Next()
+	}
+	return s.requireAuth(c)
+}
+
 // authenticateWithToken handles API token authentication
 func (s *Server) authenticateWithToken(c *fiber.Ctx, authHeader string) error {
 	// Extract token from "Bearer <token>"
@@ -68,6 +96,13 @@ func (s *Server) authenticateWithToken(c *fiber.Ctx, authHeader string) error {
 		return SendErrorWithType(c, fiber.StatusInternalServerError, "Error validating token", models.GeneralErrorType)
 	}
 
+	// Tokens with an IP allow-list only authenticate from those addresses
+	if err := core.CheckAPITokenIP(apiToken, c.IP()); err != nil {
+		metrics.RecordAuthAttempt("token", false, user)
+		s.log.Warn("API token used from disallowed address", "token_id", apiToken.ID, "ip", c.IP())
+		return SendErrorWithType(c, fiber.StatusForbidden, "Token is not allowed from this address", models.AuthenticationErrorType)
+	}
+
 	// Record successful token authentication with user context
 	metrics.RecordAuthAttempt("token", true, user)
 
@@ -128,6 +163,7 @@ func (s *Server) authenticateWithSession(c *fiber.Ctx) error {
 }
 
 // requireAdmin is middleware that ensures the authenticated user has the global 'admin' role.
+// API tokens restricted to team/source pairs never get admin access.
 // It assumes requireAuth has already run and placed the user in the context.
 func (s *Server) requireAdmin(c *fiber.Ctx) error {
 	user, ok := c.Locals("user").(*models.User)
@@ -137,7 +173,7 @@ func (s *Server) requireAdmin(c *fiber.Ctx) error {
 	}
 
 	s.log.Debug("requireAdmin check", "user_id", user.ID, "user_role", user.Role)
-	if user.Role != models.UserRoleAdmin {
+	if user.Role != models.UserRoleAdmin || core.APITokenHasResources(apiTokenFromContext(c)) {
 		s.log.Debug("Admin access denied", "user_id", user.ID)
 
 		// Record authorization failure
@@ -162,6 +198,17 @@ func (s *Server) requireTeamMember(c *fiber.Ctx) error {
 
 	s.log.Debug("requireTeamMember check", "user_id", user.ID, "user_role", user.Role, "team_id", teamIDStr)
 
+	teamID, err := core.ParseTeamID(teamIDStr)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	// API tokens restricted to team/source pairs only reach their own teams, even for admins.
+	if !core.APITokenAllowsTeam(apiTokenFromContext(c), teamID) {
+		metrics.RecordAuthorizationFailure(c.Route().Path, user, "token_resource")
+		return SendErrorWithType(c, fiber.StatusForbidden, "API token is not allowed for this team", models.AuthorizationErrorType)
+	}
+
 	// Global admins bypass specific team membership checks.
 	if user.Role == models.UserRoleAdmin {
 		s.log.Debug("Global admin granting team member access", "user_id", user.ID, "team_id", teamIDStr)
@@ -170,11 +217,6 @@ func (s *Server) requireTeamMember(c *fiber.Ctx) error {
 		return c.Next()
 	}
 
-	teamID, err := core.ParseTeamID(teamIDStr)
-	if err != nil {
-		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
-	}
-
 	// Check membership using core function.
 	isMember, err := core.IsTeamMember(c.Context(), s.sqlite, teamID, user.ID)
 	if err != nil {
@@ -208,6 +250,10 @@ func (s *Server) requireTeamAdminOrGlobalAdmin(c *fiber.Ctx) error {
 		return SendError(c, fiber.StatusBadRequest, "Invalid team ID: "+err.Error())
 	}
 
+	if !core.APITokenAllowsTeam(apiTokenFromContext(c), teamID) {
+		return SendError(c, fiber.StatusForbidden, "API token is not allowed for this team")
+	}
+
 	// Check if the user is a global admin
 	if isUserAdmin(c) {
 		return c.Next() // Allow global admins unconditionally
@@ -247,6 +293,11 @@ func (s *Server) requireTeamHasSource(c *fiber.Ctx) error {
 		return SendError(c, fiber.StatusBadRequest, "Invalid source ID: "+err.Error())
 	}
 
+	if !core.APITokenAllowsSource(apiTokenFromContext(c), teamID, sourceID) {
+		s.log.Warn("API token not allowed for source", "team_id", teamID, "source_id", sourceID)
+		return SendError(c, fiber.StatusForbidden, "API token is not allowed for this source")
+	}
+
 	// Check if the team has access to the source
 	hasAccess, err := core.TeamHasSourceAccess(c.Context(), s.sqlite, teamID, sourceID)
 	if err != nil {
@@ -263,6 +314,23 @@ func (s *Server) requireTeamHasSource(c *fiber.Ctx) error {
 	return c.Next()
 }
 
+// requireScope returns middleware that ensures an API token authenticating the request
+// grants the scope. Session authentication is not limited by scopes.
+// Assumes requireAuth has already run.
+func (s *Server) requireScope(scope models.APITokenScope) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := apiTokenFromContext(c)
+		if core.APITokenHasScope(token, scope) {
+			return c.Next()
+		}
+
+		user, _ := c.Locals("user").(*models.User)
+		metrics.RecordAuthorizationFailure(c.Route().Path, user, "token_scope")
+		s.log.Debug("API token scope denied", "token_id", token.ID, "scope", scope)
+		return SendErrorWithType(c, fiber.StatusForbidden, "API token lacks the "+string(scope)+" scope", models.AuthorizationErrorType)
+	}
+}
+
 // requireCollectionManagement checks if a user has privileges to manage collections for the requested team.
 // This includes Team Editors, Team Admins, or Global Admins.
 // Assumes requireAuth has already run.
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"log/slog"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleSearchOIDCSyncEvents lists the decisions of OIDC group sync.
+// URL: GET /api/v1/admin/oidc-sync-events
+// Query params: user_id, team_id, action (create_user|reject|grant_admin|revoke_admin|
+// add_member|update_role|remove_member|skip), limit, offset.
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleSearchOIDCSyncEvents(c *fiber.Ctx) error {
+	var filter models.OIDCSyncEventFilter
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := core.ParseUserID(v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid user_id", models.ValidationErrorType)
+		}
+		filter.UserID = &id
+	}
+	if v := c.Query("team_id"); v != "" {
+		id, err := core.ParseTeamID(v)
+		if err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team_id", models.ValidationErrorType)
+		}
+		filter.TeamID = &id
+	}
+	filter.Action = models.OIDCSyncAction(c.Query("action"))
+	filter.Limit = c.QueryInt("limit")
+	filter.Offset = c.QueryInt("offset")
+
+	events, err := core.SearchOIDCSyncEvents(c.Context(), s.sqlite, filter)
+	if err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		s.log.Error("failed to search oidc sync events", slog.Any("error", err))
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to search OIDC sync events", models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, events)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// handleGetProvisioningStatus returns the provisioning configuration and the result of the
+// last apply, including the changes it made. Reports enabled=false when not configured.
+// URL: GET /api/v1/admin/provisioning
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleGetProvisioningStatus(c *fiber.Ctx) error {
+	return SendSuccess(c, fiber.StatusOK, s.provisioner.Status())
+}
+
+// handleApplyProvisioning re-applies the provisioning files, for deployments that don't
+// watch the directory.
+// URL: POST /api/v1/admin/provisioning/apply
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleApplyProvisioning(c *fiber.Ctx) error {
+	if s.provisioner == nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Provisioning is not configured", models.ValidationErrorType)
+	}
+	if err := s.provisioner.Apply(c.Context()); err != nil {
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Error(), models.ValidationErrorType)
+		}
+		s.log.Error("failed to apply provisioning files", slog.Any("error", err))
+		return SendErrorWithType(c, fiber.StatusInternalServerError, err.Error(), models.GeneralErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, s.provisioner.Status())
+}
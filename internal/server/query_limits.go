@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mr-karan/logchef/internal/config"
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/internal/metrics"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+const (
+	// DefaultQueryLimitWaitTimeout is how long a query over a limit queues when no wait timeout is configured.
+	DefaultQueryLimitWaitTimeout = 10 * time.Second
+
+	// queryRateWindow is the window of the per-minute query limits.
+	queryRateWindow = time.Minute
+
+	// Query types tracked for the query limits.
+	queryTypeLogs      = string(models.QueryAuditTypeLogs)
+	queryTypeHistogram = string(models.QueryAuditTypeHistogram)
+	queryTypeAI        = "ai"
+	queryTypePatterns  = string(models.QueryAuditTypePatterns)
+	queryTypeContext   = "context"
+	queryTypeOptions   = "variable_options"
+	queryTypeShare     = string(models.QueryAuditTypeShare)
+)
+
+// queryLimitKey identifies the user or team whose query starts are counted for a per-minute limit.
+type queryLimitKey struct {
+	team bool
+	id   int
+}
+
+// QueryLimitError is returned when a query exceeds a query limit and no slot freed up within
+// the wait timeout.
+type QueryLimitError struct {
+	Limit      string // The exceeded limit, e.g. "user_concurrency" or "team_rate"
+	Message    string
+	RetryAfter time.Duration // How long until the query may be retried
+}
+
+func (e *QueryLimitError) Error() string {
+	return e.Message
+}
+
+// SetLimits configures the limits enforced by AcquireQuery.
+func (qt *QueryTracker) SetLimits(limits config.QueryLimitsConfig) {
+	if limits.WaitTimeout == 0 {
+		limits.WaitTimeout = DefaultQueryLimitWaitTimeout
+	}
+
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+	qt.limits = limits
+}
+
+// AcquireQuery tracks a query once it's within the query limits, and returns its ID. A query over
+// a limit queues until a running query finishes or its per-minute slot frees up, up to the wait
+// timeout. It fails with a *QueryLimitError when no slot frees up in time, and with the context's
+// error when ctx is done while queued. The query must be removed with RemoveQuery when it finishes.
+func (qt *QueryTracker) AcquireQuery(ctx context.Context, userID models.UserID, sourceID models.SourceID, teamID models.TeamID, queryType, sql string, cancel context.CancelFunc) (string, error) {
+	arrived := time.Now()
+	queued := false
+	for {
+		qt.mu.Lock()
+		now := time.Now()
+		deadline := arrived.Add(max(qt.limits.WaitTimeout, 0))
+		limitErr := qt.checkLimits(userID, sourceID, teamID, now)
+		if limitErr == nil {
+			qt.recordStart(userID, teamID, now)
+			queryID := qt.add(userID, sourceID, teamID, queryType, sql, cancel, now)
+			qt.mu.Unlock()
+			if queued {
+				metrics.RecordQueryLimitWait(queryType, now.Sub(arrived))
+			}
+			return queryID, nil
+		}
+		released := qt.released
+		qt.mu.Unlock()
+
+		// Per-minute slots free up at a known time, so don't queue for one that frees up too late.
+		remaining := deadline.Sub(now)
+		if remaining <= 0 || limitErr.RetryAfter > remaining {
+			metrics.RecordQueryLimitRejection(limitErr.Limit, queryType)
+			limitErr.RetryAfter = max(limitErr.RetryAfter, time.Second)
+			return "", limitErr
+		}
+		queued = true
+
+		wait := remaining
+		if limitErr.RetryAfter > 0 {
+			wait = limitErr.RetryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-released:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// acquireQuery tracks a query of a handler with AcquireQuery. It returns the context to run the
+// query with, cancelled when the query is cancelled, and a func to call once the query finished.
+func acquireQuery(ctx context.Context, userID models.UserID, teamID models.TeamID, sourceID models.SourceID, queryType, sql string) (context.Context, func(), error) {
+	queryCtx, cancel := context.WithCancel(ctx)
+	queryID, err := queryTracker.AcquireQuery(queryCtx, userID, sourceID, teamID, queryType, sql, cancel)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return queryCtx, func() {
+		queryTracker.RemoveQuery(queryID)
+		cancel()
+	}, nil
+}
+
+// queryAcquirer returns a core.AcquireQueryFunc tracking the queries core runs for a request of
+// userID, e.g. one per dashboard panel, so each counts against the query limits.
+func queryAcquirer(userID models.UserID) core.AcquireQueryFunc {
+	return func(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, queryType models.QueryAuditType, rawSQL string) (context.Context, func(), error) {
+		return acquireQuery(ctx, userID, teamID, sourceID, string(queryType), rawSQL)
+	}
+}
+
+// checkLimits returns the first limit a new query would exceed, or nil. RetryAfter is set for
+// per-minute limits only. The caller must hold the lock.
+func (qt *QueryTracker) checkLimits(userID models.UserID, sourceID models.SourceID, teamID models.TeamID, now time.Time) *QueryLimitError {
+	if limit := qt.limits.QueriesPerMinutePerUser; limit > 0 {
+		if retryAfter := qt.rateRetryAfter(queryLimitKey{id: int(userID)}, limit, now); retryAfter > 0 {
+			return newQueryLimitError("user_rate", fmt.Sprintf("You have run too many queries in the last minute (limit %d per minute).", limit), retryAfter)
+		}
+	}
+	if limit := qt.limits.QueriesPerMinutePerTeam; limit > 0 {
+		if retryAfter := qt.rateRetryAfter(queryLimitKey{team: true, id: int(teamID)}, limit, now); retryAfter > 0 {
+			return newQueryLimitError("team_rate", fmt.Sprintf("Your team has run too many queries in the last minute (limit %d per minute).", limit), retryAfter)
+		}
+	}
+
+	var userQueries, teamQueries, sourceQueries int
+	for _, query := range qt.queries {
+		if query.UserID == userID {
+			userQueries++
+		}
+		if query.TeamID == teamID {
+			teamQueries++
+		}
+		if query.SourceID == sourceID {
+			sourceQueries++
+		}
+	}
+	if limit := qt.limits.MaxConcurrentPerUser; limit > 0 && userQueries >= limit {
+		return newQueryLimitError("user_concurrency", fmt.Sprintf("You have too many queries running (limit %d). Wait for one to finish or cancel it.", limit), 0)
+	}
+	if limit := qt.limits.MaxConcurrentPerTeam; limit > 0 && teamQueries >= limit {
+		return newQueryLimitError("team_concurrency", fmt.Sprintf("Your team has too many queries running (limit %d).", limit), 0)
+	}
+	if limit := qt.limits.MaxConcurrentPerSource; limit > 0 && sourceQueries >= limit {
+		return newQueryLimitError("source_concurrency", fmt.Sprintf("Too many queries are running on this source (limit %d).", limit), 0)
+	}
+	return nil
+}
+
+// rateRetryAfter drops the starts of key outside the rate window, and returns how long until
+// another query may start under limit, or 0 when one may start now. The caller must hold the lock.
+func (qt *QueryTracker) rateRetryAfter(key queryLimitKey, limit int, now time.Time) time.Duration {
+	starts := qt.started[key]
+	i := 0
+	for i < len(starts) && !starts[i].After(now.Add(-queryRateWindow)) {
+		i++
+	}
+	starts = starts[i:]
+	if len(starts) == 0 {
+		delete(qt.started, key)
+		return 0
+	}
+	qt.started[key] = starts
+	if len(starts) < limit {
+		return 0
+	}
+	return starts[len(starts)-limit].Add(queryRateWindow).Sub(now)
+}
+
+// recordStart records a query start for the per-minute limits. The caller must hold the lock.
+func (qt *QueryTracker) recordStart(userID models.UserID, teamID models.TeamID, now time.Time) {
+	if qt.limits.QueriesPerMinutePerUser > 0 {
+		key := queryLimitKey{id: int(userID)}
+		qt.started[key] = append(qt.started[key], now)
+	}
+	if qt.limits.QueriesPerMinutePerTeam > 0 {
+		key := queryLimitKey{team: true, id: int(teamID)}
+		qt.started[key] = append(qt.started[key], now)
+	}
+}
+
+func newQueryLimitError(limit, message string, retryAfter time.Duration) *QueryLimitError {
+	return &QueryLimitError{Limit: limit, Message: message, RetryAfter: retryAfter}
+}
+
+// isQueryLimitError reports whether err is from a query that exceeded a query limit.
+func isQueryLimitError(err error) bool {
+	var limitErr *QueryLimitError
+	return errors.As(err, &limitErr)
+}
+
+// sendQueryLimitError responds to a query that couldn't acquire a slot under the query limits:
+// with 429 Too Many Requests and a Retry-After header when a limit was exceeded.
+func sendQueryLimitError(c *fiber.Ctx, err error) error {
+	var limitErr *QueryLimitError
+	if errors.As(err, &limitErr) {
+		retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return SendErrorWithType(c, fiber.StatusTooManyRequests,
+			fmt.Sprintf("%s Try again in %ds.", limitErr.Message, retryAfter), models.RateLimitErrorType)
+	}
+	// The request was cancelled while the query was queued.
+	return SendErrorWithType(c, fiber.StatusRequestTimeout, "Query cancelled while waiting for a query slot", models.GeneralErrorType)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/config"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+func newTestQueryTracker(limits config.QueryLimitsConfig) *QueryTracker {
+	qt := &QueryTracker{
+		queries:  make(map[string]*ActiveQuery),
+		released: make(chan struct{}),
+		started:  make(map[queryLimitKey][]time.Time),
+	}
+	qt.SetLimits(limits)
+	return qt
+}
+
+// acquire runs AcquireQuery for a logs query without a cancel func.
+func acquire(ctx context.Context, qt *QueryTracker, userID models.UserID, teamID models.TeamID, sourceID models.SourceID) (string, error) {
+	return qt.AcquireQuery(ctx, userID, sourceID, teamID, queryTypeLogs, "SELECT 1", func() {})
+}
+
+// wantLimitError fails unless err is a *QueryLimitError for limit.
+func wantLimitError(t *testing.T, err error, limit string) *QueryLimitError {
+	t.Helper()
+	var limitErr *QueryLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("error = %v, want a %s QueryLimitError", err, limit)
+	}
+	if limitErr.Limit != limit {
+		t.Fatalf("exceeded limit = %q, want %q", limitErr.Limit, limit)
+	}
+	return limitErr
+}
+
+func TestAcquireQueryConcurrencyLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits config.QueryLimitsConfig
+		// The running query is by user 1 of team 1 on source 1; next is the new query's user, team and source.
+		next      [3]int
+		wantLimit string
+	}{
+		{name: "user cap", limits: config.QueryLimitsConfig{MaxConcurrentPerUser: 1}, next: [3]int{1, 2, 2}, wantLimit: "user_concurrency"},
+		{name: "user cap other user", limits: config.QueryLimitsConfig{MaxConcurrentPerUser: 1}, next: [3]int{2, 1, 1}},
+		{name: "team cap", limits: config.QueryLimitsConfig{MaxConcurrentPerTeam: 1}, next: [3]int{2, 1, 2}, wantLimit: "team_concurrency"},
+		{name: "team cap other team", limits: config.QueryLimitsConfig{MaxConcurrentPerTeam: 1}, next: [3]int{1, 2, 1}},
+		{name: "source cap", limits: config.QueryLimitsConfig{MaxConcurrentPerSource: 1}, next: [3]int{2, 2, 1}, wantLimit: "source_concurrency"},
+		{name: "source cap other source", limits: config.QueryLimitsConfig{MaxConcurrentPerSource: 1}, next: [3]int{1, 1, 2}},
+		{name: "under the cap", limits: config.QueryLimitsConfig{MaxConcurrentPerUser: 2, MaxConcurrentPerTeam: 2, MaxConcurrentPerSource: 2}, next: [3]int{1, 1, 1}},
+		{name: "no limits", next: [3]int{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.limits.WaitTimeout = -1 // Reject right away rather than queueing.
+			qt := newTestQueryTracker(tt.limits)
+			if _, err := acquire(context.Background(), qt, 1, 1, 1); err != nil {
+				t.Fatalf("first query: %v", err)
+			}
+
+			_, err := acquire(context.Background(), qt, models.UserID(tt.next[0]), models.TeamID(tt.next[1]), models.SourceID(tt.next[2]))
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Fatalf("second query: %v", err)
+				}
+				return
+			}
+			limitErr := wantLimitError(t, err, tt.wantLimit)
+			if limitErr.RetryAfter < time.Second {
+				t.Errorf("RetryAfter = %v, want at least 1s", limitErr.RetryAfter)
+			}
+			if len(qt.queries) != 1 {
+				t.Errorf("%d queries tracked, want only the first", len(qt.queries))
+			}
+		})
+	}
+}
+
+func TestAcquireQueryQueueTimeout(t *testing.T) {
+	qt := newTestQueryTracker(config.QueryLimitsConfig{MaxConcurrentPerUser: 1, WaitTimeout: 50 * time.Millisecond})
+	if _, err := acquire(context.Background(), qt, 1, 1, 1); err != nil {
+		t.Fatalf("first query: %v", err)
+	}
+
+	start := time.Now()
+	_, err := acquire(context.Background(), qt, 1, 1, 1)
+	wantLimitError(t, err, "user_concurrency")
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("rejected after %v, want it to queue for the wait timeout", waited)
+	}
+}
+
+func TestAcquireQueryRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		release func(qt *QueryTracker, queryID string)
+	}{
+		{name: "removed", release: func(qt *QueryTracker, queryID string) { qt.RemoveQuery(queryID) }},
+		{name: "cancelled", release: func(qt *QueryTracker, queryID string) { qt.CancelQuery(queryID, 1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qt := newTestQueryTracker(config.QueryLimitsConfig{MaxConcurrentPerUser: 1, WaitTimeout: 5 * time.Second})
+			first, err := acquire(context.Background(), qt, 1, 1, 1)
+			if err != nil {
+				t.Fatalf("first query: %v", err)
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := acquire(context.Background(), qt, 1, 1, 1)
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				t.Fatalf("second query didn't queue: %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			tt.release(qt, first)
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("second query after release: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("second query still queued after the first was released")
+			}
+			if len(qt.queries) != 1 {
+				t.Errorf("%d queries tracked, want only the second", len(qt.queries))
+			}
+		})
+	}
+}
+
+func TestAcquireQueryContextDone(t *testing.T) {
+	qt := newTestQueryTracker(config.QueryLimitsConfig{MaxConcurrentPerUser: 1, WaitTimeout: 5 * time.Second})
+	if _, err := acquire(context.Background(), qt, 1, 1, 1); err != nil {
+		t.Fatalf("first query: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := acquire(ctx, qt, 1, 1, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the context's error", err)
+	}
+}
+
+func TestAcquireQueryRateWindow(t *testing.T) {
+	userKey := queryLimitKey{id: 1}
+	teamKey := queryLimitKey{team: true, id: 1}
+	tests := []struct {
+		name        string
+		limits      config.QueryLimitsConfig
+		key         queryLimitKey
+		startedAgo  []time.Duration // Earlier starts of key, oldest first
+		wantLimit   string
+		wantRetry   time.Duration // Approximate RetryAfter of a rejection
+		wantWait    time.Duration // Minimum time queued before an accepted query starts
+		wantStarted int           // Starts of key recorded afterwards
+	}{
+		{
+			name:        "under the limit",
+			limits:      config.QueryLimitsConfig{QueriesPerMinutePerUser: 2, WaitTimeout: -1},
+			key:         userKey,
+			startedAgo:  []time.Duration{10 * time.Second},
+			wantStarted: 2,
+		},
+		{
+			name:        "starts outside the window are dropped",
+			limits:      config.QueryLimitsConfig{QueriesPerMinutePerUser: 1, WaitTimeout: -1},
+			key:         userKey,
+			startedAgo:  []time.Duration{2 * time.Minute, 61 * time.Second},
+			wantStarted: 1,
+		},
+		{
+			name:       "user limit reached",
+			limits:     config.QueryLimitsConfig{QueriesPerMinutePerUser: 2, WaitTimeout: -1},
+			key:        userKey,
+			startedAgo: []time.Duration{40 * time.Second, 10 * time.Second},
+			wantLimit:  "user_rate",
+			wantRetry:  20 * time.Second,
+		},
+		{
+			name:       "team limit reached",
+			limits:     config.QueryLimitsConfig{QueriesPerMinutePerTeam: 1, WaitTimeout: -1},
+			key:        teamKey,
+			startedAgo: []time.Duration{30 * time.Second},
+			wantLimit:  "team_rate",
+			wantRetry:  30 * time.Second,
+		},
+		{
+			name:       "slot frees up after the wait timeout",
+			limits:     config.QueryLimitsConfig{QueriesPerMinutePerUser: 1, WaitTimeout: time.Second},
+			key:        userKey,
+			startedAgo: []time.Duration{30 * time.Second},
+			wantLimit:  "user_rate",
+			wantRetry:  30 * time.Second,
+		},
+		{
+			name:        "queues until the window rolls over",
+			limits:      config.QueryLimitsConfig{QueriesPerMinutePerUser: 1, WaitTimeout: 2 * time.Second},
+			key:         userKey,
+			startedAgo:  []time.Duration{queryRateWindow - 100*time.Millisecond},
+			wantWait:    100 * time.Millisecond,
+			wantStarted: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qt := newTestQueryTracker(tt.limits)
+			now := time.Now()
+			for _, ago := range tt.startedAgo {
+				qt.started[tt.key] = append(qt.started[tt.key], now.Add(-ago))
+			}
+
+			start := time.Now()
+			_, err := acquire(context.Background(), qt, 1, 1, 1)
+			if tt.wantLimit != "" {
+				limitErr := wantLimitError(t, err, tt.wantLimit)
+				if d := limitErr.RetryAfter - tt.wantRetry; d < -time.Second || d > time.Second {
+					t.Errorf("RetryAfter = %v, want about %v", limitErr.RetryAfter, tt.wantRetry)
+				}
+				if waited := time.Since(start); waited > 500*time.Millisecond {
+					t.Errorf("queued for %v for a slot that frees up too late", waited)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if waited := time.Since(start); waited < tt.wantWait {
+				t.Errorf("started after %v, want after at least %v", waited, tt.wantWait)
+			}
+			if got := len(qt.started[tt.key]); got != tt.wantStarted {
+				t.Errorf("%d starts recorded, want %d", got, tt.wantStarted)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// --- SCIM 2.0 Handlers ---
+// Served under /scim/v2 for identity providers, authenticated with the configured SCIM token.
+
+const scimContentType = "application/scim+json"
+
+// requireSCIMToken is middleware that authenticates identity providers by the SCIM bearer token.
+func (s *Server) requireSCIMToken(c *fiber.Ctx) error {
+	token, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.SCIM.Token)) != 1 {
+		return sendSCIMErrorStatus(c, fiber.StatusUnauthorized, "", "Invalid SCIM token")
+	}
+	return c.Next()
+}
+
+// sendSCIM sends a SCIM resource or message.
+func sendSCIM(c *fiber.Ctx, status int, data any) error {
+	return c.Status(status).JSON(data, scimContentType)
+}
+
+// sendSCIMErrorStatus sends a SCIM error response.
+func sendSCIMErrorStatus(c *fiber.Ctx, status int, scimType, detail string) error {
+	return sendSCIM(c, status, models.SCIMError{
+		Schemas:  []string{models.SCIMErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// sendSCIMError maps core errors to SCIM error responses.
+func (s *Server) sendSCIMError(c *fiber.Ctx, err error, action string) error {
+	if validationErr, ok := err.(*core.ValidationError); ok {
+		scimType := "invalidValue"
+		switch validationErr.Field {
+		case "filter":
+			scimType = "invalidFilter"
+		case "path", "op":
+			scimType = "invalidPath"
+		case "userName":
+			scimType = "mutability"
+		}
+		return sendSCIMErrorStatus(c, fiber.StatusBadRequest, scimType, validationErr.Message)
+	}
+	switch {
+	case errors.Is(err, core.ErrUserNotFound):
+		return sendSCIMErrorStatus(c, fiber.StatusNotFound, "", "User not found")
+	case errors.Is(err, core.ErrTeamNotFound):
+		return sendSCIMErrorStatus(c, fiber.StatusNotFound, "", "Group not found")
+	case errors.Is(err, core.ErrUserAlreadyExists), errors.Is(err, core.ErrTeamAlreadyExists):
+		return sendSCIMErrorStatus(c, fiber.StatusConflict, "uniqueness", err.Error())
+	case errors.Is(err, core.ErrProvisioned), errors.Is(err, core.ErrCannotDeleteLastAdmin):
+		return sendSCIMErrorStatus(c, fiber.StatusConflict, "", err.Error())
+	}
+	s.log.Error("failed to "+action+" via SCIM", slog.Any("error", err))
+	return sendSCIMErrorStatus(c, fiber.StatusInternalServerError, "", "Failed to "+action)
+}
+
+// scimLocation returns the URL of a SCIM resource.
+func scimLocation(c *fiber.Ctx, endpoint, id string) string {
+	return c.BaseURL() + "/scim/v2/" + endpoint + "/" + id
+}
+
+// withUserLocation fills in the location of a SCIM user.
+func withUserLocation(c *fiber.Ctx, u *models.SCIMUser) *models.SCIMUser {
+	u.Meta.Location = scimLocation(c, "Users", u.ID)
+	return u
+}
+
+// withGroupLocation fills in the location of a SCIM group.
+func withGroupLocation(c *fiber.Ctx, g *models.SCIMGroup) *models.SCIMGroup {
+	g.Meta.Location = scimLocation(c, "Groups", g.ID)
+	return g
+}
+
+// scimWithMembers reports whether group members were not excluded by the request.
+func scimWithMembers(c *fiber.Ctx) bool {
+	for _, attr := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return false
+		}
+	}
+	return true
+}
+
+// handleSCIMServiceProviderConfig describes the supported SCIM features.
+// URL: GET /scim/v2/ServiceProviderConfig
+func (s *Server) handleSCIMServiceProviderConfig(c *fiber.Ctx) error {
+	supported := func(v bool) fiber.Map { return fiber.Map{"supported": v} }
+	return sendSCIM(c, fiber.StatusOK, fiber.Map{
+		"schemas":        []string{models.SCIMServiceConfigSchema},
+		"patch":          supported(true),
+		"bulk":           fiber.Map{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         fiber.Map{"supported": true, "maxResults": 1000},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "Authentication with the SCIM token from the Logchef configuration",
+			"primary":     true,
+		}},
+	})
+}
+
+// handleSCIMResourceTypes lists the SCIM resource types.
+// URL: GET /scim/v2/ResourceTypes
+func (s *Server) handleSCIMResourceTypes(c *fiber.Ctx) error {
+	resourceType := func(name, endpoint, schema string) fiber.Map {
+		return fiber.Map{
+			"schemas":  []string{models.SCIMResourceTypeSchema},
+			"id":       name,
+			"name":     name,
+			"endpoint": "/" + endpoint,
+			"schema":   schema,
+			"meta":     fiber.Map{"resourceType": "ResourceType", "location": c.BaseURL() + "/scim/v2/ResourceTypes/" + name},
+		}
+	}
+	resources := []any{
+		resourceType("User", "Users", models.SCIMUserSchema),
+		resourceType("Group", "Groups", models.SCIMGroupSchema),
+	}
+	return sendSCIM(c, fiber.StatusOK, models.SCIMListResponse{
+		Schemas:      []string{models.SCIMListResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// handleSCIMListUsers lists users.
+// URL: GET /scim/v2/Users?filter=userName eq "jane@example.com"&startIndex=1&count=100
+func (s *Server) handleSCIMListUsers(c *fiber.Ctx) error {
+	list, err := core.SCIMListUsers(c.Context(), s.sqlite, c.Query("filter"), c.QueryInt("startIndex", 1), c.QueryInt("count", 100))
+	if err != nil {
+		return s.sendSCIMError(c, err, "list users")
+	}
+	for _, r := range list.Resources {
+		withUserLocation(c, r.(*models.SCIMUser))
+	}
+	return sendSCIM(c, fiber.StatusOK, list)
+}
+
+// handleSCIMGetUser returns a user.
+// URL: GET /scim/v2/Users/:id
+func (s *Server) handleSCIMGetUser(c *fiber.Ctx) error {
+	user, err := core.SCIMGetUser(c.Context(), s.sqlite, c.Params("id"))
+	if err != nil {
+		return s.sendSCIMError(c, err, "get user")
+	}
+	return sendSCIM(c, fiber.StatusOK, withUserLocation(c, user))
+}
+
+// handleSCIMCreateUser creates a user with the member role.
+// URL: POST /scim/v2/Users
+func (s *Server) handleSCIMCreateUser(c *fiber.Ctx) error {
+	var req models.SCIMUser
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sendSCIMErrorStatus(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+	user, err := core.SCIMCreateUser(c.Context(), s.sqlite, s.log, &req)
+	if err != nil {
+		return s.sendSCIMError(c, err, "create user")
+	}
+	withUserLocation(c, user)
+	c.Set(fiber.HeaderLocation, user.Meta.Location)
+	return sendSCIM(c, fiber.StatusCreated, user)
+}
+
+// handleSCIMReplaceUser updates a user's name and active state.
+// URL: PUT /scim/v2/Users/:id
+func (s *Server) handleSCIMReplaceUser(c *fiber.Ctx) error {
+	var req models.SCIMUser
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sendSCIMErrorStatus(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+	user, err := core.SCIMReplaceUser(c.Context(), s.sqlite, s.log, c.Params("id"), &req)
+	if err != nil {
+		return s.sendSCIMError(c, err, "update user")
+	}
+	return sendSCIM(c, fiber.StatusOK, withUserLocation(c, user))
+}
+
+// handleSCIMPatchUser patches a user; setting active to false deactivates them.
+// URL: PATCH /scim/v2/Users/:id
+func (s *Server) handleSCIMPatchUser(c *fiber.Ctx) error {
+	var req models.SCIMPatchRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sendSCIMErrorStatus(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+	user, err := core.SCIMPatchUser(c.Context(), s.sqlite, s.log, c.Params("id"), &req)
+	if err != nil {
+		return s.sendSCIMError(c, err, "update user")
+	}
+	return sendSCIM(c, fiber.StatusOK, withUserLocation(c, user))
+}
+
+// handleSCIMDeleteUser deactivates a user, revoking their sessions and API tokens.
+// URL: DELETE /scim/v2/Users/:id
+func (s *Server) handleSCIMDeleteUser(c *fiber.Ctx) error {
+	if err := core.SCIMDeactivateUser(c.Context(), s.sqlite, s.log, c.Params("id")); err != nil {
+		return s.sendSCIMError(c, err, "deactivate user")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// handleSCIMListGroups lists teams as groups.
+// URL: GET /scim/v2/Groups?filter=displayName eq "Platform"&excludedAttributes=members
+func (s *Server) handleSCIMListGroups(c *fiber.Ctx) error {
+	list, err := core.SCIMListGroups(c.Context(), s.sqlite, c.Query("filter"), c.QueryInt("startIndex", 1), c.QueryInt("count", 100), scimWithMembers(c))
+	if err != nil {
+		return s.sendSCIMError(c, err, "list groups")
+	}
+	for _, r := range list.Resources {
+		withGroupLocation(c, r.(*models.SCIMGroup))
+	}
+	return sendSCIM(c, fiber.StatusOK, list)
+}
+
+// handleSCIMGetGroup returns a team as a group.
+// URL: GET /scim/v2/Groups/:id
+func (s *Server) handleSCIMGetGroup(c *fiber.Ctx) error {
+	group, err := core.SCIMGetGroup(c.Context(), s.sqlite, c.Params("id"), scimWithMembers(c))
+	if err != nil {
+		return s.sendSCIMError(c, err, "get group")
+	}
+	return sendSCIM(c, fiber.StatusOK, withGroupLocation(c, group))
+}
+
+// handleSCIMCreateGroup creates a team.
+// URL: POST /scim/v2/Groups
+func (s *Server) handleSCIMCreateGroup(c *fiber.Ctx) error {
+	var req models.SCIMGroup
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sendSCIMErrorStatus(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+	group, err := core.SCIMCreateGroup(c.Context(), s.sqlite, s.log, &req)
+	if err != nil {
+		return s.sendSCIMError(c, err, "create group")
+	}
+	withGroupLocation(c, group)
+	c.Set(fiber.HeaderLocation, group.Meta.Location)
+	return sendSCIM(c, fiber.StatusCreated, group)
+}
+
+// handleSCIMReplaceGroup renames a team and replaces its members.
+// URL: PUT /scim/v2/Groups/:id
+func (s *Server) handleSCIMReplaceGroup(c *fiber.Ctx) error {
+	var req models.SCIMGroup
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sendSCIMErrorStatus(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+	group, err := core.SCIMReplaceGroup(c.Context(), s.sqlite, s.log, c.Params("id"), &req)
+	if err != nil {
+		return s.sendSCIMError(c, err, "update group")
+	}
+	return sendSCIM(c, fiber.StatusOK, withGroupLocation(c, group))
+}
+
+// handleSCIMPatchGroup adds or removes team members, or renames the team.
+// URL: PATCH /scim/v2/Groups/:id
+func (s *Server) handleSCIMPatchGroup(c *fiber.Ctx) error {
+	var req models.SCIMPatchRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sendSCIMErrorStatus(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+	group, err := core.SCIMPatchGroup(c.Context(), s.sqlite, s.log, c.Params("id"), &req)
+	if err != nil {
+		return s.sendSCIMError(c, err, "update group")
+	}
+	return sendSCIM(c, fiber.StatusOK, withGroupLocation(c, group))
+}
+
+// handleSCIMDeleteGroup deletes a team.
+// URL: DELETE /scim/v2/Groups/:id
+func (s *Server) handleSCIMDeleteGroup(c *fiber.Ctx) error {
+	if err := core.SCIMDeleteGroup(c.Context(), s.sqlite, s.log, c.Params("id")); err != nil {
+		return s.sendSCIMError(c, err, "delete group")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
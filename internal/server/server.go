@@ -9,8 +9,10 @@ import (
 	"github.com/mr-karan/logchef/internal/auth"
 	"github.com/mr-karan/logchef/internal/clickhouse"
 	"github.com/mr-karan/logchef/internal/config"
+	"github.com/mr-karan/logchef/internal/core"
 	"github.com/mr-karan/logchef/internal/metrics"
 	"github.com/mr-karan/logchef/internal/sqlite"
+	"github.com/mr-karan/logchef/pkg/models"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
@@ -28,6 +30,11 @@ type ServerOptions struct {
 	SQLite       *sqlite.DB
 	ClickHouse   *clickhouse.Manager
 	OIDCProvider *auth.OIDCProvider // OIDC provider for authentication flows.
+	Auditor      *core.AuditLogger  // Query audit log writer; nil when auditing is disabled.
+	QueryCache   *core.QueryCache   // Query result cache; nil when caching is disabled.
+	MapKeys      *core.MapKeysCache // Sampled Map column keys.
+	Provisioner  *core.Provisioner  // Applies the provisioning files; nil when provisioning is disabled.
+	Scheduler    *core.Scheduler    // Runs the background maintenance jobs.
 	FS           http.FileSystem    // Filesystem for serving static assets (frontend).
 	Logger       *slog.Logger
 	BuildInfo    string
@@ -42,6 +49,12 @@ type Server struct {
 	sqlite       *sqlite.DB
 	clickhouse   *clickhouse.Manager
 	oidcProvider *auth.OIDCProvider // Handles OIDC authentication logic.
+	auditor      *core.AuditLogger  // Records executed queries.
+	queryCache   *core.QueryCache   // Caches query results.
+	mapKeys      *core.MapKeysCache // Caches sampled Map column keys.
+	provisioner  *core.Provisioner  // Reports the provisioning status.
+	scheduler    *core.Scheduler    // Reports and triggers background jobs.
+	shareLimiter *core.SharePasswordLimiter
 	fs           http.FileSystem
 	log          *slog.Logger
 	buildInfo    string
@@ -76,6 +89,11 @@ func New(opts ServerOptions) *Server {
 		DisableStartupMessage: true, // Avoid default Fiber banner.
 		ReadTimeout:           opts.Config.Server.HTTPServerTimeout,
 		WriteTimeout:          opts.Config.Server.HTTPServerTimeout,
+		// Take the client address from X-Forwarded-For only when sent by a trusted proxy.
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          opts.Config.Server.TrustedProxies,
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableIPValidation:      true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -104,12 +122,21 @@ func New(opts ServerOptions) *Server {
 		sqlite:       opts.SQLite,
 		clickhouse:   opts.ClickHouse,
 		oidcProvider: opts.OIDCProvider,
+		auditor:      opts.Auditor,
+		queryCache:   opts.QueryCache,
+		mapKeys:      opts.MapKeys,
+		provisioner:  opts.Provisioner,
+		scheduler:    opts.Scheduler,
+		shareLimiter: core.NewSharePasswordLimiter(),
 		fs:           opts.FS,
 		log:          opts.Logger,
 		buildInfo:    opts.BuildInfo,
 		version:      opts.Version,
 	}
 
+	// Apply the configured query limits to the tracker of running queries.
+	queryTracker.SetLimits(opts.Config.QueryLimits)
+
 	// Register all application routes.
 	s.setupRoutes()
 
@@ -141,59 +168,142 @@ func (s *Server) setupRoutes() {
 
 	// API Token Management for current user
 	api.Get("/me/tokens", s.requireAuth, s.handleListAPITokens)
-	api.Post("/me/tokens", s.requireAuth, s.handleCreateAPIToken)
-	api.Delete("/me/tokens/:tokenID", s.requireAuth, s.handleDeleteAPIToken)
+	api.Post("/me/tokens", s.requireAuth, s.requireScope(models.ScopeTokensWrite), s.handleCreateAPIToken)
+	api.Delete("/me/tokens/:tokenID", s.requireAuth, s.requireScope(models.ScopeTokensWrite), s.handleDeleteAPIToken)
+
+	// Query history for current user
+	api.Get("/me/query-history", s.requireAuth, s.requireScope(models.ScopeLogsQuery), s.handleListMyQueryHistory)
+
+	// Share links created by current user
+	api.Get("/me/shares", s.requireAuth, s.requireScope(models.ScopeSharesWrite), s.handleListMyShareLinks)
+
+	// --- Share Link Routes ---
+	// Opening a link only requires a login when the link or its team doesn't allow anonymous access
+	api.Get("/shares/:slug", s.optionalAuth, s.handleOpenShareLink)
+	api.Delete("/shares/:slug", s.requireAuth, s.requireScope(models.ScopeSharesWrite), s.handleRevokeShareLink)
 
 	// --- Admin Routes ---
 	// These endpoints are only accessible to admin users for global management
 	admin := api.Group("/admin", s.requireAuth, s.requireAdmin)
 	{
 		// User Management
-		admin.Get("/users", s.handleListUsers)
-		admin.Post("/users", s.handleCreateUser)
-		admin.Get("/users/:userID", s.handleGetUser)
-		admin.Put("/users/:userID", s.handleUpdateUser)
-		admin.Delete("/users/:userID", s.handleDeleteUser)
+		admin.Get("/users", s.requireScope(models.ScopeAdminUsers), s.handleListUsers)
+		admin.Post("/users", s.requireScope(models.ScopeAdminUsers), s.handleCreateUser)
+		admin.Get("/users/:userID", s.requireScope(models.ScopeAdminUsers), s.handleGetUser)
+		admin.Put("/users/:userID", s.requireScope(models.ScopeAdminUsers), s.handleUpdateUser)
+		admin.Delete("/users/:userID", s.requireScope(models.ScopeAdminUsers), s.handleDeleteUser)
 
 		// Global Team Management
-		admin.Get("/teams", s.handleListTeams)
-		admin.Post("/teams", s.handleCreateTeam)
-		admin.Delete("/teams/:teamID", s.handleDeleteTeam)
+		admin.Get("/teams", s.requireScope(models.ScopeAdminTeams), s.handleListTeams)
+		admin.Post("/teams", s.requireScope(models.ScopeAdminTeams), s.handleCreateTeam)
+		admin.Delete("/teams/:teamID", s.requireScope(models.ScopeAdminTeams), s.handleDeleteTeam)
+		admin.Get("/teams/:teamID/sources/:sourceID/row-filter", s.requireScope(models.ScopeAdminTeams), s.handleGetTeamSourceRowFilter)
+		admin.Put("/teams/:teamID/sources/:sourceID/row-filter", s.requireScope(models.ScopeAdminTeams), s.handleUpdateTeamSourceRowFilter)
+		admin.Get("/teams/:teamID/sources/:sourceID/column-policies", s.requireScope(models.ScopeAdminTeams), s.handleListColumnPolicies)
+		admin.Post("/teams/:teamID/sources/:sourceID/column-policies", s.requireScope(models.ScopeAdminTeams), s.handleSetColumnPolicy)
+		admin.Delete("/teams/:teamID/sources/:sourceID/column-policies/:policyID", s.requireScope(models.ScopeAdminTeams), s.handleDeleteColumnPolicy)
 
 		// Global Source Management
-		admin.Get("/sources", s.handleListSources) // Admin endpoint for listing all sources
-		admin.Post("/sources", s.handleCreateSource)
-		admin.Post("/sources/validate", s.handleValidateSourceConnection)
-		admin.Delete("/sources/:sourceID", s.handleDeleteSource)
-		admin.Get("/sources/:sourceID/stats", s.handleGetSourceStats) // Admin-only source stats
+		admin.Get("/sources", s.requireScope(models.ScopeAdminSources), s.handleListSources) // Admin endpoint for listing all sources
+		admin.Post("/sources", s.requireScope(models.ScopeAdminSources), s.handleCreateSource)
+		admin.Post("/sources/validate", s.requireScope(models.ScopeAdminSources), s.handleValidateSourceConnection)
+		admin.Post("/sources/discover", s.requireScope(models.ScopeAdminSources), s.handleDiscoverTables)
+		admin.Put("/sources/:sourceID", s.requireScope(models.ScopeAdminSources), s.handleUpdateSource)
+		admin.Delete("/sources/:sourceID", s.requireScope(models.ScopeAdminSources), s.handleDeleteSource)
+		admin.Get("/sources/:sourceID/retention", s.requireScope(models.ScopeAdminSources), s.handleGetSourceRetention)
+		admin.Get("/sources/:sourceID/schema-changes", s.requireScope(models.ScopeAdminSources), s.handleGetSourceSchemaChanges)
+		admin.Get("/sources/:sourceID/stats", s.requireScope(models.ScopeAdminSources), s.handleGetSourceStats) // Admin-only source stats
+		admin.Get("/schema-templates", s.requireScope(models.ScopeAdminSources), s.handleListSchemaTemplates)
+		admin.Post("/schema-templates/:name/render", s.requireScope(models.ScopeAdminSources), s.handleRenderSchemaTemplate)
+
+		// Query Audit Log
+		admin.Get("/query-audit", s.requireScope(models.ScopeAdminAudit), s.handleSearchQueryAuditLog)
+
+		// Bundles of sources, teams and collections
+		admin.Get("/bundle/export", s.requireScope(models.ScopeAdminConfig), s.handleExportBundle)
+		admin.Post("/bundle/import", s.requireScope(models.ScopeAdminConfig), s.handleImportBundle)
+
+		// Declarative provisioning
+		admin.Get("/provisioning", s.requireScope(models.ScopeAdminConfig), s.handleGetProvisioningStatus)
+		admin.Post("/provisioning/apply", s.requireScope(models.ScopeAdminConfig), s.handleApplyProvisioning)
+
+		// OIDC group sync decisions
+		admin.Get("/oidc-sync-events", s.requireScope(models.ScopeAdminAudit), s.handleSearchOIDCSyncEvents)
+
+		// Background maintenance jobs
+		admin.Get("/jobs", s.requireScope(models.ScopeAdminConfig), s.handleListJobs)
+		admin.Post("/jobs/:name/run", s.requireScope(models.ScopeAdminConfig), s.handleRunJob)
 	}
 
 	// --- Team Routes (Access controlled by team membership) ---
 	// Regular users can view teams they belong to, team admins can manage membership and linked sources
 
 	// Team details and members (requires team membership)
-	api.Get("/teams/:teamID", s.requireAuth, s.requireTeamMember, s.handleGetTeam)
+	api.Get("/teams/:teamID", s.requireAuth, s.requireTeamMember, s.requireScope(models.ScopeTeamsRead), s.handleGetTeam)
 
 	// Team member management (requires team admin or global admin)
 	teamMembers := api.Group("/teams/:teamID/members", s.requireAuth, s.requireTeamMember)
 	{
-		teamMembers.Get("/", s.handleListTeamMembers) // Any team member can view
+		teamMembers.Get("/", s.requireScope(models.ScopeTeamsRead), s.handleListTeamMembers) // Any team member can view
 		// Only team admins can add/remove members
-		teamMembers.Post("/", s.requireTeamAdminOrGlobalAdmin, s.handleAddTeamMember)
-		teamMembers.Delete("/:userID", s.requireTeamAdminOrGlobalAdmin, s.handleRemoveTeamMember)
+		teamMembers.Post("/", s.requireScope(models.ScopeTeamsWrite), s.requireTeamAdminOrGlobalAdmin, s.handleAddTeamMember)
+		teamMembers.Delete("/:userID", s.requireScope(models.ScopeTeamsWrite), s.requireTeamAdminOrGlobalAdmin, s.handleRemoveTeamMember)
 	}
 
 	// Team settings (requires team admin or global admin)
-	api.Put("/teams/:teamID", s.requireAuth, s.requireTeamAdminOrGlobalAdmin, s.handleUpdateTeam)
+	api.Put("/teams/:teamID", s.requireAuth, s.requireScope(models.ScopeTeamsWrite), s.requireTeamAdminOrGlobalAdmin, s.handleUpdateTeam)
+	api.Get("/teams/:teamID/shares", s.requireAuth, s.requireScope(models.ScopeSharesWrite), s.requireTeamAdminOrGlobalAdmin, s.handleListTeamShareLinks)
 
 	// Team Source Management (linking/unlinking)
 	teamSources := api.Group("/teams/:teamID/sources", s.requireAuth, s.requireTeamMember)
 	{
-		teamSources.Get("/", s.handleListTeamSources) // Any team member can view team sources (basic info)
+		teamSources.Get("/", s.requireScope(models.ScopeTeamsRead), s.handleListTeamSources) // Any team member can view team sources (basic info)
 
 		// Only team admins can link/unlink sources
-		teamSources.Post("/", s.requireTeamAdminOrGlobalAdmin, s.handleLinkSourceToTeam)
-		teamSources.Delete("/:sourceID", s.requireTeamAdminOrGlobalAdmin, s.handleUnlinkSourceFromTeam)
+		teamSources.Post("/", s.requireScope(models.ScopeTeamsWrite), s.requireTeamAdminOrGlobalAdmin, s.handleLinkSourceToTeam)
+		teamSources.Delete("/:sourceID", s.requireScope(models.ScopeTeamsWrite), s.requireTeamAdminOrGlobalAdmin, s.handleUnlinkSourceFromTeam)
+	}
+
+	// Team Dashboards (panels over the team's sources)
+	// Regular team members can view and run dashboards
+	dashboards := api.Group("/teams/:teamID/dashboards", s.requireAuth, s.requireTeamMember)
+	{
+		dashboards.Get("/", s.requireScope(models.ScopeCollectionsRead), s.handleListTeamDashboards)
+		dashboards.Get("/:dashboardID", s.requireScope(models.ScopeCollectionsRead), s.handleGetTeamDashboard)
+		dashboards.Post("/:dashboardID/run", s.requireScope(models.ScopeLogsQuery), s.handleRunTeamDashboard)
+
+		// Only team editors, team admins, or global admins can manage dashboards
+		dashboards.Post("/", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleCreateTeamDashboard)
+		dashboards.Put("/:dashboardID", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleUpdateTeamDashboard)
+		dashboards.Delete("/:dashboardID", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleDeleteTeamDashboard)
+	}
+
+	// Team-wide collection search and organization across all the team's sources
+	teamCollections := api.Group("/teams/:teamID/collections", s.requireAuth, s.requireTeamMember)
+	{
+		teamCollections.Get("/search", s.requireScope(models.ScopeCollectionsRead), s.handleSearchTeamCollections)
+		teamCollections.Get("/tags", s.requireScope(models.ScopeCollectionsRead), s.handleListTeamCollectionTags)
+	}
+	folders := api.Group("/teams/:teamID/collection-folders", s.requireAuth, s.requireTeamMember)
+	{
+		folders.Get("/", s.requireScope(models.ScopeCollectionsRead), s.handleListCollectionFolders)
+
+		// Only team editors, team admins, or global admins can manage folders
+		folders.Post("/", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleCreateCollectionFolder)
+		folders.Put("/:folderID", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleRenameCollectionFolder)
+		folders.Delete("/:folderID", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleDeleteCollectionFolder)
+	}
+
+	// Trace correlation across all the team's sources
+	traces := api.Group("/teams/:teamID/traces", s.requireAuth, s.requireTeamMember)
+	{
+		traces.Get("/:traceID", s.requireScope(models.ScopeLogsQuery), s.handleGetTrace)
+	}
+
+	// Federated search across several of the team's sources
+	search := api.Group("/teams/:teamID/search", s.requireAuth, s.requireTeamMember)
+	{
+		search.Post("/", s.requireScope(models.ScopeLogsQuery), s.handleFederatedSearch)
 	}
 
 	// --- Team Source Operations (requires team membership) ---
@@ -201,30 +311,66 @@ func (s *Server) setupRoutes() {
 	teamSourceOps := api.Group("/teams/:teamID/sources/:sourceID", s.requireAuth, s.requireTeamMember, s.requireTeamHasSource)
 	{
 		// Get detailed source info including connection status and schema
-		teamSourceOps.Get("/", s.handleGetTeamSource)
-		teamSourceOps.Get("/stats", s.handleGetTeamSourceStats)
+		teamSourceOps.Get("/", s.requireScope(models.ScopeLogsQuery), s.handleGetTeamSource)
+		teamSourceOps.Get("/stats", s.requireScope(models.ScopeLogsQuery), s.handleGetTeamSourceStats)
 
 		// Query and explore logs
-		teamSourceOps.Post("/logs/query", s.handleQueryLogs)
-		teamSourceOps.Post("/logs/query/:queryID/cancel", s.handleCancelQuery)
-		teamSourceOps.Get("/schema", s.handleGetSourceSchema)
-		teamSourceOps.Post("/logs/histogram", s.handleGetHistogram)
-		teamSourceOps.Post("/generate-sql", s.handleGenerateAISQL)
+		teamSourceOps.Post("/logs/query", s.requireScope(models.ScopeLogsQuery), s.handleQueryLogs)
+		teamSourceOps.Post("/logs/query/:queryID/cancel", s.requireScope(models.ScopeLogsQuery), s.handleCancelQuery)
+		teamSourceOps.Get("/schema", s.requireScope(models.ScopeLogsQuery), s.handleGetSourceSchema)
+		teamSourceOps.Get("/schema/map-keys", s.requireScope(models.ScopeLogsQuery), s.handleGetSourceMapKeys)
+		teamSourceOps.Get("/schema-changes", s.requireScope(models.ScopeLogsQuery), s.handleGetTeamSourceSchemaChanges)
+		teamSourceOps.Post("/logs/histogram", s.requireScope(models.ScopeLogsQuery), s.handleGetHistogram)
+		teamSourceOps.Post("/logs/patterns", s.requireScope(models.ScopeLogsQuery), s.handleGetLogPatterns)
+		teamSourceOps.Post("/logs/context", s.requireScope(models.ScopeLogsQuery), s.handleGetLogContext)
+		teamSourceOps.Post("/generate-sql", s.requireScope(models.ScopeLogsQuery), s.handleGenerateAISQL)
+
+		// Share query results as expiring permalinks
+		teamSourceOps.Post("/shares", s.requireScope(models.ScopeSharesWrite), s.handleCreateShareLink)
 
 		// Collections (Saved Queries) scoped to Team & Source
 		// Regular team members can view and use collections
 		collections := teamSourceOps.Group("/collections")
 		{
-			collections.Get("/", s.handleListTeamSourceCollections)
-			collections.Get("/:collectionID", s.handleGetTeamSourceCollection)
+			collections.Get("/", s.requireScope(models.ScopeCollectionsRead), s.handleListTeamSourceCollections)
+			collections.Get("/:collectionID", s.requireScope(models.ScopeCollectionsRead), s.handleGetTeamSourceCollection)
+			collections.Post("/:collectionID/run", s.requireScope(models.ScopeLogsQuery), s.handleRunTeamSourceCollection)
+			collections.Get("/:collectionID/variables/:name/options", s.requireScope(models.ScopeLogsQuery), s.handleGetCollectionVariableOptions)
+			collections.Get("/:collectionID/revisions", s.requireScope(models.ScopeCollectionsRead), s.handleListCollectionRevisions)
+			collections.Get("/:collectionID/revisions/:revision", s.requireScope(models.ScopeCollectionsRead), s.handleGetCollectionRevision)
+			collections.Get("/:collectionID/revisions/:revision/diff", s.requireScope(models.ScopeCollectionsRead), s.handleDiffCollectionRevision)
+			collections.Put("/:collectionID/favorite", s.requireScope(models.ScopeCollectionsRead), s.handleSetCollectionFavorite)
+			collections.Delete("/:collectionID/favorite", s.requireScope(models.ScopeCollectionsRead), s.handleRemoveCollectionFavorite)
 
 			// Only team editors, team admins, or global admins can manage collections
-			collections.Post("/", s.requireCollectionManagement, s.handleCreateTeamSourceCollection)
-			collections.Put("/:collectionID", s.requireCollectionManagement, s.handleUpdateTeamSourceCollection)
-			collections.Delete("/:collectionID", s.requireCollectionManagement, s.handleDeleteTeamSourceCollection)
+			collections.Post("/", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleCreateTeamSourceCollection)
+			collections.Put("/:collectionID", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleUpdateTeamSourceCollection)
+			collections.Delete("/:collectionID", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleDeleteTeamSourceCollection)
+			collections.Post("/:collectionID/revisions/:revision/restore", s.requireScope(models.ScopeCollectionsWrite), s.requireCollectionManagement, s.handleRestoreCollectionRevision)
 		}
 	}
 
+	// --- SCIM 2.0 Routes (identity provider user and team provisioning) ---
+	if s.config.SCIM.Enabled {
+		scim := s.app.Group("/scim/v2", s.requireSCIMToken)
+		scim.Get("/ServiceProviderConfig", s.handleSCIMServiceProviderConfig)
+		scim.Get("/ResourceTypes", s.handleSCIMResourceTypes)
+
+		scim.Get("/Users", s.handleSCIMListUsers)
+		scim.Post("/Users", s.handleSCIMCreateUser)
+		scim.Get("/Users/:id", s.handleSCIMGetUser)
+		scim.Put("/Users/:id", s.handleSCIMReplaceUser)
+		scim.Patch("/Users/:id", s.handleSCIMPatchUser)
+		scim.Delete("/Users/:id", s.handleSCIMDeleteUser)
+
+		scim.Get("/Groups", s.handleSCIMListGroups)
+		scim.Post("/Groups", s.handleSCIMCreateGroup)
+		scim.Get("/Groups/:id", s.handleSCIMGetGroup)
+		scim.Put("/Groups/:id", s.handleSCIMReplaceGroup)
+		scim.Patch("/Groups/:id", s.handleSCIMPatchGroup)
+		scim.Delete("/Groups/:id", s.handleSCIMDeleteGroup)
+	}
+
 	// --- Static Asset and SPA Handling ---
 	s.app.Use("/api/*", s.notFoundHandler) // Catch-all for API 404s
 	s.app.Use("/assets", filesystem.New(filesystem.Config{
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sharePasswordHeader carries the password of a protected share link.
+const sharePasswordHeader = "X-Share-Password"
+
+// sendShareError maps share link errors to responses.
+func (s *Server) sendShareError(c *fiber.Ctx, err error, msg string) error {
+	var validationErr *core.ValidationError
+	var attemptsErr *core.SharePasswordAttemptsError
+	switch {
+	case isQueryLimitError(err):
+		return sendQueryLimitError(c, err)
+	case errors.As(err, &attemptsErr):
+		retryAfter := int(math.Ceil(attemptsErr.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return SendErrorWithType(c, fiber.StatusTooManyRequests,
+			fmt.Sprintf("Too many wrong passwords for this share link. Try again in %ds.", retryAfter), models.RateLimitErrorType)
+	case errors.As(err, &validationErr):
+		return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Error(), models.ValidationErrorType)
+	case errors.Is(err, core.ErrShareLinkNotFound):
+		return SendErrorWithType(c, fiber.StatusNotFound, "Share link not found", models.NotFoundErrorType)
+	case errors.Is(err, core.ErrShareLinkExpired), errors.Is(err, core.ErrShareLinkRevoked):
+		return SendErrorWithType(c, fiber.StatusGone, err.Error(), models.NotFoundErrorType)
+	case errors.Is(err, core.ErrShareLoginRequired), errors.Is(err, core.ErrSharePasswordRequired), errors.Is(err, core.ErrSharePasswordInvalid):
+		return SendErrorWithType(c, fiber.StatusUnauthorized, err.Error(), models.AuthenticationErrorType)
+	case errors.Is(err, core.ErrShareAccessRevoked), errors.Is(err, core.ErrShareLinkForbidden):
+		return SendErrorWithType(c, fiber.StatusForbidden, err.Error(), models.AuthorizationErrorType)
+	case errors.Is(err, core.ErrSourceNotFound):
+		return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+	case errors.Is(err, core.ErrTeamNotFound):
+		return SendErrorWithType(c, fiber.StatusNotFound, "Team not found", models.NotFoundErrorType)
+	}
+	s.log.Error(msg, slog.Any("error", err), "slug", c.Params("slug"), "team_id", c.Params("teamID"))
+	return SendErrorWithType(c, fiber.StatusInternalServerError, fmt.Sprintf("%s: %v", msg, err), models.GeneralErrorType)
+}
+
+// handleCreateShareLink shares a query result of a team's source as an expiring permalink.
+// Snapshot links run the query now and store the result; live links re-run it when opened.
+// URL: POST /api/v1/teams/:teamID/sources/:sourceID/shares
+// Requires: Team membership
+func (s *Server) handleCreateShareLink(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source ID format", models.ValidationErrorType)
+	}
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	var req models.CreateShareLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	auditEntry := s.newQueryAuditEntry(c, models.QueryAuditTypeShare, teamID, sourceID, req.RawSQL)
+	start := time.Now()
+	link, err := core.CreateShareLink(core.WithAuditEntry(c.Context(), auditEntry), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, sourceID, user.ID, req, queryAcquirer(user.ID))
+	// Capturing a snapshot runs the query, which fills in the audit entry's final SQL.
+	if auditEntry.FinalSQL != "" {
+		rows := 0
+		if link != nil {
+			var snapshot struct {
+				Logs []json.RawMessage `json:"logs"`
+			}
+			if json.Unmarshal([]byte(link.Snapshot), &snapshot) == nil {
+				rows = len(snapshot.Logs)
+			}
+		}
+		s.recordQueryAudit(auditEntry, start, rows, err)
+	}
+	if err != nil {
+		return s.sendShareError(c, err, "Failed to create share link")
+	}
+	return SendSuccess(c, fiber.StatusCreated, link)
+}
+
+// handleOpenShareLink returns the result of a share link. Links allowing anonymous access
+// can be opened without logging in if their team allows it. The password of a protected
+// link is sent in the X-Share-Password header; wrong passwords are limited per link and
+// client address.
+// URL: GET /api/v1/shares/:slug
+// Requires: Authenticated user, unless the link allows anonymous access
+func (s *Server) handleOpenShareLink(c *fiber.Ctx) error {
+	viewer, _ := c.Locals("user").(*models.User)
+	// Live links count against the viewer's query limits. Anonymous viewers share the limits of
+	// user ID 0.
+	var viewerID models.UserID
+	if viewer != nil {
+		viewerID = viewer.ID
+	}
+
+	auditEntry := &models.QueryAuditEntry{QueryType: models.QueryAuditTypeShare}
+	start := time.Now()
+	shared, err := core.OpenShareLink(core.WithAuditEntry(c.Context(), auditEntry), s.sqlite, s.clickhouse, s.queryCache, s.shareLimiter, s.log, c.Params("slug"), viewer, c.Get(sharePasswordHeader), c.IP(), queryAcquirer(viewerID))
+	// Only live links run a query, which fills in the audit entry.
+	if auditEntry.TeamID != nil {
+		rows := 0
+		if shared != nil && shared.Result != nil {
+			rows = len(shared.Result.Logs)
+		}
+		s.recordQueryAudit(auditEntry, start, rows, err)
+	}
+	if err != nil {
+		return s.sendShareError(c, err, "Failed to open share link")
+	}
+	return SendSuccess(c, fiber.StatusOK, shared)
+}
+
+// handleListMyShareLinks lists the share links created by the current user.
+// URL: GET /api/v1/me/shares
+// Requires: Authenticated user
+func (s *Server) handleListMyShareLinks(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	links, err := core.ListUserShareLinks(c.Context(), s.sqlite, user.ID)
+	if err != nil {
+		return s.sendShareError(c, err, "Failed to list share links")
+	}
+	return SendSuccess(c, fiber.StatusOK, links)
+}
+
+// handleListTeamShareLinks lists the share links of a team.
+// URL: GET /api/v1/teams/:teamID/shares
+// Requires: Team admin or global admin
+func (s *Server) handleListTeamShareLinks(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	links, err := core.ListTeamShareLinks(c.Context(), s.sqlite, teamID)
+	if err != nil {
+		return s.sendShareError(c, err, "Failed to list share links")
+	}
+	return SendSuccess(c, fiber.StatusOK, links)
+}
+
+// handleRevokeShareLink revokes a share link so it can no longer be opened.
+// URL: DELETE /api/v1/shares/:slug
+// Requires: The link's creator, an admin of its team or a global admin
+func (s *Server) handleRevokeShareLink(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	if err := core.RevokeShareLink(c.Context(), s.sqlite, s.log, c.Params("slug"), user); err != nil {
+		return s.sendShareError(c, err, "Failed to revoke share link")
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Share link revoked successfully"})
+}
@@ -59,6 +59,22 @@ func (s *Server) handleCreateSource(c *fiber.Ctx) error {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
 	}
 
+	// Tables created from a schema template default to the template's timestamp and severity fields.
+	if req.MetaIsAutoCreated && req.Schema == "" {
+		templateName := req.Template
+		if templateName == "" {
+			templateName = core.DefaultSchemaTemplate
+		}
+		if tmpl, err := core.GetSchemaTemplate(templateName); err == nil {
+			if req.MetaTSField == "" {
+				req.MetaTSField = tmpl.TimestampField
+			}
+			if req.MetaSeverityField == "" {
+				req.MetaSeverityField = tmpl.SeverityField
+			}
+		}
+	}
+
 	// Set default timestamp field if not provided by user.
 	if req.MetaTSField == "" {
 		req.MetaTSField = "timestamp"
@@ -78,7 +94,11 @@ func (s *Server) handleCreateSource(c *fiber.Ctx) error {
 		req.TTLDays,
 		req.MetaTSField,
 		req.MetaSeverityField,
+		req.FieldRoles,
+		req.SeverityMapping,
 		req.Schema,
+		req.Template,
+		req.TemplateParams,
 	)
 	if err != nil {
 		// Handle specific validation or creation errors from core.
@@ -96,6 +116,165 @@ func (s *Server) handleCreateSource(c *fiber.Ctx) error {
 	return SendSuccess(c, fiber.StatusCreated, createdSource.ToResponse())
 }
 
+// handleUpdateSource updates the description, retention, field roles and severity mapping of
+// a source. A new retention is applied to the ClickHouse table of an auto-created source.
+// URL: PUT /api/v1/admin/sources/:sourceID
+// Requires: Admin privileges
+func (s *Server) handleUpdateSource(c *fiber.Ctx) error {
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	var req models.UpdateSourceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	// Omitted fields keep their current values.
+	src, err := s.sqlite.GetSource(c.Context(), sourceID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) || errors.Is(err, sql.ErrNoRows) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to get source before update", slog.Any("error", err), "source_id", sourceID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Error getting source details", models.DatabaseErrorType)
+	}
+	description, ttlDays := src.Description, src.TTLDays
+	if req.Description != nil {
+		description = *req.Description
+	}
+	if req.TTLDays != nil {
+		ttlDays = *req.TTLDays
+	}
+	fieldRoles, severityMapping := src.FieldRoles, src.SeverityMapping
+	if req.FieldRoles != nil {
+		fieldRoles = *req.FieldRoles
+	}
+	if req.SeverityMapping != nil {
+		severityMapping = *req.SeverityMapping
+	}
+
+	var changedBy *models.UserID
+	if userID := getUserIDFromContext(c); userID != 0 {
+		changedBy = &userID
+	}
+
+	updated, err := core.UpdateSource(c.Context(), s.sqlite, s.clickhouse, s.queryCache, s.log, sourceID, description, ttlDays, fieldRoles, severityMapping, changedBy)
+	if err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Error(), models.ValidationErrorType)
+		}
+		switch {
+		case errors.Is(err, core.ErrSourceNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		case errors.Is(err, core.ErrProvisioned):
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		case errors.Is(err, core.ErrRetentionNotApplied):
+			return SendErrorWithType(c, fiber.StatusBadGateway, err.Error(), models.DatabaseErrorType)
+		}
+		s.log.Error("failed to update source via core function", slog.Any("error", err), "source_id", sourceID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Error updating source", models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, updated.ToResponse())
+}
+
+// handleGetSourceRetention compares the retention stored for a source with the TTL of its
+// ClickHouse table and lists the latest retention changes. The comparison is null when the
+// table can't be inspected.
+// URL: GET /api/v1/admin/sources/:sourceID/retention
+// Requires: Admin privileges
+func (s *Server) handleGetSourceRetention(c *fiber.Ctx) error {
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	retention, err := core.GetSourceRetention(c.Context(), s.sqlite, s.clickhouse, sourceID)
+	if errors.Is(err, core.ErrSourceNotFound) {
+		return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+	}
+	if err != nil {
+		s.log.Warn("failed to inspect source table ttl", slog.Any("error", err), "source_id", sourceID)
+	}
+
+	changes, err := core.ListSourceRetentionChanges(c.Context(), s.sqlite, sourceID)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Error listing retention changes", models.DatabaseErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"retention": retention, "changes": changes})
+}
+
+// handleGetSourceSchemaChanges lists the schema changes detected on a source and the saved
+// queries referencing removed columns.
+// URL: GET /api/v1/admin/sources/:sourceID/schema-changes
+// Requires: Admin privileges
+func (s *Server) handleGetSourceSchemaChanges(c *fiber.Ctx) error {
+	return s.sendSourceSchemaDrift(c, nil)
+}
+
+// handleGetTeamSourceSchemaChanges lists the schema changes detected on a source and the
+// team's saved queries referencing removed columns.
+// URL: GET /api/v1/teams/:teamID/sources/:sourceID/schema-changes
+// Requires: Team membership, team access to the source
+func (s *Server) handleGetTeamSourceSchemaChanges(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID: "+err.Error(), models.ValidationErrorType)
+	}
+	return s.sendSourceSchemaDrift(c, &teamID)
+}
+
+// sendSourceSchemaDrift sends the schema drift of the source in the URL, limited to a team's
+// saved queries when teamID is set.
+func (s *Server) sendSourceSchemaDrift(c *fiber.Ctx, teamID *models.TeamID) error {
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	drift, err := core.GetSourceSchemaDrift(c.Context(), s.sqlite, sourceID, teamID)
+	if err != nil {
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to get source schema changes", slog.Any("error", err), "source_id", sourceID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Error listing schema changes", models.DatabaseErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, drift)
+}
+
+// handleListSchemaTemplates lists the schema templates available for auto-created tables.
+// URL: GET /api/v1/admin/schema-templates
+// Requires: Admin privileges
+func (s *Server) handleListSchemaTemplates(c *fiber.Ctx) error {
+	return SendSuccess(c, fiber.StatusOK, core.ListSchemaTemplates())
+}
+
+// handleRenderSchemaTemplate renders the CREATE TABLE statement of a schema template, to preview it.
+// URL: POST /api/v1/admin/schema-templates/:name/render
+// Requires: Admin privileges
+func (s *Server) handleRenderSchemaTemplate(c *fiber.Ctx) error {
+	var req models.RenderSchemaTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	ddl, err := core.RenderSchemaTemplate(c.Params("name"), req.Database, req.Table, req.TTLDays, req.Params)
+	if err != nil {
+		if errors.Is(err, core.ErrSchemaTemplateNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Schema template not found", models.NotFoundErrorType)
+		}
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Error(), models.ValidationErrorType)
+		}
+		s.log.Error("failed to render schema template", slog.Any("error", err), "template", c.Params("name"))
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Error rendering schema template", models.GeneralErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"schema": ddl})
+}
+
 // handleDeleteSource deletes a data source.
 // URL: DELETE /api/v1/admin/sources/:sourceID
 // Requires: Admin privileges
@@ -110,10 +289,13 @@ func (s *Server) handleDeleteSource(c *fiber.Ctx) error {
 	}
 
 	// Call core function to remove from manager and delete from DB.
-	if err := core.DeleteSource(c.Context(), s.sqlite, s.clickhouse, s.log, sourceID); err != nil {
+	if err := core.DeleteSource(c.Context(), s.sqlite, s.clickhouse, s.queryCache, s.log, sourceID); err != nil {
 		if errors.Is(err, core.ErrSourceNotFound) {
 			return SendError(c, fiber.StatusNotFound, "Source not found")
 		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendError(c, fiber.StatusConflict, err.Error())
+		}
 		s.log.Error("failed to delete source via core function", slog.Any("error", err), "source_id", sourceID)
 		return SendError(c, fiber.StatusInternalServerError, "Error deleting source: "+err.Error())
 	}
@@ -146,7 +328,7 @@ func (s *Server) handleValidateSourceConnection(c *fiber.Ctx) error {
 	if req.TimestampField != "" {
 		result, coreErr = core.ValidateConnectionWithColumns(
 			c.Context(), s.clickhouse, s.log, req.ConnectionInfo,
-			req.TimestampField, req.SeverityField,
+			req.TimestampField, req.SeverityField, req.SeverityMapping,
 		)
 	} else {
 		result, coreErr = core.ValidateConnection(
@@ -169,6 +351,27 @@ func (s *Server) handleValidateSourceConnection(c *fiber.Ctx) error {
 	return SendSuccess(c, fiber.StatusOK, result)
 }
 
+// handleDiscoverTables lists the databases and tables reachable with the connection details
+// provided in the request body, to pick the table of a new source.
+// URL: POST /api/v1/admin/sources/discover
+// Requires: Admin privileges
+func (s *Server) handleDiscoverTables(c *fiber.Ctx) error {
+	var req models.DiscoverTablesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+	}
+
+	discovery, err := core.DiscoverTables(c.Context(), s.sqlite, s.clickhouse, s.log, req)
+	if err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Error(), models.ValidationErrorType)
+		}
+		s.log.Error("error discovering tables", "error", err, "host", req.Host)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Error discovering tables: "+err.Error(), models.ExternalServiceErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, discovery)
+}
+
 // --- User Source Access Handlers ---
 
 // handleGetSourceStats retrieves table and column statistics for a specific source.
@@ -3,7 +3,11 @@ package server
 import (
 	"errors"
 	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/mr-karan/logchef/internal/clickhouse"
 	"github.com/mr-karan/logchef/internal/core"
 	"github.com/mr-karan/logchef/pkg/models"
 
@@ -82,21 +86,36 @@ func (s *Server) handleUpdateTeam(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Name        *string `json:"name"`
-		Description *string `json:"description"`
+		Name                 *string `json:"name"`
+		Description          *string `json:"description"`
+		AllowAnonymousShares *bool   `json:"allow_anonymous_shares"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return SendError(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	// Construct update DTO.
-	updateData := models.Team{}
+	// Construct update DTO, keeping the current value of omitted fields.
+	current, err := core.GetTeam(c.Context(), s.sqlite, teamID)
+	if err != nil {
+		if errors.Is(err, core.ErrTeamNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Team not found", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to get team for update", slog.Any("error", err), "team_id", teamID)
+		return SendError(c, fiber.StatusInternalServerError, "Failed to update team")
+	}
+	updateData := models.Team{
+		Description:          current.Description,
+		AllowAnonymousShares: current.AllowAnonymousShares,
+	}
 	if req.Name != nil {
 		updateData.Name = *req.Name
 	}
 	if req.Description != nil {
 		updateData.Description = *req.Description
 	}
+	if req.AllowAnonymousShares != nil {
+		updateData.AllowAnonymousShares = *req.AllowAnonymousShares
+	}
 
 	// Call core update function.
 	if err := core.UpdateTeam(c.Context(), s.sqlite, s.log, teamID, updateData); err != nil {
@@ -109,6 +128,9 @@ func (s *Server) handleUpdateTeam(c *fiber.Ctx) error {
 		if errors.Is(err, &core.ValidationError{}) {
 			return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
 		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
 		s.log.Error("failed to update team via core function", slog.Any("error", err), "team_id", teamID)
 		return SendError(c, fiber.StatusInternalServerError, "Failed to update team")
 	}
@@ -136,6 +158,9 @@ func (s *Server) handleDeleteTeam(c *fiber.Ctx) error {
 		if errors.Is(err, core.ErrTeamNotFound) {
 			return SendErrorWithType(c, fiber.StatusNotFound, "Team not found", models.NotFoundErrorType)
 		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
 		s.log.Error("failed to delete team via core function", slog.Any("error", err), "team_id", teamID)
 		return SendError(c, fiber.StatusInternalServerError, "Failed to delete team")
 	}
@@ -190,6 +215,9 @@ func (s *Server) handleAddTeamMember(c *fiber.Ctx) error {
 		if errors.Is(err, core.ErrInvalidRole) || errors.Is(err, &core.ValidationError{}) {
 			return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
 		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
 		// Handle potential already-exists non-error case implicitly (core function returns nil)
 		s.log.Error("failed to add team member via core function", slog.Any("error", err), "team_id", teamID, "user_id", req.UserID)
 		return SendError(c, fiber.StatusInternalServerError, "Failed to add team member")
@@ -214,6 +242,9 @@ func (s *Server) handleRemoveTeamMember(c *fiber.Ctx) error {
 	}
 
 	if err := core.RemoveTeamMember(c.Context(), s.sqlite, s.log, teamID, userID); err != nil {
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
 		// Core function returns nil if member didn't exist, so only log unexpected errors.
 		s.log.Error("failed to remove team member via core function", slog.Any("error", err), "team_id", teamID, "user_id", userID)
 		return SendError(c, fiber.StatusInternalServerError, "Failed to remove team member")
@@ -292,8 +323,25 @@ func (s *Server) handleGetTeamSource(c *fiber.Ctx) error {
 		return SendError(c, fiber.StatusInternalServerError, "Failed to get source details")
 	}
 
-	// Convert to response object.
-	return SendSuccess(c, fiber.StatusOK, sourceDetails.ToResponse())
+	// Only describe the columns visible to the team. The CREATE TABLE statement and sort keys
+	// could name hidden columns, so they're dropped or filtered when the team has policies.
+	policies, err := core.ListColumnPolicies(c.Context(), s.sqlite, teamID, sourceID)
+	if err != nil {
+		s.log.Error("failed to get column policies", slog.Any("error", err), "source_id", sourceID, "team_id", teamID)
+		return SendError(c, fiber.StatusInternalServerError, "Failed to get source details")
+	}
+	response := sourceDetails.ToResponse()
+	if len(policies) > 0 {
+		response.Columns = core.ApplyColumnPolicies(response.Columns, policies)
+		response.Schema = ""
+		visible := make(map[string]bool, len(response.Columns))
+		for _, col := range response.Columns {
+			visible[col.Name] = true
+		}
+		response.SortKeys = slices.DeleteFunc(slices.Clone(response.SortKeys), func(key string) bool { return !visible[key] })
+	}
+
+	return SendSuccess(c, fiber.StatusOK, response)
 }
 
 // handleLinkSourceToTeam links an existing source to a team.
@@ -307,7 +355,8 @@ func (s *Server) handleLinkSourceToTeam(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		SourceID models.SourceID `json:"source_id"`
+		SourceID  models.SourceID `json:"source_id"`
+		RowFilter string          `json:"row_filter,omitempty"` // Optional; only global admins may set it.
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return SendError(c, fiber.StatusBadRequest, "Invalid request body")
@@ -315,6 +364,14 @@ func (s *Server) handleLinkSourceToTeam(c *fiber.Ctx) error {
 	if req.SourceID <= 0 {
 		return SendError(c, fiber.StatusBadRequest, "Invalid source ID in request body")
 	}
+	if strings.TrimSpace(req.RowFilter) != "" {
+		if !isUserAdmin(c) {
+			return SendErrorWithType(c, fiber.StatusForbidden, "Only global admins can set row-level filters", models.AuthorizationErrorType)
+		}
+		if err := clickhouse.ValidateRowFilter(req.RowFilter); err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+		}
+	}
 
 	// Call core function to create the link.
 	if err := core.AddTeamSource(c.Context(), s.sqlite, s.log, teamID, req.SourceID); err != nil {
@@ -322,10 +379,20 @@ func (s *Server) handleLinkSourceToTeam(c *fiber.Ctx) error {
 			// Return 404 if either team or source doesn't exist.
 			return SendErrorWithType(c, fiber.StatusNotFound, err.Error(), models.NotFoundErrorType)
 		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
 		// Core function returns nil if link already exists.
 		s.log.Error("failed to add team source via core function", slog.Any("error", err), "team_id", teamID, "source_id", req.SourceID)
 		return SendError(c, fiber.StatusInternalServerError, "Failed to link source to team")
 	}
+
+	if strings.TrimSpace(req.RowFilter) != "" {
+		if err := core.UpdateTeamSourceRowFilter(c.Context(), s.sqlite, s.clickhouse, s.mapKeys, s.log, teamID, req.SourceID, req.RowFilter); err != nil {
+			s.log.Error("failed to set team source row filter", slog.Any("error", err), "team_id", teamID, "source_id", req.SourceID)
+			return SendError(c, fiber.StatusInternalServerError, "Failed to set row-level filter")
+		}
+	}
 	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Source linked to team successfully"})
 }
 
@@ -345,11 +412,189 @@ func (s *Server) handleUnlinkSourceFromTeam(c *fiber.Ctx) error {
 		return SendError(c, fiber.StatusBadRequest, "Invalid source ID: "+err.Error())
 	}
 
+	// A link carrying a row-level filter can only be removed by a global admin,
+	// otherwise a team admin could unlink and relink the source without the filter.
+	if !isUserAdmin(c) {
+		rowFilter, err := core.GetTeamSourceRowFilter(c.Context(), s.sqlite, teamID, sourceID)
+		if err != nil && !errors.Is(err, core.ErrSourceNotFound) {
+			s.log.Error("failed to get team source row filter", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
+			return SendError(c, fiber.StatusInternalServerError, "Failed to remove team source link")
+		}
+		if rowFilter != "" {
+			return SendErrorWithType(c, fiber.StatusForbidden, "Only global admins can unlink sources with a row-level filter", models.AuthorizationErrorType)
+		}
+	}
+
 	// Call core function to remove the link.
 	if err := core.RemoveTeamSource(c.Context(), s.sqlite, s.log, teamID, sourceID); err != nil {
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
 		// Core function likely doesn't error if link doesn't exist, log unexpected errors.
 		s.log.Error("failed to remove team source via core function", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
 		return SendError(c, fiber.StatusInternalServerError, "Failed to remove team source link")
 	}
 	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Source unlinked from team successfully"})
 }
+
+// handleGetTeamSourceRowFilter returns the row-level filter of a team-source link.
+// URL: GET /api/v1/admin/teams/:teamID/sources/:sourceID/row-filter
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleGetTeamSourceRowFilter(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid team ID: "+err.Error())
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid source ID: "+err.Error())
+	}
+
+	rowFilter, err := core.GetTeamSourceRowFilter(c.Context(), s.sqlite, teamID, sourceID)
+	if err != nil {
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not linked to this team", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to get team source row filter", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
+		return SendError(c, fiber.StatusInternalServerError, "Failed to get row-level filter")
+	}
+
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"row_filter": rowFilter})
+}
+
+// handleUpdateTeamSourceRowFilter sets or clears the row-level filter of a team-source link.
+// The filter is injected into the WHERE clause of every query the team runs against the source.
+// URL: PUT /api/v1/admin/teams/:teamID/sources/:sourceID/row-filter
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleUpdateTeamSourceRowFilter(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid team ID: "+err.Error())
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid source ID: "+err.Error())
+	}
+
+	var req struct {
+		RowFilter string `json:"row_filter"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := core.UpdateTeamSourceRowFilter(c.Context(), s.sqlite, s.clickhouse, s.mapKeys, s.log, teamID, sourceID, req.RowFilter); err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not linked to this team", models.NotFoundErrorType)
+		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
+		s.log.Error("failed to update team source row filter", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
+		return SendError(c, fiber.StatusInternalServerError, "Failed to update row-level filter")
+	}
+
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"row_filter": strings.TrimSpace(req.RowFilter)})
+}
+
+// handleListColumnPolicies lists the column policies of a team-source link.
+// URL: GET /api/v1/admin/teams/:teamID/sources/:sourceID/column-policies
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleListColumnPolicies(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid team ID: "+err.Error())
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid source ID: "+err.Error())
+	}
+
+	policies, err := core.ListColumnPolicies(c.Context(), s.sqlite, teamID, sourceID)
+	if err != nil {
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not linked to this team", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to list column policies", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
+		return SendError(c, fiber.StatusInternalServerError, "Failed to list column policies")
+	}
+
+	return SendSuccess(c, fiber.StatusOK, policies)
+}
+
+// handleSetColumnPolicy creates or replaces the policy for a column of a team-source link.
+// Hidden columns can't be queried; hashed and redacted columns return masked values.
+// URL: POST /api/v1/admin/teams/:teamID/sources/:sourceID/column-policies
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleSetColumnPolicy(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid team ID: "+err.Error())
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid source ID: "+err.Error())
+	}
+
+	var req struct {
+		Column      string                    `json:"column"`
+		Action      models.ColumnPolicyAction `json:"action"`
+		Pattern     string                    `json:"pattern"`
+		Replacement string                    `json:"replacement"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	policy := &models.ColumnPolicy{
+		TeamID:      teamID,
+		SourceID:    sourceID,
+		Column:      req.Column,
+		Action:      req.Action,
+		Pattern:     req.Pattern,
+		Replacement: req.Replacement,
+	}
+	if err := core.SetColumnPolicy(c.Context(), s.sqlite, s.clickhouse, s.mapKeys, s.log, policy); err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		if errors.Is(err, core.ErrSourceNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not linked to this team", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to set column policy", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
+		return SendError(c, fiber.StatusInternalServerError, "Failed to set column policy")
+	}
+
+	return SendSuccess(c, fiber.StatusOK, policy)
+}
+
+// handleDeleteColumnPolicy removes a column policy from a team-source link.
+// URL: DELETE /api/v1/admin/teams/:teamID/sources/:sourceID/column-policies/:policyID
+// Requires: Global admin (requireAdmin middleware)
+func (s *Server) handleDeleteColumnPolicy(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid team ID: "+err.Error())
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendError(c, fiber.StatusBadRequest, "Invalid source ID: "+err.Error())
+	}
+	policyID, err := strconv.Atoi(c.Params("policyID"))
+	if err != nil || policyID <= 0 {
+		return SendError(c, fiber.StatusBadRequest, "Invalid column policy ID")
+	}
+
+	if err := core.DeleteColumnPolicy(c.Context(), s.sqlite, s.mapKeys, s.log, teamID, sourceID, policyID); err != nil {
+		if errors.Is(err, core.ErrColumnPolicyNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Column policy not found", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to delete column policy", slog.Any("error", err), "team_id", teamID, "source_id", sourceID, "policy_id", policyID)
+		return SendError(c, fiber.StatusInternalServerError, "Failed to delete column policy")
+	}
+
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Column policy deleted successfully"})
+}
@@ -1,10 +1,11 @@
 package server
 
 import (
-	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
 	"strconv"
+	"time"
 
 	core "github.com/mr-karan/logchef/internal/core"
 	"github.com/mr-karan/logchef/pkg/models"
@@ -13,6 +14,7 @@ import (
 )
 
 // handleListTeamSourceCollections retrieves saved queries (collections) for a specific team and source.
+// Query params: sort (name|updated|last_used, default name).
 // Assumes requireAuth and requireTeamMember middleware have run.
 func (s *Server) handleListTeamSourceCollections(c *fiber.Ctx) error {
 	teamIDStr := c.Params("teamID")
@@ -27,11 +29,13 @@ func (s *Server) handleListTeamSourceCollections(c *fiber.Ctx) error {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source_id parameter", models.ValidationErrorType)
 	}
 
-	// LINTER_ISSUE: Linter incorrectly reports core.ListQueriesByTeamAndSource as undefined.
-	// Using direct sqlite call as workaround.
-	queries, err := s.sqlite.ListQueriesByTeamAndSource(c.Context(), teamID, sourceID)
+	// Pinned favorites of the user come first, then the sort order (name, updated or last_used).
+	queries, err := core.ListQueriesForTeamAndSource(c.Context(), s.sqlite, s.log, teamID, sourceID, getUserIDFromContext(c), models.SavedQuerySort(c.Query("sort")))
 	if err != nil {
-		s.log.Error("failed to list collections via db function", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		s.log.Error("failed to list collections", slog.Any("error", err), "team_id", teamID, "source_id", sourceID)
 		return SendError(c, fiber.StatusInternalServerError, "Failed to list collections")
 	}
 	return SendSuccess(c, fiber.StatusOK, queries)
@@ -54,10 +58,12 @@ func (s *Server) handleCreateTeamSourceCollection(c *fiber.Ctx) error {
 
 	// Parse request body.
 	var req struct {
-		Name         string `json:"name"`
-		Description  string `json:"description"`
-		QueryType    string `json:"query_type"`
-		QueryContent string `json:"query_content"`
+		Name         string    `json:"name"`
+		Description  string    `json:"description"`
+		QueryType    string    `json:"query_type"`
+		QueryContent string    `json:"query_content"`
+		FolderID     *int      `json:"folder_id"`
+		Tags         *[]string `json:"tags"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
@@ -78,7 +84,8 @@ func (s *Server) handleCreateTeamSourceCollection(c *fiber.Ctx) error {
 		return SendErrorWithType(c, fiber.StatusForbidden, "Specified team does not have access to the specified source", models.AuthorizationErrorType)
 	}
 
-	// Create query using core function.
+	// Create query using core function, recording the current user as its author.
+	userID := getUserIDFromContext(c)
 	createdQuery, err := core.CreateTeamSourceQuery(
 		c.Context(),
 		s.sqlite,
@@ -89,7 +96,7 @@ func (s *Server) handleCreateTeamSourceCollection(c *fiber.Ctx) error {
 		req.Description,
 		req.QueryContent,
 		req.QueryType,
-		// Pass creator ID if core function supports it in the future.
+		&userID,
 	)
 
 	if err != nil {
@@ -100,6 +107,20 @@ func (s *Server) handleCreateTeamSourceCollection(c *fiber.Ctx) error {
 		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to create collection", models.GeneralErrorType)
 	}
 
+	// Place the new query in its folder and tag it.
+	if req.FolderID != nil || req.Tags != nil {
+		if err := core.UpdateSavedQueryOrganization(c.Context(), s.sqlite, s.log, teamID, sourceID, createdQuery.ID, req.FolderID, req.Tags); err != nil {
+			if validationErr, ok := err.(*core.ValidationError); ok {
+				return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+			}
+			s.log.Error("failed to organize created collection", slog.Any("error", err), "collection_id", createdQuery.ID)
+			return SendErrorWithType(c, fiber.StatusInternalServerError, "Collection created but failed to set its folder or tags", models.GeneralErrorType)
+		}
+		if createdQuery, err = core.GetTeamSourceQuery(c.Context(), s.sqlite, s.log, teamID, sourceID, createdQuery.ID); err != nil {
+			return SendErrorWithType(c, fiber.StatusInternalServerError, "Collection created but failed to retrieve latest state", models.GeneralErrorType)
+		}
+	}
+
 	return SendSuccess(c, fiber.StatusCreated, createdQuery)
 }
 
@@ -126,12 +147,9 @@ func (s *Server) handleGetTeamSourceCollection(c *fiber.Ctx) error {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid Collection ID format", models.ValidationErrorType)
 	}
 
-	// Call the sqlite method directly for now due to linter issues with core.handleNotFoundError
-	query, err := s.sqlite.GetTeamSourceQuery(c.Context(), teamID, sourceID, collectionID)
+	query, err := core.GetTeamSourceQuery(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID)
 	if err != nil {
-		// LINTER_ISSUE: Cannot reliably check core.ErrQueryNotFound due to potential resolution issues.
-		// Checking for sql.ErrNoRows from the underlying db call instead.
-		if errors.Is(err, sql.ErrNoRows) { // Check for underlying DB error
+		if errors.Is(err, core.ErrQueryNotFound) {
 			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
 		}
 		s.log.Error("failed to get collection via db function", slog.Any("error", err), "collection_id", collectionID)
@@ -166,16 +184,18 @@ func (s *Server) handleUpdateTeamSourceCollection(c *fiber.Ctx) error {
 
 	// Parse request body.
 	var req struct {
-		Name         *string `json:"name"`
-		Description  *string `json:"description"`
-		QueryType    *string `json:"query_type"`
-		QueryContent *string `json:"query_content"`
+		Name         *string   `json:"name"`
+		Description  *string   `json:"description"`
+		QueryType    *string   `json:"query_type"`
+		QueryContent *string   `json:"query_content"`
+		FolderID     *int      `json:"folder_id"` // 0 removes the collection from its folder
+		Tags         *[]string `json:"tags"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
 	}
 
-	// Prepare update data - pass empty strings for omitted fields.
+	// Prepare update data - empty strings keep the current value.
 	name := ""
 	if req.Name != nil {
 		name = *req.Name
@@ -193,37 +213,57 @@ func (s *Server) handleUpdateTeamSourceCollection(c *fiber.Ctx) error {
 		queryContent = *req.QueryContent
 	}
 
-	// Validate Content and Type before sending to DB.
-	if queryType != "" && models.SavedQueryType(queryType) != models.SavedQueryTypeLogchefQL && models.SavedQueryType(queryType) != models.SavedQueryTypeSQL {
-		return SendErrorWithType(c, fiber.StatusBadRequest, core.ErrInvalidQueryType.Error(), models.ValidationErrorType)
-	}
-	if queryContent != "" {
-		if err := core.ValidateSavedQueryContent(queryContent); err != nil {
-			return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	// Middleware ensures user has appropriate team admin rights.
+	// Each content update is recorded as a new revision authored by the current user.
+	if req.Name != nil || req.Description != nil || req.QueryType != nil || req.QueryContent != nil {
+		userID := getUserIDFromContext(c)
+		_, err = core.UpdateTeamSourceQuery(
+			c.Context(),
+			s.sqlite,
+			s.log,
+			teamID,
+			sourceID,
+			collectionID,
+			name, description, queryContent, queryType,
+			&userID,
+		)
+		if err != nil {
+			if errors.Is(err, core.ErrQueryNotFound) {
+				return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+			}
+			if errors.Is(err, core.ErrInvalidQueryType) || errors.Is(err, core.ErrInvalidQueryContent) {
+				return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+			}
+			if errors.Is(err, core.ErrProvisioned) {
+				return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+			}
+			s.log.Error("failed to update collection", slog.Any("error", err), "collection_id", collectionID)
+			return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to update collection", models.GeneralErrorType)
 		}
 	}
 
-	// Call sqlite update function.
-	// Middleware ensures user has appropriate team admin rights.
-	err = s.sqlite.UpdateTeamSourceQuery(
-		c.Context(),
-		teamID,
-		sourceID,
-		collectionID,
-		name, description, queryType, queryContent,
-	)
-	if err != nil {
-		// Check if the underlying error was potentially ErrNoRows.
-		if errors.Is(err, sql.ErrNoRows) {
-			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+	// Folder and tag changes don't create a revision.
+	if req.FolderID != nil || req.Tags != nil {
+		if err := core.UpdateSavedQueryOrganization(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID, req.FolderID, req.Tags); err != nil {
+			if errors.Is(err, core.ErrQueryNotFound) {
+				return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+			}
+			if validationErr, ok := err.(*core.ValidationError); ok {
+				return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+			}
+			if errors.Is(err, core.ErrProvisioned) {
+				return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+			}
+			s.log.Error("failed to update collection folder or tags", slog.Any("error", err), "collection_id", collectionID)
+			return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to update collection", models.GeneralErrorType)
 		}
-		s.log.Error("failed to update collection via db function", slog.Any("error", err), "collection_id", collectionID)
-		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to update collection", models.GeneralErrorType)
 	}
 
-	// Fetch and return updated query.
-	updatedQuery, err := s.sqlite.GetTeamSourceQuery(c.Context(), teamID, sourceID, collectionID)
+	updatedQuery, err := core.GetTeamSourceQuery(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID)
 	if err != nil {
+		if errors.Is(err, core.ErrQueryNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+		}
 		s.log.Error("failed to fetch updated collection after update", slog.Any("error", err), "collection_id", collectionID)
 		return SendErrorWithType(c, fiber.StatusInternalServerError, "Collection updated but failed to retrieve latest state", models.GeneralErrorType)
 	}
@@ -254,17 +294,300 @@ func (s *Server) handleDeleteTeamSourceCollection(c *fiber.Ctx) error {
 		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid Collection ID format", models.ValidationErrorType)
 	}
 
-	// Call sqlite delete function.
 	// Middleware ensures user has appropriate team admin rights.
-	err = s.sqlite.DeleteTeamSourceQuery(c.Context(), teamID, sourceID, collectionID)
+	err = core.DeleteTeamSourceQuery(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID)
 	if err != nil {
-		// DELETE often doesn't return ErrNoRows.
-		if errors.Is(err, sql.ErrNoRows) { // Check just in case.
+		if errors.Is(err, core.ErrQueryNotFound) {
 			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
 		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+		}
 		s.log.Error("failed to delete collection via db function", slog.Any("error", err), "collection_id", collectionID)
 		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to delete collection", models.GeneralErrorType)
 	}
 
 	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Collection deleted successfully"})
 }
+
+// handleRunTeamSourceCollection runs a saved SQL query (collection) with the given variable values.
+// Assumes requireAuth and requireTeamMember middleware have run.
+func (s *Server) handleRunTeamSourceCollection(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team_id parameter", models.ValidationErrorType)
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source_id parameter", models.ValidationErrorType)
+	}
+	collectionID, err := strconv.Atoi(c.Params("collectionID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid Collection ID format", models.ValidationErrorType)
+	}
+
+	var req models.APIRunSavedQueryRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+		}
+	}
+	if err := models.ValidateQueryTimeout(req.QueryTimeout); err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Track the query for the query limits, queueing while it's over them.
+	queryCtx, release, err := acquireQuery(c.Context(), user.ID, teamID, sourceID, queryTypeLogs, "")
+	if err != nil {
+		return sendQueryLimitError(c, err)
+	}
+	defer release()
+
+	auditEntry := s.newQueryAuditEntry(c, models.QueryAuditTypeLogs, teamID, sourceID, "")
+	start := time.Now()
+	result, err := core.RunSavedQuery(core.WithAuditEntry(queryCtx, auditEntry), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, sourceID, collectionID, req)
+	rows := 0
+	if result != nil {
+		rows = len(result.Logs)
+	}
+	auditEntry.RawSQL = auditEntry.FinalSQL
+	s.recordQueryAudit(auditEntry, start, rows, err)
+	if err != nil {
+		var validationErr *core.ValidationError
+		switch {
+		case errors.Is(err, core.ErrQueryNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+		case errors.Is(err, core.ErrSourceNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		case errors.As(err, &validationErr), errors.Is(err, core.ErrInvalidQueryContent):
+			return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+		}
+		s.log.Error("failed to run collection", slog.Any("error", err), "collection_id", collectionID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to run collection: %v", err), models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{
+		"data":    result.Logs,
+		"stats":   result.Stats,
+		"columns": result.Columns,
+	})
+}
+
+// handleGetCollectionVariableOptions returns the allowed values of a saved query's enum variable.
+// Assumes requireAuth and requireTeamMember middleware have run.
+func (s *Server) handleGetCollectionVariableOptions(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team_id parameter", models.ValidationErrorType)
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid source_id parameter", models.ValidationErrorType)
+	}
+	collectionID, err := strconv.Atoi(c.Params("collectionID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid Collection ID format", models.ValidationErrorType)
+	}
+
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Options can come from a query on the source, so they count against the query limits.
+	queryCtx, release, err := acquireQuery(c.Context(), user.ID, teamID, sourceID, queryTypeOptions, "")
+	if err != nil {
+		return sendQueryLimitError(c, err)
+	}
+	defer release()
+
+	options, err := core.GetSavedQueryVariableOptions(queryCtx, s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, sourceID, collectionID, c.Params("name"))
+	if err != nil {
+		var validationErr *core.ValidationError
+		switch {
+		case errors.Is(err, core.ErrQueryNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+		case errors.Is(err, core.ErrSourceNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Source not found", models.NotFoundErrorType)
+		case errors.As(err, &validationErr), errors.Is(err, core.ErrInvalidQueryContent):
+			return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+		}
+		s.log.Error("failed to get variable options", slog.Any("error", err), "collection_id", collectionID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to get variable options: %v", err), models.DatabaseErrorType)
+	}
+
+	return SendSuccess(c, fiber.StatusOK, options)
+}
+
+// parseCollectionParams parses the team, source and collection IDs of a collection route.
+func parseCollectionParams(c *fiber.Ctx) (models.TeamID, models.SourceID, int, error) {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return 0, 0, 0, errors.New("Invalid team_id parameter")
+	}
+	sourceID, err := core.ParseSourceID(c.Params("sourceID"))
+	if err != nil {
+		return 0, 0, 0, errors.New("Invalid source_id parameter")
+	}
+	collectionID, err := strconv.Atoi(c.Params("collectionID"))
+	if err != nil {
+		return 0, 0, 0, errors.New("Invalid Collection ID format")
+	}
+	return teamID, sourceID, collectionID, nil
+}
+
+// sendRevisionError maps core saved query revision errors to responses.
+func (s *Server) sendRevisionError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, core.ErrQueryNotFound):
+		return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+	case errors.Is(err, core.ErrRevisionNotFound):
+		return SendErrorWithType(c, fiber.StatusNotFound, "Revision not found", models.NotFoundErrorType)
+	case errors.Is(err, core.ErrProvisioned):
+		return SendErrorWithType(c, fiber.StatusConflict, err.Error(), models.ConflictErrorType)
+	}
+	s.log.Error(msg, slog.Any("error", err), "collection_id", c.Params("collectionID"), "revision", c.Params("revision"))
+	return SendErrorWithType(c, fiber.StatusInternalServerError, msg, models.GeneralErrorType)
+}
+
+// handleListCollectionRevisions lists the revision history of a saved query (collection), newest first.
+// URL: GET /api/v1/teams/:teamID/sources/:sourceID/collections/:collectionID/revisions
+// Assumes requireAuth and requireTeamMember middleware have run.
+func (s *Server) handleListCollectionRevisions(c *fiber.Ctx) error {
+	teamID, sourceID, collectionID, err := parseCollectionParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	revisions, err := core.ListSavedQueryRevisions(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID)
+	if err != nil {
+		return s.sendRevisionError(c, err, "Failed to list collection revisions")
+	}
+	return SendSuccess(c, fiber.StatusOK, revisions)
+}
+
+// handleGetCollectionRevision returns a single revision of a saved query (collection).
+// URL: GET /api/v1/teams/:teamID/sources/:sourceID/collections/:collectionID/revisions/:revision
+// Assumes requireAuth and requireTeamMember middleware have run.
+func (s *Server) handleGetCollectionRevision(c *fiber.Ctx) error {
+	teamID, sourceID, collectionID, err := parseCollectionParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision < 1 {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid revision format", models.ValidationErrorType)
+	}
+
+	rev, err := core.GetSavedQueryRevision(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID, revision)
+	if err != nil {
+		return s.sendRevisionError(c, err, "Failed to get collection revision")
+	}
+	return SendSuccess(c, fiber.StatusOK, rev)
+}
+
+// handleDiffCollectionRevision diffs a revision of a saved query (collection) against another one.
+// The optional `against` query parameter selects the older revision and defaults to the previous one.
+// URL: GET /api/v1/teams/:teamID/sources/:sourceID/collections/:collectionID/revisions/:revision/diff
+// Assumes requireAuth and requireTeamMember middleware have run.
+func (s *Server) handleDiffCollectionRevision(c *fiber.Ctx) error {
+	teamID, sourceID, collectionID, err := parseCollectionParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision < 1 {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid revision format", models.ValidationErrorType)
+	}
+	against := 0
+	if v := c.Query("against"); v != "" {
+		against, err = strconv.Atoi(v)
+		if err != nil || against < 1 {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid against revision format", models.ValidationErrorType)
+		}
+	}
+
+	diff, err := core.DiffSavedQueryRevisions(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID, against, revision)
+	if err != nil {
+		return s.sendRevisionError(c, err, "Failed to diff collection revisions")
+	}
+	return SendSuccess(c, fiber.StatusOK, diff)
+}
+
+// handleRestoreCollectionRevision restores a saved query (collection) to an earlier revision.
+// The restore is recorded as a new revision authored by the current user.
+// URL: POST /api/v1/teams/:teamID/sources/:sourceID/collections/:collectionID/revisions/:revision/restore
+// Assumes requireAuth, requireTeamMember, and requireCollectionManagement middleware have run.
+func (s *Server) handleRestoreCollectionRevision(c *fiber.Ctx) error {
+	teamID, sourceID, collectionID, err := parseCollectionParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision < 1 {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid revision format", models.ValidationErrorType)
+	}
+
+	userID := getUserIDFromContext(c)
+	query, err := core.RestoreSavedQueryRevision(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID, revision, &userID)
+	if err != nil {
+		return s.sendRevisionError(c, err, "Failed to restore collection revision")
+	}
+	return SendSuccess(c, fiber.StatusOK, query)
+}
+
+// handleSetCollectionFavorite adds a saved query (collection) to the current user's favorites,
+// or updates whether it's pinned. Body: {"pinned": bool}.
+// URL: PUT /api/v1/teams/:teamID/sources/:sourceID/collections/:collectionID/favorite
+// Assumes requireAuth and requireTeamMember middleware have run.
+func (s *Server) handleSetCollectionFavorite(c *fiber.Ctx) error {
+	teamID, sourceID, collectionID, err := parseCollectionParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	var req struct {
+		Pinned bool `json:"pinned"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid request body", models.ValidationErrorType)
+		}
+	}
+
+	if err := core.SetSavedQueryFavorite(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID, getUserIDFromContext(c), req.Pinned); err != nil {
+		if errors.Is(err, core.ErrQueryNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to set collection favorite", slog.Any("error", err), "collection_id", collectionID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to set favorite", models.GeneralErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"is_favorite": true, "is_pinned": req.Pinned})
+}
+
+// handleRemoveCollectionFavorite removes a saved query (collection) from the current user's favorites.
+// URL: DELETE /api/v1/teams/:teamID/sources/:sourceID/collections/:collectionID/favorite
+// Assumes requireAuth and requireTeamMember middleware have run.
+func (s *Server) handleRemoveCollectionFavorite(c *fiber.Ctx) error {
+	teamID, sourceID, collectionID, err := parseCollectionParams(c)
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, err.Error(), models.ValidationErrorType)
+	}
+
+	if err := core.RemoveSavedQueryFavorite(c.Context(), s.sqlite, s.log, teamID, sourceID, collectionID, getUserIDFromContext(c)); err != nil {
+		switch {
+		case errors.Is(err, core.ErrQueryNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Collection not found", models.NotFoundErrorType)
+		case errors.Is(err, core.ErrFavoriteNotFound):
+			return SendErrorWithType(c, fiber.StatusNotFound, "Collection is not a favorite", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to remove collection favorite", slog.Any("error", err), "collection_id", collectionID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to remove favorite", models.GeneralErrorType)
+	}
+	return SendSuccess(c, fiber.StatusOK, fiber.Map{"message": "Favorite removed successfully"})
+}
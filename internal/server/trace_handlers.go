@@ -0,0 +1,70 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mr-karan/logchef/internal/core"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// handleGetTrace returns every log of a trace across the team's sources with a trace ID
+// field, merged into a time-ordered timeline with the trace's span tree.
+// URL: GET /api/v1/teams/:teamID/traces/:traceID
+// Query params: time (time hint, Unix milliseconds), window (searched before and after the
+// time hint, e.g. "30m", defaults to tracing.window).
+// Requires: Team membership
+func (s *Server) handleGetTrace(c *fiber.Ctx) error {
+	teamID, err := core.ParseTeamID(c.Params("teamID"))
+	if err != nil {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid team ID format", models.ValidationErrorType)
+	}
+
+	timeMs, err := strconv.ParseInt(c.Query("time"), 10, 64)
+	if err != nil || timeMs <= 0 {
+		return SendErrorWithType(c, fiber.StatusBadRequest, "time parameter must be a Unix timestamp in milliseconds", models.ValidationErrorType)
+	}
+	window := s.config.Tracing.Window
+	if v := c.Query("window"); v != "" {
+		if window, err = time.ParseDuration(v); err != nil {
+			return SendErrorWithType(c, fiber.StatusBadRequest, "Invalid window duration", models.ValidationErrorType)
+		}
+	}
+
+	user, ok := c.Locals("user").(*models.User)
+	if !ok || user == nil {
+		return SendErrorWithType(c, fiber.StatusUnauthorized, "User context not found", models.AuthenticationErrorType)
+	}
+
+	// Each source's query counts against the query limits.
+	params := core.TraceParams{
+		TraceID:      c.Params("traceID"),
+		Time:         time.UnixMilli(timeMs),
+		Window:       window,
+		URLTemplate:  s.config.Tracing.URLTemplate,
+		AcquireQuery: queryAcquirer(user.ID),
+	}
+	// A token restricted to team/source pairs only searches the sources it covers.
+	params.AllowSource = tokenSourceFilter(c, teamID)
+
+	timeline, audits, err := core.GetTraceTimeline(c.Context(), s.sqlite, s.clickhouse, s.queryCache, s.log, teamID, params)
+	if err != nil {
+		if validationErr, ok := err.(*core.ValidationError); ok {
+			return SendErrorWithType(c, fiber.StatusBadRequest, validationErr.Message, models.ValidationErrorType)
+		}
+		if errors.Is(err, core.ErrTeamNotFound) {
+			return SendErrorWithType(c, fiber.StatusNotFound, "Team not found", models.NotFoundErrorType)
+		}
+		s.log.Error("failed to get trace", slog.Any("error", err), "team_id", teamID)
+		return SendErrorWithType(c, fiber.StatusInternalServerError, "Failed to get trace", models.DatabaseErrorType)
+	}
+
+	for _, audit := range audits {
+		setAuditActor(c, audit)
+		s.auditor.Record(audit)
+	}
+	return SendSuccess(c, fiber.StatusOK, timeline)
+}
@@ -3,7 +3,6 @@ package server
 import (
 	"errors"
 	"strconv"
-	"time"
 
 	"github.com/mr-karan/logchef/internal/core"
 	"github.com/mr-karan/logchef/pkg/models"
@@ -141,7 +140,7 @@ func (s *Server) handleUpdateUser(c *fiber.Ctx) error {
 		if errors.Is(err, core.ErrUserNotFound) {
 			return SendError(c, fiber.StatusNotFound, "User not found")
 		}
-		if errors.Is(err, core.ErrUserAlreadyExists) {
+		if errors.Is(err, core.ErrUserAlreadyExists) || errors.Is(err, core.ErrProvisioned) {
 			return SendError(c, fiber.StatusConflict, err.Error())
 		}
 		if valErr, ok := err.(*core.ValidationError); ok {
@@ -184,6 +183,9 @@ func (s *Server) handleDeleteUser(c *fiber.Ctx) error {
 		if errors.Is(err, core.ErrCannotDeleteLastAdmin) {
 			return SendError(c, fiber.StatusBadRequest, core.ErrCannotDeleteLastAdmin.Error())
 		}
+		if errors.Is(err, core.ErrProvisioned) {
+			return SendError(c, fiber.StatusConflict, err.Error())
+		}
 		s.log.Error("failed to delete user", "error", err, "user_id", userID)
 		return SendError(c, fiber.StatusInternalServerError, "Error deleting user")
 	}
@@ -253,21 +255,23 @@ func (s *Server) handleCreateAPIToken(c *fiber.Ctx) error {
 		return SendError(c, fiber.StatusInternalServerError, "Error retrieving user context")
 	}
 
-	var req struct {
-		Name      string     `json:"name"`
-		ExpiresAt *time.Time `json:"expires_at,omitempty"`
-	}
-
+	var req models.CreateAPITokenRequest
 	if err := c.BodyParser(&req); err != nil {
 		return SendError(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	response, err := core.CreateAPIToken(c.Context(), s.sqlite, s.log, &s.config.Auth, user.ID, req.Name, req.ExpiresAt)
+	// A token creating another token can only hand out a subset of its own access
+	parent, _ := c.Locals("api_token").(*models.APIToken)
+
+	response, err := core.CreateAPIToken(c.Context(), s.sqlite, s.log, &s.config.Auth, user.ID, req.Name, req.ExpiresAt, req.APITokenRestrictions, parent)
 	if err != nil {
 		// Handle specific error types from core
 		if valErr, ok := err.(*core.ValidationError); ok {
 			return SendError(c, fiber.StatusBadRequest, valErr.Error())
 		}
+		if errors.Is(err, core.ErrTokenExceedsParent) {
+			return SendErrorWithType(c, fiber.StatusForbidden, err.Error(), models.AuthorizationErrorType)
+		}
 
 		s.log.Error("failed to create API token", "error", err, "user_id", user.ID)
 		return SendError(c, fiber.StatusInternalServerError, "Error creating API token")
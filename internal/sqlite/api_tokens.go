@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
 	"github.com/mr-karan/logchef/pkg/models"
@@ -100,6 +101,21 @@ func (db *DB) DeleteAPIToken(ctx context.Context, params sqlc.DeleteAPITokenPara
 	return nil
 }
 
+// DisableUserAPITokens disables all active API tokens of a user.
+func (db *DB) DisableUserAPITokens(ctx context.Context, userID int64, disabledAt time.Time) error {
+	db.log.Debug("disabling API tokens of user", "user_id", userID)
+
+	err := db.queries.DisableUserAPITokens(ctx, sqlc.DisableUserAPITokensParams{
+		DisabledAt: sql.NullTime{Time: disabledAt, Valid: true},
+		UserID:     userID,
+	})
+	if err != nil {
+		db.log.Error("failed to disable API tokens in db", "error", err, "user_id", userID)
+		return fmt.Errorf("failed to disable API tokens: %w", err)
+	}
+	return nil
+}
+
 // DeleteExpiredAPITokens removes all expired API tokens.
 func (db *DB) DeleteExpiredAPITokens(ctx context.Context) error {
 	db.log.Debug("deleting expired API tokens")
@@ -0,0 +1,292 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Collection folder, tag and favorite methods
+
+// ListTeamCollectionFolders retrieves all collection folders of a team.
+func (db *DB) ListTeamCollectionFolders(ctx context.Context, teamID models.TeamID) ([]*models.CollectionFolder, error) {
+	db.log.Debug("listing team collection folders", "team_id", teamID)
+
+	rows, err := db.queries.ListTeamCollectionFolders(ctx, int64(teamID))
+	if err != nil {
+		db.log.Error("failed to list team collection folders from db", "error", err, "team_id", teamID)
+		return nil, fmt.Errorf("error listing team collection folders: %w", err)
+	}
+
+	folders := make([]*models.CollectionFolder, 0, len(rows))
+	for _, row := range rows {
+		folder := mapCollectionFolderRowToModel(sqlc.CollectionFolder{
+			ID:        row.ID,
+			TeamID:    row.TeamID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+		folder.QueryCount = int(row.QueryCount)
+		folders = append(folders, folder)
+	}
+	return folders, nil
+}
+
+// GetTeamCollectionFolder retrieves a collection folder by ID, scoped to a team.
+func (db *DB) GetTeamCollectionFolder(ctx context.Context, teamID models.TeamID, folderID int) (*models.CollectionFolder, error) {
+	db.log.Debug("getting team collection folder", "folder_id", folderID, "team_id", teamID)
+
+	row, err := db.queries.GetTeamCollectionFolder(ctx, sqlc.GetTeamCollectionFolderParams{
+		ID:     int64(folderID),
+		TeamID: int64(teamID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		db.log.Error("failed to get team collection folder from db", "error", err, "folder_id", folderID)
+		return nil, fmt.Errorf("error getting team collection folder: %w", err)
+	}
+	return mapCollectionFolderRowToModel(row), nil
+}
+
+// CreateTeamCollectionFolder inserts a new collection folder for a team.
+// Populates the folder ID on the input model upon success.
+func (db *DB) CreateTeamCollectionFolder(ctx context.Context, folder *models.CollectionFolder) error {
+	db.log.Debug("creating team collection folder", "team_id", folder.TeamID, "name", folder.Name)
+
+	id, err := db.queries.CreateTeamCollectionFolder(ctx, sqlc.CreateTeamCollectionFolderParams{
+		TeamID: int64(folder.TeamID),
+		Name:   folder.Name,
+	})
+	if err != nil {
+		if IsUniqueConstraintError(err) {
+			return handleUniqueConstraintError(err, "collection_folders", "name", folder.Name)
+		}
+		db.log.Error("failed to create team collection folder in db", "error", err, "team_id", folder.TeamID)
+		return fmt.Errorf("error creating team collection folder: %w", err)
+	}
+
+	folder.ID = int(id)
+	return nil
+}
+
+// UpdateTeamCollectionFolder renames a collection folder of a team.
+func (db *DB) UpdateTeamCollectionFolder(ctx context.Context, teamID models.TeamID, folderID int, name string) error {
+	db.log.Debug("updating team collection folder", "folder_id", folderID, "team_id", teamID)
+
+	rows, err := db.queries.UpdateTeamCollectionFolder(ctx, sqlc.UpdateTeamCollectionFolderParams{
+		Name:   name,
+		ID:     int64(folderID),
+		TeamID: int64(teamID),
+	})
+	if err != nil {
+		if IsUniqueConstraintError(err) {
+			return handleUniqueConstraintError(err, "collection_folders", "name", name)
+		}
+		db.log.Error("failed to update team collection folder in db", "error", err, "folder_id", folderID)
+		return fmt.Errorf("error updating team collection folder: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteTeamCollectionFolder removes a collection folder of a team. Its queries become unfiled.
+func (db *DB) DeleteTeamCollectionFolder(ctx context.Context, teamID models.TeamID, folderID int) error {
+	db.log.Debug("deleting team collection folder", "folder_id", folderID, "team_id", teamID)
+
+	rows, err := db.queries.DeleteTeamCollectionFolder(ctx, sqlc.DeleteTeamCollectionFolderParams{
+		ID:     int64(folderID),
+		TeamID: int64(teamID),
+	})
+	if err != nil {
+		db.log.Error("failed to delete team collection folder from db", "error", err, "folder_id", folderID)
+		return fmt.Errorf("error deleting team collection folder: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetTeamQueryFolder moves a saved query into a folder, or out of any folder when folderID is nil.
+func (db *DB) SetTeamQueryFolder(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, queryID int, folderID *int) error {
+	db.log.Debug("setting team query folder", "query_id", queryID, "folder_id", folderID)
+
+	params := sqlc.SetTeamQueryFolderParams{
+		ID:       int64(queryID),
+		TeamID:   int64(teamID),
+		SourceID: int64(sourceID),
+	}
+	if folderID != nil {
+		params.FolderID = sql.NullInt64{Int64: int64(*folderID), Valid: true}
+	}
+
+	rows, err := db.queries.SetTeamQueryFolder(ctx, params)
+	if err != nil {
+		db.log.Error("failed to set team query folder in db", "error", err, "query_id", queryID)
+		return fmt.Errorf("error setting team query folder: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetTeamQueryTags replaces the tags of a saved query.
+func (db *DB) SetTeamQueryTags(ctx context.Context, queryID int, tags []string) error {
+	db.log.Debug("setting team query tags", "query_id", queryID, "tags", tags)
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting team query tags transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := sqlc.New(tx)
+
+	if err := qtx.DeleteTeamQueryTags(ctx, int64(queryID)); err != nil {
+		return fmt.Errorf("error clearing team query tags: %w", err)
+	}
+	for _, tag := range tags {
+		if err := qtx.AddTeamQueryTag(ctx, sqlc.AddTeamQueryTagParams{QueryID: int64(queryID), Tag: tag}); err != nil {
+			db.log.Error("failed to add team query tag in db", "error", err, "query_id", queryID, "tag", tag)
+			return fmt.Errorf("error adding team query tag: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTeamTags retrieves the distinct tags used by the saved queries of a team.
+func (db *DB) ListTeamTags(ctx context.Context, teamID models.TeamID) ([]string, error) {
+	tags, err := db.queries.ListTeamTags(ctx, int64(teamID))
+	if err != nil {
+		db.log.Error("failed to list team tags from db", "error", err, "team_id", teamID)
+		return nil, fmt.Errorf("error listing team tags: %w", err)
+	}
+	return tags, nil
+}
+
+// SetUserQueryFavorite adds a saved query to the favorites of a user, or updates whether it's pinned.
+func (db *DB) SetUserQueryFavorite(ctx context.Context, userID models.UserID, queryID int, pinned bool) error {
+	err := db.queries.UpsertUserQueryFavorite(ctx, sqlc.UpsertUserQueryFavoriteParams{
+		UserID:  int64(userID),
+		QueryID: int64(queryID),
+		Pinned:  boolToInt(pinned),
+	})
+	if err != nil {
+		db.log.Error("failed to set user query favorite in db", "error", err, "user_id", userID, "query_id", queryID)
+		return fmt.Errorf("error setting user query favorite: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserQueryFavorite removes a saved query from the favorites of a user.
+func (db *DB) DeleteUserQueryFavorite(ctx context.Context, userID models.UserID, queryID int) error {
+	rows, err := db.queries.DeleteUserQueryFavorite(ctx, sqlc.DeleteUserQueryFavoriteParams{
+		UserID:  int64(userID),
+		QueryID: int64(queryID),
+	})
+	if err != nil {
+		db.log.Error("failed to delete user query favorite from db", "error", err, "user_id", userID, "query_id", queryID)
+		return fmt.Errorf("error deleting user query favorite: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkTeamQueryUsed records that a saved query was just run.
+func (db *DB) MarkTeamQueryUsed(ctx context.Context, queryID int) error {
+	if err := db.queries.MarkTeamQueryUsed(ctx, int64(queryID)); err != nil {
+		return fmt.Errorf("error marking team query used: %w", err)
+	}
+	return nil
+}
+
+// SearchTeamQueries retrieves the saved queries of a team matching the filter,
+// pinned favorites of the user first.
+func (db *DB) SearchTeamQueries(ctx context.Context, filter models.SavedQuerySearchFilter) ([]*models.SavedTeamQuery, error) {
+	db.log.Debug("searching team queries", "filter", filter)
+
+	params := sqlc.SearchTeamQueriesParams{
+		UserID: int64(filter.UserID),
+		TeamID: int64(filter.TeamID),
+		Sort:   string(filter.Sort),
+		Limit:  -1, // SQLite treats a negative limit as no limit.
+		Offset: int64(filter.Offset),
+	}
+	if filter.Limit > 0 {
+		params.Limit = int64(filter.Limit)
+	}
+	if filter.SourceID != nil {
+		params.SourceID = sql.NullInt64{Int64: int64(*filter.SourceID), Valid: true}
+	}
+	if filter.FolderID != nil {
+		params.FolderID = sql.NullInt64{Int64: int64(*filter.FolderID), Valid: true}
+	}
+	if filter.Tag != "" {
+		params.Tag = sql.NullString{String: filter.Tag, Valid: true}
+	}
+	if filter.FavoritesOnly {
+		params.FavoritesOnly = true
+	}
+	if filter.Search != "" {
+		params.Search = filter.Search
+	}
+
+	rows, err := db.queries.SearchTeamQueries(ctx, params)
+	if err != nil {
+		db.log.Error("failed to search team queries in db", "error", err, "team_id", filter.TeamID)
+		return nil, fmt.Errorf("error searching team queries: %w", err)
+	}
+
+	queries := make([]*models.SavedTeamQuery, 0, len(rows))
+	for _, row := range rows {
+		query := mapTeamQueryRowToModel(&sqlc.TeamQuery{
+			ID:           row.ID,
+			TeamID:       row.TeamID,
+			SourceID:     row.SourceID,
+			Name:         row.Name,
+			Description:  row.Description,
+			QueryType:    row.QueryType,
+			QueryContent: row.QueryContent,
+			CreatedAt:    row.CreatedAt,
+			UpdatedAt:    row.UpdatedAt,
+			CreatedBy:    row.CreatedBy,
+			UpdatedBy:    row.UpdatedBy,
+			FolderID:     row.FolderID,
+			LastUsedAt:   row.LastUsedAt,
+			Provisioned:  row.Provisioned,
+		})
+		if row.Tags.Valid && row.Tags.String != "" {
+			// Tags can't contain commas, so the concatenated list splits cleanly.
+			query.Tags = strings.Split(row.Tags.String, ",")
+		}
+		query.IsFavorite = row.IsFavorite != 0
+		query.IsPinned = row.IsPinned != 0
+		queries = append(queries, query)
+	}
+	return queries, nil
+}
+
+// mapCollectionFolderRowToModel converts a sqlc CollectionFolder row to the domain model.
+func mapCollectionFolderRowToModel(row sqlc.CollectionFolder) *models.CollectionFolder {
+	return &models.CollectionFolder{
+		ID:     int(row.ID),
+		TeamID: models.TeamID(row.TeamID),
+		Name:   row.Name,
+		Timestamps: models.Timestamps{
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		},
+	}
+}
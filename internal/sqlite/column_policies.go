@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Column policy methods
+
+// ListColumnPolicies retrieves all column policies for a team's access to a source.
+func (db *DB) ListColumnPolicies(ctx context.Context, teamID models.TeamID, sourceID models.SourceID) ([]*models.ColumnPolicy, error) {
+	db.log.Debug("listing column policies", "team_id", teamID, "source_id", sourceID)
+
+	rows, err := db.queries.ListColumnPolicies(ctx, sqlc.ListColumnPoliciesParams{
+		TeamID:   int64(teamID),
+		SourceID: int64(sourceID),
+	})
+	if err != nil {
+		db.log.Error("failed to list column policies from db", "error", err, "team_id", teamID, "source_id", sourceID)
+		return nil, fmt.Errorf("error listing column policies: %w", err)
+	}
+
+	policies := make([]*models.ColumnPolicy, 0, len(rows))
+	for i := range rows {
+		policies = append(policies, mapColumnPolicyRowToModel(&rows[i]))
+	}
+	return policies, nil
+}
+
+// GetColumnPolicy retrieves a single column policy by ID, scoped to a team and source.
+func (db *DB) GetColumnPolicy(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, policyID int) (*models.ColumnPolicy, error) {
+	db.log.Debug("getting column policy", "policy_id", policyID, "team_id", teamID, "source_id", sourceID)
+
+	row, err := db.queries.GetColumnPolicy(ctx, sqlc.GetColumnPolicyParams{
+		ID:       int64(policyID),
+		TeamID:   int64(teamID),
+		SourceID: int64(sourceID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		db.log.Error("failed to get column policy from db", "error", err, "policy_id", policyID)
+		return nil, fmt.Errorf("error getting column policy: %w", err)
+	}
+
+	return mapColumnPolicyRowToModel(&row), nil
+}
+
+// UpsertColumnPolicy creates or replaces the policy for a column.
+// Populates the policy ID on the input model upon success.
+func (db *DB) UpsertColumnPolicy(ctx context.Context, policy *models.ColumnPolicy) error {
+	db.log.Debug("upserting column policy", "team_id", policy.TeamID, "source_id", policy.SourceID, "column", policy.Column, "action", policy.Action)
+
+	id, err := db.queries.UpsertColumnPolicy(ctx, sqlc.UpsertColumnPolicyParams{
+		TeamID:      int64(policy.TeamID),
+		SourceID:    int64(policy.SourceID),
+		ColumnName:  policy.Column,
+		Action:      string(policy.Action),
+		Pattern:     policy.Pattern,
+		Replacement: policy.Replacement,
+	})
+	if err != nil {
+		db.log.Error("failed to upsert column policy in db", "error", err, "team_id", policy.TeamID, "source_id", policy.SourceID)
+		return fmt.Errorf("error upserting column policy: %w", err)
+	}
+
+	policy.ID = int(id)
+	return nil
+}
+
+// DeleteColumnPolicy removes a column policy, scoped to a team and source.
+func (db *DB) DeleteColumnPolicy(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, policyID int) error {
+	db.log.Debug("deleting column policy", "policy_id", policyID, "team_id", teamID, "source_id", sourceID)
+
+	rows, err := db.queries.DeleteColumnPolicy(ctx, sqlc.DeleteColumnPolicyParams{
+		ID:       int64(policyID),
+		TeamID:   int64(teamID),
+		SourceID: int64(sourceID),
+	})
+	if err != nil {
+		db.log.Error("failed to delete column policy from db", "error", err, "policy_id", policyID)
+		return fmt.Errorf("error deleting column policy: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetTeamSourceAccess links a team to a source, unless it's linked already, and replaces the
+// link's row filter and column policies. Policies are left unchanged when policies is nil.
+// It runs in a single transaction, so the team never gets access to the source without its
+// filter and policies.
+func (db *DB) SetTeamSourceAccess(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, filter string, policies []*models.ColumnPolicy) error {
+	db.log.Debug("setting team source access", "team_id", teamID, "source_id", sourceID, "policies", len(policies))
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting team source access transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := sqlc.New(tx)
+
+	if err := qtx.AddTeamSource(ctx, sqlc.AddTeamSourceParams{TeamID: int64(teamID), SourceID: int64(sourceID)}); err != nil && !IsUniqueConstraintError(err) {
+		db.log.Error("failed to add team source record", "error", err, "team_id", teamID, "source_id", sourceID)
+		return fmt.Errorf("error adding team source: %w", err)
+	}
+	if _, err := qtx.UpdateTeamSourceRowFilter(ctx, sqlc.UpdateTeamSourceRowFilterParams{
+		RowFilter: filter,
+		TeamID:    int64(teamID),
+		SourceID:  int64(sourceID),
+	}); err != nil {
+		db.log.Error("failed to update team source row filter in db", "error", err, "team_id", teamID, "source_id", sourceID)
+		return fmt.Errorf("error updating team source row filter: %w", err)
+	}
+	if policies != nil {
+		if err := qtx.DeleteTeamSourceColumnPolicies(ctx, sqlc.DeleteTeamSourceColumnPoliciesParams{
+			TeamID:   int64(teamID),
+			SourceID: int64(sourceID),
+		}); err != nil {
+			db.log.Error("failed to delete column policies from db", "error", err, "team_id", teamID, "source_id", sourceID)
+			return fmt.Errorf("error deleting column policies: %w", err)
+		}
+	}
+	for _, policy := range policies {
+		if _, err := qtx.UpsertColumnPolicy(ctx, sqlc.UpsertColumnPolicyParams{
+			TeamID:      int64(teamID),
+			SourceID:    int64(sourceID),
+			ColumnName:  policy.Column,
+			Action:      string(policy.Action),
+			Pattern:     policy.Pattern,
+			Replacement: policy.Replacement,
+		}); err != nil {
+			db.log.Error("failed to upsert column policy in db", "error", err, "team_id", teamID, "source_id", sourceID)
+			return fmt.Errorf("error upserting column policy: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing team source access: %w", err)
+	}
+	return nil
+}
+
+// mapColumnPolicyRowToModel converts a sqlc ColumnPolicy row to the domain model.
+func mapColumnPolicyRowToModel(row *sqlc.ColumnPolicy) *models.ColumnPolicy {
+	return &models.ColumnPolicy{
+		ID:          int(row.ID),
+		TeamID:      models.TeamID(row.TeamID),
+		SourceID:    models.SourceID(row.SourceID),
+		Column:      row.ColumnName,
+		Action:      models.ColumnPolicyAction(row.Action),
+		Pattern:     row.Pattern,
+		Replacement: row.Replacement,
+		Timestamps: models.Timestamps{
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		},
+	}
+}
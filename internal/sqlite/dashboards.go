@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Dashboard methods
+
+// ListTeamDashboards retrieves all dashboards of a team.
+func (db *DB) ListTeamDashboards(ctx context.Context, teamID models.TeamID) ([]*models.Dashboard, error) {
+	db.log.Debug("listing team dashboards", "team_id", teamID)
+
+	rows, err := db.queries.ListTeamDashboards(ctx, int64(teamID))
+	if err != nil {
+		db.log.Error("failed to list team dashboards from db", "error", err, "team_id", teamID)
+		return nil, fmt.Errorf("error listing team dashboards: %w", err)
+	}
+
+	dashboards := make([]*models.Dashboard, 0, len(rows))
+	for i := range rows {
+		dashboard, err := mapDashboardRowToModel(&rows[i])
+		if err != nil {
+			return nil, err
+		}
+		dashboards = append(dashboards, dashboard)
+	}
+	return dashboards, nil
+}
+
+// GetTeamDashboard retrieves a dashboard by ID, scoped to a team.
+func (db *DB) GetTeamDashboard(ctx context.Context, teamID models.TeamID, dashboardID int) (*models.Dashboard, error) {
+	db.log.Debug("getting team dashboard", "dashboard_id", dashboardID, "team_id", teamID)
+
+	row, err := db.queries.GetTeamDashboard(ctx, sqlc.GetTeamDashboardParams{
+		ID:     int64(dashboardID),
+		TeamID: int64(teamID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		db.log.Error("failed to get team dashboard from db", "error", err, "dashboard_id", dashboardID)
+		return nil, fmt.Errorf("error getting team dashboard: %w", err)
+	}
+
+	return mapDashboardRowToModel(&row)
+}
+
+// CreateTeamDashboard inserts a new dashboard for a team.
+// Populates the dashboard ID on the input model upon success.
+func (db *DB) CreateTeamDashboard(ctx context.Context, dashboard *models.Dashboard) error {
+	db.log.Debug("creating team dashboard", "team_id", dashboard.TeamID, "name", dashboard.Name)
+
+	definition, err := marshalDashboardDefinition(dashboard)
+	if err != nil {
+		return err
+	}
+
+	id, err := db.queries.CreateTeamDashboard(ctx, sqlc.CreateTeamDashboardParams{
+		TeamID:      int64(dashboard.TeamID),
+		Name:        dashboard.Name,
+		Description: dashboard.Description,
+		Definition:  definition,
+	})
+	if err != nil {
+		db.log.Error("failed to create team dashboard in db", "error", err, "team_id", dashboard.TeamID)
+		return fmt.Errorf("error creating team dashboard: %w", err)
+	}
+
+	dashboard.ID = int(id)
+	return nil
+}
+
+// UpdateTeamDashboard replaces the name, description and definition of a team's dashboard.
+func (db *DB) UpdateTeamDashboard(ctx context.Context, dashboard *models.Dashboard) error {
+	db.log.Debug("updating team dashboard", "dashboard_id", dashboard.ID, "team_id", dashboard.TeamID)
+
+	definition, err := marshalDashboardDefinition(dashboard)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.queries.UpdateTeamDashboard(ctx, sqlc.UpdateTeamDashboardParams{
+		Name:        dashboard.Name,
+		Description: dashboard.Description,
+		Definition:  definition,
+		ID:          int64(dashboard.ID),
+		TeamID:      int64(dashboard.TeamID),
+	})
+	if err != nil {
+		db.log.Error("failed to update team dashboard in db", "error", err, "dashboard_id", dashboard.ID)
+		return fmt.Errorf("error updating team dashboard: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteTeamDashboard removes a dashboard, scoped to a team.
+func (db *DB) DeleteTeamDashboard(ctx context.Context, teamID models.TeamID, dashboardID int) error {
+	db.log.Debug("deleting team dashboard", "dashboard_id", dashboardID, "team_id", teamID)
+
+	rows, err := db.queries.DeleteTeamDashboard(ctx, sqlc.DeleteTeamDashboardParams{
+		ID:     int64(dashboardID),
+		TeamID: int64(teamID),
+	})
+	if err != nil {
+		db.log.Error("failed to delete team dashboard from db", "error", err, "dashboard_id", dashboardID)
+		return fmt.Errorf("error deleting team dashboard: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// marshalDashboardDefinition encodes the panels, variables, time range and concurrency of a dashboard.
+func marshalDashboardDefinition(dashboard *models.Dashboard) (string, error) {
+	definition, err := json.Marshal(models.DashboardDefinition{
+		Panels:      dashboard.Panels,
+		Variables:   dashboard.Variables,
+		TimeRange:   dashboard.TimeRange,
+		Concurrency: dashboard.Concurrency,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding dashboard definition: %w", err)
+	}
+	return string(definition), nil
+}
+
+// mapDashboardRowToModel converts a sqlc Dashboard row to the domain model.
+func mapDashboardRowToModel(row *sqlc.Dashboard) (*models.Dashboard, error) {
+	var definition models.DashboardDefinition
+	if err := json.Unmarshal([]byte(row.Definition), &definition); err != nil {
+		return nil, fmt.Errorf("error decoding definition of dashboard %d: %w", row.ID, err)
+	}
+	if definition.Panels == nil {
+		definition.Panels = []models.DashboardPanel{}
+	}
+	if definition.Variables == nil {
+		definition.Variables = []models.SavedQueryVariable{}
+	}
+
+	return &models.Dashboard{
+		ID:          int(row.ID),
+		TeamID:      models.TeamID(row.TeamID),
+		Name:        row.Name,
+		Description: row.Description,
+		Panels:      definition.Panels,
+		Variables:   definition.Variables,
+		TimeRange:   definition.TimeRange,
+		Concurrency: definition.Concurrency,
+		Timestamps: models.Timestamps{
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		},
+	}, nil
+}
@@ -0,0 +1,2 @@
+-- Remove row-level filters from team-source links
+ALTER TABLE team_sources DROP COLUMN row_filter;
@@ -0,0 +1,3 @@
+-- Add a mandatory row-level filter expression to team-source links.
+-- The expression is injected into the WHERE clause of every query a team runs against the source.
+ALTER TABLE team_sources ADD COLUMN row_filter TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Drop column policies table
+DROP TABLE IF EXISTS column_policies;
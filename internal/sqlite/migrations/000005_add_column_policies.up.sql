@@ -0,0 +1,18 @@
+-- Create column policies table for per-team, per-source column masking
+CREATE TABLE IF NOT EXISTS column_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_id INTEGER NOT NULL,
+    source_id INTEGER NOT NULL,
+    column_name TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('hide', 'hash', 'redact')),
+    pattern TEXT NOT NULL DEFAULT '', -- Regex for 'redact' policies
+    replacement TEXT NOT NULL DEFAULT '', -- Replacement text for 'redact' policies
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (team_id, source_id, column_name),
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_column_policies_team_source ON column_policies(team_id, source_id);
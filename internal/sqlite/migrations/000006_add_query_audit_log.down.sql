@@ -0,0 +1,2 @@
+-- Drop query audit log table
+DROP TABLE IF EXISTS query_audit_log;
@@ -0,0 +1,21 @@
+-- Create query audit log table recording every query executed against a source.
+-- Rows intentionally have no foreign keys so the record survives deleted users, teams and sources.
+CREATE TABLE IF NOT EXISTS query_audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER, -- User who ran the query
+    user_email TEXT NOT NULL DEFAULT '', -- Email at the time of the query, kept if the user is deleted
+    api_token_id INTEGER, -- Set when the query was authenticated with an API token
+    team_id INTEGER,
+    source_id INTEGER,
+    query_type TEXT NOT NULL, -- 'logs', 'histogram' or 'patterns'
+    raw_sql TEXT NOT NULL DEFAULT '', -- Query as submitted
+    final_sql TEXT NOT NULL DEFAULT '', -- Query as executed, after validation and policy rewriting
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    rows_returned INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '', -- Empty on success
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_audit_log_created_at ON query_audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_query_audit_log_user_created ON query_audit_log(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_query_audit_log_source_created ON query_audit_log(source_id, created_at);
@@ -0,0 +1,2 @@
+-- Drop dashboards table
+DROP TABLE IF EXISTS dashboards;
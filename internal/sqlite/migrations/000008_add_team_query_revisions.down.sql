@@ -0,0 +1,4 @@
+-- Drop team query revisions table and authorship columns
+DROP TABLE IF EXISTS team_query_revisions;
+ALTER TABLE team_queries DROP COLUMN updated_by;
+ALTER TABLE team_queries DROP COLUMN created_by;
@@ -0,0 +1,24 @@
+-- Record who created and last updated each saved query
+ALTER TABLE team_queries ADD COLUMN created_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE team_queries ADD COLUMN updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Create team query revisions table keeping every version of a saved query
+CREATE TABLE IF NOT EXISTS team_query_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    query_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL, -- Sequential per query, starting at 1
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    query_type TEXT NOT NULL,
+    query_content TEXT NOT NULL,
+    created_by INTEGER, -- Author of the revision, NULL if unknown
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+
+    UNIQUE (query_id, revision),
+    FOREIGN KEY (query_id) REFERENCES team_queries(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Existing queries start with their current state as the first revision
+INSERT INTO team_query_revisions (query_id, revision, name, description, query_type, query_content, created_at)
+SELECT id, 1, name, COALESCE(description, ''), query_type, query_content, updated_at FROM team_queries;
@@ -0,0 +1,8 @@
+-- Drop collection folders, tags and favorites
+DROP TABLE IF EXISTS user_query_favorites;
+DROP INDEX IF EXISTS idx_team_query_tags_tag;
+DROP TABLE IF EXISTS team_query_tags;
+DROP INDEX IF EXISTS idx_team_queries_folder_id;
+ALTER TABLE team_queries DROP COLUMN last_used_at;
+ALTER TABLE team_queries DROP COLUMN folder_id;
+DROP TABLE IF EXISTS collection_folders;
@@ -0,0 +1,37 @@
+-- Create collection folders table grouping the saved queries of a team
+CREATE TABLE IF NOT EXISTS collection_folders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    team_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    UNIQUE (team_id, name)
+);
+
+-- Place saved queries in folders and track when they were last run
+ALTER TABLE team_queries ADD COLUMN folder_id INTEGER REFERENCES collection_folders(id) ON DELETE SET NULL;
+ALTER TABLE team_queries ADD COLUMN last_used_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_team_queries_folder_id ON team_queries(folder_id);
+
+-- Create team query tags table with free-form tags of saved queries
+CREATE TABLE IF NOT EXISTS team_query_tags (
+    query_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (query_id, tag),
+    FOREIGN KEY (query_id) REFERENCES team_queries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_query_tags_tag ON team_query_tags(tag);
+
+-- Create user query favorites table with the favorite and pinned saved queries of each user
+CREATE TABLE IF NOT EXISTS user_query_favorites (
+    user_id INTEGER NOT NULL,
+    query_id INTEGER NOT NULL,
+    pinned INTEGER NOT NULL DEFAULT 0, -- Pinned favorites are listed first
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (user_id, query_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (query_id) REFERENCES team_queries(id) ON DELETE CASCADE
+);
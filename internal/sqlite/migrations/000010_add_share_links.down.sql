@@ -0,0 +1,5 @@
+-- Drop share links table and the team setting
+DROP INDEX IF EXISTS idx_share_links_created_by;
+DROP INDEX IF EXISTS idx_share_links_team_id;
+DROP TABLE IF EXISTS share_links;
+ALTER TABLE teams DROP COLUMN allow_anonymous_shares;
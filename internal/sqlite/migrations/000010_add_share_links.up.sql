@@ -0,0 +1,31 @@
+-- Allow teams to let share links be opened without logging in
+ALTER TABLE teams ADD COLUMN allow_anonymous_shares INTEGER NOT NULL DEFAULT 0;
+
+-- Create share links table with expiring permalinks to query results
+CREATE TABLE IF NOT EXISTS share_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL UNIQUE, -- Random identifier used in the link
+    team_id INTEGER NOT NULL,
+    source_id INTEGER NOT NULL,
+    created_by INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    mode TEXT NOT NULL CHECK (mode IN ('snapshot', 'live')),
+    raw_sql TEXT NOT NULL,
+    row_limit INTEGER NOT NULL,
+    start_time DATETIME, -- Time range covered by the query, for display
+    end_time DATETIME,
+    snapshot TEXT, -- JSON query result captured at creation, for snapshot links
+    password_hash TEXT NOT NULL DEFAULT '',
+    allow_anonymous INTEGER NOT NULL DEFAULT 0,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    last_viewed_at DATETIME,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_team_id ON share_links(team_id);
+CREATE INDEX IF NOT EXISTS idx_share_links_created_by ON share_links(created_by);
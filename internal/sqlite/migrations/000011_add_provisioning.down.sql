@@ -0,0 +1,6 @@
+-- Drop provisioning markers
+ALTER TABLE team_queries DROP COLUMN provisioned;
+ALTER TABLE team_members DROP COLUMN provisioned;
+ALTER TABLE teams DROP COLUMN provisioned;
+ALTER TABLE sources DROP COLUMN provisioned;
+ALTER TABLE users DROP COLUMN provisioned;
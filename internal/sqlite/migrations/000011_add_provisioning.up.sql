@@ -0,0 +1,6 @@
+-- Mark objects managed by declarative provisioning, which can't be changed from the UI
+ALTER TABLE users ADD COLUMN provisioned INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sources ADD COLUMN provisioned INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE teams ADD COLUMN provisioned INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_members ADD COLUMN provisioned INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_queries ADD COLUMN provisioned INTEGER NOT NULL DEFAULT 0;
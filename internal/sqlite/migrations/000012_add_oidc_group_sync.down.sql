@@ -0,0 +1,6 @@
+-- Drop the OIDC sync log and grant tracking
+DROP INDEX IF EXISTS idx_oidc_sync_events_created_at;
+DROP INDEX IF EXISTS idx_oidc_sync_events_user_id;
+DROP TABLE IF EXISTS oidc_sync_events;
+ALTER TABLE team_members DROP COLUMN oidc_synced;
+ALTER TABLE users DROP COLUMN oidc_admin;
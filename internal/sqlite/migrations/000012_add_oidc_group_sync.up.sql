@@ -0,0 +1,22 @@
+-- Track what OIDC group sync granted, so it only ever revokes its own grants
+ALTER TABLE users ADD COLUMN oidc_admin INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_members ADD COLUMN oidc_synced INTEGER NOT NULL DEFAULT 0;
+
+-- Create the log of decisions made by OIDC group sync on login
+CREATE TABLE IF NOT EXISTS oidc_sync_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER, -- NULL for rejected logins
+    user_email TEXT NOT NULL,
+    team_id INTEGER,
+    team_name TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT '',
+    groups TEXT NOT NULL DEFAULT '[]', -- JSON array of the groups behind the decision
+    reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_sync_events_user_id ON oidc_sync_events(user_id);
+CREATE INDEX IF NOT EXISTS idx_oidc_sync_events_created_at ON oidc_sync_events(created_at);
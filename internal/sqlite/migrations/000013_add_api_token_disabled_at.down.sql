@@ -0,0 +1,2 @@
+-- Drop the disabled state of API tokens
+ALTER TABLE api_tokens DROP COLUMN disabled_at;
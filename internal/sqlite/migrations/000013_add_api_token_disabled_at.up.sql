@@ -0,0 +1,3 @@
+-- Disabled tokens are kept for reference but no longer authenticate.
+-- Tokens are disabled when their user is deactivated.
+ALTER TABLE api_tokens ADD COLUMN disabled_at DATETIME;
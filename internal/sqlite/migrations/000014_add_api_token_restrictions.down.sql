@@ -0,0 +1,4 @@
+-- Drop the restrictions of API tokens
+ALTER TABLE api_tokens DROP COLUMN allowed_cidrs;
+ALTER TABLE api_tokens DROP COLUMN resources;
+ALTER TABLE api_tokens DROP COLUMN scopes;
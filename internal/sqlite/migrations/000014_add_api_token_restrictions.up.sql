@@ -0,0 +1,7 @@
+-- Least-privilege restrictions of API tokens, stored as JSON arrays.
+-- Existing tokens keep full access of their user ("*" scope, no restrictions).
+ALTER TABLE api_tokens ADD COLUMN scopes TEXT NOT NULL DEFAULT '["*"]';
+-- Team/source pairs the token is limited to; empty means every pair of the user.
+ALTER TABLE api_tokens ADD COLUMN resources TEXT NOT NULL DEFAULT '[]';
+-- CIDRs the token may be used from; empty means any address.
+ALTER TABLE api_tokens ADD COLUMN allowed_cidrs TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+-- Drop the log of source retention changes
+DROP INDEX IF EXISTS idx_source_retention_changes_source_id;
+DROP TABLE IF EXISTS source_retention_changes;
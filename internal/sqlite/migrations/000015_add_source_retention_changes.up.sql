@@ -0,0 +1,16 @@
+-- Create the log of retention (TTL) changes made to sources
+CREATE TABLE IF NOT EXISTS source_retention_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id INTEGER NOT NULL,
+    user_id INTEGER, -- NULL once the user is deleted
+    old_ttl_days INTEGER NOT NULL,
+    new_ttl_days INTEGER NOT NULL,
+    status TEXT NOT NULL, -- applied, failed or not_applied
+    statement TEXT NOT NULL DEFAULT '', -- ALTER TABLE statement run on ClickHouse, if any
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_source_retention_changes_source_id ON source_retention_changes(source_id, created_at);
@@ -0,0 +1,4 @@
+-- Drop the source schema snapshots and change log
+DROP INDEX IF EXISTS idx_source_schema_changes_source_id;
+DROP TABLE IF EXISTS source_schema_changes;
+DROP TABLE IF EXISTS source_schema_snapshots;
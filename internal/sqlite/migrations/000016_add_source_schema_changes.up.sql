@@ -0,0 +1,22 @@
+-- Create the latest schema snapshot of each source's table
+CREATE TABLE IF NOT EXISTS source_schema_snapshots (
+    source_id INTEGER PRIMARY KEY,
+    columns TEXT NOT NULL DEFAULT '[]', -- JSON array of {name, type}
+    sort_keys TEXT NOT NULL DEFAULT '[]', -- JSON array of column names
+    taken_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+-- Create the log of schema changes detected between snapshots
+CREATE TABLE IF NOT EXISTS source_schema_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id INTEGER NOT NULL,
+    change_type TEXT NOT NULL, -- column_added, column_removed, column_type_changed or sort_keys_changed
+    column_name TEXT NOT NULL DEFAULT '',
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    detected_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_source_schema_changes_source_id ON source_schema_changes(source_id, detected_at);
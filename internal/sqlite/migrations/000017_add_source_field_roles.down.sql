@@ -0,0 +1,3 @@
+-- Remove source field roles and severity mappings
+ALTER TABLE sources DROP COLUMN severity_mapping;
+ALTER TABLE sources DROP COLUMN field_roles;
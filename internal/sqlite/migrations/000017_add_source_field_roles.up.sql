@@ -0,0 +1,5 @@
+-- Declare the semantic role of source columns beyond the timestamp and severity fields
+-- (message, service, host, trace and span IDs, HTTP status, duration), as a JSON object
+ALTER TABLE sources ADD COLUMN field_roles TEXT NOT NULL DEFAULT '{}';
+-- Map raw values of the severity field (or ranges of numeric values) to severity levels, as a JSON object
+ALTER TABLE sources ADD COLUMN severity_mapping TEXT NOT NULL DEFAULT '{}';
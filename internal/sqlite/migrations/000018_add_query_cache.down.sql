@@ -0,0 +1,4 @@
+-- Drop the on-disk tier of the query result cache
+DROP INDEX IF EXISTS idx_query_cache_source_id;
+DROP INDEX IF EXISTS idx_query_cache_expires_at;
+DROP TABLE IF EXISTS query_cache;
@@ -0,0 +1,14 @@
+-- Create the on-disk tier of the query result cache
+CREATE TABLE IF NOT EXISTS query_cache (
+    cache_key TEXT PRIMARY KEY, -- Hash of the query kind, source, final SQL and parameters
+    source_id INTEGER NOT NULL,
+    kind TEXT NOT NULL, -- logs or histogram
+    value TEXT NOT NULL, -- JSON encoded result
+    size INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_cache_expires_at ON query_cache(expires_at);
+CREATE INDEX IF NOT EXISTS idx_query_cache_source_id ON query_cache(source_id);
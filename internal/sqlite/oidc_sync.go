@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// OIDC group sync methods
+
+// SetUserOIDCAdmin marks whether a user's admin role was granted by an OIDC group.
+func (db *DB) SetUserOIDCAdmin(ctx context.Context, id models.UserID, granted bool) error {
+	err := db.queries.SetUserOIDCAdmin(ctx, sqlc.SetUserOIDCAdminParams{OidcAdmin: boolToInt(granted), ID: int64(id)})
+	if err != nil {
+		return fmt.Errorf("error marking user admin role as synced: %w", err)
+	}
+	return nil
+}
+
+// SetTeamMemberOIDCSynced marks whether a team membership was granted by an OIDC group.
+func (db *DB) SetTeamMemberOIDCSynced(ctx context.Context, teamID models.TeamID, userID models.UserID, synced bool) error {
+	err := db.queries.SetTeamMemberOIDCSynced(ctx, sqlc.SetTeamMemberOIDCSyncedParams{
+		OidcSynced: boolToInt(synced),
+		TeamID:     int64(teamID),
+		UserID:     int64(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("error marking team member as synced: %w", err)
+	}
+	return nil
+}
+
+// InsertOIDCSyncEvent records a decision of OIDC group sync.
+func (db *DB) InsertOIDCSyncEvent(ctx context.Context, event *models.OIDCSyncEvent) error {
+	groups, err := json.Marshal(event.Groups)
+	if err != nil {
+		return fmt.Errorf("error encoding sync event groups: %w", err)
+	}
+	params := sqlc.InsertOIDCSyncEventParams{
+		UserEmail: event.UserEmail,
+		TeamName:  event.TeamName,
+		Action:    string(event.Action),
+		Role:      event.Role,
+		Groups:    string(groups),
+		Reason:    event.Reason,
+		CreatedAt: event.CreatedAt.UTC(),
+	}
+	if event.UserID != nil {
+		params.UserID = sql.NullInt64{Int64: int64(*event.UserID), Valid: true}
+	}
+	if event.TeamID != nil {
+		params.TeamID = sql.NullInt64{Int64: int64(*event.TeamID), Valid: true}
+	}
+
+	if err := db.queries.InsertOIDCSyncEvent(ctx, params); err != nil {
+		db.log.Error("failed to insert oidc sync event", "error", err, "email", event.UserEmail)
+		return fmt.Errorf("error inserting oidc sync event: %w", err)
+	}
+	return nil
+}
+
+// SearchOIDCSyncEvents returns sync events matching the filter, newest first.
+func (db *DB) SearchOIDCSyncEvents(ctx context.Context, filter models.OIDCSyncEventFilter) ([]*models.OIDCSyncEvent, error) {
+	db.log.Debug("searching oidc sync events", "filter", filter)
+
+	params := sqlc.SearchOIDCSyncEventsParams{
+		Limit:  int64(filter.Limit),
+		Offset: int64(filter.Offset),
+	}
+	if filter.UserID != nil {
+		params.UserID = sql.NullInt64{Int64: int64(*filter.UserID), Valid: true}
+	}
+	if filter.TeamID != nil {
+		params.TeamID = sql.NullInt64{Int64: int64(*filter.TeamID), Valid: true}
+	}
+	if filter.Action != "" {
+		params.Action = sql.NullString{String: string(filter.Action), Valid: true}
+	}
+
+	rows, err := db.queries.SearchOIDCSyncEvents(ctx, params)
+	if err != nil {
+		db.log.Error("failed to search oidc sync events in db", "error", err)
+		return nil, fmt.Errorf("error searching oidc sync events: %w", err)
+	}
+
+	events := make([]*models.OIDCSyncEvent, 0, len(rows))
+	for _, row := range rows {
+		event := &models.OIDCSyncEvent{
+			ID:        row.ID,
+			UserEmail: row.UserEmail,
+			TeamName:  row.TeamName,
+			Action:    models.OIDCSyncAction(row.Action),
+			Role:      row.Role,
+			Reason:    row.Reason,
+			CreatedAt: row.CreatedAt,
+		}
+		if err := json.Unmarshal([]byte(row.Groups), &event.Groups); err != nil {
+			db.log.Warn("invalid groups in oidc sync event", "id", row.ID, "error", err)
+		}
+		if row.UserID.Valid {
+			userID := models.UserID(row.UserID.Int64)
+			event.UserID = &userID
+		}
+		if row.TeamID.Valid {
+			teamID := models.TeamID(row.TeamID.Int64)
+			event.TeamID = &teamID
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Provisioning methods
+
+// SetUserProvisioned marks whether a user is managed by provisioning.
+func (db *DB) SetUserProvisioned(ctx context.Context, id models.UserID, provisioned bool) error {
+	err := db.queries.SetUserProvisioned(ctx, sqlc.SetUserProvisionedParams{Provisioned: boolToInt(provisioned), ID: int64(id)})
+	if err != nil {
+		return fmt.Errorf("error marking user as provisioned: %w", err)
+	}
+	return nil
+}
+
+// SetSourceProvisioned marks whether a source is managed by provisioning.
+func (db *DB) SetSourceProvisioned(ctx context.Context, id models.SourceID, provisioned bool) error {
+	err := db.queries.SetSourceProvisioned(ctx, sqlc.SetSourceProvisionedParams{Provisioned: boolToInt(provisioned), ID: int64(id)})
+	if err != nil {
+		return fmt.Errorf("error marking source as provisioned: %w", err)
+	}
+	return nil
+}
+
+// SetTeamProvisioned marks whether a team is managed by provisioning.
+func (db *DB) SetTeamProvisioned(ctx context.Context, id models.TeamID, provisioned bool) error {
+	err := db.queries.SetTeamProvisioned(ctx, sqlc.SetTeamProvisionedParams{Provisioned: boolToInt(provisioned), ID: int64(id)})
+	if err != nil {
+		return fmt.Errorf("error marking team as provisioned: %w", err)
+	}
+	return nil
+}
+
+// SetTeamMemberProvisioned marks whether a team membership is managed by provisioning.
+func (db *DB) SetTeamMemberProvisioned(ctx context.Context, teamID models.TeamID, userID models.UserID, provisioned bool) error {
+	err := db.queries.SetTeamMemberProvisioned(ctx, sqlc.SetTeamMemberProvisionedParams{
+		Provisioned: boolToInt(provisioned),
+		TeamID:      int64(teamID),
+		UserID:      int64(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("error marking team member as provisioned: %w", err)
+	}
+	return nil
+}
+
+// SetTeamQueryProvisioned marks whether a team query is managed by provisioning.
+func (db *DB) SetTeamQueryProvisioned(ctx context.Context, id int, provisioned bool) error {
+	err := db.queries.SetTeamQueryProvisioned(ctx, sqlc.SetTeamQueryProvisionedParams{Provisioned: boolToInt(provisioned), ID: int64(id)})
+	if err != nil {
+		return fmt.Errorf("error marking team query as provisioned: %w", err)
+	}
+	return nil
+}
@@ -3,8 +3,8 @@
 -- name: CreateSource :one
 -- Create a new source entry
 INSERT INTO sources (
-    name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, database, table_name, description, ttl_days, created_at, updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+    name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, database, table_name, description, ttl_days, field_roles, severity_mapping, created_at, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
 RETURNING id;
 
 -- name: GetSource :one
@@ -33,6 +33,8 @@ SET name = ?,
     table_name = ?,
     description = ?,
     ttl_days = ?,
+    field_roles = ?,
+    severity_mapping = ?,
     updated_at = datetime('now')
 WHERE id = ?;
 
@@ -103,6 +105,10 @@ DELETE FROM sessions WHERE user_id = ?;
 -- Count active sessions for a user
 SELECT COUNT(*) FROM sessions WHERE user_id = ? AND expires_at > ?;
 
+-- name: DeleteExpiredSessions :execrows
+-- Delete all sessions that expired before the given time
+DELETE FROM sessions WHERE expires_at < ?;
+
 -- Teams
 
 -- name: CreateTeam :one
@@ -120,6 +126,7 @@ SELECT * FROM teams WHERE id = ?;
 UPDATE teams
 SET name = ?,
     description = ?,
+    allow_anonymous_shares = ?,
     updated_at = ?
 WHERE id = ?;
 
@@ -159,14 +166,14 @@ WHERE team_id = ? AND user_id = ?;
 
 -- name: ListTeamMembers :many
 -- List all members of a team
-SELECT tm.team_id, tm.user_id, tm.role, tm.created_at
+SELECT tm.team_id, tm.user_id, tm.role, tm.created_at, tm.provisioned, tm.oidc_synced
 FROM team_members tm
 WHERE tm.team_id = ?
 ORDER BY tm.created_at;
 
 -- name: ListTeamMembersWithDetails :many
 -- List all members of a team with user details
-SELECT tm.team_id, tm.user_id, tm.role, tm.created_at, u.email, u.full_name
+SELECT tm.team_id, tm.user_id, tm.role, tm.created_at, tm.provisioned, tm.oidc_synced, u.email, u.full_name
 FROM team_members tm
 JOIN users u ON tm.user_id = u.id
 WHERE tm.team_id = ?
@@ -207,12 +214,23 @@ JOIN team_sources ts ON t.id = ts.team_id
 WHERE ts.source_id = ?
 ORDER BY t.name;
 
+-- name: GetTeamSourceRowFilter :one
+-- Get the row-level filter expression for a team's access to a source
+SELECT row_filter FROM team_sources
+WHERE team_id = ? AND source_id = ?;
+
+-- name: UpdateTeamSourceRowFilter :execrows
+-- Set the row-level filter expression for a team's access to a source
+UPDATE team_sources
+SET row_filter = ?
+WHERE team_id = ? AND source_id = ?;
+
 -- Team Queries
 
 -- name: CreateTeamSourceQuery :one
 -- Create a new query for a team and source
-INSERT INTO team_queries (team_id, source_id, name, description, query_type, query_content)
-VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO team_queries (team_id, source_id, name, description, query_type, query_content, created_by, updated_by)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id;
 
 -- name: GetTeamSourceQuery :one
@@ -220,13 +238,14 @@ RETURNING id;
 SELECT * FROM team_queries
 WHERE id = ? AND team_id = ? AND source_id = ?;
 
--- name: UpdateTeamSourceQuery :exec
+-- name: UpdateTeamSourceQuery :execrows
 -- Update a query for a team and source
 UPDATE team_queries
 SET name = ?,
     description = ?,
     query_type = ?,
     query_content = ?,
+    updated_by = ?,
     updated_at = datetime('now')
 WHERE id = ? AND team_id = ? AND source_id = ?;
 
@@ -239,6 +258,192 @@ WHERE id = ? AND team_id = ? AND source_id = ?;
 -- List all queries for a specific team and source
 SELECT * FROM team_queries WHERE team_id = ? AND source_id = ? ORDER BY created_at DESC;
 
+-- name: ListQueriesBySource :many
+-- List the queries of all teams for a specific source
+SELECT * FROM team_queries WHERE source_id = ? ORDER BY team_id, created_at DESC;
+
+-- Team Query Revisions
+
+-- name: CreateTeamQueryRevision :exec
+-- Record the current state of a query as its next revision
+INSERT INTO team_query_revisions (query_id, revision, name, description, query_type, query_content, created_by)
+SELECT id,
+    COALESCE((SELECT MAX(revision) FROM team_query_revisions WHERE query_id = ?), 0) + 1,
+    name, COALESCE(description, ''), query_type, query_content, updated_by
+FROM team_queries
+WHERE id = ?;
+
+-- name: ListTeamQueryRevisions :many
+-- List all revisions of a query, newest first
+SELECT r.*, u.email AS created_by_email
+FROM team_query_revisions r
+LEFT JOIN users u ON u.id = r.created_by
+WHERE r.query_id = ?
+ORDER BY r.revision DESC;
+
+-- name: GetTeamQueryRevision :one
+-- Get a single revision of a query
+SELECT r.*, u.email AS created_by_email
+FROM team_query_revisions r
+LEFT JOIN users u ON u.id = r.created_by
+WHERE r.query_id = ? AND r.revision = ?;
+
+-- Collection Folders
+
+-- name: ListTeamCollectionFolders :many
+-- List all collection folders of a team with their query counts
+SELECT f.*, (SELECT COUNT(*) FROM team_queries q WHERE q.folder_id = f.id) AS query_count
+FROM collection_folders f
+WHERE f.team_id = ?
+ORDER BY f.name;
+
+-- name: GetTeamCollectionFolder :one
+-- Get a collection folder by ID for a team
+SELECT * FROM collection_folders
+WHERE id = ? AND team_id = ?;
+
+-- name: CreateTeamCollectionFolder :one
+-- Create a new collection folder for a team
+INSERT INTO collection_folders (team_id, name)
+VALUES (?, ?)
+RETURNING id;
+
+-- name: UpdateTeamCollectionFolder :execrows
+-- Rename a collection folder of a team
+UPDATE collection_folders
+SET name = ?, updated_at = datetime('now')
+WHERE id = ? AND team_id = ?;
+
+-- name: DeleteTeamCollectionFolder :execrows
+-- Delete a collection folder of a team, leaving its queries unfiled
+DELETE FROM collection_folders
+WHERE id = ? AND team_id = ?;
+
+-- name: SetTeamQueryFolder :execrows
+-- Move a query into a folder, or out of any folder when NULL
+UPDATE team_queries
+SET folder_id = ?
+WHERE id = ? AND team_id = ? AND source_id = ?;
+
+-- Team Query Tags
+
+-- name: ListTeamQueryTags :many
+-- List the tags of a query
+SELECT tag FROM team_query_tags
+WHERE query_id = ?
+ORDER BY tag;
+
+-- name: ListTeamTags :many
+-- List the distinct tags used by the queries of a team
+SELECT DISTINCT t.tag
+FROM team_query_tags t
+JOIN team_queries q ON q.id = t.query_id
+WHERE q.team_id = ?
+ORDER BY t.tag;
+
+-- name: AddTeamQueryTag :exec
+-- Add a tag to a query
+INSERT OR IGNORE INTO team_query_tags (query_id, tag)
+VALUES (?, ?);
+
+-- name: DeleteTeamQueryTags :exec
+-- Remove all tags of a query
+DELETE FROM team_query_tags WHERE query_id = ?;
+
+-- User Query Favorites
+
+-- name: UpsertUserQueryFavorite :exec
+-- Add a query to the favorites of a user, or update whether it's pinned
+INSERT INTO user_query_favorites (user_id, query_id, pinned)
+VALUES (?, ?, ?)
+ON CONFLICT(user_id, query_id) DO UPDATE SET pinned = excluded.pinned;
+
+-- name: DeleteUserQueryFavorite :execrows
+-- Remove a query from the favorites of a user
+DELETE FROM user_query_favorites
+WHERE user_id = ? AND query_id = ?;
+
+-- name: MarkTeamQueryUsed :exec
+-- Record that a query was just run
+UPDATE team_queries
+SET last_used_at = datetime('now')
+WHERE id = ?;
+
+-- name: SearchTeamQueries :many
+-- Search the queries of a team across its sources, ignoring NULL filters. Folder 0 matches unfiled queries.
+SELECT q.*,
+    (SELECT group_concat(t.tag, ',') FROM team_query_tags t WHERE t.query_id = q.id) AS tags,
+    CAST(f.user_id IS NOT NULL AS INTEGER) AS is_favorite,
+    CAST(COALESCE(f.pinned, 0) AS INTEGER) AS is_pinned
+FROM team_queries q
+JOIN team_sources ts ON ts.team_id = q.team_id AND ts.source_id = q.source_id
+LEFT JOIN user_query_favorites f ON f.query_id = q.id AND f.user_id = sqlc.arg('user_id')
+WHERE q.team_id = sqlc.arg('team_id')
+  AND (sqlc.narg('source_id') IS NULL OR q.source_id = sqlc.narg('source_id'))
+  AND (sqlc.narg('folder_id') IS NULL OR q.folder_id = sqlc.narg('folder_id') OR (sqlc.narg('folder_id') = 0 AND q.folder_id IS NULL))
+  AND (sqlc.narg('tag') IS NULL OR EXISTS (SELECT 1 FROM team_query_tags t WHERE t.query_id = q.id AND t.tag = sqlc.narg('tag')))
+  AND (sqlc.narg('favorites_only') IS NULL OR f.user_id IS NOT NULL)
+  AND (sqlc.narg('search') IS NULL
+    OR instr(lower(q.name), lower(sqlc.narg('search'))) > 0
+    OR instr(lower(COALESCE(q.description, '')), lower(sqlc.narg('search'))) > 0
+    OR instr(lower(q.query_content), lower(sqlc.narg('search'))) > 0
+    OR EXISTS (SELECT 1 FROM team_query_tags t WHERE t.query_id = q.id AND instr(t.tag, lower(sqlc.narg('search'))) > 0))
+ORDER BY
+    COALESCE(f.pinned, 0) DESC,
+    CASE WHEN sqlc.arg('sort') = 'last_used' THEN q.last_used_at END DESC,
+    CASE WHEN sqlc.arg('sort') = 'updated' THEN q.updated_at END DESC,
+    q.name COLLATE NOCASE,
+    q.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- Share Links
+
+-- name: CreateShareLink :one
+-- Create a new share link
+INSERT INTO share_links (slug, team_id, source_id, created_by, title, mode, raw_sql, row_limit, start_time, end_time, snapshot, password_hash, allow_anonymous, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: GetShareLinkBySlug :one
+-- Get a share link by its slug with the creator and the team setting for anonymous access
+SELECT l.*, u.email AS created_by_email, t.allow_anonymous_shares AS team_allow_anonymous
+FROM share_links l
+JOIN users u ON u.id = l.created_by
+JOIN teams t ON t.id = l.team_id
+WHERE l.slug = ?;
+
+-- name: ListUserShareLinks :many
+-- List the share links created by a user, newest first, without snapshots
+SELECT l.id, l.slug, l.team_id, l.source_id, l.created_by, l.title, l.mode, l.raw_sql, l.row_limit, l.start_time, l.end_time,
+    l.password_hash != '' AS has_password, l.allow_anonymous, l.view_count, l.last_viewed_at, l.expires_at, l.revoked_at, l.created_at,
+    u.email AS created_by_email
+FROM share_links l
+JOIN users u ON u.id = l.created_by
+WHERE l.created_by = ?
+ORDER BY l.created_at DESC, l.id DESC;
+
+-- name: ListTeamShareLinks :many
+-- List the share links of a team, newest first, without snapshots
+SELECT l.id, l.slug, l.team_id, l.source_id, l.created_by, l.title, l.mode, l.raw_sql, l.row_limit, l.start_time, l.end_time,
+    l.password_hash != '' AS has_password, l.allow_anonymous, l.view_count, l.last_viewed_at, l.expires_at, l.revoked_at, l.created_at,
+    u.email AS created_by_email
+FROM share_links l
+JOIN users u ON u.id = l.created_by
+WHERE l.team_id = ?
+ORDER BY l.created_at DESC, l.id DESC;
+
+-- name: RevokeShareLink :execrows
+-- Revoke a share link that isn't revoked yet
+UPDATE share_links
+SET revoked_at = datetime('now')
+WHERE id = ? AND revoked_at IS NULL;
+
+-- name: RecordShareLinkView :exec
+-- Count a view of a share link
+UPDATE share_links
+SET view_count = view_count + 1, last_viewed_at = datetime('now')
+WHERE id = ?;
+
 -- Additional queries for user-source and team-source access
 
 -- name: TeamHasSource :one
@@ -287,8 +492,8 @@ ORDER BY s.created_at DESC;
 
 -- name: CreateAPIToken :one
 -- Create a new API token
-INSERT INTO api_tokens (user_id, name, token_hash, prefix, expires_at)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO api_tokens (user_id, name, token_hash, prefix, expires_at, scopes, resources, allowed_cidrs)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id;
 
 -- name: GetAPIToken :one
@@ -314,6 +519,222 @@ WHERE id = ?;
 -- Delete an API token by ID and user ID (ensure user owns the token)
 DELETE FROM api_tokens WHERE id = ? AND user_id = ?;
 
+-- name: DisableUserAPITokens :exec
+-- Disable all active API tokens of a user
+UPDATE api_tokens
+SET disabled_at = ?,
+    updated_at = datetime('now')
+WHERE user_id = ? AND disabled_at IS NULL;
+
 -- name: DeleteExpiredAPITokens :exec
 -- Delete all expired API tokens
 DELETE FROM api_tokens WHERE expires_at IS NOT NULL AND expires_at < datetime('now');
+
+-- Column Policies
+
+-- name: ListColumnPolicies :many
+-- List all column policies for a team's access to a source
+SELECT * FROM column_policies
+WHERE team_id = ? AND source_id = ?
+ORDER BY column_name;
+
+-- name: GetColumnPolicy :one
+-- Get a column policy by ID for a team and source
+SELECT * FROM column_policies
+WHERE id = ? AND team_id = ? AND source_id = ?;
+
+-- name: UpsertColumnPolicy :one
+-- Create or replace the policy for a column of a team's source
+INSERT INTO column_policies (team_id, source_id, column_name, action, pattern, replacement)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (team_id, source_id, column_name) DO UPDATE SET
+    action = excluded.action,
+    pattern = excluded.pattern,
+    replacement = excluded.replacement,
+    updated_at = datetime('now')
+RETURNING id;
+
+-- name: DeleteColumnPolicy :execrows
+-- Delete a column policy for a team and source
+DELETE FROM column_policies
+WHERE id = ? AND team_id = ? AND source_id = ?;
+
+-- name: DeleteTeamSourceColumnPolicies :exec
+-- Delete all column policies for a team's access to a source
+DELETE FROM column_policies
+WHERE team_id = ? AND source_id = ?;
+
+-- Query Audit Log
+
+-- name: InsertQueryAuditEntry :exec
+-- Record an executed query
+INSERT INTO query_audit_log (
+    user_id, user_email, api_token_id, team_id, source_id, query_type,
+    raw_sql, final_sql, duration_ms, rows_returned, error, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListUserQueryHistory :many
+-- List the most recent queries run by a user
+SELECT * FROM query_audit_log
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?;
+
+-- name: SearchQueryAuditLog :many
+-- Search the query audit log, ignoring NULL filters
+SELECT * FROM query_audit_log
+WHERE (sqlc.narg('user_id') IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('team_id') IS NULL OR team_id = sqlc.narg('team_id'))
+  AND (sqlc.narg('source_id') IS NULL OR source_id = sqlc.narg('source_id'))
+  AND (sqlc.narg('api_token_id') IS NULL OR api_token_id = sqlc.narg('api_token_id'))
+  AND (sqlc.narg('query_type') IS NULL OR query_type = sqlc.narg('query_type'))
+  AND (sqlc.narg('failed') IS NULL OR (error != '') = sqlc.narg('failed'))
+  AND (sqlc.narg('search') IS NULL OR instr(final_sql, sqlc.narg('search')) > 0 OR instr(raw_sql, sqlc.narg('search')) > 0)
+  AND (sqlc.narg('start_time') IS NULL OR created_at >= sqlc.narg('start_time'))
+  AND (sqlc.narg('end_time') IS NULL OR created_at < sqlc.narg('end_time'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: DeleteQueryAuditLogBefore :execrows
+-- Delete audit entries older than the given time
+DELETE FROM query_audit_log WHERE created_at < ?;
+
+-- Dashboards
+
+-- name: ListTeamDashboards :many
+-- List all dashboards of a team
+SELECT * FROM dashboards
+WHERE team_id = ?
+ORDER BY name;
+
+-- name: GetTeamDashboard :one
+-- Get a dashboard by ID for a team
+SELECT * FROM dashboards
+WHERE id = ? AND team_id = ?;
+
+-- name: CreateTeamDashboard :one
+-- Create a new dashboard for a team
+INSERT INTO dashboards (team_id, name, description, definition)
+VALUES (?, ?, ?, ?)
+RETURNING id;
+
+-- name: UpdateTeamDashboard :execrows
+-- Update a dashboard of a team
+UPDATE dashboards
+SET name = ?, description = ?, definition = ?, updated_at = datetime('now')
+WHERE id = ? AND team_id = ?;
+
+-- name: DeleteTeamDashboard :execrows
+-- Delete a dashboard of a team
+DELETE FROM dashboards
+WHERE id = ? AND team_id = ?;
+
+-- Provisioning
+
+-- name: SetUserProvisioned :exec
+-- Mark whether a user is managed by provisioning
+UPDATE users SET provisioned = ? WHERE id = ?;
+
+-- name: SetSourceProvisioned :exec
+-- Mark whether a source is managed by provisioning
+UPDATE sources SET provisioned = ? WHERE id = ?;
+
+-- name: SetTeamProvisioned :exec
+-- Mark whether a team is managed by provisioning
+UPDATE teams SET provisioned = ? WHERE id = ?;
+
+-- name: SetTeamMemberProvisioned :exec
+-- Mark whether a team membership is managed by provisioning
+UPDATE team_members SET provisioned = ? WHERE team_id = ? AND user_id = ?;
+
+-- name: SetTeamQueryProvisioned :exec
+-- Mark whether a team query is managed by provisioning
+UPDATE team_queries SET provisioned = ? WHERE id = ?;
+
+-- OIDC group sync
+
+-- name: SetUserOIDCAdmin :exec
+-- Mark whether a user's admin role was granted by an OIDC group
+UPDATE users SET oidc_admin = ? WHERE id = ?;
+
+-- name: SetTeamMemberOIDCSynced :exec
+-- Mark whether a team membership was granted by an OIDC group
+UPDATE team_members SET oidc_synced = ? WHERE team_id = ? AND user_id = ?;
+
+-- name: InsertOIDCSyncEvent :exec
+-- Record a decision made by OIDC group sync
+INSERT INTO oidc_sync_events (user_id, user_email, team_id, team_name, action, role, groups, reason, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: SearchOIDCSyncEvents :many
+-- Search the OIDC sync log, ignoring NULL filters
+SELECT * FROM oidc_sync_events
+WHERE (sqlc.narg('user_id') IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('team_id') IS NULL OR team_id = sqlc.narg('team_id'))
+  AND (sqlc.narg('action') IS NULL OR action = sqlc.narg('action'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- Source retention changes
+
+-- name: InsertSourceRetentionChange :exec
+-- Record a change of a source's retention
+INSERT INTO source_retention_changes (source_id, user_id, old_ttl_days, new_ttl_days, status, statement, error, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListSourceRetentionChanges :many
+-- List the retention changes of a source, newest first
+SELECT * FROM source_retention_changes
+WHERE source_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
+
+-- Source schema drift
+
+-- name: GetSourceSchemaSnapshot :one
+-- Get the latest schema snapshot of a source
+SELECT * FROM source_schema_snapshots WHERE source_id = ?;
+
+-- name: UpsertSourceSchemaSnapshot :exec
+-- Replace the latest schema snapshot of a source
+INSERT INTO source_schema_snapshots (source_id, columns, sort_keys, taken_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(source_id) DO UPDATE SET columns = excluded.columns, sort_keys = excluded.sort_keys, taken_at = excluded.taken_at;
+
+-- name: InsertSourceSchemaChange :exec
+-- Record a schema change detected for a source
+INSERT INTO source_schema_changes (source_id, change_type, column_name, old_value, new_value, detected_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListSourceSchemaChanges :many
+-- List the schema changes of a source, newest first
+SELECT * FROM source_schema_changes
+WHERE source_id = ?
+ORDER BY detected_at DESC, id DESC
+LIMIT ?;
+
+-- Query result cache
+
+-- name: GetQueryCacheEntry :one
+-- Get a cached query result that hasn't expired
+SELECT * FROM query_cache WHERE cache_key = ? AND expires_at > ?;
+
+-- name: UpsertQueryCacheEntry :exec
+-- Store a query result, replacing any previous result of the same query
+INSERT INTO query_cache (cache_key, source_id, kind, value, size, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(cache_key) DO UPDATE SET value = excluded.value, size = excluded.size, created_at = excluded.created_at, expires_at = excluded.expires_at;
+
+-- name: DeleteSourceQueryCacheEntries :exec
+-- Drop the cached query results of a source
+DELETE FROM query_cache WHERE source_id = ?;
+
+-- name: DeleteExpiredQueryCacheEntries :execrows
+-- Drop expired query results
+DELETE FROM query_cache WHERE expires_at <= ?;
+
+-- name: TrimQueryCacheEntries :execrows
+-- Drop the oldest query results beyond the given number of entries
+DELETE FROM query_cache WHERE cache_key IN (
+    SELECT cache_key FROM query_cache ORDER BY created_at DESC LIMIT -1 OFFSET ?
+);
@@ -0,0 +1,173 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Query audit log methods
+
+// InsertQueryAuditEntries writes a batch of audit entries in a single transaction.
+func (db *DB) InsertQueryAuditEntries(ctx context.Context, entries []*models.QueryAuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	db.log.Debug("inserting query audit entries", "count", len(entries))
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting audit log transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := sqlc.New(tx)
+	for _, entry := range entries {
+		params := sqlc.InsertQueryAuditEntryParams{
+			UserEmail:    entry.UserEmail,
+			QueryType:    string(entry.QueryType),
+			RawSql:       entry.RawSQL,
+			FinalSql:     entry.FinalSQL,
+			DurationMs:   entry.DurationMs,
+			RowsReturned: int64(entry.RowsReturned),
+			Error:        entry.Error,
+			CreatedAt:    entry.CreatedAt.UTC(),
+		}
+		if entry.UserID != nil {
+			params.UserID = sql.NullInt64{Int64: int64(*entry.UserID), Valid: true}
+		}
+		if entry.APITokenID != nil {
+			params.ApiTokenID = sql.NullInt64{Int64: int64(*entry.APITokenID), Valid: true}
+		}
+		if entry.TeamID != nil {
+			params.TeamID = sql.NullInt64{Int64: int64(*entry.TeamID), Valid: true}
+		}
+		if entry.SourceID != nil {
+			params.SourceID = sql.NullInt64{Int64: int64(*entry.SourceID), Valid: true}
+		}
+		if err := qtx.InsertQueryAuditEntry(ctx, params); err != nil {
+			db.log.Error("failed to insert query audit entry", "error", err)
+			return fmt.Errorf("error inserting query audit entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing audit log transaction: %w", err)
+	}
+	return nil
+}
+
+// ListUserQueryHistory returns the most recent queries run by a user.
+func (db *DB) ListUserQueryHistory(ctx context.Context, userID models.UserID, limit, offset int) ([]*models.QueryAuditEntry, error) {
+	db.log.Debug("listing user query history", "user_id", userID, "limit", limit, "offset", offset)
+
+	rows, err := db.queries.ListUserQueryHistory(ctx, sqlc.ListUserQueryHistoryParams{
+		UserID: sql.NullInt64{Int64: int64(userID), Valid: true},
+		Limit:  int64(limit),
+		Offset: int64(offset),
+	})
+	if err != nil {
+		db.log.Error("failed to list user query history from db", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("error listing query history: %w", err)
+	}
+
+	return mapQueryAuditRows(rows), nil
+}
+
+// SearchQueryAuditLog returns audit entries matching the filter, newest first.
+func (db *DB) SearchQueryAuditLog(ctx context.Context, filter models.QueryAuditFilter) ([]*models.QueryAuditEntry, error) {
+	db.log.Debug("searching query audit log", "filter", filter)
+
+	params := sqlc.SearchQueryAuditLogParams{
+		Limit:  int64(filter.Limit),
+		Offset: int64(filter.Offset),
+	}
+	if filter.UserID != nil {
+		params.UserID = sql.NullInt64{Int64: int64(*filter.UserID), Valid: true}
+	}
+	if filter.TeamID != nil {
+		params.TeamID = sql.NullInt64{Int64: int64(*filter.TeamID), Valid: true}
+	}
+	if filter.SourceID != nil {
+		params.SourceID = sql.NullInt64{Int64: int64(*filter.SourceID), Valid: true}
+	}
+	if filter.APITokenID != nil {
+		params.ApiTokenID = sql.NullInt64{Int64: int64(*filter.APITokenID), Valid: true}
+	}
+	if filter.QueryType != "" {
+		params.QueryType = sql.NullString{String: string(filter.QueryType), Valid: true}
+	}
+	if filter.Failed != nil {
+		params.Failed = *filter.Failed
+	}
+	if filter.Search != "" {
+		params.Search = filter.Search
+	}
+	if filter.StartTime != nil {
+		params.StartTime = sql.NullTime{Time: filter.StartTime.UTC(), Valid: true}
+	}
+	if filter.EndTime != nil {
+		params.EndTime = sql.NullTime{Time: filter.EndTime.UTC(), Valid: true}
+	}
+
+	rows, err := db.queries.SearchQueryAuditLog(ctx, params)
+	if err != nil {
+		db.log.Error("failed to search query audit log in db", "error", err)
+		return nil, fmt.Errorf("error searching query audit log: %w", err)
+	}
+
+	return mapQueryAuditRows(rows), nil
+}
+
+// DeleteQueryAuditLogBefore removes audit entries created before the cutoff and
+// returns the number of entries deleted.
+func (db *DB) DeleteQueryAuditLogBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	db.log.Debug("deleting old query audit entries", "cutoff", cutoff)
+
+	deleted, err := db.queries.DeleteQueryAuditLogBefore(ctx, cutoff.UTC())
+	if err != nil {
+		db.log.Error("failed to delete old query audit entries", "error", err)
+		return 0, fmt.Errorf("error deleting old query audit entries: %w", err)
+	}
+	return deleted, nil
+}
+
+// mapQueryAuditRows converts sqlc QueryAuditLog rows to domain models.
+func mapQueryAuditRows(rows []sqlc.QueryAuditLog) []*models.QueryAuditEntry {
+	entries := make([]*models.QueryAuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := &models.QueryAuditEntry{
+			ID:           row.ID,
+			UserEmail:    row.UserEmail,
+			QueryType:    models.QueryAuditType(row.QueryType),
+			RawSQL:       row.RawSql,
+			FinalSQL:     row.FinalSql,
+			DurationMs:   row.DurationMs,
+			RowsReturned: int(row.RowsReturned),
+			Error:        row.Error,
+			CreatedAt:    row.CreatedAt,
+		}
+		if row.UserID.Valid {
+			userID := models.UserID(row.UserID.Int64)
+			entry.UserID = &userID
+		}
+		if row.ApiTokenID.Valid {
+			tokenID := int(row.ApiTokenID.Int64)
+			entry.APITokenID = &tokenID
+		}
+		if row.TeamID.Valid {
+			teamID := models.TeamID(row.TeamID.Int64)
+			entry.TeamID = &teamID
+		}
+		if row.SourceID.Valid {
+			sourceID := models.SourceID(row.SourceID.Int64)
+			entry.SourceID = &sourceID
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
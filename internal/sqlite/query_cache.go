@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Query result cache methods
+
+// GetQueryCacheEntry returns a cached query result that hasn't expired at now.
+func (db *DB) GetQueryCacheEntry(ctx context.Context, key string, now time.Time) (*models.QueryCacheEntry, error) {
+	row, err := db.queries.GetQueryCacheEntry(ctx, sqlc.GetQueryCacheEntryParams{
+		CacheKey:  key,
+		ExpiresAt: now.UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		db.log.Error("failed to get query cache entry", "error", err)
+		return nil, fmt.Errorf("error getting query cache entry: %w", err)
+	}
+	return &models.QueryCacheEntry{
+		Key:       row.CacheKey,
+		SourceID:  models.SourceID(row.SourceID),
+		Kind:      row.Kind,
+		Value:     []byte(row.Value),
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+	}, nil
+}
+
+// UpsertQueryCacheEntry stores a query result, replacing any previous result of the same query.
+func (db *DB) UpsertQueryCacheEntry(ctx context.Context, entry *models.QueryCacheEntry) error {
+	if err := db.queries.UpsertQueryCacheEntry(ctx, sqlc.UpsertQueryCacheEntryParams{
+		CacheKey:  entry.Key,
+		SourceID:  int64(entry.SourceID),
+		Kind:      entry.Kind,
+		Value:     string(entry.Value),
+		Size:      int64(len(entry.Value)),
+		CreatedAt: entry.CreatedAt.UTC(),
+		ExpiresAt: entry.ExpiresAt.UTC(),
+	}); err != nil {
+		db.log.Error("failed to upsert query cache entry", "error", err, "source_id", entry.SourceID)
+		return fmt.Errorf("error storing query cache entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteSourceQueryCacheEntries drops the cached query results of a source.
+func (db *DB) DeleteSourceQueryCacheEntries(ctx context.Context, sourceID models.SourceID) error {
+	if err := db.queries.DeleteSourceQueryCacheEntries(ctx, int64(sourceID)); err != nil {
+		db.log.Error("failed to delete source query cache entries", "error", err, "source_id", sourceID)
+		return fmt.Errorf("error deleting query cache entries: %w", err)
+	}
+	return nil
+}
+
+// PruneQueryCache drops expired query results, then the oldest results beyond maxEntries.
+// Returns the number of entries dropped.
+func (db *DB) PruneQueryCache(ctx context.Context, now time.Time, maxEntries int) (int64, error) {
+	expired, err := db.queries.DeleteExpiredQueryCacheEntries(ctx, now.UTC())
+	if err != nil {
+		db.log.Error("failed to delete expired query cache entries", "error", err)
+		return 0, fmt.Errorf("error deleting expired query cache entries: %w", err)
+	}
+	trimmed, err := db.queries.TrimQueryCacheEntries(ctx, int64(maxEntries))
+	if err != nil {
+		db.log.Error("failed to trim query cache entries", "error", err)
+		return expired, fmt.Errorf("error trimming query cache entries: %w", err)
+	}
+	return expired + trimmed, nil
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Source schema drift methods
+
+// GetSourceSchemaSnapshot returns the latest schema snapshot of a source, or ErrNotFound
+// when none has been taken yet.
+func (db *DB) GetSourceSchemaSnapshot(ctx context.Context, sourceID models.SourceID) (*models.SchemaSnapshot, error) {
+	row, err := db.queries.GetSourceSchemaSnapshot(ctx, int64(sourceID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		db.log.Error("failed to get source schema snapshot", "error", err, "source_id", sourceID)
+		return nil, fmt.Errorf("error getting source schema snapshot: %w", err)
+	}
+
+	snapshot := &models.SchemaSnapshot{
+		SourceID: models.SourceID(row.SourceID),
+		TakenAt:  row.TakenAt,
+	}
+	if err := json.Unmarshal([]byte(row.Columns), &snapshot.Columns); err != nil {
+		return nil, fmt.Errorf("error decoding schema snapshot columns: %w", err)
+	}
+	if err := json.Unmarshal([]byte(row.SortKeys), &snapshot.SortKeys); err != nil {
+		return nil, fmt.Errorf("error decoding schema snapshot sort keys: %w", err)
+	}
+	return snapshot, nil
+}
+
+// UpsertSourceSchemaSnapshot replaces the latest schema snapshot of a source.
+func (db *DB) UpsertSourceSchemaSnapshot(ctx context.Context, snapshot *models.SchemaSnapshot) error {
+	columns, err := json.Marshal(snapshot.Columns)
+	if err != nil {
+		return fmt.Errorf("error encoding schema snapshot columns: %w", err)
+	}
+	sortKeys, err := json.Marshal(snapshot.SortKeys)
+	if err != nil {
+		return fmt.Errorf("error encoding schema snapshot sort keys: %w", err)
+	}
+
+	err = db.queries.UpsertSourceSchemaSnapshot(ctx, sqlc.UpsertSourceSchemaSnapshotParams{
+		SourceID: int64(snapshot.SourceID),
+		Columns:  string(columns),
+		SortKeys: string(sortKeys),
+		TakenAt:  snapshot.TakenAt.UTC(),
+	})
+	if err != nil {
+		db.log.Error("failed to save source schema snapshot", "error", err, "source_id", snapshot.SourceID)
+		return fmt.Errorf("error saving source schema snapshot: %w", err)
+	}
+	return nil
+}
+
+// InsertSourceSchemaChange records a schema change detected for a source.
+func (db *DB) InsertSourceSchemaChange(ctx context.Context, change *models.SchemaChange) error {
+	err := db.queries.InsertSourceSchemaChange(ctx, sqlc.InsertSourceSchemaChangeParams{
+		SourceID:   int64(change.SourceID),
+		ChangeType: string(change.Type),
+		ColumnName: change.Column,
+		OldValue:   change.OldValue,
+		NewValue:   change.NewValue,
+		DetectedAt: change.DetectedAt.UTC(),
+	})
+	if err != nil {
+		db.log.Error("failed to insert source schema change", "error", err, "source_id", change.SourceID)
+		return fmt.Errorf("error inserting source schema change: %w", err)
+	}
+	return nil
+}
+
+// ListSourceSchemaChanges returns the latest schema changes of a source, newest first.
+func (db *DB) ListSourceSchemaChanges(ctx context.Context, sourceID models.SourceID, limit int) ([]*models.SchemaChange, error) {
+	rows, err := db.queries.ListSourceSchemaChanges(ctx, sqlc.ListSourceSchemaChangesParams{
+		SourceID: int64(sourceID),
+		Limit:    int64(limit),
+	})
+	if err != nil {
+		db.log.Error("failed to list source schema changes", "error", err, "source_id", sourceID)
+		return nil, fmt.Errorf("error listing source schema changes: %w", err)
+	}
+
+	changes := make([]*models.SchemaChange, 0, len(rows))
+	for _, row := range rows {
+		changes = append(changes, &models.SchemaChange{
+			ID:         row.ID,
+			SourceID:   models.SourceID(row.SourceID),
+			Type:       models.SchemaChangeType(row.ChangeType),
+			Column:     row.ColumnName,
+			OldValue:   row.OldValue,
+			NewValue:   row.NewValue,
+			DetectedAt: row.DetectedAt,
+		})
+	}
+	return changes, nil
+}
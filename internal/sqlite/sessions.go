@@ -113,3 +113,16 @@ func (db *DB) CountUserSessions(ctx context.Context, userID models.UserID) (int,
 	db.log.Debug("active user sessions counted", "user_id", userID, "count", int(count))
 	return int(count), nil
 }
+
+// DeleteExpiredSessions removes all sessions that expired before the given time and
+// returns the number deleted.
+func (db *DB) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	db.log.Debug("deleting expired session records", "before", before)
+
+	deleted, err := db.queries.DeleteExpiredSessions(ctx, before)
+	if err != nil {
+		db.log.Error("failed to delete expired sessions from db", "error", err)
+		return 0, fmt.Errorf("error deleting expired sessions: %w", err)
+	}
+	return deleted, nil
+}
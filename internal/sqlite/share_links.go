@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Share link methods
+
+// CreateShareLink inserts a new share link.
+// Populates the share link ID on the input model upon success.
+func (db *DB) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	db.log.Debug("creating share link", "team_id", link.TeamID, "source_id", link.SourceID, "mode", link.Mode)
+
+	id, err := db.queries.CreateShareLink(ctx, sqlc.CreateShareLinkParams{
+		Slug:           link.Slug,
+		TeamID:         int64(link.TeamID),
+		SourceID:       int64(link.SourceID),
+		CreatedBy:      int64(link.CreatedBy),
+		Title:          link.Title,
+		Mode:           string(link.Mode),
+		RawSql:         link.RawSQL,
+		RowLimit:       int64(link.Limit),
+		StartTime:      nullTime(link.StartTime),
+		EndTime:        nullTime(link.EndTime),
+		Snapshot:       sql.NullString{String: link.Snapshot, Valid: link.Snapshot != ""},
+		PasswordHash:   link.PasswordHash,
+		AllowAnonymous: boolToInt(link.AllowAnonymous),
+		ExpiresAt:      link.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		if IsUniqueConstraintError(err) {
+			return handleUniqueConstraintError(err, "share_links", "slug", link.Slug)
+		}
+		db.log.Error("failed to create share link in db", "error", err, "team_id", link.TeamID)
+		return fmt.Errorf("error creating share link: %w", err)
+	}
+
+	link.ID = int(id)
+	return nil
+}
+
+// GetShareLinkBySlug retrieves a share link, including its snapshot and password hash.
+func (db *DB) GetShareLinkBySlug(ctx context.Context, slug string) (*models.ShareLink, error) {
+	row, err := db.queries.GetShareLinkBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		db.log.Error("failed to get share link from db", "error", err)
+		return nil, fmt.Errorf("error getting share link: %w", err)
+	}
+
+	return &models.ShareLink{
+		ID:                 int(row.ID),
+		Slug:               row.Slug,
+		TeamID:             models.TeamID(row.TeamID),
+		SourceID:           models.SourceID(row.SourceID),
+		CreatedBy:          models.UserID(row.CreatedBy),
+		CreatedByEmail:     row.CreatedByEmail,
+		Title:              row.Title,
+		Mode:               models.ShareLinkMode(row.Mode),
+		RawSQL:             row.RawSql,
+		Limit:              int(row.RowLimit),
+		StartTime:          timeFromNull(row.StartTime),
+		EndTime:            timeFromNull(row.EndTime),
+		HasPassword:        row.PasswordHash != "",
+		AllowAnonymous:     row.AllowAnonymous != 0,
+		ViewCount:          int(row.ViewCount),
+		LastViewedAt:       timeFromNull(row.LastViewedAt),
+		ExpiresAt:          row.ExpiresAt,
+		RevokedAt:          timeFromNull(row.RevokedAt),
+		CreatedAt:          row.CreatedAt,
+		PasswordHash:       row.PasswordHash,
+		Snapshot:           row.Snapshot.String,
+		TeamAllowAnonymous: row.TeamAllowAnonymous != 0,
+	}, nil
+}
+
+// ListUserShareLinks retrieves the share links created by a user, newest first.
+func (db *DB) ListUserShareLinks(ctx context.Context, userID models.UserID) ([]*models.ShareLink, error) {
+	rows, err := db.queries.ListUserShareLinks(ctx, int64(userID))
+	if err != nil {
+		db.log.Error("failed to list user share links from db", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("error listing user share links: %w", err)
+	}
+
+	links := make([]*models.ShareLink, 0, len(rows))
+	for _, row := range rows {
+		links = append(links, mapShareLinkListRowToModel(row))
+	}
+	return links, nil
+}
+
+// ListTeamShareLinks retrieves the share links of a team, newest first.
+func (db *DB) ListTeamShareLinks(ctx context.Context, teamID models.TeamID) ([]*models.ShareLink, error) {
+	rows, err := db.queries.ListTeamShareLinks(ctx, int64(teamID))
+	if err != nil {
+		db.log.Error("failed to list team share links from db", "error", err, "team_id", teamID)
+		return nil, fmt.Errorf("error listing team share links: %w", err)
+	}
+
+	links := make([]*models.ShareLink, 0, len(rows))
+	for _, row := range rows {
+		// Both list queries select the same columns.
+		links = append(links, mapShareLinkListRowToModel(sqlc.ListUserShareLinksRow(row)))
+	}
+	return links, nil
+}
+
+// RevokeShareLink marks a share link as revoked. Returns ErrNotFound if it doesn't exist or
+// was already revoked.
+func (db *DB) RevokeShareLink(ctx context.Context, id int) error {
+	rows, err := db.queries.RevokeShareLink(ctx, int64(id))
+	if err != nil {
+		db.log.Error("failed to revoke share link in db", "error", err, "share_link_id", id)
+		return fmt.Errorf("error revoking share link: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordShareLinkView increments the view count of a share link.
+func (db *DB) RecordShareLinkView(ctx context.Context, id int) error {
+	if err := db.queries.RecordShareLinkView(ctx, int64(id)); err != nil {
+		return fmt.Errorf("error recording share link view: %w", err)
+	}
+	return nil
+}
+
+// mapShareLinkListRowToModel converts a share link list row to a model, without the snapshot.
+func mapShareLinkListRowToModel(row sqlc.ListUserShareLinksRow) *models.ShareLink {
+	return &models.ShareLink{
+		ID:             int(row.ID),
+		Slug:           row.Slug,
+		TeamID:         models.TeamID(row.TeamID),
+		SourceID:       models.SourceID(row.SourceID),
+		CreatedBy:      models.UserID(row.CreatedBy),
+		CreatedByEmail: row.CreatedByEmail,
+		Title:          row.Title,
+		Mode:           models.ShareLinkMode(row.Mode),
+		RawSQL:         row.RawSql,
+		Limit:          int(row.RowLimit),
+		StartTime:      timeFromNull(row.StartTime),
+		EndTime:        timeFromNull(row.EndTime),
+		HasPassword:    row.HasPassword != 0,
+		AllowAnonymous: row.AllowAnonymous != 0,
+		ViewCount:      int(row.ViewCount),
+		LastViewedAt:   timeFromNull(row.LastViewedAt),
+		ExpiresAt:      row.ExpiresAt,
+		RevokedAt:      timeFromNull(row.RevokedAt),
+		CreatedAt:      row.CreatedAt,
+	}
+}
+
+// nullTime converts an optional time to its SQL representation, in UTC.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
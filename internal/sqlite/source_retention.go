@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
+	"github.com/mr-karan/logchef/pkg/models"
+)
+
+// Source retention change methods
+
+// InsertSourceRetentionChange records a change of a source's retention.
+func (db *DB) InsertSourceRetentionChange(ctx context.Context, change *models.SourceRetentionChange) error {
+	params := sqlc.InsertSourceRetentionChangeParams{
+		SourceID:   int64(change.SourceID),
+		OldTtlDays: int64(change.OldTTLDays),
+		NewTtlDays: int64(change.NewTTLDays),
+		Status:     string(change.Status),
+		Statement:  change.Statement,
+		Error:      change.Error,
+		CreatedAt:  change.CreatedAt.UTC(),
+	}
+	if change.UserID != nil {
+		params.UserID = sql.NullInt64{Int64: int64(*change.UserID), Valid: true}
+	}
+
+	if err := db.queries.InsertSourceRetentionChange(ctx, params); err != nil {
+		db.log.Error("failed to insert source retention change", "error", err, "source_id", change.SourceID)
+		return fmt.Errorf("error inserting source retention change: %w", err)
+	}
+	return nil
+}
+
+// ListSourceRetentionChanges returns the latest retention changes of a source, newest first.
+func (db *DB) ListSourceRetentionChanges(ctx context.Context, sourceID models.SourceID, limit int) ([]*models.SourceRetentionChange, error) {
+	rows, err := db.queries.ListSourceRetentionChanges(ctx, sqlc.ListSourceRetentionChangesParams{
+		SourceID: int64(sourceID),
+		Limit:    int64(limit),
+	})
+	if err != nil {
+		db.log.Error("failed to list source retention changes", "error", err, "source_id", sourceID)
+		return nil, fmt.Errorf("error listing source retention changes: %w", err)
+	}
+
+	changes := make([]*models.SourceRetentionChange, 0, len(rows))
+	for _, row := range rows {
+		change := &models.SourceRetentionChange{
+			ID:         row.ID,
+			SourceID:   models.SourceID(row.SourceID),
+			OldTTLDays: int(row.OldTtlDays),
+			NewTTLDays: int(row.NewTtlDays),
+			Status:     models.RetentionChangeStatus(row.Status),
+			Statement:  row.Statement,
+			Error:      row.Error,
+			CreatedAt:  row.CreatedAt,
+		}
+		if row.UserID.Valid {
+			userID := models.UserID(row.UserID.Int64)
+			change.UserID = &userID
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -19,6 +20,11 @@ import (
 func (db *DB) CreateSource(ctx context.Context, source *models.Source) error {
 	db.log.Debug("creating source record", "name", source.Name, "database", source.Connection.Database, "table", source.Connection.TableName)
 
+	fieldRoles, severityMapping, err := encodeSourceFields(source)
+	if err != nil {
+		return err
+	}
+
 	// Map domain model to sqlc parameters.
 	params := sqlc.CreateSourceParams{
 		Name:              source.Name,
@@ -32,6 +38,8 @@ func (db *DB) CreateSource(ctx context.Context, source *models.Source) error {
 		TableName:         source.Connection.TableName,
 		Description:       sql.NullString{String: source.Description, Valid: source.Description != ""},
 		TtlDays:           int64(source.TTLDays),
+		FieldRoles:        fieldRoles,
+		SeverityMapping:   severityMapping,
 	}
 
 	// Execute the generated query.
@@ -143,6 +151,11 @@ func (db *DB) ListSources(ctx context.Context) ([]*models.Source, error) {
 func (db *DB) UpdateSource(ctx context.Context, source *models.Source) error {
 	db.log.Debug("updating source record", "source_id", source.ID, "name", source.Name)
 
+	fieldRoles, severityMapping, err := encodeSourceFields(source)
+	if err != nil {
+		return err
+	}
+
 	// Map domain model to sqlc parameters.
 	params := sqlc.UpdateSourceParams{
 		Name:              source.Name,
@@ -156,10 +169,12 @@ func (db *DB) UpdateSource(ctx context.Context, source *models.Source) error {
 		TableName:         source.Connection.TableName,
 		Description:       sql.NullString{String: source.Description, Valid: source.Description != ""},
 		TtlDays:           int64(source.TTLDays),
+		FieldRoles:        fieldRoles,
+		SeverityMapping:   severityMapping,
 		ID:                int64(source.ID),
 	}
 
-	err := db.queries.UpdateSource(ctx, params)
+	err = db.queries.UpdateSource(ctx, params)
 	if err != nil {
 		db.log.Error("failed to update source record in db", "error", err, "source_id", source.ID)
 		// TODO: Check for specific errors like not found? The sqlc exec doesn't return ErrNoRows usually.
@@ -187,3 +202,20 @@ func (db *DB) DeleteSource(ctx context.Context, id models.SourceID) error {
 	db.log.Debug("source record deleted successfully", "source_id", id)
 	return nil
 }
+
+// encodeSourceFields encodes the field roles and severity mapping of a source as JSON for storage.
+func encodeSourceFields(source *models.Source) (string, string, error) {
+	fieldRoles, err := json.Marshal(source.FieldRoles)
+	if err != nil {
+		return "", "", fmt.Errorf("error encoding source field roles: %w", err)
+	}
+	mapping := source.SeverityMapping
+	if mapping == nil {
+		mapping = models.SeverityMapping{}
+	}
+	severityMapping, err := json.Marshal(mapping)
+	if err != nil {
+		return "", "", fmt.Errorf("error encoding source severity mapping: %w", err)
+	}
+	return string(fieldRoles), string(severityMapping), nil
+}
@@ -27,6 +27,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.addTeamMemberStmt, err = db.PrepareContext(ctx, addTeamMember); err != nil {
 		return nil, fmt.Errorf("error preparing query AddTeamMember: %w", err)
 	}
+	if q.addTeamQueryTagStmt, err = db.PrepareContext(ctx, addTeamQueryTag); err != nil {
+		return nil, fmt.Errorf("error preparing query AddTeamQueryTag: %w", err)
+	}
 	if q.addTeamSourceStmt, err = db.PrepareContext(ctx, addTeamSource); err != nil {
 		return nil, fmt.Errorf("error preparing query AddTeamSource: %w", err)
 	}
@@ -42,12 +45,24 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createShareLinkStmt, err = db.PrepareContext(ctx, createShareLink); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateShareLink: %w", err)
+	}
 	if q.createSourceStmt, err = db.PrepareContext(ctx, createSource); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSource: %w", err)
 	}
 	if q.createTeamStmt, err = db.PrepareContext(ctx, createTeam); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTeam: %w", err)
 	}
+	if q.createTeamCollectionFolderStmt, err = db.PrepareContext(ctx, createTeamCollectionFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTeamCollectionFolder: %w", err)
+	}
+	if q.createTeamDashboardStmt, err = db.PrepareContext(ctx, createTeamDashboard); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTeamDashboard: %w", err)
+	}
+	if q.createTeamQueryRevisionStmt, err = db.PrepareContext(ctx, createTeamQueryRevision); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTeamQueryRevision: %w", err)
+	}
 	if q.createTeamSourceQueryStmt, err = db.PrepareContext(ctx, createTeamSourceQuery); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTeamSourceQuery: %w", err)
 	}
@@ -57,66 +72,147 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteAPITokenStmt, err = db.PrepareContext(ctx, deleteAPIToken); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAPIToken: %w", err)
 	}
+	if q.deleteColumnPolicyStmt, err = db.PrepareContext(ctx, deleteColumnPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteColumnPolicy: %w", err)
+	}
 	if q.deleteExpiredAPITokensStmt, err = db.PrepareContext(ctx, deleteExpiredAPITokens); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteExpiredAPITokens: %w", err)
 	}
+	if q.deleteExpiredQueryCacheEntriesStmt, err = db.PrepareContext(ctx, deleteExpiredQueryCacheEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredQueryCacheEntries: %w", err)
+	}
+	if q.deleteExpiredSessionsStmt, err = db.PrepareContext(ctx, deleteExpiredSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredSessions: %w", err)
+	}
+	if q.deleteQueryAuditLogBeforeStmt, err = db.PrepareContext(ctx, deleteQueryAuditLogBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteQueryAuditLogBefore: %w", err)
+	}
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
 	}
 	if q.deleteSourceStmt, err = db.PrepareContext(ctx, deleteSource); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSource: %w", err)
 	}
+	if q.deleteSourceQueryCacheEntriesStmt, err = db.PrepareContext(ctx, deleteSourceQueryCacheEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSourceQueryCacheEntries: %w", err)
+	}
 	if q.deleteTeamStmt, err = db.PrepareContext(ctx, deleteTeam); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteTeam: %w", err)
 	}
+	if q.deleteTeamCollectionFolderStmt, err = db.PrepareContext(ctx, deleteTeamCollectionFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTeamCollectionFolder: %w", err)
+	}
+	if q.deleteTeamDashboardStmt, err = db.PrepareContext(ctx, deleteTeamDashboard); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTeamDashboard: %w", err)
+	}
+	if q.deleteTeamQueryTagsStmt, err = db.PrepareContext(ctx, deleteTeamQueryTags); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTeamQueryTags: %w", err)
+	}
+	if q.deleteTeamSourceColumnPoliciesStmt, err = db.PrepareContext(ctx, deleteTeamSourceColumnPolicies); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteTeamSourceColumnPolicies: %w", err)
+	}
 	if q.deleteTeamSourceQueryStmt, err = db.PrepareContext(ctx, deleteTeamSourceQuery); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteTeamSourceQuery: %w", err)
 	}
 	if q.deleteUserStmt, err = db.PrepareContext(ctx, deleteUser); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteUser: %w", err)
 	}
+	if q.deleteUserQueryFavoriteStmt, err = db.PrepareContext(ctx, deleteUserQueryFavorite); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserQueryFavorite: %w", err)
+	}
 	if q.deleteUserSessionsStmt, err = db.PrepareContext(ctx, deleteUserSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteUserSessions: %w", err)
 	}
+	if q.disableUserAPITokensStmt, err = db.PrepareContext(ctx, disableUserAPITokens); err != nil {
+		return nil, fmt.Errorf("error preparing query DisableUserAPITokens: %w", err)
+	}
 	if q.getAPITokenStmt, err = db.PrepareContext(ctx, getAPIToken); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAPIToken: %w", err)
 	}
 	if q.getAPITokenByHashStmt, err = db.PrepareContext(ctx, getAPITokenByHash); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAPITokenByHash: %w", err)
 	}
+	if q.getColumnPolicyStmt, err = db.PrepareContext(ctx, getColumnPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query GetColumnPolicy: %w", err)
+	}
+	if q.getQueryCacheEntryStmt, err = db.PrepareContext(ctx, getQueryCacheEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetQueryCacheEntry: %w", err)
+	}
 	if q.getSessionStmt, err = db.PrepareContext(ctx, getSession); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSession: %w", err)
 	}
+	if q.getShareLinkBySlugStmt, err = db.PrepareContext(ctx, getShareLinkBySlug); err != nil {
+		return nil, fmt.Errorf("error preparing query GetShareLinkBySlug: %w", err)
+	}
 	if q.getSourceStmt, err = db.PrepareContext(ctx, getSource); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSource: %w", err)
 	}
 	if q.getSourceByNameStmt, err = db.PrepareContext(ctx, getSourceByName); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSourceByName: %w", err)
 	}
+	if q.getSourceSchemaSnapshotStmt, err = db.PrepareContext(ctx, getSourceSchemaSnapshot); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSourceSchemaSnapshot: %w", err)
+	}
 	if q.getTeamStmt, err = db.PrepareContext(ctx, getTeam); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTeam: %w", err)
 	}
 	if q.getTeamByNameStmt, err = db.PrepareContext(ctx, getTeamByName); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTeamByName: %w", err)
 	}
+	if q.getTeamCollectionFolderStmt, err = db.PrepareContext(ctx, getTeamCollectionFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTeamCollectionFolder: %w", err)
+	}
+	if q.getTeamDashboardStmt, err = db.PrepareContext(ctx, getTeamDashboard); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTeamDashboard: %w", err)
+	}
 	if q.getTeamMemberStmt, err = db.PrepareContext(ctx, getTeamMember); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTeamMember: %w", err)
 	}
+	if q.getTeamQueryRevisionStmt, err = db.PrepareContext(ctx, getTeamQueryRevision); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTeamQueryRevision: %w", err)
+	}
 	if q.getTeamSourceQueryStmt, err = db.PrepareContext(ctx, getTeamSourceQuery); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTeamSourceQuery: %w", err)
 	}
+	if q.getTeamSourceRowFilterStmt, err = db.PrepareContext(ctx, getTeamSourceRowFilter); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTeamSourceRowFilter: %w", err)
+	}
 	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
 	}
 	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
 	}
+	if q.insertOIDCSyncEventStmt, err = db.PrepareContext(ctx, insertOIDCSyncEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertOIDCSyncEvent: %w", err)
+	}
+	if q.insertQueryAuditEntryStmt, err = db.PrepareContext(ctx, insertQueryAuditEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertQueryAuditEntry: %w", err)
+	}
+	if q.insertSourceRetentionChangeStmt, err = db.PrepareContext(ctx, insertSourceRetentionChange); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertSourceRetentionChange: %w", err)
+	}
+	if q.insertSourceSchemaChangeStmt, err = db.PrepareContext(ctx, insertSourceSchemaChange); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertSourceSchemaChange: %w", err)
+	}
 	if q.listAPITokensForUserStmt, err = db.PrepareContext(ctx, listAPITokensForUser); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAPITokensForUser: %w", err)
 	}
+	if q.listColumnPoliciesStmt, err = db.PrepareContext(ctx, listColumnPolicies); err != nil {
+		return nil, fmt.Errorf("error preparing query ListColumnPolicies: %w", err)
+	}
+	if q.listQueriesBySourceStmt, err = db.PrepareContext(ctx, listQueriesBySource); err != nil {
+		return nil, fmt.Errorf("error preparing query ListQueriesBySource: %w", err)
+	}
 	if q.listQueriesByTeamAndSourceStmt, err = db.PrepareContext(ctx, listQueriesByTeamAndSource); err != nil {
 		return nil, fmt.Errorf("error preparing query ListQueriesByTeamAndSource: %w", err)
 	}
+	if q.listSourceRetentionChangesStmt, err = db.PrepareContext(ctx, listSourceRetentionChanges); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSourceRetentionChanges: %w", err)
+	}
+	if q.listSourceSchemaChangesStmt, err = db.PrepareContext(ctx, listSourceSchemaChanges); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSourceSchemaChanges: %w", err)
+	}
 	if q.listSourceTeamsStmt, err = db.PrepareContext(ctx, listSourceTeams); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSourceTeams: %w", err)
 	}
@@ -126,36 +222,105 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listSourcesForUserStmt, err = db.PrepareContext(ctx, listSourcesForUser); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSourcesForUser: %w", err)
 	}
+	if q.listTeamCollectionFoldersStmt, err = db.PrepareContext(ctx, listTeamCollectionFolders); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTeamCollectionFolders: %w", err)
+	}
+	if q.listTeamDashboardsStmt, err = db.PrepareContext(ctx, listTeamDashboards); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTeamDashboards: %w", err)
+	}
 	if q.listTeamMembersStmt, err = db.PrepareContext(ctx, listTeamMembers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTeamMembers: %w", err)
 	}
 	if q.listTeamMembersWithDetailsStmt, err = db.PrepareContext(ctx, listTeamMembersWithDetails); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTeamMembersWithDetails: %w", err)
 	}
+	if q.listTeamQueryRevisionsStmt, err = db.PrepareContext(ctx, listTeamQueryRevisions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTeamQueryRevisions: %w", err)
+	}
+	if q.listTeamQueryTagsStmt, err = db.PrepareContext(ctx, listTeamQueryTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTeamQueryTags: %w", err)
+	}
+	if q.listTeamShareLinksStmt, err = db.PrepareContext(ctx, listTeamShareLinks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTeamShareLinks: %w", err)
+	}
 	if q.listTeamSourcesStmt, err = db.PrepareContext(ctx, listTeamSources); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTeamSources: %w", err)
 	}
+	if q.listTeamTagsStmt, err = db.PrepareContext(ctx, listTeamTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTeamTags: %w", err)
+	}
 	if q.listTeamsStmt, err = db.PrepareContext(ctx, listTeams); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTeams: %w", err)
 	}
 	if q.listTeamsForUserStmt, err = db.PrepareContext(ctx, listTeamsForUser); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTeamsForUser: %w", err)
 	}
+	if q.listUserQueryHistoryStmt, err = db.PrepareContext(ctx, listUserQueryHistory); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserQueryHistory: %w", err)
+	}
+	if q.listUserShareLinksStmt, err = db.PrepareContext(ctx, listUserShareLinks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserShareLinks: %w", err)
+	}
 	if q.listUserTeamsStmt, err = db.PrepareContext(ctx, listUserTeams); err != nil {
 		return nil, fmt.Errorf("error preparing query ListUserTeams: %w", err)
 	}
 	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
 	}
+	if q.markTeamQueryUsedStmt, err = db.PrepareContext(ctx, markTeamQueryUsed); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkTeamQueryUsed: %w", err)
+	}
+	if q.recordShareLinkViewStmt, err = db.PrepareContext(ctx, recordShareLinkView); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordShareLinkView: %w", err)
+	}
 	if q.removeTeamMemberStmt, err = db.PrepareContext(ctx, removeTeamMember); err != nil {
 		return nil, fmt.Errorf("error preparing query RemoveTeamMember: %w", err)
 	}
 	if q.removeTeamSourceStmt, err = db.PrepareContext(ctx, removeTeamSource); err != nil {
 		return nil, fmt.Errorf("error preparing query RemoveTeamSource: %w", err)
 	}
+	if q.revokeShareLinkStmt, err = db.PrepareContext(ctx, revokeShareLink); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeShareLink: %w", err)
+	}
+	if q.searchOIDCSyncEventsStmt, err = db.PrepareContext(ctx, searchOIDCSyncEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchOIDCSyncEvents: %w", err)
+	}
+	if q.searchQueryAuditLogStmt, err = db.PrepareContext(ctx, searchQueryAuditLog); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchQueryAuditLog: %w", err)
+	}
+	if q.searchTeamQueriesStmt, err = db.PrepareContext(ctx, searchTeamQueries); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchTeamQueries: %w", err)
+	}
+	if q.setSourceProvisionedStmt, err = db.PrepareContext(ctx, setSourceProvisioned); err != nil {
+		return nil, fmt.Errorf("error preparing query SetSourceProvisioned: %w", err)
+	}
+	if q.setTeamMemberOIDCSyncedStmt, err = db.PrepareContext(ctx, setTeamMemberOIDCSynced); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTeamMemberOIDCSynced: %w", err)
+	}
+	if q.setTeamMemberProvisionedStmt, err = db.PrepareContext(ctx, setTeamMemberProvisioned); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTeamMemberProvisioned: %w", err)
+	}
+	if q.setTeamProvisionedStmt, err = db.PrepareContext(ctx, setTeamProvisioned); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTeamProvisioned: %w", err)
+	}
+	if q.setTeamQueryFolderStmt, err = db.PrepareContext(ctx, setTeamQueryFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTeamQueryFolder: %w", err)
+	}
+	if q.setTeamQueryProvisionedStmt, err = db.PrepareContext(ctx, setTeamQueryProvisioned); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTeamQueryProvisioned: %w", err)
+	}
+	if q.setUserOIDCAdminStmt, err = db.PrepareContext(ctx, setUserOIDCAdmin); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserOIDCAdmin: %w", err)
+	}
+	if q.setUserProvisionedStmt, err = db.PrepareContext(ctx, setUserProvisioned); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserProvisioned: %w", err)
+	}
 	if q.teamHasSourceStmt, err = db.PrepareContext(ctx, teamHasSource); err != nil {
 		return nil, fmt.Errorf("error preparing query TeamHasSource: %w", err)
 	}
+	if q.trimQueryCacheEntriesStmt, err = db.PrepareContext(ctx, trimQueryCacheEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query TrimQueryCacheEntries: %w", err)
+	}
 	if q.updateAPITokenLastUsedStmt, err = db.PrepareContext(ctx, updateAPITokenLastUsed); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateAPITokenLastUsed: %w", err)
 	}
@@ -165,15 +330,36 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.updateTeamStmt, err = db.PrepareContext(ctx, updateTeam); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateTeam: %w", err)
 	}
+	if q.updateTeamCollectionFolderStmt, err = db.PrepareContext(ctx, updateTeamCollectionFolder); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateTeamCollectionFolder: %w", err)
+	}
+	if q.updateTeamDashboardStmt, err = db.PrepareContext(ctx, updateTeamDashboard); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateTeamDashboard: %w", err)
+	}
 	if q.updateTeamMemberRoleStmt, err = db.PrepareContext(ctx, updateTeamMemberRole); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateTeamMemberRole: %w", err)
 	}
 	if q.updateTeamSourceQueryStmt, err = db.PrepareContext(ctx, updateTeamSourceQuery); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateTeamSourceQuery: %w", err)
 	}
+	if q.updateTeamSourceRowFilterStmt, err = db.PrepareContext(ctx, updateTeamSourceRowFilter); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateTeamSourceRowFilter: %w", err)
+	}
 	if q.updateUserStmt, err = db.PrepareContext(ctx, updateUser); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateUser: %w", err)
 	}
+	if q.upsertColumnPolicyStmt, err = db.PrepareContext(ctx, upsertColumnPolicy); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertColumnPolicy: %w", err)
+	}
+	if q.upsertQueryCacheEntryStmt, err = db.PrepareContext(ctx, upsertQueryCacheEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertQueryCacheEntry: %w", err)
+	}
+	if q.upsertSourceSchemaSnapshotStmt, err = db.PrepareContext(ctx, upsertSourceSchemaSnapshot); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertSourceSchemaSnapshot: %w", err)
+	}
+	if q.upsertUserQueryFavoriteStmt, err = db.PrepareContext(ctx, upsertUserQueryFavorite); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertUserQueryFavorite: %w", err)
+	}
 	if q.userHasSourceAccessStmt, err = db.PrepareContext(ctx, userHasSourceAccess); err != nil {
 		return nil, fmt.Errorf("error preparing query UserHasSourceAccess: %w", err)
 	}
@@ -187,6 +373,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing addTeamMemberStmt: %w", cerr)
 		}
 	}
+	if q.addTeamQueryTagStmt != nil {
+		if cerr := q.addTeamQueryTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addTeamQueryTagStmt: %w", cerr)
+		}
+	}
 	if q.addTeamSourceStmt != nil {
 		if cerr := q.addTeamSourceStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing addTeamSourceStmt: %w", cerr)
@@ -212,6 +403,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createShareLinkStmt != nil {
+		if cerr := q.createShareLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createShareLinkStmt: %w", cerr)
+		}
+	}
 	if q.createSourceStmt != nil {
 		if cerr := q.createSourceStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSourceStmt: %w", cerr)
@@ -222,6 +418,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createTeamStmt: %w", cerr)
 		}
 	}
+	if q.createTeamCollectionFolderStmt != nil {
+		if cerr := q.createTeamCollectionFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTeamCollectionFolderStmt: %w", cerr)
+		}
+	}
+	if q.createTeamDashboardStmt != nil {
+		if cerr := q.createTeamDashboardStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTeamDashboardStmt: %w", cerr)
+		}
+	}
+	if q.createTeamQueryRevisionStmt != nil {
+		if cerr := q.createTeamQueryRevisionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTeamQueryRevisionStmt: %w", cerr)
+		}
+	}
 	if q.createTeamSourceQueryStmt != nil {
 		if cerr := q.createTeamSourceQueryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createTeamSourceQueryStmt: %w", cerr)
@@ -237,11 +448,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteAPITokenStmt: %w", cerr)
 		}
 	}
+	if q.deleteColumnPolicyStmt != nil {
+		if cerr := q.deleteColumnPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteColumnPolicyStmt: %w", cerr)
+		}
+	}
 	if q.deleteExpiredAPITokensStmt != nil {
 		if cerr := q.deleteExpiredAPITokensStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteExpiredAPITokensStmt: %w", cerr)
 		}
 	}
+	if q.deleteExpiredQueryCacheEntriesStmt != nil {
+		if cerr := q.deleteExpiredQueryCacheEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredQueryCacheEntriesStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredSessionsStmt != nil {
+		if cerr := q.deleteExpiredSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteQueryAuditLogBeforeStmt != nil {
+		if cerr := q.deleteQueryAuditLogBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteQueryAuditLogBeforeStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionStmt != nil {
 		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
@@ -252,11 +483,36 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSourceStmt: %w", cerr)
 		}
 	}
+	if q.deleteSourceQueryCacheEntriesStmt != nil {
+		if cerr := q.deleteSourceQueryCacheEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSourceQueryCacheEntriesStmt: %w", cerr)
+		}
+	}
 	if q.deleteTeamStmt != nil {
 		if cerr := q.deleteTeamStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteTeamStmt: %w", cerr)
 		}
 	}
+	if q.deleteTeamCollectionFolderStmt != nil {
+		if cerr := q.deleteTeamCollectionFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTeamCollectionFolderStmt: %w", cerr)
+		}
+	}
+	if q.deleteTeamDashboardStmt != nil {
+		if cerr := q.deleteTeamDashboardStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTeamDashboardStmt: %w", cerr)
+		}
+	}
+	if q.deleteTeamQueryTagsStmt != nil {
+		if cerr := q.deleteTeamQueryTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTeamQueryTagsStmt: %w", cerr)
+		}
+	}
+	if q.deleteTeamSourceColumnPoliciesStmt != nil {
+		if cerr := q.deleteTeamSourceColumnPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteTeamSourceColumnPoliciesStmt: %w", cerr)
+		}
+	}
 	if q.deleteTeamSourceQueryStmt != nil {
 		if cerr := q.deleteTeamSourceQueryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteTeamSourceQueryStmt: %w", cerr)
@@ -267,11 +523,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteUserStmt: %w", cerr)
 		}
 	}
+	if q.deleteUserQueryFavoriteStmt != nil {
+		if cerr := q.deleteUserQueryFavoriteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserQueryFavoriteStmt: %w", cerr)
+		}
+	}
 	if q.deleteUserSessionsStmt != nil {
 		if cerr := q.deleteUserSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteUserSessionsStmt: %w", cerr)
 		}
 	}
+	if q.disableUserAPITokensStmt != nil {
+		if cerr := q.disableUserAPITokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing disableUserAPITokensStmt: %w", cerr)
+		}
+	}
 	if q.getAPITokenStmt != nil {
 		if cerr := q.getAPITokenStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAPITokenStmt: %w", cerr)
@@ -282,11 +548,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getAPITokenByHashStmt: %w", cerr)
 		}
 	}
+	if q.getColumnPolicyStmt != nil {
+		if cerr := q.getColumnPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getColumnPolicyStmt: %w", cerr)
+		}
+	}
+	if q.getQueryCacheEntryStmt != nil {
+		if cerr := q.getQueryCacheEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getQueryCacheEntryStmt: %w", cerr)
+		}
+	}
 	if q.getSessionStmt != nil {
 		if cerr := q.getSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSessionStmt: %w", cerr)
 		}
 	}
+	if q.getShareLinkBySlugStmt != nil {
+		if cerr := q.getShareLinkBySlugStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getShareLinkBySlugStmt: %w", cerr)
+		}
+	}
 	if q.getSourceStmt != nil {
 		if cerr := q.getSourceStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSourceStmt: %w", cerr)
@@ -297,6 +578,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSourceByNameStmt: %w", cerr)
 		}
 	}
+	if q.getSourceSchemaSnapshotStmt != nil {
+		if cerr := q.getSourceSchemaSnapshotStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSourceSchemaSnapshotStmt: %w", cerr)
+		}
+	}
 	if q.getTeamStmt != nil {
 		if cerr := q.getTeamStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTeamStmt: %w", cerr)
@@ -307,16 +593,36 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getTeamByNameStmt: %w", cerr)
 		}
 	}
+	if q.getTeamCollectionFolderStmt != nil {
+		if cerr := q.getTeamCollectionFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTeamCollectionFolderStmt: %w", cerr)
+		}
+	}
+	if q.getTeamDashboardStmt != nil {
+		if cerr := q.getTeamDashboardStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTeamDashboardStmt: %w", cerr)
+		}
+	}
 	if q.getTeamMemberStmt != nil {
 		if cerr := q.getTeamMemberStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTeamMemberStmt: %w", cerr)
 		}
 	}
+	if q.getTeamQueryRevisionStmt != nil {
+		if cerr := q.getTeamQueryRevisionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTeamQueryRevisionStmt: %w", cerr)
+		}
+	}
 	if q.getTeamSourceQueryStmt != nil {
 		if cerr := q.getTeamSourceQueryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTeamSourceQueryStmt: %w", cerr)
 		}
 	}
+	if q.getTeamSourceRowFilterStmt != nil {
+		if cerr := q.getTeamSourceRowFilterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTeamSourceRowFilterStmt: %w", cerr)
+		}
+	}
 	if q.getUserStmt != nil {
 		if cerr := q.getUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
@@ -327,16 +633,56 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
 		}
 	}
+	if q.insertOIDCSyncEventStmt != nil {
+		if cerr := q.insertOIDCSyncEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertOIDCSyncEventStmt: %w", cerr)
+		}
+	}
+	if q.insertQueryAuditEntryStmt != nil {
+		if cerr := q.insertQueryAuditEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertQueryAuditEntryStmt: %w", cerr)
+		}
+	}
+	if q.insertSourceRetentionChangeStmt != nil {
+		if cerr := q.insertSourceRetentionChangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertSourceRetentionChangeStmt: %w", cerr)
+		}
+	}
+	if q.insertSourceSchemaChangeStmt != nil {
+		if cerr := q.insertSourceSchemaChangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertSourceSchemaChangeStmt: %w", cerr)
+		}
+	}
 	if q.listAPITokensForUserStmt != nil {
 		if cerr := q.listAPITokensForUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listAPITokensForUserStmt: %w", cerr)
 		}
 	}
+	if q.listColumnPoliciesStmt != nil {
+		if cerr := q.listColumnPoliciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listColumnPoliciesStmt: %w", cerr)
+		}
+	}
+	if q.listQueriesBySourceStmt != nil {
+		if cerr := q.listQueriesBySourceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listQueriesBySourceStmt: %w", cerr)
+		}
+	}
 	if q.listQueriesByTeamAndSourceStmt != nil {
 		if cerr := q.listQueriesByTeamAndSourceStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listQueriesByTeamAndSourceStmt: %w", cerr)
 		}
 	}
+	if q.listSourceRetentionChangesStmt != nil {
+		if cerr := q.listSourceRetentionChangesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSourceRetentionChangesStmt: %w", cerr)
+		}
+	}
+	if q.listSourceSchemaChangesStmt != nil {
+		if cerr := q.listSourceSchemaChangesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSourceSchemaChangesStmt: %w", cerr)
+		}
+	}
 	if q.listSourceTeamsStmt != nil {
 		if cerr := q.listSourceTeamsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listSourceTeamsStmt: %w", cerr)
@@ -352,6 +698,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listSourcesForUserStmt: %w", cerr)
 		}
 	}
+	if q.listTeamCollectionFoldersStmt != nil {
+		if cerr := q.listTeamCollectionFoldersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTeamCollectionFoldersStmt: %w", cerr)
+		}
+	}
+	if q.listTeamDashboardsStmt != nil {
+		if cerr := q.listTeamDashboardsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTeamDashboardsStmt: %w", cerr)
+		}
+	}
 	if q.listTeamMembersStmt != nil {
 		if cerr := q.listTeamMembersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTeamMembersStmt: %w", cerr)
@@ -362,11 +718,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listTeamMembersWithDetailsStmt: %w", cerr)
 		}
 	}
+	if q.listTeamQueryRevisionsStmt != nil {
+		if cerr := q.listTeamQueryRevisionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTeamQueryRevisionsStmt: %w", cerr)
+		}
+	}
+	if q.listTeamQueryTagsStmt != nil {
+		if cerr := q.listTeamQueryTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTeamQueryTagsStmt: %w", cerr)
+		}
+	}
+	if q.listTeamShareLinksStmt != nil {
+		if cerr := q.listTeamShareLinksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTeamShareLinksStmt: %w", cerr)
+		}
+	}
 	if q.listTeamSourcesStmt != nil {
 		if cerr := q.listTeamSourcesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTeamSourcesStmt: %w", cerr)
 		}
 	}
+	if q.listTeamTagsStmt != nil {
+		if cerr := q.listTeamTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTeamTagsStmt: %w", cerr)
+		}
+	}
 	if q.listTeamsStmt != nil {
 		if cerr := q.listTeamsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTeamsStmt: %w", cerr)
@@ -377,6 +753,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listTeamsForUserStmt: %w", cerr)
 		}
 	}
+	if q.listUserQueryHistoryStmt != nil {
+		if cerr := q.listUserQueryHistoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserQueryHistoryStmt: %w", cerr)
+		}
+	}
+	if q.listUserShareLinksStmt != nil {
+		if cerr := q.listUserShareLinksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserShareLinksStmt: %w", cerr)
+		}
+	}
 	if q.listUserTeamsStmt != nil {
 		if cerr := q.listUserTeamsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listUserTeamsStmt: %w", cerr)
@@ -387,6 +773,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
 		}
 	}
+	if q.markTeamQueryUsedStmt != nil {
+		if cerr := q.markTeamQueryUsedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markTeamQueryUsedStmt: %w", cerr)
+		}
+	}
+	if q.recordShareLinkViewStmt != nil {
+		if cerr := q.recordShareLinkViewStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordShareLinkViewStmt: %w", cerr)
+		}
+	}
 	if q.removeTeamMemberStmt != nil {
 		if cerr := q.removeTeamMemberStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing removeTeamMemberStmt: %w", cerr)
@@ -397,11 +793,76 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing removeTeamSourceStmt: %w", cerr)
 		}
 	}
+	if q.revokeShareLinkStmt != nil {
+		if cerr := q.revokeShareLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeShareLinkStmt: %w", cerr)
+		}
+	}
+	if q.searchOIDCSyncEventsStmt != nil {
+		if cerr := q.searchOIDCSyncEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchOIDCSyncEventsStmt: %w", cerr)
+		}
+	}
+	if q.searchQueryAuditLogStmt != nil {
+		if cerr := q.searchQueryAuditLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchQueryAuditLogStmt: %w", cerr)
+		}
+	}
+	if q.searchTeamQueriesStmt != nil {
+		if cerr := q.searchTeamQueriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchTeamQueriesStmt: %w", cerr)
+		}
+	}
+	if q.setSourceProvisionedStmt != nil {
+		if cerr := q.setSourceProvisionedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setSourceProvisionedStmt: %w", cerr)
+		}
+	}
+	if q.setTeamMemberOIDCSyncedStmt != nil {
+		if cerr := q.setTeamMemberOIDCSyncedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTeamMemberOIDCSyncedStmt: %w", cerr)
+		}
+	}
+	if q.setTeamMemberProvisionedStmt != nil {
+		if cerr := q.setTeamMemberProvisionedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTeamMemberProvisionedStmt: %w", cerr)
+		}
+	}
+	if q.setTeamProvisionedStmt != nil {
+		if cerr := q.setTeamProvisionedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTeamProvisionedStmt: %w", cerr)
+		}
+	}
+	if q.setTeamQueryFolderStmt != nil {
+		if cerr := q.setTeamQueryFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTeamQueryFolderStmt: %w", cerr)
+		}
+	}
+	if q.setTeamQueryProvisionedStmt != nil {
+		if cerr := q.setTeamQueryProvisionedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTeamQueryProvisionedStmt: %w", cerr)
+		}
+	}
+	if q.setUserOIDCAdminStmt != nil {
+		if cerr := q.setUserOIDCAdminStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserOIDCAdminStmt: %w", cerr)
+		}
+	}
+	if q.setUserProvisionedStmt != nil {
+		if cerr := q.setUserProvisionedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserProvisionedStmt: %w", cerr)
+		}
+	}
 	if q.teamHasSourceStmt != nil {
 		if cerr := q.teamHasSourceStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing teamHasSourceStmt: %w", cerr)
 		}
 	}
+	if q.trimQueryCacheEntriesStmt != nil {
+		if cerr := q.trimQueryCacheEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing trimQueryCacheEntriesStmt: %w", cerr)
+		}
+	}
 	if q.updateAPITokenLastUsedStmt != nil {
 		if cerr := q.updateAPITokenLastUsedStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateAPITokenLastUsedStmt: %w", cerr)
@@ -417,6 +878,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateTeamStmt: %w", cerr)
 		}
 	}
+	if q.updateTeamCollectionFolderStmt != nil {
+		if cerr := q.updateTeamCollectionFolderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateTeamCollectionFolderStmt: %w", cerr)
+		}
+	}
+	if q.updateTeamDashboardStmt != nil {
+		if cerr := q.updateTeamDashboardStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateTeamDashboardStmt: %w", cerr)
+		}
+	}
 	if q.updateTeamMemberRoleStmt != nil {
 		if cerr := q.updateTeamMemberRoleStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateTeamMemberRoleStmt: %w", cerr)
@@ -427,11 +898,36 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateTeamSourceQueryStmt: %w", cerr)
 		}
 	}
+	if q.updateTeamSourceRowFilterStmt != nil {
+		if cerr := q.updateTeamSourceRowFilterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateTeamSourceRowFilterStmt: %w", cerr)
+		}
+	}
 	if q.updateUserStmt != nil {
 		if cerr := q.updateUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateUserStmt: %w", cerr)
 		}
 	}
+	if q.upsertColumnPolicyStmt != nil {
+		if cerr := q.upsertColumnPolicyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertColumnPolicyStmt: %w", cerr)
+		}
+	}
+	if q.upsertQueryCacheEntryStmt != nil {
+		if cerr := q.upsertQueryCacheEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertQueryCacheEntryStmt: %w", cerr)
+		}
+	}
+	if q.upsertSourceSchemaSnapshotStmt != nil {
+		if cerr := q.upsertSourceSchemaSnapshotStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertSourceSchemaSnapshotStmt: %w", cerr)
+		}
+	}
+	if q.upsertUserQueryFavoriteStmt != nil {
+		if cerr := q.upsertUserQueryFavoriteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertUserQueryFavoriteStmt: %w", cerr)
+		}
+	}
 	if q.userHasSourceAccessStmt != nil {
 		if cerr := q.userHasSourceAccessStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing userHasSourceAccessStmt: %w", cerr)
@@ -474,115 +970,239 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                             DBTX
-	tx                             *sql.Tx
-	addTeamMemberStmt              *sql.Stmt
-	addTeamSourceStmt              *sql.Stmt
-	countAdminUsersStmt            *sql.Stmt
-	countUserSessionsStmt          *sql.Stmt
-	createAPITokenStmt             *sql.Stmt
-	createSessionStmt              *sql.Stmt
-	createSourceStmt               *sql.Stmt
-	createTeamStmt                 *sql.Stmt
-	createTeamSourceQueryStmt      *sql.Stmt
-	createUserStmt                 *sql.Stmt
-	deleteAPITokenStmt             *sql.Stmt
-	deleteExpiredAPITokensStmt     *sql.Stmt
-	deleteSessionStmt              *sql.Stmt
-	deleteSourceStmt               *sql.Stmt
-	deleteTeamStmt                 *sql.Stmt
-	deleteTeamSourceQueryStmt      *sql.Stmt
-	deleteUserStmt                 *sql.Stmt
-	deleteUserSessionsStmt         *sql.Stmt
-	getAPITokenStmt                *sql.Stmt
-	getAPITokenByHashStmt          *sql.Stmt
-	getSessionStmt                 *sql.Stmt
-	getSourceStmt                  *sql.Stmt
-	getSourceByNameStmt            *sql.Stmt
-	getTeamStmt                    *sql.Stmt
-	getTeamByNameStmt              *sql.Stmt
-	getTeamMemberStmt              *sql.Stmt
-	getTeamSourceQueryStmt         *sql.Stmt
-	getUserStmt                    *sql.Stmt
-	getUserByEmailStmt             *sql.Stmt
-	listAPITokensForUserStmt       *sql.Stmt
-	listQueriesByTeamAndSourceStmt *sql.Stmt
-	listSourceTeamsStmt            *sql.Stmt
-	listSourcesStmt                *sql.Stmt
-	listSourcesForUserStmt         *sql.Stmt
-	listTeamMembersStmt            *sql.Stmt
-	listTeamMembersWithDetailsStmt *sql.Stmt
-	listTeamSourcesStmt            *sql.Stmt
-	listTeamsStmt                  *sql.Stmt
-	listTeamsForUserStmt           *sql.Stmt
-	listUserTeamsStmt              *sql.Stmt
-	listUsersStmt                  *sql.Stmt
-	removeTeamMemberStmt           *sql.Stmt
-	removeTeamSourceStmt           *sql.Stmt
-	teamHasSourceStmt              *sql.Stmt
-	updateAPITokenLastUsedStmt     *sql.Stmt
-	updateSourceStmt               *sql.Stmt
-	updateTeamStmt                 *sql.Stmt
-	updateTeamMemberRoleStmt       *sql.Stmt
-	updateTeamSourceQueryStmt      *sql.Stmt
-	updateUserStmt                 *sql.Stmt
-	userHasSourceAccessStmt        *sql.Stmt
+	db                                 DBTX
+	tx                                 *sql.Tx
+	addTeamMemberStmt                  *sql.Stmt
+	addTeamQueryTagStmt                *sql.Stmt
+	addTeamSourceStmt                  *sql.Stmt
+	countAdminUsersStmt                *sql.Stmt
+	countUserSessionsStmt              *sql.Stmt
+	createAPITokenStmt                 *sql.Stmt
+	createSessionStmt                  *sql.Stmt
+	createShareLinkStmt                *sql.Stmt
+	createSourceStmt                   *sql.Stmt
+	createTeamStmt                     *sql.Stmt
+	createTeamCollectionFolderStmt     *sql.Stmt
+	createTeamDashboardStmt            *sql.Stmt
+	createTeamQueryRevisionStmt        *sql.Stmt
+	createTeamSourceQueryStmt          *sql.Stmt
+	createUserStmt                     *sql.Stmt
+	deleteAPITokenStmt                 *sql.Stmt
+	deleteColumnPolicyStmt             *sql.Stmt
+	deleteExpiredAPITokensStmt         *sql.Stmt
+	deleteExpiredQueryCacheEntriesStmt *sql.Stmt
+	deleteExpiredSessionsStmt          *sql.Stmt
+	deleteQueryAuditLogBeforeStmt      *sql.Stmt
+	deleteSessionStmt                  *sql.Stmt
+	deleteSourceStmt                   *sql.Stmt
+	deleteSourceQueryCacheEntriesStmt  *sql.Stmt
+	deleteTeamStmt                     *sql.Stmt
+	deleteTeamCollectionFolderStmt     *sql.Stmt
+	deleteTeamDashboardStmt            *sql.Stmt
+	deleteTeamQueryTagsStmt            *sql.Stmt
+	deleteTeamSourceColumnPoliciesStmt *sql.Stmt
+	deleteTeamSourceQueryStmt          *sql.Stmt
+	deleteUserStmt                     *sql.Stmt
+	deleteUserQueryFavoriteStmt        *sql.Stmt
+	deleteUserSessionsStmt             *sql.Stmt
+	disableUserAPITokensStmt           *sql.Stmt
+	getAPITokenStmt                    *sql.Stmt
+	getAPITokenByHashStmt              *sql.Stmt
+	getColumnPolicyStmt                *sql.Stmt
+	getQueryCacheEntryStmt             *sql.Stmt
+	getSessionStmt                     *sql.Stmt
+	getShareLinkBySlugStmt             *sql.Stmt
+	getSourceStmt                      *sql.Stmt
+	getSourceByNameStmt                *sql.Stmt
+	getSourceSchemaSnapshotStmt        *sql.Stmt
+	getTeamStmt                        *sql.Stmt
+	getTeamByNameStmt                  *sql.Stmt
+	getTeamCollectionFolderStmt        *sql.Stmt
+	getTeamDashboardStmt               *sql.Stmt
+	getTeamMemberStmt                  *sql.Stmt
+	getTeamQueryRevisionStmt           *sql.Stmt
+	getTeamSourceQueryStmt             *sql.Stmt
+	getTeamSourceRowFilterStmt         *sql.Stmt
+	getUserStmt                        *sql.Stmt
+	getUserByEmailStmt                 *sql.Stmt
+	insertOIDCSyncEventStmt            *sql.Stmt
+	insertQueryAuditEntryStmt          *sql.Stmt
+	insertSourceRetentionChangeStmt    *sql.Stmt
+	insertSourceSchemaChangeStmt       *sql.Stmt
+	listAPITokensForUserStmt           *sql.Stmt
+	listColumnPoliciesStmt             *sql.Stmt
+	listQueriesBySourceStmt            *sql.Stmt
+	listQueriesByTeamAndSourceStmt     *sql.Stmt
+	listSourceRetentionChangesStmt     *sql.Stmt
+	listSourceSchemaChangesStmt        *sql.Stmt
+	listSourceTeamsStmt                *sql.Stmt
+	listSourcesStmt                    *sql.Stmt
+	listSourcesForUserStmt             *sql.Stmt
+	listTeamCollectionFoldersStmt      *sql.Stmt
+	listTeamDashboardsStmt             *sql.Stmt
+	listTeamMembersStmt                *sql.Stmt
+	listTeamMembersWithDetailsStmt     *sql.Stmt
+	listTeamQueryRevisionsStmt         *sql.Stmt
+	listTeamQueryTagsStmt              *sql.Stmt
+	listTeamShareLinksStmt             *sql.Stmt
+	listTeamSourcesStmt                *sql.Stmt
+	listTeamTagsStmt                   *sql.Stmt
+	listTeamsStmt                      *sql.Stmt
+	listTeamsForUserStmt               *sql.Stmt
+	listUserQueryHistoryStmt           *sql.Stmt
+	listUserShareLinksStmt             *sql.Stmt
+	listUserTeamsStmt                  *sql.Stmt
+	listUsersStmt                      *sql.Stmt
+	markTeamQueryUsedStmt              *sql.Stmt
+	recordShareLinkViewStmt            *sql.Stmt
+	removeTeamMemberStmt               *sql.Stmt
+	removeTeamSourceStmt               *sql.Stmt
+	revokeShareLinkStmt                *sql.Stmt
+	searchOIDCSyncEventsStmt           *sql.Stmt
+	searchQueryAuditLogStmt            *sql.Stmt
+	searchTeamQueriesStmt              *sql.Stmt
+	setSourceProvisionedStmt           *sql.Stmt
+	setTeamMemberOIDCSyncedStmt        *sql.Stmt
+	setTeamMemberProvisionedStmt       *sql.Stmt
+	setTeamProvisionedStmt             *sql.Stmt
+	setTeamQueryFolderStmt             *sql.Stmt
+	setTeamQueryProvisionedStmt        *sql.Stmt
+	setUserOIDCAdminStmt               *sql.Stmt
+	setUserProvisionedStmt             *sql.Stmt
+	teamHasSourceStmt                  *sql.Stmt
+	trimQueryCacheEntriesStmt          *sql.Stmt
+	updateAPITokenLastUsedStmt         *sql.Stmt
+	updateSourceStmt                   *sql.Stmt
+	updateTeamStmt                     *sql.Stmt
+	updateTeamCollectionFolderStmt     *sql.Stmt
+	updateTeamDashboardStmt            *sql.Stmt
+	updateTeamMemberRoleStmt           *sql.Stmt
+	updateTeamSourceQueryStmt          *sql.Stmt
+	updateTeamSourceRowFilterStmt      *sql.Stmt
+	updateUserStmt                     *sql.Stmt
+	upsertColumnPolicyStmt             *sql.Stmt
+	upsertQueryCacheEntryStmt          *sql.Stmt
+	upsertSourceSchemaSnapshotStmt     *sql.Stmt
+	upsertUserQueryFavoriteStmt        *sql.Stmt
+	userHasSourceAccessStmt            *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                             tx,
-		tx:                             tx,
-		addTeamMemberStmt:              q.addTeamMemberStmt,
-		addTeamSourceStmt:              q.addTeamSourceStmt,
-		countAdminUsersStmt:            q.countAdminUsersStmt,
-		countUserSessionsStmt:          q.countUserSessionsStmt,
-		createAPITokenStmt:             q.createAPITokenStmt,
-		createSessionStmt:              q.createSessionStmt,
-		createSourceStmt:               q.createSourceStmt,
-		createTeamStmt:                 q.createTeamStmt,
-		createTeamSourceQueryStmt:      q.createTeamSourceQueryStmt,
-		createUserStmt:                 q.createUserStmt,
-		deleteAPITokenStmt:             q.deleteAPITokenStmt,
-		deleteExpiredAPITokensStmt:     q.deleteExpiredAPITokensStmt,
-		deleteSessionStmt:              q.deleteSessionStmt,
-		deleteSourceStmt:               q.deleteSourceStmt,
-		deleteTeamStmt:                 q.deleteTeamStmt,
-		deleteTeamSourceQueryStmt:      q.deleteTeamSourceQueryStmt,
-		deleteUserStmt:                 q.deleteUserStmt,
-		deleteUserSessionsStmt:         q.deleteUserSessionsStmt,
-		getAPITokenStmt:                q.getAPITokenStmt,
-		getAPITokenByHashStmt:          q.getAPITokenByHashStmt,
-		getSessionStmt:                 q.getSessionStmt,
-		getSourceStmt:                  q.getSourceStmt,
-		getSourceByNameStmt:            q.getSourceByNameStmt,
-		getTeamStmt:                    q.getTeamStmt,
-		getTeamByNameStmt:              q.getTeamByNameStmt,
-		getTeamMemberStmt:              q.getTeamMemberStmt,
-		getTeamSourceQueryStmt:         q.getTeamSourceQueryStmt,
-		getUserStmt:                    q.getUserStmt,
-		getUserByEmailStmt:             q.getUserByEmailStmt,
-		listAPITokensForUserStmt:       q.listAPITokensForUserStmt,
-		listQueriesByTeamAndSourceStmt: q.listQueriesByTeamAndSourceStmt,
-		listSourceTeamsStmt:            q.listSourceTeamsStmt,
-		listSourcesStmt:                q.listSourcesStmt,
-		listSourcesForUserStmt:         q.listSourcesForUserStmt,
-		listTeamMembersStmt:            q.listTeamMembersStmt,
-		listTeamMembersWithDetailsStmt: q.listTeamMembersWithDetailsStmt,
-		listTeamSourcesStmt:            q.listTeamSourcesStmt,
-		listTeamsStmt:                  q.listTeamsStmt,
-		listTeamsForUserStmt:           q.listTeamsForUserStmt,
-		listUserTeamsStmt:              q.listUserTeamsStmt,
-		listUsersStmt:                  q.listUsersStmt,
-		removeTeamMemberStmt:           q.removeTeamMemberStmt,
-		removeTeamSourceStmt:           q.removeTeamSourceStmt,
-		teamHasSourceStmt:              q.teamHasSourceStmt,
-		updateAPITokenLastUsedStmt:     q.updateAPITokenLastUsedStmt,
-		updateSourceStmt:               q.updateSourceStmt,
-		updateTeamStmt:                 q.updateTeamStmt,
-		updateTeamMemberRoleStmt:       q.updateTeamMemberRoleStmt,
-		updateTeamSourceQueryStmt:      q.updateTeamSourceQueryStmt,
-		updateUserStmt:                 q.updateUserStmt,
-		userHasSourceAccessStmt:        q.userHasSourceAccessStmt,
+		db:                                 tx,
+		tx:                                 tx,
+		addTeamMemberStmt:                  q.addTeamMemberStmt,
+		addTeamQueryTagStmt:                q.addTeamQueryTagStmt,
+		addTeamSourceStmt:                  q.addTeamSourceStmt,
+		countAdminUsersStmt:                q.countAdminUsersStmt,
+		countUserSessionsStmt:              q.countUserSessionsStmt,
+		createAPITokenStmt:                 q.createAPITokenStmt,
+		createSessionStmt:                  q.createSessionStmt,
+		createShareLinkStmt:                q.createShareLinkStmt,
+		createSourceStmt:                   q.createSourceStmt,
+		createTeamStmt:                     q.createTeamStmt,
+		createTeamCollectionFolderStmt:     q.createTeamCollectionFolderStmt,
+		createTeamDashboardStmt:            q.createTeamDashboardStmt,
+		createTeamQueryRevisionStmt:        q.createTeamQueryRevisionStmt,
+		createTeamSourceQueryStmt:          q.createTeamSourceQueryStmt,
+		createUserStmt:                     q.createUserStmt,
+		deleteAPITokenStmt:                 q.deleteAPITokenStmt,
+		deleteColumnPolicyStmt:             q.deleteColumnPolicyStmt,
+		deleteExpiredAPITokensStmt:         q.deleteExpiredAPITokensStmt,
+		deleteExpiredQueryCacheEntriesStmt: q.deleteExpiredQueryCacheEntriesStmt,
+		deleteExpiredSessionsStmt:          q.deleteExpiredSessionsStmt,
+		deleteQueryAuditLogBeforeStmt:      q.deleteQueryAuditLogBeforeStmt,
+		deleteSessionStmt:                  q.deleteSessionStmt,
+		deleteSourceStmt:                   q.deleteSourceStmt,
+		deleteSourceQueryCacheEntriesStmt:  q.deleteSourceQueryCacheEntriesStmt,
+		deleteTeamStmt:                     q.deleteTeamStmt,
+		deleteTeamCollectionFolderStmt:     q.deleteTeamCollectionFolderStmt,
+		deleteTeamDashboardStmt:            q.deleteTeamDashboardStmt,
+		deleteTeamQueryTagsStmt:            q.deleteTeamQueryTagsStmt,
+		deleteTeamSourceColumnPoliciesStmt: q.deleteTeamSourceColumnPoliciesStmt,
+		deleteTeamSourceQueryStmt:          q.deleteTeamSourceQueryStmt,
+		deleteUserStmt:                     q.deleteUserStmt,
+		deleteUserQueryFavoriteStmt:        q.deleteUserQueryFavoriteStmt,
+		deleteUserSessionsStmt:             q.deleteUserSessionsStmt,
+		disableUserAPITokensStmt:           q.disableUserAPITokensStmt,
+		getAPITokenStmt:                    q.getAPITokenStmt,
+		getAPITokenByHashStmt:              q.getAPITokenByHashStmt,
+		getColumnPolicyStmt:                q.getColumnPolicyStmt,
+		getQueryCacheEntryStmt:             q.getQueryCacheEntryStmt,
+		getSessionStmt:                     q.getSessionStmt,
+		getShareLinkBySlugStmt:             q.getShareLinkBySlugStmt,
+		getSourceStmt:                      q.getSourceStmt,
+		getSourceByNameStmt:                q.getSourceByNameStmt,
+		getSourceSchemaSnapshotStmt:        q.getSourceSchemaSnapshotStmt,
+		getTeamStmt:                        q.getTeamStmt,
+		getTeamByNameStmt:                  q.getTeamByNameStmt,
+		getTeamCollectionFolderStmt:        q.getTeamCollectionFolderStmt,
+		getTeamDashboardStmt:               q.getTeamDashboardStmt,
+		getTeamMemberStmt:                  q.getTeamMemberStmt,
+		getTeamQueryRevisionStmt:           q.getTeamQueryRevisionStmt,
+		getTeamSourceQueryStmt:             q.getTeamSourceQueryStmt,
+		getTeamSourceRowFilterStmt:         q.getTeamSourceRowFilterStmt,
+		getUserStmt:                        q.getUserStmt,
+		getUserByEmailStmt:                 q.getUserByEmailStmt,
+		insertOIDCSyncEventStmt:            q.insertOIDCSyncEventStmt,
+		insertQueryAuditEntryStmt:          q.insertQueryAuditEntryStmt,
+		insertSourceRetentionChangeStmt:    q.insertSourceRetentionChangeStmt,
+		insertSourceSchemaChangeStmt:       q.insertSourceSchemaChangeStmt,
+		listAPITokensForUserStmt:           q.listAPITokensForUserStmt,
+		listColumnPoliciesStmt:             q.listColumnPoliciesStmt,
+		listQueriesBySourceStmt:            q.listQueriesBySourceStmt,
+		listQueriesByTeamAndSourceStmt:     q.listQueriesByTeamAndSourceStmt,
+		listSourceRetentionChangesStmt:     q.listSourceRetentionChangesStmt,
+		listSourceSchemaChangesStmt:        q.listSourceSchemaChangesStmt,
+		listSourceTeamsStmt:                q.listSourceTeamsStmt,
+		listSourcesStmt:                    q.listSourcesStmt,
+		listSourcesForUserStmt:             q.listSourcesForUserStmt,
+		listTeamCollectionFoldersStmt:      q.listTeamCollectionFoldersStmt,
+		listTeamDashboardsStmt:             q.listTeamDashboardsStmt,
+		listTeamMembersStmt:                q.listTeamMembersStmt,
+		listTeamMembersWithDetailsStmt:     q.listTeamMembersWithDetailsStmt,
+		listTeamQueryRevisionsStmt:         q.listTeamQueryRevisionsStmt,
+		listTeamQueryTagsStmt:              q.listTeamQueryTagsStmt,
+		listTeamShareLinksStmt:             q.listTeamShareLinksStmt,
+		listTeamSourcesStmt:                q.listTeamSourcesStmt,
+		listTeamTagsStmt:                   q.listTeamTagsStmt,
+		listTeamsStmt:                      q.listTeamsStmt,
+		listTeamsForUserStmt:               q.listTeamsForUserStmt,
+		listUserQueryHistoryStmt:           q.listUserQueryHistoryStmt,
+		listUserShareLinksStmt:             q.listUserShareLinksStmt,
+		listUserTeamsStmt:                  q.listUserTeamsStmt,
+		listUsersStmt:                      q.listUsersStmt,
+		markTeamQueryUsedStmt:              q.markTeamQueryUsedStmt,
+		recordShareLinkViewStmt:            q.recordShareLinkViewStmt,
+		removeTeamMemberStmt:               q.removeTeamMemberStmt,
+		removeTeamSourceStmt:               q.removeTeamSourceStmt,
+		revokeShareLinkStmt:                q.revokeShareLinkStmt,
+		searchOIDCSyncEventsStmt:           q.searchOIDCSyncEventsStmt,
+		searchQueryAuditLogStmt:            q.searchQueryAuditLogStmt,
+		searchTeamQueriesStmt:              q.searchTeamQueriesStmt,
+		setSourceProvisionedStmt:           q.setSourceProvisionedStmt,
+		setTeamMemberOIDCSyncedStmt:        q.setTeamMemberOIDCSyncedStmt,
+		setTeamMemberProvisionedStmt:       q.setTeamMemberProvisionedStmt,
+		setTeamProvisionedStmt:             q.setTeamProvisionedStmt,
+		setTeamQueryFolderStmt:             q.setTeamQueryFolderStmt,
+		setTeamQueryProvisionedStmt:        q.setTeamQueryProvisionedStmt,
+		setUserOIDCAdminStmt:               q.setUserOIDCAdminStmt,
+		setUserProvisionedStmt:             q.setUserProvisionedStmt,
+		teamHasSourceStmt:                  q.teamHasSourceStmt,
+		trimQueryCacheEntriesStmt:          q.trimQueryCacheEntriesStmt,
+		updateAPITokenLastUsedStmt:         q.updateAPITokenLastUsedStmt,
+		updateSourceStmt:                   q.updateSourceStmt,
+		updateTeamStmt:                     q.updateTeamStmt,
+		updateTeamCollectionFolderStmt:     q.updateTeamCollectionFolderStmt,
+		updateTeamDashboardStmt:            q.updateTeamDashboardStmt,
+		updateTeamMemberRoleStmt:           q.updateTeamMemberRoleStmt,
+		updateTeamSourceQueryStmt:          q.updateTeamSourceQueryStmt,
+		updateTeamSourceRowFilterStmt:      q.updateTeamSourceRowFilterStmt,
+		updateUserStmt:                     q.updateUserStmt,
+		upsertColumnPolicyStmt:             q.upsertColumnPolicyStmt,
+		upsertQueryCacheEntryStmt:          q.upsertQueryCacheEntryStmt,
+		upsertSourceSchemaSnapshotStmt:     q.upsertSourceSchemaSnapshotStmt,
+		upsertUserQueryFavoriteStmt:        q.upsertUserQueryFavoriteStmt,
+		userHasSourceAccessStmt:            q.userHasSourceAccessStmt,
 	}
 }
@@ -10,15 +10,88 @@ import (
 )
 
 type ApiToken struct {
-	ID         int64        `json:"id"`
-	UserID     int64        `json:"user_id"`
-	Name       string       `json:"name"`
-	TokenHash  string       `json:"token_hash"`
-	Prefix     string       `json:"prefix"`
-	LastUsedAt sql.NullTime `json:"last_used_at"`
-	ExpiresAt  sql.NullTime `json:"expires_at"`
-	CreatedAt  time.Time    `json:"created_at"`
-	UpdatedAt  time.Time    `json:"updated_at"`
+	ID           int64        `json:"id"`
+	UserID       int64        `json:"user_id"`
+	Name         string       `json:"name"`
+	TokenHash    string       `json:"token_hash"`
+	Prefix       string       `json:"prefix"`
+	LastUsedAt   sql.NullTime `json:"last_used_at"`
+	ExpiresAt    sql.NullTime `json:"expires_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	DisabledAt   sql.NullTime `json:"disabled_at"`
+	Scopes       string       `json:"scopes"`
+	Resources    string       `json:"resources"`
+	AllowedCidrs string       `json:"allowed_cidrs"`
+}
+
+type CollectionFolder struct {
+	ID        int64     `json:"id"`
+	TeamID    int64     `json:"team_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ColumnPolicy struct {
+	ID          int64     `json:"id"`
+	TeamID      int64     `json:"team_id"`
+	SourceID    int64     `json:"source_id"`
+	ColumnName  string    `json:"column_name"`
+	Action      string    `json:"action"`
+	Pattern     string    `json:"pattern"`
+	Replacement string    `json:"replacement"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Dashboard struct {
+	ID          int64     `json:"id"`
+	TeamID      int64     `json:"team_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Definition  string    `json:"definition"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type OidcSyncEvent struct {
+	ID        int64         `json:"id"`
+	UserID    sql.NullInt64 `json:"user_id"`
+	UserEmail string        `json:"user_email"`
+	TeamID    sql.NullInt64 `json:"team_id"`
+	TeamName  string        `json:"team_name"`
+	Action    string        `json:"action"`
+	Role      string        `json:"role"`
+	Groups    string        `json:"groups"`
+	Reason    string        `json:"reason"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type QueryAuditLog struct {
+	ID           int64         `json:"id"`
+	UserID       sql.NullInt64 `json:"user_id"`
+	UserEmail    string        `json:"user_email"`
+	ApiTokenID   sql.NullInt64 `json:"api_token_id"`
+	TeamID       sql.NullInt64 `json:"team_id"`
+	SourceID     sql.NullInt64 `json:"source_id"`
+	QueryType    string        `json:"query_type"`
+	RawSql       string        `json:"raw_sql"`
+	FinalSql     string        `json:"final_sql"`
+	DurationMs   int64         `json:"duration_ms"`
+	RowsReturned int64         `json:"rows_returned"`
+	Error        string        `json:"error"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type QueryCache struct {
+	CacheKey  string    `json:"cache_key"`
+	SourceID  int64     `json:"source_id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type Session struct {
@@ -28,6 +101,28 @@ type Session struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type ShareLink struct {
+	ID             int64          `json:"id"`
+	Slug           string         `json:"slug"`
+	TeamID         int64          `json:"team_id"`
+	SourceID       int64          `json:"source_id"`
+	CreatedBy      int64          `json:"created_by"`
+	Title          string         `json:"title"`
+	Mode           string         `json:"mode"`
+	RawSql         string         `json:"raw_sql"`
+	RowLimit       int64          `json:"row_limit"`
+	StartTime      sql.NullTime   `json:"start_time"`
+	EndTime        sql.NullTime   `json:"end_time"`
+	Snapshot       sql.NullString `json:"snapshot"`
+	PasswordHash   string         `json:"password_hash"`
+	AllowAnonymous int64          `json:"allow_anonymous"`
+	ViewCount      int64          `json:"view_count"`
+	LastViewedAt   sql.NullTime   `json:"last_viewed_at"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+	RevokedAt      sql.NullTime   `json:"revoked_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
 type Source struct {
 	ID                int64          `json:"id"`
 	Name              string         `json:"name"`
@@ -43,21 +138,57 @@ type Source struct {
 	TtlDays           int64          `json:"ttl_days"`
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
+	Provisioned       int64          `json:"provisioned"`
+	FieldRoles        string         `json:"field_roles"`
+	SeverityMapping   string         `json:"severity_mapping"`
+}
+
+type SourceRetentionChange struct {
+	ID         int64         `json:"id"`
+	SourceID   int64         `json:"source_id"`
+	UserID     sql.NullInt64 `json:"user_id"`
+	OldTtlDays int64         `json:"old_ttl_days"`
+	NewTtlDays int64         `json:"new_ttl_days"`
+	Status     string        `json:"status"`
+	Statement  string        `json:"statement"`
+	Error      string        `json:"error"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type SourceSchemaChange struct {
+	ID         int64     `json:"id"`
+	SourceID   int64     `json:"source_id"`
+	ChangeType string    `json:"change_type"`
+	ColumnName string    `json:"column_name"`
+	OldValue   string    `json:"old_value"`
+	NewValue   string    `json:"new_value"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+type SourceSchemaSnapshot struct {
+	SourceID int64     `json:"source_id"`
+	Columns  string    `json:"columns"`
+	SortKeys string    `json:"sort_keys"`
+	TakenAt  time.Time `json:"taken_at"`
 }
 
 type Team struct {
-	ID          int64          `json:"id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID                   int64          `json:"id"`
+	Name                 string         `json:"name"`
+	Description          sql.NullString `json:"description"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	AllowAnonymousShares int64          `json:"allow_anonymous_shares"`
+	Provisioned          int64          `json:"provisioned"`
 }
 
 type TeamMember struct {
-	TeamID    int64     `json:"team_id"`
-	UserID    int64     `json:"user_id"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	TeamID      int64     `json:"team_id"`
+	UserID      int64     `json:"user_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	Provisioned int64     `json:"provisioned"`
+	OidcSynced  int64     `json:"oidc_synced"`
 }
 
 type TeamQuery struct {
@@ -70,12 +201,35 @@ type TeamQuery struct {
 	QueryContent string         `json:"query_content"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
+	CreatedBy    sql.NullInt64  `json:"created_by"`
+	UpdatedBy    sql.NullInt64  `json:"updated_by"`
+	FolderID     sql.NullInt64  `json:"folder_id"`
+	LastUsedAt   sql.NullTime   `json:"last_used_at"`
+	Provisioned  int64          `json:"provisioned"`
+}
+
+type TeamQueryRevision struct {
+	ID           int64         `json:"id"`
+	QueryID      int64         `json:"query_id"`
+	Revision     int64         `json:"revision"`
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	QueryType    string        `json:"query_type"`
+	QueryContent string        `json:"query_content"`
+	CreatedBy    sql.NullInt64 `json:"created_by"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type TeamQueryTag struct {
+	QueryID int64  `json:"query_id"`
+	Tag     string `json:"tag"`
 }
 
 type TeamSource struct {
 	TeamID    int64     `json:"team_id"`
 	SourceID  int64     `json:"source_id"`
 	CreatedAt time.Time `json:"created_at"`
+	RowFilter string    `json:"row_filter"`
 }
 
 type User struct {
@@ -88,4 +242,13 @@ type User struct {
 	LastActiveAt sql.NullTime `json:"last_active_at"`
 	CreatedAt    time.Time    `json:"created_at"`
 	UpdatedAt    time.Time    `json:"updated_at"`
+	Provisioned  int64        `json:"provisioned"`
+	OidcAdmin    int64        `json:"oidc_admin"`
+}
+
+type UserQueryFavorite struct {
+	UserID    int64     `json:"user_id"`
+	QueryID   int64     `json:"query_id"`
+	Pinned    int64     `json:"pinned"`
+	CreatedAt time.Time `json:"created_at"`
 }
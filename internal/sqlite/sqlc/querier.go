@@ -6,12 +6,15 @@ package sqlc
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
 	// Team Members
 	// Add a member to a team
 	AddTeamMember(ctx context.Context, arg AddTeamMemberParams) error
+	// Add a tag to a query
+	AddTeamQueryTag(ctx context.Context, arg AddTeamQueryTagParams) error
 	// Team Sources
 	// Add a data source to a team
 	AddTeamSource(ctx context.Context, arg AddTeamSourceParams) error
@@ -25,12 +28,22 @@ type Querier interface {
 	// Sessions
 	// Create a new session
 	CreateSession(ctx context.Context, arg CreateSessionParams) error
+	// Share Links
+	// Create a new share link
+	CreateShareLink(ctx context.Context, arg CreateShareLinkParams) (int64, error)
 	// Sources
 	// Create a new source entry
 	CreateSource(ctx context.Context, arg CreateSourceParams) (int64, error)
 	// Teams
 	// Create a new team
 	CreateTeam(ctx context.Context, arg CreateTeamParams) (int64, error)
+	// Create a new collection folder for a team
+	CreateTeamCollectionFolder(ctx context.Context, arg CreateTeamCollectionFolderParams) (int64, error)
+	// Create a new dashboard for a team
+	CreateTeamDashboard(ctx context.Context, arg CreateTeamDashboardParams) (int64, error)
+	// Team Query Revisions
+	// Record the current state of a query as its next revision
+	CreateTeamQueryRevision(ctx context.Context, arg CreateTeamQueryRevisionParams) error
 	// Team Queries
 	// Create a new query for a team and source
 	CreateTeamSourceQuery(ctx context.Context, arg CreateTeamSourceQueryParams) (int64, error)
@@ -39,85 +52,210 @@ type Querier interface {
 	CreateUser(ctx context.Context, arg CreateUserParams) (int64, error)
 	// Delete an API token by ID and user ID (ensure user owns the token)
 	DeleteAPIToken(ctx context.Context, arg DeleteAPITokenParams) error
+	// Delete a column policy for a team and source
+	DeleteColumnPolicy(ctx context.Context, arg DeleteColumnPolicyParams) (int64, error)
 	// Delete all expired API tokens
 	DeleteExpiredAPITokens(ctx context.Context) error
+	// Drop expired query results
+	DeleteExpiredQueryCacheEntries(ctx context.Context, expiresAt time.Time) (int64, error)
+	// Delete all sessions that expired before the given time
+	DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error)
+	// Delete audit entries older than the given time
+	DeleteQueryAuditLogBefore(ctx context.Context, createdAt time.Time) (int64, error)
 	// Delete a session by ID
 	DeleteSession(ctx context.Context, id string) error
 	// Delete a source by ID
 	DeleteSource(ctx context.Context, id int64) error
+	// Drop the cached query results of a source
+	DeleteSourceQueryCacheEntries(ctx context.Context, sourceID int64) error
 	// Delete a team by ID
 	DeleteTeam(ctx context.Context, id int64) error
+	// Delete a collection folder of a team, leaving its queries unfiled
+	DeleteTeamCollectionFolder(ctx context.Context, arg DeleteTeamCollectionFolderParams) (int64, error)
+	// Delete a dashboard of a team
+	DeleteTeamDashboard(ctx context.Context, arg DeleteTeamDashboardParams) (int64, error)
+	// Remove all tags of a query
+	DeleteTeamQueryTags(ctx context.Context, queryID int64) error
+	// Delete all column policies for a team's access to a source
+	DeleteTeamSourceColumnPolicies(ctx context.Context, arg DeleteTeamSourceColumnPoliciesParams) error
 	// Delete a query by ID for a specific team and source
 	DeleteTeamSourceQuery(ctx context.Context, arg DeleteTeamSourceQueryParams) error
 	// Delete a user by ID
 	DeleteUser(ctx context.Context, id int64) error
+	// Remove a query from the favorites of a user
+	DeleteUserQueryFavorite(ctx context.Context, arg DeleteUserQueryFavoriteParams) (int64, error)
 	// Delete all sessions for a user
 	DeleteUserSessions(ctx context.Context, userID int64) error
+	// Disable all active API tokens of a user
+	DisableUserAPITokens(ctx context.Context, arg DisableUserAPITokensParams) error
 	// Get an API token by ID
 	GetAPIToken(ctx context.Context, id int64) (ApiToken, error)
 	// Get an API token by its hash (for authentication)
 	GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error)
+	// Get a column policy by ID for a team and source
+	GetColumnPolicy(ctx context.Context, arg GetColumnPolicyParams) (ColumnPolicy, error)
+	// Query result cache
+	// Get a cached query result that hasn't expired
+	GetQueryCacheEntry(ctx context.Context, arg GetQueryCacheEntryParams) (QueryCache, error)
 	// Get a session by ID
 	GetSession(ctx context.Context, id string) (Session, error)
+	// Get a share link by its slug with the creator and the team setting for anonymous access
+	GetShareLinkBySlug(ctx context.Context, slug string) (GetShareLinkBySlugRow, error)
 	// Get a single source by ID
 	GetSource(ctx context.Context, id int64) (Source, error)
 	// Get a single source by table name and database
 	GetSourceByName(ctx context.Context, arg GetSourceByNameParams) (Source, error)
+	// Source schema drift
+	// Get the latest schema snapshot of a source
+	GetSourceSchemaSnapshot(ctx context.Context, sourceID int64) (SourceSchemaSnapshot, error)
 	// Get a team by ID
 	GetTeam(ctx context.Context, id int64) (Team, error)
 	// Get a team by its name
 	GetTeamByName(ctx context.Context, name string) (Team, error)
+	// Get a collection folder by ID for a team
+	GetTeamCollectionFolder(ctx context.Context, arg GetTeamCollectionFolderParams) (CollectionFolder, error)
+	// Get a dashboard by ID for a team
+	GetTeamDashboard(ctx context.Context, arg GetTeamDashboardParams) (Dashboard, error)
 	// Get a team member
 	GetTeamMember(ctx context.Context, arg GetTeamMemberParams) (TeamMember, error)
+	// Get a single revision of a query
+	GetTeamQueryRevision(ctx context.Context, arg GetTeamQueryRevisionParams) (GetTeamQueryRevisionRow, error)
 	// Get a query by ID for a specific team and source
 	GetTeamSourceQuery(ctx context.Context, arg GetTeamSourceQueryParams) (TeamQuery, error)
+	// Get the row-level filter expression for a team's access to a source
+	GetTeamSourceRowFilter(ctx context.Context, arg GetTeamSourceRowFilterParams) (string, error)
 	// Get a user by ID
 	GetUser(ctx context.Context, id int64) (User, error)
 	// Get a user by email
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	// Record a decision made by OIDC group sync
+	InsertOIDCSyncEvent(ctx context.Context, arg InsertOIDCSyncEventParams) error
+	// Query Audit Log
+	// Record an executed query
+	InsertQueryAuditEntry(ctx context.Context, arg InsertQueryAuditEntryParams) error
+	// Source retention changes
+	// Record a change of a source's retention
+	InsertSourceRetentionChange(ctx context.Context, arg InsertSourceRetentionChangeParams) error
+	// Record a schema change detected for a source
+	InsertSourceSchemaChange(ctx context.Context, arg InsertSourceSchemaChangeParams) error
 	// List all API tokens for a user
 	ListAPITokensForUser(ctx context.Context, userID int64) ([]ApiToken, error)
+	// Column Policies
+	// List all column policies for a team's access to a source
+	ListColumnPolicies(ctx context.Context, arg ListColumnPoliciesParams) ([]ColumnPolicy, error)
+	// List the queries of all teams for a specific source
+	ListQueriesBySource(ctx context.Context, sourceID int64) ([]TeamQuery, error)
 	// List all queries for a specific team and source
 	ListQueriesByTeamAndSource(ctx context.Context, arg ListQueriesByTeamAndSourceParams) ([]TeamQuery, error)
+	// List the retention changes of a source, newest first
+	ListSourceRetentionChanges(ctx context.Context, arg ListSourceRetentionChangesParams) ([]SourceRetentionChange, error)
+	// List the schema changes of a source, newest first
+	ListSourceSchemaChanges(ctx context.Context, arg ListSourceSchemaChangesParams) ([]SourceSchemaChange, error)
 	// List all teams a data source is a member of
 	ListSourceTeams(ctx context.Context, sourceID int64) ([]Team, error)
 	// Get all sources ordered by creation date
 	ListSources(ctx context.Context) ([]Source, error)
 	// List all sources a user has access to
 	ListSourcesForUser(ctx context.Context, userID int64) ([]Source, error)
+	// Collection Folders
+	// List all collection folders of a team with their query counts
+	ListTeamCollectionFolders(ctx context.Context, teamID int64) ([]ListTeamCollectionFoldersRow, error)
+	// Dashboards
+	// List all dashboards of a team
+	ListTeamDashboards(ctx context.Context, teamID int64) ([]Dashboard, error)
 	// List all members of a team
 	ListTeamMembers(ctx context.Context, teamID int64) ([]TeamMember, error)
 	// List all members of a team with user details
 	ListTeamMembersWithDetails(ctx context.Context, teamID int64) ([]ListTeamMembersWithDetailsRow, error)
+	// List all revisions of a query, newest first
+	ListTeamQueryRevisions(ctx context.Context, queryID int64) ([]ListTeamQueryRevisionsRow, error)
+	// Team Query Tags
+	// List the tags of a query
+	ListTeamQueryTags(ctx context.Context, queryID int64) ([]string, error)
+	// List the share links of a team, newest first, without snapshots
+	ListTeamShareLinks(ctx context.Context, teamID int64) ([]ListTeamShareLinksRow, error)
 	// List all data sources in a team
 	ListTeamSources(ctx context.Context, teamID int64) ([]Source, error)
+	// List the distinct tags used by the queries of a team
+	ListTeamTags(ctx context.Context, teamID int64) ([]string, error)
 	// List all teams
 	ListTeams(ctx context.Context) ([]ListTeamsRow, error)
 	// List all teams a user is a member of
 	ListTeamsForUser(ctx context.Context, userID int64) ([]ListTeamsForUserRow, error)
+	// List the most recent queries run by a user
+	ListUserQueryHistory(ctx context.Context, arg ListUserQueryHistoryParams) ([]QueryAuditLog, error)
+	// List the share links created by a user, newest first, without snapshots
+	ListUserShareLinks(ctx context.Context, createdBy int64) ([]ListUserShareLinksRow, error)
 	// List all teams a user is a member of
 	ListUserTeams(ctx context.Context, userID int64) ([]Team, error)
 	// List all users
 	ListUsers(ctx context.Context) ([]User, error)
+	// Record that a query was just run
+	MarkTeamQueryUsed(ctx context.Context, id int64) error
+	// Count a view of a share link
+	RecordShareLinkView(ctx context.Context, id int64) error
 	// Remove a member from a team
 	RemoveTeamMember(ctx context.Context, arg RemoveTeamMemberParams) error
 	// Remove a data source from a team
 	RemoveTeamSource(ctx context.Context, arg RemoveTeamSourceParams) error
+	// Revoke a share link that isn't revoked yet
+	RevokeShareLink(ctx context.Context, id int64) (int64, error)
+	// Search the OIDC sync log, ignoring NULL filters
+	SearchOIDCSyncEvents(ctx context.Context, arg SearchOIDCSyncEventsParams) ([]OidcSyncEvent, error)
+	// Search the query audit log, ignoring NULL filters
+	SearchQueryAuditLog(ctx context.Context, arg SearchQueryAuditLogParams) ([]QueryAuditLog, error)
+	// Search the queries of a team across its sources, ignoring NULL filters. Folder 0 matches unfiled queries.
+	SearchTeamQueries(ctx context.Context, arg SearchTeamQueriesParams) ([]SearchTeamQueriesRow, error)
+	// Mark whether a source is managed by provisioning
+	SetSourceProvisioned(ctx context.Context, arg SetSourceProvisionedParams) error
+	// Mark whether a team membership was granted by an OIDC group
+	SetTeamMemberOIDCSynced(ctx context.Context, arg SetTeamMemberOIDCSyncedParams) error
+	// Mark whether a team membership is managed by provisioning
+	SetTeamMemberProvisioned(ctx context.Context, arg SetTeamMemberProvisionedParams) error
+	// Mark whether a team is managed by provisioning
+	SetTeamProvisioned(ctx context.Context, arg SetTeamProvisionedParams) error
+	// Move a query into a folder, or out of any folder when NULL
+	SetTeamQueryFolder(ctx context.Context, arg SetTeamQueryFolderParams) (int64, error)
+	// Mark whether a team query is managed by provisioning
+	SetTeamQueryProvisioned(ctx context.Context, arg SetTeamQueryProvisionedParams) error
+	// OIDC group sync
+	// Mark whether a user's admin role was granted by an OIDC group
+	SetUserOIDCAdmin(ctx context.Context, arg SetUserOIDCAdminParams) error
+	// Provisioning
+	// Mark whether a user is managed by provisioning
+	SetUserProvisioned(ctx context.Context, arg SetUserProvisionedParams) error
 	// Additional queries for user-source and team-source access
 	// Check if a team has access to a source
 	TeamHasSource(ctx context.Context, arg TeamHasSourceParams) (int64, error)
+	// Drop the oldest query results beyond the given number of entries
+	TrimQueryCacheEntries(ctx context.Context, offset int64) (int64, error)
 	// Update the last used timestamp for an API token
 	UpdateAPITokenLastUsed(ctx context.Context, id int64) error
 	// Update an existing source
 	UpdateSource(ctx context.Context, arg UpdateSourceParams) error
 	// Update a team
 	UpdateTeam(ctx context.Context, arg UpdateTeamParams) error
+	// Rename a collection folder of a team
+	UpdateTeamCollectionFolder(ctx context.Context, arg UpdateTeamCollectionFolderParams) (int64, error)
+	// Update a dashboard of a team
+	UpdateTeamDashboard(ctx context.Context, arg UpdateTeamDashboardParams) (int64, error)
 	// Update a team member's role
 	UpdateTeamMemberRole(ctx context.Context, arg UpdateTeamMemberRoleParams) error
 	// Update a query for a team and source
-	UpdateTeamSourceQuery(ctx context.Context, arg UpdateTeamSourceQueryParams) error
+	UpdateTeamSourceQuery(ctx context.Context, arg UpdateTeamSourceQueryParams) (int64, error)
+	// Set the row-level filter expression for a team's access to a source
+	UpdateTeamSourceRowFilter(ctx context.Context, arg UpdateTeamSourceRowFilterParams) (int64, error)
 	// Update a user
 	UpdateUser(ctx context.Context, arg UpdateUserParams) error
+	// Create or replace the policy for a column of a team's source
+	UpsertColumnPolicy(ctx context.Context, arg UpsertColumnPolicyParams) (int64, error)
+	// Store a query result, replacing any previous result of the same query
+	UpsertQueryCacheEntry(ctx context.Context, arg UpsertQueryCacheEntryParams) error
+	// Replace the latest schema snapshot of a source
+	UpsertSourceSchemaSnapshot(ctx context.Context, arg UpsertSourceSchemaSnapshotParams) error
+	// User Query Favorites
+	// Add a query to the favorites of a user, or update whether it's pinned
+	UpsertUserQueryFavorite(ctx context.Context, arg UpsertUserQueryFavoriteParams) error
 	// Check if a user has access to a source through any team
 	UserHasSourceAccess(ctx context.Context, arg UserHasSourceAccessParams) (int64, error)
 }
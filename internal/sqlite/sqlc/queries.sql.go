@@ -30,6 +30,22 @@ func (q *Queries) AddTeamMember(ctx context.Context, arg AddTeamMemberParams) er
 	return err
 }
 
+const addTeamQueryTag = `-- name: AddTeamQueryTag :exec
+INSERT OR IGNORE INTO team_query_tags (query_id, tag)
+VALUES (?, ?)
+`
+
+type AddTeamQueryTagParams struct {
+	QueryID int64  `json:"query_id"`
+	Tag     string `json:"tag"`
+}
+
+// Add a tag to a query
+func (q *Queries) AddTeamQueryTag(ctx context.Context, arg AddTeamQueryTagParams) error {
+	_, err := q.exec(ctx, q.addTeamQueryTagStmt, addTeamQueryTag, arg.QueryID, arg.Tag)
+	return err
+}
+
 const addTeamSource = `-- name: AddTeamSource :exec
 
 INSERT INTO team_sources (team_id, source_id)
@@ -84,17 +100,20 @@ func (q *Queries) CountUserSessions(ctx context.Context, arg CountUserSessionsPa
 
 const createAPIToken = `-- name: CreateAPIToken :one
 
-INSERT INTO api_tokens (user_id, name, token_hash, prefix, expires_at)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO api_tokens (user_id, name, token_hash, prefix, expires_at, scopes, resources, allowed_cidrs)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id
 `
 
 type CreateAPITokenParams struct {
-	UserID    int64        `json:"user_id"`
-	Name      string       `json:"name"`
-	TokenHash string       `json:"token_hash"`
-	Prefix    string       `json:"prefix"`
-	ExpiresAt sql.NullTime `json:"expires_at"`
+	UserID       int64        `json:"user_id"`
+	Name         string       `json:"name"`
+	TokenHash    string       `json:"token_hash"`
+	Prefix       string       `json:"prefix"`
+	ExpiresAt    sql.NullTime `json:"expires_at"`
+	Scopes       string       `json:"scopes"`
+	Resources    string       `json:"resources"`
+	AllowedCidrs string       `json:"allowed_cidrs"`
 }
 
 // API Tokens
@@ -106,6 +125,9 @@ func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams)
 		arg.TokenHash,
 		arg.Prefix,
 		arg.ExpiresAt,
+		arg.Scopes,
+		arg.Resources,
+		arg.AllowedCidrs,
 	)
 	var id int64
 	err := row.Scan(&id)
@@ -137,11 +159,59 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) er
 	return err
 }
 
+const createShareLink = `-- name: CreateShareLink :one
+
+INSERT INTO share_links (slug, team_id, source_id, created_by, title, mode, raw_sql, row_limit, start_time, end_time, snapshot, password_hash, allow_anonymous, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type CreateShareLinkParams struct {
+	Slug           string         `json:"slug"`
+	TeamID         int64          `json:"team_id"`
+	SourceID       int64          `json:"source_id"`
+	CreatedBy      int64          `json:"created_by"`
+	Title          string         `json:"title"`
+	Mode           string         `json:"mode"`
+	RawSql         string         `json:"raw_sql"`
+	RowLimit       int64          `json:"row_limit"`
+	StartTime      sql.NullTime   `json:"start_time"`
+	EndTime        sql.NullTime   `json:"end_time"`
+	Snapshot       sql.NullString `json:"snapshot"`
+	PasswordHash   string         `json:"password_hash"`
+	AllowAnonymous int64          `json:"allow_anonymous"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+}
+
+// Share Links
+// Create a new share link
+func (q *Queries) CreateShareLink(ctx context.Context, arg CreateShareLinkParams) (int64, error) {
+	row := q.queryRow(ctx, q.createShareLinkStmt, createShareLink,
+		arg.Slug,
+		arg.TeamID,
+		arg.SourceID,
+		arg.CreatedBy,
+		arg.Title,
+		arg.Mode,
+		arg.RawSql,
+		arg.RowLimit,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Snapshot,
+		arg.PasswordHash,
+		arg.AllowAnonymous,
+		arg.ExpiresAt,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const createSource = `-- name: CreateSource :one
 
 INSERT INTO sources (
-    name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, database, table_name, description, ttl_days, created_at, updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+    name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, database, table_name, description, ttl_days, field_roles, severity_mapping, created_at, updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
 RETURNING id
 `
 
@@ -157,6 +227,8 @@ type CreateSourceParams struct {
 	TableName         string         `json:"table_name"`
 	Description       sql.NullString `json:"description"`
 	TtlDays           int64          `json:"ttl_days"`
+	FieldRoles        string         `json:"field_roles"`
+	SeverityMapping   string         `json:"severity_mapping"`
 }
 
 // Sources
@@ -174,6 +246,8 @@ func (q *Queries) CreateSource(ctx context.Context, arg CreateSourceParams) (int
 		arg.TableName,
 		arg.Description,
 		arg.TtlDays,
+		arg.FieldRoles,
+		arg.SeverityMapping,
 	)
 	var id int64
 	err := row.Scan(&id)
@@ -201,10 +275,77 @@ func (q *Queries) CreateTeam(ctx context.Context, arg CreateTeamParams) (int64,
 	return id, err
 }
 
+const createTeamCollectionFolder = `-- name: CreateTeamCollectionFolder :one
+INSERT INTO collection_folders (team_id, name)
+VALUES (?, ?)
+RETURNING id
+`
+
+type CreateTeamCollectionFolderParams struct {
+	TeamID int64  `json:"team_id"`
+	Name   string `json:"name"`
+}
+
+// Create a new collection folder for a team
+func (q *Queries) CreateTeamCollectionFolder(ctx context.Context, arg CreateTeamCollectionFolderParams) (int64, error) {
+	row := q.queryRow(ctx, q.createTeamCollectionFolderStmt, createTeamCollectionFolder, arg.TeamID, arg.Name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createTeamDashboard = `-- name: CreateTeamDashboard :one
+INSERT INTO dashboards (team_id, name, description, definition)
+VALUES (?, ?, ?, ?)
+RETURNING id
+`
+
+type CreateTeamDashboardParams struct {
+	TeamID      int64  `json:"team_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Definition  string `json:"definition"`
+}
+
+// Create a new dashboard for a team
+func (q *Queries) CreateTeamDashboard(ctx context.Context, arg CreateTeamDashboardParams) (int64, error) {
+	row := q.queryRow(ctx, q.createTeamDashboardStmt, createTeamDashboard,
+		arg.TeamID,
+		arg.Name,
+		arg.Description,
+		arg.Definition,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createTeamQueryRevision = `-- name: CreateTeamQueryRevision :exec
+
+INSERT INTO team_query_revisions (query_id, revision, name, description, query_type, query_content, created_by)
+SELECT id,
+    COALESCE((SELECT MAX(revision) FROM team_query_revisions WHERE query_id = ?), 0) + 1,
+    name, COALESCE(description, ''), query_type, query_content, updated_by
+FROM team_queries
+WHERE id = ?
+`
+
+type CreateTeamQueryRevisionParams struct {
+	QueryID int64 `json:"query_id"`
+	ID      int64 `json:"id"`
+}
+
+// Team Query Revisions
+// Record the current state of a query as its next revision
+func (q *Queries) CreateTeamQueryRevision(ctx context.Context, arg CreateTeamQueryRevisionParams) error {
+	_, err := q.exec(ctx, q.createTeamQueryRevisionStmt, createTeamQueryRevision, arg.QueryID, arg.ID)
+	return err
+}
+
 const createTeamSourceQuery = `-- name: CreateTeamSourceQuery :one
 
-INSERT INTO team_queries (team_id, source_id, name, description, query_type, query_content)
-VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO team_queries (team_id, source_id, name, description, query_type, query_content, created_by, updated_by)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id
 `
 
@@ -215,6 +356,8 @@ type CreateTeamSourceQueryParams struct {
 	Description  sql.NullString `json:"description"`
 	QueryType    string         `json:"query_type"`
 	QueryContent string         `json:"query_content"`
+	CreatedBy    sql.NullInt64  `json:"created_by"`
+	UpdatedBy    sql.NullInt64  `json:"updated_by"`
 }
 
 // Team Queries
@@ -227,6 +370,8 @@ func (q *Queries) CreateTeamSourceQuery(ctx context.Context, arg CreateTeamSourc
 		arg.Description,
 		arg.QueryType,
 		arg.QueryContent,
+		arg.CreatedBy,
+		arg.UpdatedBy,
 	)
 	var id int64
 	err := row.Scan(&id)
@@ -278,6 +423,26 @@ func (q *Queries) DeleteAPIToken(ctx context.Context, arg DeleteAPITokenParams)
 	return err
 }
 
+const deleteColumnPolicy = `-- name: DeleteColumnPolicy :execrows
+DELETE FROM column_policies
+WHERE id = ? AND team_id = ? AND source_id = ?
+`
+
+type DeleteColumnPolicyParams struct {
+	ID       int64 `json:"id"`
+	TeamID   int64 `json:"team_id"`
+	SourceID int64 `json:"source_id"`
+}
+
+// Delete a column policy for a team and source
+func (q *Queries) DeleteColumnPolicy(ctx context.Context, arg DeleteColumnPolicyParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteColumnPolicyStmt, deleteColumnPolicy, arg.ID, arg.TeamID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteExpiredAPITokens = `-- name: DeleteExpiredAPITokens :exec
 DELETE FROM api_tokens WHERE expires_at IS NOT NULL AND expires_at < datetime('now')
 `
@@ -288,6 +453,45 @@ func (q *Queries) DeleteExpiredAPITokens(ctx context.Context) error {
 	return err
 }
 
+const deleteExpiredQueryCacheEntries = `-- name: DeleteExpiredQueryCacheEntries :execrows
+DELETE FROM query_cache WHERE expires_at <= ?
+`
+
+// Drop expired query results
+func (q *Queries) DeleteExpiredQueryCacheEntries(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredQueryCacheEntriesStmt, deleteExpiredQueryCacheEntries, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expires_at < ?
+`
+
+// Delete all sessions that expired before the given time
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredSessionsStmt, deleteExpiredSessions, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteQueryAuditLogBefore = `-- name: DeleteQueryAuditLogBefore :execrows
+DELETE FROM query_audit_log WHERE created_at < ?
+`
+
+// Delete audit entries older than the given time
+func (q *Queries) DeleteQueryAuditLogBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.deleteQueryAuditLogBeforeStmt, deleteQueryAuditLogBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteSession = `-- name: DeleteSession :exec
 DELETE FROM sessions WHERE id = ?
 `
@@ -308,6 +512,16 @@ func (q *Queries) DeleteSource(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteSourceQueryCacheEntries = `-- name: DeleteSourceQueryCacheEntries :exec
+DELETE FROM query_cache WHERE source_id = ?
+`
+
+// Drop the cached query results of a source
+func (q *Queries) DeleteSourceQueryCacheEntries(ctx context.Context, sourceID int64) error {
+	_, err := q.exec(ctx, q.deleteSourceQueryCacheEntriesStmt, deleteSourceQueryCacheEntries, sourceID)
+	return err
+}
+
 const deleteTeam = `-- name: DeleteTeam :exec
 DELETE FROM teams WHERE id = ?
 `
@@ -318,6 +532,70 @@ func (q *Queries) DeleteTeam(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteTeamCollectionFolder = `-- name: DeleteTeamCollectionFolder :execrows
+DELETE FROM collection_folders
+WHERE id = ? AND team_id = ?
+`
+
+type DeleteTeamCollectionFolderParams struct {
+	ID     int64 `json:"id"`
+	TeamID int64 `json:"team_id"`
+}
+
+// Delete a collection folder of a team, leaving its queries unfiled
+func (q *Queries) DeleteTeamCollectionFolder(ctx context.Context, arg DeleteTeamCollectionFolderParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteTeamCollectionFolderStmt, deleteTeamCollectionFolder, arg.ID, arg.TeamID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTeamDashboard = `-- name: DeleteTeamDashboard :execrows
+DELETE FROM dashboards
+WHERE id = ? AND team_id = ?
+`
+
+type DeleteTeamDashboardParams struct {
+	ID     int64 `json:"id"`
+	TeamID int64 `json:"team_id"`
+}
+
+// Delete a dashboard of a team
+func (q *Queries) DeleteTeamDashboard(ctx context.Context, arg DeleteTeamDashboardParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteTeamDashboardStmt, deleteTeamDashboard, arg.ID, arg.TeamID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTeamQueryTags = `-- name: DeleteTeamQueryTags :exec
+DELETE FROM team_query_tags WHERE query_id = ?
+`
+
+// Remove all tags of a query
+func (q *Queries) DeleteTeamQueryTags(ctx context.Context, queryID int64) error {
+	_, err := q.exec(ctx, q.deleteTeamQueryTagsStmt, deleteTeamQueryTags, queryID)
+	return err
+}
+
+const deleteTeamSourceColumnPolicies = `-- name: DeleteTeamSourceColumnPolicies :exec
+DELETE FROM column_policies
+WHERE team_id = ? AND source_id = ?
+`
+
+type DeleteTeamSourceColumnPoliciesParams struct {
+	TeamID   int64 `json:"team_id"`
+	SourceID int64 `json:"source_id"`
+}
+
+// Delete all column policies for a team's access to a source
+func (q *Queries) DeleteTeamSourceColumnPolicies(ctx context.Context, arg DeleteTeamSourceColumnPoliciesParams) error {
+	_, err := q.exec(ctx, q.deleteTeamSourceColumnPoliciesStmt, deleteTeamSourceColumnPolicies, arg.TeamID, arg.SourceID)
+	return err
+}
+
 const deleteTeamSourceQuery = `-- name: DeleteTeamSourceQuery :exec
 DELETE FROM team_queries
 WHERE id = ? AND team_id = ? AND source_id = ?
@@ -345,6 +623,25 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteUserQueryFavorite = `-- name: DeleteUserQueryFavorite :execrows
+DELETE FROM user_query_favorites
+WHERE user_id = ? AND query_id = ?
+`
+
+type DeleteUserQueryFavoriteParams struct {
+	UserID  int64 `json:"user_id"`
+	QueryID int64 `json:"query_id"`
+}
+
+// Remove a query from the favorites of a user
+func (q *Queries) DeleteUserQueryFavorite(ctx context.Context, arg DeleteUserQueryFavoriteParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteUserQueryFavoriteStmt, deleteUserQueryFavorite, arg.UserID, arg.QueryID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteUserSessions = `-- name: DeleteUserSessions :exec
 DELETE FROM sessions WHERE user_id = ?
 `
@@ -355,8 +652,26 @@ func (q *Queries) DeleteUserSessions(ctx context.Context, userID int64) error {
 	return err
 }
 
+const disableUserAPITokens = `-- name: DisableUserAPITokens :exec
+UPDATE api_tokens
+SET disabled_at = ?,
+    updated_at = datetime('now')
+WHERE user_id = ? AND disabled_at IS NULL
+`
+
+type DisableUserAPITokensParams struct {
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	UserID     int64        `json:"user_id"`
+}
+
+// Disable all active API tokens of a user
+func (q *Queries) DisableUserAPITokens(ctx context.Context, arg DisableUserAPITokensParams) error {
+	_, err := q.exec(ctx, q.disableUserAPITokensStmt, disableUserAPITokens, arg.DisabledAt, arg.UserID)
+	return err
+}
+
 const getAPIToken = `-- name: GetAPIToken :one
-SELECT id, user_id, name, token_hash, prefix, last_used_at, expires_at, created_at, updated_at FROM api_tokens WHERE id = ?
+SELECT id, user_id, name, token_hash, prefix, last_used_at, expires_at, created_at, updated_at, disabled_at, scopes, resources, allowed_cidrs FROM api_tokens WHERE id = ?
 `
 
 // Get an API token by ID
@@ -373,12 +688,16 @@ func (q *Queries) GetAPIToken(ctx context.Context, id int64) (ApiToken, error) {
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.Scopes,
+		&i.Resources,
+		&i.AllowedCidrs,
 	)
 	return i, err
 }
 
 const getAPITokenByHash = `-- name: GetAPITokenByHash :one
-SELECT id, user_id, name, token_hash, prefix, last_used_at, expires_at, created_at, updated_at FROM api_tokens WHERE token_hash = ?
+SELECT id, user_id, name, token_hash, prefix, last_used_at, expires_at, created_at, updated_at, disabled_at, scopes, resources, allowed_cidrs FROM api_tokens WHERE token_hash = ?
 `
 
 // Get an API token by its hash (for authentication)
@@ -395,6 +714,66 @@ func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiT
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisabledAt,
+		&i.Scopes,
+		&i.Resources,
+		&i.AllowedCidrs,
+	)
+	return i, err
+}
+
+const getColumnPolicy = `-- name: GetColumnPolicy :one
+SELECT id, team_id, source_id, column_name, action, pattern, replacement, created_at, updated_at FROM column_policies
+WHERE id = ? AND team_id = ? AND source_id = ?
+`
+
+type GetColumnPolicyParams struct {
+	ID       int64 `json:"id"`
+	TeamID   int64 `json:"team_id"`
+	SourceID int64 `json:"source_id"`
+}
+
+// Get a column policy by ID for a team and source
+func (q *Queries) GetColumnPolicy(ctx context.Context, arg GetColumnPolicyParams) (ColumnPolicy, error) {
+	row := q.queryRow(ctx, q.getColumnPolicyStmt, getColumnPolicy, arg.ID, arg.TeamID, arg.SourceID)
+	var i ColumnPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.TeamID,
+		&i.SourceID,
+		&i.ColumnName,
+		&i.Action,
+		&i.Pattern,
+		&i.Replacement,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getQueryCacheEntry = `-- name: GetQueryCacheEntry :one
+
+SELECT cache_key, source_id, kind, value, size, created_at, expires_at FROM query_cache WHERE cache_key = ? AND expires_at > ?
+`
+
+type GetQueryCacheEntryParams struct {
+	CacheKey  string    `json:"cache_key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Query result cache
+// Get a cached query result that hasn't expired
+func (q *Queries) GetQueryCacheEntry(ctx context.Context, arg GetQueryCacheEntryParams) (QueryCache, error) {
+	row := q.queryRow(ctx, q.getQueryCacheEntryStmt, getQueryCacheEntry, arg.CacheKey, arg.ExpiresAt)
+	var i QueryCache
+	err := row.Scan(
+		&i.CacheKey,
+		&i.SourceID,
+		&i.Kind,
+		&i.Value,
+		&i.Size,
+		&i.CreatedAt,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
@@ -416,8 +795,70 @@ func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
 	return i, err
 }
 
+const getShareLinkBySlug = `-- name: GetShareLinkBySlug :one
+SELECT l.id, l.slug, l.team_id, l.source_id, l.created_by, l.title, l.mode, l.raw_sql, l.row_limit, l.start_time, l.end_time, l.snapshot, l.password_hash, l.allow_anonymous, l.view_count, l.last_viewed_at, l.expires_at, l.revoked_at, l.created_at, u.email AS created_by_email, t.allow_anonymous_shares AS team_allow_anonymous
+FROM share_links l
+JOIN users u ON u.id = l.created_by
+JOIN teams t ON t.id = l.team_id
+WHERE l.slug = ?
+`
+
+type GetShareLinkBySlugRow struct {
+	ID                 int64          `json:"id"`
+	Slug               string         `json:"slug"`
+	TeamID             int64          `json:"team_id"`
+	SourceID           int64          `json:"source_id"`
+	CreatedBy          int64          `json:"created_by"`
+	Title              string         `json:"title"`
+	Mode               string         `json:"mode"`
+	RawSql             string         `json:"raw_sql"`
+	RowLimit           int64          `json:"row_limit"`
+	StartTime          sql.NullTime   `json:"start_time"`
+	EndTime            sql.NullTime   `json:"end_time"`
+	Snapshot           sql.NullString `json:"snapshot"`
+	PasswordHash       string         `json:"password_hash"`
+	AllowAnonymous     int64          `json:"allow_anonymous"`
+	ViewCount          int64          `json:"view_count"`
+	LastViewedAt       sql.NullTime   `json:"last_viewed_at"`
+	ExpiresAt          time.Time      `json:"expires_at"`
+	RevokedAt          sql.NullTime   `json:"revoked_at"`
+	CreatedAt          time.Time      `json:"created_at"`
+	CreatedByEmail     string         `json:"created_by_email"`
+	TeamAllowAnonymous int64          `json:"team_allow_anonymous"`
+}
+
+// Get a share link by its slug with the creator and the team setting for anonymous access
+func (q *Queries) GetShareLinkBySlug(ctx context.Context, slug string) (GetShareLinkBySlugRow, error) {
+	row := q.queryRow(ctx, q.getShareLinkBySlugStmt, getShareLinkBySlug, slug)
+	var i GetShareLinkBySlugRow
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.TeamID,
+		&i.SourceID,
+		&i.CreatedBy,
+		&i.Title,
+		&i.Mode,
+		&i.RawSql,
+		&i.RowLimit,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Snapshot,
+		&i.PasswordHash,
+		&i.AllowAnonymous,
+		&i.ViewCount,
+		&i.LastViewedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.CreatedByEmail,
+		&i.TeamAllowAnonymous,
+	)
+	return i, err
+}
+
 const getSource = `-- name: GetSource :one
-SELECT id, name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, "database", table_name, description, ttl_days, created_at, updated_at FROM sources WHERE id = ?
+SELECT id, name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, "database", table_name, description, ttl_days, created_at, updated_at, provisioned, field_roles, severity_mapping FROM sources WHERE id = ?
 `
 
 // Get a single source by ID
@@ -439,12 +880,15 @@ func (q *Queries) GetSource(ctx context.Context, id int64) (Source, error) {
 		&i.TtlDays,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Provisioned,
+		&i.FieldRoles,
+		&i.SeverityMapping,
 	)
 	return i, err
 }
 
 const getSourceByName = `-- name: GetSourceByName :one
-SELECT id, name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, "database", table_name, description, ttl_days, created_at, updated_at FROM sources WHERE database = ? AND table_name = ?
+SELECT id, name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, "database", table_name, description, ttl_days, created_at, updated_at, provisioned, field_roles, severity_mapping FROM sources WHERE database = ? AND table_name = ?
 `
 
 type GetSourceByNameParams struct {
@@ -471,12 +915,34 @@ func (q *Queries) GetSourceByName(ctx context.Context, arg GetSourceByNameParams
 		&i.TtlDays,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Provisioned,
+		&i.FieldRoles,
+		&i.SeverityMapping,
+	)
+	return i, err
+}
+
+const getSourceSchemaSnapshot = `-- name: GetSourceSchemaSnapshot :one
+
+SELECT source_id, columns, sort_keys, taken_at FROM source_schema_snapshots WHERE source_id = ?
+`
+
+// Source schema drift
+// Get the latest schema snapshot of a source
+func (q *Queries) GetSourceSchemaSnapshot(ctx context.Context, sourceID int64) (SourceSchemaSnapshot, error) {
+	row := q.queryRow(ctx, q.getSourceSchemaSnapshotStmt, getSourceSchemaSnapshot, sourceID)
+	var i SourceSchemaSnapshot
+	err := row.Scan(
+		&i.SourceID,
+		&i.Columns,
+		&i.SortKeys,
+		&i.TakenAt,
 	)
 	return i, err
 }
 
 const getTeam = `-- name: GetTeam :one
-SELECT id, name, description, created_at, updated_at FROM teams WHERE id = ?
+SELECT id, name, description, created_at, updated_at, allow_anonymous_shares, provisioned FROM teams WHERE id = ?
 `
 
 // Get a team by ID
@@ -489,12 +955,14 @@ func (q *Queries) GetTeam(ctx context.Context, id int64) (Team, error) {
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AllowAnonymousShares,
+		&i.Provisioned,
 	)
 	return i, err
 }
 
 const getTeamByName = `-- name: GetTeamByName :one
-SELECT id, name, description, created_at, updated_at FROM teams WHERE name = ?
+SELECT id, name, description, created_at, updated_at, allow_anonymous_shares, provisioned FROM teams WHERE name = ?
 `
 
 // Get a team by its name
@@ -507,12 +975,64 @@ func (q *Queries) GetTeamByName(ctx context.Context, name string) (Team, error)
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AllowAnonymousShares,
+		&i.Provisioned,
+	)
+	return i, err
+}
+
+const getTeamCollectionFolder = `-- name: GetTeamCollectionFolder :one
+SELECT id, team_id, name, created_at, updated_at FROM collection_folders
+WHERE id = ? AND team_id = ?
+`
+
+type GetTeamCollectionFolderParams struct {
+	ID     int64 `json:"id"`
+	TeamID int64 `json:"team_id"`
+}
+
+// Get a collection folder by ID for a team
+func (q *Queries) GetTeamCollectionFolder(ctx context.Context, arg GetTeamCollectionFolderParams) (CollectionFolder, error) {
+	row := q.queryRow(ctx, q.getTeamCollectionFolderStmt, getTeamCollectionFolder, arg.ID, arg.TeamID)
+	var i CollectionFolder
+	err := row.Scan(
+		&i.ID,
+		&i.TeamID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTeamDashboard = `-- name: GetTeamDashboard :one
+SELECT id, team_id, name, description, definition, created_at, updated_at FROM dashboards
+WHERE id = ? AND team_id = ?
+`
+
+type GetTeamDashboardParams struct {
+	ID     int64 `json:"id"`
+	TeamID int64 `json:"team_id"`
+}
+
+// Get a dashboard by ID for a team
+func (q *Queries) GetTeamDashboard(ctx context.Context, arg GetTeamDashboardParams) (Dashboard, error) {
+	row := q.queryRow(ctx, q.getTeamDashboardStmt, getTeamDashboard, arg.ID, arg.TeamID)
+	var i Dashboard
+	err := row.Scan(
+		&i.ID,
+		&i.TeamID,
+		&i.Name,
+		&i.Description,
+		&i.Definition,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
 const getTeamMember = `-- name: GetTeamMember :one
-SELECT team_id, user_id, role, created_at FROM team_members WHERE team_id = ? AND user_id = ?
+SELECT team_id, user_id, role, created_at, provisioned, oidc_synced FROM team_members WHERE team_id = ? AND user_id = ?
 `
 
 type GetTeamMemberParams struct {
@@ -529,12 +1049,58 @@ func (q *Queries) GetTeamMember(ctx context.Context, arg GetTeamMemberParams) (T
 		&i.UserID,
 		&i.Role,
 		&i.CreatedAt,
+		&i.Provisioned,
+		&i.OidcSynced,
+	)
+	return i, err
+}
+
+const getTeamQueryRevision = `-- name: GetTeamQueryRevision :one
+SELECT r.id, r.query_id, r.revision, r.name, r.description, r.query_type, r.query_content, r.created_by, r.created_at, u.email AS created_by_email
+FROM team_query_revisions r
+LEFT JOIN users u ON u.id = r.created_by
+WHERE r.query_id = ? AND r.revision = ?
+`
+
+type GetTeamQueryRevisionParams struct {
+	QueryID  int64 `json:"query_id"`
+	Revision int64 `json:"revision"`
+}
+
+type GetTeamQueryRevisionRow struct {
+	ID             int64          `json:"id"`
+	QueryID        int64          `json:"query_id"`
+	Revision       int64          `json:"revision"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	QueryType      string         `json:"query_type"`
+	QueryContent   string         `json:"query_content"`
+	CreatedBy      sql.NullInt64  `json:"created_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	CreatedByEmail sql.NullString `json:"created_by_email"`
+}
+
+// Get a single revision of a query
+func (q *Queries) GetTeamQueryRevision(ctx context.Context, arg GetTeamQueryRevisionParams) (GetTeamQueryRevisionRow, error) {
+	row := q.queryRow(ctx, q.getTeamQueryRevisionStmt, getTeamQueryRevision, arg.QueryID, arg.Revision)
+	var i GetTeamQueryRevisionRow
+	err := row.Scan(
+		&i.ID,
+		&i.QueryID,
+		&i.Revision,
+		&i.Name,
+		&i.Description,
+		&i.QueryType,
+		&i.QueryContent,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.CreatedByEmail,
 	)
 	return i, err
 }
 
 const getTeamSourceQuery = `-- name: GetTeamSourceQuery :one
-SELECT id, team_id, source_id, name, description, query_type, query_content, created_at, updated_at FROM team_queries
+SELECT id, team_id, source_id, name, description, query_type, query_content, created_at, updated_at, created_by, updated_by, folder_id, last_used_at, provisioned FROM team_queries
 WHERE id = ? AND team_id = ? AND source_id = ?
 `
 
@@ -558,18 +1124,41 @@ func (q *Queries) GetTeamSourceQuery(ctx context.Context, arg GetTeamSourceQuery
 		&i.QueryContent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.UpdatedBy,
+		&i.FolderID,
+		&i.LastUsedAt,
+		&i.Provisioned,
 	)
 	return i, err
 }
 
-const getUser = `-- name: GetUser :one
-SELECT id, email, full_name, role, status, last_login_at, last_active_at, created_at, updated_at FROM users WHERE id = ?
+const getTeamSourceRowFilter = `-- name: GetTeamSourceRowFilter :one
+SELECT row_filter FROM team_sources
+WHERE team_id = ? AND source_id = ?
 `
 
-// Get a user by ID
-func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
-	row := q.queryRow(ctx, q.getUserStmt, getUser, id)
-	var i User
+type GetTeamSourceRowFilterParams struct {
+	TeamID   int64 `json:"team_id"`
+	SourceID int64 `json:"source_id"`
+}
+
+// Get the row-level filter expression for a team's access to a source
+func (q *Queries) GetTeamSourceRowFilter(ctx context.Context, arg GetTeamSourceRowFilterParams) (string, error) {
+	row := q.queryRow(ctx, q.getTeamSourceRowFilterStmt, getTeamSourceRowFilter, arg.TeamID, arg.SourceID)
+	var row_filter string
+	err := row.Scan(&row_filter)
+	return row_filter, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, email, full_name, role, status, last_login_at, last_active_at, created_at, updated_at, provisioned, oidc_admin FROM users WHERE id = ?
+`
+
+// Get a user by ID
+func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
+	row := q.queryRow(ctx, q.getUserStmt, getUser, id)
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Email,
@@ -580,12 +1169,14 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.LastActiveAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Provisioned,
+		&i.OidcAdmin,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, full_name, role, status, last_login_at, last_active_at, created_at, updated_at FROM users WHERE email = ?
+SELECT id, email, full_name, role, status, last_login_at, last_active_at, created_at, updated_at, provisioned, oidc_admin FROM users WHERE email = ?
 `
 
 // Get a user by email
@@ -602,12 +1193,150 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.LastActiveAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Provisioned,
+		&i.OidcAdmin,
 	)
 	return i, err
 }
 
+const insertOIDCSyncEvent = `-- name: InsertOIDCSyncEvent :exec
+INSERT INTO oidc_sync_events (user_id, user_email, team_id, team_name, action, role, groups, reason, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertOIDCSyncEventParams struct {
+	UserID    sql.NullInt64 `json:"user_id"`
+	UserEmail string        `json:"user_email"`
+	TeamID    sql.NullInt64 `json:"team_id"`
+	TeamName  string        `json:"team_name"`
+	Action    string        `json:"action"`
+	Role      string        `json:"role"`
+	Groups    string        `json:"groups"`
+	Reason    string        `json:"reason"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Record a decision made by OIDC group sync
+func (q *Queries) InsertOIDCSyncEvent(ctx context.Context, arg InsertOIDCSyncEventParams) error {
+	_, err := q.exec(ctx, q.insertOIDCSyncEventStmt, insertOIDCSyncEvent,
+		arg.UserID,
+		arg.UserEmail,
+		arg.TeamID,
+		arg.TeamName,
+		arg.Action,
+		arg.Role,
+		arg.Groups,
+		arg.Reason,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertQueryAuditEntry = `-- name: InsertQueryAuditEntry :exec
+
+INSERT INTO query_audit_log (
+    user_id, user_email, api_token_id, team_id, source_id, query_type,
+    raw_sql, final_sql, duration_ms, rows_returned, error, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertQueryAuditEntryParams struct {
+	UserID       sql.NullInt64 `json:"user_id"`
+	UserEmail    string        `json:"user_email"`
+	ApiTokenID   sql.NullInt64 `json:"api_token_id"`
+	TeamID       sql.NullInt64 `json:"team_id"`
+	SourceID     sql.NullInt64 `json:"source_id"`
+	QueryType    string        `json:"query_type"`
+	RawSql       string        `json:"raw_sql"`
+	FinalSql     string        `json:"final_sql"`
+	DurationMs   int64         `json:"duration_ms"`
+	RowsReturned int64         `json:"rows_returned"`
+	Error        string        `json:"error"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// Query Audit Log
+// Record an executed query
+func (q *Queries) InsertQueryAuditEntry(ctx context.Context, arg InsertQueryAuditEntryParams) error {
+	_, err := q.exec(ctx, q.insertQueryAuditEntryStmt, insertQueryAuditEntry,
+		arg.UserID,
+		arg.UserEmail,
+		arg.ApiTokenID,
+		arg.TeamID,
+		arg.SourceID,
+		arg.QueryType,
+		arg.RawSql,
+		arg.FinalSql,
+		arg.DurationMs,
+		arg.RowsReturned,
+		arg.Error,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertSourceRetentionChange = `-- name: InsertSourceRetentionChange :exec
+
+INSERT INTO source_retention_changes (source_id, user_id, old_ttl_days, new_ttl_days, status, statement, error, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertSourceRetentionChangeParams struct {
+	SourceID   int64         `json:"source_id"`
+	UserID     sql.NullInt64 `json:"user_id"`
+	OldTtlDays int64         `json:"old_ttl_days"`
+	NewTtlDays int64         `json:"new_ttl_days"`
+	Status     string        `json:"status"`
+	Statement  string        `json:"statement"`
+	Error      string        `json:"error"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// Source retention changes
+// Record a change of a source's retention
+func (q *Queries) InsertSourceRetentionChange(ctx context.Context, arg InsertSourceRetentionChangeParams) error {
+	_, err := q.exec(ctx, q.insertSourceRetentionChangeStmt, insertSourceRetentionChange,
+		arg.SourceID,
+		arg.UserID,
+		arg.OldTtlDays,
+		arg.NewTtlDays,
+		arg.Status,
+		arg.Statement,
+		arg.Error,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertSourceSchemaChange = `-- name: InsertSourceSchemaChange :exec
+INSERT INTO source_schema_changes (source_id, change_type, column_name, old_value, new_value, detected_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type InsertSourceSchemaChangeParams struct {
+	SourceID   int64     `json:"source_id"`
+	ChangeType string    `json:"change_type"`
+	ColumnName string    `json:"column_name"`
+	OldValue   string    `json:"old_value"`
+	NewValue   string    `json:"new_value"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Record a schema change detected for a source
+func (q *Queries) InsertSourceSchemaChange(ctx context.Context, arg InsertSourceSchemaChangeParams) error {
+	_, err := q.exec(ctx, q.insertSourceSchemaChangeStmt, insertSourceSchemaChange,
+		arg.SourceID,
+		arg.ChangeType,
+		arg.ColumnName,
+		arg.OldValue,
+		arg.NewValue,
+		arg.DetectedAt,
+	)
+	return err
+}
+
 const listAPITokensForUser = `-- name: ListAPITokensForUser :many
-SELECT id, user_id, name, token_hash, prefix, last_used_at, expires_at, created_at, updated_at FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC
+SELECT id, user_id, name, token_hash, prefix, last_used_at, expires_at, created_at, updated_at, disabled_at, scopes, resources, allowed_cidrs FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC
 `
 
 // List all API tokens for a user
@@ -630,6 +1359,100 @@ func (q *Queries) ListAPITokensForUser(ctx context.Context, userID int64) ([]Api
 			&i.ExpiresAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DisabledAt,
+			&i.Scopes,
+			&i.Resources,
+			&i.AllowedCidrs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listColumnPolicies = `-- name: ListColumnPolicies :many
+
+SELECT id, team_id, source_id, column_name, action, pattern, replacement, created_at, updated_at FROM column_policies
+WHERE team_id = ? AND source_id = ?
+ORDER BY column_name
+`
+
+type ListColumnPoliciesParams struct {
+	TeamID   int64 `json:"team_id"`
+	SourceID int64 `json:"source_id"`
+}
+
+// Column Policies
+// List all column policies for a team's access to a source
+func (q *Queries) ListColumnPolicies(ctx context.Context, arg ListColumnPoliciesParams) ([]ColumnPolicy, error) {
+	rows, err := q.query(ctx, q.listColumnPoliciesStmt, listColumnPolicies, arg.TeamID, arg.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ColumnPolicy{}
+	for rows.Next() {
+		var i ColumnPolicy
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.SourceID,
+			&i.ColumnName,
+			&i.Action,
+			&i.Pattern,
+			&i.Replacement,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQueriesBySource = `-- name: ListQueriesBySource :many
+SELECT id, team_id, source_id, name, description, query_type, query_content, created_at, updated_at, created_by, updated_by, folder_id, last_used_at, provisioned FROM team_queries WHERE source_id = ? ORDER BY team_id, created_at DESC
+`
+
+// List the queries of all teams for a specific source
+func (q *Queries) ListQueriesBySource(ctx context.Context, sourceID int64) ([]TeamQuery, error) {
+	rows, err := q.query(ctx, q.listQueriesBySourceStmt, listQueriesBySource, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamQuery{}
+	for rows.Next() {
+		var i TeamQuery
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.SourceID,
+			&i.Name,
+			&i.Description,
+			&i.QueryType,
+			&i.QueryContent,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CreatedBy,
+			&i.UpdatedBy,
+			&i.FolderID,
+			&i.LastUsedAt,
+			&i.Provisioned,
 		); err != nil {
 			return nil, err
 		}
@@ -645,7 +1468,7 @@ func (q *Queries) ListAPITokensForUser(ctx context.Context, userID int64) ([]Api
 }
 
 const listQueriesByTeamAndSource = `-- name: ListQueriesByTeamAndSource :many
-SELECT id, team_id, source_id, name, description, query_type, query_content, created_at, updated_at FROM team_queries WHERE team_id = ? AND source_id = ? ORDER BY created_at DESC
+SELECT id, team_id, source_id, name, description, query_type, query_content, created_at, updated_at, created_by, updated_by, folder_id, last_used_at, provisioned FROM team_queries WHERE team_id = ? AND source_id = ? ORDER BY created_at DESC
 `
 
 type ListQueriesByTeamAndSourceParams struct {
@@ -673,6 +1496,101 @@ func (q *Queries) ListQueriesByTeamAndSource(ctx context.Context, arg ListQuerie
 			&i.QueryContent,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CreatedBy,
+			&i.UpdatedBy,
+			&i.FolderID,
+			&i.LastUsedAt,
+			&i.Provisioned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSourceRetentionChanges = `-- name: ListSourceRetentionChanges :many
+SELECT id, source_id, user_id, old_ttl_days, new_ttl_days, status, statement, error, created_at FROM source_retention_changes
+WHERE source_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ?
+`
+
+type ListSourceRetentionChangesParams struct {
+	SourceID int64 `json:"source_id"`
+	Limit    int64 `json:"limit"`
+}
+
+// List the retention changes of a source, newest first
+func (q *Queries) ListSourceRetentionChanges(ctx context.Context, arg ListSourceRetentionChangesParams) ([]SourceRetentionChange, error) {
+	rows, err := q.query(ctx, q.listSourceRetentionChangesStmt, listSourceRetentionChanges, arg.SourceID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SourceRetentionChange{}
+	for rows.Next() {
+		var i SourceRetentionChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.SourceID,
+			&i.UserID,
+			&i.OldTtlDays,
+			&i.NewTtlDays,
+			&i.Status,
+			&i.Statement,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSourceSchemaChanges = `-- name: ListSourceSchemaChanges :many
+SELECT id, source_id, change_type, column_name, old_value, new_value, detected_at FROM source_schema_changes
+WHERE source_id = ?
+ORDER BY detected_at DESC, id DESC
+LIMIT ?
+`
+
+type ListSourceSchemaChangesParams struct {
+	SourceID int64 `json:"source_id"`
+	Limit    int64 `json:"limit"`
+}
+
+// List the schema changes of a source, newest first
+func (q *Queries) ListSourceSchemaChanges(ctx context.Context, arg ListSourceSchemaChangesParams) ([]SourceSchemaChange, error) {
+	rows, err := q.query(ctx, q.listSourceSchemaChangesStmt, listSourceSchemaChanges, arg.SourceID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SourceSchemaChange{}
+	for rows.Next() {
+		var i SourceSchemaChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.SourceID,
+			&i.ChangeType,
+			&i.ColumnName,
+			&i.OldValue,
+			&i.NewValue,
+			&i.DetectedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -688,7 +1606,7 @@ func (q *Queries) ListQueriesByTeamAndSource(ctx context.Context, arg ListQuerie
 }
 
 const listSourceTeams = `-- name: ListSourceTeams :many
-SELECT t.id, t.name, t.description, t.created_at, t.updated_at
+SELECT t.id, t.name, t.description, t.created_at, t.updated_at, t.allow_anonymous_shares, t.provisioned
 FROM teams t
 JOIN team_sources ts ON t.id = ts.team_id
 WHERE ts.source_id = ?
@@ -711,6 +1629,8 @@ func (q *Queries) ListSourceTeams(ctx context.Context, sourceID int64) ([]Team,
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AllowAnonymousShares,
+			&i.Provisioned,
 		); err != nil {
 			return nil, err
 		}
@@ -726,7 +1646,7 @@ func (q *Queries) ListSourceTeams(ctx context.Context, sourceID int64) ([]Team,
 }
 
 const listSources = `-- name: ListSources :many
-SELECT id, name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, "database", table_name, description, ttl_days, created_at, updated_at FROM sources ORDER BY created_at DESC
+SELECT id, name, _meta_is_auto_created, _meta_ts_field, _meta_severity_field, host, username, password, "database", table_name, description, ttl_days, created_at, updated_at, provisioned, field_roles, severity_mapping FROM sources ORDER BY created_at DESC
 `
 
 // Get all sources ordered by creation date
@@ -754,6 +1674,9 @@ func (q *Queries) ListSources(ctx context.Context) ([]Source, error) {
 			&i.TtlDays,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Provisioned,
+			&i.FieldRoles,
+			&i.SeverityMapping,
 		); err != nil {
 			return nil, err
 		}
@@ -769,7 +1692,7 @@ func (q *Queries) ListSources(ctx context.Context) ([]Source, error) {
 }
 
 const listSourcesForUser = `-- name: ListSourcesForUser :many
-SELECT DISTINCT s.id, s.name, s._meta_is_auto_created, s._meta_ts_field, s._meta_severity_field, s.host, s.username, s.password, s."database", s.table_name, s.description, s.ttl_days, s.created_at, s.updated_at FROM sources s
+SELECT DISTINCT s.id, s.name, s._meta_is_auto_created, s._meta_ts_field, s._meta_severity_field, s.host, s.username, s.password, s."database", s.table_name, s.description, s.ttl_days, s.created_at, s.updated_at, s.provisioned, s.field_roles, s.severity_mapping FROM sources s
 JOIN team_sources ts ON s.id = ts.source_id
 JOIN team_members tm ON ts.team_id = tm.team_id
 WHERE tm.user_id = ?
@@ -801,6 +1724,98 @@ func (q *Queries) ListSourcesForUser(ctx context.Context, userID int64) ([]Sourc
 			&i.TtlDays,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Provisioned,
+			&i.FieldRoles,
+			&i.SeverityMapping,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamCollectionFolders = `-- name: ListTeamCollectionFolders :many
+
+SELECT f.id, f.team_id, f.name, f.created_at, f.updated_at, (SELECT COUNT(*) FROM team_queries q WHERE q.folder_id = f.id) AS query_count
+FROM collection_folders f
+WHERE f.team_id = ?
+ORDER BY f.name
+`
+
+type ListTeamCollectionFoldersRow struct {
+	ID         int64     `json:"id"`
+	TeamID     int64     `json:"team_id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	QueryCount int64     `json:"query_count"`
+}
+
+// Collection Folders
+// List all collection folders of a team with their query counts
+func (q *Queries) ListTeamCollectionFolders(ctx context.Context, teamID int64) ([]ListTeamCollectionFoldersRow, error) {
+	rows, err := q.query(ctx, q.listTeamCollectionFoldersStmt, listTeamCollectionFolders, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTeamCollectionFoldersRow{}
+	for rows.Next() {
+		var i ListTeamCollectionFoldersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.QueryCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamDashboards = `-- name: ListTeamDashboards :many
+
+SELECT id, team_id, name, description, definition, created_at, updated_at FROM dashboards
+WHERE team_id = ?
+ORDER BY name
+`
+
+// Dashboards
+// List all dashboards of a team
+func (q *Queries) ListTeamDashboards(ctx context.Context, teamID int64) ([]Dashboard, error) {
+	rows, err := q.query(ctx, q.listTeamDashboardsStmt, listTeamDashboards, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Dashboard{}
+	for rows.Next() {
+		var i Dashboard
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.Name,
+			&i.Description,
+			&i.Definition,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -816,7 +1831,7 @@ func (q *Queries) ListSourcesForUser(ctx context.Context, userID int64) ([]Sourc
 }
 
 const listTeamMembers = `-- name: ListTeamMembers :many
-SELECT tm.team_id, tm.user_id, tm.role, tm.created_at
+SELECT tm.team_id, tm.user_id, tm.role, tm.created_at, tm.provisioned, tm.oidc_synced
 FROM team_members tm
 WHERE tm.team_id = ?
 ORDER BY tm.created_at
@@ -837,6 +1852,8 @@ func (q *Queries) ListTeamMembers(ctx context.Context, teamID int64) ([]TeamMemb
 			&i.UserID,
 			&i.Role,
 			&i.CreatedAt,
+			&i.Provisioned,
+			&i.OidcSynced,
 		); err != nil {
 			return nil, err
 		}
@@ -852,7 +1869,7 @@ func (q *Queries) ListTeamMembers(ctx context.Context, teamID int64) ([]TeamMemb
 }
 
 const listTeamMembersWithDetails = `-- name: ListTeamMembersWithDetails :many
-SELECT tm.team_id, tm.user_id, tm.role, tm.created_at, u.email, u.full_name
+SELECT tm.team_id, tm.user_id, tm.role, tm.created_at, tm.provisioned, tm.oidc_synced, u.email, u.full_name
 FROM team_members tm
 JOIN users u ON tm.user_id = u.id
 WHERE tm.team_id = ?
@@ -860,12 +1877,14 @@ ORDER BY tm.created_at ASC
 `
 
 type ListTeamMembersWithDetailsRow struct {
-	TeamID    int64     `json:"team_id"`
-	UserID    int64     `json:"user_id"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	Email     string    `json:"email"`
-	FullName  string    `json:"full_name"`
+	TeamID      int64     `json:"team_id"`
+	UserID      int64     `json:"user_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	Provisioned int64     `json:"provisioned"`
+	OidcSynced  int64     `json:"oidc_synced"`
+	Email       string    `json:"email"`
+	FullName    string    `json:"full_name"`
 }
 
 // List all members of a team with user details
@@ -883,6 +1902,8 @@ func (q *Queries) ListTeamMembersWithDetails(ctx context.Context, teamID int64)
 			&i.UserID,
 			&i.Role,
 			&i.CreatedAt,
+			&i.Provisioned,
+			&i.OidcSynced,
 			&i.Email,
 			&i.FullName,
 		); err != nil {
@@ -899,31 +1920,195 @@ func (q *Queries) ListTeamMembersWithDetails(ctx context.Context, teamID int64)
 	return items, nil
 }
 
-const listTeamSources = `-- name: ListTeamSources :many
-SELECT s.id, s.name, s._meta_is_auto_created, s._meta_ts_field, s._meta_severity_field, s.host, s.username, s.password, s."database", s.table_name, s.description, s.ttl_days, s.created_at, s.updated_at
-FROM sources s
-JOIN team_sources ts ON s.id = ts.source_id
-WHERE ts.team_id = ?
-ORDER BY s.created_at DESC
+const listTeamQueryRevisions = `-- name: ListTeamQueryRevisions :many
+SELECT r.id, r.query_id, r.revision, r.name, r.description, r.query_type, r.query_content, r.created_by, r.created_at, u.email AS created_by_email
+FROM team_query_revisions r
+LEFT JOIN users u ON u.id = r.created_by
+WHERE r.query_id = ?
+ORDER BY r.revision DESC
 `
 
-// List all data sources in a team
-func (q *Queries) ListTeamSources(ctx context.Context, teamID int64) ([]Source, error) {
-	rows, err := q.query(ctx, q.listTeamSourcesStmt, listTeamSources, teamID)
+type ListTeamQueryRevisionsRow struct {
+	ID             int64          `json:"id"`
+	QueryID        int64          `json:"query_id"`
+	Revision       int64          `json:"revision"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	QueryType      string         `json:"query_type"`
+	QueryContent   string         `json:"query_content"`
+	CreatedBy      sql.NullInt64  `json:"created_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	CreatedByEmail sql.NullString `json:"created_by_email"`
+}
+
+// List all revisions of a query, newest first
+func (q *Queries) ListTeamQueryRevisions(ctx context.Context, queryID int64) ([]ListTeamQueryRevisionsRow, error) {
+	rows, err := q.query(ctx, q.listTeamQueryRevisionsStmt, listTeamQueryRevisions, queryID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Source{}
+	items := []ListTeamQueryRevisionsRow{}
 	for rows.Next() {
-		var i Source
+		var i ListTeamQueryRevisionsRow
 		if err := rows.Scan(
 			&i.ID,
+			&i.QueryID,
+			&i.Revision,
 			&i.Name,
-			&i.MetaIsAutoCreated,
-			&i.MetaTsField,
-			&i.MetaSeverityField,
-			&i.Host,
+			&i.Description,
+			&i.QueryType,
+			&i.QueryContent,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.CreatedByEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamQueryTags = `-- name: ListTeamQueryTags :many
+
+SELECT tag FROM team_query_tags
+WHERE query_id = ?
+ORDER BY tag
+`
+
+// Team Query Tags
+// List the tags of a query
+func (q *Queries) ListTeamQueryTags(ctx context.Context, queryID int64) ([]string, error) {
+	rows, err := q.query(ctx, q.listTeamQueryTagsStmt, listTeamQueryTags, queryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamShareLinks = `-- name: ListTeamShareLinks :many
+SELECT l.id, l.slug, l.team_id, l.source_id, l.created_by, l.title, l.mode, l.raw_sql, l.row_limit, l.start_time, l.end_time,
+    l.password_hash != '' AS has_password, l.allow_anonymous, l.view_count, l.last_viewed_at, l.expires_at, l.revoked_at, l.created_at,
+    u.email AS created_by_email
+FROM share_links l
+JOIN users u ON u.id = l.created_by
+WHERE l.team_id = ?
+ORDER BY l.created_at DESC, l.id DESC
+`
+
+type ListTeamShareLinksRow struct {
+	ID             int64        `json:"id"`
+	Slug           string       `json:"slug"`
+	TeamID         int64        `json:"team_id"`
+	SourceID       int64        `json:"source_id"`
+	CreatedBy      int64        `json:"created_by"`
+	Title          string       `json:"title"`
+	Mode           string       `json:"mode"`
+	RawSql         string       `json:"raw_sql"`
+	RowLimit       int64        `json:"row_limit"`
+	StartTime      sql.NullTime `json:"start_time"`
+	EndTime        sql.NullTime `json:"end_time"`
+	HasPassword    int64        `json:"has_password"`
+	AllowAnonymous int64        `json:"allow_anonymous"`
+	ViewCount      int64        `json:"view_count"`
+	LastViewedAt   sql.NullTime `json:"last_viewed_at"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	RevokedAt      sql.NullTime `json:"revoked_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	CreatedByEmail string       `json:"created_by_email"`
+}
+
+// List the share links of a team, newest first, without snapshots
+func (q *Queries) ListTeamShareLinks(ctx context.Context, teamID int64) ([]ListTeamShareLinksRow, error) {
+	rows, err := q.query(ctx, q.listTeamShareLinksStmt, listTeamShareLinks, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTeamShareLinksRow{}
+	for rows.Next() {
+		var i ListTeamShareLinksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.TeamID,
+			&i.SourceID,
+			&i.CreatedBy,
+			&i.Title,
+			&i.Mode,
+			&i.RawSql,
+			&i.RowLimit,
+			&i.StartTime,
+			&i.EndTime,
+			&i.HasPassword,
+			&i.AllowAnonymous,
+			&i.ViewCount,
+			&i.LastViewedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.CreatedByEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTeamSources = `-- name: ListTeamSources :many
+SELECT s.id, s.name, s._meta_is_auto_created, s._meta_ts_field, s._meta_severity_field, s.host, s.username, s.password, s."database", s.table_name, s.description, s.ttl_days, s.created_at, s.updated_at, s.provisioned, s.field_roles, s.severity_mapping
+FROM sources s
+JOIN team_sources ts ON s.id = ts.source_id
+WHERE ts.team_id = ?
+ORDER BY s.created_at DESC
+`
+
+// List all data sources in a team
+func (q *Queries) ListTeamSources(ctx context.Context, teamID int64) ([]Source, error) {
+	rows, err := q.query(ctx, q.listTeamSourcesStmt, listTeamSources, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Source{}
+	for rows.Next() {
+		var i Source
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.MetaIsAutoCreated,
+			&i.MetaTsField,
+			&i.MetaSeverityField,
+			&i.Host,
 			&i.Username,
 			&i.Password,
 			&i.Database,
@@ -932,6 +2117,9 @@ func (q *Queries) ListTeamSources(ctx context.Context, teamID int64) ([]Source,
 			&i.TtlDays,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Provisioned,
+			&i.FieldRoles,
+			&i.SeverityMapping,
 		); err != nil {
 			return nil, err
 		}
@@ -946,8 +2134,40 @@ func (q *Queries) ListTeamSources(ctx context.Context, teamID int64) ([]Source,
 	return items, nil
 }
 
+const listTeamTags = `-- name: ListTeamTags :many
+SELECT DISTINCT t.tag
+FROM team_query_tags t
+JOIN team_queries q ON q.id = t.query_id
+WHERE q.team_id = ?
+ORDER BY t.tag
+`
+
+// List the distinct tags used by the queries of a team
+func (q *Queries) ListTeamTags(ctx context.Context, teamID int64) ([]string, error) {
+	rows, err := q.query(ctx, q.listTeamTagsStmt, listTeamTags, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTeams = `-- name: ListTeams :many
-SELECT t.id, t.name, t.description, t.created_at, t.updated_at, COUNT(tm.user_id) as member_count
+SELECT t.id, t.name, t.description, t.created_at, t.updated_at, t.allow_anonymous_shares, t.provisioned, COUNT(tm.user_id) as member_count
 FROM teams t
 LEFT JOIN team_members tm ON t.id = tm.team_id
 GROUP BY t.id
@@ -955,12 +2175,14 @@ ORDER BY t.created_at DESC
 `
 
 type ListTeamsRow struct {
-	ID          int64          `json:"id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	MemberCount int64          `json:"member_count"`
+	ID                   int64          `json:"id"`
+	Name                 string         `json:"name"`
+	Description          sql.NullString `json:"description"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	AllowAnonymousShares int64          `json:"allow_anonymous_shares"`
+	Provisioned          int64          `json:"provisioned"`
+	MemberCount          int64          `json:"member_count"`
 }
 
 // List all teams
@@ -979,6 +2201,8 @@ func (q *Queries) ListTeams(ctx context.Context) ([]ListTeamsRow, error) {
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AllowAnonymousShares,
+			&i.Provisioned,
 			&i.MemberCount,
 		); err != nil {
 			return nil, err
@@ -1055,8 +2279,135 @@ func (q *Queries) ListTeamsForUser(ctx context.Context, userID int64) ([]ListTea
 	return items, nil
 }
 
+const listUserQueryHistory = `-- name: ListUserQueryHistory :many
+SELECT id, user_id, user_email, api_token_id, team_id, source_id, query_type, raw_sql, final_sql, duration_ms, rows_returned, error, created_at FROM query_audit_log
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListUserQueryHistoryParams struct {
+	UserID sql.NullInt64 `json:"user_id"`
+	Limit  int64         `json:"limit"`
+	Offset int64         `json:"offset"`
+}
+
+// List the most recent queries run by a user
+func (q *Queries) ListUserQueryHistory(ctx context.Context, arg ListUserQueryHistoryParams) ([]QueryAuditLog, error) {
+	rows, err := q.query(ctx, q.listUserQueryHistoryStmt, listUserQueryHistory, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QueryAuditLog{}
+	for rows.Next() {
+		var i QueryAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserEmail,
+			&i.ApiTokenID,
+			&i.TeamID,
+			&i.SourceID,
+			&i.QueryType,
+			&i.RawSql,
+			&i.FinalSql,
+			&i.DurationMs,
+			&i.RowsReturned,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserShareLinks = `-- name: ListUserShareLinks :many
+SELECT l.id, l.slug, l.team_id, l.source_id, l.created_by, l.title, l.mode, l.raw_sql, l.row_limit, l.start_time, l.end_time,
+    l.password_hash != '' AS has_password, l.allow_anonymous, l.view_count, l.last_viewed_at, l.expires_at, l.revoked_at, l.created_at,
+    u.email AS created_by_email
+FROM share_links l
+JOIN users u ON u.id = l.created_by
+WHERE l.created_by = ?
+ORDER BY l.created_at DESC, l.id DESC
+`
+
+type ListUserShareLinksRow struct {
+	ID             int64        `json:"id"`
+	Slug           string       `json:"slug"`
+	TeamID         int64        `json:"team_id"`
+	SourceID       int64        `json:"source_id"`
+	CreatedBy      int64        `json:"created_by"`
+	Title          string       `json:"title"`
+	Mode           string       `json:"mode"`
+	RawSql         string       `json:"raw_sql"`
+	RowLimit       int64        `json:"row_limit"`
+	StartTime      sql.NullTime `json:"start_time"`
+	EndTime        sql.NullTime `json:"end_time"`
+	HasPassword    int64        `json:"has_password"`
+	AllowAnonymous int64        `json:"allow_anonymous"`
+	ViewCount      int64        `json:"view_count"`
+	LastViewedAt   sql.NullTime `json:"last_viewed_at"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	RevokedAt      sql.NullTime `json:"revoked_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	CreatedByEmail string       `json:"created_by_email"`
+}
+
+// List the share links created by a user, newest first, without snapshots
+func (q *Queries) ListUserShareLinks(ctx context.Context, createdBy int64) ([]ListUserShareLinksRow, error) {
+	rows, err := q.query(ctx, q.listUserShareLinksStmt, listUserShareLinks, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserShareLinksRow{}
+	for rows.Next() {
+		var i ListUserShareLinksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.TeamID,
+			&i.SourceID,
+			&i.CreatedBy,
+			&i.Title,
+			&i.Mode,
+			&i.RawSql,
+			&i.RowLimit,
+			&i.StartTime,
+			&i.EndTime,
+			&i.HasPassword,
+			&i.AllowAnonymous,
+			&i.ViewCount,
+			&i.LastViewedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.CreatedByEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUserTeams = `-- name: ListUserTeams :many
-SELECT t.id, t.name, t.description, t.created_at, t.updated_at
+SELECT t.id, t.name, t.description, t.created_at, t.updated_at, t.allow_anonymous_shares, t.provisioned
 FROM teams t
 JOIN team_members tm ON t.id = tm.team_id
 WHERE tm.user_id = ?
@@ -1079,6 +2430,8 @@ func (q *Queries) ListUserTeams(ctx context.Context, userID int64) ([]Team, erro
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AllowAnonymousShares,
+			&i.Provisioned,
 		); err != nil {
 			return nil, err
 		}
@@ -1094,7 +2447,7 @@ func (q *Queries) ListUserTeams(ctx context.Context, userID int64) ([]Team, erro
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, full_name, role, status, last_login_at, last_active_at, created_at, updated_at FROM users ORDER BY created_at ASC
+SELECT id, email, full_name, role, status, last_login_at, last_active_at, created_at, updated_at, provisioned, oidc_admin FROM users ORDER BY created_at ASC
 `
 
 // List all users
@@ -1117,6 +2470,8 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.LastActiveAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Provisioned,
+			&i.OidcAdmin,
 		); err != nil {
 			return nil, err
 		}
@@ -1131,6 +2486,30 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const markTeamQueryUsed = `-- name: MarkTeamQueryUsed :exec
+UPDATE team_queries
+SET last_used_at = datetime('now')
+WHERE id = ?
+`
+
+// Record that a query was just run
+func (q *Queries) MarkTeamQueryUsed(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.markTeamQueryUsedStmt, markTeamQueryUsed, id)
+	return err
+}
+
+const recordShareLinkView = `-- name: RecordShareLinkView :exec
+UPDATE share_links
+SET view_count = view_count + 1, last_viewed_at = datetime('now')
+WHERE id = ?
+`
+
+// Count a view of a share link
+func (q *Queries) RecordShareLinkView(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.recordShareLinkViewStmt, recordShareLinkView, id)
+	return err
+}
+
 const removeTeamMember = `-- name: RemoveTeamMember :exec
 DELETE FROM team_members
 WHERE team_id = ? AND user_id = ?
@@ -1162,6 +2541,409 @@ func (q *Queries) RemoveTeamSource(ctx context.Context, arg RemoveTeamSourcePara
 	return err
 }
 
+const revokeShareLink = `-- name: RevokeShareLink :execrows
+UPDATE share_links
+SET revoked_at = datetime('now')
+WHERE id = ? AND revoked_at IS NULL
+`
+
+// Revoke a share link that isn't revoked yet
+func (q *Queries) RevokeShareLink(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.revokeShareLinkStmt, revokeShareLink, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchOIDCSyncEvents = `-- name: SearchOIDCSyncEvents :many
+SELECT id, user_id, user_email, team_id, team_name, action, role, groups, reason, created_at FROM oidc_sync_events
+WHERE (?1 IS NULL OR user_id = ?1)
+  AND (?2 IS NULL OR team_id = ?2)
+  AND (?3 IS NULL OR action = ?3)
+ORDER BY created_at DESC, id DESC
+LIMIT ?4 OFFSET ?5
+`
+
+type SearchOIDCSyncEventsParams struct {
+	UserID sql.NullInt64  `json:"user_id"`
+	TeamID sql.NullInt64  `json:"team_id"`
+	Action sql.NullString `json:"action"`
+	Limit  int64          `json:"limit"`
+	Offset int64          `json:"offset"`
+}
+
+// Search the OIDC sync log, ignoring NULL filters
+func (q *Queries) SearchOIDCSyncEvents(ctx context.Context, arg SearchOIDCSyncEventsParams) ([]OidcSyncEvent, error) {
+	rows, err := q.query(ctx, q.searchOIDCSyncEventsStmt, searchOIDCSyncEvents,
+		arg.UserID,
+		arg.TeamID,
+		arg.Action,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OidcSyncEvent{}
+	for rows.Next() {
+		var i OidcSyncEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserEmail,
+			&i.TeamID,
+			&i.TeamName,
+			&i.Action,
+			&i.Role,
+			&i.Groups,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchQueryAuditLog = `-- name: SearchQueryAuditLog :many
+SELECT id, user_id, user_email, api_token_id, team_id, source_id, query_type, raw_sql, final_sql, duration_ms, rows_returned, error, created_at FROM query_audit_log
+WHERE (?1 IS NULL OR user_id = ?1)
+  AND (?2 IS NULL OR team_id = ?2)
+  AND (?3 IS NULL OR source_id = ?3)
+  AND (?4 IS NULL OR api_token_id = ?4)
+  AND (?5 IS NULL OR query_type = ?5)
+  AND (?6 IS NULL OR (error != '') = ?6)
+  AND (?7 IS NULL OR instr(final_sql, ?7) > 0 OR instr(raw_sql, ?7) > 0)
+  AND (?8 IS NULL OR created_at >= ?8)
+  AND (?9 IS NULL OR created_at < ?9)
+ORDER BY created_at DESC, id DESC
+LIMIT ?10 OFFSET ?11
+`
+
+type SearchQueryAuditLogParams struct {
+	UserID     sql.NullInt64  `json:"user_id"`
+	TeamID     sql.NullInt64  `json:"team_id"`
+	SourceID   sql.NullInt64  `json:"source_id"`
+	ApiTokenID sql.NullInt64  `json:"api_token_id"`
+	QueryType  sql.NullString `json:"query_type"`
+	Failed     interface{}    `json:"failed"`
+	Search     interface{}    `json:"search"`
+	StartTime  sql.NullTime   `json:"start_time"`
+	EndTime    sql.NullTime   `json:"end_time"`
+	Limit      int64          `json:"limit"`
+	Offset     int64          `json:"offset"`
+}
+
+// Search the query audit log, ignoring NULL filters
+func (q *Queries) SearchQueryAuditLog(ctx context.Context, arg SearchQueryAuditLogParams) ([]QueryAuditLog, error) {
+	rows, err := q.query(ctx, q.searchQueryAuditLogStmt, searchQueryAuditLog,
+		arg.UserID,
+		arg.TeamID,
+		arg.SourceID,
+		arg.ApiTokenID,
+		arg.QueryType,
+		arg.Failed,
+		arg.Search,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QueryAuditLog{}
+	for rows.Next() {
+		var i QueryAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserEmail,
+			&i.ApiTokenID,
+			&i.TeamID,
+			&i.SourceID,
+			&i.QueryType,
+			&i.RawSql,
+			&i.FinalSql,
+			&i.DurationMs,
+			&i.RowsReturned,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTeamQueries = `-- name: SearchTeamQueries :many
+SELECT q.id, q.team_id, q.source_id, q.name, q.description, q.query_type, q.query_content, q.created_at, q.updated_at, q.created_by, q.updated_by, q.folder_id, q.last_used_at, q.provisioned,
+    (SELECT group_concat(t.tag, ',') FROM team_query_tags t WHERE t.query_id = q.id) AS tags,
+    CAST(f.user_id IS NOT NULL AS INTEGER) AS is_favorite,
+    CAST(COALESCE(f.pinned, 0) AS INTEGER) AS is_pinned
+FROM team_queries q
+JOIN team_sources ts ON ts.team_id = q.team_id AND ts.source_id = q.source_id
+LEFT JOIN user_query_favorites f ON f.query_id = q.id AND f.user_id = ?1
+WHERE q.team_id = ?2
+  AND (?3 IS NULL OR q.source_id = ?3)
+  AND (?4 IS NULL OR q.folder_id = ?4 OR (?4 = 0 AND q.folder_id IS NULL))
+  AND (?5 IS NULL OR EXISTS (SELECT 1 FROM team_query_tags t WHERE t.query_id = q.id AND t.tag = ?5))
+  AND (?6 IS NULL OR f.user_id IS NOT NULL)
+  AND (?7 IS NULL
+    OR instr(lower(q.name), lower(?7)) > 0
+    OR instr(lower(COALESCE(q.description, '')), lower(?7)) > 0
+    OR instr(lower(q.query_content), lower(?7)) > 0
+    OR EXISTS (SELECT 1 FROM team_query_tags t WHERE t.query_id = q.id AND instr(t.tag, lower(?7)) > 0))
+ORDER BY
+    COALESCE(f.pinned, 0) DESC,
+    CASE WHEN ?8 = 'last_used' THEN q.last_used_at END DESC,
+    CASE WHEN ?8 = 'updated' THEN q.updated_at END DESC,
+    q.name COLLATE NOCASE,
+    q.id
+LIMIT ?9 OFFSET ?10
+`
+
+type SearchTeamQueriesParams struct {
+	UserID        int64          `json:"user_id"`
+	TeamID        int64          `json:"team_id"`
+	SourceID      sql.NullInt64  `json:"source_id"`
+	FolderID      sql.NullInt64  `json:"folder_id"`
+	Tag           sql.NullString `json:"tag"`
+	FavoritesOnly interface{}    `json:"favorites_only"`
+	Search        interface{}    `json:"search"`
+	Sort          interface{}    `json:"sort"`
+	Limit         int64          `json:"limit"`
+	Offset        int64          `json:"offset"`
+}
+
+type SearchTeamQueriesRow struct {
+	ID           int64          `json:"id"`
+	TeamID       int64          `json:"team_id"`
+	SourceID     int64          `json:"source_id"`
+	Name         string         `json:"name"`
+	Description  sql.NullString `json:"description"`
+	QueryType    string         `json:"query_type"`
+	QueryContent string         `json:"query_content"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	CreatedBy    sql.NullInt64  `json:"created_by"`
+	UpdatedBy    sql.NullInt64  `json:"updated_by"`
+	FolderID     sql.NullInt64  `json:"folder_id"`
+	LastUsedAt   sql.NullTime   `json:"last_used_at"`
+	Provisioned  int64          `json:"provisioned"`
+	Tags         sql.NullString `json:"tags"`
+	IsFavorite   int64          `json:"is_favorite"`
+	IsPinned     int64          `json:"is_pinned"`
+}
+
+// Search the queries of a team across its sources, ignoring NULL filters. Folder 0 matches unfiled queries.
+func (q *Queries) SearchTeamQueries(ctx context.Context, arg SearchTeamQueriesParams) ([]SearchTeamQueriesRow, error) {
+	rows, err := q.query(ctx, q.searchTeamQueriesStmt, searchTeamQueries,
+		arg.UserID,
+		arg.TeamID,
+		arg.SourceID,
+		arg.FolderID,
+		arg.Tag,
+		arg.FavoritesOnly,
+		arg.Search,
+		arg.Sort,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchTeamQueriesRow{}
+	for rows.Next() {
+		var i SearchTeamQueriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.SourceID,
+			&i.Name,
+			&i.Description,
+			&i.QueryType,
+			&i.QueryContent,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CreatedBy,
+			&i.UpdatedBy,
+			&i.FolderID,
+			&i.LastUsedAt,
+			&i.Provisioned,
+			&i.Tags,
+			&i.IsFavorite,
+			&i.IsPinned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSourceProvisioned = `-- name: SetSourceProvisioned :exec
+UPDATE sources SET provisioned = ? WHERE id = ?
+`
+
+type SetSourceProvisionedParams struct {
+	Provisioned int64 `json:"provisioned"`
+	ID          int64 `json:"id"`
+}
+
+// Mark whether a source is managed by provisioning
+func (q *Queries) SetSourceProvisioned(ctx context.Context, arg SetSourceProvisionedParams) error {
+	_, err := q.exec(ctx, q.setSourceProvisionedStmt, setSourceProvisioned, arg.Provisioned, arg.ID)
+	return err
+}
+
+const setTeamMemberOIDCSynced = `-- name: SetTeamMemberOIDCSynced :exec
+UPDATE team_members SET oidc_synced = ? WHERE team_id = ? AND user_id = ?
+`
+
+type SetTeamMemberOIDCSyncedParams struct {
+	OidcSynced int64 `json:"oidc_synced"`
+	TeamID     int64 `json:"team_id"`
+	UserID     int64 `json:"user_id"`
+}
+
+// Mark whether a team membership was granted by an OIDC group
+func (q *Queries) SetTeamMemberOIDCSynced(ctx context.Context, arg SetTeamMemberOIDCSyncedParams) error {
+	_, err := q.exec(ctx, q.setTeamMemberOIDCSyncedStmt, setTeamMemberOIDCSynced, arg.OidcSynced, arg.TeamID, arg.UserID)
+	return err
+}
+
+const setTeamMemberProvisioned = `-- name: SetTeamMemberProvisioned :exec
+UPDATE team_members SET provisioned = ? WHERE team_id = ? AND user_id = ?
+`
+
+type SetTeamMemberProvisionedParams struct {
+	Provisioned int64 `json:"provisioned"`
+	TeamID      int64 `json:"team_id"`
+	UserID      int64 `json:"user_id"`
+}
+
+// Mark whether a team membership is managed by provisioning
+func (q *Queries) SetTeamMemberProvisioned(ctx context.Context, arg SetTeamMemberProvisionedParams) error {
+	_, err := q.exec(ctx, q.setTeamMemberProvisionedStmt, setTeamMemberProvisioned, arg.Provisioned, arg.TeamID, arg.UserID)
+	return err
+}
+
+const setTeamProvisioned = `-- name: SetTeamProvisioned :exec
+UPDATE teams SET provisioned = ? WHERE id = ?
+`
+
+type SetTeamProvisionedParams struct {
+	Provisioned int64 `json:"provisioned"`
+	ID          int64 `json:"id"`
+}
+
+// Mark whether a team is managed by provisioning
+func (q *Queries) SetTeamProvisioned(ctx context.Context, arg SetTeamProvisionedParams) error {
+	_, err := q.exec(ctx, q.setTeamProvisionedStmt, setTeamProvisioned, arg.Provisioned, arg.ID)
+	return err
+}
+
+const setTeamQueryFolder = `-- name: SetTeamQueryFolder :execrows
+UPDATE team_queries
+SET folder_id = ?
+WHERE id = ? AND team_id = ? AND source_id = ?
+`
+
+type SetTeamQueryFolderParams struct {
+	FolderID sql.NullInt64 `json:"folder_id"`
+	ID       int64         `json:"id"`
+	TeamID   int64         `json:"team_id"`
+	SourceID int64         `json:"source_id"`
+}
+
+// Move a query into a folder, or out of any folder when NULL
+func (q *Queries) SetTeamQueryFolder(ctx context.Context, arg SetTeamQueryFolderParams) (int64, error) {
+	result, err := q.exec(ctx, q.setTeamQueryFolderStmt, setTeamQueryFolder,
+		arg.FolderID,
+		arg.ID,
+		arg.TeamID,
+		arg.SourceID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setTeamQueryProvisioned = `-- name: SetTeamQueryProvisioned :exec
+UPDATE team_queries SET provisioned = ? WHERE id = ?
+`
+
+type SetTeamQueryProvisionedParams struct {
+	Provisioned int64 `json:"provisioned"`
+	ID          int64 `json:"id"`
+}
+
+// Mark whether a team query is managed by provisioning
+func (q *Queries) SetTeamQueryProvisioned(ctx context.Context, arg SetTeamQueryProvisionedParams) error {
+	_, err := q.exec(ctx, q.setTeamQueryProvisionedStmt, setTeamQueryProvisioned, arg.Provisioned, arg.ID)
+	return err
+}
+
+const setUserOIDCAdmin = `-- name: SetUserOIDCAdmin :exec
+
+UPDATE users SET oidc_admin = ? WHERE id = ?
+`
+
+type SetUserOIDCAdminParams struct {
+	OidcAdmin int64 `json:"oidc_admin"`
+	ID        int64 `json:"id"`
+}
+
+// OIDC group sync
+// Mark whether a user's admin role was granted by an OIDC group
+func (q *Queries) SetUserOIDCAdmin(ctx context.Context, arg SetUserOIDCAdminParams) error {
+	_, err := q.exec(ctx, q.setUserOIDCAdminStmt, setUserOIDCAdmin, arg.OidcAdmin, arg.ID)
+	return err
+}
+
+const setUserProvisioned = `-- name: SetUserProvisioned :exec
+
+UPDATE users SET provisioned = ? WHERE id = ?
+`
+
+type SetUserProvisionedParams struct {
+	Provisioned int64 `json:"provisioned"`
+	ID          int64 `json:"id"`
+}
+
+// Provisioning
+// Mark whether a user is managed by provisioning
+func (q *Queries) SetUserProvisioned(ctx context.Context, arg SetUserProvisionedParams) error {
+	_, err := q.exec(ctx, q.setUserProvisionedStmt, setUserProvisioned, arg.Provisioned, arg.ID)
+	return err
+}
+
 const teamHasSource = `-- name: TeamHasSource :one
 
 SELECT COUNT(*) FROM team_sources
@@ -1182,6 +2964,21 @@ func (q *Queries) TeamHasSource(ctx context.Context, arg TeamHasSourceParams) (i
 	return count, err
 }
 
+const trimQueryCacheEntries = `-- name: TrimQueryCacheEntries :execrows
+DELETE FROM query_cache WHERE cache_key IN (
+    SELECT cache_key FROM query_cache ORDER BY created_at DESC LIMIT -1 OFFSET ?
+)
+`
+
+// Drop the oldest query results beyond the given number of entries
+func (q *Queries) TrimQueryCacheEntries(ctx context.Context, offset int64) (int64, error) {
+	result, err := q.exec(ctx, q.trimQueryCacheEntriesStmt, trimQueryCacheEntries, offset)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAPITokenLastUsed = `-- name: UpdateAPITokenLastUsed :exec
 UPDATE api_tokens
 SET last_used_at = datetime('now'),
@@ -1208,6 +3005,8 @@ SET name = ?,
     table_name = ?,
     description = ?,
     ttl_days = ?,
+    field_roles = ?,
+    severity_mapping = ?,
     updated_at = datetime('now')
 WHERE id = ?
 `
@@ -1224,6 +3023,8 @@ type UpdateSourceParams struct {
 	TableName         string         `json:"table_name"`
 	Description       sql.NullString `json:"description"`
 	TtlDays           int64          `json:"ttl_days"`
+	FieldRoles        string         `json:"field_roles"`
+	SeverityMapping   string         `json:"severity_mapping"`
 	ID                int64          `json:"id"`
 }
 
@@ -1241,6 +3042,8 @@ func (q *Queries) UpdateSource(ctx context.Context, arg UpdateSourceParams) erro
 		arg.TableName,
 		arg.Description,
 		arg.TtlDays,
+		arg.FieldRoles,
+		arg.SeverityMapping,
 		arg.ID,
 	)
 	return err
@@ -1250,15 +3053,17 @@ const updateTeam = `-- name: UpdateTeam :exec
 UPDATE teams
 SET name = ?,
     description = ?,
+    allow_anonymous_shares = ?,
     updated_at = ?
 WHERE id = ?
 `
 
 type UpdateTeamParams struct {
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	ID          int64          `json:"id"`
+	Name                 string         `json:"name"`
+	Description          sql.NullString `json:"description"`
+	AllowAnonymousShares int64          `json:"allow_anonymous_shares"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	ID                   int64          `json:"id"`
 }
 
 // Update a team
@@ -1266,12 +3071,63 @@ func (q *Queries) UpdateTeam(ctx context.Context, arg UpdateTeamParams) error {
 	_, err := q.exec(ctx, q.updateTeamStmt, updateTeam,
 		arg.Name,
 		arg.Description,
+		arg.AllowAnonymousShares,
 		arg.UpdatedAt,
 		arg.ID,
 	)
 	return err
 }
 
+const updateTeamCollectionFolder = `-- name: UpdateTeamCollectionFolder :execrows
+UPDATE collection_folders
+SET name = ?, updated_at = datetime('now')
+WHERE id = ? AND team_id = ?
+`
+
+type UpdateTeamCollectionFolderParams struct {
+	Name   string `json:"name"`
+	ID     int64  `json:"id"`
+	TeamID int64  `json:"team_id"`
+}
+
+// Rename a collection folder of a team
+func (q *Queries) UpdateTeamCollectionFolder(ctx context.Context, arg UpdateTeamCollectionFolderParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateTeamCollectionFolderStmt, updateTeamCollectionFolder, arg.Name, arg.ID, arg.TeamID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateTeamDashboard = `-- name: UpdateTeamDashboard :execrows
+UPDATE dashboards
+SET name = ?, description = ?, definition = ?, updated_at = datetime('now')
+WHERE id = ? AND team_id = ?
+`
+
+type UpdateTeamDashboardParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Definition  string `json:"definition"`
+	ID          int64  `json:"id"`
+	TeamID      int64  `json:"team_id"`
+}
+
+// Update a dashboard of a team
+func (q *Queries) UpdateTeamDashboard(ctx context.Context, arg UpdateTeamDashboardParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateTeamDashboardStmt, updateTeamDashboard,
+		arg.Name,
+		arg.Description,
+		arg.Definition,
+		arg.ID,
+		arg.TeamID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateTeamMemberRole = `-- name: UpdateTeamMemberRole :exec
 UPDATE team_members
 SET role = ?
@@ -1290,12 +3146,13 @@ func (q *Queries) UpdateTeamMemberRole(ctx context.Context, arg UpdateTeamMember
 	return err
 }
 
-const updateTeamSourceQuery = `-- name: UpdateTeamSourceQuery :exec
+const updateTeamSourceQuery = `-- name: UpdateTeamSourceQuery :execrows
 UPDATE team_queries
 SET name = ?,
     description = ?,
     query_type = ?,
     query_content = ?,
+    updated_by = ?,
     updated_at = datetime('now')
 WHERE id = ? AND team_id = ? AND source_id = ?
 `
@@ -1305,23 +3162,49 @@ type UpdateTeamSourceQueryParams struct {
 	Description  sql.NullString `json:"description"`
 	QueryType    string         `json:"query_type"`
 	QueryContent string         `json:"query_content"`
+	UpdatedBy    sql.NullInt64  `json:"updated_by"`
 	ID           int64          `json:"id"`
 	TeamID       int64          `json:"team_id"`
 	SourceID     int64          `json:"source_id"`
 }
 
 // Update a query for a team and source
-func (q *Queries) UpdateTeamSourceQuery(ctx context.Context, arg UpdateTeamSourceQueryParams) error {
-	_, err := q.exec(ctx, q.updateTeamSourceQueryStmt, updateTeamSourceQuery,
+func (q *Queries) UpdateTeamSourceQuery(ctx context.Context, arg UpdateTeamSourceQueryParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateTeamSourceQueryStmt, updateTeamSourceQuery,
 		arg.Name,
 		arg.Description,
 		arg.QueryType,
 		arg.QueryContent,
+		arg.UpdatedBy,
 		arg.ID,
 		arg.TeamID,
 		arg.SourceID,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateTeamSourceRowFilter = `-- name: UpdateTeamSourceRowFilter :execrows
+UPDATE team_sources
+SET row_filter = ?
+WHERE team_id = ? AND source_id = ?
+`
+
+type UpdateTeamSourceRowFilterParams struct {
+	RowFilter string `json:"row_filter"`
+	TeamID    int64  `json:"team_id"`
+	SourceID  int64  `json:"source_id"`
+}
+
+// Set the row-level filter expression for a team's access to a source
+func (q *Queries) UpdateTeamSourceRowFilter(ctx context.Context, arg UpdateTeamSourceRowFilterParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateTeamSourceRowFilterStmt, updateTeamSourceRowFilter, arg.RowFilter, arg.TeamID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const updateUser = `-- name: UpdateUser :exec
@@ -1362,6 +3245,115 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 	return err
 }
 
+const upsertColumnPolicy = `-- name: UpsertColumnPolicy :one
+INSERT INTO column_policies (team_id, source_id, column_name, action, pattern, replacement)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (team_id, source_id, column_name) DO UPDATE SET
+    action = excluded.action,
+    pattern = excluded.pattern,
+    replacement = excluded.replacement,
+    updated_at = datetime('now')
+RETURNING id
+`
+
+type UpsertColumnPolicyParams struct {
+	TeamID      int64  `json:"team_id"`
+	SourceID    int64  `json:"source_id"`
+	ColumnName  string `json:"column_name"`
+	Action      string `json:"action"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// Create or replace the policy for a column of a team's source
+func (q *Queries) UpsertColumnPolicy(ctx context.Context, arg UpsertColumnPolicyParams) (int64, error) {
+	row := q.queryRow(ctx, q.upsertColumnPolicyStmt, upsertColumnPolicy,
+		arg.TeamID,
+		arg.SourceID,
+		arg.ColumnName,
+		arg.Action,
+		arg.Pattern,
+		arg.Replacement,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const upsertQueryCacheEntry = `-- name: UpsertQueryCacheEntry :exec
+INSERT INTO query_cache (cache_key, source_id, kind, value, size, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(cache_key) DO UPDATE SET value = excluded.value, size = excluded.size, created_at = excluded.created_at, expires_at = excluded.expires_at
+`
+
+type UpsertQueryCacheEntryParams struct {
+	CacheKey  string    `json:"cache_key"`
+	SourceID  int64     `json:"source_id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store a query result, replacing any previous result of the same query
+func (q *Queries) UpsertQueryCacheEntry(ctx context.Context, arg UpsertQueryCacheEntryParams) error {
+	_, err := q.exec(ctx, q.upsertQueryCacheEntryStmt, upsertQueryCacheEntry,
+		arg.CacheKey,
+		arg.SourceID,
+		arg.Kind,
+		arg.Value,
+		arg.Size,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const upsertSourceSchemaSnapshot = `-- name: UpsertSourceSchemaSnapshot :exec
+INSERT INTO source_schema_snapshots (source_id, columns, sort_keys, taken_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(source_id) DO UPDATE SET columns = excluded.columns, sort_keys = excluded.sort_keys, taken_at = excluded.taken_at
+`
+
+type UpsertSourceSchemaSnapshotParams struct {
+	SourceID int64     `json:"source_id"`
+	Columns  string    `json:"columns"`
+	SortKeys string    `json:"sort_keys"`
+	TakenAt  time.Time `json:"taken_at"`
+}
+
+// Replace the latest schema snapshot of a source
+func (q *Queries) UpsertSourceSchemaSnapshot(ctx context.Context, arg UpsertSourceSchemaSnapshotParams) error {
+	_, err := q.exec(ctx, q.upsertSourceSchemaSnapshotStmt, upsertSourceSchemaSnapshot,
+		arg.SourceID,
+		arg.Columns,
+		arg.SortKeys,
+		arg.TakenAt,
+	)
+	return err
+}
+
+const upsertUserQueryFavorite = `-- name: UpsertUserQueryFavorite :exec
+
+INSERT INTO user_query_favorites (user_id, query_id, pinned)
+VALUES (?, ?, ?)
+ON CONFLICT(user_id, query_id) DO UPDATE SET pinned = excluded.pinned
+`
+
+type UpsertUserQueryFavoriteParams struct {
+	UserID  int64 `json:"user_id"`
+	QueryID int64 `json:"query_id"`
+	Pinned  int64 `json:"pinned"`
+}
+
+// User Query Favorites
+// Add a query to the favorites of a user, or update whether it's pinned
+func (q *Queries) UpsertUserQueryFavorite(ctx context.Context, arg UpsertUserQueryFavoriteParams) error {
+	_, err := q.exec(ctx, q.upsertUserQueryFavoriteStmt, upsertUserQueryFavorite, arg.UserID, arg.QueryID, arg.Pinned)
+	return err
+}
+
 const userHasSourceAccess = `-- name: UserHasSourceAccess :one
 SELECT COUNT(*) FROM team_members tm
 JOIN team_sources ts ON tm.team_id = ts.team_id
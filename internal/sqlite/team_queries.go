@@ -3,13 +3,16 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/mr-karan/logchef/internal/sqlite/sqlc"
 	"github.com/mr-karan/logchef/pkg/models"
 )
 
-// CreateTeamSourceQuery inserts a new saved query record associated with a team and source.
+// CreateTeamSourceQuery inserts a new saved query record associated with a team and source,
+// along with its first revision.
 // It populates the ID field on the input query model upon success.
 func (db *DB) CreateTeamSourceQuery(ctx context.Context, query *models.TeamQuery) error {
 	db.log.Debug("creating team source query record", "team_id", query.TeamID, "source_id", query.SourceID, "name", query.Name)
@@ -23,14 +26,30 @@ func (db *DB) CreateTeamSourceQuery(ctx context.Context, query *models.TeamQuery
 		Description:  description,
 		QueryType:    string(query.QueryType),
 		QueryContent: query.QueryContent,
+		CreatedBy:    nullUserID(query.CreatedBy),
+		UpdatedBy:    nullUserID(query.CreatedBy),
 	}
 
-	id, err := db.queries.CreateTeamSourceQuery(ctx, params)
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting team source query transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := sqlc.New(tx)
+
+	id, err := qtx.CreateTeamSourceQuery(ctx, params)
 	if err != nil {
 		// Consider checking for specific constraint errors (e.g., FK violations if team/source don't exist).
 		db.log.Error("failed to create team source query record in db", "error", err, "team_id", query.TeamID, "source_id", query.SourceID)
 		return fmt.Errorf("error creating team source query: %w", err)
 	}
+	if err := qtx.CreateTeamQueryRevision(ctx, sqlc.CreateTeamQueryRevisionParams{QueryID: id, ID: id}); err != nil {
+		db.log.Error("failed to record team source query revision in db", "error", err, "query_id", id)
+		return fmt.Errorf("error recording team source query revision: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing team source query: %w", err)
+	}
 
 	// Set auto-generated ID on the input model.
 	query.ID = int(id)
@@ -57,45 +76,57 @@ func (db *DB) GetTeamSourceQuery(ctx context.Context, teamID models.TeamID, sour
 	}
 
 	// Map sqlc result to the SavedTeamQuery domain model.
-	return &models.SavedTeamQuery{
-		ID:           int(sqlcQuery.ID),
-		TeamID:       models.TeamID(sqlcQuery.TeamID),
-		SourceID:     models.SourceID(sqlcQuery.SourceID),
-		Name:         sqlcQuery.Name,
-		Description:  sqlcQuery.Description.String, // Handle NULL string
-		QueryType:    models.SavedQueryType(sqlcQuery.QueryType),
-		QueryContent: sqlcQuery.QueryContent,
-		CreatedAt:    sqlcQuery.CreatedAt,
-		UpdatedAt:    sqlcQuery.UpdatedAt,
-		// CreatedByUserID is not present in the sqlc model/query.
-	}, nil
+	query := mapTeamQueryRowToModel(&sqlcQuery)
+	tags, err := db.queries.ListTeamQueryTags(ctx, int64(queryID))
+	if err != nil {
+		db.log.Error("failed to list team query tags from db", "error", err, "query_id", queryID)
+		return nil, fmt.Errorf("error listing team query tags: %w", err)
+	}
+	query.Tags = tags
+	return query, nil
 }
 
-// UpdateTeamSourceQuery updates an existing saved query record.
-// Only non-empty fields in the input arguments are intended to be updated (though the current SQL updates all).
+// UpdateTeamSourceQuery updates an existing saved query record and records the result
+// as a new revision authored by updatedBy.
+// The SQL query updates all fields, so callers must pass the complete new state.
 // The `updated_at` timestamp is automatically set by the query.
-func (db *DB) UpdateTeamSourceQuery(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, queryID int, name, description, queryType, queryContent string) error {
+func (db *DB) UpdateTeamSourceQuery(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, queryID int, name, description, queryType, queryContent string, updatedBy *models.UserID) error {
 	db.log.Debug("updating team source query record", "query_id", queryID, "team_id", teamID, "source_id", sourceID)
 
-	// Prepare parameters, handling potentially empty update values.
-	// The SQL query updates all specified fields; partial updates would require a different query or dynamic SQL.
 	desc := sql.NullString{String: description, Valid: description != ""} // Handle empty description correctly.
 	params := sqlc.UpdateTeamSourceQueryParams{
 		Name:         name,
 		Description:  desc,
 		QueryType:    queryType,
 		QueryContent: queryContent,
+		UpdatedBy:    nullUserID(updatedBy),
 		ID:           int64(queryID),
 		TeamID:       int64(teamID),
 		SourceID:     int64(sourceID),
 	}
 
-	err := db.queries.UpdateTeamSourceQuery(ctx, params)
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting team source query transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := sqlc.New(tx)
+
+	rows, err := qtx.UpdateTeamSourceQuery(ctx, params)
 	if err != nil {
-		// sqlc Update typically doesn't return ErrNoRows. Check for other errors.
 		db.log.Error("failed to update team source query record in db", "error", err, "query_id", queryID)
 		return fmt.Errorf("error updating team source query: %w", err)
 	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	if err := qtx.CreateTeamQueryRevision(ctx, sqlc.CreateTeamQueryRevisionParams{QueryID: int64(queryID), ID: int64(queryID)}); err != nil {
+		db.log.Error("failed to record team source query revision in db", "error", err, "query_id", queryID)
+		return fmt.Errorf("error recording team source query revision: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing team source query update: %w", err)
+	}
 
 	db.log.Debug("team source query record updated successfully", "query_id", queryID)
 	return nil
@@ -137,20 +168,132 @@ func (db *DB) ListQueriesByTeamAndSource(ctx context.Context, teamID models.Team
 
 	// Map results to domain model slice.
 	queries := make([]*models.SavedTeamQuery, 0, len(sqlcQueries))
-	for _, sq := range sqlcQueries {
-		queries = append(queries, &models.SavedTeamQuery{
-			ID:           int(sq.ID),
-			TeamID:       models.TeamID(sq.TeamID),
-			SourceID:     models.SourceID(sq.SourceID),
-			Name:         sq.Name,
-			Description:  sq.Description.String, // Handle NULL string
-			QueryType:    models.SavedQueryType(sq.QueryType),
-			QueryContent: sq.QueryContent,
-			CreatedAt:    sq.CreatedAt,
-			UpdatedAt:    sq.UpdatedAt,
-		})
+	for i := range sqlcQueries {
+		queries = append(queries, mapTeamQueryRowToModel(&sqlcQueries[i]))
 	}
 
 	db.log.Debug("team and source queries listed", "team_id", teamID, "source_id", sourceID, "count", len(queries))
 	return queries, nil
 }
+
+// ListQueriesBySource retrieves the saved queries of all teams for a source.
+func (db *DB) ListQueriesBySource(ctx context.Context, sourceID models.SourceID) ([]*models.SavedTeamQuery, error) {
+	sqlcQueries, err := db.queries.ListQueriesBySource(ctx, int64(sourceID))
+	if err != nil {
+		db.log.Error("failed to list queries for source from db", "error", err, "source_id", sourceID)
+		return nil, fmt.Errorf("error listing queries for source: %w", err)
+	}
+
+	queries := make([]*models.SavedTeamQuery, 0, len(sqlcQueries))
+	for i := range sqlcQueries {
+		queries = append(queries, mapTeamQueryRowToModel(&sqlcQueries[i]))
+	}
+	return queries, nil
+}
+
+// ListTeamQueryRevisions retrieves all revisions of a saved query, newest first.
+func (db *DB) ListTeamQueryRevisions(ctx context.Context, queryID int) ([]*models.SavedQueryRevision, error) {
+	db.log.Debug("listing team query revisions", "query_id", queryID)
+
+	rows, err := db.queries.ListTeamQueryRevisions(ctx, int64(queryID))
+	if err != nil {
+		db.log.Error("failed to list team query revisions from db", "error", err, "query_id", queryID)
+		return nil, fmt.Errorf("error listing team query revisions: %w", err)
+	}
+
+	revisions := make([]*models.SavedQueryRevision, 0, len(rows))
+	for _, row := range rows {
+		revisions = append(revisions, mapTeamQueryRevisionRowToModel(sqlc.GetTeamQueryRevisionRow(row)))
+	}
+	return revisions, nil
+}
+
+// GetTeamQueryRevision retrieves a single revision of a saved query.
+func (db *DB) GetTeamQueryRevision(ctx context.Context, queryID, revision int) (*models.SavedQueryRevision, error) {
+	db.log.Debug("getting team query revision", "query_id", queryID, "revision", revision)
+
+	row, err := db.queries.GetTeamQueryRevision(ctx, sqlc.GetTeamQueryRevisionParams{
+		QueryID:  int64(queryID),
+		Revision: int64(revision),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		db.log.Error("failed to get team query revision from db", "error", err, "query_id", queryID, "revision", revision)
+		return nil, fmt.Errorf("error getting team query revision: %w", err)
+	}
+	return mapTeamQueryRevisionRowToModel(row), nil
+}
+
+// mapTeamQueryRowToModel converts a sqlc TeamQuery row to the SavedTeamQuery domain model.
+func mapTeamQueryRowToModel(row *sqlc.TeamQuery) *models.SavedTeamQuery {
+	return &models.SavedTeamQuery{
+		ID:           int(row.ID),
+		TeamID:       models.TeamID(row.TeamID),
+		SourceID:     models.SourceID(row.SourceID),
+		Name:         row.Name,
+		Description:  row.Description.String, // Handle NULL string
+		QueryType:    models.SavedQueryType(row.QueryType),
+		QueryContent: row.QueryContent,
+		CreatedBy:    userIDFromNull(row.CreatedBy),
+		UpdatedBy:    userIDFromNull(row.UpdatedBy),
+		FolderID:     intFromNull(row.FolderID),
+		Tags:         []string{},
+		LastUsedAt:   timeFromNull(row.LastUsedAt),
+		Provisioned:  row.Provisioned != 0,
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt,
+	}
+}
+
+// mapTeamQueryRevisionRowToModel converts a sqlc revision row to the domain model.
+func mapTeamQueryRevisionRowToModel(row sqlc.GetTeamQueryRevisionRow) *models.SavedQueryRevision {
+	return &models.SavedQueryRevision{
+		ID:             int(row.ID),
+		QueryID:        int(row.QueryID),
+		Revision:       int(row.Revision),
+		Name:           row.Name,
+		Description:    row.Description,
+		QueryType:      models.SavedQueryType(row.QueryType),
+		QueryContent:   row.QueryContent,
+		CreatedBy:      userIDFromNull(row.CreatedBy),
+		CreatedByEmail: row.CreatedByEmail.String,
+		CreatedAt:      row.CreatedAt,
+	}
+}
+
+// nullUserID converts an optional user ID to a nullable column value.
+func nullUserID(id *models.UserID) sql.NullInt64 {
+	if id == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*id), Valid: true}
+}
+
+// userIDFromNull converts a nullable column value to an optional user ID.
+func userIDFromNull(v sql.NullInt64) *models.UserID {
+	if !v.Valid {
+		return nil
+	}
+	id := models.UserID(v.Int64)
+	return &id
+}
+
+// intFromNull converts a nullable column value to an optional int.
+func intFromNull(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+// timeFromNull converts a nullable column value to an optional time.
+func timeFromNull(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
@@ -62,9 +62,11 @@ func (db *DB) GetTeam(ctx context.Context, teamID models.TeamID) (*models.Team,
 
 	// Map sqlc result to domain model.
 	team := &models.Team{
-		ID:          models.TeamID(teamRow.ID),
-		Name:        teamRow.Name,
-		Description: teamRow.Description.String,
+		ID:                   models.TeamID(teamRow.ID),
+		Name:                 teamRow.Name,
+		Description:          teamRow.Description.String,
+		AllowAnonymousShares: teamRow.AllowAnonymousShares != 0,
+		Provisioned:          teamRow.Provisioned != 0,
 		Timestamps: models.Timestamps{
 			CreatedAt: teamRow.CreatedAt,
 			UpdatedAt: teamRow.UpdatedAt,
@@ -80,10 +82,11 @@ func (db *DB) UpdateTeam(ctx context.Context, team *models.Team) error {
 	db.log.Debug("updating team record", "team_id", team.ID, "name", team.Name)
 
 	params := sqlc.UpdateTeamParams{
-		Name:        team.Name,
-		Description: sql.NullString{String: team.Description, Valid: team.Description != ""},
-		UpdatedAt:   team.UpdatedAt, // Pass current time or let DB handle? Assuming passed in.
-		ID:          int64(team.ID),
+		Name:                 team.Name,
+		Description:          sql.NullString{String: team.Description, Valid: team.Description != ""},
+		AllowAnonymousShares: boolToInt(team.AllowAnonymousShares),
+		UpdatedAt:            team.UpdatedAt, // Pass current time or let DB handle? Assuming passed in.
+		ID:                   int64(team.ID),
 	}
 
 	err := db.queries.UpdateTeam(ctx, params)
@@ -128,10 +131,12 @@ func (db *DB) ListTeams(ctx context.Context) ([]*models.Team, error) {
 	teams := make([]*models.Team, 0, len(teamRows))
 	for _, row := range teamRows {
 		teams = append(teams, &models.Team{
-			ID:          models.TeamID(row.ID),
-			Name:        row.Name,
-			Description: row.Description.String,
-			MemberCount: int(row.MemberCount), // Include member count from query.
+			ID:                   models.TeamID(row.ID),
+			Name:                 row.Name,
+			Description:          row.Description.String,
+			AllowAnonymousShares: row.AllowAnonymousShares != 0,
+			Provisioned:          row.Provisioned != 0,
+			MemberCount:          int(row.MemberCount), // Include member count from query.
 			Timestamps: models.Timestamps{
 				CreatedAt: row.CreatedAt,
 				UpdatedAt: row.UpdatedAt,
@@ -206,10 +211,12 @@ func (db *DB) GetTeamMember(ctx context.Context, teamID models.TeamID, userID mo
 
 	// Map sqlc result to domain model.
 	member := &models.TeamMember{
-		TeamID:    models.TeamID(memberRow.TeamID),
-		UserID:    models.UserID(memberRow.UserID),
-		Role:      models.TeamRole(memberRow.Role),
-		CreatedAt: memberRow.CreatedAt,
+		TeamID:      models.TeamID(memberRow.TeamID),
+		UserID:      models.UserID(memberRow.UserID),
+		Role:        models.TeamRole(memberRow.Role),
+		CreatedAt:   memberRow.CreatedAt,
+		Provisioned: memberRow.Provisioned != 0,
+		OIDCSynced:  memberRow.OidcSynced != 0,
 	}
 	return member, nil
 }
@@ -276,10 +283,12 @@ func (db *DB) ListTeamMembers(ctx context.Context, teamID models.TeamID) ([]*mod
 	members := make([]*models.TeamMember, 0, len(memberRows))
 	for _, row := range memberRows {
 		members = append(members, &models.TeamMember{
-			TeamID:    models.TeamID(row.TeamID),
-			UserID:    models.UserID(row.UserID),
-			Role:      models.TeamRole(row.Role),
-			CreatedAt: row.CreatedAt,
+			TeamID:      models.TeamID(row.TeamID),
+			UserID:      models.UserID(row.UserID),
+			Role:        models.TeamRole(row.Role),
+			CreatedAt:   row.CreatedAt,
+			Provisioned: row.Provisioned != 0,
+			OIDCSynced:  row.OidcSynced != 0,
 		})
 	}
 
@@ -301,12 +310,14 @@ func (db *DB) ListTeamMembersWithDetails(ctx context.Context, teamID models.Team
 	members := make([]*models.TeamMember, 0, len(memberRows))
 	for _, row := range memberRows {
 		members = append(members, &models.TeamMember{
-			TeamID:    models.TeamID(row.TeamID),
-			UserID:    models.UserID(row.UserID),
-			Role:      models.TeamRole(row.Role),
-			Email:     row.Email,    // From joined users table
-			FullName:  row.FullName, // From joined users table
-			CreatedAt: row.CreatedAt,
+			TeamID:      models.TeamID(row.TeamID),
+			UserID:      models.UserID(row.UserID),
+			Role:        models.TeamRole(row.Role),
+			Email:       row.Email,    // From joined users table
+			FullName:    row.FullName, // From joined users table
+			CreatedAt:   row.CreatedAt,
+			Provisioned: row.Provisioned != 0,
+			OIDCSynced:  row.OidcSynced != 0,
 		})
 	}
 
@@ -328,9 +339,11 @@ func (db *DB) ListUserTeams(ctx context.Context, userID models.UserID) ([]*model
 	teams := make([]*models.Team, 0, len(teamRows))
 	for _, row := range teamRows {
 		teams = append(teams, &models.Team{
-			ID:          models.TeamID(row.ID),
-			Name:        row.Name,
-			Description: row.Description.String,
+			ID:                   models.TeamID(row.ID),
+			Name:                 row.Name,
+			Description:          row.Description.String,
+			AllowAnonymousShares: row.AllowAnonymousShares != 0,
+			Provisioned:          row.Provisioned != 0,
 			Timestamps: models.Timestamps{
 				CreatedAt: row.CreatedAt,
 				UpdatedAt: row.UpdatedAt,
@@ -391,6 +404,47 @@ func (db *DB) RemoveTeamSource(ctx context.Context, teamID models.TeamID, source
 	return nil
 }
 
+// GetTeamSourceRowFilter retrieves the row-level filter expression of a team-source link.
+// An empty string means the team has unrestricted access to the source.
+func (db *DB) GetTeamSourceRowFilter(ctx context.Context, teamID models.TeamID, sourceID models.SourceID) (string, error) {
+	db.log.Debug("getting team source row filter", "team_id", teamID, "source_id", sourceID)
+
+	filter, err := db.queries.GetTeamSourceRowFilter(ctx, sqlc.GetTeamSourceRowFilterParams{
+		TeamID:   int64(teamID),
+		SourceID: int64(sourceID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		db.log.Error("failed to get team source row filter from db", "error", err, "team_id", teamID, "source_id", sourceID)
+		return "", fmt.Errorf("error getting team source row filter: %w", err)
+	}
+
+	return filter, nil
+}
+
+// UpdateTeamSourceRowFilter sets the row-level filter expression of a team-source link.
+func (db *DB) UpdateTeamSourceRowFilter(ctx context.Context, teamID models.TeamID, sourceID models.SourceID, filter string) error {
+	db.log.Debug("updating team source row filter", "team_id", teamID, "source_id", sourceID)
+
+	rows, err := db.queries.UpdateTeamSourceRowFilter(ctx, sqlc.UpdateTeamSourceRowFilterParams{
+		RowFilter: filter,
+		TeamID:    int64(teamID),
+		SourceID:  int64(sourceID),
+	})
+	if err != nil {
+		db.log.Error("failed to update team source row filter in db", "error", err, "team_id", teamID, "source_id", sourceID)
+		return fmt.Errorf("error updating team source row filter: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	db.log.Debug("team source row filter updated successfully", "team_id", teamID, "source_id", sourceID)
+	return nil
+}
+
 // ListTeamSources retrieves all data sources associated with a specific team.
 func (db *DB) ListTeamSources(ctx context.Context, teamID models.TeamID) ([]*models.Source, error) {
 	db.log.Debug("listing sources for team", "team_id", teamID)
@@ -428,9 +482,11 @@ func (db *DB) ListSourceTeams(ctx context.Context, sourceID models.SourceID) ([]
 	teams := make([]*models.Team, 0, len(teamRows))
 	for _, row := range teamRows {
 		teams = append(teams, &models.Team{
-			ID:          models.TeamID(row.ID),
-			Name:        row.Name,
-			Description: row.Description.String,
+			ID:                   models.TeamID(row.ID),
+			Name:                 row.Name,
+			Description:          row.Description.String,
+			AllowAnonymousShares: row.AllowAnonymousShares != 0,
+			Provisioned:          row.Provisioned != 0,
 			Timestamps: models.Timestamps{
 				CreatedAt: row.CreatedAt,
 				UpdatedAt: row.UpdatedAt,
@@ -477,9 +533,11 @@ func (db *DB) GetTeamByName(ctx context.Context, name string) (*models.Team, err
 
 	// Map result.
 	team := &models.Team{
-		ID:          models.TeamID(teamRow.ID),
-		Name:        teamRow.Name,
-		Description: teamRow.Description.String,
+		ID:                   models.TeamID(teamRow.ID),
+		Name:                 teamRow.Name,
+		Description:          teamRow.Description.String,
+		AllowAnonymousShares: teamRow.AllowAnonymousShares != 0,
+		Provisioned:          teamRow.Provisioned != 0,
 		Timestamps: models.Timestamps{
 			CreatedAt: teamRow.CreatedAt,
 			UpdatedAt: teamRow.UpdatedAt,
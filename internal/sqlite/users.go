@@ -208,6 +208,8 @@ func mapUserRowToModel(row sqlc.User) *models.User {
 		Status:       models.UserStatus(row.Status),
 		LastLoginAt:  lastLoginAt,
 		LastActiveAt: lastActiveAt,
+		Provisioned:  row.Provisioned != 0,
+		OIDCAdmin:    row.OidcAdmin != 0,
 		Timestamps: models.Timestamps{
 			CreatedAt: row.CreatedAt,
 			UpdatedAt: row.UpdatedAt,
@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -90,7 +91,7 @@ func mapSourceRowToModel(row *sqlc.Source) *models.Source {
 	if row == nil {
 		return nil
 	}
-	return &models.Source{
+	source := &models.Source{
 		ID:                models.SourceID(row.ID),
 		Name:              row.Name,
 		MetaIsAutoCreated: row.MetaIsAutoCreated == 1,
@@ -98,6 +99,7 @@ func mapSourceRowToModel(row *sqlc.Source) *models.Source {
 		MetaSeverityField: row.MetaSeverityField.String,
 		Description:       row.Description.String,
 		TTLDays:           int(row.TtlDays),
+		Provisioned:       row.Provisioned != 0,
 		Connection: models.ConnectionInfo{
 			Host:      row.Host,
 			Username:  row.Username,
@@ -110,6 +112,10 @@ func mapSourceRowToModel(row *sqlc.Source) *models.Source {
 			UpdatedAt: row.UpdatedAt,
 		},
 	}
+	// Both columns are written as JSON by CreateSource and UpdateSource, invalid values are left empty.
+	_ = json.Unmarshal([]byte(row.FieldRoles), &source.FieldRoles)
+	_ = json.Unmarshal([]byte(row.SeverityMapping), &source.SeverityMapping)
+	return source
 }
 
 // Note: IsConnected and Schema/Columns are populated dynamically, not from DB row.
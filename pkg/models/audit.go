@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// QueryAuditType identifies the kind of request that executed a query.
+type QueryAuditType string
+
+const (
+	// QueryAuditTypeLogs is a log search query.
+	QueryAuditTypeLogs QueryAuditType = "logs"
+
+	// QueryAuditTypeHistogram is a histogram query.
+	QueryAuditTypeHistogram QueryAuditType = "histogram"
+
+	// QueryAuditTypePatterns is a pattern clustering query.
+	QueryAuditTypePatterns QueryAuditType = "patterns"
+
+	// QueryAuditTypeShare is a live share link query, run on behalf of the link's creator.
+	QueryAuditTypeShare QueryAuditType = "share"
+)
+
+// QueryAuditEntry records a single query executed against a source.
+type QueryAuditEntry struct {
+	ID           int64          `json:"id"`
+	UserID       *UserID        `json:"user_id,omitempty"`
+	UserEmail    string         `json:"user_email"`
+	APITokenID   *int           `json:"api_token_id,omitempty"` // Set when authenticated with an API token.
+	TeamID       *TeamID        `json:"team_id,omitempty"`
+	SourceID     *SourceID      `json:"source_id,omitempty"`
+	QueryType    QueryAuditType `json:"query_type"`
+	RawSQL       string         `json:"raw_sql"`   // Query as submitted.
+	FinalSQL     string         `json:"final_sql"` // Query as executed, after validation and policy rewriting.
+	DurationMs   int64          `json:"duration_ms"`
+	RowsReturned int            `json:"rows_returned"`
+	Error        string         `json:"error,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// QueryAuditFilter narrows a search of the query audit log. Nil and empty fields are ignored.
+type QueryAuditFilter struct {
+	UserID     *UserID
+	TeamID     *TeamID
+	SourceID   *SourceID
+	APITokenID *int
+	QueryType  QueryAuditType
+	Failed     *bool      // Only failed (true) or successful (false) queries.
+	Search     string     // Substring matched against the submitted and executed SQL.
+	StartTime  *time.Time // Inclusive.
+	EndTime    *time.Time // Exclusive.
+	Limit      int
+	Offset     int
+}
@@ -50,6 +50,11 @@ type User struct {
 	Status       UserStatus `json:"status" db:"status"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
 	LastActiveAt *time.Time `json:"last_active_at,omitempty" db:"last_active_at"`
+	// Provisioned users are managed by provisioning files and can't be changed from the UI.
+	Provisioned bool `json:"provisioned" db:"provisioned"`
+	// OIDCAdmin is set when the admin role was granted by an OIDC admin group, so group
+	// sync may revoke it again.
+	OIDCAdmin bool `json:"oidc_admin" db:"oidc_admin"`
 	Timestamps
 }
 
@@ -82,17 +87,24 @@ type Team struct {
 	Name        string `db:"name" json:"name"`
 	Description string `db:"description" json:"description"`
 	MemberCount int    `db:"-" json:"member_count"`
+	// AllowAnonymousShares lets share links of the team be opened without logging in.
+	AllowAnonymousShares bool `db:"allow_anonymous_shares" json:"allow_anonymous_shares"`
+	// Provisioned teams, including their source links, are managed by provisioning files
+	// and can't be changed from the UI.
+	Provisioned bool `db:"provisioned" json:"provisioned"`
 	Timestamps
 }
 
 // TeamMember represents a user's membership in a team
 type TeamMember struct {
-	TeamID    TeamID    `db:"team_id" json:"team_id"`
-	UserID    UserID    `db:"user_id" json:"user_id"`
-	Role      TeamRole  `db:"role" json:"role"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	Email     string    `db:"email" json:"email,omitempty"`
-	FullName  string    `db:"full_name" json:"full_name,omitempty"`
+	TeamID      TeamID    `db:"team_id" json:"team_id"`
+	UserID      UserID    `db:"user_id" json:"user_id"`
+	Role        TeamRole  `db:"role" json:"role"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	Provisioned bool      `db:"provisioned" json:"provisioned"`
+	OIDCSynced  bool      `db:"oidc_synced" json:"oidc_synced"` // Granted by an OIDC group mapping
+	Email       string    `db:"email" json:"email,omitempty"`
+	FullName    string    `db:"full_name" json:"full_name,omitempty"`
 }
 
 // UserTeamDetails represents the details of a team a user is part of, including their role.
@@ -115,6 +127,8 @@ type TeamQuery struct {
 	Description  string         `json:"description" db:"description"`
 	QueryType    SavedQueryType `json:"query_type" db:"query_type"`
 	QueryContent string         `json:"query_content" db:"query_content"`
+	CreatedBy    *UserID        `json:"created_by,omitempty" db:"created_by"`
+	UpdatedBy    *UserID        `json:"updated_by,omitempty" db:"updated_by"`
 	Timestamps
 }
 
@@ -127,13 +141,49 @@ type APIToken struct {
 	Prefix     string     `json:"prefix" db:"prefix"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty" db:"disabled_at"` // Set when the user was deactivated
+	APITokenRestrictions
 	Timestamps
 }
 
+// APITokenScope is a permission granted to an API token, as "<area>:<action>".
+// "<area>:*" grants every action of an area and "*" grants everything the user can do.
+type APITokenScope string
+
+const (
+	ScopeAll              APITokenScope = "*"
+	ScopeLogsQuery        APITokenScope = "logs:query"        // Query and explore logs, run collections and dashboards
+	ScopeCollectionsRead  APITokenScope = "collections:read"  // View collections, dashboards and folders
+	ScopeCollectionsWrite APITokenScope = "collections:write" // Manage collections, dashboards and folders
+	ScopeTeamsRead        APITokenScope = "teams:read"        // View teams, members and linked sources
+	ScopeTeamsWrite       APITokenScope = "teams:write"       // Manage team settings, members and linked sources
+	ScopeSharesWrite      APITokenScope = "shares:write"      // Create and revoke share links
+	ScopeTokensWrite      APITokenScope = "tokens:write"      // Create and delete the user's API tokens
+	ScopeAdminUsers       APITokenScope = "admin:users"
+	ScopeAdminTeams       APITokenScope = "admin:teams"
+	ScopeAdminSources     APITokenScope = "admin:sources"
+	ScopeAdminAudit       APITokenScope = "admin:audit"  // Query audit log and OIDC sync events
+	ScopeAdminConfig      APITokenScope = "admin:config" // Bundles and provisioning
+)
+
+// APITokenResource is a team/source pair an API token is restricted to.
+type APITokenResource struct {
+	TeamID   TeamID   `json:"team_id"`
+	SourceID SourceID `json:"source_id"`
+}
+
+// APITokenRestrictions limit what an API token can do on behalf of its user.
+type APITokenRestrictions struct {
+	Scopes       []APITokenScope    `json:"scopes"`
+	Resources    []APITokenResource `json:"resources"`     // Empty means all of the user's teams and sources
+	AllowedCIDRs []string           `json:"allowed_cidrs"` // Empty means any client address
+}
+
 // CreateAPITokenRequest represents a request to create a new API token
 type CreateAPITokenRequest struct {
 	Name      string     `json:"name"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	APITokenRestrictions
 }
 
 // CreateAPITokenResponse represents the response when creating an API token
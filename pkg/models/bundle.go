@@ -0,0 +1,136 @@
+package models
+
+import "time"
+
+// BundleVersion is the version of the bundle format written by exports. Imports accept
+// bundles up to this version.
+const BundleVersion = 1
+
+// BundleFormat is the encoding of a bundle.
+type BundleFormat string
+
+const (
+	// BundleFormatYAML encodes bundles as YAML.
+	BundleFormatYAML BundleFormat = "yaml"
+	// BundleFormatJSON encodes bundles as JSON.
+	BundleFormatJSON BundleFormat = "json"
+)
+
+// BundleConflictStrategy decides what an import does with objects that already exist.
+type BundleConflictStrategy string
+
+const (
+	// BundleConflictSkip keeps existing objects unchanged.
+	BundleConflictSkip BundleConflictStrategy = "skip"
+	// BundleConflictOverwrite replaces existing objects with the bundle's version.
+	BundleConflictOverwrite BundleConflictStrategy = "overwrite"
+	// BundleConflictRename imports conflicting objects under a new name.
+	BundleConflictRename BundleConflictStrategy = "rename"
+)
+
+// Bundle is a portable export of sources, teams, their source links and collections.
+// Objects reference each other by their IDs in the exporting instance, which imports
+// remap to the IDs in the target instance. Source credentials are never exported.
+type Bundle struct {
+	Version    int            `json:"version" yaml:"version"`
+	ExportedAt time.Time      `json:"exported_at" yaml:"exported_at"`
+	Sources    []BundleSource `json:"sources" yaml:"sources"`
+	Teams      []BundleTeam   `json:"teams" yaml:"teams"`
+}
+
+// BundleSource is a source definition without its credentials. Sources are identified
+// by their database and table.
+type BundleSource struct {
+	ID                SourceID         `json:"id" yaml:"id"`
+	Name              string           `json:"name" yaml:"name"`
+	Description       string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Host              string           `json:"host" yaml:"host"`
+	Database          string           `json:"database" yaml:"database"`
+	TableName         string           `json:"table_name" yaml:"table_name"`
+	MetaTSField       string           `json:"meta_ts_field" yaml:"meta_ts_field"`
+	MetaSeverityField string           `json:"meta_severity_field,omitempty" yaml:"meta_severity_field,omitempty"`
+	TTLDays           int              `json:"ttl_days" yaml:"ttl_days"`
+	FieldRoles        SourceFieldRoles `json:"field_roles,omitempty" yaml:"field_roles,omitempty"`
+	SeverityMapping   SeverityMapping  `json:"severity_mapping,omitempty" yaml:"severity_mapping,omitempty"`
+}
+
+// BundleTeam is a team with its source links and collections. Teams are identified by name.
+type BundleTeam struct {
+	ID                   TeamID             `json:"id" yaml:"id"`
+	Name                 string             `json:"name" yaml:"name"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	AllowAnonymousShares bool               `json:"allow_anonymous_shares,omitempty" yaml:"allow_anonymous_shares,omitempty"`
+	Sources              []BundleTeamSource `json:"sources" yaml:"sources"`
+	Collections          []BundleCollection `json:"collections" yaml:"collections"`
+}
+
+// BundleTeamSource links a team to a bundle source, with the row filter and column policies
+// restricting the team's access to it.
+type BundleTeamSource struct {
+	SourceID       SourceID             `json:"source_id" yaml:"source_id"`
+	RowFilter      string               `json:"row_filter,omitempty" yaml:"row_filter,omitempty"`
+	ColumnPolicies []BundleColumnPolicy `json:"column_policies,omitempty" yaml:"column_policies,omitempty"`
+}
+
+// BundleColumnPolicy hides or masks a column for a team (see ColumnPolicy).
+type BundleColumnPolicy struct {
+	Column      string             `json:"column" yaml:"column"`
+	Action      ColumnPolicyAction `json:"action" yaml:"action"`
+	Pattern     string             `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Replacement string             `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+}
+
+// BundleCollection is a saved query of a team. Collections are identified by their team,
+// source and name.
+type BundleCollection struct {
+	ID          int            `json:"id" yaml:"id"`
+	SourceID    SourceID       `json:"source_id" yaml:"source_id"`
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	QueryType   SavedQueryType `json:"query_type" yaml:"query_type"`
+	Folder      string         `json:"folder,omitempty" yaml:"folder,omitempty"`
+	Tags        []string       `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Content is the saved query content (see SavedQueryContent), kept as a document so
+	// bundles stay readable as YAML.
+	Content map[string]any `json:"content" yaml:"content"`
+}
+
+// BundleImportOptions controls how a bundle is imported.
+type BundleImportOptions struct {
+	DryRun   bool                   `json:"dry_run"`
+	Conflict BundleConflictStrategy `json:"conflict"` // Defaults to skip
+	// ImportedBy is recorded as the creator of imported collections, if set.
+	ImportedBy *UserID `json:"-"`
+}
+
+// BundleImportAction is what an import did, or would do in a dry run, with an object.
+type BundleImportAction string
+
+const (
+	BundleImportCreate BundleImportAction = "create"
+	BundleImportUpdate BundleImportAction = "update"
+	BundleImportSkip   BundleImportAction = "skip"
+	BundleImportRename BundleImportAction = "rename"
+)
+
+// BundleImportItem reports the outcome for one object of a bundle.
+type BundleImportItem struct {
+	Kind    string             `json:"kind"` // source, team, team_source or collection
+	Name    string             `json:"name"`
+	Action  BundleImportAction `json:"action"`
+	OldID   int                `json:"old_id,omitempty"`
+	NewID   int                `json:"new_id,omitempty"` // Unset for objects a dry run would create
+	Message string             `json:"message,omitempty"`
+}
+
+// BundleImportResult summarizes an import, including how bundle IDs map to IDs in this instance.
+type BundleImportResult struct {
+	DryRun        bool                   `json:"dry_run"`
+	Conflict      BundleConflictStrategy `json:"conflict"`
+	Items         []BundleImportItem     `json:"items"`
+	SourceIDs     map[SourceID]SourceID  `json:"source_ids"`
+	TeamIDs       map[TeamID]TeamID      `json:"team_ids"`
+	CollectionIDs map[int]int            `json:"collection_ids"`
+	// CreatedSources lists sources created without credentials, which must be set before use.
+	CreatedSources []SourceID `json:"created_sources,omitempty"`
+}
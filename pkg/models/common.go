@@ -42,6 +42,7 @@ type QueryStats struct {
 	ExecutionTimeMs float64 `json:"execution_time_ms"`
 	RowsRead        int     `json:"rows_read"`
 	BytesRead       int     `json:"bytes_read,omitempty"`
+	Cached          bool    `json:"cached,omitempty"` // Served from the query result cache
 }
 
 // ColumnInfo represents column metadata from ClickHouse
@@ -0,0 +1,94 @@
+package models
+
+// DashboardPanelType represents how a dashboard panel displays its query results.
+type DashboardPanelType string
+
+const (
+	// DashboardPanelTable shows the query rows as a table.
+	DashboardPanelTable DashboardPanelType = "table"
+	// DashboardPanelHistogram shows log counts over time.
+	DashboardPanelHistogram DashboardPanelType = "histogram"
+	// DashboardPanelStat shows the first value of the first row.
+	DashboardPanelStat DashboardPanelType = "stat"
+	// DashboardPanelPie shows the first column as labels and the second as values.
+	DashboardPanelPie DashboardPanelType = "pie"
+)
+
+// DashboardPanelLayout is the position and size of a panel on the dashboard grid.
+type DashboardPanelLayout struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// DashboardPanel is a single panel of a dashboard, backed by either a saved SQL query
+// of the team or an inline SQL query.
+type DashboardPanel struct {
+	ID           string               `json:"id"`
+	Title        string               `json:"title"`
+	Type         DashboardPanelType   `json:"type"`
+	SourceID     SourceID             `json:"source_id"`
+	SavedQueryID *int                 `json:"saved_query_id,omitempty"`
+	Query        string               `json:"query,omitempty"`    // Inline SQL, used when SavedQueryID is unset
+	Limit        int                  `json:"limit,omitempty"`    // Row limit, defaults to the saved query's limit
+	Window       string               `json:"window,omitempty"`   // Histogram bucket size, e.g. "1m"
+	GroupBy      string               `json:"group_by,omitempty"` // Optional histogram grouping field
+	Layout       DashboardPanelLayout `json:"layout"`
+}
+
+// Dashboard is a grid of query panels sharing variables and a time range.
+// Panel queries reference the shared variables as {{name}} and the time picker as {{time_range}}.
+type Dashboard struct {
+	ID          int                  `json:"id"`
+	TeamID      TeamID               `json:"team_id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Panels      []DashboardPanel     `json:"panels"`
+	Variables   []SavedQueryVariable `json:"variables"`
+	TimeRange   SavedQueryTimeRange  `json:"time_range"`
+	// Concurrency is the number of panels queried at once, capped by dashboards.max_concurrency.
+	// 0 uses the maximum.
+	Concurrency int `json:"concurrency"`
+	Timestamps
+}
+
+// DashboardDefinition is the layout and configuration of a dashboard, stored as JSON.
+type DashboardDefinition struct {
+	Panels      []DashboardPanel     `json:"panels"`
+	Variables   []SavedQueryVariable `json:"variables"`
+	TimeRange   SavedQueryTimeRange  `json:"time_range"`
+	Concurrency int                  `json:"concurrency,omitempty"`
+}
+
+// CreateDashboardRequest represents a request to create a dashboard
+type CreateDashboardRequest struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Panels      []DashboardPanel     `json:"panels"`
+	Variables   []SavedQueryVariable `json:"variables"`
+	TimeRange   SavedQueryTimeRange  `json:"time_range"`
+	Concurrency int                  `json:"concurrency"`
+}
+
+// UpdateDashboardRequest represents a request to update a dashboard. Omitted fields are unchanged.
+type UpdateDashboardRequest struct {
+	Name        *string               `json:"name"`
+	Description *string               `json:"description"`
+	Panels      *[]DashboardPanel     `json:"panels"`
+	Variables   *[]SavedQueryVariable `json:"variables"`
+	TimeRange   *SavedQueryTimeRange  `json:"time_range"`
+	Concurrency *int                  `json:"concurrency"`
+}
+
+// APIRunDashboardRequest represents the request payload for running a dashboard's panels.
+type APIRunDashboardRequest struct {
+	// Variables maps shared variable names to their values. "time_range" overrides the dashboard's time range.
+	Variables map[string]string `json:"variables,omitempty"`
+	// PanelIDs limits the run to the given panels. All panels run when empty.
+	PanelIDs []string `json:"panel_ids,omitempty"`
+	// Query execution timeout in seconds for each panel. If not specified, uses default timeout.
+	QueryTimeout *int `json:"query_timeout,omitempty"`
+	// NoCache bypasses the query result cache and refreshes the panels' cached results.
+	NoCache bool `json:"no_cache,omitempty"`
+}
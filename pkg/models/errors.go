@@ -28,6 +28,9 @@ const (
 	// ExternalServiceErrorType indicates an error with an external service
 	ExternalServiceErrorType ErrorType = "ExternalServiceError"
 
+	// RateLimitErrorType indicates a request was rejected by a rate or concurrency limit
+	RateLimitErrorType ErrorType = "RateLimitError"
+
 	// GeneralErrorType is a fallback for general errors
 	GeneralErrorType ErrorType = "GeneralError"
 
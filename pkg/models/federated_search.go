@@ -0,0 +1,22 @@
+package models
+
+// FederatedSourceResult is the outcome of searching a single source in a federated search. A
+// failed source carries its error without failing the rest of the search.
+type FederatedSourceResult struct {
+	SourceID       SourceID   `json:"source_id"`
+	Name           string     `json:"name"`
+	TimestampField string     `json:"timestamp_field"`
+	Rows           int        `json:"rows"`                // Rows returned by the source before merging
+	Truncated      bool       `json:"truncated,omitempty"` // More rows matched than were fetched
+	Stats          QueryStats `json:"stats"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// FederatedSearchResult is the merged result of searching several sources, newest rows first.
+// Each row carries the name and ID of its source in the _source and _source_id columns.
+type FederatedSearchResult struct {
+	Logs    []map[string]interface{} `json:"logs"`
+	Columns []ColumnInfo             `json:"columns"` // Union of the sources' columns
+	Sources []*FederatedSourceResult `json:"sources"`
+	Stats   QueryStats               `json:"stats"` // Totals across sources
+}
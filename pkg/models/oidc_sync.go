@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// OIDCSyncAction is a decision made while syncing a user's OIDC groups on login.
+type OIDCSyncAction string
+
+const (
+	// OIDCSyncCreateUser creates a user on their first login.
+	OIDCSyncCreateUser OIDCSyncAction = "create_user"
+
+	// OIDCSyncReject refuses the first login of a user who matches no group mapping.
+	OIDCSyncReject OIDCSyncAction = "reject"
+
+	// OIDCSyncGrantAdmin makes a member of an admin group a global admin.
+	OIDCSyncGrantAdmin OIDCSyncAction = "grant_admin"
+
+	// OIDCSyncRevokeAdmin demotes a user who left all admin groups.
+	OIDCSyncRevokeAdmin OIDCSyncAction = "revoke_admin"
+
+	// OIDCSyncAddMember adds a user to a mapped team.
+	OIDCSyncAddMember OIDCSyncAction = "add_member"
+
+	// OIDCSyncUpdateRole changes the role of a synced membership.
+	OIDCSyncUpdateRole OIDCSyncAction = "update_role"
+
+	// OIDCSyncRemoveMember removes a synced membership whose groups the user left.
+	OIDCSyncRemoveMember OIDCSyncAction = "remove_member"
+
+	// OIDCSyncSkip leaves something unchanged that the groups call for changing, e.g. a
+	// manual membership or an unknown team.
+	OIDCSyncSkip OIDCSyncAction = "skip"
+)
+
+// OIDCSyncEvent records one decision of OIDC group sync.
+type OIDCSyncEvent struct {
+	ID        int64          `json:"id"`
+	UserID    *UserID        `json:"user_id,omitempty"` // Unset for rejected logins
+	UserEmail string         `json:"user_email"`
+	TeamID    *TeamID        `json:"team_id,omitempty"`
+	TeamName  string         `json:"team_name,omitempty"`
+	Action    OIDCSyncAction `json:"action"`
+	Role      string         `json:"role,omitempty"`   // Team or global role granted or changed to
+	Groups    []string       `json:"groups"`           // Groups behind the decision
+	Reason    string         `json:"reason,omitempty"` // Why a change was skipped or rejected
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// OIDCSyncEventFilter narrows a search of the OIDC sync log. Nil and empty fields are ignored.
+type OIDCSyncEventFilter struct {
+	UserID *UserID
+	TeamID *TeamID
+	Action OIDCSyncAction
+	Limit  int
+	Offset int
+}
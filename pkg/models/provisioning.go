@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+// ProvisioningFile declares users, sources and teams managed as code. All files of the
+// provisioning directory are merged, and each object may only be declared once.
+// References of the form ${NAME} are replaced with environment variables, which keeps
+// source credentials out of the files.
+type ProvisioningFile struct {
+	Users   []ProvisionedUser   `yaml:"users"`
+	Sources []ProvisionedSource `yaml:"sources"`
+	Teams   []ProvisionedTeam   `yaml:"teams"`
+}
+
+// ProvisionedUser declares a user, identified by email.
+type ProvisionedUser struct {
+	Email    string     `yaml:"email"`
+	FullName string     `yaml:"full_name"`
+	Role     UserRole   `yaml:"role"`   // Defaults to member
+	Status   UserStatus `yaml:"status"` // Defaults to active
+}
+
+// ProvisionedSource declares a source, identified by its database and table.
+// Teams refer to it by name.
+type ProvisionedSource struct {
+	Name              string           `yaml:"name"`
+	Description       string           `yaml:"description"`
+	Host              string           `yaml:"host"`
+	Username          string           `yaml:"username"`
+	Password          string           `yaml:"password"`
+	Database          string           `yaml:"database"`
+	TableName         string           `yaml:"table_name"`
+	MetaTSField       string           `yaml:"meta_ts_field"`
+	MetaSeverityField string           `yaml:"meta_severity_field"`
+	TTLDays           int              `yaml:"ttl_days"`
+	FieldRoles        SourceFieldRoles `yaml:"field_roles"`
+	SeverityMapping   SeverityMapping  `yaml:"severity_mapping"`
+}
+
+// ProvisionedTeam declares a team, identified by name. Its source links are kept exactly
+// as declared; members and collections added from the UI are left alone.
+type ProvisionedTeam struct {
+	Name                 string                  `yaml:"name"`
+	Description          string                  `yaml:"description"`
+	AllowAnonymousShares bool                    `yaml:"allow_anonymous_shares"`
+	Members              []ProvisionedTeamMember `yaml:"members"`
+	Sources              []ProvisionedTeamSource `yaml:"sources"`
+	Collections          []ProvisionedCollection `yaml:"collections"`
+}
+
+// ProvisionedTeamMember declares the membership of a user in a team.
+type ProvisionedTeamMember struct {
+	Email string   `yaml:"email"`
+	Role  TeamRole `yaml:"role"` // Defaults to member
+}
+
+// ProvisionedTeamSource links a team to a source by the source's name. Declared column
+// policies replace the link's policies, including an empty list; when column_policies is
+// omitted, policies set from the UI are left alone.
+type ProvisionedTeamSource struct {
+	Source         string                    `yaml:"source"`
+	RowFilter      string                    `yaml:"row_filter"`
+	ColumnPolicies []ProvisionedColumnPolicy `yaml:"column_policies"`
+}
+
+// ProvisionedColumnPolicy hides or masks a column for a team (see ColumnPolicy).
+type ProvisionedColumnPolicy struct {
+	Column      string             `yaml:"column"`
+	Action      ColumnPolicyAction `yaml:"action"`
+	Pattern     string             `yaml:"pattern"`
+	Replacement string             `yaml:"replacement"`
+}
+
+// ProvisionedCollection declares a saved query of a team, identified by its source and name.
+type ProvisionedCollection struct {
+	Name        string         `yaml:"name"`
+	Source      string         `yaml:"source"`
+	Description string         `yaml:"description"`
+	QueryType   SavedQueryType `yaml:"query_type"`
+	Folder      string         `yaml:"folder"`
+	Tags        []string       `yaml:"tags"`
+	// Content is the saved query content (see SavedQueryContent). Its sourceId is set
+	// from Source.
+	Content map[string]any `yaml:"content"`
+}
+
+// ProvisioningAction is a change made while applying the provisioning files.
+type ProvisioningAction string
+
+const (
+	ProvisioningCreate ProvisioningAction = "create"
+	ProvisioningUpdate ProvisioningAction = "update"
+	ProvisioningDelete ProvisioningAction = "delete"
+	// ProvisioningRelease unmarks an object that is no longer declared, when pruning is
+	// disabled, so it can be managed from the UI again.
+	ProvisioningRelease ProvisioningAction = "release"
+)
+
+// ProvisioningChange reports a change made to one object.
+type ProvisioningChange struct {
+	Kind   string             `json:"kind"` // user, source, team, team_member, team_source or collection
+	Name   string             `json:"name"`
+	Action ProvisioningAction `json:"action"`
+}
+
+// ProvisioningStatus reports the configuration and the last run of provisioning.
+type ProvisioningStatus struct {
+	Enabled   bool                 `json:"enabled"`
+	Path      string               `json:"path"`
+	Watch     bool                 `json:"watch"`
+	Prune     bool                 `json:"prune"`
+	AppliedAt *time.Time           `json:"applied_at,omitempty"`
+	Files     []string             `json:"files"`
+	Changes   []ProvisioningChange `json:"changes"` // Of the last run
+	Error     string               `json:"error,omitempty"`
+}
@@ -39,6 +39,8 @@ type APIQueryRequest struct {
 	RawSQL string `json:"raw_sql"`
 	// Query execution timeout in seconds. If not specified, uses default timeout.
 	QueryTimeout *int `json:"query_timeout,omitempty"`
+	// NoCache bypasses the query result cache and refreshes the cached result.
+	NoCache bool `json:"no_cache,omitempty"`
 	// Sort and other general query params could be added here if needed later.
 }
 
@@ -53,6 +55,43 @@ type APIHistogramRequest struct {
 	Timezone       string `json:"timezone,omitempty"`        // Kept for histogram, optional otherwise
 	// Query execution timeout in seconds. If not specified, uses default timeout.
 	QueryTimeout *int `json:"query_timeout,omitempty"`
+	// NoCache bypasses the query result cache and refreshes the cached result.
+	NoCache bool `json:"no_cache,omitempty"`
+}
+
+// APIPatternsRequest represents the request payload for the log patterns endpoint.
+type APIPatternsRequest struct {
+	RawSQL      string  `json:"raw_sql"`                // Query whose results are clustered
+	Field       string  `json:"field,omitempty"`        // Text column to cluster, defaults to "body"
+	SampleSize  int     `json:"sample_size,omitempty"`  // Maximum number of rows clustered
+	MaxPatterns int     `json:"max_patterns,omitempty"` // Maximum number of patterns returned
+	Samples     int     `json:"samples,omitempty"`      // Sample rows returned per pattern
+	Similarity  float64 `json:"similarity,omitempty"`   // Token similarity threshold between 0 and 1
+	// Query execution timeout in seconds. If not specified, uses default timeout.
+	QueryTimeout *int `json:"query_timeout,omitempty"`
+}
+
+// APIRunSavedQueryRequest represents the request payload for running a saved query.
+type APIRunSavedQueryRequest struct {
+	// Variables maps variable names to their values. Omitted variables use their defaults.
+	// The built-in time_range variable overrides the saved time range.
+	Variables map[string]string `json:"variables,omitempty"`
+	Limit     int               `json:"limit,omitempty"` // Overrides the saved limit when positive
+	// Query execution timeout in seconds. If not specified, uses default timeout.
+	QueryTimeout *int `json:"query_timeout,omitempty"`
+}
+
+// APIFederatedSearchRequest represents the request payload for searching several sources of a team.
+type APIFederatedSearchRequest struct {
+	// Query is a LogchefQL filter. Besides each source's columns, it can use the semantic fields
+	// sources declare (service, trace_id, ..., timestamp and severity) to match all sources alike.
+	Query     string     `json:"query"`
+	SourceIDs []SourceID `json:"source_ids,omitempty"` // Sources searched, defaults to all of the team's sources
+	StartTime int64      `json:"start_time"`           // Unix timestamp in milliseconds
+	EndTime   int64      `json:"end_time"`             // Unix timestamp in milliseconds
+	Limit     int        `json:"limit,omitempty"`      // Maximum number of merged rows
+	// Query execution timeout in seconds, applied to each source. If not specified, uses default timeout.
+	QueryTimeout *int `json:"query_timeout,omitempty"`
 }
 
 // LogQueryResult represents the result of a log query
@@ -66,8 +105,11 @@ type LogQueryResult struct {
 type LogContextRequest struct {
 	SourceID    SourceID `json:"source_id"`
 	Timestamp   int64    `json:"timestamp"`    // Target timestamp in milliseconds
-	BeforeLimit int      `json:"before_limit"` // Optional, defaults to 5
-	AfterLimit  int      `json:"after_limit"`  // Optional, defaults to 5
+	BeforeLimit int      `json:"before_limit"` // Logs returned before the target
+	AfterLimit  int      `json:"after_limit"`  // Logs returned after the target
+	// Scope restricts the context to logs with these values of the source's role fields,
+	// e.g. {"service": "api", "host": "web-1"}.
+	Scope map[FieldRole]string `json:"scope,omitempty"`
 }
 
 // LogContextResponse represents temporal context query results
@@ -90,12 +132,55 @@ const (
 	SavedQueryTabRawSQL SavedQueryTab = "raw_sql"
 )
 
-// SavedQueryTimeRange represents a time range for a saved query
+// SavedQueryTimeRange represents a time range for a saved query.
+// A relative range is resolved against the time the query runs and takes
+// precedence over an absolute range.
 type SavedQueryTimeRange struct {
-	Absolute struct {
-		Start int64 `json:"start"` // Unix timestamp in milliseconds
-		End   int64 `json:"end"`   // Unix timestamp in milliseconds
-	} `json:"absolute"`
+	Relative string                   `json:"relative,omitempty"` // Range ending now, e.g. "15m" or "last 24h"
+	Absolute *SavedQueryAbsoluteRange `json:"absolute,omitempty"`
+}
+
+// SavedQueryAbsoluteRange represents a fixed time range.
+type SavedQueryAbsoluteRange struct {
+	Start int64 `json:"start"` // Unix timestamp in milliseconds
+	End   int64 `json:"end"`   // Unix timestamp in milliseconds
+}
+
+// IsZero reports whether no time range is set.
+func (r SavedQueryTimeRange) IsZero() bool {
+	return r.Relative == "" && (r.Absolute == nil || (r.Absolute.Start == 0 && r.Absolute.End == 0))
+}
+
+// SavedQueryVariableType represents the type of a saved query variable
+type SavedQueryVariableType string
+
+const (
+	// SavedQueryVariableString is a free-form string value
+	SavedQueryVariableString SavedQueryVariableType = "string"
+
+	// SavedQueryVariableEnum is a string value chosen from a list of options
+	SavedQueryVariableEnum SavedQueryVariableType = "enum"
+
+	// SavedQueryVariableNumber is a numeric value
+	SavedQueryVariableNumber SavedQueryVariableType = "number"
+
+	// SavedQueryVariableTimeRange is a relative or absolute time range
+	SavedQueryVariableTimeRange SavedQueryVariableType = "time_range"
+)
+
+// SavedQueryVariable declares a variable referenced in a saved query as {{name}}.
+// Time range variables are referenced as {{name}} for a condition on the source's
+// timestamp field, or as {{name.start}} and {{name.end}} for the bounds.
+type SavedQueryVariable struct {
+	Name     string                 `json:"name"`
+	Label    string                 `json:"label,omitempty"`
+	Type     SavedQueryVariableType `json:"type"`
+	Required bool                   `json:"required,omitempty"`
+	Default  string                 `json:"default,omitempty"`
+	// Options lists the allowed values of an enum variable.
+	Options []string `json:"options,omitempty"`
+	// OptionsColumn populates an enum variable with the distinct values of a column.
+	OptionsColumn string `json:"options_column,omitempty"`
 }
 
 // SavedQueryType represents the type of saved query
@@ -116,6 +201,8 @@ type SavedQueryContent struct {
 	TimeRange SavedQueryTimeRange `json:"timeRange"`
 	Limit     int                 `json:"limit"`
 	Content   string              `json:"content"` // Query content (SQL or LogchefQL)
+	// Variables declared by the query and referenced in Content as {{name}}.
+	Variables []SavedQueryVariable `json:"variables,omitempty"`
 }
 
 // SavedTeamQuery represents a saved query associated with a team
@@ -127,10 +214,98 @@ type SavedTeamQuery struct {
 	Description  string         `json:"description" db:"description"`
 	QueryType    SavedQueryType `json:"query_type" db:"query_type"`
 	QueryContent string         `json:"query_content" db:"query_content"` // JSON string of SavedQueryContent
+	CreatedBy    *UserID        `json:"created_by,omitempty" db:"created_by"`
+	UpdatedBy    *UserID        `json:"updated_by,omitempty" db:"updated_by"`
+	FolderID     *int           `json:"folder_id,omitempty" db:"folder_id"`
+	Tags         []string       `json:"tags" db:"-"`
+	LastUsedAt   *time.Time     `json:"last_used_at,omitempty" db:"last_used_at"`
+	IsFavorite   bool           `json:"is_favorite" db:"-"` // For the requesting user, set when listing or searching
+	IsPinned     bool           `json:"is_pinned" db:"-"`   // For the requesting user, set when listing or searching
+	Provisioned  bool           `json:"provisioned" db:"provisioned"`
 	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
 }
 
+// CollectionFolder groups saved queries of a team.
+type CollectionFolder struct {
+	ID         int    `json:"id"`
+	TeamID     TeamID `json:"team_id"`
+	Name       string `json:"name"`
+	QueryCount int    `json:"query_count"`
+	Timestamps
+}
+
+// SavedQuerySort is the order of saved query search results. Pinned favorites always come first.
+type SavedQuerySort string
+
+const (
+	// SavedQuerySortLastUsed orders by the last run, most recent first
+	SavedQuerySortLastUsed SavedQuerySort = "last_used"
+	// SavedQuerySortUpdated orders by the last update, most recent first
+	SavedQuerySortUpdated SavedQuerySort = "updated"
+	// SavedQuerySortName orders alphabetically
+	SavedQuerySortName SavedQuerySort = "name"
+)
+
+// SavedQuerySearchFilter selects saved queries of a team across its sources.
+type SavedQuerySearchFilter struct {
+	TeamID        TeamID
+	UserID        UserID // User whose favorites and pins are reported
+	SourceID      *SourceID
+	FolderID      *int // 0 selects queries outside any folder
+	Tag           string
+	FavoritesOnly bool
+	Search        string // Substring matched against name, description, tags and query text
+	Sort          SavedQuerySort
+	Limit         int // No limit when 0
+	Offset        int
+	// AllowSource, when set, limits the results to the sources it reports as allowed, e.g. the
+	// sources an API token is restricted to.
+	AllowSource func(SourceID) bool
+}
+
+// SavedQueryRevision is a version of a saved query, recorded each time it's created,
+// updated or restored.
+type SavedQueryRevision struct {
+	ID             int            `json:"id"`
+	QueryID        int            `json:"query_id"`
+	Revision       int            `json:"revision"` // Sequential per query, starting at 1
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	QueryType      SavedQueryType `json:"query_type"`
+	QueryContent   string         `json:"query_content"` // JSON string of SavedQueryContent
+	CreatedBy      *UserID        `json:"created_by,omitempty"`
+	CreatedByEmail string         `json:"created_by_email,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// DiffOp is the kind of change of a line in a diff.
+type DiffOp string
+
+const (
+	// DiffOpEqual marks a line present in both versions
+	DiffOpEqual DiffOp = "equal"
+	// DiffOpAdd marks a line only present in the newer version
+	DiffOpAdd DiffOp = "add"
+	// DiffOpRemove marks a line only present in the older version
+	DiffOpRemove DiffOp = "remove"
+)
+
+// DiffLine is a single line of a line-based diff.
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// SavedQueryRevisionDiff is the line-based diff between two revisions of a saved query.
+type SavedQueryRevisionDiff struct {
+	QueryID      int        `json:"query_id"`
+	FromRevision int        `json:"from_revision"` // 0 when diffing the first revision against nothing
+	ToRevision   int        `json:"to_revision"`
+	Lines        []DiffLine `json:"lines"`
+	Unified      string     `json:"unified"` // The same diff in unified format
+}
+
 // CreateTeamQueryRequest represents a request to create a team query
 type CreateTeamQueryRequest struct {
 	Name         string         `json:"name" validate:"required"`
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// QueryCacheEntry is a query result stored in the on-disk tier of the query cache.
+type QueryCacheEntry struct {
+	Key       string
+	SourceID  SourceID
+	Kind      string // logs or histogram
+	Value     []byte // JSON encoded result
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
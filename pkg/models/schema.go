@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // TableSchema represents a table's schema information
 type TableSchema struct {
 	Columns []ColumnInfo `json:"columns"`
@@ -12,67 +14,86 @@ type QueryResult struct {
 	Columns []ColumnInfo             `json:"columns"`
 }
 
-// Schema Constants
-const (
-	// OTELLogsTableSchema is the schema for OpenTelemetry logs
-	OTELLogsTableSchema = `CREATE TABLE "{{database_name}}"."{{table_name}}"
-	(
-		timestamp DateTime64(3) CODEC(DoubleDelta, LZ4),
-		trace_id String CODEC(ZSTD(1)),
-		span_id String CODEC(ZSTD(1)),
-		trace_flags UInt32 CODEC(ZSTD(1)),
-		severity_text LowCardinality(String) CODEC(ZSTD(1)),
-		severity_number Int32 CODEC(ZSTD(1)),
-		service_name LowCardinality(String) CODEC(ZSTD(1)),
-		namespace LowCardinality(String) CODEC(ZSTD(1)),
-		body String CODEC(ZSTD(1)),
-		log_attributes Map(LowCardinality(String), String) CODEC(ZSTD(1)),
+// SchemaTemplate describes a named table schema that can be used when auto-creating a source's table.
+type SchemaTemplate struct {
+	Name           string                    `json:"name"`
+	Title          string                    `json:"title"`
+	Description    string                    `json:"description"`
+	TimestampField string                    `json:"timestamp_field"`
+	SeverityField  string                    `json:"severity_field,omitempty"`
+	FieldRoles     SourceFieldRoles          `json:"field_roles"`
+	Columns        []string                  `json:"columns"`
+	Parameters     []SchemaTemplateParameter `json:"parameters"`
+}
+
+// SchemaTemplateParameter describes a parameter accepted by a schema template.
+type SchemaTemplateParameter struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"` // "string", "string_list", "bool" or "int"
+	Default     any      `json:"default,omitempty"`
+	Options     []string `json:"options,omitempty"` // Allowed values, if restricted
+	Description string   `json:"description"`
+}
+
+// SchemaTemplateParams are the parameters used to render a schema template. Empty fields
+// use the template defaults. The TTL comes from the source's ttl_days.
+type SchemaTemplateParams struct {
+	PartitionBy   string   `json:"partition_by,omitempty"` // "day", "week", "month" or "none"
+	OrderBy       []string `json:"order_by,omitempty"`
+	Codec         string   `json:"codec,omitempty"`
+	Replicated    bool     `json:"replicated,omitempty"`
+	Cluster       string   `json:"cluster,omitempty"`
+	ZooKeeperPath string   `json:"zookeeper_path,omitempty"`
+	ReplicaName   string   `json:"replica_name,omitempty"`
+}
+
+// RenderSchemaTemplateRequest is the request to preview the DDL of a schema template.
+type RenderSchemaTemplateRequest struct {
+	Database string               `json:"database"`
+	Table    string               `json:"table"`
+	TTLDays  int                  `json:"ttl_days"`
+	Params   SchemaTemplateParams `json:"params"`
+}
 
-		INDEX idx_trace_id trace_id TYPE bloom_filter(0.001) GRANULARITY 1,
-		INDEX idx_severity_text severity_text TYPE set(100) GRANULARITY 4,
-		INDEX idx_log_attributes_keys mapKeys(log_attributes) TYPE bloom_filter(0.01) GRANULARITY 1,
-		INDEX idx_log_attributes_values mapValues(log_attributes) TYPE bloom_filter(0.01) GRANULARITY 1,
-		INDEX idx_body body TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 1
-	)
-	ENGINE = MergeTree()
-	PARTITION BY toDate(timestamp)
-	ORDER BY (namespace, service_name, timestamp)
-	TTL toDateTime(timestamp) + INTERVAL {{ttl_day}} DAY
-	SETTINGS index_granularity = 8192, ttl_only_drop_parts = 1;`
+// SchemaSnapshot is the schema of a source's table when it was last checked.
+type SchemaSnapshot struct {
+	SourceID SourceID     `json:"source_id"`
+	Columns  []ColumnInfo `json:"columns"`
+	SortKeys []string     `json:"sort_keys"`
+	TakenAt  time.Time    `json:"taken_at"`
+}
+
+// SchemaChangeType is the kind of a detected schema change.
+type SchemaChangeType string
 
-	// HTTPLogsTableSchema is the schema for HTTP logs table
-	HTTPLogsTableSchema = `
-	CREATE TABLE {{database_name}}.{{table_name}} (
-		timestamp DateTime,
-		remote_addr String,
-		request_method String,
-		request_uri String,
-		status UInt16,
-		body_bytes_sent UInt64,
-		http_referer String,
-		http_user_agent String,
-		http_x_forwarded_for String,
-		http_host String,
-		request_time Float64,
-		upstream_response_time Float64,
-		upstream_addr String,
-		upstream_status UInt16,
-		request_id String,
-		request_length UInt64,
-		request_completion String,
-		ssl_protocol String,
-		ssl_cipher String,
-		scheme String,
-		gzip_ratio Float64,
-		http_cf_ray String,
-		http_cf_connecting_ip String,
-		http_true_client_ip String,
-		http_cf_ipcountry String,
-		http_cf_visitor String,
-		http_cdn_loop String,
-		http_cf_worker String
-	) ENGINE = MergeTree()
-	PARTITION BY toYYYYMM(timestamp)
-	ORDER BY (timestamp, request_id)
-	TTL timestamp + INTERVAL {{ttl_day}} DAY`
+const (
+	SchemaChangeColumnAdded       SchemaChangeType = "column_added"
+	SchemaChangeColumnRemoved     SchemaChangeType = "column_removed"
+	SchemaChangeColumnTypeChanged SchemaChangeType = "column_type_changed"
+	SchemaChangeSortKeysChanged   SchemaChangeType = "sort_keys_changed"
 )
+
+// SchemaChange is a change of a source's table detected between two schema snapshots.
+type SchemaChange struct {
+	ID         int64            `json:"id"`
+	SourceID   SourceID         `json:"source_id"`
+	Type       SchemaChangeType `json:"type"`
+	Column     string           `json:"column,omitempty"`    // Empty for sort key changes
+	OldValue   string           `json:"old_value,omitempty"` // Previous type, or sort keys separated by commas
+	NewValue   string           `json:"new_value,omitempty"` // New type, or sort keys separated by commas
+	DetectedAt time.Time        `json:"detected_at"`
+}
+
+// SchemaChangeImpact is a saved query referencing columns that were removed from its source.
+type SchemaChangeImpact struct {
+	QueryID        int      `json:"query_id"`
+	TeamID         TeamID   `json:"team_id"`
+	Name           string   `json:"name"`
+	MissingColumns []string `json:"missing_columns"`
+}
+
+// SchemaDrift lists the latest schema changes of a source and the saved queries they break.
+type SchemaDrift struct {
+	Changes         []*SchemaChange       `json:"changes"`
+	AffectedQueries []*SchemaChangeImpact `json:"affected_queries"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// ShareLinkMode controls what a share link shows when opened.
+type ShareLinkMode string
+
+const (
+	// ShareLinkSnapshot shows the query result captured when the link was created.
+	ShareLinkSnapshot ShareLinkMode = "snapshot"
+	// ShareLinkLive re-runs the query with the permissions of the link's creator.
+	ShareLinkLive ShareLinkMode = "live"
+)
+
+// ShareLink is an expiring, revocable permalink to a query result.
+type ShareLink struct {
+	ID             int           `json:"id"`
+	Slug           string        `json:"slug"`
+	TeamID         TeamID        `json:"team_id"`
+	SourceID       SourceID      `json:"source_id"`
+	CreatedBy      UserID        `json:"created_by"`
+	CreatedByEmail string        `json:"created_by_email"`
+	Title          string        `json:"title"`
+	Mode           ShareLinkMode `json:"mode"`
+	RawSQL         string        `json:"raw_sql"`
+	Limit          int           `json:"limit"`
+	StartTime      *time.Time    `json:"start_time,omitempty"`
+	EndTime        *time.Time    `json:"end_time,omitempty"`
+	HasPassword    bool          `json:"has_password"`
+	AllowAnonymous bool          `json:"allow_anonymous"`
+	ViewCount      int           `json:"view_count"`
+	LastViewedAt   *time.Time    `json:"last_viewed_at,omitempty"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	RevokedAt      *time.Time    `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+
+	// Internal fields, never serialized.
+	PasswordHash       string `json:"-"`
+	Snapshot           string `json:"-"` // JSON encoded QueryResult of snapshot links
+	TeamAllowAnonymous bool   `json:"-"` // Whether the team currently allows anonymous access
+}
+
+// CreateShareLinkRequest represents a request to share a query result.
+type CreateShareLinkRequest struct {
+	Title  string        `json:"title"`
+	Mode   ShareLinkMode `json:"mode"` // Defaults to snapshot
+	RawSQL string        `json:"raw_sql"`
+	Limit  int           `json:"limit"`
+	// Query execution timeout in seconds, for capturing a snapshot.
+	QueryTimeout *int `json:"query_timeout,omitempty"`
+	// StartTime and EndTime are the time range of the query, set together. RawSQL must then
+	// reference it as {{time_range}}, {{time_range.start}} or {{time_range.end}}, which are
+	// bound to it whenever the query runs.
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+	// ExpiresInHours is the lifetime of the link, defaults to 7 days.
+	ExpiresInHours int    `json:"expires_in_hours"`
+	Password       string `json:"password,omitempty"`
+	// AllowAnonymous lets the link be opened without logging in, if the team allows it.
+	AllowAnonymous bool `json:"allow_anonymous"`
+}
+
+// SharedQueryResult is what a viewer of a share link receives.
+type SharedQueryResult struct {
+	Share      *ShareLink   `json:"share"`
+	Result     *QueryResult `json:"result"`
+	ExecutedAt time.Time    `json:"executed_at"` // When the result was captured or run
+}
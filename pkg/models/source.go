@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -24,6 +26,10 @@ type Source struct {
 	Connection        ConnectionInfo `db:"connection" json:"connection"`
 	Description       string         `db:"description" json:"description,omitempty"`
 	TTLDays           int            `db:"ttl_days" json:"ttl_days"`
+	Provisioned       bool           `db:"provisioned" json:"provisioned"`
+	// Columns with a semantic role and the levels of severity values
+	FieldRoles      SourceFieldRoles `db:"field_roles" json:"field_roles"`
+	SeverityMapping SeverityMapping  `db:"severity_mapping" json:"severity_mapping"`
 	Timestamps
 	IsConnected bool         `db:"-" json:"is_connected"`
 	Schema      string       `db:"-" json:"schema,omitempty"`
@@ -51,6 +57,9 @@ type SourceResponse struct {
 	Connection        ConnectionInfoResponse `json:"connection"`
 	Description       string                 `json:"description,omitempty"`
 	TTLDays           int                    `json:"ttl_days"`
+	Provisioned       bool                   `json:"provisioned"`
+	FieldRoles        SourceFieldRoles       `json:"field_roles"`
+	SeverityMapping   SeverityMapping        `json:"severity_mapping"`
 	CreatedAt         time.Time              `json:"created_at"`
 	UpdatedAt         time.Time              `json:"updated_at"`
 	IsConnected       bool                   `json:"is_connected"`
@@ -75,24 +84,149 @@ func (s *Source) ToResponse() *SourceResponse {
 			Database:  s.Connection.Database,
 			TableName: s.Connection.TableName,
 		},
-		Description:  s.Description,
-		TTLDays:      s.TTLDays,
-		CreatedAt:    s.CreatedAt,
-		UpdatedAt:    s.UpdatedAt,
-		IsConnected:  s.IsConnected,
-		Schema:       s.Schema,
-		Columns:      s.Columns,
-		Engine:       s.Engine,
-		EngineParams: s.EngineParams,
-		SortKeys:     s.SortKeys,
+		Description:     s.Description,
+		TTLDays:         s.TTLDays,
+		Provisioned:     s.Provisioned,
+		FieldRoles:      s.FieldRoles,
+		SeverityMapping: s.SeverityMapping,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt,
+		IsConnected:     s.IsConnected,
+		Schema:          s.Schema,
+		Columns:         s.Columns,
+		Engine:          s.Engine,
+		EngineParams:    s.EngineParams,
+		SortKeys:        s.SortKeys,
 	}
 }
 
+// FieldRole is the semantic role of a source column.
+type FieldRole string
+
+const (
+	FieldRoleMessage      FieldRole = "message"        // Log message or body
+	FieldRoleService      FieldRole = "service"        // Name of the service emitting the log
+	FieldRoleHost         FieldRole = "host"           // Host, pod or instance emitting the log
+	FieldRoleTraceID      FieldRole = "trace_id"       // Distributed tracing trace ID
+	FieldRoleSpanID       FieldRole = "span_id"        // Distributed tracing span ID
+	FieldRoleParentSpanID FieldRole = "parent_span_id" // Distributed tracing parent span ID
+	FieldRoleHTTPStatus   FieldRole = "http_status"    // HTTP response status code
+	FieldRoleDuration     FieldRole = "duration"       // Request or operation duration
+)
+
+// FieldRoles lists the semantic roles a source column can have, in display order.
+var FieldRoles = []FieldRole{
+	FieldRoleMessage, FieldRoleService, FieldRoleHost, FieldRoleTraceID,
+	FieldRoleSpanID, FieldRoleParentSpanID, FieldRoleHTTPStatus, FieldRoleDuration,
+}
+
+// SourceFieldRoles declares the columns of a source holding well-known log fields, besides
+// its timestamp and severity fields. An empty field means no column has the role.
+type SourceFieldRoles struct {
+	Message      string `json:"message,omitempty" yaml:"message,omitempty"`
+	Service      string `json:"service,omitempty" yaml:"service,omitempty"`
+	Host         string `json:"host,omitempty" yaml:"host,omitempty"`
+	TraceID      string `json:"trace_id,omitempty" yaml:"trace_id,omitempty"`
+	SpanID       string `json:"span_id,omitempty" yaml:"span_id,omitempty"`
+	ParentSpanID string `json:"parent_span_id,omitempty" yaml:"parent_span_id,omitempty"`
+	HTTPStatus   string `json:"http_status,omitempty" yaml:"http_status,omitempty"`
+	Duration     string `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// Column returns the column having a role, or "" when none is declared.
+func (r SourceFieldRoles) Column(role FieldRole) string {
+	switch role {
+	case FieldRoleMessage:
+		return r.Message
+	case FieldRoleService:
+		return r.Service
+	case FieldRoleHost:
+		return r.Host
+	case FieldRoleTraceID:
+		return r.TraceID
+	case FieldRoleSpanID:
+		return r.SpanID
+	case FieldRoleParentSpanID:
+		return r.ParentSpanID
+	case FieldRoleHTTPStatus:
+		return r.HTTPStatus
+	case FieldRoleDuration:
+		return r.Duration
+	}
+	return ""
+}
+
+// RolesOf returns the roles declared for a column.
+func (r SourceFieldRoles) RolesOf(column string) []FieldRole {
+	var roles []FieldRole
+	for _, role := range FieldRoles {
+		if column != "" && r.Column(role) == column {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// SeverityLevels lists the levels severity values can be mapped to, from least to most severe.
+var SeverityLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// SeverityMapping maps raw values of a source's severity field to severity levels. Keys are
+// exact values ("E", "warning") or inclusive ranges of numeric values ("9-12", as used by
+// OpenTelemetry severity numbers).
+type SeverityMapping map[string]string
+
+// Level returns the level of a raw severity value. Exact values take precedence over ranges.
+func (m SeverityMapping) Level(value string) (string, bool) {
+	if level, ok := m[value]; ok {
+		return level, true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	for key, level := range m {
+		if lo, hi, ok := ParseSeverityRange(key); ok && n >= lo && n <= hi {
+			return level, true
+		}
+	}
+	return "", false
+}
+
+// ParseSeverityRange parses a "lo-hi" severity mapping key.
+func ParseSeverityRange(key string) (lo, hi int64, ok bool) {
+	a, b, found := strings.Cut(key, "-")
+	if !found {
+		return 0, 0, false
+	}
+	lo, errA := strconv.ParseInt(strings.TrimSpace(a), 10, 64)
+	hi, errB := strconv.ParseInt(strings.TrimSpace(b), 10, 64)
+	if errA != nil || errB != nil || lo > hi {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
 // GetFullTableName returns the fully qualified table name (database.table)
 func (s *Source) GetFullTableName() string {
 	return fmt.Sprintf("%s.%s", s.Connection.Database, s.Connection.TableName)
 }
 
+// ColumnRoles returns the semantic roles of a column, including "timestamp" and "severity"
+// for the source's meta fields.
+func (s *Source) ColumnRoles(column string) []string {
+	var roles []string
+	if column == s.MetaTSField {
+		roles = append(roles, "timestamp")
+	}
+	if column != "" && column == s.MetaSeverityField {
+		roles = append(roles, "severity")
+	}
+	for _, role := range s.FieldRoles.RolesOf(column) {
+		roles = append(roles, string(role))
+	}
+	return roles
+}
+
 // SourceHealth represents the health status of a source
 type SourceHealth struct {
 	SourceID    SourceID     `json:"source_id"`
@@ -103,21 +237,104 @@ type SourceHealth struct {
 
 // CreateSourceRequest represents a request to create a new data source
 type CreateSourceRequest struct {
-	Name              string         `json:"name"`
-	MetaIsAutoCreated bool           `json:"meta_is_auto_created"`
-	MetaTSField       string         `json:"meta_ts_field"`
-	MetaSeverityField string         `json:"meta_severity_field"`
-	Connection        ConnectionInfo `json:"connection"`
-	Description       string         `json:"description"`
-	TTLDays           int            `json:"ttl_days"`
-	Schema            string         `json:"schema,omitempty"`
+	Name              string               `json:"name"`
+	MetaIsAutoCreated bool                 `json:"meta_is_auto_created"`
+	MetaTSField       string               `json:"meta_ts_field"`
+	MetaSeverityField string               `json:"meta_severity_field"`
+	Connection        ConnectionInfo       `json:"connection"`
+	Description       string               `json:"description"`
+	TTLDays           int                  `json:"ttl_days"`
+	Schema            string               `json:"schema,omitempty"`   // Custom CREATE TABLE statement
+	Template          string               `json:"template,omitempty"` // Schema template, used when no custom schema is given
+	TemplateParams    SchemaTemplateParams `json:"template_params"`    // Parameters of the schema template
+	FieldRoles        SourceFieldRoles     `json:"field_roles"`
+	SeverityMapping   SeverityMapping      `json:"severity_mapping"`
+}
+
+// UpdateSourceRequest represents a request to update a source. Omitted fields are unchanged.
+type UpdateSourceRequest struct {
+	Description     *string           `json:"description"`
+	TTLDays         *int              `json:"ttl_days"`
+	FieldRoles      *SourceFieldRoles `json:"field_roles"`
+	SeverityMapping *SeverityMapping  `json:"severity_mapping"`
+}
+
+// RetentionChangeStatus is the outcome of a change of a source's retention.
+type RetentionChangeStatus string
+
+const (
+	// RetentionChangeApplied means the new TTL was applied to the ClickHouse table.
+	RetentionChangeApplied RetentionChangeStatus = "applied"
+	// RetentionChangeFailed means applying the new TTL to the ClickHouse table failed.
+	RetentionChangeFailed RetentionChangeStatus = "failed"
+	// RetentionChangeNotApplied means only the stored retention changed, since LogChef
+	// doesn't manage the table.
+	RetentionChangeNotApplied RetentionChangeStatus = "not_applied"
+)
+
+// SourceRetentionChange records a change of a source's retention (ttl_days).
+type SourceRetentionChange struct {
+	ID         int64                 `json:"id"`
+	SourceID   SourceID              `json:"source_id"`
+	UserID     *UserID               `json:"user_id,omitempty"`
+	OldTTLDays int                   `json:"old_ttl_days"`
+	NewTTLDays int                   `json:"new_ttl_days"`
+	Status     RetentionChangeStatus `json:"status"`
+	Statement  string                `json:"statement,omitempty"` // ALTER TABLE statement run on ClickHouse
+	Error      string                `json:"error,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+}
+
+// SourceRetention compares a source's stored retention with the TTL of its ClickHouse table.
+type SourceRetention struct {
+	TTLDays      int    `json:"ttl_days"`                 // Retention stored for the source; 0 or -1 means no TTL
+	Table        string `json:"table"`                    // Table holding the TTL, the local table of a Distributed table
+	TableTTL     string `json:"table_ttl,omitempty"`      // TTL expression of the table
+	TableTTLDays *int   `json:"table_ttl_days,omitempty"` // Days of the table TTL, nil when absent or not a plain interval
+	Drift        bool   `json:"drift"`                    // The table TTL doesn't match the stored retention
 }
 
 // ValidateConnectionRequest represents a request to validate a connection
 type ValidateConnectionRequest struct {
 	ConnectionInfo
-	TimestampField string `json:"timestamp_field"`
-	SeverityField  string `json:"severity_field"`
+	TimestampField  string          `json:"timestamp_field"`
+	SeverityField   string          `json:"severity_field"`
+	SeverityMapping SeverityMapping `json:"severity_mapping"` // Allows a numeric severity field
+}
+
+// DiscoverTablesRequest lists the tables reachable with a connection. Database is optional:
+// without it the tables of every non-system database are listed.
+type DiscoverTablesRequest struct {
+	Host     string `json:"host"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Database string `json:"database"`
+}
+
+// DiscoveredTable is a ClickHouse table that can be registered as a source
+type DiscoveredTable struct {
+	Database    string       `json:"database"`
+	Table       string       `json:"table"`
+	Engine      string       `json:"engine"`
+	Rows        uint64       `json:"rows"`
+	BytesOnDisk uint64       `json:"bytes_on_disk"`
+	Size        string       `json:"size"`
+	Columns     []ColumnInfo `json:"columns"`
+	SortKeys    []string     `json:"sort_keys"`
+	// Suggested source fields, empty when no column qualifies
+	TimestampField string `json:"timestamp_field,omitempty"`
+	SeverityField  string `json:"severity_field,omitempty"`
+	// All the columns usable as timestamp or severity field, best first
+	TimestampCandidates []string `json:"timestamp_candidates"`
+	SeverityCandidates  []string `json:"severity_candidates"`
+	// Source already registered for the table, if any
+	SourceID *SourceID `json:"source_id,omitempty"`
+}
+
+// TableDiscovery lists the databases and tables of a ClickHouse server
+type TableDiscovery struct {
+	Databases []string           `json:"databases"`
+	Tables    []*DiscoveredTable `json:"tables"`
 }
 
 // SourceWithTeams represents a source along with the teams that have access to it
@@ -137,3 +354,29 @@ type TeamGroupedQuery struct {
 type ConnectionValidationResult struct {
 	Message string `json:"message"`
 }
+
+// ColumnPolicyAction defines how a column is masked for a team.
+type ColumnPolicyAction string
+
+const (
+	// ColumnPolicyHide removes the column from query results and schema.
+	ColumnPolicyHide ColumnPolicyAction = "hide"
+
+	// ColumnPolicyHash replaces column values with their SHA-256 hash.
+	ColumnPolicyHash ColumnPolicyAction = "hash"
+
+	// ColumnPolicyRedact replaces regex matches within column values.
+	ColumnPolicyRedact ColumnPolicyAction = "redact"
+)
+
+// ColumnPolicy restricts how a team sees a column of a source.
+type ColumnPolicy struct {
+	ID          int                `json:"id" db:"id"`
+	TeamID      TeamID             `json:"team_id" db:"team_id"`
+	SourceID    SourceID           `json:"source_id" db:"source_id"`
+	Column      string             `json:"column" db:"column_name"`
+	Action      ColumnPolicyAction `json:"action" db:"action"`
+	Pattern     string             `json:"pattern,omitempty" db:"pattern"`         // Regex matched for redact policies.
+	Replacement string             `json:"replacement,omitempty" db:"replacement"` // Replacement text for redact policies.
+	Timestamps
+}
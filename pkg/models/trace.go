@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// TraceLog is a log of a trace, with the fields of its source's role columns.
+type TraceLog struct {
+	SourceID     SourceID               `json:"source_id"`
+	SourceName   string                 `json:"source_name"`
+	Timestamp    time.Time              `json:"timestamp"`
+	SpanID       string                 `json:"span_id,omitempty"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Service      string                 `json:"service,omitempty"`
+	Severity     string                 `json:"severity,omitempty"` // Mapped level when the source has a severity mapping
+	Message      string                 `json:"message,omitempty"`
+	Log          map[string]interface{} `json:"log"`
+}
+
+// TraceSpan is a span of a trace, built from the logs emitted within it.
+type TraceSpan struct {
+	SpanID       string       `json:"span_id"`
+	ParentSpanID string       `json:"parent_span_id,omitempty"` // Set even when the parent has no logs
+	Service      string       `json:"service,omitempty"`
+	Start        time.Time    `json:"start"` // Timestamp of the span's first log
+	End          time.Time    `json:"end"`   // Timestamp of the span's last log
+	LogCount     int          `json:"log_count"`
+	Children     []*TraceSpan `json:"children"`
+}
+
+// TraceSourceResult is the outcome of searching a source for the logs of a trace. A failed
+// source carries its error without failing the rest of the search.
+type TraceSourceResult struct {
+	SourceID  SourceID `json:"source_id"`
+	Name      string   `json:"name"`
+	Logs      int      `json:"logs"`
+	Truncated bool     `json:"truncated,omitempty"` // More logs matched than were fetched
+	Error     string   `json:"error,omitempty"`
+}
+
+// TraceTimeline is every log of a trace across the sources of a team, in time order, with
+// the span tree built from them.
+type TraceTimeline struct {
+	TraceID string               `json:"trace_id"`
+	From    time.Time            `json:"from"` // Start of the searched time range
+	To      time.Time            `json:"to"`   // End of the searched time range
+	Logs    []*TraceLog          `json:"logs"`
+	Spans   []*TraceSpan         `json:"spans"` // Root spans
+	Sources []*TraceSourceResult `json:"sources"`
+	URL     string               `json:"url,omitempty"` // Link to the trace in an external tracing UI
+}